@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// QueueBacklog is how the size of a broker queue moved during the run.
+type QueueBacklog struct {
+	Queue string `json:"queue"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Peak  int    `json:"peak"`
+	// Growth is End minus Start; a queue that keeps growing is consumed
+	// slower than it is filled.
+	Growth int `json:"growth"`
+}
+
+// backlog samples the queues of the order service through
+// GET /admin/queues.
+type backlog struct {
+	client *client
+
+	mu     sync.Mutex
+	queues map[string]*QueueBacklog
+	order  []string
+}
+
+func newBacklog(c *client) *backlog {
+	return &backlog{client: c, queues: make(map[string]*QueueBacklog)}
+}
+
+// sample records the current size of every queue.
+func (b *backlog) sample(ctx context.Context) error {
+	resp, err := b.client.get(ctx, "/admin/queues")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("GET /admin/queues answered %d: %s", resp.StatusCode, body)
+	}
+
+	var list struct {
+		Queues []struct {
+			Name string `json:"name"`
+			Size int    `json:"size"`
+		} `json:"queues"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, q := range list.Queues {
+		qb, ok := b.queues[q.Name]
+		if !ok {
+			qb = &QueueBacklog{Queue: q.Name, Start: q.Size}
+			b.queues[q.Name] = qb
+			b.order = append(b.order, q.Name)
+		}
+		qb.End = q.Size
+		qb.Peak = max(qb.Peak, q.Size)
+		qb.Growth = qb.End - qb.Start
+	}
+	return nil
+}
+
+// run samples every interval until ctx is done. Failed samples are
+// skipped.
+func (b *backlog) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.sample(ctx)
+		}
+	}
+}
+
+// total is the size of every queue at the last sample.
+func (b *backlog) total() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := 0
+	for _, qb := range b.queues {
+		n += qb.End
+	}
+	return n
+}
+
+// results returns the queues in the order they were listed.
+func (b *backlog) results() []QueueBacklog {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]QueueBacklog, 0, len(b.order))
+	for _, name := range b.order {
+		out = append(out, *b.queues[name])
+	}
+	return out
+}
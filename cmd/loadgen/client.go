@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+)
+
+// client calls the Order Service HTTP API.
+type client struct {
+	baseURL string
+	token   string
+	timeout time.Duration
+	http    *http.Client
+}
+
+func newClient(cfg Config) *client {
+	// Every request in flight may need a connection of its own.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = cfg.Concurrency
+	return &client{
+		baseURL: strings.TrimRight(cfg.OrderURL, "/"),
+		token:   cfg.Token,
+		timeout: cfg.Timeout,
+		http:    &http.Client{Transport: &tenant.Transport{Base: &logging.Transport{Base: transport}}},
+	}
+}
+
+func (c *client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set(auth.AuthorizationHeader, "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+// createOrder posts body to /orders and returns the response status and
+// how long it took, the body read included.
+func (c *client) createOrder(ctx context.Context, body any) (int, time.Duration, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return 0, 0, err
+	}
+	ctx, cancel := context.WithTimeout(logging.WithRequestID(ctx, logging.NewRequestID()), c.timeout)
+	defer cancel()
+	req, err := c.newRequest(ctx, http.MethodPost, "/orders", bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	start := time.Now()
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, time.Since(start), err
+	}
+	_, err = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.StatusCode, time.Since(start), err
+}
+
+func (c *client) get(ctx context.Context, path string) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = cancelOnClose{resp.Body, cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases the context of a response with its body.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// errorKind names the failure of a request that got no response.
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection refused"
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return "connection reset"
+	default:
+		return "transport"
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Config holds the load to generate and where to send it.
+type Config struct {
+	OrderURL string `config:"order-url" usage:"Order Service base URL"`
+	Token    string `config:"token,secret" usage:"API key or JWT for the Order Service; the admin role also measures the broker backlog"`
+	Tenant   string `config:"tenant" usage:"Tenant of the orders, the default tenant when empty"`
+
+	Rate        float64       `config:"rate" usage:"Orders created per second"`
+	Duration    time.Duration `config:"duration" usage:"How long to send orders"`
+	Concurrency int           `config:"concurrency" usage:"Most requests in flight; orders due while all are busy are skipped and counted"`
+	Timeout     time.Duration `config:"timeout" usage:"Time allowed for each request"`
+
+	Products    []string `config:"products" usage:"Catalog as product_id:unit_price_cents entries, most popular first"`
+	MaxItems    int      `config:"max-items" usage:"Most distinct products in one order"`
+	DeclineRate float64  `config:"decline-rate" usage:"Fraction of orders whose total ends in 99 cents, which the simulated gateway declines"`
+	Seed        int64    `config:"seed" usage:"Seed of the order generator, 0 for a random one"`
+
+	ReportInterval  time.Duration `config:"report-interval" usage:"Interval of the progress lines, 0 for none"`
+	BacklogInterval time.Duration `config:"backlog-interval" usage:"Interval at which the broker queues are sampled, 0 to skip them"`
+	Output          string        `config:"output" usage:"Format of the final report: table or json"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		OrderURL:    "http://localhost:8080",
+		Rate:        50,
+		Duration:    30 * time.Second,
+		Concurrency: 100,
+		Timeout:     10 * time.Second,
+		Products: []string{
+			"mouse:4990", "keyboard:12900", "cable:1490", "headset:19900",
+			"charger:7990", "webcam:29900", "monitor:89900", "laptop:249900",
+		},
+		MaxItems:        5,
+		DeclineRate:     0.05,
+		ReportInterval:  5 * time.Second,
+		BacklogInterval: time.Second,
+		Output:          "table",
+	}
+}
+
+func (c Config) Validate() error {
+	switch {
+	case c.OrderURL == "":
+		return errors.New("order-url is required")
+	case c.Rate <= 0:
+		return errors.New("rate must be positive")
+	case c.Duration <= 0:
+		return errors.New("duration must be positive")
+	case c.Concurrency < 1:
+		return errors.New("concurrency must be at least 1")
+	case c.Timeout <= 0:
+		return errors.New("timeout must be positive")
+	case c.MaxItems < 1:
+		return errors.New("max-items must be at least 1")
+	case c.DeclineRate < 0 || c.DeclineRate > 1:
+		return fmt.Errorf("decline-rate must be between 0 and 1, got %g", c.DeclineRate)
+	case c.ReportInterval < 0 || c.BacklogInterval < 0:
+		return errors.New("report-interval and backlog-interval must not be negative")
+	case c.Output != "table" && c.Output != "json":
+		return fmt.Errorf("output must be table or json, got %q", c.Output)
+	}
+	if _, err := parseCatalog(c.Products); err != nil {
+		return fmt.Errorf("products: %w", err)
+	}
+	return nil
+}
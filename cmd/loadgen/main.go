@@ -0,0 +1,153 @@
+// Command loadgen creates orders at a steady rate against a running Order
+// Service and reports the latency percentiles and outcomes of the requests,
+// along with how the broker queues of the service grew meanwhile, so
+// changes to the payment path or the queues can be measured:
+//
+//	loadgen -rate 200 -duration 1m -token admin-key
+//
+// Requests are sent at the configured rate whatever the latency of the
+// service, up to -concurrency in flight. Settings come from flags,
+// LOADGEN_* environment variables or the YAML file named by -config.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/config"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+)
+
+func main() {
+	cfg := defaultConfig()
+	loader := config.Register(flag.CommandLine, "loadgen", &cfg)
+	flag.Parse()
+	if err := loader.Load(); err != nil {
+		fmt.Fprintln(os.Stderr, "loadgen:", err)
+		os.Exit(2)
+	}
+	catalog, _ := parseCatalog(cfg.Products)
+
+	// An interrupt ends the run early; the report covers what was sent.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if cfg.Tenant != "" {
+		ctx = tenant.NewContext(ctx, cfg.Tenant)
+	}
+
+	c := newClient(cfg)
+	var queues *backlog
+	if cfg.BacklogInterval > 0 {
+		queues = newBacklog(c)
+		if err := queues.sample(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "loadgen: broker backlog not measured: %v\n", err)
+			queues = nil
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "loadgen: %.1f orders/s for %s against %s\n", cfg.Rate, cfg.Duration, cfg.OrderURL)
+	rec := newRecorder()
+	elapsed := run(ctx, cfg, c, newGenerator(cfg, catalog), rec, queues)
+	if queues != nil {
+		queues.sample(context.WithoutCancel(ctx))
+	}
+
+	report := newReport(cfg, elapsed, rec.snapshot(), queues)
+	var err error
+	if cfg.Output == "json" {
+		err = report.writeJSON(os.Stdout)
+	} else {
+		err = report.writeTable(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "loadgen:", err)
+		os.Exit(1)
+	}
+}
+
+// run sends orders for the configured duration and waits for the requests
+// in flight. It returns how long orders were sent for.
+func run(ctx context.Context, cfg Config, c *client, gen *generator, rec *recorder, queues *backlog) time.Duration {
+	runCtx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	start := time.Now()
+	if queues != nil {
+		go queues.run(runCtx, cfg.BacklogInterval)
+	}
+	if cfg.ReportInterval > 0 {
+		go progress(runCtx, cfg.ReportInterval, start, rec, queues)
+	}
+
+	// Orders are due at fixed times from the start. Each tick sends those
+	// that came due since the last one, so a late tick does not lower the
+	// rate.
+	tick := max(time.Duration(float64(time.Second)/cfg.Rate), time.Millisecond)
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	inFlight := make(chan struct{}, cfg.Concurrency)
+	var wg sync.WaitGroup
+	issued := 0
+	for {
+		select {
+		case <-runCtx.Done():
+			elapsed := time.Since(start)
+			wg.Wait()
+			return elapsed
+		case <-ticker.C:
+		}
+
+		due := int(time.Since(start).Seconds() * cfg.Rate)
+		for ; issued < due; issued++ {
+			select {
+			case inFlight <- struct{}{}:
+			default:
+				rec.skip()
+				continue
+			}
+			body := gen.next()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-inFlight }()
+				status, d, err := c.createOrder(ctx, body)
+				if err != nil && ctx.Err() != nil {
+					return // interrupted, not failed
+				}
+				rec.record(status, err, d)
+			}()
+		}
+	}
+}
+
+// progress writes a line of the counts so far every interval.
+func progress(ctx context.Context, interval time.Duration, start time.Time, rec *recorder, queues *backlog) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		s := rec.snapshot()
+		line := fmt.Sprintf("%6s  sent %d", time.Since(start).Round(time.Second), s.sent())
+		for _, o := range outcomes {
+			if n := len(s.latencies[o]); n > 0 {
+				line += fmt.Sprintf("  %s %d", o, n)
+			}
+		}
+		line += fmt.Sprintf("  skipped %d  p99 %s", s.skipped, formatLatency(summarize(s.all()).P99))
+		if queues != nil {
+			line += fmt.Sprintf("  backlog %d", queues.total())
+		}
+		fmt.Fprintln(os.Stderr, line)
+	}
+}
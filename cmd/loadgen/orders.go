@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+)
+
+// product is an entry of the catalog orders are drawn from.
+type product struct {
+	id         string
+	priceCents int64
+}
+
+// parseCatalog parses product_id:unit_price_cents entries.
+func parseCatalog(entries []string) ([]product, error) {
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("at least one product is required")
+	}
+	catalog := make([]product, 0, len(entries))
+	for _, e := range entries {
+		id, price, ok := strings.Cut(e, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("%q is not product_id:unit_price_cents", e)
+		}
+		cents, err := strconv.ParseInt(price, 10, 64)
+		if err != nil || cents <= 0 {
+			return nil, fmt.Errorf("%q: price must be a positive number of cents", e)
+		}
+		catalog = append(catalog, product{id: id, priceCents: cents})
+	}
+	return catalog, nil
+}
+
+// generator draws orders that look like real traffic: most orders hold a
+// single product and one unit of it, and products are picked by a Zipf
+// distribution over the catalog, so the first ones sell the most.
+type generator struct {
+	catalog     []product
+	maxItems    int
+	declineRate float64
+	rng         *rand.Rand
+	zipf        *rand.Zipf
+}
+
+func newGenerator(cfg Config, catalog []product) *generator {
+	seed := uint64(cfg.Seed)
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	rng := rand.New(rand.NewPCG(seed, seed>>1|1))
+	return &generator{
+		catalog:     catalog,
+		maxItems:    cfg.MaxItems,
+		declineRate: cfg.DeclineRate,
+		rng:         rng,
+		zipf:        rand.NewZipf(rng, 1.2, 1, uint64(len(catalog)-1)),
+	}
+}
+
+// geometric returns 1 plus the number of failures before a success of
+// probability p, capped at limit.
+func (g *generator) geometric(p float64, limit int) int {
+	n := 1
+	for n < limit && g.rng.Float64() >= p {
+		n++
+	}
+	return n
+}
+
+// next returns the body of a POST /orders request. The generator is not
+// safe for concurrent use.
+func (g *generator) next() map[string]any {
+	count := g.geometric(0.55, min(g.maxItems, len(g.catalog)))
+	picked := make(map[int]bool, count)
+	items := make([]order.OrderItem, 0, count)
+	var total int64
+	for len(items) < count {
+		i := int(g.zipf.Uint64())
+		if picked[i] {
+			continue
+		}
+		picked[i] = true
+		p := g.catalog[i]
+		item := order.OrderItem{
+			ProductID:      p.id,
+			ProductName:    p.id,
+			Quantity:       int32(g.geometric(0.8, 5)),
+			UnitPriceCents: p.priceCents,
+		}
+		total += int64(item.Quantity) * item.UnitPriceCents
+		items = append(items, item)
+	}
+
+	// The simulated gateway declines totals ending in 99 cents. The price
+	// of a single unit of the first item moves the total there, or off it
+	// for the orders meant to be paid.
+	first := &items[0]
+	switch {
+	case g.rng.Float64() < g.declineRate:
+		total -= int64(first.Quantity-1) * first.UnitPriceCents
+		first.Quantity = 1
+		first.UnitPriceCents += (99 - total%100 + 100) % 100
+	case total%100 == 99:
+		first.UnitPriceCents++
+	}
+
+	return map[string]any{
+		"customer_email": fmt.Sprintf("load+%03d@example.com", g.rng.IntN(1000)),
+		"items":          items,
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Report is the result of a run, as written by -output json. Durations
+// are in nanoseconds.
+type Report struct {
+	Target     string              `json:"target"`
+	Rate       float64             `json:"rate"`
+	Duration   time.Duration       `json:"duration_ns"`
+	Sent       int                 `json:"sent"`
+	Skipped    int                 `json:"skipped"`
+	Throughput float64             `json:"throughput"`
+	Outcomes   map[outcome]int     `json:"outcomes"`
+	Statuses   map[int]int         `json:"statuses"`
+	Errors     map[string]int      `json:"errors,omitempty"`
+	Latency    Latency             `json:"latency"`
+	ByOutcome  map[outcome]Latency `json:"latency_by_outcome"`
+	Backlog    []QueueBacklog      `json:"backlog,omitempty"`
+}
+
+func newReport(cfg Config, elapsed time.Duration, s snapshot, queues *backlog) *Report {
+	r := &Report{
+		Target:    cfg.OrderURL + "/orders",
+		Rate:      cfg.Rate,
+		Duration:  elapsed,
+		Sent:      s.sent(),
+		Skipped:   s.skipped,
+		Outcomes:  make(map[outcome]int),
+		Statuses:  s.statuses,
+		Errors:    s.errors,
+		Latency:   summarize(s.all()),
+		ByOutcome: make(map[outcome]Latency),
+	}
+	if elapsed > 0 {
+		r.Throughput = float64(r.Sent) / elapsed.Seconds()
+	}
+	for o, l := range s.latencies {
+		r.Outcomes[o] = len(l)
+		r.ByOutcome[o] = summarize(l)
+	}
+	if queues != nil {
+		r.Backlog = queues.results()
+	}
+	return r
+}
+
+func (r *Report) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func (r *Report) writeTable(w io.Writer) error {
+	fmt.Fprintf(w, "Target      %s\n", r.Target)
+	fmt.Fprintf(w, "Rate        %.1f/s for %s, %d sent, %d skipped\n", r.Rate, r.Duration.Round(time.Millisecond), r.Sent, r.Skipped)
+	fmt.Fprintf(w, "Throughput  %.1f/s\n\n", r.Throughput)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OUTCOME\tCOUNT\tSHARE\tP50\tP90\tP95\tP99\tMAX")
+	row := func(name string, l Latency) {
+		share := 0.0
+		if r.Sent > 0 {
+			share = 100 * float64(l.Count) / float64(r.Sent)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%.1f%%\t%s\t%s\t%s\t%s\t%s\n", name, l.Count, share,
+			formatLatency(l.P50), formatLatency(l.P90), formatLatency(l.P95), formatLatency(l.P99), formatLatency(l.Max))
+	}
+	for _, o := range outcomes {
+		if l, ok := r.ByOutcome[o]; ok {
+			row(string(o), l)
+		}
+	}
+	row("all", r.Latency)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nStatus codes  %s\n", counts(r.Statuses))
+	if len(r.Errors) > 0 {
+		fmt.Fprintf(w, "Errors        %s\n", counts(r.Errors))
+	}
+
+	if len(r.Backlog) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "QUEUE\tSTART\tEND\tPEAK\tGROWTH")
+	for _, q := range r.Backlog {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%+d\n", q.Queue, q.Start, q.End, q.Peak, q.Growth)
+	}
+	return tw.Flush()
+}
+
+// counts formats a tally as "201×950 402×50", sorted by key.
+func counts[K int | string](m map[K]int) string {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%v×%d", k, m[k])
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, "  ")
+}
+
+// formatLatency rounds d for display.
+func formatLatency(d time.Duration) string {
+	switch {
+	case d == 0:
+		return "-"
+	case d < time.Millisecond:
+		return d.Round(time.Microsecond).String()
+	case d < time.Second:
+		return d.Round(100 * time.Microsecond).String()
+	default:
+		return d.Round(time.Millisecond).String()
+	}
+}
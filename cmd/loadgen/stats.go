@@ -0,0 +1,167 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// outcome classifies the answer to an order creation.
+type outcome string
+
+const (
+	outcomeCreated   outcome = "created"   // 201, the order is paid
+	outcomeAccepted  outcome = "accepted"  // 202, queued by asynchronous creation
+	outcomeDeclined  outcome = "declined"  // 402, the payment was declined
+	outcomeRejected  outcome = "rejected"  // other 4xx, such as invalid orders and no stock
+	outcomeThrottled outcome = "throttled" // 429
+	outcomeError     outcome = "error"     // 5xx, timeouts and transport failures
+)
+
+var outcomes = []outcome{outcomeCreated, outcomeAccepted, outcomeDeclined, outcomeRejected, outcomeThrottled, outcomeError}
+
+// classify maps a response status, 0 for none, to its outcome.
+func classify(status int) outcome {
+	switch {
+	case status == 201:
+		return outcomeCreated
+	case status == 202:
+		return outcomeAccepted
+	case status == 402:
+		return outcomeDeclined
+	case status == 429:
+		return outcomeThrottled
+	case status >= 400 && status < 500:
+		return outcomeRejected
+	default:
+		return outcomeError
+	}
+}
+
+// recorder collects the latency and outcome of every request.
+type recorder struct {
+	mu        sync.Mutex
+	latencies map[outcome][]time.Duration
+	statuses  map[int]int
+	errors    map[string]int
+	skipped   int
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		latencies: make(map[outcome][]time.Duration),
+		statuses:  make(map[int]int),
+		errors:    make(map[string]int),
+	}
+}
+
+// record adds a request answered with status, or failed with err.
+func (r *recorder) record(status int, err error, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	o := classify(status)
+	r.latencies[o] = append(r.latencies[o], d)
+	if err != nil {
+		r.errors[errorKind(err)]++
+	} else {
+		r.statuses[status]++
+	}
+}
+
+// skip counts an order that was due while every request was in flight.
+func (r *recorder) skip() {
+	r.mu.Lock()
+	r.skipped++
+	r.mu.Unlock()
+}
+
+// snapshot copies what was recorded so far.
+func (r *recorder) snapshot() snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := snapshot{
+		latencies: make(map[outcome][]time.Duration, len(r.latencies)),
+		statuses:  make(map[int]int, len(r.statuses)),
+		errors:    make(map[string]int, len(r.errors)),
+		skipped:   r.skipped,
+	}
+	for o, l := range r.latencies {
+		s.latencies[o] = slices.Clone(l)
+	}
+	for k, v := range r.statuses {
+		s.statuses[k] = v
+	}
+	for k, v := range r.errors {
+		s.errors[k] = v
+	}
+	return s
+}
+
+type snapshot struct {
+	latencies map[outcome][]time.Duration
+	statuses  map[int]int
+	errors    map[string]int
+	skipped   int
+}
+
+// sent is the number of requests answered or failed.
+func (s snapshot) sent() int {
+	n := 0
+	for _, l := range s.latencies {
+		n += len(l)
+	}
+	return n
+}
+
+// all returns every latency, sorted.
+func (s snapshot) all() []time.Duration {
+	var all []time.Duration
+	for _, l := range s.latencies {
+		all = append(all, l...)
+	}
+	slices.Sort(all)
+	return all
+}
+
+// Latency summarizes a set of latencies.
+type Latency struct {
+	Count int           `json:"count"`
+	Min   time.Duration `json:"min_ns"`
+	Mean  time.Duration `json:"mean_ns"`
+	P50   time.Duration `json:"p50_ns"`
+	P90   time.Duration `json:"p90_ns"`
+	P95   time.Duration `json:"p95_ns"`
+	P99   time.Duration `json:"p99_ns"`
+	Max   time.Duration `json:"max_ns"`
+}
+
+// summarize computes the percentiles of latencies, which it sorts.
+func summarize(latencies []time.Duration) Latency {
+	if len(latencies) == 0 {
+		return Latency{}
+	}
+	slices.Sort(latencies)
+	var sum time.Duration
+	for _, d := range latencies {
+		sum += d
+	}
+	return Latency{
+		Count: len(latencies),
+		Min:   latencies[0],
+		Mean:  sum / time.Duration(len(latencies)),
+		P50:   percentile(latencies, 50),
+		P90:   percentile(latencies, 90),
+		P95:   percentile(latencies, 95),
+		P99:   percentile(latencies, 99),
+		Max:   latencies[len(latencies)-1],
+	}
+}
+
+// percentile returns the nearest-rank percentile p of sorted.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
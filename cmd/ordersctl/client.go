@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+)
+
+// orderClient calls the Order Service HTTP API.
+type orderClient struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func newOrderClient(cfg Config) *orderClient {
+	return &orderClient{
+		baseURL: strings.TrimRight(cfg.OrderURL, "/"),
+		token:   cfg.Token,
+		http:    &http.Client{Transport: &tenant.Transport{Base: &logging.Transport{}}},
+	}
+}
+
+// apiError is a response other than 200, 201 or 202.
+type apiError struct {
+	code    int
+	message string
+	body    []byte
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.code, http.StatusText(e.code), e.message)
+}
+
+// do sends body, when not nil, as JSON to path with the query and returns
+// the raw response body.
+func (c *orderClient) do(ctx context.Context, method, path string, query url.Values, body any) ([]byte, error) {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(data)
+	}
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set(auth.AuthorizationHeader, "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
+		return data, nil
+	}
+	return nil, &apiError{code: resp.StatusCode, message: errorMessage(data), body: data}
+}
+
+// errorMessage extracts the "error" field of a JSON error response, with
+// the invalid fields of a validation error.
+func errorMessage(data []byte) string {
+	var e struct {
+		Error  string `json:"error"`
+		Fields []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"fields"`
+	}
+	if json.Unmarshal(data, &e) != nil || e.Error == "" {
+		return strings.TrimSpace(string(data))
+	}
+	msg := e.Error
+	for _, f := range e.Fields {
+		msg += "; " + f.Field + " " + f.Message
+	}
+	return msg
+}
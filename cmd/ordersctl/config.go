@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/config"
+)
+
+// Config holds the connection settings of ordersctl.
+type Config struct {
+	OrderURL string `config:"order-url" usage:"Order Service base URL"`
+	Token    string `config:"token,secret" usage:"API key or JWT for the Order Service; queue commands need the admin role"`
+	Tenant   string `config:"tenant" usage:"Tenant of the requests, the default tenant when empty"`
+
+	Payment PaymentConfig `config:"payment"`
+
+	Timeout time.Duration `config:"timeout" usage:"Time allowed for each request"`
+	Output  string        `config:"output" usage:"Output format: table or json"`
+}
+
+// PaymentConfig is the connection to the payment service gRPC API.
+type PaymentConfig struct {
+	Addr  string           `config:"addr" usage:"Payment service gRPC address"`
+	Token string           `config:"token,secret" usage:"API key or JWT sent to the payment service"`
+	TLS   config.ClientTLS `config:"tls"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		OrderURL: "http://localhost:8080",
+		Payment:  PaymentConfig{Addr: "localhost:50051"},
+		Timeout:  10 * time.Second,
+		Output:   "table",
+	}
+}
+
+func (c Config) Validate() error {
+	switch {
+	case c.OrderURL == "":
+		return errors.New("order-url is required")
+	case c.Payment.Addr == "":
+		return errors.New("payment-addr is required")
+	case c.Timeout <= 0:
+		return errors.New("timeout must be positive")
+	}
+	if c.Output != "table" && c.Output != "json" {
+		return fmt.Errorf("output must be table or json, got %q", c.Output)
+	}
+	return nil
+}
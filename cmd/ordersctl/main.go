@@ -0,0 +1,156 @@
+// Command ordersctl drives a running system from the command line: it
+// creates and looks up orders, queries payments and inspects and redrives
+// the broker queues of the order service.
+//
+//	ordersctl [flags] <command> <subcommand> [args]
+//
+// Connection settings come from flags, ORDERSCTL_* environment variables
+// or the YAML file named by -config, such as ORDERSCTL_ORDER_URL and
+// ORDERSCTL_TOKEN.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/config"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+)
+
+const usage = `Usage: ordersctl [flags] <command> <subcommand> [args]
+
+Commands:
+  orders create -email EMAIL -item NAME:QTY:PRICE_CENTS ...   Create an order
+  orders create -file order.json                               Create an order from a JSON body ("-" for stdin)
+  orders list [-status S] [-customer ID] [-limit N] [-cursor C] List orders, newest first
+  orders get ID                                                Show an order
+  payments status TRANSACTION_ID                               Show a payment and its transitions
+  queues list                                                  Show the broker queues of the order service
+  dlq list QUEUE [-limit N]                                    Show the dead-lettered messages of a queue
+  dlq redrive QUEUE [-limit N]                                 Requeue dead-lettered messages
+
+Run "ordersctl <command> <subcommand> -h" for the flags of a command.
+
+Flags:
+`
+
+// errUsage reports a command line that names no known command; the usage
+// has already been printed.
+var errUsage = errors.New("invalid usage")
+
+// command runs a subcommand with its arguments.
+type command func(ctx context.Context, app *app, args []string) error
+
+var commands = map[string]map[string]command{
+	"orders": {
+		"create": createOrder,
+		"list":   listOrders,
+		"get":    getOrder,
+	},
+	"payments": {
+		"status": paymentStatus,
+	},
+	"queues": {
+		"list": listQueues,
+	},
+	"dlq": {
+		"list":    listDeadLetters,
+		"redrive": redriveDeadLetters,
+	},
+}
+
+// app holds what the commands share.
+type app struct {
+	cfg    Config
+	orders *orderClient
+	out    io.Writer
+}
+
+func main() {
+	cfg := defaultConfig()
+	loader := config.Register(flag.CommandLine, "ordersctl", &cfg)
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if err := loader.Load(); err != nil {
+		fmt.Fprintln(os.Stderr, "ordersctl:", err)
+		os.Exit(2)
+	}
+
+	args := flag.Args()
+	if len(args) < 2 || commands[args[0]][args[1]] == nil {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// One request ID for every call of the command finds them in the logs
+	// of the services.
+	requestID := logging.NewRequestID()
+	ctx := logging.WithRequestID(context.Background(), requestID)
+	if cfg.Tenant != "" {
+		ctx = tenant.NewContext(ctx, cfg.Tenant)
+	}
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	a := &app{cfg: cfg, orders: newOrderClient(cfg), out: os.Stdout}
+	if err := commands[args[0]][args[1]](ctx, a, args[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "ordersctl: %v (request ID %s)\n", err, requestID)
+		}
+		os.Exit(exitCode(err))
+	}
+}
+
+// exitCode is 2 for usage errors and 1 for failed requests.
+func exitCode(err error) int {
+	if errors.Is(err, errUsage) {
+		return 2
+	}
+	return 1
+}
+
+// subcommand creates the flag set of a subcommand, named for its usage
+// line.
+func subcommand(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: ordersctl %s %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses the flags of fs, which may come before or after the
+// positional arguments, and checks that there are want of those.
+func parse(fs *flag.FlagSet, args []string, want int) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, errUsage
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(positional) != want {
+		fs.Usage()
+		return nil, errUsage
+	}
+	return positional, nil
+}
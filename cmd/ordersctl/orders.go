@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+)
+
+// itemFlags collects the -item flags of orders create.
+type itemFlags []order.OrderItem
+
+func (f *itemFlags) String() string {
+	return fmt.Sprint(len(*f), " items")
+}
+
+// Set parses NAME:QTY:PRICE_CENTS. The name may itself hold colons.
+func (f *itemFlags) Set(s string) error {
+	parts := strings.Split(s, ":")
+	if len(parts) < 3 {
+		return fmt.Errorf("item %q is not NAME:QTY:PRICE_CENTS", s)
+	}
+	n := len(parts)
+	qty, err := strconv.ParseInt(parts[n-2], 10, 32)
+	if err != nil {
+		return fmt.Errorf("item %q: invalid quantity", s)
+	}
+	price, err := strconv.ParseInt(parts[n-1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("item %q: invalid price in cents", s)
+	}
+	*f = append(*f, order.OrderItem{
+		ProductName:    strings.Join(parts[:n-2], ":"),
+		Quantity:       int32(qty),
+		UnitPriceCents: price,
+	})
+	return nil
+}
+
+// createOrder calls POST /orders with a body built from flags or read from
+// a file.
+func createOrder(ctx context.Context, a *app, args []string) error {
+	fs := subcommand("orders create", "-email EMAIL -item NAME:QTY:PRICE_CENTS ... | -file FILE")
+	email := fs.String("email", "", "Customer email")
+	customer := fs.String("customer", "", "Customer ID")
+	currency := fs.String("currency", "", "Currency code, the service default when empty")
+	product := fs.String("product", "", "Product ID of every item, for stock reservations")
+	coupons := fs.String("coupons", "", "Comma separated coupon codes")
+	file := fs.String("file", "", `JSON body as POST /orders takes it, "-" for stdin`)
+	var items itemFlags
+	fs.Var(&items, "item", "Item as NAME:QTY:PRICE_CENTS; repeat for more items")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	var body any
+	switch {
+	case *file != "" && len(items) > 0:
+		return fmt.Errorf("-file and -item are exclusive")
+	case *file != "":
+		raw, err := readBody(*file)
+		if err != nil {
+			return err
+		}
+		body = raw
+	case len(items) == 0:
+		fs.Usage()
+		return errUsage
+	default:
+		for i := range items {
+			items[i].ProductID = *product
+		}
+		req := map[string]any{
+			"customer_id":    *customer,
+			"customer_email": *email,
+			"items":          items,
+			"currency":       *currency,
+		}
+		if *coupons != "" {
+			req["coupon_codes"] = strings.Split(*coupons, ",")
+		}
+		body = req
+	}
+
+	data, err := a.orders.do(ctx, http.MethodPost, "/orders", nil, body)
+	if err != nil {
+		return err
+	}
+	return a.printOrder(data)
+}
+
+// readBody reads a JSON document from path, or stdin for "-".
+func readBody(path string) (json.RawMessage, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("%s is not valid JSON", path)
+	}
+	return data, nil
+}
+
+// listOrders calls GET /orders, newest first.
+func listOrders(ctx context.Context, a *app, args []string) error {
+	fs := subcommand("orders list", "[-status S] [-customer ID] [-limit N] [-cursor C]")
+	status := fs.String("status", "", "Only orders in this status, such as paid")
+	customer := fs.String("customer", "", "Only orders of this customer")
+	limit := fs.Int("limit", 20, "Orders to list")
+	cursor := fs.String("cursor", "", "Cursor of the next page, from a previous listing")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	q := url.Values{"order": {"desc"}, "limit": {strconv.Itoa(*limit)}}
+	for name, v := range map[string]string{"status": *status, "customer_id": *customer, "cursor": *cursor} {
+		if v != "" {
+			q.Set(name, v)
+		}
+	}
+	data, err := a.orders.do(ctx, http.MethodGet, "/orders", q, nil)
+	if err != nil {
+		return err
+	}
+	if a.cfg.Output == "json" {
+		return writeJSON(a.out, data)
+	}
+
+	var page struct {
+		Orders     []*order.Order `json:"orders"`
+		NextCursor string         `json:"next_cursor"`
+	}
+	if err := json.Unmarshal(data, &page); err != nil {
+		return err
+	}
+	t := newTable(a.out, "ID", "STATUS", "TOTAL", "CUSTOMER", "CREATED")
+	for _, o := range page.Orders {
+		t.row(o.ID, o.Status, money(o.TotalCents, o.Currency), orDash(o.CustomerEmail), timestamp(o.CreatedAt))
+	}
+	if err := t.flush(); err != nil {
+		return err
+	}
+	if page.NextCursor != "" {
+		fmt.Fprintf(a.out, "\nMore orders: -cursor %s\n", page.NextCursor)
+	}
+	return nil
+}
+
+// getOrder calls GET /orders/{id}.
+func getOrder(ctx context.Context, a *app, args []string) error {
+	fs := subcommand("orders get", "ID")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	data, err := a.orders.do(ctx, http.MethodGet, "/orders/"+url.PathEscape(pos[0]), nil, nil)
+	if err != nil {
+		return err
+	}
+	return a.printOrder(data)
+}
+
+// printOrder writes an order response with its items.
+func (a *app) printOrder(data []byte) error {
+	if a.cfg.Output == "json" {
+		return writeJSON(a.out, data)
+	}
+
+	var o order.Order
+	if err := json.Unmarshal(data, &o); err != nil {
+		return err
+	}
+	t := newTable(a.out, "ID", o.ID)
+	t.row("Status", o.Status)
+	customer := o.CustomerEmail
+	if o.CustomerID != "" {
+		customer = o.CustomerID + " <" + o.CustomerEmail + ">"
+	}
+	t.row("Customer", orDash(customer))
+	t.row("Total", money(o.TotalCents, o.Currency))
+	t.row("Transaction", orDash(o.PaymentTransactionID))
+	t.row("Tenant", o.TenantID)
+	t.row("Created", timestamp(o.CreatedAt))
+	t.row("Updated", timestamp(o.UpdatedAt))
+	if err := t.flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(a.out)
+	t = newTable(a.out, "PRODUCT", "NAME", "QTY", "UNIT PRICE")
+	for _, item := range o.Items {
+		t.row(orDash(item.ProductID), orDash(item.ProductName), item.Quantity, money(item.UnitPriceCents, o.Currency))
+	}
+	return t.flush()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	_ "github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/codec"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tlsutil"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// dialPayment connects to the payment service with the credentials,
+// tenant and request ID of each call.
+func dialPayment(cfg PaymentConfig) (*grpc.ClientConn, error) {
+	creds, err := tlsutil.ClientCredentials(cfg.TLS.Config())
+	if err != nil {
+		return nil, fmt.Errorf("payment TLS: %w", err)
+	}
+	return grpc.NewClient(cfg.Addr,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype("json")),
+		grpc.WithChainUnaryInterceptor(
+			grpcmw.UnaryClientRequestIDInterceptor(),
+			tenant.UnaryClientInterceptor(),
+			auth.UnaryClientInterceptor(cfg.Token),
+		),
+	)
+}
+
+// paymentStatus calls GetPaymentStatus.
+func paymentStatus(ctx context.Context, a *app, args []string) error {
+	fs := subcommand("payments status", "TRANSACTION_ID")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	conn, err := dialPayment(a.cfg.Payment)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	resp, err := payment.NewPaymentServiceClient(conn).GetPaymentStatus(ctx, &payment.PaymentStatusRequest{TransactionID: pos[0]})
+	if err != nil {
+		return rpcError(err)
+	}
+	if a.cfg.Output == "json" {
+		data, err := json.Marshal(resp)
+		if err != nil {
+			return err
+		}
+		return writeJSON(a.out, data)
+	}
+
+	t := newTable(a.out, "Transaction", resp.TransactionID)
+	t.row("Order", resp.OrderID)
+	t.row("Status", resp.Status)
+	t.row("Amount", money(resp.AmountCents, resp.Currency))
+	t.row("Gateway", orDash(resp.Gateway))
+	t.row("Tenant", orDash(resp.TenantID))
+	t.row("Created", timestamp(resp.CreatedAt))
+	t.row("Updated", timestamp(resp.UpdatedAt))
+	if err := t.flush(); err != nil {
+		return err
+	}
+	if len(resp.Transitions) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(a.out)
+	t = newTable(a.out, "AT", "FROM", "TO", "REASON")
+	for _, tr := range resp.Transitions {
+		t.row(timestamp(tr.At), tr.From, tr.To, orDash(tr.Reason))
+	}
+	return t.flush()
+}
+
+// rpcError spells a gRPC error with its code name and reason, such as
+// "NOT_FOUND (TRANSACTION_NOT_FOUND): transaction not found".
+func rpcError(err error) error {
+	st := status.Convert(err)
+	code := grpcmw.CodeName(st.Code())
+	if info, ok := grpcmw.Reason(err); ok {
+		code += " (" + info.Reason + ")"
+	}
+	return fmt.Errorf("%s: %s", code, st.Message())
+}
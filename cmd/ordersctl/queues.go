@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// listQueues calls GET /admin/queues.
+func listQueues(ctx context.Context, a *app, args []string) error {
+	fs := subcommand("queues list", "")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	data, err := a.orders.do(ctx, http.MethodGet, "/admin/queues", nil, nil)
+	if err != nil {
+		return err
+	}
+	if a.cfg.Output == "json" {
+		return writeJSON(a.out, data)
+	}
+
+	var resp struct {
+		Queues []struct {
+			Name      string `json:"name"`
+			Size      int    `json:"size"`
+			Received  int64  `json:"received"`
+			Processed int64  `json:"processed"`
+			Failed    int64  `json:"failed"`
+			DLQ       string `json:"dlq"`
+			DLQSize   int    `json:"dlq_size"`
+		} `json:"queues"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return err
+	}
+	t := newTable(a.out, "QUEUE", "SIZE", "RECEIVED", "PROCESSED", "FAILED", "DLQ", "DEAD")
+	for _, q := range resp.Queues {
+		dead := "-"
+		if q.DLQ != "" {
+			dead = strconv.Itoa(q.DLQSize)
+		}
+		t.row(q.Name, q.Size, q.Received, q.Processed, q.Failed, orDash(q.DLQ), dead)
+	}
+	return t.flush()
+}
+
+// listDeadLetters calls GET /admin/dlq/{queue}.
+func listDeadLetters(ctx context.Context, a *app, args []string) error {
+	fs := subcommand("dlq list", "QUEUE [-limit N]")
+	limit := fs.Int("limit", 100, "Messages to list, oldest first; 0 for all")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	q := url.Values{"limit": {strconv.Itoa(*limit)}}
+	data, err := a.orders.do(ctx, http.MethodGet, "/admin/dlq/"+url.PathEscape(pos[0]), q, nil)
+	if err != nil {
+		return err
+	}
+	if a.cfg.Output == "json" {
+		return writeJSON(a.out, data)
+	}
+
+	var resp struct {
+		DLQ      string `json:"dlq"`
+		Total    int    `json:"total"`
+		Messages []struct {
+			ID            string    `json:"id"`
+			Type          string    `json:"type"`
+			Timestamp     time.Time `json:"timestamp"`
+			FailureReason string    `json:"failure_reason"`
+			LastError     string    `json:"last_error"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return err
+	}
+	t := newTable(a.out, "ID", "TYPE", "PUBLISHED", "REASON", "LAST ERROR")
+	for _, m := range resp.Messages {
+		t.row(m.ID, m.Type, timestamp(m.Timestamp), orDash(m.FailureReason), orDash(m.LastError))
+	}
+	if err := t.flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "\n%d of %d messages in %s\n", len(resp.Messages), resp.Total, resp.DLQ)
+	return nil
+}
+
+// redriveDeadLetters calls POST /admin/dlq/{queue}/redrive.
+func redriveDeadLetters(ctx context.Context, a *app, args []string) error {
+	fs := subcommand("dlq redrive", "QUEUE [-limit N]")
+	limit := fs.Int("limit", 0, "Messages to requeue, oldest first; 0 for all")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	q := url.Values{"limit": {strconv.Itoa(*limit)}}
+	data, err := a.orders.do(ctx, http.MethodPost, "/admin/dlq/"+url.PathEscape(pos[0])+"/redrive", q, nil)
+	if err != nil {
+		return err
+	}
+	if a.cfg.Output == "json" {
+		return writeJSON(a.out, data)
+	}
+
+	var resp struct {
+		Queue     string `json:"queue"`
+		DLQ       string `json:"dlq"`
+		Redriven  int    `json:"redriven"`
+		Remaining int    `json:"remaining"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "Requeued %d messages from %s to %s; %d remain\n", resp.Redriven, resp.DLQ, resp.Queue, resp.Remaining)
+	return nil
+}
@@ -0,0 +1,58 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/events"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+)
+
+// orderOf returns a create order request for one item at unitPriceCents.
+// Amounts ending in 99 cents are declined by the simulated gateway.
+func orderOf(unitPriceCents int) map[string]interface{} {
+	return map[string]interface{}{
+		"customer_email": "ana@example.com",
+		"items":          []map[string]interface{}{{"product_name": "Mug", "quantity": 1, "unit_price_cents": unitPriceCents}},
+	}
+}
+
+func TestDeclinedPaymentCancelsOrder(t *testing.T) {
+	h := New(t)
+
+	resp := h.Order.Do(t, "POST", "/orders", orderOf(1999))
+	if resp.StatusCode != http.StatusPaymentRequired {
+		t.Fatalf("status %d: %s", resp.StatusCode, resp.Body)
+	}
+	var declined struct {
+		Code      string `json:"code"`
+		Retriable bool   `json:"retriable"`
+	}
+	resp.Decode(t, &declined)
+	if declined.Code != "INVALID_CARD" || declined.Retriable {
+		t.Fatalf("declined with code %q retriable %t, want INVALID_CARD not retriable", declined.Code, declined.Retriable)
+	}
+	h.Settle(t)
+
+	var changed order.OrderStatusChangedEvent
+	h.Events.AssertPublished(t, "order.status_changed").Decode(&changed)
+	if changed.To != order.OrderStatus_ORDER_STATUS_CANCELLED {
+		t.Fatalf("order %s went from %s to %s, want CANCELLED", changed.OrderID, changed.From, changed.To)
+	}
+
+	var stored struct {
+		Status order.OrderStatus `json:"status"`
+	}
+	got := h.Order.Do(t, "GET", "/orders/"+changed.OrderID, nil)
+	if got.StatusCode != http.StatusOK {
+		t.Fatalf("GET order: status %d: %s", got.StatusCode, got.Body)
+	}
+	got.Decode(t, &stored)
+	if stored.Status != order.OrderStatus_ORDER_STATUS_CANCELLED {
+		t.Fatalf("stored order is %s, want CANCELLED", stored.Status)
+	}
+
+	// The notification service only reacts to order.created, so without
+	// it the customer is sent nothing.
+	h.Events.AssertNotPublished(t, events.TypeOrderCreated)
+}
@@ -0,0 +1,70 @@
+// Package e2e wires the in-process payment and order services into one
+// harness for full-flow tests. Both services share a broker and a clock
+// the test controls, and every event they publish is recorded:
+//
+//	func TestDeclinedPaymentCancelsOrder(t *testing.T) {
+//		h := e2e.New(t)
+//		resp := h.Order.Do(t, "POST", "/orders", map[string]interface{}{
+//			"customer_email": "ana@example.com",
+//			"items":          []map[string]interface{}{{"product_name": "Mug", "quantity": 1, "unit_price_cents": 1999}},
+//		})
+//		if resp.StatusCode != http.StatusPaymentRequired {
+//			t.Fatalf("status %d: %s", resp.StatusCode, resp.Body)
+//		}
+//		h.Settle(t)
+//		h.Events.AssertPublished(t, "order.status_changed")
+//		h.Events.AssertNotPublished(t, events.TypeOrderCreated)
+//	}
+//
+// The notification service reacts to order.created, so an order without
+// that event sends no notification.
+package e2e
+
+import (
+	"testing"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/testkit"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/order/ordertest"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/payment/paymenttest"
+)
+
+// Harness is one payment and one order service running in process.
+type Harness struct {
+	Clock   *testkit.Clock
+	Broker  *broker.Broker
+	Payment *paymenttest.Payment
+	Order   *ordertest.Order
+
+	// Events records everything published on the order and payment topics.
+	Events *testkit.Recorder
+}
+
+// New starts a harness that stops when tb ends. The clock starts at
+// testkit.Epoch.
+func New(tb testing.TB) *Harness {
+	tb.Helper()
+
+	config := broker.DefaultBrokerConfig()
+	config.EnableLogging = false
+	h := &Harness{
+		Clock:  testkit.NewClock(testkit.Epoch),
+		Broker: broker.NewBroker(config),
+	}
+
+	h.Payment = paymenttest.Start(tb, paymenttest.Options{Clock: h.Clock.Now, Broker: h.Broker})
+	h.Order = ordertest.Start(tb, ordertest.Options{
+		Payment: h.Payment.Client,
+		Broker:  h.Broker,
+		Clock:   h.Clock.Now,
+	})
+	h.Events = testkit.NewRecorder(tb, h.Broker, append([]string{paymenttest.EventsTopic}, ordertest.Topics...)...)
+	return h
+}
+
+// Settle waits until every event the services publish in the background
+// has been recorded.
+func (h *Harness) Settle(tb testing.TB) {
+	tb.Helper()
+	h.Order.Flush(tb)
+}
@@ -0,0 +1,213 @@
+// Package auth authenticates callers using static API keys or JWT bearer tokens.
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// RoleAdmin grants access to every customer's data.
+const RoleAdmin = "admin"
+
+// Principal is the authenticated identity attached to a request.
+type Principal struct {
+	Subject string
+	Method  string
+	Roles   []string
+
+	// CustomerID is the customer the principal acts for. It defaults to
+	// the subject.
+	CustomerID string
+
+	// TenantID binds the principal to one tenant; empty lets it act for
+	// any tenant it names.
+	TenantID string
+}
+
+func (p *Principal) HasRole(role string) bool {
+	if p == nil {
+		return false
+	}
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Principal, error)
+}
+
+// StaticKeys authenticates against a fixed set of API keys mapped to principal names.
+type StaticKeys struct {
+	keys map[string]staticKey
+}
+
+type staticKey struct {
+	name  string
+	roles []string
+}
+
+func NewStaticKeys(keys map[string]string) *StaticKeys {
+	copied := make(map[string]staticKey, len(keys))
+	for k, v := range keys {
+		copied[k] = staticKey{name: v}
+	}
+	return &StaticKeys{keys: copied}
+}
+
+// ParseStaticKeys parses a comma separated "key:name[:role|role]" list. A key
+// without a name uses the key prefix as principal name.
+func ParseStaticKeys(spec string) (*StaticKeys, error) {
+	keys := make(map[string]staticKey)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, rest, _ := strings.Cut(entry, ":")
+		if key == "" {
+			return nil, fmt.Errorf("invalid API key entry %q", entry)
+		}
+		name, roles, _ := strings.Cut(rest, ":")
+		if name == "" {
+			name = "key_" + key[:min(4, len(key))]
+		}
+		k := staticKey{name: name}
+		if roles != "" {
+			k.roles = strings.Split(roles, "|")
+		}
+		keys[key] = k
+	}
+	return &StaticKeys{keys: keys}, nil
+}
+
+func (s *StaticKeys) Len() int {
+	return len(s.keys)
+}
+
+func (s *StaticKeys) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	for key, k := range s.keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(token)) == 1 {
+			return &Principal{Subject: k.name, Method: MethodAPIKey, Roles: k.roles, CustomerID: k.name}, nil
+		}
+	}
+	return nil, ErrInvalidCredentials
+}
+
+// JWTValidator validates HMAC-signed JWTs and optionally checks the issuer.
+type JWTValidator struct {
+	secret []byte
+	issuer string
+	jwtOptions
+}
+
+// JWTOption configures JWTValidator and JWKSValidator.
+type JWTOption func(*jwtOptions)
+
+type jwtOptions struct {
+	audience string
+}
+
+// WithAudience requires tokens to list aud in their audience claim.
+func WithAudience(aud string) JWTOption {
+	return func(o *jwtOptions) {
+		o.audience = aud
+	}
+}
+
+func NewJWTValidator(secret []byte, issuer string, opts ...JWTOption) *JWTValidator {
+	v := &JWTValidator{secret: secret, issuer: issuer}
+	for _, opt := range opts {
+		opt(&v.jwtOptions)
+	}
+	return v
+}
+
+type Claims struct {
+	jwt.RegisteredClaims
+	Roles      []string `json:"roles,omitempty"`
+	CustomerID string   `json:"customer_id,omitempty"`
+	TenantID   string   `json:"tenant_id,omitempty"`
+}
+
+func (v *JWTValidator) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	return parseJWT(token, []string{"HS256", "HS384", "HS512"}, v.issuer, v.jwtOptions, func(*jwt.Token) (interface{}, error) {
+		return v.secret, nil
+	})
+}
+
+// parseJWT verifies token with the key returned by keyFunc and turns its
+// claims into a principal.
+func parseJWT(token string, methods []string, issuer string, o jwtOptions, keyFunc jwt.Keyfunc) (*Principal, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+	}
+	if issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+	if o.audience != "" {
+		opts = append(opts, jwt.WithAudience(o.audience))
+	}
+
+	var claims Claims
+	if _, err := jwt.ParseWithClaims(token, &claims, keyFunc, opts...); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	customerID := claims.CustomerID
+	if customerID == "" {
+		customerID = claims.Subject
+	}
+	return &Principal{
+		Subject:    claims.Subject,
+		Method:     MethodJWT,
+		Roles:      claims.Roles,
+		CustomerID: customerID,
+		TenantID:   claims.TenantID,
+	}, nil
+}
+
+// Chain tries each authenticator in order and returns the first success.
+type Chain []Authenticator
+
+func (c Chain) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	if token == "" {
+		return nil, ErrMissingCredentials
+	}
+	for _, a := range c {
+		if p, err := a.Authenticate(ctx, token); err == nil {
+			return p, nil
+		}
+	}
+	return nil, ErrInvalidCredentials
+}
+
+type principalKey struct{}
+
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	AuthorizationHeader = "authorization"
+	APIKeyHeader        = "x-api-key"
+)
+
+// HealthMethods are exempt from authentication by default so that load
+// balancers and orchestrators can probe the service without credentials.
+var HealthMethods = []string{
+	"/grpc.health.v1.Health/Check",
+	"/grpc.health.v1.Health/Watch",
+	"/grpc.health.v1.Health/List",
+}
+
+// UnaryServerInterceptor rejects calls without valid credentials with
+// codes.Unauthenticated. Methods listed in exempt skip authentication.
+func UnaryServerInterceptor(authn Authenticator, exempt ...string) grpc.UnaryServerInterceptor {
+	skip := make(map[string]bool, len(exempt))
+	for _, m := range exempt {
+		skip[m] = true
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if skip[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		ctx, err := authenticate(ctx, authn)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// UnaryServerInterceptorFor authenticates the calls to the listed methods
+// with authn and passes the others through, so that some methods, such as
+// those of an admin service, can take separate credentials. Exempt them
+// from the interceptor covering the other methods.
+func UnaryServerInterceptorFor(authn Authenticator, methods ...string) grpc.UnaryServerInterceptor {
+	guarded := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		guarded[m] = true
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !guarded[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		ctx, err := authenticate(ctx, authn)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor.
+func StreamServerInterceptor(authn Authenticator, exempt ...string) grpc.StreamServerInterceptor {
+	skip := make(map[string]bool, len(exempt))
+	for _, m := range exempt {
+		skip[m] = true
+	}
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if skip[info.FullMethod] {
+			return handler(srv, ss)
+		}
+
+		ctx, err := authenticate(ss.Context(), authn)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticate attaches the principal of the credentials in the metadata
+// of ctx, or fails with codes.Unauthenticated.
+func authenticate(ctx context.Context, authn Authenticator) (context.Context, error) {
+	token := TokenFromIncomingContext(ctx)
+	if token == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing credentials")
+	}
+
+	principal, err := authn.Authenticate(ctx, token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	return NewContext(ctx, principal), nil
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// TokenFromIncomingContext extracts a bearer token or API key from gRPC metadata.
+func TokenFromIncomingContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(AuthorizationHeader); len(values) > 0 {
+		if token, ok := ParseBearer(values[0]); ok {
+			return token
+		}
+	}
+	if values := md.Get(APIKeyHeader); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func ParseBearer(header string) (string, bool) {
+	const prefix = "bearer "
+	if len(header) < len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+	return strings.TrimSpace(header[len(prefix):]), true
+}
+
+// StreamClientInterceptor attaches the token as a bearer credential on every stream.
+func StreamClientInterceptor(token string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if token != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, AuthorizationHeader, "Bearer "+token)
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+// UnaryClientInterceptor attaches the token as a bearer credential on every call.
+func UnaryClientInterceptor(token string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if token != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, AuthorizationHeader, "Bearer "+token)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// HTTPMiddleware rejects requests without valid credentials with 401 and
+// attaches the principal to the request context. Paths listed in exempt
+// skip authentication.
+func HTTPMiddleware(authn Authenticator, exempt ...string) func(http.Handler) http.Handler {
+	skip := make(map[string]bool, len(exempt))
+	for _, p := range exempt {
+		skip[p] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skip[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token := TokenFromRequest(r)
+			if token == "" {
+				unauthorized(w, "missing credentials")
+				return
+			}
+
+			principal, err := authn.Authenticate(r.Context(), token)
+			if err != nil {
+				unauthorized(w, "invalid credentials")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), principal)))
+		})
+	}
+}
+
+// TokenFromRequest extracts a bearer token or API key from HTTP headers.
+func TokenFromRequest(r *http.Request) string {
+	if token, ok := ParseBearer(r.Header.Get(AuthorizationHeader)); ok {
+		return token
+	}
+	return r.Header.Get(APIKeyHeader)
+}
+
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
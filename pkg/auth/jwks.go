@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// jwksRefreshInterval is how long fetched keys are trusted before the
+	// set is downloaded again.
+	jwksRefreshInterval = time.Hour
+
+	// jwksMinRefresh limits refetches triggered by tokens with an unknown
+	// key ID, so bogus tokens cannot hammer the identity provider.
+	jwksMinRefresh = time.Minute
+)
+
+// JWKSValidator validates RSA and ECDSA signed JWTs against the keys
+// published at a JSON Web Key Set URL. Keys are fetched on first use and
+// refreshed hourly or when a token names an unknown key.
+type JWKSValidator struct {
+	url    string
+	issuer string
+	client *http.Client
+	jwtOptions
+
+	mu        sync.Mutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+	// refreshing is closed when the fetch in flight ends, nil without one,
+	// and refreshErr is the error of the last fetch.
+	refreshing chan struct{}
+	refreshErr error
+}
+
+func NewJWKSValidator(url, issuer string, opts ...JWTOption) *JWKSValidator {
+	v := &JWKSValidator{
+		url:    url,
+		issuer: issuer,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(&v.jwtOptions)
+	}
+	return v
+}
+
+func (v *JWKSValidator) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	methods := []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+	return parseJWT(token, methods, v.issuer, v.jwtOptions, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.key(ctx, kid)
+	})
+}
+
+// key returns the public key with the given ID. An empty ID matches a set
+// holding a single key. A cached key is served while the set is fetched
+// again; only a key not cached waits for the fetch.
+func (v *JWKSValidator) key(ctx context.Context, kid string) (interface{}, error) {
+	v.mu.Lock()
+	key, known := v.lookupLocked(kid)
+	age := time.Since(v.fetchedAt)
+	var refreshed <-chan struct{}
+	if v.keys == nil || age > jwksRefreshInterval || (!known && age > jwksMinRefresh) {
+		refreshed = v.refreshLocked()
+	} else if !known {
+		// A fetch started by another token may bring the key.
+		refreshed = v.refreshing
+	}
+	v.mu.Unlock()
+
+	if known {
+		return key, nil
+	}
+	if refreshed != nil {
+		select {
+		case <-refreshed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	key, ok := v.lookupLocked(kid)
+	switch {
+	case ok:
+		return key, nil
+	case v.keys == nil && v.refreshErr != nil:
+		return nil, v.refreshErr
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// refreshLocked starts fetching the key set unless a fetch is in flight,
+// and returns a channel closed when it ends. The fetch outlives the
+// request that started it, bounded by the timeout of the client, and
+// replaces the keys only if it succeeds.
+func (v *JWKSValidator) refreshLocked() <-chan struct{} {
+	if v.refreshing != nil {
+		return v.refreshing
+	}
+	v.fetchedAt = time.Now()
+	done := make(chan struct{})
+	v.refreshing = done
+
+	go func() {
+		keys, err := v.fetch(context.Background())
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		if err == nil {
+			v.keys = keys
+		}
+		v.refreshErr = err
+		v.refreshing = nil
+		close(done)
+	}()
+	return done
+}
+
+func (v *JWKSValidator) lookupLocked(kid string) (interface{}, bool) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	key, ok := v.keys[kid]
+	return key, ok
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetch downloads the key set and returns its signing keys by ID.
+func (v *JWKSValidator) fetch(ctx context.Context) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch JWKS: %s", resp.Status)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			// Skip keys of unsupported types rather than rejecting the set.
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
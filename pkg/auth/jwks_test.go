@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksServer serves a key set holding key under kid, counting the fetches.
+// Fetches wait for release once block is set.
+type jwksServer struct {
+	*httptest.Server
+	fetches atomic.Int64
+	block   atomic.Bool
+	release chan struct{}
+}
+
+func newJWKSServer(t *testing.T, kid string, key *rsa.PublicKey) *jwksServer {
+	t.Helper()
+	s := &jwksServer{release: make(chan struct{})}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		if s.block.Load() {
+			<-s.release
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []jsonWebKey{{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	t.Cleanup(s.Close)
+	t.Cleanup(func() {
+		if s.block.Load() {
+			close(s.release)
+		}
+	})
+	return s
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, kid string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"sub": "cust_1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestJWKSServesCachedKeyWhileRefreshing(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newJWKSServer(t, "k1", &key.PublicKey)
+	v := NewJWKSValidator(server.URL, "")
+	ctx := context.Background()
+
+	known := signToken(t, key, "k1")
+	if _, err := v.Authenticate(ctx, known); err != nil {
+		t.Fatalf("first token: %v", err)
+	}
+
+	// The keys are due for a refresh, which the identity provider holds.
+	server.block.Store(true)
+	v.mu.Lock()
+	v.fetchedAt = time.Now().Add(-2 * jwksRefreshInterval)
+	v.mu.Unlock()
+
+	// Tokens naming an unknown key wait for the single fetch in flight.
+	unknown := make(chan error, 5)
+	for range cap(unknown) {
+		go func() {
+			_, err := v.Authenticate(ctx, signToken(t, key, "k2"))
+			unknown <- err
+		}()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		for range 10 {
+			if _, err := v.Authenticate(ctx, known); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("cached key while refreshing: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("tokens of a cached key waited for the refresh")
+	}
+
+	server.block.Store(false)
+	close(server.release)
+	for range cap(unknown) {
+		if err := <-unknown; err == nil {
+			t.Fatal("token of an unknown key accepted")
+		}
+	}
+	if n := server.fetches.Load(); n != 2 {
+		t.Fatalf("%d fetches, want 2: the first and one refresh", n)
+	}
+}
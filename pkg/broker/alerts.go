@@ -0,0 +1,116 @@
+package broker
+
+import (
+	"context"
+	"time"
+)
+
+// AlertThresholds are the limits of a queue that raise an alert: the number
+// of messages waiting and the age of the oldest one. Zero disables a limit.
+type AlertThresholds struct {
+	Depth     int
+	OldestAge time.Duration
+}
+
+// exceeded reports whether stats break any of the limits.
+func (t AlertThresholds) exceeded(stats QueueStats) bool {
+	return (t.Depth > 0 && stats.CurrentSize >= t.Depth) ||
+		(t.OldestAge > 0 && stats.OldestAge >= t.OldestAge)
+}
+
+// QueueAlert reports that a queue went past its thresholds, or, with Firing
+// unset, that it is back under them.
+type QueueAlert struct {
+	Queue      string
+	Firing     bool
+	Depth      int
+	OldestAge  time.Duration
+	Thresholds AlertThresholds
+	Time       time.Time
+}
+
+// AlertFunc receives the alerts of Monitor.
+type AlertFunc func(QueueAlert)
+
+// SetAlertThresholds makes Monitor alert when queueName holds depth
+// messages or more, or its oldest message is oldestAge old; zero disables
+// either limit, and both remove the thresholds.
+func (b *Broker) SetAlertThresholds(queueName string, depth int, oldestAge time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.queues[queueName]; !ok {
+		return ErrQueueNotFound
+	}
+	if depth <= 0 && oldestAge <= 0 {
+		delete(b.thresholds, queueName)
+		delete(b.firing, queueName)
+		return nil
+	}
+	b.thresholds[queueName] = AlertThresholds{Depth: depth, OldestAge: oldestAge}
+	return nil
+}
+
+// OnAlert calls fn for every alert raised or resolved by Monitor, in
+// addition to the warning it logs.
+func (b *Broker) OnAlert(fn AlertFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onAlert = append(b.onAlert, fn)
+}
+
+// Monitor samples the queues with thresholds every interval until ctx is
+// done. An alert fires once when a queue goes past its thresholds and is
+// resolved once it is back under them, not on every sample in between.
+// Every interval it also reclaims the messages of all queues whose
+// visibility timeout lapsed, see OnVisibilityExpired.
+func (b *Broker) Monitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.reclaimExpired()
+			b.checkThresholds(ctx)
+		}
+	}
+}
+
+func (b *Broker) checkThresholds(ctx context.Context) {
+	b.mu.Lock()
+	var alerts []QueueAlert
+	for name, thresholds := range b.thresholds {
+		stats := b.queues[name].Stats()
+		exceeded := thresholds.exceeded(stats)
+		if exceeded == b.firing[name] {
+			continue
+		}
+		b.firing[name] = exceeded
+		alerts = append(alerts, QueueAlert{
+			Queue:      name,
+			Firing:     exceeded,
+			Depth:      stats.CurrentSize,
+			OldestAge:  stats.OldestAge,
+			Thresholds: thresholds,
+			Time:       time.Now(),
+		})
+	}
+	handlers := append([]AlertFunc(nil), b.onAlert...)
+	b.mu.Unlock()
+
+	for _, alert := range alerts {
+		args := []any{"queue", alert.Queue, "depth", alert.Depth, "oldest_age", alert.OldestAge,
+			"depth_threshold", alert.Thresholds.Depth, "age_threshold", alert.Thresholds.OldestAge}
+		if alert.Firing {
+			b.log.WarnContext(ctx, "queue is backing up", args...)
+		} else {
+			b.log.InfoContext(ctx, "queue is back under its thresholds", args...)
+		}
+		for _, fn := range handlers {
+			fn(alert)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package broker
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// The benchmarks measure the broker from several goroutines at once, so
+// changes to its locking can be compared before and after, per message:
+//
+//	go test ./pkg/broker -run '^$' -bench . -benchtime 2s
+var (
+	benchSubscribers = flag.Int("subscribers", 1, "Queues subscribed to the topic of BenchmarkPublish")
+	benchDepth       = flag.Int("depth", 10000, "Messages kept waiting in the queue by BenchmarkBacklog")
+)
+
+// benchGoroutines are the goroutine counts each benchmark is run with.
+var benchGoroutines = []int{1, 4, 16}
+
+// runGoroutines runs bench as a sub-benchmark for each of benchGoroutines.
+func runGoroutines(b *testing.B, bench func(b *testing.B, goroutines int)) {
+	for _, n := range benchGoroutines {
+		b.Run(fmt.Sprintf("goroutines=%d", n), func(b *testing.B) {
+			bench(b, n)
+		})
+	}
+}
+
+// newBenchBroker returns a broker without logs, whose topic "bench"
+// delivers to queues "bench-0" to "bench-{subscribers-1}".
+func newBenchBroker(subscribers int) (*Broker, []*Queue) {
+	cfg := DefaultBrokerConfig()
+	cfg.EnableLogging = false
+	br := NewBroker(cfg)
+	br.CreateTopic("bench")
+	queues := make([]*Queue, subscribers)
+	for i := range queues {
+		name := fmt.Sprintf("bench-%d", i)
+		queues[i] = br.CreateQueue(name)
+		br.Subscribe("bench", name)
+	}
+	return br, queues
+}
+
+func newBenchMessage() *Message {
+	msg, err := NewMessage("bench", map[string]int{"n": 1})
+	if err != nil {
+		panic(err)
+	}
+	return msg
+}
+
+// parallel calls op b.N times in total from goroutines goroutines.
+func parallel(b *testing.B, goroutines int, op func()) {
+	var left atomic.Int64
+	left.Store(int64(b.N))
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for left.Add(-1) >= 0 {
+				op()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// receiveAck receives a message of q and acknowledges it. A message
+// received by no one else is always there, as every caller enqueued one
+// first, unless an earlier run left the queue short.
+func receiveAck(ctx context.Context, q *Queue) {
+	msg, err := q.Receive(ctx)
+	if err != nil || msg == nil {
+		return
+	}
+	q.Acknowledge(ctx, msg.ReceiptHandle)
+}
+
+// BenchmarkPublish measures Broker.Publish to a topic with -subscribers
+// queues.
+func BenchmarkPublish(b *testing.B) {
+	runGoroutines(b, func(b *testing.B, goroutines int) {
+		ctx := context.Background()
+		br, _ := newBenchBroker(*benchSubscribers)
+		b.ReportAllocs()
+		b.ResetTimer()
+		parallel(b, goroutines, func() {
+			br.Publish(ctx, "bench", newBenchMessage())
+		})
+	})
+}
+
+// BenchmarkReceiveAck measures Receive and Acknowledge of messages enqueued
+// beforehand.
+func BenchmarkReceiveAck(b *testing.B) {
+	runGoroutines(b, func(b *testing.B, goroutines int) {
+		ctx := context.Background()
+		_, queues := newBenchBroker(1)
+		q := queues[0]
+		for range b.N {
+			q.Enqueue(ctx, newBenchMessage())
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		parallel(b, goroutines, func() {
+			receiveAck(ctx, q)
+		})
+	})
+}
+
+// BenchmarkPipeline measures Publish, Receive and Acknowledge of one
+// message, with the queue about empty.
+func BenchmarkPipeline(b *testing.B) {
+	runGoroutines(b, func(b *testing.B, goroutines int) {
+		pipeline(b, 0, goroutines)
+	})
+}
+
+// BenchmarkBacklog is BenchmarkPipeline with -depth messages waiting in the
+// queue.
+func BenchmarkBacklog(b *testing.B) {
+	runGoroutines(b, func(b *testing.B, goroutines int) {
+		pipeline(b, *benchDepth, goroutines)
+	})
+}
+
+// pipeline publishes, receives and acknowledges a message per operation on
+// a queue holding depth messages from the start.
+func pipeline(b *testing.B, depth, goroutines int) {
+	ctx := context.Background()
+	br, queues := newBenchBroker(1)
+	q := queues[0]
+	for range depth {
+		q.Enqueue(ctx, newBenchMessage())
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	parallel(b, goroutines, func() {
+		br.Publish(ctx, "bench", newBenchMessage())
+		receiveAck(ctx, q)
+	})
+}
@@ -2,14 +2,52 @@ package broker
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"maps"
 	"sync"
 	"time"
 )
 
 type BrokerConfig struct {
-	DefaultVisibilityTimeout time.Duration
+	DefaultVisibilityTimeout time.Duration `config:"visibility-timeout" usage:"Time a received message stays hidden from other consumers before it is redelivered"`
 	DefaultMaxRetries        int
-	EnableLogging            bool
+
+	// EnableLogging turns every log of the broker off when unset. The logs
+	// go to Logger, or when it is nil to the slog default at LogLevel,
+	// whatever the level of the service.
+	EnableLogging bool
+	LogLevel      string `config:"log-level" usage:"Level of the broker logs: debug, info, warn or error; debug logs every message enqueued, received and acknowledged"`
+	Logger        Logger
+
+	// TraceHistory is the number of messages whose trace is kept, see
+	// Broker.MessageTrace. 0 turns tracing off.
+	TraceHistory int `config:"trace-history" usage:"Messages whose delivery trace is kept for debugging, oldest evicted first; 0 disables tracing"`
+
+	// TopicRetention and TopicRetentionMessages are the WithRetention of
+	// every topic created without one.
+	TopicRetention         time.Duration `config:"topic-retention" usage:"Time topics keep published messages for replay to their subscribers; 0 keeps them for no set time"`
+	TopicRetentionMessages int           `config:"topic-retention-messages" usage:"Most messages each topic keeps for replay; 0 sets no bound, and with topic-retention 0 too disables retention"`
+}
+
+func (c BrokerConfig) Validate() error {
+	if c.DefaultVisibilityTimeout <= 0 {
+		return fmt.Errorf("visibility-timeout must be positive, got %s", c.DefaultVisibilityTimeout)
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); c.LogLevel != "" && err != nil {
+		return fmt.Errorf("invalid log-level %q", c.LogLevel)
+	}
+	if c.TraceHistory < 0 {
+		return fmt.Errorf("trace-history must not be negative, got %d", c.TraceHistory)
+	}
+	if c.TopicRetention < 0 {
+		return fmt.Errorf("topic-retention must not be negative, got %s", c.TopicRetention)
+	}
+	if c.TopicRetentionMessages < 0 {
+		return fmt.Errorf("topic-retention-messages must not be negative, got %d", c.TopicRetentionMessages)
+	}
+	return nil
 }
 
 func DefaultBrokerConfig() BrokerConfig {
@@ -17,25 +55,49 @@ func DefaultBrokerConfig() BrokerConfig {
 		DefaultVisibilityTimeout: 30 * time.Second,
 		DefaultMaxRetries:        3,
 		EnableLogging:            true,
+		LogLevel:                 "info",
 	}
 }
 
+// Publisher publishes messages to topics. Services that only publish take
+// a Publisher rather than a *Broker, so tests can record what they send.
+type Publisher interface {
+	Publish(ctx context.Context, topicName string, msg *Message) error
+}
+
 type Broker struct {
 	mu     sync.RWMutex
 	topics map[string]*Topic
 	queues map[string]*Queue
 	config BrokerConfig
+	log    Logger
+	trace  *tracer
+
+	// thresholds and firing are the alert thresholds of queues and whether
+	// their alert is raised, see Monitor.
+	thresholds map[string]AlertThresholds
+	firing     map[string]bool
+	onAlert    []AlertFunc
+	onExpired  []ExpiryFunc
+
+	// forwards are the forwarding rules, which apply to the topics created
+	// later too.
+	forwards []forwardRule
 }
 
 func NewBroker(config BrokerConfig) *Broker {
 	return &Broker{
-		topics: make(map[string]*Topic),
-		queues: make(map[string]*Queue),
-		config: config,
+		topics:     make(map[string]*Topic),
+		queues:     make(map[string]*Queue),
+		config:     config,
+		log:        newLogger(config),
+		trace:      newTracer(config.TraceHistory),
+		thresholds: make(map[string]AlertThresholds),
+		firing:     make(map[string]bool),
 	}
 }
 
-func (b *Broker) CreateTopic(name string) *Topic {
+func (b *Broker) CreateTopic(name string, opts ...TopicOption) *Topic {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
@@ -46,13 +108,22 @@ func (b *Broker) CreateTopic(name string) *Topic {
 	topic := &Topic{
 		name:        name,
 		subscribers: make([]*Queue, 0),
+		log:         b.log,
+		trace:       b.trace,
+
+		retention:      b.config.TopicRetention,
+		retainMessages: b.config.TopicRetentionMessages,
+	}
+	for _, opt := range opts {
+		opt(topic)
 	}
 	b.topics[name] = topic
-
-	if b.config.EnableLogging {
-		logInfo("Created topic: %s", name)
+	for _, rule := range b.forwards {
+		rule.attach(topic)
 	}
 
+	b.log.InfoContext(context.Background(), "created topic", "topic", name)
+
 	return topic
 }
 
@@ -71,9 +142,39 @@ func WithVisibilityTimeout(d time.Duration) QueueOption {
 	}
 }
 
+// WithMaxRetries dead-letters a message once it was received n times, see
+// RedrivePolicy.MaxReceives.
 func WithMaxRetries(n int) QueueOption {
+	return WithRedrivePolicy(RedrivePolicy{MaxReceives: n})
+}
+
+// RedrivePolicy decides when a queue gives up on a message and moves it to
+// its dead-letter queue, or discards it without one. A message is given up
+// on as soon as it reaches either limit; a zero limit does not apply.
+type RedrivePolicy struct {
+	// MaxReceives limits the deliveries of a message, failed or lost to a
+	// lapsed visibility timeout as when a consumer crashes: it is given up
+	// on when its MaxReceives-th delivery fails, or instead of being
+	// received once more.
+	MaxReceives int `json:"max_receives,omitempty" yaml:"max_receives,omitempty"`
+
+	// MaxFailures limits the deliveries of a message that were nacked, so
+	// a message redelivered after a crash keeps its retries.
+	MaxFailures int `json:"max_failures,omitempty" yaml:"max_failures,omitempty"`
+}
+
+// exhausted reports whether msg reached a limit of p, so that the queue
+// gives up on it rather than deliver it again.
+func (p RedrivePolicy) exhausted(msg *Message) bool {
+	return (p.MaxReceives > 0 && msg.RetryCount >= p.MaxReceives) ||
+		(p.MaxFailures > 0 && msg.FailureCount >= p.MaxFailures)
+}
+
+// WithRedrivePolicy sets the redrive policy of the queue, replacing
+// WithMaxRetries and BrokerConfig.DefaultMaxRetries.
+func WithRedrivePolicy(policy RedrivePolicy) QueueOption {
 	return func(q *Queue) {
-		q.maxRetries = n
+		q.redrive = policy
 	}
 }
 
@@ -83,6 +184,48 @@ func WithDLQ(dlq *Queue) QueueOption {
 	}
 }
 
+// PriorityAging raises the effective priority of a message by one for every
+// Interval of its Age, by at most MaxBoost, so a steady flow of high
+// priority messages cannot starve the others. A zero Interval disables
+// aging and a zero MaxBoost leaves the boost unbounded.
+type PriorityAging struct {
+	Interval time.Duration
+	MaxBoost int
+}
+
+// boost returns the priority a message of age has gained.
+func (a PriorityAging) boost(age time.Duration) int {
+	if a.Interval <= 0 || age <= 0 {
+		return 0
+	}
+	boost := int(age / a.Interval)
+	if a.MaxBoost > 0 {
+		boost = min(boost, a.MaxBoost)
+	}
+	return boost
+}
+
+// WithPriorityAging ages the priority of the messages of the queue with
+// policy.
+func WithPriorityAging(policy PriorityAging) QueueOption {
+	return func(q *Queue) {
+		q.aging = policy
+	}
+}
+
+// EnqueueFilter sees every message enqueued on a queue and returns the
+// messages to store instead: none drops it and several duplicate it. A
+// message whose VisibleAt lies ahead is not received before then.
+type EnqueueFilter func(msg *Message) []*Message
+
+// WithEnqueueFilter passes the messages enqueued on the queue through f,
+// for example to inject faults.
+func WithEnqueueFilter(f EnqueueFilter) QueueOption {
+	return func(q *Queue) {
+		q.enqueueFilter = f
+	}
+}
+
 func (b *Broker) CreateQueue(name string, opts ...QueueOption) *Queue {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -93,9 +236,13 @@ func (b *Broker) CreateQueue(name string, opts ...QueueOption) *Queue {
 
 	queue := &Queue{
 		name:              name,
-		messages:          make([]*Message, 0),
+		inflight:          make(map[string]*Message),
 		visibilityTimeout: b.config.DefaultVisibilityTimeout,
-		maxRetries:        b.config.DefaultMaxRetries,
+		redrive:           RedrivePolicy{MaxReceives: b.config.DefaultMaxRetries},
+		log:               b.log,
+		trace:             b.trace,
+		onExpire:          b.visibilityExpired,
+		onAck:             b.acknowledged,
 	}
 
 	for _, opt := range opts {
@@ -104,9 +251,7 @@ func (b *Broker) CreateQueue(name string, opts ...QueueOption) *Queue {
 
 	b.queues[name] = queue
 
-	if b.config.EnableLogging {
-		logInfo("Created queue: %s", name)
-	}
+	b.log.InfoContext(context.Background(), "created queue", "queue", name)
 
 	return queue
 }
@@ -134,9 +279,7 @@ func (b *Broker) Subscribe(topicName, queueName string) error {
 
 	topic.addSubscriber(queue)
 
-	if b.config.EnableLogging {
-		logInfo("Subscribed queue '%s' to topic '%s'", queueName, topicName)
-	}
+	b.log.InfoContext(context.Background(), "subscribed queue to topic", "queue", queueName, "topic", topicName)
 
 	return nil
 }
@@ -153,6 +296,44 @@ func (b *Broker) Publish(ctx context.Context, topicName string, msg *Message) er
 	return topic.Publish(ctx, msg)
 }
 
+// Redrive moves up to limit messages of the dead-letter queue dlqName back
+// to the queues they failed in, oldest first; 0 moves them all. Their retry
+// count and failure metadata are reset. Messages whose original queue does
+// not exist, or that a worker is handling, stay where they are. It returns
+// the number of messages moved.
+func (b *Broker) Redrive(ctx context.Context, dlqName string, limit int) (int, error) {
+	b.mu.RLock()
+	dlq, ok := b.queues[dlqName]
+	queues := maps.Clone(b.queues)
+	b.mu.RUnlock()
+
+	if !ok {
+		return 0, ErrQueueNotFound
+	}
+
+	msgs := dlq.take(limit, func(msg *Message) bool {
+		_, ok := queues[msg.GetMetadata(OriginalQueueMetadata)]
+		return ok
+	})
+	for _, msg := range msgs {
+		target := queues[msg.GetMetadata(OriginalQueueMetadata)]
+		delete(msg.Metadata, OriginalQueueMetadata)
+		delete(msg.Metadata, FailureReasonMetadata)
+		delete(msg.Metadata, LastErrorMetadata)
+		msg.RetryCount = 0
+		msg.FailureCount = 0
+		msg.VisibleAt = time.Time{}
+		msg.ReceiptHandle = ""
+
+		b.trace.record(msg.ID, TraceEvent{Event: TraceRedriven, Queue: dlqName})
+		// The message has left the dead-letter queue already.
+		target.Enqueue(context.WithoutCancel(ctx), msg)
+		b.log.InfoContext(ctx, "message redriven", "message_id", msg.ID, "dlq", dlqName, "queue", target.name)
+	}
+
+	return len(msgs), nil
+}
+
 func (b *Broker) Stats() BrokerStats {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
@@ -1,10 +1,6 @@
 package broker
 
-import (
-	"errors"
-	"log"
-	"time"
-)
+import "errors"
 
 var (
 	ErrTopicNotFound        = errors.New("topic not found")
@@ -12,58 +8,6 @@ var (
 	ErrMessageNotFound      = errors.New("message not found")
 	ErrInvalidReceiptHandle = errors.New("invalid or expired receipt handle")
 	ErrQueueEmpty           = errors.New("queue is empty")
+	ErrNoRetention          = errors.New("topic does not retain messages")
+	ErrOffsetOutOfRange     = errors.New("offset out of range")
 )
-
-var loggingEnabled = true
-
-func SetLogging(enabled bool) {
-	loggingEnabled = enabled
-}
-
-func logInfo(format string, args ...interface{}) {
-	if loggingEnabled {
-		log.Printf("[BROKER] "+format, args...)
-	}
-}
-
-func logError(format string, args ...interface{}) {
-	if loggingEnabled {
-		log.Printf("[BROKER] ERROR: "+format, args...)
-	}
-}
-
-func logDebug(format string, args ...interface{}) {
-	// Debug logging disabled by default
-}
-
-type RetryConfig struct {
-	MaxRetries     int
-	InitialBackoff time.Duration
-	MaxBackoff     time.Duration
-	BackoffFactor  float64
-}
-
-func DefaultRetryConfig() RetryConfig {
-	return RetryConfig{
-		MaxRetries:     3,
-		InitialBackoff: 100 * time.Millisecond,
-		MaxBackoff:     30 * time.Second,
-		BackoffFactor:  2.0,
-	}
-}
-
-func (c RetryConfig) BackoffDuration(attempt int) time.Duration {
-	if attempt <= 0 {
-		return c.InitialBackoff
-	}
-
-	backoff := c.InitialBackoff
-	for i := 0; i < attempt; i++ {
-		backoff = time.Duration(float64(backoff) * c.BackoffFactor)
-		if backoff > c.MaxBackoff {
-			return c.MaxBackoff
-		}
-	}
-
-	return backoff
-}
@@ -0,0 +1,101 @@
+package broker
+
+import (
+	"context"
+	"time"
+)
+
+// VisibilityExpiry reports a delivery whose visibility timeout lapsed before
+// it was acknowledged or nacked. The message is visible again and will be
+// handled a second time; the usual cause is a handler that hangs or takes
+// longer than the timeout.
+type VisibilityExpiry struct {
+	Queue     string
+	MessageID string
+
+	// Worker is the worker that received the message, empty when it was
+	// received with Queue.Receive or ReceiveWait.
+	Worker string
+
+	// Receives is the number of times the message was received, the
+	// expired delivery included.
+	Receives   int
+	ReceivedAt time.Time
+	ExpiredAt  time.Time
+}
+
+// ExpiryFunc receives the visibility expiries of OnVisibilityExpired.
+type ExpiryFunc func(VisibilityExpiry)
+
+// OnVisibilityExpired calls fn for every delivery of a queue of b whose
+// visibility timeout lapses, in addition to the warning it logs. A lapse is
+// noticed by the next receive on the queue, and by Monitor when nothing
+// receives, as when the only worker of the queue hangs. fn runs on a
+// goroutine of its own.
+func (b *Broker) OnVisibilityExpired(fn ExpiryFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onExpired = append(b.onExpired, fn)
+}
+
+func (b *Broker) visibilityExpired(expiries []VisibilityExpiry) {
+	b.mu.RLock()
+	handlers := append([]ExpiryFunc(nil), b.onExpired...)
+	b.mu.RUnlock()
+
+	for _, expiry := range expiries {
+		for _, fn := range handlers {
+			fn(expiry)
+		}
+	}
+}
+
+// reclaimExpired returns to their queues the messages whose visibility
+// timeout lapsed, reporting the expiries.
+func (b *Broker) reclaimExpired() {
+	b.mu.RLock()
+	queues := make([]*Queue, 0, len(b.queues))
+	for _, q := range b.queues {
+		queues = append(queues, q)
+	}
+	b.mu.RUnlock()
+
+	now := time.Now()
+	for _, q := range queues {
+		q.mu.Lock()
+		q.reclaimLocked(now)
+		q.mu.Unlock()
+	}
+}
+
+// expireLocked records that the delivery of msg, in flight until now, lapsed.
+// q.flightMu is held.
+func (q *Queue) expireLocked(msg *Message) VisibilityExpiry {
+	expiry := VisibilityExpiry{
+		Queue:      q.name,
+		MessageID:  msg.ID,
+		Worker:     msg.receivedBy,
+		Receives:   msg.RetryCount,
+		ReceivedAt: msg.receivedAt,
+		ExpiredAt:  msg.VisibleAt,
+	}
+	q.expired.Add(1)
+	if msg.receivedBy != "" {
+		if q.expiredBy == nil {
+			q.expiredBy = make(map[string]int64)
+		}
+		q.expiredBy[msg.receivedBy]++
+	}
+	q.trace.record(msg.ID, TraceEvent{Event: TraceExpired, Queue: q.name, Worker: msg.receivedBy, Retry: msg.RetryCount})
+	q.log.WarnContext(msg.Context(context.Background()), "visibility timeout lapsed before the message was acknowledged, it will be redelivered",
+		"queue", q.name, "worker", msg.receivedBy, "receives", msg.RetryCount, "held", msg.VisibleAt.Sub(msg.receivedAt))
+	return expiry
+}
+
+// expiredFor returns the number of deliveries to worker whose visibility
+// timeout lapsed.
+func (q *Queue) expiredFor(worker string) int64 {
+	q.flightMu.Lock()
+	defer q.flightMu.Unlock()
+	return q.expiredBy[worker]
+}
@@ -0,0 +1,132 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+)
+
+// Metadata set on forwarded messages: the number of forwarding rules the
+// message went through and the topic it was last forwarded from.
+const (
+	ForwardHopsMetadata   = "forward_hops"
+	ForwardedFromMetadata = "forwarded_from"
+)
+
+// MaxForwardHops is the number of forwarding rules a message may go through.
+// A message that reaches it is not forwarded any further, which ends loops
+// such as two topics forwarding to each other.
+const MaxForwardHops = 8
+
+// ForwardFilter reports whether a message published to the source topic of
+// a forwarding rule is forwarded. It must not modify the message.
+type ForwardFilter func(msg *Message) bool
+
+type forwardRule struct {
+	pattern string
+	dst     *Topic
+	filter  ForwardFilter
+}
+
+// Forward republishes the messages published to srcTopic on dstTopic, after
+// they are delivered to the subscribers of srcTopic, if filter accepts them;
+// a nil filter forwards them all. srcTopic can be a pattern, see path.Match,
+// such as "order.*", which applies to the matching topics created later too
+// but never to dstTopic itself. Forwarded messages get a new ID and carry
+// ForwardHopsMetadata and ForwardedFromMetadata; see MaxForwardHops for
+// loops.
+func (b *Broker) Forward(srcTopic, dstTopic string, filter ForwardFilter) error {
+	if _, err := path.Match(srcTopic, ""); err != nil {
+		return fmt.Errorf("forward from %q: %w", srcTopic, err)
+	}
+	wildcard := strings.ContainsAny(srcTopic, `*?[\`)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	dst, ok := b.topics[dstTopic]
+	if !ok {
+		return ErrTopicNotFound
+	}
+	if !wildcard {
+		if _, ok := b.topics[srcTopic]; !ok {
+			return ErrTopicNotFound
+		}
+		if srcTopic == dstTopic {
+			return fmt.Errorf("forward from %q to itself", srcTopic)
+		}
+	}
+
+	rule := forwardRule{pattern: srcTopic, dst: dst, filter: filter}
+	b.forwards = append(b.forwards, rule)
+	for _, topic := range b.topics {
+		rule.attach(topic)
+	}
+
+	b.log.InfoContext(context.Background(), "forwarding topic", "from", srcTopic, "to", dstTopic)
+
+	return nil
+}
+
+// attach adds the rule to topic if its pattern matches the topic name.
+func (r forwardRule) attach(topic *Topic) {
+	if topic == r.dst {
+		return
+	}
+	if ok, _ := path.Match(r.pattern, topic.name); !ok {
+		return
+	}
+
+	topic.mu.Lock()
+	defer topic.mu.Unlock()
+	topic.forwards = append(topic.forwards, r)
+}
+
+// forward publishes msg, which was published to t, on the destinations of
+// the forwarding rules of t.
+func (t *Topic) forward(ctx context.Context, msg *Message) {
+	t.mu.RLock()
+	rules := t.forwards
+	t.mu.RUnlock()
+
+	if len(rules) == 0 {
+		return
+	}
+
+	hops, _ := strconv.Atoi(msg.GetMetadata(ForwardHopsMetadata))
+	if hops >= MaxForwardHops {
+		t.mu.Lock()
+		t.forwardsDropped++
+		t.mu.Unlock()
+		t.log.WarnContext(msg.Context(ctx), "dropped forwarded message at the hop limit, check the forwarding rules for a loop",
+			"topic", t.name, "hops", hops, "forwarded_from", msg.GetMetadata(ForwardedFromMetadata))
+		return
+	}
+
+	for _, rule := range rules {
+		if rule.filter != nil && !rule.filter(msg) {
+			continue
+		}
+
+		clone := msg.Clone()
+		clone.SetMetadata(ForwardHopsMetadata, strconv.Itoa(hops+1))
+		clone.SetMetadata(ForwardedFromMetadata, t.name)
+		t.trace.inherit(msg.ID, clone.ID)
+
+		if err := rule.dst.Publish(ctx, clone); err != nil {
+			t.log.ErrorContext(msg.Context(ctx), "failed to forward message", "topic", t.name, "to", rule.dst.name, logging.Err(err))
+		}
+	}
+}
+
+// ForwardsDropped returns the number of messages published to the topic
+// that were not forwarded because they reached MaxForwardHops.
+func (t *Topic) ForwardsDropped() int64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.forwardsDropped
+}
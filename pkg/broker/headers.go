@@ -0,0 +1,111 @@
+package broker
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+)
+
+// Metadata keys of the well-known headers of a message, see MessageHeaders.
+// The tenant is kept under TenantMetadata.
+const (
+	CorrelationIDMetadata = "correlation_id"
+	CausationIDMetadata   = "causation_id"
+	SchemaVersionMetadata = "schema_version"
+	ContentTypeMetadata   = "content_type"
+	ReplyToMetadata       = "reply_to"
+)
+
+// ContentTypeJSON is the content type of the payloads built by NewMessage.
+const ContentTypeJSON = "application/json"
+
+// MessageHeaders are the well-known metadata of a message, typed. They are
+// stored in Metadata, so they travel with every clone and redrive like any
+// other key.
+type MessageHeaders struct {
+	// CorrelationID is shared by every message published for one request
+	// or workflow. Topic.Publish sets it to the correlation ID of the
+	// message being handled, else to the request ID, else to the message ID.
+	CorrelationID string
+
+	// CausationID is the ID of the message whose handling published this
+	// one. Topic.Publish sets it when the context comes from
+	// Message.Context.
+	CausationID string
+
+	TenantID string
+
+	// SchemaVersion is the version of the payload schema, 0 when the
+	// payload is not versioned.
+	SchemaVersion int
+
+	// ContentType is the media type of Payload.
+	ContentType string
+
+	// ReplyTo is the topic replies to the message are published on.
+	ReplyTo string
+}
+
+// Headers returns the well-known headers of m. A schema version that is not
+// a number reads as 0.
+func (m *Message) Headers() MessageHeaders {
+	version, _ := strconv.Atoi(m.GetMetadata(SchemaVersionMetadata))
+	return MessageHeaders{
+		CorrelationID: m.GetMetadata(CorrelationIDMetadata),
+		CausationID:   m.GetMetadata(CausationIDMetadata),
+		TenantID:      m.GetMetadata(TenantMetadata),
+		SchemaVersion: version,
+		ContentType:   m.GetMetadata(ContentTypeMetadata),
+		ReplyTo:       m.GetMetadata(ReplyToMetadata),
+	}
+}
+
+// SetHeaders stores the non-zero fields of h in the metadata of m. Zero
+// fields keep the current value.
+func (m *Message) SetHeaders(h MessageHeaders) {
+	set := func(key, value string) {
+		if value != "" {
+			m.SetMetadata(key, value)
+		}
+	}
+	set(CorrelationIDMetadata, h.CorrelationID)
+	set(CausationIDMetadata, h.CausationID)
+	set(TenantMetadata, h.TenantID)
+	if h.SchemaVersion != 0 {
+		m.SetMetadata(SchemaVersionMetadata, strconv.Itoa(h.SchemaVersion))
+	}
+	set(ContentTypeMetadata, h.ContentType)
+	set(ReplyToMetadata, h.ReplyTo)
+}
+
+type causeKey struct{}
+
+// cause is what Message.Context attaches for the messages published while
+// handling a message.
+type cause struct {
+	messageID     string
+	correlationID string
+}
+
+// setCausation fills in the correlation and causation IDs of msg, which is
+// about to be published, unless they are set already.
+func setCausation(ctx context.Context, msg *Message) {
+	c, _ := ctx.Value(causeKey{}).(cause)
+	h := MessageHeaders{CausationID: c.messageID, CorrelationID: c.correlationID}
+	if h.CorrelationID == "" {
+		h.CorrelationID = logging.RequestID(ctx)
+	}
+	if h.CorrelationID == "" {
+		h.CorrelationID = msg.ID
+	}
+
+	current := msg.Headers()
+	if current.CorrelationID != "" {
+		h.CorrelationID = ""
+	}
+	if current.CausationID != "" {
+		h.CausationID = ""
+	}
+	msg.SetHeaders(h)
+}
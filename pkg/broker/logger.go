@@ -0,0 +1,63 @@
+package broker
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+)
+
+// Logger receives the logs of a broker and of its queues, topics and
+// workers. *slog.Logger implements it.
+type Logger interface {
+	DebugContext(ctx context.Context, msg string, args ...any)
+	InfoContext(ctx context.Context, msg string, args ...any)
+	WarnContext(ctx context.Context, msg string, args ...any)
+	ErrorContext(ctx context.Context, msg string, args ...any)
+}
+
+// NewSlogLogger returns a Logger writing the records of level and above to
+// l, even those the handler of l would filter out: a service logging at
+// info level still gets the broker's debug logs with level debug.
+func NewSlogLogger(l *slog.Logger, level slog.Leveler) Logger {
+	return slog.New(levelHandler{Handler: l.Handler(), level: level})
+}
+
+// newLogger returns the logger config asks for.
+func newLogger(config BrokerConfig) Logger {
+	switch {
+	case !config.EnableLogging:
+		return nopLogger{}
+	case config.Logger != nil:
+		return config.Logger
+	}
+	var level slog.Level // info unless LogLevel says otherwise
+	level.UnmarshalText([]byte(config.LogLevel))
+	return NewSlogLogger(logging.Component("broker"), level)
+}
+
+// levelHandler is a handler whose level replaces that of the handler it
+// wraps.
+type levelHandler struct {
+	slog.Handler
+	level slog.Leveler
+}
+
+func (h levelHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return levelHandler{Handler: h.Handler.WithAttrs(attrs), level: h.level}
+}
+
+func (h levelHandler) WithGroup(name string) slog.Handler {
+	return levelHandler{Handler: h.Handler.WithGroup(name), level: h.level}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) DebugContext(context.Context, string, ...any) {}
+func (nopLogger) InfoContext(context.Context, string, ...any)  {}
+func (nopLogger) WarnContext(context.Context, string, ...any)  {}
+func (nopLogger) ErrorContext(context.Context, string, ...any) {}
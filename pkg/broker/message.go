@@ -1,21 +1,75 @@
 package broker
 
 import (
+	"context"
 	"encoding/json"
 	"time"
 
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/reqmeta"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
 	"github.com/google/uuid"
 )
 
+// RequestIDMetadata is the metadata key carrying the ID of the request that
+// published a message. Topic.Publish sets it from the context.
+const RequestIDMetadata = "request_id"
+
+// TenantMetadata is the metadata key carrying the tenant a message belongs
+// to. Topic.Publish sets it from the context.
+const TenantMetadata = "tenant_id"
+
+// ActorMetadata is the metadata key carrying the user whose request
+// published a message. Topic.Publish sets it from the context, see
+// reqmeta.Actor; messages published outside of a request have none.
+const ActorMetadata = "actor"
+
+// Metadata set on messages moved to a dead-letter queue: the queue they
+// failed in, why they were moved and the error of the last failed attempt.
+const (
+	OriginalQueueMetadata = "original_queue"
+	FailureReasonMetadata = "failure_reason"
+	LastErrorMetadata     = "last_error"
+)
+
+// MaxRetriesExceeded is the FailureReasonMetadata of the messages
+// dead-lettered for reaching a limit of the redrive policy of their queue,
+// whether on a failed delivery or in place of the next one.
+const MaxRetriesExceeded = "max_retries_exceeded"
+
 type Message struct {
-	ID            string            `json:"id"`
-	Type          string            `json:"type"`
-	Payload       json.RawMessage   `json:"payload"`
-	Metadata      map[string]string `json:"metadata,omitempty"`
-	Timestamp     time.Time         `json:"timestamp"`
-	RetryCount    int               `json:"retry_count"`
-	VisibleAt     time.Time         `json:"-"`
-	ReceiptHandle string            `json:"-"`
+	ID       string            `json:"id"`
+	Type     string            `json:"type"`
+	Payload  json.RawMessage   `json:"payload"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Priority orders the messages of a queue: Receive returns the visible
+	// message of highest priority, the oldest one among equals. See
+	// WithPriorityAging for keeping low priorities from starving.
+	Priority int `json:"priority,omitempty"`
+
+	Timestamp time.Time `json:"timestamp"`
+
+	// RetryCount is the number of times the message was received, and
+	// FailureCount the number of those deliveries that were nacked. They
+	// differ by the deliveries whose visibility timeout lapsed.
+	RetryCount   int `json:"retry_count"`
+	FailureCount int `json:"failure_count,omitempty"`
+
+	// Sequence is the place of the message in the queue that delivers it:
+	// Enqueue numbers the messages a queue stores from 1, one more for
+	// each, and a redelivery keeps its number. A message copied to another
+	// queue, such as the subscriber queues of a topic or a dead-letter
+	// queue, is numbered by that queue. See SequenceTracker.
+	Sequence uint64 `json:"sequence,omitempty"`
+
+	VisibleAt     time.Time `json:"-"`
+	ReceiptHandle string    `json:"-"`
+
+	// receivedBy and receivedAt are the worker that received the delivery
+	// in flight, empty for Queue.Receive, and when.
+	receivedBy string
+	receivedAt time.Time
 }
 
 func NewMessage(messageType string, payload interface{}) (*Message, error) {
@@ -28,7 +82,7 @@ func NewMessage(messageType string, payload interface{}) (*Message, error) {
 		ID:        uuid.New().String(),
 		Type:      messageType,
 		Payload:   payloadBytes,
-		Metadata:  make(map[string]string),
+		Metadata:  map[string]string{ContentTypeMetadata: ContentTypeJSON},
 		Timestamp: time.Now(),
 	}, nil
 }
@@ -51,11 +105,32 @@ func (m *Message) GetMetadata(key string) string {
 	return m.Metadata[key]
 }
 
+// Context returns parent with the request ID, tenant, actor and message ID
+// of m attached, so handlers act for the tenant and user and log under the
+// request that published the message. Messages published with the context
+// are correlated with m and name it as their cause.
+func (m *Message) Context(parent context.Context) context.Context {
+	correlationID := m.GetMetadata(CorrelationIDMetadata)
+	if correlationID == "" {
+		correlationID = m.ID
+	}
+	ctx := context.WithValue(parent, causeKey{}, cause{messageID: m.ID, correlationID: correlationID})
+	ctx = logging.WithAttrs(ctx, "message_id", m.ID)
+	if id := m.GetMetadata(RequestIDMetadata); id != "" {
+		ctx = logging.WithRequestID(ctx, id)
+	}
+	if id := m.GetMetadata(TenantMetadata); id != "" {
+		ctx = tenant.NewContext(ctx, id)
+	}
+	return reqmeta.WithActor(ctx, m.GetMetadata(ActorMetadata))
+}
+
 func (m *Message) Clone() *Message {
 	clone := &Message{
 		ID:         uuid.New().String(),
 		Type:       m.Type,
 		Payload:    make(json.RawMessage, len(m.Payload)),
+		Priority:   m.Priority,
 		Timestamp:  m.Timestamp,
 		RetryCount: 0,
 	}
@@ -1,21 +1,58 @@
 package broker
 
 import (
+	"cmp"
 	"context"
+	"fmt"
+	"maps"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Queue keeps the messages waiting to be received apart from those in
+// flight, each under its own lock, so acknowledging a delivery does not
+// wait for publishers and consumers of the queue. When both locks are
+// held, mu is taken first.
 type Queue struct {
-	mu                sync.Mutex
-	name              string
-	messages          []*Message
+	mu    sync.Mutex
+	name  string
+	ready []*Message // waiting or delayed messages, in enqueue order
+	seq   uint64     // Sequence of the last message stored
+	// priorities counts the messages of ready by priority.
+	priorities map[int]int
+
+	flightMu sync.Mutex
+	inflight map[string]*Message // by receipt handle
+	// nextLapse is a time before which no visibility timeout of the
+	// messages in flight lapses, zero without any.
+	nextLapse time.Time
+	// expiredBy counts the lapsed deliveries of each worker.
+	expiredBy map[string]int64
+
 	visibilityTimeout time.Duration
-	maxRetries        int
+	redrive           RedrivePolicy
 	deadLetterQueue   *Queue
-	stats             QueueStats
+	enqueueFilter     EnqueueFilter
+	aging             PriorityAging
+	log               Logger
+	trace             *tracer
+	// onExpire is handed the deliveries whose visibility timeout lapsed,
+	// and onAck the messages acknowledged.
+	onExpire func([]VisibilityExpiry)
+	onAck    func(*Queue, *Message)
+
+	received  atomic.Int64
+	processed atomic.Int64
+	failed    atomic.Int64
+	expired   atomic.Int64
+
+	// changed is closed and replaced whenever a message may have become
+	// receivable, to wake ReceiveWait and idle workers.
+	changed chan struct{}
 }
 
 type QueueStats struct {
@@ -23,15 +60,27 @@ type QueueStats struct {
 	TotalProcessed int64
 	TotalFailed    int64
 	CurrentSize    int
+
+	// TotalExpired counts the deliveries whose visibility timeout lapsed
+	// before they were acknowledged or nacked.
+	TotalExpired int64
+
+	// OldestAge is the Age of the oldest message waiting, zero for an
+	// empty queue.
+	OldestAge time.Duration
 }
 
 func (q *Queue) Name() string {
 	return q.name
 }
 
+// Enqueue stores msg with the next Sequence of q, or returns ctx.Err()
+// without storing it when ctx is already done. Messages an enqueue filter
+// stores in place of msg are numbered in the order it returns them.
 func (q *Queue) Enqueue(ctx context.Context, msg *Message) error {
-	q.mu.Lock()
-	defer q.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	if msg.ID == "" {
 		msg.ID = uuid.New().String()
@@ -40,108 +89,392 @@ func (q *Queue) Enqueue(ctx context.Context, msg *Message) error {
 		msg.Timestamp = time.Now()
 	}
 
-	q.messages = append(q.messages, msg)
-	q.stats.TotalReceived++
-	q.stats.CurrentSize = len(q.messages)
+	stored := []*Message{msg}
+	if q.enqueueFilter != nil {
+		stored = q.enqueueFilter(msg)
+	}
+	for _, m := range stored {
+		q.trace.inherit(msg.ID, m.ID)
+		q.trace.record(m.ID, TraceEvent{Event: TraceEnqueued, Queue: q.name})
+	}
+	if len(stored) == 0 {
+		q.trace.record(msg.ID, TraceEvent{Event: TraceDropped, Queue: q.name})
+	}
+	q.received.Add(1)
+
+	q.mu.Lock()
+	for _, m := range stored {
+		q.seq++
+		m.Sequence = q.seq
+		q.ready = append(q.ready, m)
+		q.countLocked(m, 1)
+	}
+	if len(stored) > 0 {
+		q.notifyLocked()
+	}
+	q.mu.Unlock()
 
-	logDebug("Enqueued message '%s' to queue '%s'", msg.ID, q.name)
+	q.log.DebugContext(msg.Context(ctx), "enqueued message", "queue", q.name, "stored", len(stored))
 
 	return nil
 }
 
+// Receive hides the next visible message for the visibility timeout and
+// returns a copy of it with a new receipt handle. The handle acknowledges
+// or nacks that delivery only: it is rejected once the visibility timeout
+// lapses, and a later Receive of the message issues a handle of its own.
+// It returns nil when no message is visible, and ctx.Err() when ctx is
+// done.
+//
+// Among the visible messages of equal effective priority, Receive returns
+// the one of lowest Sequence, so a queue with a single consumer, no
+// priorities and no delays delivers its messages in enqueue order. A
+// message nacked or whose visibility timeout lapsed goes back to its place
+// and is received again before the messages enqueued after it, but only
+// once visible, so the messages received meanwhile overtake it. Messages
+// dead-lettered, discarded or taken out of the queue leave gaps in the
+// sequences delivered; sequences are never reused.
 func (q *Queue) Receive(ctx context.Context) (*Message, error) {
+	return q.receive(ctx, "")
+}
+
+// receive is Receive for consumer, which the trace of the message names.
+func (q *Queue) receive(ctx context.Context, consumer string) (*Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	handle := uuid.New().String()
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
-	now := time.Now()
-
-	for _, msg := range q.messages {
-		if msg.IsVisible() {
-			msg.VisibleAt = now.Add(q.visibilityTimeout)
-			msg.ReceiptHandle = uuid.New().String()
-			msg.RetryCount++
+	msg, _ := q.receiveLocked(ctx, consumer, handle)
+	return msg, nil
+}
 
-			logDebug("Received message '%s' from queue '%s' (retry %d)",
-				msg.ID, q.name, msg.RetryCount)
+// ReceiveWait is Receive waiting for a message to become visible, either
+// enqueued, nacked or back from an expired visibility timeout. It returns
+// ctx.Err() once ctx is done, so a consumer blocked on an empty queue stops
+// as soon as it is cancelled.
+func (q *Queue) ReceiveWait(ctx context.Context) (*Message, error) {
+	handle := uuid.New().String()
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 
+		q.mu.Lock()
+		msg, next := q.receiveLocked(ctx, "", handle)
+		changed := q.changedLocked()
+		q.mu.Unlock()
+		if msg != nil {
 			return msg, nil
 		}
-	}
 
-	return nil, nil
+		var visible <-chan time.Time
+		var timer *time.Timer
+		if !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			visible = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-changed:
+		case <-visible:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
 }
 
-func (q *Queue) Acknowledge(ctx context.Context, receiptHandle string) error {
+// Changed returns a channel closed the next time a message may become
+// receivable: when one is enqueued or nacked. Messages coming back from an
+// expired visibility timeout do not close it.
+func (q *Queue) Changed() <-chan struct{} {
 	q.mu.Lock()
 	defer q.mu.Unlock()
+	return q.changedLocked()
+}
 
-	for i, msg := range q.messages {
-		if msg.ReceiptHandle == receiptHandle {
-			q.messages = append(q.messages[:i], q.messages[i+1:]...)
-			q.stats.TotalProcessed++
-			q.stats.CurrentSize = len(q.messages)
+func (q *Queue) changedLocked() chan struct{} {
+	if q.changed == nil {
+		q.changed = make(chan struct{})
+	}
+	return q.changed
+}
 
-			logDebug("Acknowledged message with receipt '%s' from queue '%s'",
-				receiptHandle, q.name)
+func (q *Queue) notifyLocked() {
+	if q.changed != nil {
+		close(q.changed)
+		q.changed = nil
+	}
+}
 
-			return nil
+// receiveLocked receives the next visible message for consumer under
+// receipt handle. Without one, it returns when the first hidden message
+// becomes visible again, or the zero time. Messages received as many times
+// as the redrive policy allows are given up on instead.
+func (q *Queue) receiveLocked(ctx context.Context, consumer, handle string) (*Message, time.Time) {
+	now := time.Now()
+	nextLapse := q.reclaimLocked(now)
+
+	var next *Message
+	var best int
+	for {
+		i, p, nextVisible := q.nextLocked(now)
+		if i < 0 {
+			if nextVisible.IsZero() || (!nextLapse.IsZero() && nextLapse.Before(nextVisible)) {
+				nextVisible = nextLapse
+			}
+			return nil, nextVisible
 		}
+		next, best = q.ready[i], p
+		q.removeReadyLocked(i)
+		if !q.redrive.exhausted(next) {
+			break
+		}
+		q.moveToDeadLetterQueueLocked(next)
 	}
 
-	return ErrInvalidReceiptHandle
-}
+	next.VisibleAt = now.Add(q.visibilityTimeout)
+	next.ReceiptHandle = handle
+	next.RetryCount++
+	next.receivedBy, next.receivedAt = consumer, now
+	q.flightMu.Lock()
+	q.inflight[handle] = next
+	if q.nextLapse.IsZero() || next.VisibleAt.Before(q.nextLapse) {
+		q.nextLapse = next.VisibleAt
+	}
+	q.flightMu.Unlock()
+	q.trace.record(next.ID, TraceEvent{Event: TraceReceived, Queue: q.name, Worker: consumer, Retry: next.RetryCount})
 
-func (q *Queue) Nack(ctx context.Context, receiptHandle string) error {
-	q.mu.Lock()
-	defer q.mu.Unlock()
+	q.log.DebugContext(next.Context(ctx), "received message", "queue", q.name, "retry", next.RetryCount,
+		"priority", next.Priority, "effective_priority", best)
 
-	for _, msg := range q.messages {
-		if msg.ReceiptHandle == receiptHandle {
-			if msg.RetryCount >= q.maxRetries {
-				return q.moveToDeadLetterQueueLocked(msg)
+	delivery := *next
+	delivery.Metadata = maps.Clone(next.Metadata)
+	return &delivery, time.Time{}
+}
+
+// nextLocked returns the index in ready of the visible message of highest
+// effective priority at now, the oldest among equals, with its priority,
+// or -1 and when the first delayed message becomes visible. Without aging
+// the scan stops at the first visible message of the highest priority
+// waiting.
+func (q *Queue) nextLocked(now time.Time) (int, int, time.Time) {
+	top, bounded := 0, q.aging.Interval <= 0
+	if bounded {
+		first := true
+		for p := range q.priorities {
+			if first || p > top {
+				top, first = p, false
 			}
+		}
+	}
 
-			msg.VisibleAt = time.Time{}
-			msg.ReceiptHandle = ""
+	next, best := -1, 0
+	var nextVisible time.Time
+	for i, msg := range q.ready {
+		if !msg.VisibleAt.IsZero() && !now.After(msg.VisibleAt) {
+			if nextVisible.IsZero() || msg.VisibleAt.Before(nextVisible) {
+				nextVisible = msg.VisibleAt
+			}
+			continue
+		}
+		if p := q.effectivePriorityLocked(msg, now); next < 0 || p > best {
+			next, best = i, p
+			if bounded && p == top {
+				break
+			}
+		}
+	}
+	return next, best, nextVisible
+}
 
-			logDebug("Nacked message '%s' in queue '%s', will retry", msg.ID, q.name)
+// reclaimLocked moves the messages in flight whose visibility timeout
+// lapsed back to ready, reporting the expiries, and returns when the next
+// one lapses, or the zero time.
+func (q *Queue) reclaimLocked(now time.Time) time.Time {
+	q.flightMu.Lock()
+	defer q.flightMu.Unlock()
 
-			return nil
+	if q.nextLapse.IsZero() || now.Before(q.nextLapse) {
+		return q.nextLapse
+	}
+	q.nextLapse = time.Time{}
+	var expiries []VisibilityExpiry
+	for handle, msg := range q.inflight {
+		if now.Before(msg.VisibleAt) {
+			if q.nextLapse.IsZero() || msg.VisibleAt.Before(q.nextLapse) {
+				q.nextLapse = msg.VisibleAt
+			}
+			continue
 		}
+		// The message keeps its receipt handle, for deliveryLocked to
+		// report the lapse.
+		delete(q.inflight, handle)
+		q.insertReadyLocked(msg)
+		expiries = append(expiries, q.expireLocked(msg))
+	}
+	if len(expiries) > 0 && q.onExpire != nil {
+		go q.onExpire(expiries)
+	}
+	return q.nextLapse
+}
+
+// insertReadyLocked puts msg back into ready at its place in enqueue order.
+func (q *Queue) insertReadyLocked(msg *Message) {
+	i, _ := slices.BinarySearchFunc(q.ready, msg.Sequence, func(m *Message, seq uint64) int {
+		return cmp.Compare(m.Sequence, seq)
+	})
+	q.ready = slices.Insert(q.ready, i, msg)
+	q.countLocked(msg, 1)
+}
+
+func (q *Queue) removeReadyLocked(i int) {
+	q.countLocked(q.ready[i], -1)
+	if i == 0 {
+		q.ready[0] = nil
+		q.ready = q.ready[1:]
+		return
+	}
+	q.ready = slices.Delete(q.ready, i, i+1)
+}
+
+func (q *Queue) countLocked(msg *Message, delta int) {
+	if q.priorities == nil {
+		q.priorities = make(map[int]int)
+	}
+	q.priorities[msg.Priority] += delta
+	if q.priorities[msg.Priority] <= 0 {
+		delete(q.priorities, msg.Priority)
+	}
+}
+
+// takeDelivery removes and returns the message in flight under
+// receiptHandle. It returns ErrInvalidReceiptHandle when the message was
+// received again since, is gone, or its visibility timeout lapsed.
+func (q *Queue) takeDelivery(receiptHandle string) (*Message, error) {
+	if receiptHandle == "" {
+		return nil, ErrInvalidReceiptHandle
+	}
+
+	q.flightMu.Lock()
+	msg, ok := q.inflight[receiptHandle]
+	if ok && time.Now().Before(msg.VisibleAt) {
+		delete(q.inflight, receiptHandle)
+		q.flightMu.Unlock()
+		return msg, nil
 	}
+	q.flightMu.Unlock()
 
+	if ok {
+		return nil, lapsedError(msg)
+	}
+	return nil, ErrInvalidReceiptHandle
+}
+
+// lapsedDeliveryLocked tells a handle whose message was reclaimed from one
+// that was never issued or was received again since.
+func (q *Queue) lapsedDeliveryLocked(receiptHandle string) error {
+	for _, msg := range q.ready {
+		if msg.ReceiptHandle == receiptHandle {
+			return lapsedError(msg)
+		}
+	}
 	return ErrInvalidReceiptHandle
 }
 
+func lapsedError(msg *Message) error {
+	late := time.Since(msg.VisibleAt)
+	return fmt.Errorf("%w: visibility timeout lapsed %s ago", ErrInvalidReceiptHandle, late.Round(time.Millisecond))
+}
+
+// effectivePriorityLocked returns the priority of msg at now, aged with the
+// policy of q.
+func (q *Queue) effectivePriorityLocked(msg *Message, now time.Time) int {
+	return msg.Priority + q.aging.boost(now.Sub(msg.Timestamp))
+}
+
+func (q *Queue) Acknowledge(ctx context.Context, receiptHandle string) error {
+	msg, err := q.takeDelivery(receiptHandle)
+	if err == ErrInvalidReceiptHandle && receiptHandle != "" {
+		q.mu.Lock()
+		err = q.lapsedDeliveryLocked(receiptHandle)
+		q.mu.Unlock()
+	}
+	if err != nil {
+		return err
+	}
+	q.processed.Add(1)
+	q.trace.record(msg.ID, TraceEvent{Event: TraceAcknowledged, Queue: q.name})
+	if q.onAck != nil {
+		q.onAck(q, msg)
+	}
+
+	q.log.DebugContext(msg.Context(ctx), "acknowledged message", "queue", q.name)
+
+	return nil
+}
+
+func (q *Queue) Nack(ctx context.Context, receiptHandle string) error {
+	return q.Fail(ctx, receiptHandle, nil)
+}
+
+// Fail is Nack for a message whose handler returned cause. The cause is kept
+// in LastErrorMetadata, so a dead-lettered message shows why it failed.
+func (q *Queue) Fail(ctx context.Context, receiptHandle string, cause error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	msg, err := q.takeDelivery(receiptHandle)
+	if err == ErrInvalidReceiptHandle && receiptHandle != "" {
+		err = q.lapsedDeliveryLocked(receiptHandle)
+	}
+	if err != nil {
+		return err
+	}
+	event := TraceEvent{Event: TraceNacked, Queue: q.name, Retry: msg.RetryCount}
+	if cause != nil {
+		msg.SetMetadata(LastErrorMetadata, cause.Error())
+		event.Error = cause.Error()
+	}
+	q.trace.record(msg.ID, event)
+	msg.FailureCount++
+	if q.redrive.exhausted(msg) {
+		return q.moveToDeadLetterQueueLocked(msg)
+	}
+
+	msg.VisibleAt = time.Time{}
+	msg.ReceiptHandle = ""
+	q.insertReadyLocked(msg)
+	q.notifyLocked()
+
+	q.log.DebugContext(msg.Context(ctx), "nacked message, will retry", "queue", q.name)
+
+	return nil
+}
+
+// moveToDeadLetterQueueLocked gives up on msg, which was taken out of the
+// queue already as it exhausted the redrive policy. The dead-lettered copy
+// carries MaxRetriesExceeded in FailureReasonMetadata.
 func (q *Queue) moveToDeadLetterQueueLocked(msg *Message) error {
+	q.failed.Add(1)
 	if q.deadLetterQueue == nil {
-		q.stats.TotalFailed++
-		for i, m := range q.messages {
-			if m.ID == msg.ID {
-				q.messages = append(q.messages[:i], q.messages[i+1:]...)
-				q.stats.CurrentSize = len(q.messages)
-				break
-			}
-		}
-		logError("Message '%s' exceeded max retries, no DLQ configured, discarding", msg.ID)
+		q.trace.record(msg.ID, TraceEvent{Event: TraceDiscarded, Queue: q.name})
+		q.log.ErrorContext(msg.Context(context.Background()), "message exceeded its redrive policy, no DLQ configured, discarding",
+			"queue", q.name, "reason", MaxRetriesExceeded)
 		return nil
 	}
 
 	dlqMsg := msg.Clone()
-	dlqMsg.SetMetadata("original_queue", q.name)
-	dlqMsg.SetMetadata("failure_reason", "max_retries_exceeded")
+	dlqMsg.SetMetadata(OriginalQueueMetadata, q.name)
+	dlqMsg.SetMetadata(FailureReasonMetadata, MaxRetriesExceeded)
 	dlqMsg.ReceiptHandle = ""
 	dlqMsg.VisibleAt = time.Time{}
-
-	for i, m := range q.messages {
-		if m.ID == msg.ID {
-			q.messages = append(q.messages[:i], q.messages[i+1:]...)
-			q.stats.CurrentSize = len(q.messages)
-			break
-		}
-	}
-
-	q.stats.TotalFailed++
+	q.trace.record(msg.ID, TraceEvent{Event: TraceDeadLettered, Queue: q.name})
+	q.trace.inherit(msg.ID, dlqMsg.ID)
 
 	go func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -149,21 +482,102 @@ func (q *Queue) moveToDeadLetterQueueLocked(msg *Message) error {
 		q.deadLetterQueue.Enqueue(ctx, dlqMsg)
 	}()
 
-	logInfo("Message '%s' moved to DLQ '%s' after %d retries",
-		msg.ID, q.deadLetterQueue.name, msg.RetryCount)
+	q.log.InfoContext(msg.Context(context.Background()), "message moved to DLQ",
+		"queue", q.name, "dlq", q.deadLetterQueue.name, "reason", MaxRetriesExceeded, "retries", msg.RetryCount, "failures", msg.FailureCount)
 
 	return nil
 }
 
+// DeadLetterQueue returns the queue that receives the messages of q that
+// exceeded their retries, or nil.
+func (q *Queue) DeadLetterQueue() *Queue {
+	return q.deadLetterQueue
+}
+
+// allLocked returns the messages of q, waiting or in flight, oldest first.
+func (q *Queue) allLocked() []*Message {
+	q.flightMu.Lock()
+	all := make([]*Message, 0, len(q.ready)+len(q.inflight))
+	all = append(all, q.ready...)
+	for _, msg := range q.inflight {
+		all = append(all, msg)
+	}
+	q.flightMu.Unlock()
+	slices.SortFunc(all, func(a, b *Message) int { return cmp.Compare(a.Sequence, b.Sequence) })
+	return all
+}
+
+// Peek returns copies of up to limit messages of q, oldest first, without
+// receiving them; 0 returns every message.
+func (q *Queue) Peek(limit int) []*Message {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	all := q.allLocked()
+	n := len(all)
+	if limit > 0 {
+		n = min(n, limit)
+	}
+	out := make([]*Message, 0, n)
+	for _, msg := range all[:n] {
+		c := msg.Clone()
+		c.ID = msg.ID
+		c.Sequence = msg.Sequence
+		c.RetryCount = msg.RetryCount
+		c.FailureCount = msg.FailureCount
+		out = append(out, c)
+	}
+	return out
+}
+
+// take removes up to limit visible messages accepted by keep, oldest first;
+// 0 removes every such message.
+func (q *Queue) take(limit int, keep func(*Message) bool) []*Message {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.reclaimLocked(time.Now())
+	var taken []*Message
+	remaining := q.ready[:0]
+	for _, msg := range q.ready {
+		if (limit == 0 || len(taken) < limit) && msg.IsVisible() && keep(msg) {
+			taken = append(taken, msg)
+			q.countLocked(msg, -1)
+			continue
+		}
+		remaining = append(remaining, msg)
+	}
+	clear(q.ready[len(remaining):])
+	q.ready = remaining
+	return taken
+}
+
 func (q *Queue) Stats() QueueStats {
 	q.mu.Lock()
 	defer q.mu.Unlock()
-	q.stats.CurrentSize = len(q.messages)
-	return q.stats
+
+	stats := QueueStats{
+		TotalReceived:  q.received.Load(),
+		TotalProcessed: q.processed.Load(),
+		TotalFailed:    q.failed.Load(),
+		TotalExpired:   q.expired.Load(),
+	}
+	q.flightMu.Lock()
+	defer q.flightMu.Unlock()
+	stats.CurrentSize = len(q.ready) + len(q.inflight)
+	for _, msg := range q.ready {
+		stats.OldestAge = max(stats.OldestAge, msg.Age())
+	}
+	for _, msg := range q.inflight {
+		stats.OldestAge = max(stats.OldestAge, msg.Age())
+	}
+	return stats
 }
 
 func (q *Queue) Size() int {
 	q.mu.Lock()
 	defer q.mu.Unlock()
-	return len(q.messages)
+	q.flightMu.Lock()
+	defer q.flightMu.Unlock()
+	return len(q.ready) + len(q.inflight)
 }
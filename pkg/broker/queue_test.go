@@ -0,0 +1,332 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestQueue returns a queue of a broker that does not log, without a
+// redrive limit unless opts set one.
+func newTestQueue(t testing.TB, visibilityTimeout time.Duration, opts ...QueueOption) *Queue {
+	t.Helper()
+	b := NewBroker(BrokerConfig{DefaultVisibilityTimeout: visibilityTimeout})
+	return b.CreateQueue(t.Name(), opts...)
+}
+
+// enqueueTest enqueues a message of priority on q and returns it.
+func enqueueTest(t testing.TB, q *Queue, priority int) *Message {
+	t.Helper()
+	msg, err := NewMessage("test", priority)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.Priority = priority
+	if err := q.Enqueue(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+// mustReceive receives the next message of q, failing when there is none.
+func mustReceive(t testing.TB, q *Queue) *Message {
+	t.Helper()
+	msg, err := q.Receive(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg == nil {
+		t.Fatal("Receive returned no message")
+	}
+	return msg
+}
+
+func TestAcknowledgeAfterVisibilityTimeout(t *testing.T) {
+	ctx := context.Background()
+	q := newTestQueue(t, 20*time.Millisecond)
+	first := enqueueTest(t, q, 0)
+
+	// Lapsed while still in flight, before anything reclaimed it.
+	delivery := mustReceive(t, q)
+	time.Sleep(40 * time.Millisecond)
+	err := q.Acknowledge(ctx, delivery.ReceiptHandle)
+	if !errors.Is(err, ErrInvalidReceiptHandle) || !strings.Contains(err.Error(), "lapsed") {
+		t.Fatalf("Acknowledge after the timeout = %v, want a lapsed ErrInvalidReceiptHandle", err)
+	}
+
+	// Lapsed and reclaimed by a Receive that returned another message.
+	enqueueTest(t, q, 1)
+	if other := mustReceive(t, q); other.ID == first.ID {
+		t.Fatal("Receive returned the lapsed message before the one of higher priority")
+	}
+	err = q.Acknowledge(ctx, delivery.ReceiptHandle)
+	if !errors.Is(err, ErrInvalidReceiptHandle) || !strings.Contains(err.Error(), "lapsed") {
+		t.Fatalf("Acknowledge after the reclaim = %v, want a lapsed ErrInvalidReceiptHandle", err)
+	}
+	if err := q.Nack(ctx, delivery.ReceiptHandle); !errors.Is(err, ErrInvalidReceiptHandle) {
+		t.Fatalf("Nack after the reclaim = %v, want ErrInvalidReceiptHandle", err)
+	}
+
+	// The rejected acknowledgement left the message to be delivered again.
+	again := mustReceive(t, q)
+	if again.ID != first.ID || again.RetryCount != 2 {
+		t.Fatalf("redelivered %s with RetryCount %d, want %s with 2", again.ID, again.RetryCount, first.ID)
+	}
+	if err := q.Acknowledge(ctx, again.ReceiptHandle); err != nil {
+		t.Fatalf("Acknowledge of the redelivery: %v", err)
+	}
+	if stats := q.Stats(); stats.TotalProcessed != 1 || stats.TotalExpired != 1 {
+		t.Fatalf("TotalProcessed %d and TotalExpired %d, want 1 and 1", stats.TotalProcessed, stats.TotalExpired)
+	}
+}
+
+func TestAcknowledgeSupersededReceiptHandle(t *testing.T) {
+	ctx := context.Background()
+	q := newTestQueue(t, 20*time.Millisecond)
+	enqueueTest(t, q, 0)
+
+	stale := mustReceive(t, q)
+	time.Sleep(40 * time.Millisecond)
+	current := mustReceive(t, q)
+	if current.ID != stale.ID || current.ReceiptHandle == stale.ReceiptHandle {
+		t.Fatalf("second delivery %s under %q, want %s under a new handle", current.ID, current.ReceiptHandle, stale.ID)
+	}
+
+	// The old handle names no delivery any more, rather than a lapsed one.
+	for name, settle := range map[string]func(context.Context, string) error{
+		"Acknowledge": q.Acknowledge,
+		"Nack":        q.Nack,
+	} {
+		err := settle(ctx, stale.ReceiptHandle)
+		if err != ErrInvalidReceiptHandle {
+			t.Fatalf("%s with the superseded handle = %v, want ErrInvalidReceiptHandle", name, err)
+		}
+	}
+	if size := q.Size(); size != 1 {
+		t.Fatalf("Size = %d after the rejected handles, want 1", size)
+	}
+
+	if err := q.Acknowledge(ctx, current.ReceiptHandle); err != nil {
+		t.Fatalf("Acknowledge with the current handle: %v", err)
+	}
+	if size := q.Size(); size != 0 {
+		t.Fatalf("Size = %d after the acknowledgement, want 0", size)
+	}
+}
+
+// TestAcknowledgeRacesReclaim has consumers acknowledge deliveries around
+// their visibility timeout while Receive reclaims the lapsed ones, and checks
+// every message is acknowledged exactly once and never delivered after.
+// Run it with -race.
+func TestAcknowledgeRacesReclaim(t *testing.T) {
+	const (
+		messages  = 200
+		consumers = 8
+	)
+	ctx := context.Background()
+	q := newTestQueue(t, 2*time.Millisecond)
+	for range messages {
+		enqueueTest(t, q, 0)
+	}
+
+	var (
+		mu       sync.Mutex
+		acked    = make(map[string]bool)
+		failures []string
+	)
+	deadline := time.Now().Add(10 * time.Second)
+	var wg sync.WaitGroup
+	for range consumers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				mu.Lock()
+				done := len(acked) == messages
+				mu.Unlock()
+				if done {
+					return
+				}
+
+				msg, err := q.Receive(ctx)
+				if err != nil || msg == nil {
+					time.Sleep(time.Millisecond)
+					continue
+				}
+				mu.Lock()
+				if acked[msg.ID] {
+					failures = append(failures, "delivered "+msg.ID+" after it was acknowledged")
+				}
+				mu.Unlock()
+
+				// Settle before, around or after the visibility timeout,
+				// nacking some deliveries to put them back early.
+				time.Sleep(time.Duration(rand.IntN(4000)) * time.Microsecond)
+				ack := rand.IntN(4) > 0
+				var settle error
+				if ack {
+					settle = q.Acknowledge(ctx, msg.ReceiptHandle)
+				} else {
+					settle = q.Nack(ctx, msg.ReceiptHandle)
+				}
+
+				mu.Lock()
+				if ack && settle == nil && acked[msg.ID] {
+					failures = append(failures, "acknowledged "+msg.ID+" twice")
+				}
+				if settle != nil && !errors.Is(settle, ErrInvalidReceiptHandle) {
+					failures = append(failures, settle.Error())
+				}
+				if ack && settle == nil {
+					acked[msg.ID] = true
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, f := range failures {
+		t.Error(f)
+	}
+	if len(acked) != messages {
+		t.Fatalf("%d of %d messages acknowledged", len(acked), messages)
+	}
+	stats := q.Stats()
+	if stats.TotalProcessed != messages || stats.CurrentSize != 0 {
+		t.Fatalf("TotalProcessed %d and CurrentSize %d, want %d and 0", stats.TotalProcessed, stats.CurrentSize, messages)
+	}
+	if stats.TotalExpired == 0 {
+		t.Fatal("no visibility timeout lapsed, nothing raced the acknowledgements")
+	}
+}
+
+func TestPriorityAgingPreventsStarvation(t *testing.T) {
+	ctx := context.Background()
+	q := newTestQueue(t, time.Minute, WithPriorityAging(PriorityAging{Interval: 20 * time.Millisecond}))
+	low := enqueueTest(t, q, 0)
+
+	// A steady stream of priority 2 messages, one enqueued for every
+	// receive, which would starve the low priority message without aging.
+	for i := 0; ; i++ {
+		if i == 100 {
+			t.Fatal("the low priority message was never received")
+		}
+		enqueueTest(t, q, 2)
+		msg := mustReceive(t, q)
+		if err := q.Acknowledge(ctx, msg.ReceiptHandle); err != nil {
+			t.Fatal(err)
+		}
+		if msg.ID == low.ID {
+			// Two intervals raise it to the priority of the stream, and
+			// it is the oldest message of that priority.
+			if age := time.Since(low.Timestamp); age < 40*time.Millisecond {
+				t.Fatalf("received after %s, before it aged two intervals", age)
+			}
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPriorityAgingMaxBoost(t *testing.T) {
+	ctx := context.Background()
+	aging := PriorityAging{Interval: time.Millisecond, MaxBoost: 2}
+	q := newTestQueue(t, time.Minute, WithPriorityAging(aging))
+
+	// Aged far beyond MaxBoost intervals.
+	old, err := NewMessage("test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old.Timestamp = time.Now().Add(-time.Hour)
+	if err := q.Enqueue(ctx, old); err != nil {
+		t.Fatal(err)
+	}
+
+	// Capped at 0+2, it never overtakes a stream of priority 3.
+	for range 20 {
+		enqueueTest(t, q, 3)
+		msg := mustReceive(t, q)
+		if msg.ID == old.ID {
+			t.Fatal("the aged message overtook a priority above its MaxBoost")
+		}
+		if err := q.Acknowledge(ctx, msg.ReceiptHandle); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// It does overtake a stream of priority 2, being older.
+	enqueueTest(t, q, 2)
+	if msg := mustReceive(t, q); msg.ID != old.ID {
+		t.Fatalf("received priority %d, want the aged message tied at 2", msg.Priority)
+	}
+
+	for _, tc := range []struct {
+		age  time.Duration
+		want int
+	}{
+		{age: 0, want: 0},
+		{age: time.Millisecond, want: 1},
+		{age: 2 * time.Millisecond, want: 2},
+		{age: time.Hour, want: 2},
+	} {
+		if got := aging.boost(tc.age); got != tc.want {
+			t.Errorf("boost(%s) = %d, want %d", tc.age, got, tc.want)
+		}
+	}
+}
+
+func TestRedrivePolicyDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	dlq := newTestQueue(t, time.Minute)
+	q := newTestQueue(t, 20*time.Millisecond,
+		WithRedrivePolicy(RedrivePolicy{MaxFailures: 2, MaxReceives: 3}), WithDLQ(dlq))
+
+	// Failed twice: dead-lettered on the second failure.
+	enqueueTest(t, q, 0)
+	for range 2 {
+		msg := mustReceive(t, q)
+		if err := q.Fail(ctx, msg.ReceiptHandle, errors.New("boom")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Lost to a lapse three times: dead-lettered in place of a fourth
+	// delivery.
+	enqueueTest(t, q, 0)
+	for range 3 {
+		mustReceive(t, q)
+		time.Sleep(40 * time.Millisecond)
+	}
+	if msg, err := q.Receive(ctx); err != nil || msg != nil {
+		t.Fatalf("Receive = %v, %v, want no message", msg, err)
+	}
+
+	// The dead-letter queue is filled in the background.
+	deadline := time.Now().Add(time.Second)
+	for dlq.Size() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	dead := dlq.Peek(0)
+	if len(dead) != 2 {
+		t.Fatalf("%d dead letters, want 2", len(dead))
+	}
+	// Both are given up on for the same reason, the failed one keeping
+	// the error of its handler.
+	for i, want := range []string{"boom", ""} {
+		if reason := dead[i].GetMetadata(FailureReasonMetadata); reason != MaxRetriesExceeded {
+			t.Errorf("dead letter %d failure reason %q, want %q", i, reason, MaxRetriesExceeded)
+		}
+		if lastErr := dead[i].GetMetadata(LastErrorMetadata); lastErr != want {
+			t.Errorf("dead letter %d last error %q, want %q", i, lastErr, want)
+		}
+	}
+	if stats := q.Stats(); stats.TotalFailed != 2 || stats.CurrentSize != 0 {
+		t.Fatalf("TotalFailed %d and CurrentSize %d, want 2 and 0", stats.TotalFailed, stats.CurrentSize)
+	}
+}
@@ -0,0 +1,90 @@
+package broker
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WorkerRegistry keeps the workers of a service by name, to check and
+// report on them together.
+type WorkerRegistry struct {
+	mu      sync.RWMutex
+	workers map[string]*Worker
+}
+
+func NewWorkerRegistry() *WorkerRegistry {
+	return &WorkerRegistry{workers: make(map[string]*Worker)}
+}
+
+// Register adds w, replacing a worker registered under the same name.
+func (r *WorkerRegistry) Register(w *Worker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.workers[w.name] = w
+}
+
+// Workers returns the registered workers by name.
+func (r *WorkerRegistry) Workers() map[string]*Worker {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	workers := make(map[string]*Worker, len(r.workers))
+	for name, w := range r.workers {
+		workers[name] = w
+	}
+	return workers
+}
+
+// WorkerInfo reports on a worker: what it consumes, whether it runs and
+// its totals.
+type WorkerInfo struct {
+	Name         string  `json:"name"`
+	Queue        string  `json:"queue"`
+	Concurrency  int     `json:"concurrency"`
+	Running      bool    `json:"running"`
+	Processed    int64   `json:"processed"`
+	Failed       int64   `json:"failed"`
+	Panicked     int64   `json:"panicked"`
+	Expired      int64   `json:"expired"`
+	AvgLatencyMS float64 `json:"avg_latency_ms"`
+
+	LastProcessedAt *time.Time `json:"last_processed_at,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+	LastErrorAt     *time.Time `json:"last_error_at,omitempty"`
+}
+
+// Info reports on every registered worker, ordered by name.
+func (r *WorkerRegistry) Info() []WorkerInfo {
+	workers := r.Workers()
+	infos := make([]WorkerInfo, 0, len(workers))
+	for _, w := range workers {
+		infos = append(infos, w.Info())
+	}
+	slices.SortFunc(infos, func(a, b WorkerInfo) int { return strings.Compare(a.Name, b.Name) })
+	return infos
+}
+
+// Info reports on w.
+func (w *Worker) Info() WorkerInfo {
+	stats := w.Stats()
+	info := WorkerInfo{
+		Name:         w.name,
+		Queue:        w.queue.name,
+		Concurrency:  w.config.Concurrency,
+		Running:      w.Running(),
+		Processed:    stats.MessagesProcessed,
+		Failed:       stats.MessagesFailed,
+		Panicked:     stats.MessagesPanicked,
+		Expired:      stats.MessagesExpired,
+		AvgLatencyMS: float64(stats.AverageProcessTime().Microseconds()) / 1000,
+		LastError:    stats.LastError,
+	}
+	if !stats.LastProcessedAt.IsZero() {
+		info.LastProcessedAt = &stats.LastProcessedAt
+	}
+	if !stats.LastErrorAt.IsZero() {
+		info.LastErrorAt = &stats.LastErrorAt
+	}
+	return info
+}
@@ -0,0 +1,211 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// TopicOffsetMetadata is the metadata key of the offset of a message in the
+// log of a topic with retention, set on every delivery of it.
+const TopicOffsetMetadata = "topic_offset"
+
+// retainedMessage is a message kept in the log of a topic.
+type retainedMessage struct {
+	offset      int64
+	msg         *Message
+	publishedAt time.Time
+}
+
+// WithRetention makes the topic keep the messages published to it for
+// maxAge, and at most maxMessages of them; zero leaves that bound off. Each
+// gets an offset, counting the publishes of the topic from 0, which its
+// deliveries carry in TopicOffsetMetadata. The topic tracks the offset its
+// subscribers acknowledged up to, and Seek and SeekTime deliver the
+// retained messages again to one of them, so a consumer rebuilding its
+// state can replay the topic or resume from where it stopped.
+func WithRetention(maxAge time.Duration, maxMessages int) TopicOption {
+	return func(t *Topic) {
+		t.retention = maxAge
+		t.retainMessages = maxMessages
+	}
+}
+
+func (t *Topic) retains() bool {
+	return t.retention > 0 || t.retainMessages > 0
+}
+
+// retain adds msg to the log of t and returns its offset, or -1 when t
+// keeps no log.
+func (t *Topic) retain(msg *Message) int64 {
+	if !t.retains() {
+		return -1
+	}
+
+	kept := msg.Clone()
+	kept.ID = msg.ID
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	offset := t.nextOffset
+	t.nextOffset++
+	t.history = append(t.history, retainedMessage{offset: offset, msg: kept, publishedAt: now})
+	t.trimLocked(now)
+	return offset
+}
+
+// trimLocked drops the messages of the log past the retention of t.
+func (t *Topic) trimLocked(now time.Time) {
+	drop := 0
+	for _, r := range t.history {
+		over := t.retainMessages > 0 && len(t.history)-drop > t.retainMessages
+		old := t.retention > 0 && now.Sub(r.publishedAt) > t.retention
+		if !over && !old {
+			break
+		}
+		t.trimmedAt = r.publishedAt
+		drop++
+	}
+	clear(t.history[:drop])
+	t.history = t.history[drop:]
+}
+
+// firstOffsetLocked is the offset of the oldest message retained, or the
+// next offset when none is.
+func (t *Topic) firstOffsetLocked() int64 {
+	return t.nextOffset - int64(len(t.history))
+}
+
+// TopicOffsets are the offsets of a topic with retention.
+type TopicOffsets struct {
+	// First is the offset of the oldest message retained and Next the
+	// offset the next publish gets; First equals Next when none is.
+	First int64
+	Next  int64
+
+	// Queues maps each subscriber that acknowledged a message of the topic,
+	// or was sought, to the offset after the highest it acknowledged: the
+	// offset to resume from. Deliveries acknowledged out of order can move
+	// it past messages still waiting in the queue.
+	Queues map[string]int64
+}
+
+// Offsets returns the offsets of t.
+func (t *Topic) Offsets() TopicOffsets {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.trimLocked(time.Now())
+	queues := make(map[string]int64, len(t.committed))
+	for name, offset := range t.committed {
+		queues[name] = offset
+	}
+	return TopicOffsets{First: t.firstOffsetLocked(), Next: t.nextOffset, Queues: queues}
+}
+
+// commit records that queue acknowledged the message of t at offset.
+func (t *Topic) commit(queue string, offset int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, q := range t.subscribers {
+		if q.name != queue {
+			continue
+		}
+		if t.committed == nil {
+			t.committed = make(map[string]int64)
+		}
+		t.committed[queue] = max(t.committed[queue], offset+1)
+		return
+	}
+}
+
+// Seek delivers the retained messages of t from offset on to its subscriber
+// queue again, oldest first, and makes offset the one queue resumes from.
+// It returns the number of messages delivered. It fails with
+// ErrOffsetOutOfRange when offset is past the next one or messages from
+// offset on were dropped by the retention, so a consumer never skips events
+// unknowingly, and with ErrNoRetention when t keeps no log.
+func (t *Topic) Seek(ctx context.Context, queue string, offset int64) (int, error) {
+	return t.seek(ctx, queue, func(history []retainedMessage, first int64) (int64, error) {
+		if offset < first || offset > t.nextOffset {
+			return 0, fmt.Errorf("%w: offset %d is not between %d and %d", ErrOffsetOutOfRange, offset, first, t.nextOffset)
+		}
+		return offset, nil
+	})
+}
+
+// SeekTime is Seek from the oldest message published to t at or after at.
+// It fails with ErrOffsetOutOfRange when a message published since at was
+// dropped by the retention.
+func (t *Topic) SeekTime(ctx context.Context, queue string, at time.Time) (int, error) {
+	return t.seek(ctx, queue, func(history []retainedMessage, first int64) (int64, error) {
+		if !t.trimmedAt.IsZero() && !at.After(t.trimmedAt) {
+			return 0, fmt.Errorf("%w: messages published since %s are no longer retained", ErrOffsetOutOfRange, at.Format(time.RFC3339))
+		}
+		for _, r := range history {
+			if !r.publishedAt.Before(at) {
+				return r.offset, nil
+			}
+		}
+		return t.nextOffset, nil
+	})
+}
+
+// seek delivers again to queue the retained messages from the offset find
+// returns, which is called with t.mu held.
+func (t *Topic) seek(ctx context.Context, queue string, find func(history []retainedMessage, first int64) (int64, error)) (int, error) {
+	if !t.retains() {
+		return 0, ErrNoRetention
+	}
+
+	t.mu.Lock()
+	var subscriber *Queue
+	for _, q := range t.subscribers {
+		if q.name == queue {
+			subscriber = q
+		}
+	}
+	if subscriber == nil {
+		t.mu.Unlock()
+		return 0, fmt.Errorf("%w: %s is not subscribed to %s", ErrQueueNotFound, queue, t.name)
+	}
+
+	t.trimLocked(time.Now())
+	first := t.firstOffsetLocked()
+	offset, err := find(t.history, first)
+	if err != nil {
+		t.mu.Unlock()
+		return 0, err
+	}
+	replay := make([]retainedMessage, len(t.history[offset-first:]))
+	copy(replay, t.history[offset-first:])
+	if t.committed == nil {
+		t.committed = make(map[string]int64)
+	}
+	t.committed[queue] = offset
+	t.mu.Unlock()
+
+	deliverCtx := context.WithoutCancel(ctx)
+	for _, r := range replay {
+		t.deliver(deliverCtx, r.msg, subscriber, r.offset)
+	}
+	t.log.InfoContext(ctx, "topic sought", "topic", t.name, "queue", queue, "offset", offset, "replayed", len(replay))
+
+	return len(replay), nil
+}
+
+// acknowledged commits the offset of msg, acknowledged on q, on the topic it
+// was delivered from.
+func (b *Broker) acknowledged(q *Queue, msg *Message) {
+	offset, err := strconv.ParseInt(msg.GetMetadata(TopicOffsetMetadata), 10, 64)
+	if err != nil {
+		return
+	}
+	if topic, ok := b.GetTopic(msg.GetMetadata("source_topic")); ok {
+		topic.commit(q.name, offset)
+	}
+}
@@ -0,0 +1,78 @@
+package broker
+
+import "context"
+
+// Scrubber rewrites the payload and metadata of a message in place, such
+// as to erase the personal data of a customer, and reports whether it
+// changed anything.
+type Scrubber func(msg *Message) bool
+
+// ScrubReport counts the messages a scrub changed, by dead-letter queue and
+// by topic.
+type ScrubReport struct {
+	DeadLetters map[string]int `json:"dead_letters"`
+	Retained    map[string]int `json:"retained"`
+}
+
+// Scrub hands scrub the messages waiting in the dead-letter queues of b and
+// those retained by its topics, the copies of messages the broker keeps
+// once they were handled or given up on. Messages in flight are skipped:
+// the worker handling them reads them unlocked.
+func (b *Broker) Scrub(ctx context.Context, scrub Scrubber) ScrubReport {
+	b.mu.RLock()
+	dlqs := make(map[*Queue]bool)
+	for _, q := range b.queues {
+		if q.deadLetterQueue != nil {
+			dlqs[q.deadLetterQueue] = true
+		}
+	}
+	topics := make([]*Topic, 0, len(b.topics))
+	for _, t := range b.topics {
+		topics = append(topics, t)
+	}
+	b.mu.RUnlock()
+
+	report := ScrubReport{DeadLetters: make(map[string]int), Retained: make(map[string]int)}
+	for q := range dlqs {
+		if n := q.scrub(scrub); n > 0 {
+			report.DeadLetters[q.name] = n
+		}
+	}
+	for _, t := range topics {
+		if n := t.scrub(scrub); n > 0 {
+			report.Retained[t.name] = n
+		}
+	}
+	b.log.InfoContext(ctx, "messages scrubbed", "dead_letters", report.DeadLetters, "retained", report.Retained)
+	return report
+}
+
+// scrub hands scrub the messages waiting in q and returns the number it
+// changed.
+func (q *Queue) scrub(scrub Scrubber) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n := 0
+	for _, msg := range q.ready {
+		if scrub(msg) {
+			n++
+		}
+	}
+	return n
+}
+
+// scrub hands scrub the messages retained by t and returns the number it
+// changed.
+func (t *Topic) scrub(scrub Scrubber) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := 0
+	for _, r := range t.history {
+		if scrub(r.msg) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,52 @@
+package broker
+
+import "sync"
+
+// SequenceCheck is what a SequenceTracker found about a delivery.
+type SequenceCheck struct {
+	// Skipped counts the sequences between the highest one seen before and
+	// that of the delivery: messages not delivered yet, still hidden by a
+	// retry or a delay, or gone to a dead-letter queue.
+	Skipped uint64
+
+	// Late reports a delivery below the highest sequence seen, a message
+	// redelivered or overtaken by newer ones.
+	Late bool
+}
+
+// InOrder reports whether the delivery came right after the one before.
+func (c SequenceCheck) InOrder() bool {
+	return c.Skipped == 0 && !c.Late
+}
+
+// SequenceTracker follows the Sequence of the deliveries of one queue, for
+// consumers that depend on receiving messages in order to notice gaps and
+// reordering. It is safe for concurrent use by the goroutines of a worker.
+type SequenceTracker struct {
+	mu      sync.Mutex
+	highest uint64
+}
+
+// Observe records the delivery msg and reports how it relates to those
+// observed before. The first delivery observed sets the starting point, as
+// the messages before it may have been handled before the tracker existed.
+// Messages without a sequence, which did not come from a queue, are in
+// order.
+func (t *SequenceTracker) Observe(msg *Message) SequenceCheck {
+	if msg.Sequence == 0 {
+		return SequenceCheck{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var check SequenceCheck
+	switch {
+	case t.highest == 0:
+	case msg.Sequence <= t.highest:
+		check.Late = true
+	default:
+		check.Skipped = msg.Sequence - t.highest - 1
+	}
+	t.highest = max(t.highest, msg.Sequence)
+	return check
+}
@@ -0,0 +1,150 @@
+package broker
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReceiveInEnqueueOrder(t *testing.T) {
+	ctx := context.Background()
+	q := newTestQueue(t, time.Minute)
+	var enqueued []*Message
+	for range 10 {
+		enqueued = append(enqueued, enqueueTest(t, q, 0))
+	}
+
+	for i, want := range enqueued {
+		msg := mustReceive(t, q)
+		if msg.ID != want.ID || msg.Sequence != uint64(i+1) {
+			t.Fatalf("delivery %d is %s with Sequence %d, want %s with %d", i, msg.ID, msg.Sequence, want.ID, i+1)
+		}
+		if err := q.Acknowledge(ctx, msg.ReceiptHandle); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRedeliveryKeepsSequence(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("nacked", func(t *testing.T) {
+		q := newTestQueue(t, time.Minute)
+		for range 3 {
+			enqueueTest(t, q, 0)
+		}
+
+		first := mustReceive(t, q)
+		if err := q.Nack(ctx, first.ReceiptHandle); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []uint64{1, 2, 3} {
+			msg := mustReceive(t, q)
+			if msg.Sequence != want {
+				t.Fatalf("received Sequence %d, want %d", msg.Sequence, want)
+			}
+		}
+	})
+
+	t.Run("lapsed", func(t *testing.T) {
+		q := newTestQueue(t, 20*time.Millisecond)
+		for range 3 {
+			enqueueTest(t, q, 0)
+		}
+
+		first := mustReceive(t, q)
+		time.Sleep(40 * time.Millisecond)
+		for _, want := range []uint64{1, 2, 3} {
+			msg := mustReceive(t, q)
+			if msg.Sequence != want {
+				t.Fatalf("received Sequence %d, want %d", msg.Sequence, want)
+			}
+			if want == 1 && (msg.ID != first.ID || msg.RetryCount != 2) {
+				t.Fatalf("received %s with RetryCount %d, want %s redelivered", msg.ID, msg.RetryCount, first.ID)
+			}
+		}
+	})
+
+	t.Run("overtaken while hidden", func(t *testing.T) {
+		q := newTestQueue(t, 20*time.Millisecond)
+		for range 3 {
+			enqueueTest(t, q, 0)
+		}
+
+		// The message received meanwhile overtakes the hidden one, which
+		// then comes back before the message enqueued after it.
+		mustReceive(t, q)
+		if msg := mustReceive(t, q); msg.Sequence != 2 {
+			t.Fatalf("received Sequence %d while the first is hidden, want 2", msg.Sequence)
+		}
+		time.Sleep(40 * time.Millisecond)
+		if msg := mustReceive(t, q); msg.Sequence != 1 {
+			t.Fatalf("received Sequence %d after the lapse, want 1", msg.Sequence)
+		}
+	})
+}
+
+func TestSequenceTracker(t *testing.T) {
+	steps := []struct {
+		sequence uint64
+		want     SequenceCheck
+	}{
+		{sequence: 5},
+		{sequence: 6},
+		{sequence: 9, want: SequenceCheck{Skipped: 2}},
+		{sequence: 7, want: SequenceCheck{Late: true}},
+		{sequence: 9, want: SequenceCheck{Late: true}},
+		{sequence: 10},
+		{sequence: 0},
+		{sequence: 11},
+	}
+
+	var tracker SequenceTracker
+	for _, step := range steps {
+		got := tracker.Observe(&Message{Sequence: step.sequence})
+		if got != step.want {
+			t.Fatalf("Observe(%d) = %+v, want %+v", step.sequence, got, step.want)
+		}
+		if got.InOrder() != (step.want == SequenceCheck{}) {
+			t.Fatalf("InOrder of %d = %t", step.sequence, got.InOrder())
+		}
+	}
+}
+
+func TestSequenceTrackerFollowsQueue(t *testing.T) {
+	ctx := context.Background()
+	q := newTestQueue(t, time.Minute)
+	for range 4 {
+		enqueueTest(t, q, 0)
+	}
+
+	var tracker SequenceTracker
+	observe := func() (*Message, SequenceCheck) {
+		msg := mustReceive(t, q)
+		return msg, tracker.Observe(msg)
+	}
+
+	first, check := observe()
+	if !check.InOrder() {
+		t.Fatalf("first delivery %+v, want in order", check)
+	}
+	if _, check = observe(); !check.InOrder() {
+		t.Fatalf("second delivery %+v, want in order", check)
+	}
+
+	// The nacked message comes back late.
+	if err := q.Nack(ctx, first.ReceiptHandle); err != nil {
+		t.Fatal(err)
+	}
+	if msg, check := observe(); msg.Sequence != 1 || !check.Late {
+		t.Fatalf("redelivery of Sequence %d %+v, want 1 late", msg.Sequence, check)
+	}
+
+	// Taking the third message out of the queue leaves a gap.
+	if taken := q.take(1, func(*Message) bool { return true }); len(taken) != 1 || taken[0].Sequence != 3 {
+		t.Fatalf("took %d messages, want Sequence 3", len(taken))
+	}
+	if msg, check := observe(); msg.Sequence != 4 || check.Skipped != 1 {
+		t.Fatalf("delivery of Sequence %d %+v, want 4 skipping 1", msg.Sequence, check)
+	}
+}
@@ -2,16 +2,62 @@ package broker
 
 import (
 	"context"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/reqmeta"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
 	"github.com/google/uuid"
 )
 
+// PublishKeyMetadata is the metadata key of the key a topic with a dedup
+// window deduplicates publishes by, instead of the message ID.
+const PublishKeyMetadata = "publish_key"
+
 type Topic struct {
 	mu          sync.RWMutex
 	name        string
 	subscribers []*Queue
+	log         Logger
+	trace       *tracer
+
+	// dedupWindow is how long the publish keys in published are kept, in
+	// the order they were first published.
+	dedupWindow time.Duration
+	published   map[string]time.Time
+	publishKeys []string
+	duplicates  int64
+
+	// forwards are the forwarding rules matching the topic, see
+	// Broker.Forward.
+	forwards        []forwardRule
+	forwardsDropped int64
+
+	// retention and retainMessages bound history, the messages published
+	// in the order of their offsets, see WithRetention. trimmedAt is when
+	// the last message dropped from it was published, and committed the
+	// offset each subscriber resumes from.
+	retention      time.Duration
+	retainMessages int
+	history        []retainedMessage
+	nextOffset     int64
+	trimmedAt      time.Time
+	committed      map[string]int64
+}
+
+type TopicOption func(*Topic)
+
+// WithDedupWindow makes the topic drop publishes of a message whose publish
+// key, its PublishKeyMetadata or else its ID, was published in the last
+// window. A publisher that retries with the same message, such as a relay
+// resending what it is not sure was published, then delivers it once.
+func WithDedupWindow(window time.Duration) TopicOption {
+	return func(t *Topic) {
+		t.dedupWindow = window
+		t.published = make(map[string]time.Time)
+	}
 }
 
 func (t *Topic) Name() string {
@@ -33,18 +79,95 @@ func (t *Topic) Publish(ctx context.Context, msg *Message) error {
 	if msg.Timestamp.IsZero() {
 		msg.Timestamp = time.Now()
 	}
+	if id := logging.RequestID(ctx); id != "" && msg.GetMetadata(RequestIDMetadata) == "" {
+		msg.SetMetadata(RequestIDMetadata, id)
+	}
+	if msg.GetMetadata(TenantMetadata) == "" {
+		msg.SetMetadata(TenantMetadata, tenant.FromContext(ctx))
+	}
+	if actor := reqmeta.Actor(ctx); actor != "" && msg.GetMetadata(ActorMetadata) == "" {
+		msg.SetMetadata(ActorMetadata, actor)
+	}
+	setCausation(ctx, msg)
 
+	if t.duplicate(msg) {
+		t.log.DebugContext(msg.Context(ctx), "dropped duplicate publish", "topic", t.name)
+		return nil
+	}
+	t.trace.record(msg.ID, TraceEvent{Event: TracePublished, Topic: t.name})
+	offset := t.retain(msg)
+
+	// Once a publish is under way it reaches every subscriber: the caller
+	// going away must not drop the event of a change it already made.
+	deliverCtx := context.WithoutCancel(ctx)
 	for _, queue := range subscribers {
-		clone := msg.Clone()
-		clone.SetMetadata("source_topic", t.name)
-		clone.SetMetadata("delivery_id", uuid.New().String())
+		t.deliver(deliverCtx, msg, queue, offset)
+	}
+	t.forward(deliverCtx, msg)
 
-		if err := queue.Enqueue(ctx, clone); err != nil {
-			logError("Failed to deliver message to queue '%s': %v", queue.name, err)
+	return nil
+}
+
+// deliver enqueues a copy of msg, at offset in the log of t or -1, on
+// queue.
+func (t *Topic) deliver(ctx context.Context, msg *Message, queue *Queue, offset int64) {
+	clone := msg.Clone()
+	clone.SetMetadata("source_topic", t.name)
+	clone.SetMetadata("delivery_id", uuid.New().String())
+	if offset >= 0 {
+		clone.SetMetadata(TopicOffsetMetadata, strconv.FormatInt(offset, 10))
+	}
+	t.trace.inherit(msg.ID, clone.ID)
+
+	if err := queue.Enqueue(ctx, clone); err != nil {
+		t.log.ErrorContext(msg.Context(ctx), "failed to deliver message", "topic", t.name, "queue", queue.name, logging.Err(err))
+	}
+}
+
+// duplicate reports whether the publish key of msg was published within the
+// dedup window, and records it otherwise.
+func (t *Topic) duplicate(msg *Message) bool {
+	if t.dedupWindow <= 0 {
+		return false
+	}
+	key := msg.GetMetadata(PublishKeyMetadata)
+	if key == "" {
+		key = msg.ID
+	}
+	if key == "" {
+		return false
+	}
+	key = msg.GetMetadata(TenantMetadata) + "/" + key
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	expired := 0
+	for _, k := range t.publishKeys {
+		if now.Sub(t.published[k]) < t.dedupWindow {
+			break
 		}
+		delete(t.published, k)
+		expired++
 	}
+	clear(t.publishKeys[:expired])
+	t.publishKeys = t.publishKeys[expired:]
 
-	return nil
+	if _, ok := t.published[key]; ok {
+		t.duplicates++
+		return true
+	}
+	t.published[key] = now
+	t.publishKeys = append(t.publishKeys, key)
+	return false
+}
+
+// Duplicates returns the number of publishes dropped by the dedup window.
+func (t *Topic) Duplicates() int64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.duplicates
 }
 
 func (t *Topic) SubscriberCount() int {
@@ -0,0 +1,324 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Topology declares the topics and queues of a broker and how they are
+// wired, so a service can keep them in a file rather than in code. See
+// Broker.ApplyTopology and Broker.ExportTopology.
+type Topology struct {
+	Topics []TopicSpec `json:"topics,omitempty" yaml:"topics,omitempty"`
+	Queues []QueueSpec `json:"queues,omitempty" yaml:"queues,omitempty"`
+}
+
+// TopicSpec declares a topic. Zero retention settings keep the
+// TopicRetention and TopicRetentionMessages of the BrokerConfig.
+type TopicSpec struct {
+	Name              string   `json:"name" yaml:"name"`
+	Retention         Duration `json:"retention,omitempty" yaml:"retention,omitempty"`
+	RetentionMessages int      `json:"retention_messages,omitempty" yaml:"retention_messages,omitempty"`
+	DedupWindow       Duration `json:"dedup_window,omitempty" yaml:"dedup_window,omitempty"`
+}
+
+// QueueSpec declares a queue and the topics it subscribes to. Zero settings
+// keep the defaults of CreateQueue.
+type QueueSpec struct {
+	Name string `json:"name" yaml:"name"`
+
+	// Topics are the topics the queue subscribes to.
+	Topics []string `json:"topics,omitempty" yaml:"topics,omitempty"`
+
+	VisibilityTimeout Duration `json:"visibility_timeout,omitempty" yaml:"visibility_timeout,omitempty"`
+
+	// Redrive replaces the DefaultMaxRetries of the BrokerConfig; an empty
+	// one gives up on no message.
+	Redrive *RedrivePolicy `json:"redrive,omitempty" yaml:"redrive,omitempty"`
+
+	// DeadLetterQueue names the queue that receives the messages given up
+	// on, declared along with this one or existing already.
+	DeadLetterQueue string `json:"dead_letter_queue,omitempty" yaml:"dead_letter_queue,omitempty"`
+
+	PriorityAgingInterval Duration `json:"priority_aging_interval,omitempty" yaml:"priority_aging_interval,omitempty"`
+	PriorityAgingMaxBoost int      `json:"priority_aging_max_boost,omitempty" yaml:"priority_aging_max_boost,omitempty"`
+
+	// AlertDepth and AlertOldestAge are the thresholds of Monitor, see
+	// SetAlertThresholds.
+	AlertDepth     int      `json:"alert_depth,omitempty" yaml:"alert_depth,omitempty"`
+	AlertOldestAge Duration `json:"alert_oldest_age,omitempty" yaml:"alert_oldest_age,omitempty"`
+}
+
+// Duration is a time.Duration written like "30s" in topology files.
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// ParseTopology reads a Topology from JSON or YAML. Unknown keys are
+// errors, so a misspelled setting is not silently ignored.
+func ParseTopology(data []byte) (Topology, error) {
+	var t Topology
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&t); err != nil {
+			return Topology{}, fmt.Errorf("invalid topology: %w", err)
+		}
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&t); err != nil && !errors.Is(err, io.EOF) {
+			return Topology{}, fmt.Errorf("invalid topology: %w", err)
+		}
+	}
+	return t, t.Validate()
+}
+
+// LoadTopology reads the Topology file at path, see ParseTopology.
+func LoadTopology(path string) (Topology, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Topology{}, err
+	}
+	t, err := ParseTopology(data)
+	if err != nil {
+		return Topology{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+// Validate checks t on its own: names are set and unique, settings are not
+// negative and dead-letter queues do not loop. Topics and queues it refers
+// to without declaring them must exist when it is applied.
+func (t Topology) Validate() error {
+	var errs []error
+	topics := make(map[string]bool, len(t.Topics))
+	for i, spec := range t.Topics {
+		switch {
+		case spec.Name == "":
+			errs = append(errs, fmt.Errorf("topics[%d]: name is required", i))
+		case topics[spec.Name]:
+			errs = append(errs, fmt.Errorf("topic %q is declared twice", spec.Name))
+		}
+		topics[spec.Name] = true
+		if spec.Retention < 0 || spec.RetentionMessages < 0 || spec.DedupWindow < 0 {
+			errs = append(errs, fmt.Errorf("topic %q: settings must not be negative", spec.Name))
+		}
+	}
+
+	queues := make(map[string]QueueSpec, len(t.Queues))
+	for i, spec := range t.Queues {
+		switch {
+		case spec.Name == "":
+			errs = append(errs, fmt.Errorf("queues[%d]: name is required", i))
+		case queues[spec.Name].Name != "":
+			errs = append(errs, fmt.Errorf("queue %q is declared twice", spec.Name))
+		}
+		queues[spec.Name] = spec
+		if spec.VisibilityTimeout < 0 || spec.PriorityAgingInterval < 0 || spec.PriorityAgingMaxBoost < 0 ||
+			spec.AlertDepth < 0 || spec.AlertOldestAge < 0 ||
+			spec.Redrive != nil && (spec.Redrive.MaxReceives < 0 || spec.Redrive.MaxFailures < 0) {
+			errs = append(errs, fmt.Errorf("queue %q: settings must not be negative", spec.Name))
+		}
+		if spec.DeadLetterQueue == spec.Name && spec.Name != "" {
+			errs = append(errs, fmt.Errorf("queue %q is its own dead-letter queue", spec.Name))
+		}
+	}
+	for _, spec := range t.Queues {
+		seen := map[string]bool{spec.Name: true}
+		for next := queues[spec.DeadLetterQueue]; next.Name != ""; next = queues[next.DeadLetterQueue] {
+			if seen[next.Name] {
+				errs = append(errs, fmt.Errorf("queue %q: dead-letter queues loop through %q", spec.Name, next.Name))
+				break
+			}
+			seen[next.Name] = true
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ApplyTopology creates the topics and queues t declares and subscribes the
+// queues to their topics. A topic or queue that exists already is kept as
+// it is, and only the subscriptions it lacks are added, so applying the
+// same topology twice changes nothing. Nothing is created when t is
+// invalid or refers to a topic or queue that neither it declares nor b
+// has.
+//
+// extra adds options a file cannot declare, such as fault injection, to
+// every queue created but the dead-letter queues; a nil option leaves the
+// queue alone.
+func (b *Broker) ApplyTopology(t Topology, extra ...func(queue string) QueueOption) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	topics := make(map[string]bool, len(t.Topics))
+	for _, spec := range t.Topics {
+		topics[spec.Name] = true
+	}
+	queues := make(map[string]QueueSpec, len(t.Queues))
+	deadLetters := make(map[string]bool)
+	for _, spec := range t.Queues {
+		queues[spec.Name] = spec
+		if spec.DeadLetterQueue != "" {
+			deadLetters[spec.DeadLetterQueue] = true
+		}
+	}
+
+	var errs []error
+	for _, spec := range t.Queues {
+		if _, ok := queues[spec.DeadLetterQueue]; spec.DeadLetterQueue != "" && !ok {
+			if _, exists := b.GetQueue(spec.DeadLetterQueue); !exists {
+				errs = append(errs, fmt.Errorf("queue %q: dead-letter queue %q: %w", spec.Name, spec.DeadLetterQueue, ErrQueueNotFound))
+			}
+		}
+		for _, topic := range spec.Topics {
+			if _, exists := b.GetTopic(topic); !topics[topic] && !exists {
+				errs = append(errs, fmt.Errorf("queue %q: topic %q: %w", spec.Name, topic, ErrTopicNotFound))
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	for _, spec := range t.Topics {
+		var opts []TopicOption
+		if spec.Retention > 0 || spec.RetentionMessages > 0 {
+			opts = append(opts, WithRetention(time.Duration(spec.Retention), spec.RetentionMessages))
+		}
+		if spec.DedupWindow > 0 {
+			opts = append(opts, WithDedupWindow(time.Duration(spec.DedupWindow)))
+		}
+		b.CreateTopic(spec.Name, opts...)
+	}
+
+	// Dead-letter queues are created before the queues that use them,
+	// which Validate guarantees can be done.
+	var create func(name string) *Queue
+	create = func(name string) *Queue {
+		if q, ok := b.GetQueue(name); ok {
+			return q
+		}
+		spec := queues[name]
+		var opts []QueueOption
+		if spec.VisibilityTimeout > 0 {
+			opts = append(opts, WithVisibilityTimeout(time.Duration(spec.VisibilityTimeout)))
+		}
+		if spec.Redrive != nil {
+			opts = append(opts, WithRedrivePolicy(*spec.Redrive))
+		}
+		if spec.DeadLetterQueue != "" {
+			opts = append(opts, WithDLQ(create(spec.DeadLetterQueue)))
+		}
+		if spec.PriorityAgingInterval > 0 {
+			opts = append(opts, WithPriorityAging(PriorityAging{
+				Interval: time.Duration(spec.PriorityAgingInterval),
+				MaxBoost: spec.PriorityAgingMaxBoost,
+			}))
+		}
+		if !deadLetters[name] {
+			for _, fn := range extra {
+				if opt := fn(name); opt != nil {
+					opts = append(opts, opt)
+				}
+			}
+		}
+		return b.CreateQueue(name, opts...)
+	}
+	for _, spec := range t.Queues {
+		create(spec.Name)
+		if spec.AlertDepth > 0 || spec.AlertOldestAge > 0 {
+			b.SetAlertThresholds(spec.Name, spec.AlertDepth, time.Duration(spec.AlertOldestAge))
+		}
+	}
+
+	for _, spec := range t.Queues {
+		q, _ := b.GetQueue(spec.Name)
+		for _, name := range spec.Topics {
+			if topic, _ := b.GetTopic(name); !topic.subscribed(q) {
+				b.Subscribe(name, spec.Name)
+			}
+		}
+	}
+
+	b.log.InfoContext(context.Background(), "applied topology", "topics", len(t.Topics), "queues", len(t.Queues))
+	return nil
+}
+
+// ExportTopology returns the topics and queues of b as a Topology, sorted
+// by name, which ApplyTopology recreates on an empty broker. Options only
+// code can set, such as enqueue filters and forwarding rules, are left
+// out.
+func (b *Broker) ExportTopology() Topology {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var t Topology
+	subscriptions := make(map[*Queue][]string)
+	for _, topic := range b.topics {
+		topic.mu.RLock()
+		t.Topics = append(t.Topics, TopicSpec{
+			Name:              topic.name,
+			Retention:         Duration(topic.retention),
+			RetentionMessages: topic.retainMessages,
+			DedupWindow:       Duration(topic.dedupWindow),
+		})
+		for _, q := range topic.subscribers {
+			subscriptions[q] = append(subscriptions[q], topic.name)
+		}
+		topic.mu.RUnlock()
+	}
+	for _, q := range b.queues {
+		redrive := q.redrive
+		spec := QueueSpec{
+			Name:                  q.name,
+			Topics:                subscriptions[q],
+			VisibilityTimeout:     Duration(q.visibilityTimeout),
+			Redrive:               &redrive,
+			PriorityAgingInterval: Duration(q.aging.Interval),
+			PriorityAgingMaxBoost: q.aging.MaxBoost,
+		}
+		slices.Sort(spec.Topics)
+		if q.deadLetterQueue != nil {
+			spec.DeadLetterQueue = q.deadLetterQueue.name
+		}
+		if thresholds, ok := b.thresholds[q.name]; ok {
+			spec.AlertDepth = thresholds.Depth
+			spec.AlertOldestAge = Duration(thresholds.OldestAge)
+		}
+		t.Queues = append(t.Queues, spec)
+	}
+
+	slices.SortFunc(t.Topics, func(a, b TopicSpec) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(t.Queues, func(a, b QueueSpec) int { return strings.Compare(a.Name, b.Name) })
+	return t
+}
+
+// subscribed reports whether q subscribes to t.
+func (t *Topic) subscribed(q *Queue) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return slices.Contains(t.subscribers, q)
+}
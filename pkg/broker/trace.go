@@ -0,0 +1,111 @@
+package broker
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Events of a message trace.
+const (
+	TracePublished    = "published"
+	TraceEnqueued     = "enqueued"
+	TraceDropped      = "dropped"
+	TraceReceived     = "received"
+	TraceAcknowledged = "acknowledged"
+	TraceNacked       = "nacked"
+	TraceExpired      = "visibility_expired"
+	TraceDeadLettered = "dead_lettered"
+	TraceDiscarded    = "discarded"
+	TraceRedriven     = "redriven"
+)
+
+// TraceEvent is a step in the life of a message: where it was published,
+// enqueued or received, which worker received it and how its handling
+// failed.
+type TraceEvent struct {
+	At     time.Time `json:"at"`
+	Event  string    `json:"event"`
+	Topic  string    `json:"topic,omitempty"`
+	Queue  string    `json:"queue,omitempty"`
+	Worker string    `json:"worker,omitempty"`
+	Retry  int       `json:"retry,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// tracer keeps the trace of the last limit messages. A nil tracer records
+// nothing.
+type tracer struct {
+	mu     sync.Mutex
+	limit  int
+	traces map[string][]TraceEvent
+
+	// ids are the traced message IDs, oldest first.
+	ids []string
+}
+
+func newTracer(limit int) *tracer {
+	if limit <= 0 {
+		return nil
+	}
+	return &tracer{limit: limit, traces: make(map[string][]TraceEvent)}
+}
+
+func (t *tracer) record(id string, event TraceEvent) {
+	if t == nil || id == "" {
+		return
+	}
+	event.At = time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.appendLocked(id, event)
+}
+
+// inherit starts the trace of child, a copy of the message parent, with the
+// trace of parent, so the trace of a delivery or a dead letter shows where
+// it came from.
+func (t *tracer) inherit(parent, child string) {
+	if t == nil || parent == child {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, event := range t.traces[parent] {
+		t.appendLocked(child, event)
+	}
+}
+
+func (t *tracer) appendLocked(id string, event TraceEvent) {
+	if _, ok := t.traces[id]; !ok {
+		t.ids = append(t.ids, id)
+		if evicted := len(t.ids) - t.limit; evicted > 0 {
+			for _, old := range t.ids[:evicted] {
+				delete(t.traces, old)
+			}
+			clear(t.ids[:evicted])
+			t.ids = t.ids[evicted:]
+		}
+	}
+	t.traces[id] = append(t.traces[id], event)
+}
+
+func (t *tracer) get(id string) []TraceEvent {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.traces[id])
+}
+
+// MessageTrace returns the trace of the message with the given ID, oldest
+// event first, or nil when tracing is off or the message is not among the
+// last TraceHistory messages traced. Every copy a topic delivers and every
+// dead letter has an ID of its own, whose trace starts with that of the
+// message it was copied from.
+func (b *Broker) MessageTrace(id string) []TraceEvent {
+	return b.trace.get(id)
+}
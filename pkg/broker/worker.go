@@ -2,12 +2,32 @@ package broker
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
 	"sync"
 	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
 )
 
 type MessageHandler func(*Message) error
 
+// PanicError is the failure of a handler that panicked. Value is what it
+// panicked with and Stack the stack trace of the panic.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("handler panicked: %v", e.Value)
+}
+
+// CrashFunc is called with the message whose handler panicked and the
+// panic, for example to report the crash.
+type CrashFunc func(msg *Message, err *PanicError)
+
 type WorkerConfig struct {
 	PollInterval time.Duration
 	Concurrency  int
@@ -26,15 +46,42 @@ type Worker struct {
 	handler MessageHandler
 	config  WorkerConfig
 	stats   WorkerStats
+	onCrash CrashFunc
 	mu      sync.Mutex
 	running bool
 	stopCh  chan struct{}
+
+	draining bool
+	drainCh  chan struct{}
+	done     chan struct{}
+	doneOnce sync.Once
 }
 
 type WorkerStats struct {
 	MessagesProcessed int64
 	MessagesFailed    int64
-	TotalProcessTime  time.Duration
+	// MessagesPanicked counts the messages whose handler panicked; they
+	// are not counted in MessagesFailed.
+	MessagesPanicked int64
+	// MessagesExpired counts the messages the worker received but did not
+	// acknowledge or nack within the visibility timeout of the queue.
+	MessagesExpired  int64
+	TotalProcessTime time.Duration
+
+	// LastProcessedAt is when a message was last handled successfully, and
+	// LastError, at LastErrorAt, the last failure or panic of the handler.
+	LastProcessedAt time.Time
+	LastError       string
+	LastErrorAt     time.Time
+}
+
+// AverageProcessTime is the mean time spent on the messages handled
+// successfully, zero before the first.
+func (s WorkerStats) AverageProcessTime() time.Duration {
+	if s.MessagesProcessed == 0 {
+		return 0
+	}
+	return s.TotalProcessTime / time.Duration(s.MessagesProcessed)
 }
 
 func NewWorker(name string, queue *Queue, handler MessageHandler) *Worker {
@@ -44,6 +91,8 @@ func NewWorker(name string, queue *Queue, handler MessageHandler) *Worker {
 		handler: handler,
 		config:  DefaultWorkerConfig(),
 		stopCh:  make(chan struct{}),
+		drainCh: make(chan struct{}),
+		done:    make(chan struct{}),
 	}
 }
 
@@ -54,6 +103,8 @@ func NewWorkerWithConfig(name string, queue *Queue, handler MessageHandler, conf
 		handler: handler,
 		config:  config,
 		stopCh:  make(chan struct{}),
+		drainCh: make(chan struct{}),
+		done:    make(chan struct{}),
 	}
 }
 
@@ -65,8 +116,9 @@ func (w *Worker) Start(ctx context.Context) error {
 	}
 	w.running = true
 	w.mu.Unlock()
+	defer w.doneOnce.Do(func() { close(w.done) })
 
-	logInfo("Worker '%s' started, polling queue '%s'", w.name, w.queue.name)
+	w.queue.log.InfoContext(ctx, "worker started", "worker", w.name, "queue", w.queue.name)
 
 	for {
 		select {
@@ -77,15 +129,33 @@ func (w *Worker) Start(ctx context.Context) error {
 		default:
 		}
 
-		msg, err := w.queue.Receive(ctx)
+		changed := w.queue.Changed()
+		msg, err := w.queue.receive(ctx, w.name)
 		if err != nil {
-			logError("Worker '%s' failed to receive message: %v", w.name, err)
+			if ctx.Err() != nil {
+				continue
+			}
+			w.queue.log.ErrorContext(ctx, "worker failed to receive message", "worker", w.name, logging.Err(err))
 			time.Sleep(w.config.PollInterval)
 			continue
 		}
 
 		if msg == nil {
-			time.Sleep(w.config.PollInterval)
+			select {
+			case <-w.drainCh:
+				w.queue.log.InfoContext(ctx, "worker drained", "worker", w.name, "queue", w.queue.name)
+				return nil
+			default:
+			}
+			// Wake up for a new message right away, and every poll
+			// interval for messages whose visibility timeout lapsed.
+			select {
+			case <-ctx.Done():
+			case <-w.stopCh:
+			case <-w.drainCh:
+			case <-changed:
+			case <-time.After(w.config.PollInterval):
+			}
 			continue
 		}
 
@@ -93,51 +163,156 @@ func (w *Worker) Start(ctx context.Context) error {
 	}
 }
 
+// OnCrash makes the worker call fn for every message whose handler panics.
+// The panic is recovered either way: the message is nacked, with the panic
+// as its last error, and the worker goes on with the next one.
+func (w *Worker) OnCrash(fn CrashFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onCrash = fn
+}
+
 func (w *Worker) processMessage(ctx context.Context, msg *Message) {
 	start := time.Now()
 
-	err := w.handler(msg)
+	err := w.handle(msg)
 
 	elapsed := time.Since(start)
 
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		w.mu.Lock()
+		w.stats.MessagesPanicked++
+		w.recordErrorLocked(err)
+		onCrash := w.onCrash
+		w.mu.Unlock()
+
+		w.queue.log.ErrorContext(msg.Context(ctx), "worker handler panicked", "worker", w.name,
+			"panic", fmt.Sprint(panicErr.Value), "stack", string(panicErr.Stack))
+		if onCrash != nil {
+			onCrash(msg, panicErr)
+		}
+
+		if nackErr := w.queue.Fail(ctx, msg.ReceiptHandle, err); nackErr != nil {
+			w.queue.log.ErrorContext(msg.Context(ctx), "worker failed to nack message", "worker", w.name, logging.Err(nackErr))
+		}
+		return
+	}
+
 	if err != nil {
 		w.mu.Lock()
 		w.stats.MessagesFailed++
+		w.recordErrorLocked(err)
 		w.mu.Unlock()
 
-		logError("Worker '%s' failed to process message '%s': %v", w.name, msg.ID, err)
+		w.queue.log.ErrorContext(msg.Context(ctx), "worker failed to process message", "worker", w.name, logging.Err(err))
 
-		if nackErr := w.queue.Nack(ctx, msg.ReceiptHandle); nackErr != nil {
-			logError("Worker '%s' failed to nack message '%s': %v", w.name, msg.ID, nackErr)
+		if nackErr := w.queue.Fail(ctx, msg.ReceiptHandle, err); nackErr != nil {
+			w.queue.log.ErrorContext(msg.Context(ctx), "worker failed to nack message", "worker", w.name, logging.Err(nackErr))
 		}
 		return
 	}
 
 	if ackErr := w.queue.Acknowledge(ctx, msg.ReceiptHandle); ackErr != nil {
-		logError("Worker '%s' failed to ack message '%s': %v", w.name, msg.ID, ackErr)
+		w.queue.log.ErrorContext(msg.Context(ctx), "worker failed to ack message", "worker", w.name, logging.Err(ackErr))
 		return
 	}
 
 	w.mu.Lock()
 	w.stats.MessagesProcessed++
 	w.stats.TotalProcessTime += elapsed
+	w.stats.LastProcessedAt = time.Now()
 	w.mu.Unlock()
 }
 
+func (w *Worker) recordErrorLocked(err error) {
+	w.stats.LastError = err.Error()
+	w.stats.LastErrorAt = time.Now()
+}
+
+// handle runs the handler on msg, turning a panic into a *PanicError.
+func (w *Worker) handle(msg *Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return w.handler(msg)
+}
+
 func (w *Worker) Stop() {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	if w.running {
 		close(w.stopCh)
 		w.running = false
-		logInfo("Worker '%s' stopped", w.name)
+		w.queue.log.InfoContext(context.Background(), "worker stopped", "worker", w.name)
 	}
 }
 
-func (w *Worker) Stats() WorkerStats {
+// Shutdown drains the worker: it keeps handling visible messages until the
+// queue is empty, then returns. Once ctx is done the worker stops taking new
+// messages and Shutdown returns ctx.Err() as soon as the message in flight,
+// if any, has been handled and acknowledged. Handlers are never interrupted.
+func (w *Worker) Shutdown(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.running {
+		w.mu.Unlock()
+		return nil
+	}
+	if !w.draining {
+		w.draining = true
+		close(w.drainCh)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	w.Stop()
+	<-w.done
+	return ctx.Err()
+}
+
+// Running reports whether the worker has started and has not exited yet.
+func (w *Worker) Running() bool {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	return w.stats
+	if !w.running {
+		return false
+	}
+	select {
+	case <-w.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Name returns the name the worker was created with.
+func (w *Worker) Name() string {
+	return w.name
+}
+
+// Queue returns the queue the worker consumes.
+func (w *Worker) Queue() *Queue {
+	return w.queue
+}
+
+// Config returns the configuration of the worker.
+func (w *Worker) Config() WorkerConfig {
+	return w.config
+}
+
+func (w *Worker) Stats() WorkerStats {
+	w.mu.Lock()
+	stats := w.stats
+	w.mu.Unlock()
+	stats.MessagesExpired = w.queue.expiredFor(w.name)
+	return stats
 }
 
 type IdempotencyStore interface {
@@ -184,7 +359,7 @@ func (s *InMemoryIdempotencyStore) MarkProcessed(messageID string) error {
 func IdempotentWorker(name string, queue *Queue, handler MessageHandler, store IdempotencyStore) *Worker {
 	wrappedHandler := func(msg *Message) error {
 		if store.IsProcessed(msg.ID) {
-			logInfo("Message '%s' already processed, skipping", msg.ID)
+			queue.log.InfoContext(msg.Context(context.Background()), "message already processed, skipping")
 			return nil
 		}
 
@@ -0,0 +1,17 @@
+// Package cache implements byte caches with expiring entries: an in-memory
+// LRU and a client of a Redis server.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores values by key. A zero ttl keeps a value until it is deleted
+// or evicted.
+type Cache interface {
+	// Get returns the value of key and whether it was found.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
@@ -0,0 +1,204 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// RedisConfig locates a Redis server.
+type RedisConfig struct {
+	Addr     string        `config:"addr" usage:"Redis server address, host:port"`
+	Password string        `config:"password,secret" usage:"Redis password, sent with AUTH"`
+	DB       int           `config:"db" usage:"Redis database number"`
+	PoolSize int           `config:"pool-size" usage:"Idle Redis connections kept open"`
+	Timeout  time.Duration `config:"timeout" usage:"Timeout of dialing and of each Redis command"`
+}
+
+func DefaultRedisConfig() RedisConfig {
+	return RedisConfig{PoolSize: 8, Timeout: time.Second}
+}
+
+func (c RedisConfig) Validate() error {
+	if c.DB < 0 || c.PoolSize < 1 || c.Timeout <= 0 {
+		return errors.New("redis db must not be negative, pool-size at least 1 and timeout positive")
+	}
+	return nil
+}
+
+// Redis is a Cache kept on a Redis server, shared by every replica using
+// it. It speaks just enough of the RESP protocol for GET, SET and DEL.
+type Redis struct {
+	config RedisConfig
+	idle   chan *redisConn
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// redisError is an error reply of the server.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+func NewRedis(config RedisConfig) *Redis {
+	return &Redis{config: config, idle: make(chan *redisConn, config.PoolSize)}
+}
+
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := c.do(ctx, "GET", key)
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected GET reply %v", reply)
+	}
+	return value, true, nil
+}
+
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(max(ttl.Milliseconds(), 1), 10))
+	}
+	_, err := c.do(ctx, args...)
+	return err
+}
+
+func (c *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := c.do(ctx, append([]string{"DEL"}, keys...)...)
+	return err
+}
+
+// Ping checks that the server answers.
+func (c *Redis) Ping(ctx context.Context) error {
+	_, err := c.do(ctx, "PING")
+	return err
+}
+
+// Close closes the idle connections.
+func (c *Redis) Close() error {
+	for {
+		select {
+		case conn := <-c.idle:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do sends a command and reads its reply: nil, a string, an int64 or a
+// []byte. Connections that fail are closed; error replies keep them.
+func (c *Redis) do(ctx context.Context, args ...string) (any, error) {
+	conn, err := c.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.roundTrip(ctx, c.config.Timeout, args)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		conn.Close()
+		return nil, err
+	}
+	select {
+	case c.idle <- conn:
+	default:
+		conn.Close()
+	}
+	return reply, err
+}
+
+func (c *Redis) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: c.config.Timeout}
+	nc, err := dialer.DialContext(ctx, "tcp", c.config.Addr)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{Conn: nc, r: bufio.NewReader(nc)}
+	if c.config.Password != "" {
+		if _, err := conn.roundTrip(ctx, c.config.Timeout, []string{"AUTH", c.config.Password}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if c.config.DB != 0 {
+		if _, err := conn.roundTrip(ctx, c.config.Timeout, []string{"SELECT", strconv.Itoa(c.config.DB)}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (conn *redisConn) roundTrip(ctx context.Context, timeout time.Duration, args []string) (any, error) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	buf := fmt.Appendf(nil, "*%d\r\n", len(args))
+	for _, arg := range args {
+		buf = fmt.Appendf(buf, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := conn.Write(buf); err != nil {
+		return nil, err
+	}
+	return conn.readReply()
+}
+
+func (conn *redisConn) readReply() (any, error) {
+	line, err := conn.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(conn.r, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	default:
+		return nil, fmt.Errorf("redis: unsupported reply type %q", kind)
+	}
+}
@@ -0,0 +1,38 @@
+package chaos
+
+import (
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+)
+
+// QueueOption injects message faults into a queue: dropped messages are
+// never stored, duplicated ones are stored twice with the same ID, as an
+// at-least-once redelivery would be, and reordered ones stay invisible for
+// up to ReorderDelay so later messages overtake them.
+func (i *Injector) QueueOption(queue string) broker.QueueOption {
+	return broker.WithEnqueueFilter(func(msg *broker.Message) []*broker.Message {
+		cfg := i.Config()
+		if !cfg.Enabled {
+			return []*broker.Message{msg}
+		}
+
+		if chance(cfg.DropRate) {
+			logger.Info("dropped message", "queue", queue, "type", msg.Type, "message_id", msg.ID)
+			return nil
+		}
+		if chance(cfg.ReorderRate) {
+			msg.VisibleAt = time.Now().Add(jitter(cfg.ReorderDelay) + time.Millisecond)
+			logger.Info("held back message", "queue", queue, "type", msg.Type, "message_id", msg.ID,
+				"until", msg.VisibleAt)
+		}
+		stored := []*broker.Message{msg}
+		if chance(cfg.DuplicateRate) {
+			dup := msg.Clone()
+			dup.ID = msg.ID
+			stored = append(stored, dup)
+			logger.Info("duplicated message", "queue", queue, "type", msg.Type, "message_id", msg.ID)
+		}
+		return stored
+	})
+}
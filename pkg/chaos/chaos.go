@@ -0,0 +1,131 @@
+// Package chaos injects faults for resilience testing: latency and errors
+// into gRPC calls, and dropped, duplicated and reordered messages into
+// broker queues. The faults are set at startup from Config and can be
+// changed while the service runs through Handler.
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"path"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"google.golang.org/grpc/codes"
+)
+
+var logger = logging.Component("chaos")
+
+// Config describes the faults to inject. The zero value injects none.
+type Config struct {
+	Enabled bool `config:"chaos" usage:"Install the fault injection hooks configured by the chaos-* settings and the admin endpoint"`
+
+	Latency   time.Duration `config:"chaos-latency" usage:"Delay added to every affected gRPC call"`
+	Jitter    time.Duration `config:"chaos-jitter" usage:"Random extra delay of up to this value added to chaos-latency"`
+	ErrorRate float64       `config:"chaos-error-rate" usage:"Fraction of affected gRPC calls failed with chaos-error-code"`
+	ErrorCode string        `config:"chaos-error-code" usage:"gRPC code of injected errors, such as UNAVAILABLE"`
+	Methods   []string      `config:"chaos-methods" usage:"Comma separated gRPC method names affected, such as ProcessPayment; empty affects all"`
+
+	DropRate      float64       `config:"chaos-drop-rate" usage:"Fraction of enqueued messages dropped"`
+	DuplicateRate float64       `config:"chaos-duplicate-rate" usage:"Fraction of enqueued messages stored twice"`
+	ReorderRate   float64       `config:"chaos-reorder-rate" usage:"Fraction of enqueued messages held back behind later ones"`
+	ReorderDelay  time.Duration `config:"chaos-reorder-delay" usage:"Longest time a reordered message is held back"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		ErrorCode:    "UNAVAILABLE",
+		ReorderDelay: time.Second,
+	}
+}
+
+func (c Config) Validate() error {
+	for name, rate := range map[string]float64{
+		"chaos-error-rate":     c.ErrorRate,
+		"chaos-drop-rate":      c.DropRate,
+		"chaos-duplicate-rate": c.DuplicateRate,
+		"chaos-reorder-rate":   c.ReorderRate,
+	} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s must be between 0 and 1, got %g", name, rate)
+		}
+	}
+	if c.Latency < 0 || c.Jitter < 0 || c.ReorderDelay < 0 {
+		return errors.New("chaos-latency, chaos-jitter and chaos-reorder-delay must not be negative")
+	}
+	if c.ReorderRate > 0 && c.ReorderDelay == 0 {
+		return errors.New("chaos-reorder-rate requires chaos-reorder-delay")
+	}
+	if _, err := c.code(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// code parses ErrorCode, which defaults to UNAVAILABLE.
+func (c Config) code() (codes.Code, error) {
+	if c.ErrorCode == "" {
+		return codes.Unavailable, nil
+	}
+	var code codes.Code
+	if err := code.UnmarshalJSON([]byte(`"` + c.ErrorCode + `"`)); err != nil || code == codes.OK {
+		return 0, fmt.Errorf("chaos-error-code %q is not a gRPC error code", c.ErrorCode)
+	}
+	return code, nil
+}
+
+// affects reports whether the gRPC method fullMethod gets call faults.
+func (c Config) affects(fullMethod string) bool {
+	return len(c.Methods) == 0 || slices.Contains(c.Methods, path.Base(fullMethod))
+}
+
+// Injector holds the faults in effect. Its hooks inject nothing while the
+// config is disabled.
+type Injector struct {
+	mu  sync.RWMutex
+	cfg Config
+}
+
+// NewInjector returns an injector for cfg, which must be valid.
+func NewInjector(cfg Config) *Injector {
+	return &Injector{cfg: cfg}
+}
+
+// Config returns the faults in effect.
+func (i *Injector) Config() Config {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	cfg := i.cfg
+	cfg.Methods = slices.Clone(cfg.Methods)
+	return cfg
+}
+
+// Set replaces the faults in effect after validating cfg.
+func (i *Injector) Set(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	i.mu.Lock()
+	i.cfg = cfg
+	i.mu.Unlock()
+	logger.Info("faults updated", "enabled", cfg.Enabled,
+		"latency", cfg.Latency.String(), "error_rate", cfg.ErrorRate, "methods", cfg.Methods,
+		"drop_rate", cfg.DropRate, "duplicate_rate", cfg.DuplicateRate, "reorder_rate", cfg.ReorderRate)
+	return nil
+}
+
+// chance reports true with probability rate.
+func chance(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
+
+// jitter returns a random duration in [0, d).
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d)
+}
@@ -0,0 +1,81 @@
+package chaos
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor delays and fails the calls of the affected
+// methods before they are handled. Methods in exempt, such as the health
+// checks, are never affected.
+func (i *Injector) UnaryServerInterceptor(exempt ...string) grpc.UnaryServerInterceptor {
+	skip := exemptSet(exempt)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !skip[info.FullMethod] {
+			if err := i.inject(ctx, info.FullMethod); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (i *Injector) StreamServerInterceptor(exempt ...string) grpc.StreamServerInterceptor {
+	skip := exemptSet(exempt)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !skip[info.FullMethod] {
+			if err := i.inject(ss.Context(), info.FullMethod); err != nil {
+				return err
+			}
+		}
+		return handler(srv, ss)
+	}
+}
+
+// UnaryClientInterceptor delays and fails outgoing calls of the affected
+// methods without sending them, as a failing network would.
+func (i *Injector) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := i.inject(ctx, method); err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// inject waits out the configured latency and returns the injected error
+// of the call, if any.
+func (i *Injector) inject(ctx context.Context, fullMethod string) error {
+	cfg := i.Config()
+	if !cfg.Enabled || !cfg.affects(fullMethod) {
+		return nil
+	}
+
+	if delay := cfg.Latency + jitter(cfg.Jitter); delay > 0 {
+		t := time.NewTimer(delay)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+
+	if chance(cfg.ErrorRate) {
+		code, _ := cfg.code()
+		logger.InfoContext(ctx, "injected error", "method", fullMethod, "code", code.String())
+		return status.Error(code, "chaos: injected fault")
+	}
+	return nil
+}
+
+func exemptSet(methods []string) map[string]bool {
+	skip := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		skip[m] = true
+	}
+	return skip
+}
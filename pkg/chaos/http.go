@@ -0,0 +1,108 @@
+package chaos
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// configView is the JSON form of Config served by Handler.
+type configView struct {
+	Enabled   bool     `json:"enabled"`
+	Latency   string   `json:"latency"`
+	Jitter    string   `json:"jitter"`
+	ErrorRate float64  `json:"error_rate"`
+	ErrorCode string   `json:"error_code"`
+	Methods   []string `json:"methods"`
+
+	DropRate      float64 `json:"drop_rate"`
+	DuplicateRate float64 `json:"duplicate_rate"`
+	ReorderRate   float64 `json:"reorder_rate"`
+	ReorderDelay  string  `json:"reorder_delay"`
+}
+
+func viewOf(c Config) configView {
+	methods := c.Methods
+	if methods == nil {
+		methods = []string{}
+	}
+	return configView{
+		Enabled:       c.Enabled,
+		Latency:       c.Latency.String(),
+		Jitter:        c.Jitter.String(),
+		ErrorRate:     c.ErrorRate,
+		ErrorCode:     c.ErrorCode,
+		Methods:       methods,
+		DropRate:      c.DropRate,
+		DuplicateRate: c.DuplicateRate,
+		ReorderRate:   c.ReorderRate,
+		ReorderDelay:  c.ReorderDelay.String(),
+	}
+}
+
+func (v configView) config() (Config, error) {
+	c := Config{
+		Enabled:       v.Enabled,
+		ErrorRate:     v.ErrorRate,
+		ErrorCode:     v.ErrorCode,
+		Methods:       v.Methods,
+		DropRate:      v.DropRate,
+		DuplicateRate: v.DuplicateRate,
+		ReorderRate:   v.ReorderRate,
+	}
+	for _, d := range []struct {
+		s   string
+		out *time.Duration
+	}{{v.Latency, &c.Latency}, {v.Jitter, &c.Jitter}, {v.ReorderDelay, &c.ReorderDelay}} {
+		if d.s == "" {
+			continue
+		}
+		var err error
+		if *d.out, err = time.ParseDuration(d.s); err != nil {
+			return Config{}, err
+		}
+	}
+	return c, c.Validate()
+}
+
+// Handler serves the faults of i: GET returns them, PUT replaces them with
+// the JSON body, whose missing fields keep their current values, and
+// DELETE disables injection.
+func Handler(i *Injector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			view := viewOf(i.Config())
+			if err := json.NewDecoder(r.Body).Decode(&view); err != nil {
+				writeError(w, "invalid request body: "+err.Error())
+				return
+			}
+			cfg, err := view.config()
+			if err == nil {
+				err = i.Set(cfg)
+			}
+			if err != nil {
+				writeError(w, err.Error())
+				return
+			}
+		case http.MethodDelete:
+			cfg := i.Config()
+			cfg.Enabled = false
+			i.Set(cfg)
+		default:
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(viewOf(i.Config()))
+	})
+}
+
+func writeError(w http.ResponseWriter, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
@@ -0,0 +1,27 @@
+package circuitbreaker
+
+import (
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+)
+
+// Middleware runs handler through b, counting the errors failed reports as
+// failures; a nil failed counts every error. While the circuit is open the
+// message is held until a trial call may be made, so messages are not
+// dead-lettered for a dependency that is down; the worker takes no other
+// message meanwhile.
+func Middleware(b *Breaker, failed func(error) bool, handler broker.MessageHandler) broker.MessageHandler {
+	return func(msg *broker.Message) error {
+		for !b.Allow() {
+			time.Sleep(max(b.RetryIn(), 10*time.Millisecond))
+		}
+		err := handler(msg)
+		if failed == nil {
+			b.Record(err == nil)
+		} else {
+			b.Record(!failed(err))
+		}
+		return err
+	}
+}
@@ -0,0 +1,322 @@
+// Package circuitbreaker stops calling a dependency that keeps failing, so
+// callers fail fast instead of waiting for timeouts, and lets a single trial
+// call through once a cooldown has passed.
+//
+// A closed circuit opens after Failures consecutive failures, or when at
+// least MinRequests calls were made in the current Window and the share of
+// failed ones reached FailureRatio. An open circuit refuses calls for
+// Cooldown, then turns half-open: the next call is a trial that closes the
+// circuit when it succeeds and opens it again when it fails.
+package circuitbreaker
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+)
+
+var logger = logging.Component("circuit")
+
+// ErrOpen is returned for calls refused by an open circuit.
+var ErrOpen = errors.New("circuit breaker is open")
+
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+// States lists every state, in the order of their values.
+var States = []State{Closed, Open, HalfOpen}
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Config sets when the circuit opens and how long it stays open. A circuit
+// with neither Failures nor FailureRatio set never opens.
+type Config struct {
+	// Failures consecutive failures open the circuit; 0 disables the
+	// count.
+	Failures int `config:"breaker-failures" usage:"Consecutive failures that open the circuit, 0 disables"`
+
+	// FailureRatio of the calls made in Window, once there were at least
+	// MinRequests of them, opens the circuit; 0 disables the ratio.
+	FailureRatio float64       `config:"breaker-ratio" usage:"Share of failed calls in breaker-window that opens the circuit, 0 disables"`
+	MinRequests  int           `config:"breaker-min-requests" usage:"Calls in breaker-window before breaker-ratio applies"`
+	Window       time.Duration `config:"breaker-window" usage:"Period over which breaker-ratio is measured"`
+
+	// Cooldown is how long the circuit stays open before a trial call.
+	Cooldown time.Duration `config:"breaker-cooldown" usage:"How long the circuit stays open before a trial call"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Failures:    5,
+		MinRequests: 20,
+		Window:      10 * time.Second,
+		Cooldown:    30 * time.Second,
+	}
+}
+
+func (c Config) Validate() error {
+	switch {
+	case c.Failures < 0:
+		return errors.New("breaker-failures must not be negative")
+	case c.FailureRatio < 0 || c.FailureRatio > 1:
+		return fmt.Errorf("breaker-ratio must be between 0 and 1, got %g", c.FailureRatio)
+	case c.FailureRatio > 0 && (c.MinRequests < 1 || c.Window <= 0):
+		return errors.New("breaker-ratio requires a positive breaker-min-requests and breaker-window")
+	case c.Cooldown < 0:
+		return errors.New("breaker-cooldown must not be negative")
+	}
+	return nil
+}
+
+// Enabled reports whether a circuit with this config can ever open.
+func (c Config) Enabled() bool {
+	return c.Failures > 0 || c.FailureRatio > 0
+}
+
+// Option customizes a Breaker.
+type Option func(*Breaker)
+
+// OnStateChange calls fn after every transition of the circuit, with the
+// breaker's lock released.
+func OnStateChange(fn func(name string, from, to State)) Option {
+	return func(b *Breaker) {
+		b.listeners = append(b.listeners, fn)
+	}
+}
+
+// WithClock replaces time.Now, for tests.
+func WithClock(now func() time.Time) Option {
+	return func(b *Breaker) {
+		b.now = now
+	}
+}
+
+// Breaker is a circuit breaker around one dependency. A nil *Breaker allows
+// every call.
+type Breaker struct {
+	name      string
+	config    Config
+	now       func() time.Time
+	listeners []func(name string, from, to State)
+	metrics   *Metrics
+
+	mu          sync.Mutex
+	state       State
+	consecutive int
+	requests    int
+	failures    int
+	windowStart time.Time
+	openedAt    time.Time
+	trial       bool
+}
+
+// New returns a closed breaker named name, which labels its logs and
+// metrics.
+func New(name string, config Config, opts ...Option) *Breaker {
+	b := &Breaker{name: name, config: config, now: time.Now}
+	for _, opt := range opts {
+		opt(b)
+	}
+	b.windowStart = b.now()
+	return b
+}
+
+func (b *Breaker) Name() string {
+	if b == nil {
+		return ""
+	}
+	return b.name
+}
+
+// Allow reports whether a call may proceed, and must be followed by Record
+// when it does. In the half-open state only one trial call is allowed at a
+// time.
+func (b *Breaker) Allow() bool {
+	if b == nil || !b.config.Enabled() {
+		return true
+	}
+
+	b.mu.Lock()
+	allowed, changed, from := b.allowLocked()
+	b.mu.Unlock()
+
+	if changed {
+		b.notify(from, HalfOpen, 0)
+	}
+	if !allowed {
+		b.metrics.rejected(b.name)
+	}
+	return allowed
+}
+
+func (b *Breaker) allowLocked() (allowed, changed bool, from State) {
+	switch b.state {
+	case Open:
+		if b.now().Sub(b.openedAt) < b.config.Cooldown {
+			return false, false, Open
+		}
+		b.state = HalfOpen
+		b.trial = true
+		return true, true, Open
+	case HalfOpen:
+		if b.trial {
+			return false, false, HalfOpen
+		}
+		b.trial = true
+		return true, false, HalfOpen
+	default:
+		return true, false, Closed
+	}
+}
+
+// Record feeds the outcome of an allowed call back into the breaker.
+func (b *Breaker) Record(ok bool) {
+	if b == nil || !b.config.Enabled() {
+		return
+	}
+
+	b.mu.Lock()
+	from := b.state
+	to := b.recordLocked(ok)
+	failures := b.failures
+	b.mu.Unlock()
+
+	if to != from {
+		b.notify(from, to, failures)
+	}
+}
+
+func (b *Breaker) recordLocked(ok bool) State {
+	now := b.now()
+	b.trial = false
+
+	if b.state == Closed && b.config.Window > 0 && now.Sub(b.windowStart) >= b.config.Window {
+		b.requests, b.failures = 0, 0
+		b.windowStart = now
+	}
+	b.requests++
+
+	if ok {
+		b.consecutive = 0
+		if b.state != Closed {
+			b.reset(now)
+		}
+		return b.state
+	}
+
+	b.consecutive++
+	b.failures++
+	if b.state == HalfOpen || b.tripped() {
+		b.state = Open
+		b.openedAt = now
+	}
+	return b.state
+}
+
+// tripped reports whether the counts of a closed circuit open it.
+func (b *Breaker) tripped() bool {
+	c := b.config
+	if c.Failures > 0 && b.consecutive >= c.Failures {
+		return true
+	}
+	return c.FailureRatio > 0 && b.requests >= c.MinRequests &&
+		float64(b.failures) >= c.FailureRatio*float64(b.requests)
+}
+
+func (b *Breaker) reset(now time.Time) {
+	b.state = Closed
+	b.consecutive, b.requests, b.failures = 0, 0, 0
+	b.windowStart = now
+}
+
+// notify logs and reports a transition; failures is the count of failed
+// calls that opened the circuit.
+func (b *Breaker) notify(from, to State, failures int) {
+	switch to {
+	case Open:
+		logger.Warn(b.name+" circuit open", "failures", failures, "retry_in", b.config.Cooldown.String())
+	case HalfOpen:
+		logger.Info(b.name + " circuit half-open, sending a trial call")
+	case Closed:
+		logger.Info(b.name + " circuit closed")
+	}
+	b.metrics.transition(b.name, to)
+	for _, fn := range b.listeners {
+		fn(b.name, from, to)
+	}
+}
+
+// Available reports whether Allow would let a call through, without
+// claiming the half-open trial.
+func (b *Breaker) Available() bool {
+	if b == nil || !b.config.Enabled() {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		return b.now().Sub(b.openedAt) >= b.config.Cooldown
+	case HalfOpen:
+		return !b.trial
+	default:
+		return true
+	}
+}
+
+// RetryIn returns how long an open circuit keeps refusing calls, 0 when
+// it does not.
+func (b *Breaker) RetryIn() time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != Open {
+		return 0
+	}
+	return max(b.config.Cooldown-b.now().Sub(b.openedAt), 0)
+}
+
+func (b *Breaker) State() State {
+	if b == nil {
+		return Closed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Execute runs fn if the circuit allows it and records whether failed
+// reports its error as a failure of the dependency; a nil failed counts
+// every error. A refused call returns ErrOpen without running fn.
+func (b *Breaker) Execute(fn func() error, failed func(error) bool) error {
+	if !b.Allow() {
+		return ErrOpen
+	}
+	err := fn()
+	if failed == nil {
+		b.Record(err == nil)
+	} else {
+		b.Record(!failed(err))
+	}
+	return err
+}
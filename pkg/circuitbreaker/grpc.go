@@ -0,0 +1,35 @@
+package circuitbreaker
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TransportFailure reports whether a gRPC error says the service could not
+// be reached or did not answer in time. Errors about the request itself,
+// such as InvalidArgument or a declined payment, leave the circuit alone.
+func TransportFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// UnaryClientInterceptor runs outgoing calls through b, counting the
+// errors that TransportFailure reports as failures. Calls refused by an
+// open circuit fail with codes.Unavailable without being sent.
+func UnaryClientInterceptor(b *Breaker) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !b.Allow() {
+			return status.Errorf(codes.Unavailable, "%s circuit is open", b.Name())
+		}
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.Record(!TransportFailure(err))
+		return err
+	}
+}
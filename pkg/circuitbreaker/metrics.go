@@ -0,0 +1,74 @@
+package circuitbreaker
+
+import (
+	"sync"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/metrics"
+)
+
+// Metrics exports the state, transitions and refused calls of every
+// breaker created with WithMetrics. A nil *Metrics records nothing.
+type Metrics struct {
+	transitions *metrics.CounterVec
+	rejections  *metrics.CounterVec
+
+	mu       sync.Mutex
+	breakers []*Breaker
+}
+
+// NewMetrics registers the circuit breaker metrics on r.
+func NewMetrics(r *metrics.Registry) *Metrics {
+	m := &Metrics{
+		transitions: r.NewCounterVec("circuit_breaker_transitions_total",
+			"Circuit breaker state changes, by breaker and new state.",
+			"name", "state"),
+		rejections: r.NewCounterVec("circuit_breaker_rejected_total",
+			"Calls refused by an open circuit breaker, by breaker.",
+			"name"),
+	}
+	r.NewGaugeVecFunc("circuit_breaker_state",
+		"State of each circuit breaker: 1 for the current state, 0 otherwise.",
+		func(emit func(float64, ...string)) {
+			m.mu.Lock()
+			breakers := append([]*Breaker(nil), m.breakers...)
+			m.mu.Unlock()
+			for _, b := range breakers {
+				current := b.State()
+				for _, state := range States {
+					value := 0.0
+					if state == current {
+						value = 1
+					}
+					emit(value, b.name, state.String())
+				}
+			}
+		}, "name", "state")
+	return m
+}
+
+// WithMetrics records the breaker in m.
+func WithMetrics(m *Metrics) Option {
+	return func(b *Breaker) {
+		if m == nil {
+			return
+		}
+		b.metrics = m
+		m.mu.Lock()
+		m.breakers = append(m.breakers, b)
+		m.mu.Unlock()
+	}
+}
+
+func (m *Metrics) transition(name string, to State) {
+	if m == nil {
+		return
+	}
+	m.transitions.WithLabelValues(name, to.String()).Inc()
+}
+
+func (m *Metrics) rejected(name string) {
+	if m == nil {
+		return
+	}
+	m.rejections.WithLabelValues(name).Inc()
+}
@@ -0,0 +1,431 @@
+// Package config loads the startup configuration of a service into a typed
+// struct. Every setting is read from, in increasing precedence, the value
+// the struct held when it was registered, a YAML file, an environment
+// variable and a command-line flag.
+//
+// Settings are struct fields with a config tag naming them:
+//
+//	type Config struct {
+//		Port    int              `config:"port" usage:"gRPC server port"`
+//		Token   string           `config:"token,secret" usage:"API key sent to the payment service"`
+//		Payment PaymentConfig    `config:"payment"`
+//		Log     logging.Config   `config:"log"`
+//		Limit   ratelimit.Config `config:",inline"`
+//	}
+//
+// A named struct field groups its settings: they become a YAML mapping and
+// their flags are prefixed, so Payment.Addr is payment.addr in the file and
+// -payment-addr on the command line. An inline struct adds its settings to
+// the enclosing level. The environment variable of a setting is the service
+// name followed by its flag, upper case with dashes as underscores, such as
+// ORDER_PAYMENT_ADDR; an env tag replaces that name and env:"-" disables it.
+// Fields without a config tag are not settings.
+//
+// Settings may be strings, booleans, ints, int64s, float64s, durations and
+// string lists, which flags and environment variables spell as comma
+// separated values. Secret settings are redacted by -print-config.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Validator is implemented by config structs, at any level, that check
+// their values once loaded.
+type Validator interface {
+	Validate() error
+}
+
+type setting struct {
+	// path holds the YAML keys leading to the setting.
+	path   []string
+	flag   string
+	env    string
+	usage  string
+	secret bool
+
+	// index leads from the root struct to the field.
+	index []int
+}
+
+// Loader fills a config struct. Create it with Register before the flags
+// are parsed and call Load after.
+type Loader struct {
+	service  string
+	target   reflect.Value
+	defaults reflect.Value
+	settings []*setting
+
+	path  string
+	print bool
+	flags map[*setting]reflect.Value
+}
+
+// Register defines a flag for every setting of cfg, a pointer to a struct,
+// on fs, along with -config naming the YAML file (env SERVICE_CONFIG) and
+// -print-config. The current values of cfg are the defaults. It panics if
+// cfg holds a setting of an unsupported type.
+func Register(fs *flag.FlagSet, service string, cfg any) *Loader {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("config: Register needs a pointer to a struct, got %T", cfg))
+	}
+
+	l := &Loader{
+		service:  service,
+		target:   v.Elem(),
+		defaults: reflect.New(v.Elem().Type()).Elem(),
+		flags:    make(map[*setting]reflect.Value),
+	}
+	l.defaults.Set(v.Elem())
+
+	configEnv := l.envName("config")
+	fs.StringVar(&l.path, "config", os.Getenv(configEnv), "YAML config file (env "+configEnv+")")
+	fs.BoolVar(&l.print, "print-config", false, "Print the resolved configuration as YAML and exit")
+
+	l.collect(v.Elem().Type(), nil, "", nil)
+	for _, s := range l.settings {
+		usage := s.usage
+		if s.env != "" {
+			usage += " (env " + s.env + ")"
+		}
+		fs.Var(&flagValue{l: l, s: s}, s.flag, usage)
+	}
+	return l
+}
+
+func (l *Loader) collect(t reflect.Type, path []string, prefix string, index []int) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("config")
+		if !ok || !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		if f.Type.Kind() == reflect.Struct && f.Type != durationType {
+			if opts == "inline" {
+				l.collect(f.Type, path, prefix, fieldIndex)
+				continue
+			}
+			l.collect(f.Type, appendPath(path, name), joinFlag(prefix, name), fieldIndex)
+			continue
+		}
+		if !supported(f.Type) {
+			panic(fmt.Sprintf("config: unsupported type %s of setting %s", f.Type, joinFlag(prefix, name)))
+		}
+
+		s := &setting{
+			path:   appendPath(path, name),
+			flag:   joinFlag(prefix, name),
+			usage:  f.Tag.Get("usage"),
+			secret: opts == "secret",
+			index:  fieldIndex,
+		}
+		switch env := f.Tag.Get("env"); env {
+		case "":
+			s.env = l.envName(s.flag)
+		case "-":
+		default:
+			s.env = env
+		}
+		l.settings = append(l.settings, s)
+	}
+}
+
+func (l *Loader) envName(flagName string) string {
+	return strings.ToUpper(strings.ReplaceAll(l.service+"_"+flagName, "-", "_"))
+}
+
+func appendPath(path []string, name string) []string {
+	return append(append([]string(nil), path...), strings.ReplaceAll(name, "-", "_"))
+}
+
+func joinFlag(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "-" + name
+}
+
+// Path returns the config file named by -config or its environment
+// variable, or an empty string.
+func (l *Loader) Path() string {
+	return l.path
+}
+
+// Load resets the config struct to its defaults and applies the YAML file,
+// the environment and the flags given on the command line. It then runs
+// the Validate methods of the struct and of its nested structs. With
+// -print-config it writes the result to stdout and exits, with status 1
+// if validation failed.
+func (l *Loader) Load() error {
+	l.target.Set(l.defaults)
+
+	if l.path != "" {
+		if err := l.loadFile(l.path); err != nil {
+			return err
+		}
+	}
+	for _, s := range l.settings {
+		if s.env == "" {
+			continue
+		}
+		raw := os.Getenv(s.env)
+		if raw == "" {
+			continue
+		}
+		v, err := parse(l.field(s).Type(), raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", s.env, err)
+		}
+		l.field(s).Set(v)
+	}
+	for s, v := range l.flags {
+		l.field(s).Set(v)
+	}
+
+	err := validate(l.target, "")
+	if l.print {
+		if printErr := l.Print(os.Stdout); printErr != nil && err == nil {
+			err = printErr
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	return err
+}
+
+func (l *Loader) field(s *setting) reflect.Value {
+	return l.target.FieldByIndex(s.index)
+}
+
+func (l *Loader) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	for _, s := range l.settings {
+		node := lookup(doc.Content[0], s.path)
+		if node == nil {
+			continue
+		}
+		field := l.field(s)
+		if node.Kind == yaml.ScalarNode && field.Type() == stringsType {
+			field.Set(reflect.ValueOf(splitList(node.Value)))
+			continue
+		}
+		if err := node.Decode(field.Addr().Interface()); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, strings.Join(s.path, "."), err)
+		}
+	}
+	return nil
+}
+
+func lookup(node *yaml.Node, path []string) *yaml.Node {
+	for _, key := range path {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				next = node.Content[i+1]
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// validate runs the Validate methods of v and of its nested structs, the
+// innermost first.
+func validate(v reflect.Value, path string) error {
+	var errs []error
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		tag, ok := f.Tag.Lookup("config")
+		if !ok || !f.IsExported() || f.Type.Kind() != reflect.Struct || f.Type == durationType {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		sub := path
+		if opts != "inline" {
+			sub = joinFlag(path, name)
+		}
+		errs = append(errs, validate(v.Field(i), sub))
+	}
+
+	if validator, ok := v.Addr().Interface().(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			if path != "" {
+				err = fmt.Errorf("%s: %w", path, err)
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Print writes the current configuration as YAML, with secrets redacted.
+func (l *Loader) Print(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, s := range l.settings {
+		parent := root
+		for _, key := range s.path[:len(s.path)-1] {
+			parent = child(parent, key)
+		}
+
+		value := &yaml.Node{}
+		field := l.field(s)
+		if s.secret && !field.IsZero() {
+			value.SetString("<redacted>")
+		} else if err := value.Encode(field.Interface()); err != nil {
+			return err
+		}
+		parent.Content = append(parent.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: s.path[len(s.path)-1]}, value)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func child(parent *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == key {
+			return parent.Content[i+1]
+		}
+	}
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, node)
+	return node
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	stringsType  = reflect.TypeOf([]string(nil))
+)
+
+func supported(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64:
+		return true
+	}
+	return t == stringsType
+}
+
+func parse(t reflect.Type, raw string) (reflect.Value, error) {
+	v := reflect.New(t).Elem()
+	switch {
+	case t == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return v, err
+		}
+		v.SetInt(int64(d))
+	case t == stringsType:
+		v.Set(reflect.ValueOf(splitList(raw)))
+	case t.Kind() == reflect.String:
+		v.SetString(raw)
+	case t.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return v, err
+		}
+		v.SetBool(b)
+	case t.Kind() == reflect.Int, t.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return v, err
+		}
+		v.SetInt(n)
+	case t.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return v, err
+		}
+		v.SetFloat(f)
+	}
+	return v, nil
+}
+
+func format(v reflect.Value) string {
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Type() == stringsType:
+		return strings.Join(v.Interface().([]string), ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func splitList(s string) []string {
+	out := []string{}
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// flagValue records the value of a setting given on the command line. It
+// is applied by Load, after the file and the environment.
+type flagValue struct {
+	l *Loader
+	s *setting
+}
+
+func (f *flagValue) String() string {
+	if f == nil || f.l == nil {
+		return ""
+	}
+	v := f.l.defaults.FieldByIndex(f.s.index)
+	if f.s.secret && !v.IsZero() {
+		return "<redacted>"
+	}
+	return format(v)
+}
+
+func (f *flagValue) Set(raw string) error {
+	v, err := parse(f.l.defaults.FieldByIndex(f.s.index).Type(), raw)
+	if err != nil {
+		return err
+	}
+	f.l.flags[f.s] = v
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.l.defaults.FieldByIndex(f.s.index).Kind() == reflect.Bool
+}
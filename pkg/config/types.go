@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tlsutil"
+)
+
+// CheckPort reports an error unless port is a valid TCP port.
+func CheckPort(name string, port int) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("%s must be between 1 and 65535, got %d", name, port)
+	}
+	return nil
+}
+
+// HTTPServer holds the listener settings of an HTTP server. Registered as
+// http, its port is -http-port.
+type HTTPServer struct {
+	Port         int           `config:"port" usage:"HTTP server port"`
+	ReadTimeout  time.Duration `config:"read-timeout" usage:"Time allowed to read a request, body included"`
+	WriteTimeout time.Duration `config:"write-timeout" usage:"Time allowed to write a response; event streams clear it"`
+	IdleTimeout  time.Duration `config:"idle-timeout" usage:"Time an idle keep-alive connection stays open"`
+}
+
+// DefaultHTTPServer returns the settings used by the services for port.
+func DefaultHTTPServer(port int) HTTPServer {
+	return HTTPServer{
+		Port:         port,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+}
+
+func (c HTTPServer) Validate() error {
+	if err := CheckPort("port", c.Port); err != nil {
+		return err
+	}
+	if c.ReadTimeout < 0 || c.WriteTimeout < 0 || c.IdleTimeout < 0 {
+		return fmt.Errorf("timeouts must not be negative")
+	}
+	return nil
+}
+
+// Addr returns the listen address, on all interfaces.
+func (c HTTPServer) Addr() string {
+	return fmt.Sprintf(":%d", c.Port)
+}
+
+// Server returns an http.Server for h with these settings.
+func (c HTTPServer) Server(h http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         c.Addr(),
+		Handler:      h,
+		ReadTimeout:  c.ReadTimeout,
+		WriteTimeout: c.WriteTimeout,
+		IdleTimeout:  c.IdleTimeout,
+	}
+}
+
+// ServerTLS holds the certificate settings of a gRPC server. TLS is off
+// while no file is set.
+type ServerTLS struct {
+	Cert              string `config:"cert" usage:"TLS certificate file"`
+	Key               string `config:"key" usage:"TLS private key file"`
+	CA                string `config:"ca" usage:"CA file used to verify client certificates"`
+	RequireClientCert bool   `config:"require-client-cert" usage:"Require and verify client certificates"`
+}
+
+func (c ServerTLS) Config() tlsutil.Config {
+	return tlsutil.Config{CertFile: c.Cert, KeyFile: c.Key, CAFile: c.CA, RequireClientCert: c.RequireClientCert}
+}
+
+func (c ServerTLS) Validate() error {
+	if c.Config().Enabled() && (c.Cert == "" || c.Key == "") {
+		return tlsutil.ErrIncompleteKeyPair
+	}
+	if c.RequireClientCert && c.CA == "" {
+		return tlsutil.ErrClientCAMissing
+	}
+	return nil
+}
+
+// ClientTLS holds the certificate settings of a gRPC client. TLS is off
+// while no file is set.
+type ClientTLS struct {
+	Cert       string `config:"cert" usage:"Client certificate for mTLS"`
+	Key        string `config:"key" usage:"Client private key for mTLS"`
+	CA         string `config:"ca" usage:"CA file used to verify the server"`
+	ServerName string `config:"server-name" usage:"Expected server name"`
+}
+
+func (c ClientTLS) Config() tlsutil.Config {
+	return tlsutil.Config{CertFile: c.Cert, KeyFile: c.Key, CAFile: c.CA, ServerName: c.ServerName}
+}
+
+func (c ClientTLS) Validate() error {
+	if (c.Cert == "") != (c.Key == "") {
+		return tlsutil.ErrIncompleteKeyPair
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Consul resolves a service from the instances Consul reports as passing
+// their health checks.
+type Consul struct {
+	addr   string
+	token  string
+	client *http.Client
+}
+
+// NewConsul returns a resolver for the Consul agent or server at addr, an
+// HTTP base URL such as http://localhost:8500. token is sent as the ACL
+// token when set.
+func NewConsul(addr, token string) *Consul {
+	return &Consul{
+		addr:   strings.TrimRight(addr, "/"),
+		token:  token,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// consulEntry is an element of the /v1/health/service response. The
+// service address is empty when the instance uses the node's address.
+type consulEntry struct {
+	Node struct {
+		Address string
+	}
+	Service struct {
+		Address string
+		Port    int
+	}
+}
+
+func (c *Consul) Resolve(ctx context.Context, service string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.addr+"/v1/health/service/"+url.PathEscape(service)+"?passing=true", nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("consul: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("consul: %s", resp.Status)
+	}
+
+	var entries []consulEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("consul: decode response: %w", err)
+	}
+	addrs := make([]string, 0, len(entries))
+	for _, e := range entries {
+		host := e.Service.Address
+		if host == "" {
+			host = e.Node.Address
+		}
+		addrs = append(addrs, net.JoinHostPort(host, strconv.Itoa(e.Service.Port)))
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("%w for %s", ErrNoEndpoints, service)
+	}
+	return addrs, nil
+}
@@ -0,0 +1,111 @@
+// Package discovery resolves logical service names, such as payment, into
+// the host:port addresses currently serving them. The addresses come from a
+// static list, DNS or Consul, and a gRPC resolver keeps client connections
+// up to date as they change, so endpoints can move without restarting the
+// clients.
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+)
+
+var logger = logging.Component("discovery")
+
+// ErrNoEndpoints is returned for services without addresses.
+var ErrNoEndpoints = errors.New("no endpoints")
+
+// Resolver looks up the addresses of a service.
+type Resolver interface {
+	// Resolve returns the host:port addresses of service, or an error
+	// wrapping ErrNoEndpoints when there are none.
+	Resolve(ctx context.Context, service string) ([]string, error)
+}
+
+// Backends.
+const (
+	BackendStatic = "static"
+	BackendDNS    = "dns"
+	BackendConsul = "consul"
+)
+
+// Config selects the backend resolving logical names. An empty Backend
+// disables discovery and addresses are dialed as given.
+type Config struct {
+	Backend string `config:"discovery" usage:"Backend resolving logical service names: static, dns or consul; empty disables discovery"`
+
+	Static []string `config:"discovery-static" usage:"Comma separated name=host:port[|host:port...] entries of the static backend"`
+
+	DNSDomain string `config:"discovery-dns-domain" usage:"Domain appended to service names looked up in DNS"`
+	DNSPort   int    `config:"discovery-dns-port" usage:"Port of the A and AAAA records of services without SRV records, 0 requires SRV"`
+
+	ConsulAddr  string `config:"discovery-consul-addr" usage:"Consul HTTP API base URL"`
+	ConsulToken string `config:"discovery-consul-token,secret" usage:"ACL token sent to Consul"`
+
+	Refresh time.Duration `config:"discovery-refresh" usage:"How often the addresses of each service are looked up again"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		ConsulAddr: "http://localhost:8500",
+		Refresh:    10 * time.Second,
+	}
+}
+
+func (c Config) Validate() error {
+	switch c.Backend {
+	case "":
+		return nil
+	case BackendStatic:
+		if _, err := ParseStatic(c.Static); err != nil {
+			return fmt.Errorf("discovery-static: %w", err)
+		}
+	case BackendDNS:
+		if c.DNSPort < 0 || c.DNSPort > 65535 {
+			return errors.New("discovery-dns-port must be between 0 and 65535")
+		}
+	case BackendConsul:
+		if c.ConsulAddr == "" {
+			return errors.New("discovery consul requires discovery-consul-addr")
+		}
+	default:
+		return fmt.Errorf("unknown discovery backend %q", c.Backend)
+	}
+	if c.Refresh <= 0 {
+		return errors.New("discovery-refresh must be positive")
+	}
+	return nil
+}
+
+// Enabled reports whether a backend is configured.
+func (c Config) Enabled() bool {
+	return c.Backend != ""
+}
+
+// New returns the resolver of the configured backend, or nil when
+// discovery is disabled. cfg must be valid.
+func New(cfg Config) (Resolver, error) {
+	switch cfg.Backend {
+	case "":
+		return nil, nil
+	case BackendStatic:
+		return ParseStatic(cfg.Static)
+	case BackendDNS:
+		return &DNS{Domain: cfg.DNSDomain, Port: cfg.DNSPort}, nil
+	case BackendConsul:
+		return NewConsul(cfg.ConsulAddr, cfg.ConsulToken), nil
+	default:
+		return nil, fmt.Errorf("unknown discovery backend %q", cfg.Backend)
+	}
+}
+
+// IsName reports whether addr is a logical service name rather than a
+// host:port address, a list of them or a gRPC target.
+func IsName(addr string) bool {
+	return addr != "" && !strings.ContainsAny(addr, ":,/")
+}
@@ -0,0 +1,56 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// DNS resolves a service from the SRV records of _service._tcp.Domain, or,
+// when it has none and Port is set, from the A and AAAA records of
+// service.Domain.
+type DNS struct {
+	Domain string
+	Port   int
+
+	// Resolver replaces net.DefaultResolver.
+	Resolver *net.Resolver
+}
+
+func (d *DNS) Resolve(ctx context.Context, service string) ([]string, error) {
+	r := d.Resolver
+	if r == nil {
+		r = net.DefaultResolver
+	}
+
+	_, srvs, srvErr := r.LookupSRV(ctx, service, "tcp", d.Domain)
+	if srvErr == nil && len(srvs) > 0 {
+		addrs := make([]string, 0, len(srvs))
+		for _, srv := range srvs {
+			addrs = append(addrs, net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port))))
+		}
+		return addrs, nil
+	}
+	if d.Port == 0 {
+		if srvErr != nil {
+			return nil, fmt.Errorf("%w for %s: %v", ErrNoEndpoints, service, srvErr)
+		}
+		return nil, fmt.Errorf("%w for %s", ErrNoEndpoints, service)
+	}
+
+	host := service
+	if d.Domain != "" {
+		host += "." + d.Domain
+	}
+	ips, err := r.LookupHost(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("%w for %s: %v", ErrNoEndpoints, service, err)
+	}
+	addrs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, net.JoinHostPort(ip, strconv.Itoa(d.Port)))
+	}
+	return addrs, nil
+}
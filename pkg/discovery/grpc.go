@@ -0,0 +1,129 @@
+package discovery
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/resolver"
+)
+
+// Scheme is the scheme of targets resolved through a Builder.
+const Scheme = "discovery"
+
+// Builder is a gRPC resolver builder for discovery:///name targets. Each
+// connection looks the name up every refresh interval, and when gRPC asks
+// for it after a connection failure, and switches to the new addresses as
+// soon as they change. A lookup that fails keeps the last addresses.
+type Builder struct {
+	resolver Resolver
+	refresh  time.Duration
+}
+
+// NewBuilder returns a builder looking names up in r every refresh.
+func NewBuilder(r Resolver, refresh time.Duration) *Builder {
+	return &Builder{resolver: r, refresh: refresh}
+}
+
+// Target returns the target dialing the service name through b, and the
+// option installing b on the client. The name is also the authority, and
+// so the TLS server name, of the connection.
+func (b *Builder) Target(name string) (string, grpc.DialOption) {
+	return Scheme + ":///" + name, grpc.WithResolvers(b)
+}
+
+func (b *Builder) Scheme() string {
+	return Scheme
+}
+
+func (b *Builder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &watcher{
+		builder: b,
+		service: target.Endpoint(),
+		cc:      cc,
+		cancel:  cancel,
+		now:     make(chan struct{}, 1),
+	}
+	w.wg.Add(1)
+	go w.run(ctx)
+	return w, nil
+}
+
+// watcher keeps the addresses of one connection up to date.
+type watcher struct {
+	builder *Builder
+	service string
+	cc      resolver.ClientConn
+	cancel  context.CancelFunc
+	now     chan struct{}
+	wg      sync.WaitGroup
+
+	last []string
+}
+
+func (w *watcher) run(ctx context.Context) {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.builder.refresh)
+	defer ticker.Stop()
+
+	for {
+		w.resolve(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.now:
+		}
+	}
+}
+
+func (w *watcher) resolve(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, max(w.builder.refresh, 5*time.Second))
+	defer cancel()
+
+	addrs, err := w.builder.resolver.Resolve(ctx, w.service)
+	if ctx.Err() != nil && err != nil {
+		return
+	}
+	if err != nil {
+		logger.WarnContext(ctx, "service lookup failed", "name", w.service, "addresses", len(w.last), logging.Err(err))
+		if w.last == nil {
+			w.cc.ReportError(err)
+		}
+		return
+	}
+
+	slices.Sort(addrs)
+	addrs = slices.Compact(addrs)
+	if slices.Equal(addrs, w.last) {
+		return
+	}
+	logger.InfoContext(ctx, "service addresses changed", "name", w.service, "addresses", addrs)
+	w.last = addrs
+
+	endpoints := make([]resolver.Endpoint, len(addrs))
+	for i, addr := range addrs {
+		endpoints[i] = resolver.Endpoint{Addresses: []resolver.Address{{Addr: addr}}}
+	}
+	if err := w.cc.UpdateState(resolver.State{Endpoints: endpoints}); err != nil {
+		logger.WarnContext(ctx, "service addresses rejected", "name", w.service, logging.Err(err))
+	}
+}
+
+// ResolveNow looks the name up again without waiting for the next refresh.
+func (w *watcher) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case w.now <- struct{}{}:
+	default:
+	}
+}
+
+func (w *watcher) Close() {
+	w.cancel()
+	w.wg.Wait()
+}
@@ -0,0 +1,39 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Static resolves services from a fixed table.
+type Static map[string][]string
+
+// ParseStatic reads name=host:port[|host:port...] entries.
+func ParseStatic(entries []string) (Static, error) {
+	s := make(Static, len(entries))
+	for _, entry := range entries {
+		name, list, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%q is not name=host:port", entry)
+		}
+		for _, addr := range strings.Split(list, "|") {
+			addr = strings.TrimSpace(addr)
+			if !strings.Contains(addr, ":") {
+				return nil, fmt.Errorf("%q: %q is not host:port", entry, addr)
+			}
+			s[name] = append(s[name], addr)
+		}
+	}
+	return s, nil
+}
+
+func (s Static) Resolve(_ context.Context, service string) ([]string, error) {
+	addrs := s[service]
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("%w for %s", ErrNoEndpoints, service)
+	}
+	return slices.Clone(addrs), nil
+}
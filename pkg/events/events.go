@@ -0,0 +1,85 @@
+// Package events defines the versioned events published on the broker.
+// Publishers build them with the New constructors and consumers read them
+// with Decode, so both sides share one definition of each payload.
+//
+// A change that old consumers can ignore, such as a new optional field, is
+// made to the existing struct. Any other change adds a struct for the next
+// version, say OrderCreatedV2, published alongside or instead of the old one
+// once every consumer reads it.
+package events
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+)
+
+// Event types, also used as broker message types.
+const (
+	TypeOrderCreated     = "order.created"
+	TypeOrderCancelled   = "order.cancelled"
+	TypePaymentCompleted = "payment.charged"
+)
+
+// ErrUnexpectedEvent is returned by Decode for a message of another type or
+// version than the event it decodes into.
+var ErrUnexpectedEvent = errors.New("unexpected event")
+
+// Header is embedded in every event.
+type Header struct {
+	EventID   string    `json:"event_id"`
+	EventType string    `json:"event_type"`
+	Version   int       `json:"version"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func newHeader(eventType, id string, version int) Header {
+	return Header{
+		EventID:   id,
+		EventType: eventType,
+		Version:   version,
+		Timestamp: time.Now(),
+	}
+}
+
+// Event is implemented by the event structs of this package.
+type Event interface {
+	// Type and SchemaVersion name the payload the struct decodes.
+	Type() string
+	SchemaVersion() int
+
+	header() *Header
+}
+
+func (h *Header) header() *Header { return h }
+
+// NewMessage returns a broker message of the type of e with e as payload
+// and its schema version as header.
+func NewMessage(e Event) (*broker.Message, error) {
+	msg, err := broker.NewMessage(e.Type(), e)
+	if err != nil {
+		return nil, err
+	}
+	msg.SetHeaders(broker.MessageHeaders{SchemaVersion: e.SchemaVersion()})
+	return msg, nil
+}
+
+// Decode unmarshals the payload of msg into e and checks that it has the
+// type and version of e. Events published before versioning carry no
+// version and are read as version 1.
+func Decode(msg *broker.Message, e Event) error {
+	if err := msg.Decode(e); err != nil {
+		return err
+	}
+	h := e.header()
+	if h.Version == 0 {
+		h.Version = 1
+	}
+	if h.EventType != e.Type() || h.Version != e.SchemaVersion() {
+		return fmt.Errorf("%w: %s v%d, want %s v%d", ErrUnexpectedEvent,
+			h.EventType, h.Version, e.Type(), e.SchemaVersion())
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package events
+
+import (
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+)
+
+// OrderCreatedV1 is published once a new order is stored, paid or, when it
+// is charged in the background, still pending.
+type OrderCreatedV1 struct {
+	Header
+
+	// Order is the full order as stored.
+	Order order.Order `json:"order"`
+}
+
+func NewOrderCreatedV1(o order.Order) OrderCreatedV1 {
+	return OrderCreatedV1{
+		Header: newHeader(TypeOrderCreated, "evt_"+o.ID, 1),
+		Order:  o,
+	}
+}
+
+func (*OrderCreatedV1) Type() string       { return TypeOrderCreated }
+func (*OrderCreatedV1) SchemaVersion() int { return 1 }
+
+// OrderCancelledV1 is published when a customer or operator cancels an
+// order.
+type OrderCancelledV1 struct {
+	Header
+
+	OrderID string `json:"order_id"`
+	Reason  string `json:"reason"`
+}
+
+func NewOrderCancelledV1(orderID, reason string) OrderCancelledV1 {
+	return OrderCancelledV1{
+		Header:  newHeader(TypeOrderCancelled, "evt_cancelled_"+orderID, 1),
+		OrderID: orderID,
+		Reason:  reason,
+	}
+}
+
+func (*OrderCancelledV1) Type() string       { return TypeOrderCancelled }
+func (*OrderCancelledV1) SchemaVersion() int { return 1 }
@@ -0,0 +1,32 @@
+package events
+
+import (
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+)
+
+// PaymentCompletedV1 is published by the payment service for every
+// successful scheduled charge of a subscription.
+type PaymentCompletedV1 struct {
+	Header
+
+	TransactionID  string `json:"transaction_id"`
+	OrderID        string `json:"order_id"`
+	SubscriptionID string `json:"subscription_id,omitempty"`
+	Cycle          int32  `json:"cycle,omitempty"`
+	AmountCents    int64  `json:"amount_cents"`
+	Currency       string `json:"currency"`
+}
+
+// NewPaymentCompletedV1 describes the successful charge resp of req.
+func NewPaymentCompletedV1(req *payment.PaymentRequest, resp *payment.PaymentResponse) PaymentCompletedV1 {
+	return PaymentCompletedV1{
+		Header:        newHeader(TypePaymentCompleted, "evt_pay_"+req.IdempotencyKey, 1),
+		TransactionID: resp.TransactionID,
+		OrderID:       req.OrderID,
+		AmountCents:   req.AmountCents,
+		Currency:      req.Currency,
+	}
+}
+
+func (*PaymentCompletedV1) Type() string       { return TypePaymentCompleted }
+func (*PaymentCompletedV1) SchemaVersion() int { return 1 }
@@ -0,0 +1,19 @@
+package events
+
+import (
+	"embed"
+	"fmt"
+)
+
+//go:embed schemas/*.json
+var schemas embed.FS
+
+// Schema returns the JSON Schema of version of eventType, for consumers
+// that do not use this package.
+func Schema(eventType string, version int) ([]byte, error) {
+	data, err := schemas.ReadFile(fmt.Sprintf("schemas/%s.v%d.json", eventType, version))
+	if err != nil {
+		return nil, fmt.Errorf("no schema for %s v%d", eventType, version)
+	}
+	return data, nil
+}
@@ -0,0 +1,96 @@
+package grpcconn
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/health" // client-side health checking
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+// Load balancing policies.
+const (
+	// RoundRobin spreads calls over every ready address.
+	RoundRobin = "round_robin"
+
+	// PickFirst sends every call to the first address that connects and
+	// moves to the next one when it fails.
+	PickFirst = "pick_first"
+)
+
+// BalancingConfig selects how a client spreads calls over the addresses of
+// its target.
+type BalancingConfig struct {
+	Policy string `config:"lb-policy" usage:"round_robin spreads calls over every healthy address, pick_first uses one address at a time"`
+
+	// HealthCheck watches the gRPC health service of every address, so
+	// round_robin stops sending calls to replicas that are NOT_SERVING.
+	HealthCheck bool `config:"lb-health-check" usage:"Skip addresses whose gRPC health service is not SERVING"`
+}
+
+func DefaultBalancingConfig() BalancingConfig {
+	return BalancingConfig{
+		Policy:      RoundRobin,
+		HealthCheck: true,
+	}
+}
+
+func (c BalancingConfig) Validate() error {
+	if c.Policy != RoundRobin && c.Policy != PickFirst {
+		return fmt.Errorf("lb-policy must be %s or %s", RoundRobin, PickFirst)
+	}
+	return nil
+}
+
+// BalancingOptions applies cfg through the default service config of the
+// client. healthService is the service name checked when cfg.HealthCheck
+// is set. pick_first follows as the fallback policy should round_robin be
+// unavailable.
+func BalancingOptions(cfg BalancingConfig, healthService string) []grpc.DialOption {
+	type policy map[string]struct{}
+	sc := struct {
+		LoadBalancingConfig []policy `json:"loadBalancingConfig"`
+		HealthCheckConfig   *struct {
+			ServiceName string `json:"serviceName"`
+		} `json:"healthCheckConfig,omitempty"`
+	}{
+		LoadBalancingConfig: []policy{{cfg.Policy: {}}},
+	}
+	if cfg.Policy != PickFirst {
+		sc.LoadBalancingConfig = append(sc.LoadBalancingConfig, policy{PickFirst: {}})
+	}
+	if cfg.HealthCheck {
+		sc.HealthCheckConfig = &struct {
+			ServiceName string `json:"serviceName"`
+		}{healthService}
+	}
+
+	data, _ := json.Marshal(sc)
+	return []grpc.DialOption{grpc.WithDefaultServiceConfig(string(data))}
+}
+
+// Target turns addrs into a target for grpc.NewClient. addrs is either a
+// gRPC target, such as dns:///payment:50051 which resolves to every address
+// of the name, or a comma separated list of host:port addresses served by a
+// static resolver returned in the options.
+func Target(addrs string) (string, []grpc.DialOption) {
+	if strings.Contains(addrs, ":///") || !strings.Contains(addrs, ",") {
+		return addrs, nil
+	}
+
+	var endpoints []resolver.Endpoint
+	for _, addr := range strings.Split(addrs, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			endpoints = append(endpoints, resolver.Endpoint{Addresses: []resolver.Address{{Addr: addr}}})
+		}
+	}
+	r := manual.NewBuilderWithScheme("static")
+	r.InitialState(resolver.State{Endpoints: endpoints})
+
+	// The first address names the target, so it is also the TLS server
+	// name the replicas present.
+	return "static:///" + endpoints[0].Addresses[0].Addr, []grpc.DialOption{grpc.WithResolvers(r)}
+}
@@ -0,0 +1,147 @@
+// Package grpcconn configures gRPC keepalive, connection lifetime and
+// reconnect backoff, logs client connection state changes and checks the
+// health of the server behind a connection.
+package grpcconn
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/connectivity"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+)
+
+// ServerConfig controls how long server connections live and how idle
+// connections are probed. Zero durations keep the gRPC defaults.
+type ServerConfig struct {
+	// MaxConnectionIdle closes connections without active streams after this long.
+	MaxConnectionIdle time.Duration `config:"max-connection-idle" usage:"Close connections idle for this long"`
+
+	// MaxConnectionAge forces clients to reconnect periodically so that new
+	// server instances behind a load balancer receive traffic.
+	MaxConnectionAge time.Duration `config:"max-connection-age" usage:"Ask clients to reconnect after this long so load spreads over new instances"`
+
+	// MaxConnectionAgeGrace lets in-flight RPCs finish after MaxConnectionAge.
+	MaxConnectionAgeGrace time.Duration `config:"max-connection-age-grace" usage:"Time allowed for in-flight RPCs after max-connection-age"`
+
+	// Time and Timeout configure server-side pings on idle connections.
+	Time    time.Duration `config:"keepalive-time" usage:"Ping idle clients after this long"`
+	Timeout time.Duration `config:"keepalive-timeout" usage:"Close the connection if a ping is not answered within this time"`
+
+	// MinClientPingInterval is the most frequent client ping the server
+	// accepts before closing the connection with ENHANCE_YOUR_CALM.
+	MinClientPingInterval time.Duration `config:"keepalive-min-ping" usage:"Shortest client ping interval accepted"`
+	PermitWithoutStream   bool
+}
+
+func DefaultServerConfig() ServerConfig {
+	return ServerConfig{
+		MaxConnectionIdle:     15 * time.Minute,
+		MaxConnectionAge:      30 * time.Minute,
+		MaxConnectionAgeGrace: 30 * time.Second,
+		Time:                  2 * time.Hour,
+		Timeout:               20 * time.Second,
+		MinClientPingInterval: 10 * time.Second,
+		PermitWithoutStream:   true,
+	}
+}
+
+func ServerOptions(cfg ServerConfig) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle:     cfg.MaxConnectionIdle,
+			MaxConnectionAge:      cfg.MaxConnectionAge,
+			MaxConnectionAgeGrace: cfg.MaxConnectionAgeGrace,
+			Time:                  cfg.Time,
+			Timeout:               cfg.Timeout,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             cfg.MinClientPingInterval,
+			PermitWithoutStream: cfg.PermitWithoutStream,
+		}),
+	}
+}
+
+// ClientConfig controls client pings and reconnect backoff.
+type ClientConfig struct {
+	// Time between pings on an idle connection. It must not be shorter than
+	// the server's MinClientPingInterval.
+	Time                time.Duration `config:"keepalive-time" usage:"Ping the server after this long without activity"`
+	Timeout             time.Duration `config:"keepalive-timeout" usage:"Consider the connection dead if a ping is not answered within this time"`
+	PermitWithoutStream bool
+
+	BackoffBaseDelay  time.Duration `config:"backoff-base" usage:"First reconnect delay"`
+	BackoffMaxDelay   time.Duration `config:"backoff-max" usage:"Upper bound for reconnect delays"`
+	MinConnectTimeout time.Duration `config:"connect-timeout" usage:"Minimum time allowed for a connection attempt"`
+}
+
+func DefaultClientConfig() ClientConfig {
+	return ClientConfig{
+		Time:                30 * time.Second,
+		Timeout:             10 * time.Second,
+		PermitWithoutStream: true,
+		BackoffBaseDelay:    backoff.DefaultConfig.BaseDelay,
+		BackoffMaxDelay:     backoff.DefaultConfig.MaxDelay,
+		MinConnectTimeout:   20 * time.Second,
+	}
+}
+
+func DialOptions(cfg ClientConfig) []grpc.DialOption {
+	bo := backoff.DefaultConfig
+	bo.BaseDelay = cfg.BackoffBaseDelay
+	bo.MaxDelay = cfg.BackoffMaxDelay
+
+	return []grpc.DialOption{
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                cfg.Time,
+			Timeout:             cfg.Timeout,
+			PermitWithoutStream: cfg.PermitWithoutStream,
+		}),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           bo,
+			MinConnectTimeout: cfg.MinConnectTimeout,
+		}),
+	}
+}
+
+var logger = logging.Component("grpc")
+
+// LogStateChanges logs every connectivity state change of conn until ctx is
+// done or the connection is closed. It also keeps the connection warm:
+// grpc.NewClient connects lazily and a connection closed by the server's
+// MaxConnectionAge goes idle until the next RPC.
+func LogStateChanges(ctx context.Context, conn *grpc.ClientConn, name string) {
+	conn.Connect()
+
+	state := conn.GetState()
+	logger.InfoContext(ctx, "connection state", "conn", name, "state", state.String())
+
+	for conn.WaitForStateChange(ctx, state) {
+		state = conn.GetState()
+		logger.InfoContext(ctx, "connection state", "conn", name, "state", state.String())
+		switch state {
+		case connectivity.Shutdown:
+			return
+		case connectivity.Idle:
+			conn.Connect()
+		}
+	}
+}
+
+// CheckHealth asks the grpc.health.v1 server behind conn for the status of
+// service and returns an error unless it is SERVING.
+func CheckHealth(ctx context.Context, conn grpc.ClientConnInterface, service string) error {
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		return err
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("%s is %s", service, resp.GetStatus())
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package grpcmw
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/retry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// ClientConfig configures the interceptors a client adds to the calls of a
+// connection.
+type ClientConfig struct {
+	// Timeout bounds calls whose context has no deadline; 0 leaves them
+	// unbounded. Calls with a deadline keep it.
+	Timeout time.Duration `config:"timeout" usage:"Deadline for calls made without one, zero leaves them unbounded"`
+
+	// Retry repeats calls failing with Unavailable or DeadlineExceeded.
+	// It is off by default: enable it only for upstreams whose methods are
+	// safe to repeat and whose callers do not retry already.
+	Retry retry.Config `config:",inline"`
+}
+
+// DefaultClientConfig bounds calls without a deadline to 30 seconds and
+// makes a single attempt.
+func DefaultClientConfig() ClientConfig {
+	r := retry.DefaultConfig()
+	r.MaxAttempts = 1
+	return ClientConfig{
+		Timeout: 30 * time.Second,
+		Retry:   r,
+	}
+}
+
+func (c ClientConfig) Validate() error {
+	if c.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	return nil
+}
+
+// UnaryClientLoggingInterceptor logs every call with its status code and
+// duration. Successful calls are logged at debug level, as the server logs
+// them too. While the payload logs of pkg/logging are on, the redacted
+// request and reply follow. Place it inside the retry interceptor to log
+// every attempt.
+func UnaryClientLoggingInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		elapsed := time.Since(start)
+		logPayloads(ctx, "rpc called payloads", method, req, reply, err)
+		if err == nil {
+			logger.DebugContext(ctx, "rpc called", "method", method, "code", "OK", "duration_ms", durationMS(elapsed))
+			return nil
+		}
+		logCall(ctx, "rpc called", method, err, elapsed)
+		return err
+	}
+}
+
+// UnaryClientTimeoutInterceptor gives calls made without a deadline one
+// of d. It does nothing when d is 0.
+func UnaryClientTimeoutInterceptor(d time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok && d > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// UnaryClientRetryInterceptor repeats calls failing with a transient code,
+// see retry.TransientRPC, up to cfg.MaxAttempts times with its backoff.
+// Retries stop when the context of the call ends.
+func UnaryClientRetryInterceptor(cfg retry.Config) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if cfg.MaxAttempts <= 1 {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		return retry.Do(ctx, cfg, func(ctx context.Context) error {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}, retry.If(retry.TransientRPC), retry.OnRetry(func(attempt int, err error, wait time.Duration) {
+			logger.WarnContext(ctx, "rpc failed, retrying", "method", method, "attempt", attempt,
+				"attempts", cfg.MaxAttempts, "code", status.Code(err).String(), "retry_in", wait.String())
+		}))
+	}
+}
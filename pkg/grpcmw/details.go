@@ -0,0 +1,80 @@
+package grpcmw
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// FieldViolation describes one invalid request field.
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// ErrorInfo returns a status error with a google.rpc.ErrorInfo detail.
+// reason is a stable UPPER_SNAKE_CASE identifier clients can switch on.
+func ErrorInfo(code codes.Code, msg, reason, domain string, metadata map[string]string) error {
+	return withDetails(status.New(code, msg), &errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   domain,
+		Metadata: metadata,
+	})
+}
+
+// BadRequest returns a codes.InvalidArgument error with a
+// google.rpc.BadRequest detail listing the violations.
+func BadRequest(msg string, violations ...FieldViolation) error {
+	br := &errdetails.BadRequest{}
+	for _, v := range violations {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Description,
+		})
+	}
+	return withDetails(status.New(codes.InvalidArgument, msg), br)
+}
+
+// Required is a BadRequest for a single missing field.
+func Required(field string) error {
+	return BadRequest(field+" is required", FieldViolation{Field: field, Description: "is required"})
+}
+
+func withDetails(st *status.Status, details ...protoadapt.MessageV1) error {
+	if detailed, err := st.WithDetails(details...); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// Reason returns the ErrorInfo detail of a status error, if any.
+func Reason(err error) (*errdetails.ErrorInfo, bool) {
+	st, ok := status.FromError(err)
+	if !ok {
+		return nil, false
+	}
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			return info, true
+		}
+	}
+	return nil, false
+}
+
+// FieldViolations returns the BadRequest violations of a status error.
+func FieldViolations(err error) []FieldViolation {
+	st, ok := status.FromError(err)
+	if !ok {
+		return nil
+	}
+	var violations []FieldViolation
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.FieldViolations {
+				violations = append(violations, FieldViolation{Field: v.Field, Description: v.Description})
+			}
+		}
+	}
+	return violations
+}
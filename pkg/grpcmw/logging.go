@@ -0,0 +1,75 @@
+package grpcmw
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var logger = logging.Component("grpc")
+
+// UnaryLoggingInterceptor logs every call with its status code and
+// duration, and its redacted request and response while the payload logs
+// of pkg/logging are on. Place it after the request ID interceptor so the
+// record carries the request ID.
+func UnaryLoggingInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(ctx, "rpc served", info.FullMethod, err, time.Since(start))
+		logPayloads(ctx, "rpc served payloads", info.FullMethod, req, resp, err)
+		return resp, err
+	}
+}
+
+// StreamLoggingInterceptor logs every stream once it ends. The messages of
+// streams are not part of the payload logs.
+func StreamLoggingInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(ss.Context(), "rpc served", info.FullMethod, err, time.Since(start))
+		return err
+	}
+}
+
+func logCall(ctx context.Context, msg, method string, err error, elapsed time.Duration) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK, codes.Canceled, codes.NotFound, codes.AlreadyExists, codes.InvalidArgument,
+		codes.FailedPrecondition, codes.PermissionDenied, codes.Unauthenticated:
+	case codes.Internal, codes.Unknown, codes.DataLoss:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+	args := []any{"method", method, "code", code.String(), "duration_ms", durationMS(elapsed)}
+	if err != nil {
+		args = append(args, "error", status.Convert(err).Message())
+	}
+	logger.Log(ctx, level, msg, args...)
+}
+
+// logPayloads logs the redacted messages of a call at debug level while
+// the payload logs are on. The response of a failed call is left out.
+func logPayloads(ctx context.Context, msg, method string, req, resp interface{}, err error) {
+	redactor := logging.PayloadRedactor()
+	if redactor == nil || !logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	args := []any{"method", method, "code", status.Code(err).String(), "request", redactor.Value(req)}
+	if err == nil {
+		args = append(args, "response", redactor.Value(resp))
+	}
+	logger.DebugContext(ctx, msg, args...)
+}
+
+func durationMS(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,65 @@
+package grpcmw
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// NewServerHandlingHistogram registers the histogram used by the metrics
+// interceptors.
+func NewServerHandlingHistogram(r *metrics.Registry) *metrics.HistogramVec {
+	return r.NewHistogramVec("grpc_server_handling_seconds",
+		"Time spent handling gRPC calls, by method and status code.",
+		metrics.DefBuckets, "method", "code")
+}
+
+// UnaryMetricsInterceptor records the handling time of every call in h.
+func UnaryMetricsInterceptor(h *metrics.HistogramVec) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		h.WithLabelValues(info.FullMethod, status.Code(err).String()).Observe(time.Since(start).Seconds())
+		return resp, err
+	}
+}
+
+// StreamMetricsInterceptor records the lifetime of every stream in h.
+func StreamMetricsInterceptor(h *metrics.HistogramVec) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		h.WithLabelValues(info.FullMethod, status.Code(err).String()).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// NewClientHandlingHistogram registers the histogram used by
+// UnaryClientMetricsInterceptor.
+func NewClientHandlingHistogram(r *metrics.Registry) *metrics.HistogramVec {
+	return r.NewHistogramVec("grpc_client_handling_seconds",
+		"Time spent on outgoing gRPC calls, by server endpoint, method and status code.",
+		metrics.DefBuckets, "endpoint", "method", "code")
+}
+
+// UnaryClientMetricsInterceptor records the duration of every call in h,
+// labelled with the address of the server that handled it so the spread of
+// a load balanced connection shows up per replica. Calls that never reached
+// a server are labelled "none".
+func UnaryClientMetricsInterceptor(h *metrics.HistogramVec) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		var p peer.Peer
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Peer(&p))...)
+		endpoint := "none"
+		if p.Addr != nil {
+			endpoint = p.Addr.String()
+		}
+		h.WithLabelValues(endpoint, method, status.Code(err).String()).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
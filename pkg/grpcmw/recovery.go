@@ -0,0 +1,63 @@
+package grpcmw
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// ReasonInternal is the ErrorInfo reason of errors produced by a recovered panic.
+const ReasonInternal = "INTERNAL"
+
+// UnaryRecoveryInterceptor turns a panic in the handler into codes.Internal
+// carrying the request ID, so the failure can be found in the server logs.
+// Place it after the request ID interceptor.
+func UnaryRecoveryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = panicError(ctx, info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamRecoveryInterceptor is the streaming counterpart of UnaryRecoveryInterceptor.
+func StreamRecoveryInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = panicError(ss.Context(), info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+func panicError(ctx context.Context, method string, r interface{}) error {
+	id := RequestIDFromContext(ctx)
+	if id == "" {
+		id = logging.NewRequestID()
+		ctx = NewRequestIDContext(ctx, id)
+	}
+
+	logger.ErrorContext(ctx, "panic in handler", "method", method, "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+
+	return ErrorInfo(codes.Internal, fmt.Sprintf("internal error (request_id=%s)", id),
+		ReasonInternal, serviceName(method), map[string]string{"request_id": id})
+}
+
+// serviceName extracts "pkg.Service" from "/pkg.Service/Method".
+func serviceName(fullMethod string) string {
+	name := strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
@@ -0,0 +1,83 @@
+// Package grpcmw holds gRPC middleware shared by the services: request IDs,
+// panic recovery and helpers for google.rpc error details.
+package grpcmw
+
+import (
+	"context"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const RequestIDHeader = "x-request-id"
+
+// NewRequestIDContext stores id in the context used by pkg/logging, so log
+// records of the call carry it.
+func NewRequestIDContext(ctx context.Context, id string) context.Context {
+	return logging.WithRequestID(ctx, id)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	return logging.RequestID(ctx)
+}
+
+// requestID reuses the caller's x-request-id or generates a new one.
+func requestID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIDHeader); len(values) > 0 && values[0] != "" {
+			return values[0]
+		}
+	}
+	return logging.NewRequestID()
+}
+
+// UnaryRequestIDInterceptor attaches a request ID to the context and echoes
+// it back in the response headers.
+func UnaryRequestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		id := requestID(ctx)
+		grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, id))
+		return handler(NewRequestIDContext(ctx, id), req)
+	}
+}
+
+// StreamRequestIDInterceptor is the streaming counterpart of UnaryRequestIDInterceptor.
+func StreamRequestIDInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		id := requestID(ss.Context())
+		ss.SetHeader(metadata.Pairs(RequestIDHeader, id))
+		return handler(srv, &contextStream{ServerStream: ss, ctx: NewRequestIDContext(ss.Context(), id)})
+	}
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// UnaryClientRequestIDInterceptor forwards the request ID of the context as
+// x-request-id, so a request keeps one ID across services.
+func UnaryClientRequestIDInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if id := RequestIDFromContext(ctx); id != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, RequestIDHeader, id)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientRequestIDInterceptor is the streaming counterpart of
+// UnaryClientRequestIDInterceptor.
+func StreamClientRequestIDInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if id := RequestIDFromContext(ctx); id != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, RequestIDHeader, id)
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
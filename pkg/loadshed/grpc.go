@@ -0,0 +1,45 @@
+package loadshed
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// UnaryServerInterceptor handles calls within the slots of l. Shed calls
+// fail with codes.ResourceExhausted and a google.rpc.RetryInfo detail of
+// the queue timeout; calls whose deadline passes while queued fail with
+// codes.DeadlineExceeded. Exempt methods, such as health checks, skip the
+// limit.
+func UnaryServerInterceptor(l *Limiter, exempt ...string) grpc.UnaryServerInterceptor {
+	skip := make(map[string]bool, len(exempt))
+	for _, m := range exempt {
+		skip[m] = true
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if skip[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		release, err := l.Acquire(ctx)
+		var shed *ShedError
+		switch {
+		case errors.As(err, &shed):
+			st := status.New(codes.ResourceExhausted, shed.Error())
+			if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(l.config.QueueTimeout)}); err == nil {
+				st = detailed
+			}
+			return nil, st.Err()
+		case err != nil:
+			return nil, status.FromContextError(err).Err()
+		}
+		defer release()
+		return handler(ctx, req)
+	}
+}
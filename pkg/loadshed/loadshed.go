@@ -0,0 +1,154 @@
+// Package loadshed caps the requests a server handles at once. Requests over
+// the cap wait in a bounded queue for a bounded time, or are shed at once,
+// so a slow dependency cannot pile up goroutines without limit.
+package loadshed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/metrics"
+)
+
+// Reasons a request is shed.
+const (
+	ReasonQueueFull    = "queue_full"
+	ReasonQueueTimeout = "queue_timeout"
+)
+
+type Config struct {
+	// MaxInFlight is the number of requests handled at once. Zero disables
+	// the limit.
+	MaxInFlight int `config:"max-concurrent-requests" usage:"Requests handled at once before more are queued or shed, 0 disables"`
+
+	// MaxQueued requests over MaxInFlight wait up to QueueTimeout for a
+	// slot; the others are shed right away. Zero sheds every request over
+	// the limit.
+	MaxQueued    int           `config:"max-queued-requests" usage:"Requests over the concurrency limit that wait for a slot instead of being shed"`
+	QueueTimeout time.Duration `config:"request-queue-timeout" usage:"Longest a queued request waits for a slot before it is shed"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		QueueTimeout: 100 * time.Millisecond,
+	}
+}
+
+func (c Config) Validate() error {
+	switch {
+	case c.MaxInFlight < 0:
+		return fmt.Errorf("max-concurrent-requests must not be negative, got %d", c.MaxInFlight)
+	case c.MaxQueued < 0:
+		return fmt.Errorf("max-queued-requests must not be negative, got %d", c.MaxQueued)
+	case c.MaxQueued > 0 && c.QueueTimeout <= 0:
+		return errors.New("max-queued-requests requires a positive request-queue-timeout")
+	}
+	return nil
+}
+
+// ShedError is returned by Acquire for a request that was shed.
+type ShedError struct {
+	Reason string
+	// Limit is the MaxInFlight the request went over.
+	Limit int
+}
+
+func (e *ShedError) Error() string {
+	if e.Reason == ReasonQueueTimeout {
+		return fmt.Sprintf("server busy: no slot of %d freed up in time", e.Limit)
+	}
+	return fmt.Sprintf("server busy: %d requests in flight", e.Limit)
+}
+
+// Limiter hands out the slots of Config.MaxInFlight.
+type Limiter struct {
+	config Config
+	slots  chan struct{}
+
+	queued       atomic.Int64
+	queueFull    atomic.Int64
+	queueTimeout atomic.Int64
+}
+
+// NewLimiter returns a limiter for config, which must be valid.
+func NewLimiter(config Config) *Limiter {
+	l := &Limiter{config: config}
+	if config.MaxInFlight > 0 {
+		l.slots = make(chan struct{}, config.MaxInFlight)
+	}
+	return l
+}
+
+// Acquire takes a slot, waiting in the queue when every slot is taken and
+// there is room in it. The caller calls release once the request is
+// handled. It returns a *ShedError for a request shed, and ctx.Err() when
+// ctx is done while it waits.
+func (l *Limiter) Acquire(ctx context.Context) (release func(), err error) {
+	if l.slots == nil {
+		return func() {}, nil
+	}
+	release = func() { <-l.slots }
+
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	if l.queued.Add(1) > int64(l.config.MaxQueued) {
+		l.queued.Add(-1)
+		l.queueFull.Add(1)
+		return nil, &ShedError{Reason: ReasonQueueFull, Limit: l.config.MaxInFlight}
+	}
+	defer l.queued.Add(-1)
+
+	timer := time.NewTimer(l.config.QueueTimeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		l.queueTimeout.Add(1)
+		return nil, &ShedError{Reason: ReasonQueueTimeout, Limit: l.config.MaxInFlight}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Stats are the requests in flight and queued, and the totals shed by
+// reason.
+type Stats struct {
+	InFlight int
+	Queued   int
+	Shed     map[string]int64
+}
+
+func (l *Limiter) Stats() Stats {
+	return Stats{
+		InFlight: len(l.slots),
+		Queued:   int(l.queued.Load()),
+		Shed: map[string]int64{
+			ReasonQueueFull:    l.queueFull.Load(),
+			ReasonQueueTimeout: l.queueTimeout.Load(),
+		},
+	}
+}
+
+// RegisterMetrics exposes the stats of l on r.
+func (l *Limiter) RegisterMetrics(r *metrics.Registry) {
+	r.NewGaugeFunc("grpc_server_inflight_requests", "Requests being handled under the concurrency limit.", func() float64 {
+		return float64(l.Stats().InFlight)
+	})
+	r.NewGaugeFunc("grpc_server_queued_requests", "Requests waiting for a slot of the concurrency limit.", func() float64 {
+		return float64(l.Stats().Queued)
+	})
+	r.NewCounterVecFunc("grpc_server_shed_requests_total", "Requests shed by the concurrency limit, by reason.",
+		func(emit func(float64, ...string)) {
+			for reason, n := range l.Stats().Shed {
+				emit(float64(n), reason)
+			}
+		}, "reason")
+}
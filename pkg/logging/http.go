@@ -0,0 +1,175 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID between services over HTTP. gRPC
+// calls use the lower case x-request-id metadata key.
+const RequestIDHeader = "X-Request-Id"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context whose log records carry id as request_id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID of ctx, or an empty string.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns a random request ID.
+func NewRequestID() string {
+	return uuid.New().String()
+}
+
+// RequestIDFromHeader returns the X-Request-Id of h if it is usable, or a
+// new ID.
+func RequestIDFromHeader(h http.Header) string {
+	id := h.Get(RequestIDHeader)
+	if id == "" || len(id) > 128 {
+		return NewRequestID()
+	}
+	return id
+}
+
+// Middleware reuses the caller's X-Request-Id or generates one, stores it in
+// the request context and the response headers, and logs every request
+// with its status and duration once it completes. While the payload logs
+// are on, a debug line follows with the redacted headers and bodies.
+func Middleware(next http.Handler) http.Handler {
+	logger := Component("http")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := RequestIDFromHeader(r.Header)
+		r.Header.Set(RequestIDHeader, id)
+		w.Header().Set(RequestIDHeader, id)
+		ctx := WithRequestID(r.Context(), id)
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		redactor := PayloadRedactor()
+		if redactor != nil && !logger.Enabled(ctx, slog.LevelDebug) {
+			redactor = nil
+		}
+		var reqBody *capture
+		if redactor != nil {
+			reqBody = &capture{limit: redactor.limit}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = &teeBody{ReadCloser: r.Body, capture: reqBody}
+			}
+			rec.body = &capture{limit: redactor.limit}
+		}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		level := slog.LevelInfo
+		if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logger.Log(ctx, level, "request served",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration_ms", float64(time.Since(start).Microseconds())/1000,
+		)
+		if redactor != nil {
+			logger.DebugContext(ctx, "request payloads",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"query", redactor.String(r.URL.RawQuery),
+				"request_headers", redactor.Header(r.Header),
+				"request_body", redactor.Body(reqBody.bytes()),
+				"response_headers", redactor.Header(w.Header()),
+				"response_body", redactor.Body(rec.body.bytes()),
+			)
+		}
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+
+	// body keeps the start of the response for the payload logs.
+	body *capture
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(p []byte) (int, error) {
+	if rec.body != nil {
+		rec.body.write(p)
+	}
+	return rec.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach Flush and the write deadline
+// of the underlying writer, which event streams need.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// capture keeps the first bytes of a body, one past its limit so that a
+// Redactor sees it was cut. A limit of 0 keeps it whole.
+type capture struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (c *capture) write(p []byte) {
+	if c.limit > 0 {
+		if room := c.limit + 1 - c.buf.Len(); room < len(p) {
+			p = p[:max(room, 0)]
+		}
+	}
+	c.buf.Write(p)
+}
+
+func (c *capture) bytes() []byte {
+	if c == nil {
+		return nil
+	}
+	return c.buf.Bytes()
+}
+
+// teeBody copies what a handler reads of a request body to a capture.
+type teeBody struct {
+	io.ReadCloser
+	capture *capture
+}
+
+func (b *teeBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.capture.write(p[:n])
+	return n, err
+}
+
+// Transport sets X-Request-Id on outgoing requests from the request ID of
+// their context. A nil Base uses http.DefaultTransport.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if id := RequestID(req.Context()); id != "" && req.Header.Get(RequestIDHeader) == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(RequestIDHeader, id)
+	}
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,205 @@
+// Package logging configures log/slog for the services: JSON or text output,
+// levels, and attributes carried in the context such as request and order
+// IDs, so every line of a request can be correlated across services.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config holds the log settings. Their environment variables, LOG_LEVEL
+// and LOG_FORMAT, are shared by all services.
+type Config struct {
+	// Level is debug, info, warn or error.
+	Level string `config:"level" env:"LOG_LEVEL" usage:"Log level: debug, info, warn or error"`
+
+	// Format is json or text.
+	Format string `config:"format" env:"LOG_FORMAT" usage:"Log format: json or text"`
+
+	// Payloads logs the bodies of HTTP requests and responses and the
+	// messages of unary RPCs at debug level, with personal and secret data
+	// redacted, to troubleshoot a service. It requires the debug level.
+	Payloads bool `config:"payloads" env:"LOG_PAYLOADS" usage:"Log redacted request and response payloads at debug level"`
+
+	// PayloadLimit cuts logged payloads to that many bytes; 0 keeps them
+	// whole.
+	PayloadLimit int `config:"payload-limit" env:"LOG_PAYLOAD_LIMIT" usage:"Bytes of a payload logged, zero for all"`
+
+	// RedactFields names fields and headers hidden in payload logs besides
+	// DefaultRedactFields.
+	RedactFields []string `config:"redact-fields" env:"LOG_REDACT_FIELDS" usage:"Comma separated fields and headers hidden in payload logs, besides the defaults"`
+
+	// RedactPatterns are regular expressions whose matches are hidden in
+	// payload logs besides emails and card numbers.
+	RedactPatterns []string `config:"redact-patterns" env:"LOG_REDACT_PATTERNS" usage:"Comma separated regular expressions hidden in payload logs, besides emails and card numbers"`
+}
+
+func DefaultConfig() Config {
+	return Config{Level: "info", Format: "json", PayloadLimit: 4096}
+}
+
+// Redactor returns the Redactor of the payload logs of c.
+func (c Config) Redactor() (*Redactor, error) {
+	return NewRedactor(c.RedactFields, c.RedactPatterns, c.PayloadLimit)
+}
+
+func (c Config) Validate() error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Level)); err != nil {
+		return fmt.Errorf("invalid log level %q", c.Level)
+	}
+	switch strings.ToLower(c.Format) {
+	case "", "json", "text":
+	default:
+		return fmt.Errorf("invalid log format %q, expected json or text", c.Format)
+	}
+	if c.PayloadLimit < 0 {
+		return fmt.Errorf("payload limit must not be negative")
+	}
+	if c.Payloads && level > slog.LevelDebug {
+		return fmt.Errorf("logging payloads requires the debug level")
+	}
+	_, err := c.Redactor()
+	return err
+}
+
+// New returns a logger writing to w that tags every record with service and
+// with the attributes of the context passed to the *Context methods.
+func New(w io.Writer, service string, cfg Config) (*slog.Logger, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	var level slog.Level
+	level.UnmarshalText([]byte(cfg.Level))
+
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler
+	if strings.ToLower(cfg.Format) == "text" {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(contextHandler{h}).With("service", service), nil
+}
+
+// Setup makes a logger for service writing to stderr the slog default.
+// Output of the standard log package goes through it too, at info level.
+// With cfg.Payloads it also turns the payload logs on.
+func Setup(service string, cfg Config) error {
+	logger, err := New(os.Stderr, service, cfg)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	if cfg.Payloads {
+		redactor, _ := cfg.Redactor()
+		SetPayloadRedactor(redactor)
+		logger.Warn("payload logging is on; turn it off once done troubleshooting")
+	}
+	log.SetFlags(0)
+	log.SetPrefix("")
+	return nil
+}
+
+// Component returns a logger tagged with component. It writes through the
+// slog default at the time of each call, so package level loggers created
+// before Setup still use its configuration.
+func Component(name string) *slog.Logger {
+	return slog.New(defaultHandler{}).With("component", name)
+}
+
+// Fatal logs msg at error level and exits, like log.Fatal.
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// Err is the attribute used for errors.
+func Err(err error) slog.Attr {
+	return slog.Any("error", err)
+}
+
+type attrsKey struct{}
+
+// WithAttrs returns a context whose log records carry args, given as
+// alternating keys and values or slog.Attr like the slog methods take.
+func WithAttrs(ctx context.Context, args ...any) context.Context {
+	r := slog.NewRecord(time.Time{}, 0, "", 0)
+	r.Add(args...)
+	attrs := append([]slog.Attr(nil), attrsFrom(ctx)...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return context.WithValue(ctx, attrsKey{}, attrs)
+}
+
+func attrsFrom(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// contextHandler adds the request ID and the attributes stored with
+// WithAttrs to every record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := RequestID(ctx); id != "" {
+			r.AddAttrs(slog.String("request_id", id))
+		}
+		r.AddAttrs(attrsFrom(ctx)...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// defaultHandler resolves slog.Default when a record is handled and
+// replays the WithAttrs and WithGroup calls made on it.
+type defaultHandler struct {
+	ops []func(slog.Handler) slog.Handler
+}
+
+func (h defaultHandler) handler() slog.Handler {
+	inner := slog.Default().Handler()
+	for _, op := range h.ops {
+		inner = op(inner)
+	}
+	return inner
+}
+
+func (h defaultHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return slog.Default().Handler().Enabled(ctx, level)
+}
+
+func (h defaultHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handler().Handle(ctx, r)
+}
+
+func (h defaultHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(inner slog.Handler) slog.Handler { return inner.WithAttrs(attrs) })
+}
+
+func (h defaultHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(inner slog.Handler) slog.Handler { return inner.WithGroup(name) })
+}
+
+func (h defaultHandler) with(op func(slog.Handler) slog.Handler) defaultHandler {
+	return defaultHandler{ops: append(append([]func(slog.Handler) slog.Handler(nil), h.ops...), op)}
+}
@@ -0,0 +1,229 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// Redacted replaces the values a Redactor hides.
+const Redacted = "<redacted>"
+
+// DefaultRedactFields are the fields and headers whose values payload logs
+// always hide. Names match ignoring case, dashes and underscores, so
+// idempotency_key also covers Idempotency-Key and idempotencyKey.
+var DefaultRedactFields = []string{
+	"email", "customer_email", "idempotency_key",
+	"card_number", "pan", "cvv", "cvc", "expiry",
+	"authorization", "cookie", "set_cookie", "x_api_key", "api_key",
+	"password", "secret", "token",
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+	// cardPattern finds runs of 13 to 19 digits, optionally grouped by
+	// spaces or dashes; only those passing the Luhn check are hidden, so
+	// that IDs and amounts survive.
+	cardPattern = regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`)
+
+	// fieldPattern finds the "name": value pairs of JSON that could not be
+	// parsed, such as a body cut at the payload limit.
+	fieldPattern = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"\s*:\s*("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+)
+
+// Redactor hides personal and secret data in the payloads written by the
+// payload logs: the values of the fields it names, emails, card numbers
+// and whatever its extra patterns match, wherever they appear.
+type Redactor struct {
+	fields   map[string]bool
+	patterns []*regexp.Regexp
+	limit    int
+}
+
+// NewRedactor returns a Redactor for the DefaultRedactFields and fields,
+// and for the regular expressions patterns on top of emails and card
+// numbers. Payloads are cut to limit bytes; 0 keeps them whole.
+func NewRedactor(fields, patterns []string, limit int) (*Redactor, error) {
+	r := &Redactor{fields: make(map[string]bool), limit: limit}
+	for _, f := range append(append([]string(nil), DefaultRedactFields...), fields...) {
+		if f = normalizeField(f); f != "" {
+			r.fields[f] = true
+		}
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// normalizeField lowers name and drops its dashes and underscores.
+func normalizeField(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(name)))
+}
+
+// Field reports whether the values of the field or header name are hidden.
+func (r *Redactor) Field(name string) bool {
+	return r.fields[normalizeField(name)]
+}
+
+// String returns s with the emails, card numbers and pattern matches it
+// holds replaced by Redacted.
+func (r *Redactor) String(s string) string {
+	s = emailPattern.ReplaceAllString(s, Redacted)
+	s = cardPattern.ReplaceAllStringFunc(s, func(m string) string {
+		if luhn(m) {
+			return Redacted
+		}
+		return m
+	})
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, Redacted)
+	}
+	return s
+}
+
+// luhn reports whether the digits of s pass the Luhn check of card numbers.
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// Body returns data redacted for a log line. JSON has the values of the
+// named fields hidden and its strings redacted; other text is redacted as
+// a whole, and binary data is only described by its size.
+func (r *Redactor) Body(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	truncated := r.limit > 0 && len(data) > r.limit
+	if truncated {
+		data = data[:r.limit]
+	}
+
+	var out string
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err == nil && !dec.More() {
+		var b strings.Builder
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(r.value(v)); err != nil {
+			return fmt.Sprintf("<%d bytes>", len(data))
+		}
+		out = strings.TrimSuffix(b.String(), "\n")
+	} else if utf8.Valid(data) {
+		out = r.text(string(data))
+	} else {
+		return fmt.Sprintf("<%d bytes>", len(data))
+	}
+	if truncated {
+		out += "...(truncated)"
+	}
+	return out
+}
+
+// Value returns v marshaled to JSON and redacted like Body.
+func (r *Redactor) Value(v any) string {
+	if v == nil {
+		return ""
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("<%T>", v)
+	}
+	return r.Body(b)
+}
+
+// Header returns the values of h with those of the named headers hidden.
+func (r *Redactor) Header(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		if r.Field(name) {
+			out[name] = Redacted
+			continue
+		}
+		out[name] = r.String(strings.Join(values, ", "))
+	}
+	return out
+}
+
+func (r *Redactor) value(v any) any {
+	switch v := v.(type) {
+	case string:
+		return r.String(v)
+	case json.Number:
+		if s := r.String(v.String()); s != v.String() {
+			return s
+		}
+		return v
+	case map[string]any:
+		for k, item := range v {
+			if r.Field(k) && item != nil {
+				v[k] = Redacted
+				continue
+			}
+			v[k] = r.value(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = r.value(item)
+		}
+	}
+	return v
+}
+
+// text redacts JSON that did not parse, by its "name": value pairs, and
+// then as plain text.
+func (r *Redactor) text(s string) string {
+	s = fieldPattern.ReplaceAllStringFunc(s, func(m string) string {
+		sub := fieldPattern.FindStringSubmatch(m)
+		if !r.Field(sub[1]) {
+			return m
+		}
+		return `"` + sub[1] + `":"` + Redacted + `"`
+	})
+	return r.String(s)
+}
+
+var payloads atomic.Pointer[Redactor]
+
+// PayloadRedactor returns the Redactor of the payload logs, or nil while
+// they are off. Setup turns them on with Config.Payloads.
+func PayloadRedactor() *Redactor {
+	return payloads.Load()
+}
+
+// SetPayloadRedactor turns the payload logs on with r, or off with nil.
+func SetPayloadRedactor(r *Redactor) {
+	payloads.Store(r)
+}
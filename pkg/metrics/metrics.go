@@ -0,0 +1,379 @@
+// Package metrics implements counters, gauges and histograms exposed in the
+// Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets suits request latencies in seconds.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+// Registry holds the metrics served by Handler.
+type Registry struct {
+	mu         sync.Mutex
+	names      map[string]bool
+	collectors []collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+func (r *Registry) register(name string, c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.names[name] {
+		panic("metrics: duplicate metric " + name)
+	}
+	r.names[name] = true
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteTo writes every metric in registration order.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w)
+	})
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// desc is the name, help and label names shared by the series of a metric.
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d desc) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, d.kind)
+}
+
+// series formats name{labels} with optional extra label pairs.
+func (d desc) series(name string, values []string, extra ...string) string {
+	if len(d.labels) == 0 && len(extra) == 0 {
+		return name
+	}
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	first := true
+	pair := func(k, v string) {
+		if !first {
+			b.WriteByte(',')
+		}
+		first = false
+		b.WriteString(k)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(v))
+		b.WriteByte('"')
+	}
+	for i, l := range d.labels {
+		pair(l, values[i])
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pair(extra[i], extra[i+1])
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+// Counter is a monotonically increasing value.
+type Counter struct {
+	mu sync.Mutex
+	v  float64
+}
+
+func (c *Counter) Inc() { c.Add(1) }
+
+// Add panics on negative values, which would break rate() queries.
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	c.mu.Lock()
+	c.v += v
+	c.mu.Unlock()
+}
+
+func (c *Counter) Value() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.v
+}
+
+// CounterVec is a counter partitioned by label values.
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	values  []string
+	counter *Counter
+}
+
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{
+		desc:   desc{name: name, help: help, kind: "counter", labels: labels},
+		series: make(map[string]*counterSeries),
+	}
+	r.register(name, v)
+	return v
+}
+
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return r.NewCounterVec(name, help).WithLabelValues()
+}
+
+// WithLabelValues returns the counter for the values, in label order.
+func (v *CounterVec) WithLabelValues(values ...string) *Counter {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.name, len(v.labels), len(values)))
+	}
+	key := labelKey(values)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	s, ok := v.series[key]
+	if !ok {
+		s = &counterSeries{values: append([]string(nil), values...), counter: &Counter{}}
+		v.series[key] = s
+	}
+	return s.counter
+}
+
+func (v *CounterVec) write(w *bufio.Writer) {
+	v.writeHeader(w)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, key := range sortedKeys(v.series) {
+		s := v.series[key]
+		fmt.Fprintf(w, "%s %s\n", v.desc.series(v.name, s.values), formatFloat(s.counter.Value()))
+	}
+}
+
+type gaugeFunc struct {
+	desc
+	fn func() float64
+}
+
+// NewGaugeFunc registers a gauge that reports the value returned by fn at
+// scrape time.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(name, &gaugeFunc{desc: desc{name: name, help: help, kind: "gauge"}, fn: fn})
+}
+
+func (g *gaugeFunc) write(w *bufio.Writer) {
+	g.writeHeader(w)
+	fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(g.fn()))
+}
+
+// CollectFunc reports the series of a metric collected at scrape time by
+// calling emit once per label value combination.
+type CollectFunc func(emit func(value float64, labelValues ...string))
+
+type vecFunc struct {
+	desc
+	fn CollectFunc
+}
+
+// NewGaugeVecFunc registers a gauge whose series are reported by fn at
+// scrape time, such as one per queue.
+func (r *Registry) NewGaugeVecFunc(name, help string, fn CollectFunc, labels ...string) {
+	r.register(name, &vecFunc{desc: desc{name: name, help: help, kind: "gauge", labels: labels}, fn: fn})
+}
+
+// NewCounterVecFunc is NewGaugeVecFunc for totals kept elsewhere, which
+// must never decrease.
+func (r *Registry) NewCounterVecFunc(name, help string, fn CollectFunc, labels ...string) {
+	r.register(name, &vecFunc{desc: desc{name: name, help: help, kind: "counter", labels: labels}, fn: fn})
+}
+
+func (v *vecFunc) write(w *bufio.Writer) {
+	type sample struct {
+		values []string
+		value  float64
+	}
+	samples := make(map[string]sample)
+	v.fn(func(value float64, values ...string) {
+		if len(values) != len(v.labels) {
+			panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.name, len(v.labels), len(values)))
+		}
+		samples[labelKey(values)] = sample{values: values, value: value}
+	})
+
+	v.writeHeader(w)
+	for _, key := range sortedKeys(samples) {
+		s := samples[key]
+		fmt.Fprintf(w, "%s %s\n", v.desc.series(v.name, s.values), formatFloat(s.value))
+	}
+}
+
+// Histogram counts observations into cumulative buckets.
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, upper := range h.buckets {
+		if v <= upper {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// HistogramVec is a histogram partitioned by label values.
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	values    []string
+	histogram *Histogram
+}
+
+// NewHistogramVec panics if buckets are not sorted in increasing order.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if !sort.Float64sAreSorted(buckets) {
+		panic("metrics: buckets of " + name + " are not sorted")
+	}
+	v := &HistogramVec{
+		desc:    desc{name: name, help: help, kind: "histogram", labels: labels},
+		buckets: append([]float64(nil), buckets...),
+		series:  make(map[string]*histogramSeries),
+	}
+	r.register(name, v)
+	return v
+}
+
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	return r.NewHistogramVec(name, help, buckets).WithLabelValues()
+}
+
+func (v *HistogramVec) WithLabelValues(values ...string) *Histogram {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.name, len(v.labels), len(values)))
+	}
+	key := labelKey(values)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	s, ok := v.series[key]
+	if !ok {
+		s = &histogramSeries{
+			values: append([]string(nil), values...),
+			histogram: &Histogram{
+				buckets: v.buckets,
+				counts:  make([]uint64, len(v.buckets)),
+			},
+		}
+		v.series[key] = s
+	}
+	return s.histogram
+}
+
+func (v *HistogramVec) write(w *bufio.Writer) {
+	v.writeHeader(w)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, key := range sortedKeys(v.series) {
+		s := v.series[key]
+		h := s.histogram
+
+		h.mu.Lock()
+		for i, upper := range h.buckets {
+			fmt.Fprintf(w, "%s %d\n", v.desc.series(v.name+"_bucket", s.values, "le", formatFloat(upper)), h.counts[i])
+		}
+		fmt.Fprintf(w, "%s %d\n", v.desc.series(v.name+"_bucket", s.values, "le", "+Inf"), h.count)
+		fmt.Fprintf(w, "%s %s\n", v.desc.series(v.name+"_sum", s.values), formatFloat(h.sum))
+		fmt.Fprintf(w, "%s %d\n", v.desc.series(v.name+"_count", s.values), h.count)
+		h.mu.Unlock()
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,87 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+)
+
+// Published is one message handed to a fake broker.
+type Published struct {
+	Topic   string
+	Message *broker.Message
+}
+
+// Broker is a broker.Publisher that records the messages published to it
+// instead of delivering them. Deliver hands them to a message handler, as
+// the worker of a queue subscribed to the topic would.
+type Broker struct {
+	mu        sync.Mutex
+	published []Published
+	err       error
+}
+
+var _ broker.Publisher = (*Broker)(nil)
+
+func NewBroker() *Broker {
+	return &Broker{}
+}
+
+// Publish records msg, or returns the error set with Fail without
+// recording it.
+func (b *Broker) Publish(ctx context.Context, topicName string, msg *broker.Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return b.err
+	}
+	b.published = append(b.published, Published{Topic: topicName, Message: msg})
+	return nil
+}
+
+// Fail makes later publishes return err; nil restores them.
+func (b *Broker) Fail(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.err = err
+}
+
+// Published returns the messages published to topicName, oldest first;
+// an empty topicName returns those of every topic.
+func (b *Broker) Published(topicName string) []*broker.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []*broker.Message
+	for _, p := range b.published {
+		if topicName == "" || p.Topic == topicName {
+			out = append(out, p.Message)
+		}
+	}
+	return out
+}
+
+// All returns every publish with its topic, oldest first.
+func (b *Broker) All() []Published {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Published(nil), b.published...)
+}
+
+// Deliver passes the messages published to topicName, oldest first, to
+// handler and returns the first error it reports.
+func (b *Broker) Deliver(topicName string, handler broker.MessageHandler) error {
+	for _, msg := range b.Published(topicName) {
+		if err := handler(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset forgets the recorded messages.
+func (b *Broker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.published = nil
+}
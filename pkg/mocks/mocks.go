@@ -0,0 +1,20 @@
+// Package mocks provides fakes for unit tests of the services: a payment
+// client answering from a script, a broker that records what is published
+// instead of delivering it, and a clock that only moves when told to. None
+// of them needs a network listener.
+package mocks
+
+import (
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/testkit"
+)
+
+// Clock is the manual clock of package testkit; pass its Now method
+// wherever a service takes a func() time.Time.
+type Clock = testkit.Clock
+
+// NewClock returns a clock set to start; a zero start means testkit.Epoch.
+func NewClock(start time.Time) *Clock {
+	return testkit.NewClock(start)
+}
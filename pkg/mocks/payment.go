@@ -0,0 +1,127 @@
+package mocks
+
+import (
+	"context"
+	"io"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// PaymentClient is a payment.PaymentServiceClient answering from a script:
+//
+//	client := mocks.NewPaymentClient()
+//	client.On("ProcessPayment").Times(1).Fail(status.Error(codes.Unavailable, "down"))
+//	client.On("ProcessPayment").Return(&payment.PaymentResponse{Success: true, TransactionID: "tx_1"})
+//
+// ExportTransactions is scripted with a []*payment.ExportChunk, which the
+// returned stream yields before io.EOF.
+type PaymentClient struct {
+	script
+}
+
+var _ payment.PaymentServiceClient = (*PaymentClient)(nil)
+
+func NewPaymentClient() *PaymentClient {
+	return &PaymentClient{}
+}
+
+// On adds a stub answering calls of method, named as in the proto, such as
+// "ProcessPayment".
+func (c *PaymentClient) On(method string) *Stub {
+	return c.on(method)
+}
+
+func (c *PaymentClient) ProcessPayment(ctx context.Context, in *payment.PaymentRequest, _ ...grpc.CallOption) (*payment.PaymentResponse, error) {
+	resp, err := c.invoke(ctx, "ProcessPayment", in)
+	return typed[*payment.PaymentResponse]("ProcessPayment", resp, err)
+}
+
+func (c *PaymentClient) GetPaymentStatus(ctx context.Context, in *payment.PaymentStatusRequest, _ ...grpc.CallOption) (*payment.PaymentStatusResponse, error) {
+	resp, err := c.invoke(ctx, "GetPaymentStatus", in)
+	return typed[*payment.PaymentStatusResponse]("GetPaymentStatus", resp, err)
+}
+
+func (c *PaymentClient) CancelPayment(ctx context.Context, in *payment.CancelPaymentRequest, _ ...grpc.CallOption) (*payment.PaymentStatusResponse, error) {
+	resp, err := c.invoke(ctx, "CancelPayment", in)
+	return typed[*payment.PaymentStatusResponse]("CancelPayment", resp, err)
+}
+
+func (c *PaymentClient) RefundPayment(ctx context.Context, in *payment.RefundPaymentRequest, _ ...grpc.CallOption) (*payment.PaymentStatusResponse, error) {
+	resp, err := c.invoke(ctx, "RefundPayment", in)
+	return typed[*payment.PaymentStatusResponse]("RefundPayment", resp, err)
+}
+
+func (c *PaymentClient) ListHeldPayments(ctx context.Context, in *payment.ListHeldPaymentsRequest, _ ...grpc.CallOption) (*payment.ListHeldPaymentsResponse, error) {
+	resp, err := c.invoke(ctx, "ListHeldPayments", in)
+	return typed[*payment.ListHeldPaymentsResponse]("ListHeldPayments", resp, err)
+}
+
+func (c *PaymentClient) ReviewPayment(ctx context.Context, in *payment.ReviewPaymentRequest, _ ...grpc.CallOption) (*payment.PaymentStatusResponse, error) {
+	resp, err := c.invoke(ctx, "ReviewPayment", in)
+	return typed[*payment.PaymentStatusResponse]("ReviewPayment", resp, err)
+}
+
+func (c *PaymentClient) CreateSubscription(ctx context.Context, in *payment.CreateSubscriptionRequest, _ ...grpc.CallOption) (*payment.Subscription, error) {
+	resp, err := c.invoke(ctx, "CreateSubscription", in)
+	return typed[*payment.Subscription]("CreateSubscription", resp, err)
+}
+
+func (c *PaymentClient) CancelSubscription(ctx context.Context, in *payment.CancelSubscriptionRequest, _ ...grpc.CallOption) (*payment.Subscription, error) {
+	resp, err := c.invoke(ctx, "CancelSubscription", in)
+	return typed[*payment.Subscription]("CancelSubscription", resp, err)
+}
+
+func (c *PaymentClient) GetReconciliationReport(ctx context.Context, in *payment.ReconciliationReportRequest, _ ...grpc.CallOption) (*payment.ReconciliationReport, error) {
+	resp, err := c.invoke(ctx, "GetReconciliationReport", in)
+	return typed[*payment.ReconciliationReport]("GetReconciliationReport", resp, err)
+}
+
+func (c *PaymentClient) ExportTransactions(ctx context.Context, in *payment.ExportTransactionsRequest, _ ...grpc.CallOption) (grpc.ServerStreamingClient[payment.ExportChunk], error) {
+	resp, err := c.invoke(ctx, "ExportTransactions", in)
+	chunks, err := typed[[]*payment.ExportChunk]("ExportTransactions", resp, err)
+	if err != nil {
+		return nil, err
+	}
+	return &chunkStream{ctx: ctx, chunks: chunks}, nil
+}
+
+func (c *PaymentClient) GetTransactionHistory(ctx context.Context, in *payment.TransactionHistoryRequest, _ ...grpc.CallOption) (*payment.TransactionHistoryResponse, error) {
+	resp, err := c.invoke(ctx, "GetTransactionHistory", in)
+	return typed[*payment.TransactionHistoryResponse]("GetTransactionHistory", resp, err)
+}
+
+func (c *PaymentClient) CreateDispute(ctx context.Context, in *payment.CreateDisputeRequest, _ ...grpc.CallOption) (*payment.Dispute, error) {
+	resp, err := c.invoke(ctx, "CreateDispute", in)
+	return typed[*payment.Dispute]("CreateDispute", resp, err)
+}
+
+func (c *PaymentClient) ResolveDispute(ctx context.Context, in *payment.ResolveDisputeRequest, _ ...grpc.CallOption) (*payment.Dispute, error) {
+	resp, err := c.invoke(ctx, "ResolveDispute", in)
+	return typed[*payment.Dispute]("ResolveDispute", resp, err)
+}
+
+// chunkStream yields scripted export chunks.
+type chunkStream struct {
+	grpc.ClientStream
+	ctx    context.Context
+	chunks []*payment.ExportChunk
+}
+
+func (s *chunkStream) Recv() (*payment.ExportChunk, error) {
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(s.chunks) == 0 {
+		return nil, io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+func (s *chunkStream) Header() (metadata.MD, error) { return metadata.MD{}, nil }
+func (s *chunkStream) Trailer() metadata.MD         { return metadata.MD{} }
+func (s *chunkStream) CloseSend() error             { return nil }
+func (s *chunkStream) Context() context.Context     { return s.ctx }
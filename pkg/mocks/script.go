@@ -0,0 +1,174 @@
+package mocks
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Call is one call received by a fake client.
+type Call struct {
+	Method  string
+	Request interface{}
+
+	// Metadata is the outgoing metadata of the call, such as the tenant
+	// and request ID.
+	Metadata metadata.MD
+}
+
+// Stub is one scripted answer of a method, set up with On. A stub answers
+// every call until limited with Times.
+type Stub struct {
+	mu       *sync.Mutex
+	response interface{}
+	err      error
+	answer   func(req interface{}) (interface{}, error)
+	latency  time.Duration
+	// left counts the calls still answered; negative is unlimited.
+	left int
+}
+
+// Return answers with resp, which must have the response type of the
+// method.
+func (s *Stub) Return(resp interface{}) *Stub {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.response = resp
+	return s
+}
+
+// Fail answers with err, usually a status error.
+func (s *Stub) Fail(err error) *Stub {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+	return s
+}
+
+// Answer computes the answer from the request, which has the request type
+// of the method.
+func (s *Stub) Answer(fn func(req interface{}) (interface{}, error)) *Stub {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.answer = fn
+	return s
+}
+
+// After delays the answer by d. A call whose context ends first fails with
+// the matching status, as a real one would.
+func (s *Stub) After(d time.Duration) *Stub {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+	return s
+}
+
+// Times limits the stub to the next n calls; later calls go to the stubs
+// added after it.
+func (s *Stub) Times(n int) *Stub {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.left = n
+	return s
+}
+
+// script holds the stubs and calls of a fake client.
+type script struct {
+	mu    sync.Mutex
+	stubs map[string][]*Stub
+	calls []Call
+}
+
+func (sc *script) on(method string) *Stub {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.stubs == nil {
+		sc.stubs = make(map[string][]*Stub)
+	}
+	stub := &Stub{mu: &sc.mu, left: -1}
+	sc.stubs[method] = append(sc.stubs[method], stub)
+	return stub
+}
+
+// Calls returns the calls of method in the order they were made; an empty
+// method returns every call.
+func (sc *script) Calls(method string) []Call {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	var out []Call
+	for _, c := range sc.calls {
+		if method == "" || c.Method == method {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Reset removes every stub and forgets the calls made so far.
+func (sc *script) Reset() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.stubs = nil
+	sc.calls = nil
+}
+
+// invoke records a call and answers it from the first stub of method that
+// has calls left. Methods without one fail with UNIMPLEMENTED.
+func (sc *script) invoke(ctx context.Context, method string, req interface{}) (interface{}, error) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+
+	sc.mu.Lock()
+	sc.calls = append(sc.calls, Call{Method: method, Request: req, Metadata: md.Copy()})
+	var stub Stub
+	found := false
+	for _, s := range sc.stubs[method] {
+		if s.left == 0 {
+			continue
+		}
+		if s.left > 0 {
+			s.left--
+		}
+		stub, found = *s, true
+		break
+	}
+	sc.mu.Unlock()
+
+	if !found {
+		return nil, status.Errorf(codes.Unimplemented, "mocks: no answer scripted for %s", method)
+	}
+	if stub.latency > 0 {
+		t := time.NewTimer(stub.latency)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	if stub.answer != nil {
+		return stub.answer(req)
+	}
+	if stub.err == nil && stub.response == nil {
+		return nil, status.Errorf(codes.Unimplemented, "mocks: %s scripted without a response or error", method)
+	}
+	return stub.response, stub.err
+}
+
+// typed converts a scripted answer to the response type of a method. A
+// response of another type is a mistake in the test and panics.
+func typed[T any](method string, resp interface{}, err error) (T, error) {
+	var zero T
+	if err != nil {
+		return zero, err
+	}
+	out, ok := resp.(T)
+	if !ok {
+		panic(fmt.Sprintf("mocks: %s scripted with a %T response, want %T", method, resp, zero))
+	}
+	return out, nil
+}
@@ -0,0 +1,158 @@
+// Package money represents amounts as an integer number of minor units,
+// such as cents, of a currency. Its arithmetic fails with ErrOverflow
+// instead of wrapping around and with ErrCurrencyMismatch instead of
+// combining amounts of different currencies.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrOverflow is returned when a result does not fit in an int64
+	ErrOverflow = errors.New("money: amount overflows")
+
+	// ErrCurrencyMismatch is returned when combining amounts of different currencies
+	ErrCurrencyMismatch = errors.New("money: currency mismatch")
+
+	// ErrInvalidCurrency is returned for a currency code that is not three letters
+	ErrInvalidCurrency = errors.New("money: invalid currency code")
+)
+
+// Money is Amount minor units of Currency, an upper case ISO 4217 code.
+// The zero value is an amount of no currency, which only combines with
+// itself.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// New returns amount minor units of currency.
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// ParseCurrency returns code in upper case, or ErrInvalidCurrency when it
+// is not three ASCII letters.
+func ParseCurrency(code string) (string, error) {
+	if len(code) != 3 {
+		return "", fmt.Errorf("%w %q", ErrInvalidCurrency, code)
+	}
+	for _, c := range code {
+		if (c < 'A' || c > 'Z') && (c < 'a' || c > 'z') {
+			return "", fmt.Errorf("%w %q", ErrInvalidCurrency, code)
+		}
+	}
+	return strings.ToUpper(code), nil
+}
+
+// exponents lists the currencies whose minor unit is not a hundredth.
+var exponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// Exponent returns the number of decimal places of the minor unit of
+// currency: 2 for most currencies, 0 for JPY, 3 for KWD.
+func Exponent(currency string) int {
+	if e, ok := exponents[strings.ToUpper(currency)]; ok {
+		return e
+	}
+	return 2
+}
+
+func (m Money) IsZero() bool     { return m.Amount == 0 }
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+
+// Add returns m + o.
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return Money{}, err
+	}
+	sum := m.Amount + o.Amount
+	if (o.Amount > 0 && sum < m.Amount) || (o.Amount < 0 && sum > m.Amount) {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrOverflow, m, o)
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+// Sub returns m - o.
+func (m Money) Sub(o Money) (Money, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return Money{}, err
+	}
+	diff := m.Amount - o.Amount
+	if (o.Amount > 0 && diff > m.Amount) || (o.Amount < 0 && diff < m.Amount) {
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrOverflow, m, o)
+	}
+	return Money{Amount: diff, Currency: m.Currency}, nil
+}
+
+// Mul returns m times n, such as the subtotal of n units at price m.
+func (m Money) Mul(n int64) (Money, error) {
+	if m.Amount == 0 || n == 0 {
+		return Money{Currency: m.Currency}, nil
+	}
+	product := m.Amount * n
+	if product/n != m.Amount || (m.Amount == -1 && n == math.MinInt64) || (n == -1 && m.Amount == math.MinInt64) {
+		return Money{}, fmt.Errorf("%w: %s * %d", ErrOverflow, m, n)
+	}
+	return Money{Amount: product, Currency: m.Currency}, nil
+}
+
+// Sum adds amounts, all of which must be in currency.
+func Sum(currency string, amounts ...Money) (Money, error) {
+	total := New(0, currency)
+	for _, a := range amounts {
+		var err error
+		if total, err = total.Add(a); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+func (m Money) sameCurrency(o Money) error {
+	if m.Currency != o.Currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return nil
+}
+
+// Decimal formats the amount in major units with the decimal places of
+// its currency, such as "1234.50" for 123450 USD cents and "-7" for -7 JPY.
+func (m Money) Decimal() string {
+	return FormatMinor(m.Amount, Exponent(m.Currency))
+}
+
+// String formats m as its Decimal and currency, such as "1234.50 USD".
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Decimal()
+	}
+	return m.Decimal() + " " + m.Currency
+}
+
+// FormatMinor formats amount minor units with exponent decimal places.
+func FormatMinor(amount int64, exponent int) string {
+	var sign string
+	abs := uint64(amount)
+	if amount < 0 {
+		sign, abs = "-", -abs
+	}
+	digits := strconv.FormatUint(abs, 10)
+	if exponent <= 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	cut := len(digits) - exponent
+	return sign + digits[:cut] + "." + digits[cut:]
+}
@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// ClientKey identifies the caller: the authenticated principal when
+// available, otherwise the peer IP address.
+func ClientKey(ctx context.Context) string {
+	if p, ok := auth.FromContext(ctx); ok && p.Subject != "" {
+		return "principal:" + p.Subject
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return "peer:" + hostOnly(p.Addr.String())
+	}
+	return "unknown"
+}
+
+// UnaryServerInterceptor rejects calls over the limit with
+// codes.ResourceExhausted and a google.rpc.RetryInfo detail.
+func UnaryServerInterceptor(l *Limiter, exempt ...string) grpc.UnaryServerInterceptor {
+	skip := make(map[string]bool, len(exempt))
+	for _, m := range exempt {
+		skip[m] = true
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if skip[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		key := ClientKey(ctx)
+		allowed, retryAfter := l.Allow(key)
+		if allowed {
+			return handler(ctx, req)
+		}
+
+		st := status.New(codes.ResourceExhausted, fmt.Sprintf("rate limit exceeded, retry after %v", retryAfter))
+		if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)}); err == nil {
+			st = detailed
+		}
+		return nil, st.Err()
+	}
+}
+
+func hostOnly(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
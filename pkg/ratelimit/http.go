@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+)
+
+// HTTPClientKey identifies the caller of r: the authenticated principal, so
+// each API key or token subject has its own bucket, otherwise the remote IP
+// address.
+func HTTPClientKey(r *http.Request) string {
+	if p, ok := auth.FromContext(r.Context()); ok && p.Subject != "" {
+		return "principal:" + p.Subject
+	}
+	return "ip:" + hostOnly(r.RemoteAddr)
+}
+
+// HTTPMiddleware answers 429 with Retry-After once the client of a request,
+// see HTTPClientKey, runs out of tokens. Paths listed in exempt are not
+// limited. Install it inside the authentication middleware so principals
+// are known.
+func HTTPMiddleware(l *Limiter, exempt ...string) func(http.Handler) http.Handler {
+	skip := make(map[string]bool, len(exempt))
+	for _, p := range exempt {
+		skip[p] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skip[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if ok, wait := l.Allow(HTTPClientKey(r)); !ok {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Retry-After", strconv.Itoa(max(1, int(wait.Seconds()+0.999))))
+				w.WriteHeader(http.StatusTooManyRequests)
+				json.NewEncoder(w).Encode(map[string]string{"error": "rate limit exceeded"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+// Package ratelimit implements per-client token bucket rate limiting.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	// Rate is the number of tokens added per second. Zero disables limiting.
+	Rate float64 `config:"rate-limit" usage:"Requests per second allowed per client, 0 disables"`
+
+	// Burst is the bucket capacity.
+	Burst int `config:"rate-burst" usage:"Requests a client may send in a burst"`
+
+	// IdleTTL removes buckets for clients that have not been seen for this long.
+	IdleTTL time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Rate:    50,
+		Burst:   100,
+		IdleTTL: 10 * time.Minute,
+	}
+}
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// Limiter keeps one token bucket per client key.
+type Limiter struct {
+	mu        sync.Mutex
+	config    Config
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewLimiter(config Config) *Limiter {
+	if config.Burst <= 0 {
+		config.Burst = 1
+	}
+	return &Limiter{
+		config:  config,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow consumes a token for key. When the bucket is empty it returns false
+// and how long the caller should wait before a token becomes available.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l.config.Rate <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweepLocked(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.config.Burst), lastSeen: now}
+		l.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.lastSeen).Seconds()
+	b.tokens += elapsed * l.config.Rate
+	if b.tokens > float64(l.config.Burst) {
+		b.tokens = float64(l.config.Burst)
+	}
+	b.lastSeen = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	missing := 1 - b.tokens
+	retryAfter := time.Duration(missing / l.config.Rate * float64(time.Second))
+	return false, retryAfter
+}
+
+// Wait blocks until a token for key is available and consumes it, or
+// returns the error of ctx once it is done.
+func (l *Limiter) Wait(ctx context.Context, key string) error {
+	for {
+		ok, wait := l.Allow(key)
+		if ok {
+			return nil
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (l *Limiter) sweepLocked(now time.Time) {
+	if l.config.IdleTTL <= 0 || now.Sub(l.lastSweep) < l.config.IdleTTL {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > l.config.IdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Clients returns the number of tracked client buckets.
+func (l *Limiter) Clients() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
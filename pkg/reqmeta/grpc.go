@@ -0,0 +1,76 @@
+package reqmeta
+
+import (
+	"context"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// outgoing appends the metadata of ctx to its outgoing gRPC metadata.
+func outgoing(ctx context.Context) context.Context {
+	pairs := []string{tenant.MetadataKey, tenant.FromContext(ctx)}
+	if id := logging.RequestID(ctx); id != "" {
+		pairs = append(pairs, grpcmw.RequestIDHeader, id)
+	}
+	if actor := Actor(ctx); actor != "" {
+		pairs = append(pairs, ActorMetadataKey, actor)
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+// incoming restores the actor forwarded by the caller.
+func incoming(ctx context.Context) context.Context {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(ActorMetadataKey); len(values) > 0 {
+			ctx = WithActor(ctx, values[0])
+		}
+	}
+	return ctx
+}
+
+// UnaryClientInterceptor forwards the request ID, tenant and actor of the
+// context with every call.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoing(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor is the streaming counterpart of UnaryClientInterceptor.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoing(ctx), desc, cc, method, opts...)
+	}
+}
+
+// UnaryServerInterceptor reuses the caller's request ID or generates one,
+// like grpcmw.UnaryRequestIDInterceptor, and restores the forwarded actor.
+// The tenant is resolved by tenant.UnaryServerInterceptor, which must run
+// after authentication.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	requestID := grpcmw.UnaryRequestIDInterceptor()
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return requestID(incoming(ctx), req, info, handler)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	requestID := grpcmw.StreamRequestIDInterceptor()
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return requestID(srv, &contextStream{ServerStream: ss, ctx: incoming(ss.Context())}, info, handler)
+	}
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
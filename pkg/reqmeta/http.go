@@ -0,0 +1,50 @@
+package reqmeta
+
+import (
+	"net/http"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+)
+
+// Middleware restores the actor of the X-Actor header. Request IDs are
+// assigned by logging.Middleware and tenants resolved by tenant.Middleware.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if actor := r.Header.Get(ActorHeader); actor != "" {
+			r = r.WithContext(WithActor(r.Context(), actor))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Transport sets X-Request-Id, X-Tenant-Id and X-Actor on outgoing requests
+// from their context, leaving headers already set alone. A nil Base uses
+// http.DefaultTransport.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	ctx := req.Context()
+	headers := map[string]string{
+		logging.RequestIDHeader: logging.RequestID(ctx),
+		tenant.Header:           tenant.FromContext(ctx),
+		ActorHeader:             Actor(ctx),
+	}
+	cloned := false
+	for name, value := range headers {
+		if value == "" || req.Header.Get(name) != "" {
+			continue
+		}
+		if !cloned {
+			req, cloned = req.Clone(ctx), true
+		}
+		req.Header.Set(name, value)
+	}
+	return base.RoundTrip(req)
+}
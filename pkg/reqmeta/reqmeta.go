@@ -0,0 +1,69 @@
+// Package reqmeta carries what identifies a request from service to service:
+// its request ID, the tenant it acts for and the user who made it. Client
+// interceptors and Transport forward them with outgoing gRPC calls and HTTP
+// requests; server interceptors and Middleware restore them into the
+// context of the handler. Broker messages carry them as metadata, which
+// broker.Message.Context restores for workers.
+package reqmeta
+
+import (
+	"context"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+)
+
+// ActorHeader names the user an HTTP request is made for, when a service
+// calls another on behalf of its own caller. gRPC calls use the lower case
+// x-actor metadata key.
+const (
+	ActorHeader      = "X-Actor"
+	ActorMetadataKey = "x-actor"
+)
+
+// Metadata is what a request carries between services.
+type Metadata struct {
+	RequestID string
+	TenantID  string
+
+	// Actor is the user the request is made for, empty when it was not
+	// made by or for an authenticated user.
+	Actor string
+}
+
+// FromContext returns the metadata of the request of ctx.
+func FromContext(ctx context.Context) Metadata {
+	return Metadata{
+		RequestID: logging.RequestID(ctx),
+		TenantID:  tenant.FromContext(ctx),
+		Actor:     Actor(ctx),
+	}
+}
+
+type actorKey struct{}
+
+// WithActor returns a context acting for actor, as forwarded by the calling
+// service or recorded on a message.
+func WithActor(ctx context.Context, actor string) context.Context {
+	if actor == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns the user the request of ctx is made for. A forwarded actor
+// is believed when the caller is an admin or was not authenticated, which
+// is the case of calls between services without authentication and of
+// broker messages; other principals act as themselves. The actor is for
+// logs and audit records, not for access control.
+func Actor(ctx context.Context) string {
+	p, authenticated := auth.FromContext(ctx)
+	if forwarded, _ := ctx.Value(actorKey{}).(string); forwarded != "" && (!authenticated || p.HasRole(auth.RoleAdmin)) {
+		return forwarded
+	}
+	if authenticated {
+		return p.Subject
+	}
+	return ""
+}
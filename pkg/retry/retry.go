@@ -0,0 +1,239 @@
+// Package retry runs an operation again after it fails, waiting longer
+// between attempts, until it succeeds, fails in a way retrying cannot fix,
+// runs out of attempts or its context ends.
+//
+//	err := retry.Do(ctx, cfg, func(ctx context.Context) error {
+//		return client.Call(ctx, req)
+//	}, retry.If(retry.TransientRPC))
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Jitter selects how the wait before a retry is randomized, so clients
+// failing together do not retry together.
+type Jitter string
+
+const (
+	// JitterNone waits the exponential delay itself.
+	JitterNone Jitter = "none"
+	// JitterFull waits a random duration up to the exponential delay.
+	JitterFull Jitter = "full"
+	// JitterEqual waits half the exponential delay plus a random duration
+	// up to the other half.
+	JitterEqual Jitter = "equal"
+	// JitterDecorrelated waits a random duration between BaseDelay and
+	// three times the previous wait, capped at MaxDelay.
+	JitterDecorrelated Jitter = "decorrelated"
+)
+
+// Config controls the attempts of an operation and the waits between them.
+type Config struct {
+	// MaxAttempts includes the first attempt; 1 or less disables retries.
+	MaxAttempts int `config:"retries" usage:"Attempts per call, including the first"`
+
+	// BaseDelay is multiplied by Multiplier, 2 when zero, after every
+	// attempt up to MaxDelay, then randomized by Jitter, full when empty.
+	BaseDelay  time.Duration `config:"retry-base" usage:"Initial backoff between attempts"`
+	MaxDelay   time.Duration `config:"retry-max" usage:"Upper bound for the backoff between attempts"`
+	Multiplier float64       `config:"retry-multiplier" usage:"Factor applied to the backoff after every attempt"`
+	Jitter     Jitter        `config:"retry-jitter" usage:"Backoff randomization: full, equal, decorrelated or none"`
+}
+
+// DefaultConfig makes 3 attempts, 100ms then 200ms apart before jitter.
+func DefaultConfig() Config {
+	return Config{
+		MaxAttempts: 3,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+		Multiplier:  2,
+		Jitter:      JitterFull,
+	}
+}
+
+func (c Config) Validate() error {
+	switch {
+	case c.BaseDelay < 0 || c.MaxDelay < 0:
+		return errors.New("retry-base and retry-max must not be negative")
+	case c.Multiplier != 0 && c.Multiplier < 1:
+		return fmt.Errorf("retry-multiplier must be at least 1, got %g", c.Multiplier)
+	}
+	switch c.Jitter {
+	case "", JitterNone, JitterFull, JitterEqual, JitterDecorrelated:
+		return nil
+	default:
+		return fmt.Errorf("unknown retry-jitter %q", c.Jitter)
+	}
+}
+
+// exponential returns the delay before retry n (1-based) without jitter.
+func (c Config) exponential(n int) time.Duration {
+	m := c.Multiplier
+	if m == 0 {
+		m = 2
+	}
+	d := float64(c.BaseDelay)
+	for i := 1; i < n && d < float64(c.MaxDelay); i++ {
+		d *= m
+	}
+	if d > float64(c.MaxDelay) {
+		return c.MaxDelay
+	}
+	return time.Duration(d)
+}
+
+// Delay returns the wait before retry n (1-based). Decorrelated jitter,
+// which follows the previous wait, takes the exponential delay of retry
+// n-1 for it; Backoff keeps the actual waits.
+func (c Config) Delay(n int) time.Duration {
+	return c.delay(n, c.exponential(n-1))
+}
+
+func (c Config) delay(n int, prev time.Duration) time.Duration {
+	d := c.exponential(n)
+	if d <= 0 {
+		return 0
+	}
+	switch c.Jitter {
+	case JitterNone:
+		return d
+	case JitterEqual:
+		return d/2 + rand.N(d-d/2)
+	case JitterDecorrelated:
+		upper := min(3*max(prev, c.BaseDelay), c.MaxDelay)
+		if upper <= c.BaseDelay {
+			return upper
+		}
+		return c.BaseDelay + rand.N(upper-c.BaseDelay)
+	default:
+		return rand.N(d)
+	}
+}
+
+// Backoff yields the waits between the attempts of one operation.
+type Backoff struct {
+	cfg  Config
+	n    int
+	prev time.Duration
+}
+
+// Backoff starts the waits of a new operation.
+func (c Config) Backoff() *Backoff {
+	return &Backoff{cfg: c}
+}
+
+// Next returns the wait before the next retry.
+func (b *Backoff) Next() time.Duration {
+	b.n++
+	b.prev = b.cfg.delay(b.n, b.prev)
+	return b.prev
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying: Do returns it at once,
+// whatever the classifier says.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var target *permanentError
+	return errors.As(err, &target)
+}
+
+// TransientRPC reports whether err is a gRPC transport failure worth
+// retrying: Unavailable or DeadlineExceeded.
+func TransientRPC(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// TransientHTTP reports whether an HTTP response status is worth
+// retrying: 408, 429 and 5xx.
+func TransientHTTP(code int) bool {
+	return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= 500
+}
+
+type options struct {
+	retryable func(error) bool
+	onRetry   func(attempt int, err error, wait time.Duration)
+}
+
+// Option changes how Do classifies and reports failures.
+type Option func(*options)
+
+// If retries only the errors for which retryable returns true. Without it
+// every error not marked Permanent is retried.
+func If(retryable func(error) bool) Option {
+	return func(o *options) {
+		o.retryable = retryable
+	}
+}
+
+// OnRetry calls f after failed attempt number attempt, before waiting wait
+// to retry. It is the place to log retries.
+func OnRetry(f func(attempt int, err error, wait time.Duration)) Option {
+	return func(o *options) {
+		o.onRetry = f
+	}
+}
+
+// Do calls fn until it returns nil, returns an error that is Permanent or
+// not retryable, or has been called cfg.MaxAttempts times, and returns the
+// error of the last call without its Permanent mark. fn is called at least
+// once and gets ctx to honor; when ctx ends during a wait, Do returns the
+// error of the last call at once.
+func Do(ctx context.Context, cfg Config, fn func(ctx context.Context) error, opts ...Option) error {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	backoff := cfg.Backoff()
+	attempts := max(cfg.MaxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		var perm *permanentError
+		if errors.As(err, &perm) {
+			return perm.err
+		}
+		if err == nil || attempt >= attempts || (o.retryable != nil && !o.retryable(err)) {
+			return err
+		}
+
+		wait := backoff.Next()
+		if o.onRetry != nil {
+			o.onRetry(attempt, err, wait)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
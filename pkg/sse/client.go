@@ -0,0 +1,139 @@
+// Package sse reads Server-Sent Event streams, such as the order event
+// stream served at GET /orders/events.
+package sse
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+)
+
+const (
+	// reconnectMin and reconnectMax bound the wait before a closed stream
+	// is reopened.
+	reconnectMin = time.Second
+	reconnectMax = 30 * time.Second
+)
+
+// Event is one event of a stream. Comment lines such as heartbeats are not
+// reported.
+type Event struct {
+	ID   string
+	Type string
+	Data []byte
+}
+
+// Stream follows an event stream URL.
+type Stream struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+var logger = logging.Component("sse")
+
+// NewStream creates a stream for url. A non-empty token is sent as a
+// bearer token.
+func NewStream(url, token string) *Stream {
+	return &Stream{url: url, token: token, client: &http.Client{}}
+}
+
+// Follow reads the stream until ctx is done, reconnecting with exponential
+// backoff whenever it ends. Events sent while the stream is down are not
+// replayed.
+func (s *Stream) Follow(ctx context.Context, handle func(Event)) {
+	wait := reconnectMin
+	for {
+		started := time.Now()
+		err := s.read(ctx, handle)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) > reconnectMax {
+			wait = reconnectMin
+		}
+		logger.WarnContext(ctx, "stream closed, reconnecting", "url", s.url, logging.Err(err), "wait", wait.String())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, reconnectMax)
+	}
+}
+
+// read consumes a single connection of the stream.
+func (s *Stream) read(ctx context.Context, handle func(Event)) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, errorMessage(resp.Body))
+	}
+	logger.InfoContext(ctx, "following stream", "url", s.url)
+
+	var (
+		e    Event
+		data []string
+	)
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if e.Type != "" && len(data) > 0 {
+				e.Data = []byte(strings.Join(data, "\n"))
+				handle(e)
+			}
+			e, data = Event{}, nil
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			e.ID = value
+		case "event":
+			e.Type = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+// errorMessage extracts the "error" field of a JSON error response, or
+// returns the body as text.
+func errorMessage(body io.Reader) string {
+	var e struct {
+		Error string `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(body, 4096))
+	if json.Unmarshal(data, &e) == nil && e.Error != "" {
+		return e.Error
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,49 @@
+package tenant
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Header names the tenant of an HTTP request.
+const Header = "X-Tenant-Id"
+
+// Middleware resolves the tenant of every request from its X-Tenant-Id
+// header and the authenticated principal, see Resolve, and stores it in the
+// request context. Invalid tenants are rejected with 400, tenants the
+// principal may not use with 403. Install it after authentication.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := Resolve(r.Context(), r.Header.Get(Header))
+		if err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, ErrForbiddenTenant) {
+				status = http.StatusForbidden
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+	})
+}
+
+// Transport sets X-Tenant-Id on outgoing requests from the tenant of their
+// context. A nil Base uses http.DefaultTransport.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if req.Header.Get(Header) == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(Header, FromContext(req.Context()))
+	}
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,65 @@
+// Package tenant carries the tenant a request acts for across services: the
+// X-Tenant-Id HTTP header, the x-tenant-id gRPC metadata key and the
+// tenant_id message metadata. Requests that name no tenant belong to
+// Default.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+)
+
+// Default is the tenant of requests that do not name one.
+const Default = "default"
+
+var (
+	// ErrInvalidTenant is returned for tenant IDs that are not lower case
+	// letters, digits, dashes and underscores, or longer than 64 bytes.
+	ErrInvalidTenant = errors.New("invalid tenant ID")
+
+	// ErrForbiddenTenant is returned when a principal bound to one tenant
+	// asks for another.
+	ErrForbiddenTenant = errors.New("tenant not allowed for these credentials")
+)
+
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+type tenantKey struct{}
+
+// NewContext returns a context acting for tenant id. Its log records carry
+// id as tenant_id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return logging.WithAttrs(context.WithValue(ctx, tenantKey{}, id), "tenant_id", id)
+}
+
+// FromContext returns the tenant of ctx, or Default.
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(tenantKey{}).(string); ok && id != "" {
+		return id
+	}
+	return Default
+}
+
+// Resolve returns the tenant of a request that asked for requested, which
+// may be empty. A principal bound to a tenant gets that tenant and may not
+// ask for another; other callers get the one they ask for, or Default.
+func Resolve(ctx context.Context, requested string) (string, error) {
+	if requested != "" && !validID.MatchString(requested) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTenant, requested)
+	}
+	if p, ok := auth.FromContext(ctx); ok && p.TenantID != "" {
+		if requested != "" && requested != p.TenantID {
+			return "", ErrForbiddenTenant
+		}
+		return p.TenantID, nil
+	}
+	if requested == "" {
+		return Default, nil
+	}
+	return requested, nil
+}
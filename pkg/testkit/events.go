@@ -0,0 +1,110 @@
+package testkit
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+)
+
+// WaitTimeout bounds how long AssertPublished waits for an event.
+var WaitTimeout = 2 * time.Second
+
+var recorders atomic.Int64
+
+// Recorder keeps every message published on a set of topics, in publish
+// order, in a queue of its own that nothing consumes.
+type Recorder struct {
+	queue *broker.Queue
+}
+
+// NewRecorder subscribes a new recorder to topics, which must exist.
+func NewRecorder(tb testing.TB, b *broker.Broker, topics ...string) *Recorder {
+	tb.Helper()
+
+	queue := b.CreateQueue(fmt.Sprintf("testkit-recorder-%d", recorders.Add(1)))
+	for _, topic := range topics {
+		if err := b.Subscribe(topic, queue.Name()); err != nil {
+			tb.Fatalf("testkit: record topic %s: %v", topic, err)
+		}
+	}
+	return &Recorder{queue: queue}
+}
+
+// Match selects messages in the Recorder queries.
+type Match func(*broker.Message) bool
+
+// WithMetadata matches messages whose metadata key is value, such as
+// WithMetadata("order_id", id).
+func WithMetadata(key, value string) Match {
+	return func(m *broker.Message) bool {
+		return m.GetMetadata(key) == value
+	}
+}
+
+// Messages returns copies of the recorded messages, oldest first.
+func (r *Recorder) Messages() []*broker.Message {
+	return r.queue.Peek(0)
+}
+
+// OfType returns the recorded messages of msgType accepted by every match.
+func (r *Recorder) OfType(msgType string, match ...Match) []*broker.Message {
+	var out []*broker.Message
+	for _, m := range r.Messages() {
+		if m.Type == msgType && matches(m, match) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// Types returns the types of the recorded messages, oldest first.
+func (r *Recorder) Types() []string {
+	msgs := r.Messages()
+	types := make([]string, len(msgs))
+	for i, m := range msgs {
+		types[i] = m.Type
+	}
+	return types
+}
+
+// AssertPublished waits up to WaitTimeout for a message of msgType
+// accepted by every match and returns the first one. It fails tb when none
+// arrives.
+func (r *Recorder) AssertPublished(tb testing.TB, msgType string, match ...Match) *broker.Message {
+	tb.Helper()
+
+	deadline := time.Now().Add(WaitTimeout)
+	for {
+		if found := r.OfType(msgType, match...); len(found) > 0 {
+			return found[0]
+		}
+		if time.Now().After(deadline) {
+			tb.Fatalf("no %s event published within %s; recorded %v", msgType, WaitTimeout, r.Types())
+			return nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// AssertNotPublished fails tb if a message of msgType accepted by every
+// match was recorded. Events published in the background must have been
+// flushed before, or the check may pass too early.
+func (r *Recorder) AssertNotPublished(tb testing.TB, msgType string, match ...Match) {
+	tb.Helper()
+
+	if found := r.OfType(msgType, match...); len(found) > 0 {
+		tb.Fatalf("unexpected %s event: %s", msgType, found[0].Payload)
+	}
+}
+
+func matches(m *broker.Message, match []Match) bool {
+	for _, ok := range match {
+		if !ok(m) {
+			return false
+		}
+	}
+	return true
+}
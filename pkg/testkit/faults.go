@@ -0,0 +1,132 @@
+package testkit
+
+import (
+	"context"
+	"path"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Faults injects failures and delays into the RPCs of a gRPC server. Rules
+// name methods by their short name, such as "ProcessPayment", and stay in
+// place until Reset.
+type Faults struct {
+	mu    sync.Mutex
+	rules map[string]*fault
+	calls map[string]int
+}
+
+type fault struct {
+	err   error
+	delay time.Duration
+	// times is the number of calls left to fail; negative fails them all.
+	times int
+}
+
+func NewFaults() *Faults {
+	return &Faults{
+		rules: make(map[string]*fault),
+		calls: make(map[string]int),
+	}
+}
+
+// Fail makes every call of method return err, usually a status error.
+func (f *Faults) Fail(method string, err error) {
+	f.FailTimes(method, -1, err)
+}
+
+// FailTimes makes the next n calls of method return err.
+func (f *Faults) FailTimes(method string, n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rule(method).err = err
+	f.rule(method).times = n
+}
+
+// Delay holds every call of method for d before it is handled, or until
+// the call is cancelled.
+func (f *Faults) Delay(method string, d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rule(method).delay = d
+}
+
+// Reset removes every rule. Call counts are kept.
+func (f *Faults) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	clear(f.rules)
+}
+
+// Calls returns how many calls of method reached the server, failed ones
+// included.
+func (f *Faults) Calls(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+func (f *Faults) rule(method string) *fault {
+	r, ok := f.rules[method]
+	if !ok {
+		r = &fault{}
+		f.rules[method] = r
+	}
+	return r
+}
+
+// take counts a call of method and returns the delay and error it gets.
+func (f *Faults) take(method string) (time.Duration, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls[method]++
+	r, ok := f.rules[method]
+	if !ok {
+		return 0, nil
+	}
+	err := r.err
+	if err != nil {
+		if r.times == 0 {
+			err = nil
+		} else if r.times > 0 {
+			r.times--
+		}
+	}
+	return r.delay, err
+}
+
+func (f *Faults) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := f.apply(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (f *Faults) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := f.apply(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func (f *Faults) apply(ctx context.Context, fullMethod string) error {
+	delay, err := f.take(path.Base(fullMethod))
+	if delay > 0 {
+		t := time.NewTimer(delay)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	return err
+}
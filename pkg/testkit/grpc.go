@@ -0,0 +1,42 @@
+package testkit
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	_ "github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/codec"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// bufSize is the buffer of the in-memory listeners.
+const bufSize = 1 << 20
+
+// ServeGRPC serves srv on an in-memory listener until tb ends and returns
+// a connection to it. Calls use the JSON codec, like the services do.
+func ServeGRPC(tb testing.TB, srv *grpc.Server, opts ...grpc.DialOption) *grpc.ClientConn {
+	tb.Helper()
+
+	lis := bufconn.Listen(bufSize)
+	go srv.Serve(lis)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn", append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype("json")),
+	}, opts...)...)
+	if err != nil {
+		srv.Stop()
+		tb.Fatalf("testkit: dial in-memory gRPC server: %v", err)
+	}
+
+	tb.Cleanup(func() {
+		conn.Close()
+		srv.Stop()
+	})
+	return conn
+}
@@ -0,0 +1,223 @@
+// Package catalog provides types and gRPC service definitions for the product catalog.
+// NOTE: In production, these would be generated by protoc from catalog.proto
+package catalog
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/runtime/protoimpl"
+)
+
+// Ensure we implement proto.Message interface
+var (
+	_ proto.Message = (*Price)(nil)
+	_ proto.Message = (*Product)(nil)
+	_ proto.Message = (*GetProductRequest)(nil)
+	_ proto.Message = (*GetPricesRequest)(nil)
+	_ proto.Message = (*ProductPrice)(nil)
+	_ proto.Message = (*GetPricesResponse)(nil)
+)
+
+// Price is the unit price of a product in one currency
+type Price struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Currency       string `protobuf:"bytes,1,opt,name=currency,proto3" json:"currency,omitempty"`
+	UnitPriceCents int64  `protobuf:"varint,2,opt,name=unit_price_cents,proto3" json:"unit_price_cents,omitempty"`
+}
+
+func (x *Price) Reset()                           { *x = Price{} }
+func (x *Price) String() string                   { return "Price" }
+func (*Price) ProtoMessage()                      {}
+func (*Price) ProtoReflect() protoreflect.Message { return nil }
+func (*Price) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *Price) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Price) GetUnitPriceCents() int64 {
+	if x != nil {
+		return x.UnitPriceCents
+	}
+	return 0
+}
+
+// Product is an item for sale
+type Product struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ID     string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name   string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Prices []*Price `protobuf:"bytes,3,rep,name=prices,proto3" json:"prices,omitempty"`
+}
+
+func (x *Product) Reset()                           { *x = Product{} }
+func (x *Product) String() string                   { return "Product" }
+func (*Product) ProtoMessage()                      {}
+func (*Product) ProtoReflect() protoreflect.Message { return nil }
+func (*Product) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *Product) GetID() string {
+	if x != nil {
+		return x.ID
+	}
+	return ""
+}
+
+func (x *Product) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Product) GetPrices() []*Price {
+	if x != nil {
+		return x.Prices
+	}
+	return nil
+}
+
+// GetProductRequest names a product
+type GetProductRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ProductID string `protobuf:"bytes,1,opt,name=product_id,proto3" json:"product_id,omitempty"`
+}
+
+func (x *GetProductRequest) Reset()                           { *x = GetProductRequest{} }
+func (x *GetProductRequest) String() string                   { return "GetProductRequest" }
+func (*GetProductRequest) ProtoMessage()                      {}
+func (*GetProductRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*GetProductRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *GetProductRequest) GetProductID() string {
+	if x != nil {
+		return x.ProductID
+	}
+	return ""
+}
+
+// GetPricesRequest names the products to price and the currency
+type GetPricesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ProductIDs []string `protobuf:"bytes,1,rep,name=product_ids,proto3" json:"product_ids,omitempty"`
+	Currency   string   `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"`
+}
+
+func (x *GetPricesRequest) Reset()                           { *x = GetPricesRequest{} }
+func (x *GetPricesRequest) String() string                   { return "GetPricesRequest" }
+func (*GetPricesRequest) ProtoMessage()                      {}
+func (*GetPricesRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*GetPricesRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *GetPricesRequest) GetProductIDs() []string {
+	if x != nil {
+		return x.ProductIDs
+	}
+	return nil
+}
+
+func (x *GetPricesRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+// ProductPrice is the price of one requested product
+type ProductPrice struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ProductID      string `protobuf:"bytes,1,opt,name=product_id,proto3" json:"product_id,omitempty"`
+	Name           string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Currency       string `protobuf:"bytes,3,opt,name=currency,proto3" json:"currency,omitempty"`
+	UnitPriceCents int64  `protobuf:"varint,4,opt,name=unit_price_cents,proto3" json:"unit_price_cents,omitempty"`
+}
+
+func (x *ProductPrice) Reset()                           { *x = ProductPrice{} }
+func (x *ProductPrice) String() string                   { return "ProductPrice" }
+func (*ProductPrice) ProtoMessage()                      {}
+func (*ProductPrice) ProtoReflect() protoreflect.Message { return nil }
+func (*ProductPrice) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *ProductPrice) GetProductID() string {
+	if x != nil {
+		return x.ProductID
+	}
+	return ""
+}
+
+func (x *ProductPrice) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ProductPrice) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *ProductPrice) GetUnitPriceCents() int64 {
+	if x != nil {
+		return x.UnitPriceCents
+	}
+	return 0
+}
+
+// GetPricesResponse holds the prices found and the products left unpriced
+type GetPricesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Prices             []*ProductPrice `protobuf:"bytes,1,rep,name=prices,proto3" json:"prices,omitempty"`
+	UnknownProductIDs  []string        `protobuf:"bytes,2,rep,name=unknown_product_ids,proto3" json:"unknown_product_ids,omitempty"`
+	UnpricedProductIDs []string        `protobuf:"bytes,3,rep,name=unpriced_product_ids,proto3" json:"unpriced_product_ids,omitempty"`
+}
+
+func (x *GetPricesResponse) Reset()                           { *x = GetPricesResponse{} }
+func (x *GetPricesResponse) String() string                   { return "GetPricesResponse" }
+func (*GetPricesResponse) ProtoMessage()                      {}
+func (*GetPricesResponse) ProtoReflect() protoreflect.Message { return nil }
+func (*GetPricesResponse) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *GetPricesResponse) GetPrices() []*ProductPrice {
+	if x != nil {
+		return x.Prices
+	}
+	return nil
+}
+
+func (x *GetPricesResponse) GetUnknownProductIDs() []string {
+	if x != nil {
+		return x.UnknownProductIDs
+	}
+	return nil
+}
+
+func (x *GetPricesResponse) GetUnpricedProductIDs() []string {
+	if x != nil {
+		return x.UnpricedProductIDs
+	}
+	return nil
+}
@@ -0,0 +1,385 @@
+// Package customer provides types and gRPC service definitions for customer profiles.
+// NOTE: In production, these would be generated by protoc from customer.proto
+package customer
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/runtime/protoimpl"
+)
+
+// Ensure we implement proto.Message interface
+var (
+	_ proto.Message = (*Address)(nil)
+	_ proto.Message = (*PaymentPreferences)(nil)
+	_ proto.Message = (*Customer)(nil)
+	_ proto.Message = (*CreateCustomerRequest)(nil)
+	_ proto.Message = (*GetCustomerRequest)(nil)
+	_ proto.Message = (*UpdateCustomerRequest)(nil)
+	_ proto.Message = (*ListCustomersRequest)(nil)
+	_ proto.Message = (*ListCustomersResponse)(nil)
+)
+
+// Address is a postal address of the customer
+type Address struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Label      string `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"`
+	Line1      string `protobuf:"bytes,2,opt,name=line1,proto3" json:"line1,omitempty"`
+	Line2      string `protobuf:"bytes,3,opt,name=line2,proto3" json:"line2,omitempty"`
+	City       string `protobuf:"bytes,4,opt,name=city,proto3" json:"city,omitempty"`
+	State      string `protobuf:"bytes,5,opt,name=state,proto3" json:"state,omitempty"`
+	PostalCode string `protobuf:"bytes,6,opt,name=postal_code,proto3" json:"postal_code,omitempty"`
+	Country    string `protobuf:"bytes,7,opt,name=country,proto3" json:"country,omitempty"`
+	Default    bool   `protobuf:"varint,8,opt,name=default,proto3" json:"default,omitempty"`
+}
+
+func (x *Address) Reset()                           { *x = Address{} }
+func (x *Address) String() string                   { return "Address" }
+func (*Address) ProtoMessage()                      {}
+func (*Address) ProtoReflect() protoreflect.Message { return nil }
+func (*Address) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *Address) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Address) GetLine1() string {
+	if x != nil {
+		return x.Line1
+	}
+	return ""
+}
+
+func (x *Address) GetLine2() string {
+	if x != nil {
+		return x.Line2
+	}
+	return ""
+}
+
+func (x *Address) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *Address) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Address) GetPostalCode() string {
+	if x != nil {
+		return x.PostalCode
+	}
+	return ""
+}
+
+func (x *Address) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *Address) GetDefault() bool {
+	if x != nil {
+		return x.Default
+	}
+	return false
+}
+
+// PaymentPreferences are the customer's defaults for new orders
+type PaymentPreferences struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Method   string `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"`
+	Currency string `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"`
+}
+
+func (x *PaymentPreferences) Reset()                           { *x = PaymentPreferences{} }
+func (x *PaymentPreferences) String() string                   { return "PaymentPreferences" }
+func (*PaymentPreferences) ProtoMessage()                      {}
+func (*PaymentPreferences) ProtoReflect() protoreflect.Message { return nil }
+func (*PaymentPreferences) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *PaymentPreferences) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *PaymentPreferences) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+// Customer is a customer profile
+type Customer struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ID                 string              `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Email              string              `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Name               string              `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Phone              string              `protobuf:"bytes,4,opt,name=phone,proto3" json:"phone,omitempty"`
+	Addresses          []*Address          `protobuf:"bytes,5,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	PaymentPreferences *PaymentPreferences `protobuf:"bytes,6,opt,name=payment_preferences,proto3" json:"payment_preferences,omitempty"`
+	CreatedAt          time.Time           `protobuf:"bytes,7,opt,name=created_at,proto3" json:"created_at,omitempty"`
+	UpdatedAt          time.Time           `protobuf:"bytes,8,opt,name=updated_at,proto3" json:"updated_at,omitempty"`
+}
+
+func (x *Customer) Reset()                           { *x = Customer{} }
+func (x *Customer) String() string                   { return "Customer" }
+func (*Customer) ProtoMessage()                      {}
+func (*Customer) ProtoReflect() protoreflect.Message { return nil }
+func (*Customer) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *Customer) GetID() string {
+	if x != nil {
+		return x.ID
+	}
+	return ""
+}
+
+func (x *Customer) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *Customer) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Customer) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+func (x *Customer) GetAddresses() []*Address {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+func (x *Customer) GetPaymentPreferences() *PaymentPreferences {
+	if x != nil {
+		return x.PaymentPreferences
+	}
+	return nil
+}
+
+// CreateCustomerRequest stores a new profile; an empty ID is generated
+type CreateCustomerRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ID                 string              `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Email              string              `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Name               string              `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Phone              string              `protobuf:"bytes,4,opt,name=phone,proto3" json:"phone,omitempty"`
+	Addresses          []*Address          `protobuf:"bytes,5,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	PaymentPreferences *PaymentPreferences `protobuf:"bytes,6,opt,name=payment_preferences,proto3" json:"payment_preferences,omitempty"`
+}
+
+func (x *CreateCustomerRequest) Reset()                           { *x = CreateCustomerRequest{} }
+func (x *CreateCustomerRequest) String() string                   { return "CreateCustomerRequest" }
+func (*CreateCustomerRequest) ProtoMessage()                      {}
+func (*CreateCustomerRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*CreateCustomerRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *CreateCustomerRequest) GetID() string {
+	if x != nil {
+		return x.ID
+	}
+	return ""
+}
+
+func (x *CreateCustomerRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *CreateCustomerRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateCustomerRequest) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+func (x *CreateCustomerRequest) GetAddresses() []*Address {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+func (x *CreateCustomerRequest) GetPaymentPreferences() *PaymentPreferences {
+	if x != nil {
+		return x.PaymentPreferences
+	}
+	return nil
+}
+
+// GetCustomerRequest looks up a profile by ID
+type GetCustomerRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CustomerID string `protobuf:"bytes,1,opt,name=customer_id,proto3" json:"customer_id,omitempty"`
+}
+
+func (x *GetCustomerRequest) Reset()                           { *x = GetCustomerRequest{} }
+func (x *GetCustomerRequest) String() string                   { return "GetCustomerRequest" }
+func (*GetCustomerRequest) ProtoMessage()                      {}
+func (*GetCustomerRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*GetCustomerRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *GetCustomerRequest) GetCustomerID() string {
+	if x != nil {
+		return x.CustomerID
+	}
+	return ""
+}
+
+// UpdateCustomerRequest replaces the fields that are set
+type UpdateCustomerRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CustomerID         string              `protobuf:"bytes,1,opt,name=customer_id,proto3" json:"customer_id,omitempty"`
+	Email              string              `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Name               string              `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Phone              string              `protobuf:"bytes,4,opt,name=phone,proto3" json:"phone,omitempty"`
+	Addresses          []*Address          `protobuf:"bytes,5,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	PaymentPreferences *PaymentPreferences `protobuf:"bytes,6,opt,name=payment_preferences,proto3" json:"payment_preferences,omitempty"`
+}
+
+func (x *UpdateCustomerRequest) Reset()                           { *x = UpdateCustomerRequest{} }
+func (x *UpdateCustomerRequest) String() string                   { return "UpdateCustomerRequest" }
+func (*UpdateCustomerRequest) ProtoMessage()                      {}
+func (*UpdateCustomerRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*UpdateCustomerRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *UpdateCustomerRequest) GetCustomerID() string {
+	if x != nil {
+		return x.CustomerID
+	}
+	return ""
+}
+
+func (x *UpdateCustomerRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *UpdateCustomerRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UpdateCustomerRequest) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+func (x *UpdateCustomerRequest) GetAddresses() []*Address {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+func (x *UpdateCustomerRequest) GetPaymentPreferences() *PaymentPreferences {
+	if x != nil {
+		return x.PaymentPreferences
+	}
+	return nil
+}
+
+// ListCustomersRequest filters profiles by email
+type ListCustomersRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Email string `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+}
+
+func (x *ListCustomersRequest) Reset()                           { *x = ListCustomersRequest{} }
+func (x *ListCustomersRequest) String() string                   { return "ListCustomersRequest" }
+func (*ListCustomersRequest) ProtoMessage()                      {}
+func (*ListCustomersRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*ListCustomersRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *ListCustomersRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+// ListCustomersResponse holds the matching profiles
+type ListCustomersResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Customers []*Customer `protobuf:"bytes,1,rep,name=customers,proto3" json:"customers,omitempty"`
+}
+
+func (x *ListCustomersResponse) Reset()                           { *x = ListCustomersResponse{} }
+func (x *ListCustomersResponse) String() string                   { return "ListCustomersResponse" }
+func (*ListCustomersResponse) ProtoMessage()                      {}
+func (*ListCustomersResponse) ProtoReflect() protoreflect.Message { return nil }
+func (*ListCustomersResponse) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *ListCustomersResponse) GetCustomers() []*Customer {
+	if x != nil {
+		return x.Customers
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+// Package inventory provides types and gRPC service definitions for stock reservations.
+// NOTE: In production, these would be generated by protoc from inventory.proto
+package inventory
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/runtime/protoimpl"
+)
+
+// ReservationStatus enum for reservation states
+type ReservationStatus int32
+
+const (
+	ReservationStatus_RESERVATION_STATUS_UNSPECIFIED ReservationStatus = 0
+	ReservationStatus_RESERVATION_STATUS_ACTIVE      ReservationStatus = 1
+	ReservationStatus_RESERVATION_STATUS_RELEASED    ReservationStatus = 2
+)
+
+func (s ReservationStatus) String() string {
+	switch s {
+	case ReservationStatus_RESERVATION_STATUS_ACTIVE:
+		return "ACTIVE"
+	case ReservationStatus_RESERVATION_STATUS_RELEASED:
+		return "RELEASED"
+	default:
+		return "UNSPECIFIED"
+	}
+}
+
+// Ensure we implement proto.Message interface
+var (
+	_ proto.Message = (*StockItem)(nil)
+	_ proto.Message = (*CheckStockRequest)(nil)
+	_ proto.Message = (*CheckStockResponse)(nil)
+	_ proto.Message = (*StockLevel)(nil)
+	_ proto.Message = (*ReserveStockRequest)(nil)
+	_ proto.Message = (*ReleaseReservationRequest)(nil)
+	_ proto.Message = (*Reservation)(nil)
+)
+
+// StockItem is a product and a quantity
+type StockItem struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ProductID string `protobuf:"bytes,1,opt,name=product_id,proto3" json:"product_id,omitempty"`
+	Quantity  int32  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+}
+
+func (x *StockItem) Reset()                           { *x = StockItem{} }
+func (x *StockItem) String() string                   { return "StockItem" }
+func (*StockItem) ProtoMessage()                      {}
+func (*StockItem) ProtoReflect() protoreflect.Message { return nil }
+func (*StockItem) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *StockItem) GetProductID() string {
+	if x != nil {
+		return x.ProductID
+	}
+	return ""
+}
+
+func (x *StockItem) GetQuantity() int32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+// CheckStockRequest asks whether the items are available
+type CheckStockRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Items []*StockItem `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+}
+
+func (x *CheckStockRequest) Reset()                           { *x = CheckStockRequest{} }
+func (x *CheckStockRequest) String() string                   { return "CheckStockRequest" }
+func (*CheckStockRequest) ProtoMessage()                      {}
+func (*CheckStockRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*CheckStockRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *CheckStockRequest) GetItems() []*StockItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+// CheckStockResponse reports the availability of each requested item
+type CheckStockResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Available bool          `protobuf:"varint,1,opt,name=available,proto3" json:"available,omitempty"`
+	Levels    []*StockLevel `protobuf:"bytes,2,rep,name=levels,proto3" json:"levels,omitempty"`
+}
+
+func (x *CheckStockResponse) Reset()                           { *x = CheckStockResponse{} }
+func (x *CheckStockResponse) String() string                   { return "CheckStockResponse" }
+func (*CheckStockResponse) ProtoMessage()                      {}
+func (*CheckStockResponse) ProtoReflect() protoreflect.Message { return nil }
+func (*CheckStockResponse) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *CheckStockResponse) GetAvailable() bool {
+	if x != nil {
+		return x.Available
+	}
+	return false
+}
+
+func (x *CheckStockResponse) GetLevels() []*StockLevel {
+	if x != nil {
+		return x.Levels
+	}
+	return nil
+}
+
+// StockLevel is the availability of one requested product
+type StockLevel struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ProductID string `protobuf:"bytes,1,opt,name=product_id,proto3" json:"product_id,omitempty"`
+	Requested int32  `protobuf:"varint,2,opt,name=requested,proto3" json:"requested,omitempty"`
+	Available int32  `protobuf:"varint,3,opt,name=available,proto3" json:"available,omitempty"`
+}
+
+func (x *StockLevel) Reset()                           { *x = StockLevel{} }
+func (x *StockLevel) String() string                   { return "StockLevel" }
+func (*StockLevel) ProtoMessage()                      {}
+func (*StockLevel) ProtoReflect() protoreflect.Message { return nil }
+func (*StockLevel) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *StockLevel) GetProductID() string {
+	if x != nil {
+		return x.ProductID
+	}
+	return ""
+}
+
+func (x *StockLevel) GetRequested() int32 {
+	if x != nil {
+		return x.Requested
+	}
+	return 0
+}
+
+func (x *StockLevel) GetAvailable() int32 {
+	if x != nil {
+		return x.Available
+	}
+	return 0
+}
+
+// ReserveStockRequest holds stock for an order
+type ReserveStockRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	OrderID string       `protobuf:"bytes,1,opt,name=order_id,proto3" json:"order_id,omitempty"`
+	Items   []*StockItem `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+}
+
+func (x *ReserveStockRequest) Reset()                           { *x = ReserveStockRequest{} }
+func (x *ReserveStockRequest) String() string                   { return "ReserveStockRequest" }
+func (*ReserveStockRequest) ProtoMessage()                      {}
+func (*ReserveStockRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*ReserveStockRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *ReserveStockRequest) GetOrderID() string {
+	if x != nil {
+		return x.OrderID
+	}
+	return ""
+}
+
+func (x *ReserveStockRequest) GetItems() []*StockItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+// ReleaseReservationRequest returns reserved stock
+type ReleaseReservationRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ReservationID string `protobuf:"bytes,1,opt,name=reservation_id,proto3" json:"reservation_id,omitempty"`
+	Reason        string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (x *ReleaseReservationRequest) Reset()                           { *x = ReleaseReservationRequest{} }
+func (x *ReleaseReservationRequest) String() string                   { return "ReleaseReservationRequest" }
+func (*ReleaseReservationRequest) ProtoMessage()                      {}
+func (*ReleaseReservationRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*ReleaseReservationRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *ReleaseReservationRequest) GetReservationID() string {
+	if x != nil {
+		return x.ReservationID
+	}
+	return ""
+}
+
+func (x *ReleaseReservationRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// Reservation holds stock for one order
+type Reservation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ReservationID string            `protobuf:"bytes,1,opt,name=reservation_id,proto3" json:"reservation_id,omitempty"`
+	OrderID       string            `protobuf:"bytes,2,opt,name=order_id,proto3" json:"order_id,omitempty"`
+	Items         []*StockItem      `protobuf:"bytes,3,rep,name=items,proto3" json:"items,omitempty"`
+	Status        ReservationStatus `protobuf:"varint,4,opt,name=status,proto3" json:"status,omitempty"`
+	Reason        string            `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	CreatedAt     time.Time         `protobuf:"bytes,6,opt,name=created_at,proto3" json:"created_at,omitempty"`
+	ReleasedAt    time.Time         `protobuf:"bytes,7,opt,name=released_at,proto3" json:"released_at,omitempty"`
+}
+
+func (x *Reservation) Reset()                           { *x = Reservation{} }
+func (x *Reservation) String() string                   { return "Reservation" }
+func (*Reservation) ProtoMessage()                      {}
+func (*Reservation) ProtoReflect() protoreflect.Message { return nil }
+func (*Reservation) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *Reservation) GetReservationID() string {
+	if x != nil {
+		return x.ReservationID
+	}
+	return ""
+}
+
+func (x *Reservation) GetOrderID() string {
+	if x != nil {
+		return x.OrderID
+	}
+	return ""
+}
+
+func (x *Reservation) GetItems() []*StockItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *Reservation) GetStatus() ReservationStatus {
+	if x != nil {
+		return x.Status
+	}
+	return ReservationStatus_RESERVATION_STATUS_UNSPECIFIED
+}
+
+func (x *Reservation) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
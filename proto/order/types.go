@@ -8,6 +8,7 @@
 package order
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -15,13 +16,15 @@ import (
 type OrderStatus int32
 
 const (
-	OrderStatus_ORDER_STATUS_UNSPECIFIED OrderStatus = 0
-	OrderStatus_ORDER_STATUS_PENDING     OrderStatus = 1
-	OrderStatus_ORDER_STATUS_PAID        OrderStatus = 2
-	OrderStatus_ORDER_STATUS_PROCESSING  OrderStatus = 3
-	OrderStatus_ORDER_STATUS_SHIPPED     OrderStatus = 4
-	OrderStatus_ORDER_STATUS_DELIVERED   OrderStatus = 5
-	OrderStatus_ORDER_STATUS_CANCELLED   OrderStatus = 6
+	OrderStatus_ORDER_STATUS_UNSPECIFIED  OrderStatus = 0
+	OrderStatus_ORDER_STATUS_PENDING      OrderStatus = 1
+	OrderStatus_ORDER_STATUS_PAID         OrderStatus = 2
+	OrderStatus_ORDER_STATUS_PROCESSING   OrderStatus = 3
+	OrderStatus_ORDER_STATUS_SHIPPED      OrderStatus = 4
+	OrderStatus_ORDER_STATUS_DELIVERED    OrderStatus = 5
+	OrderStatus_ORDER_STATUS_CANCELLED    OrderStatus = 6
+	OrderStatus_ORDER_STATUS_DISPUTED     OrderStatus = 7
+	OrderStatus_ORDER_STATUS_CHARGED_BACK OrderStatus = 8
 )
 
 func (s OrderStatus) String() string {
@@ -38,6 +41,39 @@ func (s OrderStatus) String() string {
 		return "DELIVERED"
 	case OrderStatus_ORDER_STATUS_CANCELLED:
 		return "CANCELLED"
+	case OrderStatus_ORDER_STATUS_DISPUTED:
+		return "DISPUTED"
+	case OrderStatus_ORDER_STATUS_CHARGED_BACK:
+		return "CHARGED_BACK"
+	default:
+		return "UNSPECIFIED"
+	}
+}
+
+// ReturnStatus enum for the states of a return
+type ReturnStatus int32
+
+const (
+	ReturnStatus_RETURN_STATUS_UNSPECIFIED ReturnStatus = 0
+	ReturnStatus_RETURN_STATUS_REQUESTED   ReturnStatus = 1
+	ReturnStatus_RETURN_STATUS_APPROVED    ReturnStatus = 2
+	ReturnStatus_RETURN_STATUS_RECEIVED    ReturnStatus = 3
+	ReturnStatus_RETURN_STATUS_REFUNDED    ReturnStatus = 4
+	ReturnStatus_RETURN_STATUS_REJECTED    ReturnStatus = 5
+)
+
+func (s ReturnStatus) String() string {
+	switch s {
+	case ReturnStatus_RETURN_STATUS_REQUESTED:
+		return "REQUESTED"
+	case ReturnStatus_RETURN_STATUS_APPROVED:
+		return "APPROVED"
+	case ReturnStatus_RETURN_STATUS_RECEIVED:
+		return "RECEIVED"
+	case ReturnStatus_RETURN_STATUS_REFUNDED:
+		return "REFUNDED"
+	case ReturnStatus_RETURN_STATUS_REJECTED:
+		return "REJECTED"
 	default:
 		return "UNSPECIFIED"
 	}
@@ -49,6 +85,27 @@ type OrderItem struct {
 	ProductName    string `json:"product_name"`
 	Quantity       int32  `json:"quantity"`
 	UnitPriceCents int64  `json:"unit_price_cents"`
+
+	// Status is the status of the fulfillment holding the item, or the
+	// order status while the item is in none
+	Status OrderStatus `json:"status,omitempty"`
+}
+
+// Fulfillment is a group of order items shipped together. It starts
+// PROCESSING and moves to SHIPPED and DELIVERED on its own, or to CANCELLED
+// with the order
+type Fulfillment struct {
+	ID string `json:"id"`
+
+	// Items are indexes into Order.Items
+	Items  []int       `json:"items"`
+	Status OrderStatus `json:"status"`
+
+	Carrier        string `json:"carrier,omitempty"`
+	TrackingNumber string `json:"tracking_number,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // Order represents an order in the system
@@ -80,22 +137,143 @@ type Order struct {
 	// Timestamps
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// DisputeID is the open or last dispute raised against the payment
+	DisputeID string `json:"dispute_id,omitempty"`
+
+	// Transitions is the status history, oldest first
+	Transitions []OrderStatusTransition `json:"transitions,omitempty"`
+
+	// ReservationID is the inventory reservation holding the items
+	ReservationID string `json:"reservation_id,omitempty"`
+
+	// Customer is the customer profile at the time the order was placed
+	Customer *CustomerSnapshot `json:"customer,omitempty"`
+
+	// ShippingAddress is where the order ships to and selects the tax rate
+	ShippingAddress *Address `json:"shipping_address,omitempty"`
+
+	// Pricing itemizes how TotalCents was computed
+	Pricing *PriceBreakdown `json:"pricing,omitempty"`
+
+	// TenantID is the tenant the order belongs to
+	TenantID string `json:"tenant_id"`
+
+	// Version starts at 1 and grows with every change of the order
+	Version int64 `json:"version"`
+
+	// Fulfillments split the items into groups shipped separately. Once
+	// there is one, Status is derived from the status of the items
+	Fulfillments []Fulfillment `json:"fulfillments,omitempty"`
+
+	// Returns are the items sent back by the customer, in the order they
+	// were requested
+	Returns []Return `json:"returns,omitempty"`
 }
 
-// OrderCreatedEvent is published when a new order is created
-// This is the main event that triggers downstream processing
-type OrderCreatedEvent struct {
-	// EventID is a unique identifier for this event
-	EventID string `json:"event_id"`
+// ReturnItem is a quantity of one order item sent back
+type ReturnItem struct {
+	// Item is an index into Order.Items
+	Item     int   `json:"item"`
+	Quantity int32 `json:"quantity"`
+}
 
-	// EventType is always "order.created"
-	EventType string `json:"event_type"`
+// Return is a request to send items of a delivered order back for a
+// refund. It moves from REQUESTED to APPROVED or REJECTED, then to
+// RECEIVED when the items arrive and to REFUNDED once RefundCents is paid
+// back
+type Return struct {
+	ID     string       `json:"id"`
+	Items  []ReturnItem `json:"items"`
+	Status ReturnStatus `json:"status"`
+	Reason string       `json:"reason,omitempty"`
 
-	// Timestamp when the event was created
-	Timestamp time.Time `json:"timestamp"`
+	// RefundCents is the share of the order total paid for the items
+	RefundCents int64 `json:"refund_cents"`
 
-	// Order is the full order data
-	Order Order `json:"order"`
+	// Note is the reason of the last status change
+	Note string `json:"note,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PriceBreakdown itemizes the total of an order. TotalCents is
+// SubtotalCents - DiscountCents + TaxCents
+type PriceBreakdown struct {
+	Lines   []PriceLine     `json:"lines"`
+	Coupons []AppliedCoupon `json:"coupons,omitempty"`
+
+	// SubtotalCents is the sum of the line subtotals, before discounts
+	SubtotalCents int64 `json:"subtotal_cents"`
+
+	// DiscountCents is the sum of line discounts and coupons
+	DiscountCents int64 `json:"discount_cents"`
+
+	// TaxRegion is the matched tax rule, such as "BR-SP", or empty
+	TaxRegion  string `json:"tax_region,omitempty"`
+	TaxRateBps int64  `json:"tax_rate_bps"`
+	TaxCents   int64  `json:"tax_cents"`
+
+	TotalCents int64 `json:"total_cents"`
+}
+
+// PriceLine is the price of one order item
+type PriceLine struct {
+	ProductID      string `json:"product_id,omitempty"`
+	Quantity       int32  `json:"quantity"`
+	UnitPriceCents int64  `json:"unit_price_cents"`
+	SubtotalCents  int64  `json:"subtotal_cents"`
+	DiscountCents  int64  `json:"discount_cents"`
+
+	// Discount describes the line discount, if any
+	Discount   string `json:"discount,omitempty"`
+	TotalCents int64  `json:"total_cents"`
+}
+
+// AppliedCoupon is a coupon redeemed on an order
+type AppliedCoupon struct {
+	Code          string `json:"code"`
+	Description   string `json:"description"`
+	DiscountCents int64  `json:"discount_cents"`
+}
+
+// CustomerSnapshot is a copy of the customer profile kept with an order, so
+// consumers of order events do not need to call the Customer Service
+type CustomerSnapshot struct {
+	ID                     string   `json:"id"`
+	Name                   string   `json:"name,omitempty"`
+	Email                  string   `json:"email"`
+	Phone                  string   `json:"phone,omitempty"`
+	ShippingAddress        *Address `json:"shipping_address,omitempty"`
+	PreferredPaymentMethod string   `json:"preferred_payment_method,omitempty"`
+}
+
+// Address is a postal address
+type Address struct {
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	State      string `json:"state,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	Country    string `json:"country"`
+}
+
+// OrderStatusTransition records a single status change
+type OrderStatusTransition struct {
+	From   OrderStatus `json:"from"`
+	To     OrderStatus `json:"to"`
+	At     time.Time   `json:"at"`
+	Reason string      `json:"reason,omitempty"`
+}
+
+// OrderRequestedEvent is published when an order is accepted for payment
+// in the background
+type OrderRequestedEvent struct {
+	EventID   string    `json:"event_id"`
+	EventType string    `json:"event_type"`
+	Timestamp time.Time `json:"timestamp"`
+	OrderID   string    `json:"order_id"`
 }
 
 // OrderPaidEvent is published when payment is confirmed
@@ -108,22 +286,125 @@ type OrderPaidEvent struct {
 	AmountCents   int64     `json:"amount_cents"`
 }
 
-// OrderCancelledEvent is published when an order is cancelled
-type OrderCancelledEvent struct {
-	EventID   string    `json:"event_id"`
-	EventType string    `json:"event_type"`
-	Timestamp time.Time `json:"timestamp"`
-	OrderID   string    `json:"order_id"`
-	Reason    string    `json:"reason"`
+// OrderExpiredEvent is published when an order left PENDING for too long
+// is cancelled
+type OrderExpiredEvent struct {
+	EventID       string    `json:"event_id"`
+	EventType     string    `json:"event_type"`
+	Timestamp     time.Time `json:"timestamp"`
+	OrderID       string    `json:"order_id"`
+	CustomerID    string    `json:"customer_id"`
+	TransactionID string    `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// OrderStatusChangedEvent is published for every status transition
+type OrderStatusChangedEvent struct {
+	EventID    string      `json:"event_id"`
+	EventType  string      `json:"event_type"`
+	Timestamp  time.Time   `json:"timestamp"`
+	OrderID    string      `json:"order_id"`
+	CustomerID string      `json:"customer_id"`
+	From       OrderStatus `json:"from"`
+	To         OrderStatus `json:"to"`
+	Reason     string      `json:"reason,omitempty"`
+
+	// Customer is the snapshot stored with the order, if any
+	Customer *CustomerSnapshot `json:"customer,omitempty"`
+}
+
+// Fulfillment event types
+const (
+	EventTypeFulfillmentCreated       = "order.fulfillment_created"
+	EventTypeFulfillmentStatusChanged = "order.fulfillment_status_changed"
+)
+
+// OrderFulfillmentEvent is published when a fulfillment is created or
+// changes status
+type OrderFulfillmentEvent struct {
+	EventID     string      `json:"event_id"`
+	EventType   string      `json:"event_type"`
+	Timestamp   time.Time   `json:"timestamp"`
+	OrderID     string      `json:"order_id"`
+	CustomerID  string      `json:"customer_id"`
+	Fulfillment Fulfillment `json:"fulfillment"`
+
+	// Items are the order items of the fulfillment
+	Items  []OrderItem `json:"items"`
+	From   OrderStatus `json:"from"`
+	To     OrderStatus `json:"to"`
+	Reason string      `json:"reason,omitempty"`
+
+	// OrderStatus is the status of the order after the change
+	OrderStatus OrderStatus `json:"order_status"`
+}
+
+// Return event types
+const (
+	EventTypeReturnRequested = "order.return_requested"
+	EventTypeReturnApproved  = "order.return_approved"
+	EventTypeReturnRejected  = "order.return_rejected"
+	EventTypeReturnReceived  = "order.return_received"
+	EventTypeReturnRefunded  = "order.return_refunded"
+)
+
+// OrderReturnEvent is published when a return is requested or changes
+// status
+type OrderReturnEvent struct {
+	EventID       string    `json:"event_id"`
+	EventType     string    `json:"event_type"`
+	Timestamp     time.Time `json:"timestamp"`
+	OrderID       string    `json:"order_id"`
+	CustomerID    string    `json:"customer_id"`
+	CustomerEmail string    `json:"customer_email"`
+	Currency      string    `json:"currency"`
+	Return        Return    `json:"return"`
+
+	// Items are the order items of the return
+	Items []OrderItem  `json:"items"`
+	From  ReturnStatus `json:"from"`
+	To    ReturnStatus `json:"to"`
+}
+
+// Dispute event types
+const (
+	EventTypeOrderDisputed    = "order.disputed"
+	EventTypeOrderDisputeWon  = "order.dispute_won"
+	EventTypeOrderChargedBack = "order.charged_back"
+)
+
+// OrderDisputeEvent is published when a dispute is opened or resolved
+type OrderDisputeEvent struct {
+	EventID       string    `json:"event_id"`
+	EventType     string    `json:"event_type"`
+	Timestamp     time.Time `json:"timestamp"`
+	OrderID       string    `json:"order_id"`
+	DisputeID     string    `json:"dispute_id"`
+	TransactionID string    `json:"transaction_id"`
+	AmountCents   int64     `json:"amount_cents"`
+	Reason        string    `json:"reason,omitempty"`
 }
 
-// NewOrderCreatedEvent creates a new OrderCreatedEvent
-func NewOrderCreatedEvent(order Order) OrderCreatedEvent {
-	return OrderCreatedEvent{
-		EventID:   "evt_" + order.ID,
-		EventType: "order.created",
+// EventTypeCustomerErased is the type of CustomerErasedEvent
+const EventTypeCustomerErased = "customer.erased"
+
+// CustomerErasedEvent is published when the personal data of a customer
+// was erased from its orders
+type CustomerErasedEvent struct {
+	EventID    string    `json:"event_id"`
+	EventType  string    `json:"event_type"`
+	Timestamp  time.Time `json:"timestamp"`
+	CustomerID string    `json:"customer_id"`
+	OrderIDs   []string  `json:"order_ids"`
+}
+
+// NewOrderRequestedEvent creates a new OrderRequestedEvent
+func NewOrderRequestedEvent(orderID string) OrderRequestedEvent {
+	return OrderRequestedEvent{
+		EventID:   "evt_requested_" + orderID,
+		EventType: "order.requested",
 		Timestamp: time.Now(),
-		Order:     order,
+		OrderID:   orderID,
 	}
 }
 
@@ -138,3 +419,121 @@ func NewOrderPaidEvent(orderID, transactionID string, amountCents int64) OrderPa
 		AmountCents:   amountCents,
 	}
 }
+
+// NewOrderDisputeEvent creates a new OrderDisputeEvent
+func NewOrderDisputeEvent(eventType string, o Order, reason string) OrderDisputeEvent {
+	return OrderDisputeEvent{
+		EventID:       "evt_" + o.DisputeID + "_" + eventType,
+		EventType:     eventType,
+		Timestamp:     time.Now(),
+		OrderID:       o.ID,
+		DisputeID:     o.DisputeID,
+		TransactionID: o.PaymentTransactionID,
+		AmountCents:   o.TotalCents,
+		Reason:        reason,
+	}
+}
+
+// NewCustomerErasedEvent creates a CustomerErasedEvent for the orders
+// orderIDs of customerID
+func NewCustomerErasedEvent(customerID string, orderIDs []string) CustomerErasedEvent {
+	now := time.Now()
+	return CustomerErasedEvent{
+		EventID:    fmt.Sprintf("evt_erased_%s_%d", customerID, now.UnixNano()),
+		EventType:  EventTypeCustomerErased,
+		Timestamp:  now,
+		CustomerID: customerID,
+		OrderIDs:   orderIDs,
+	}
+}
+
+// NewOrderExpiredEvent creates a new OrderExpiredEvent
+func NewOrderExpiredEvent(o Order) OrderExpiredEvent {
+	return OrderExpiredEvent{
+		EventID:       "evt_expired_" + o.ID,
+		EventType:     "order.expired",
+		Timestamp:     time.Now(),
+		OrderID:       o.ID,
+		CustomerID:    o.CustomerID,
+		TransactionID: o.PaymentTransactionID,
+		CreatedAt:     o.CreatedAt,
+	}
+}
+
+// NewOrderFulfillmentEvent creates an OrderFulfillmentEvent for fulfillment
+// f of o, which moved from status from, UNSPECIFIED when it was created
+func NewOrderFulfillmentEvent(o Order, f Fulfillment, from OrderStatus, reason string) OrderFulfillmentEvent {
+	eventType := EventTypeFulfillmentStatusChanged
+	if from == OrderStatus_ORDER_STATUS_UNSPECIFIED {
+		eventType = EventTypeFulfillmentCreated
+	}
+	items := make([]OrderItem, 0, len(f.Items))
+	for _, i := range f.Items {
+		if i >= 0 && i < len(o.Items) {
+			items = append(items, o.Items[i])
+		}
+	}
+	return OrderFulfillmentEvent{
+		EventID:     fmt.Sprintf("evt_fulfillment_%s_%s", f.ID, f.Status),
+		EventType:   eventType,
+		Timestamp:   f.UpdatedAt,
+		OrderID:     o.ID,
+		CustomerID:  o.CustomerID,
+		Fulfillment: f,
+		Items:       items,
+		From:        from,
+		To:          f.Status,
+		Reason:      reason,
+		OrderStatus: o.Status,
+	}
+}
+
+// returnEventTypes maps the status a return moved to to the event type
+var returnEventTypes = map[ReturnStatus]string{
+	ReturnStatus_RETURN_STATUS_REQUESTED: EventTypeReturnRequested,
+	ReturnStatus_RETURN_STATUS_APPROVED:  EventTypeReturnApproved,
+	ReturnStatus_RETURN_STATUS_REJECTED:  EventTypeReturnRejected,
+	ReturnStatus_RETURN_STATUS_RECEIVED:  EventTypeReturnReceived,
+	ReturnStatus_RETURN_STATUS_REFUNDED:  EventTypeReturnRefunded,
+}
+
+// NewOrderReturnEvent creates an OrderReturnEvent for return r of o, which
+// moved from status from, UNSPECIFIED when it was requested
+func NewOrderReturnEvent(o Order, r Return, from ReturnStatus) OrderReturnEvent {
+	items := make([]OrderItem, 0, len(r.Items))
+	for _, ri := range r.Items {
+		if ri.Item >= 0 && ri.Item < len(o.Items) {
+			item := o.Items[ri.Item]
+			item.Quantity = ri.Quantity
+			items = append(items, item)
+		}
+	}
+	return OrderReturnEvent{
+		EventID:       fmt.Sprintf("evt_return_%s_%s", r.ID, r.Status),
+		EventType:     returnEventTypes[r.Status],
+		Timestamp:     r.UpdatedAt,
+		OrderID:       o.ID,
+		CustomerID:    o.CustomerID,
+		CustomerEmail: o.CustomerEmail,
+		Currency:      o.Currency,
+		Return:        r,
+		Items:         items,
+		From:          from,
+		To:            r.Status,
+	}
+}
+
+// NewOrderStatusChangedEvent creates a new OrderStatusChangedEvent
+func NewOrderStatusChangedEvent(o Order, t OrderStatusTransition) OrderStatusChangedEvent {
+	return OrderStatusChangedEvent{
+		EventID:    fmt.Sprintf("evt_status_%s_%s_%d", o.ID, t.To, t.At.UnixNano()),
+		EventType:  "order.status_changed",
+		Timestamp:  t.At,
+		OrderID:    o.ID,
+		CustomerID: o.CustomerID,
+		From:       t.From,
+		To:         t.To,
+		Reason:     t.Reason,
+		Customer:   o.Customer,
+	}
+}
@@ -0,0 +1,69 @@
+package payment
+
+import (
+	"time"
+)
+
+const (
+	EventTypePaymentFailed = "payment.failed"
+
+	EventTypeDisputeOpened = "payment.dispute_opened"
+	EventTypeDisputeWon    = "payment.dispute_won"
+	EventTypeDisputeLost   = "payment.dispute_lost"
+)
+
+// PaymentEvent is published by the payment service for every failed scheduled
+// charge and every dispute change. Successful charges publish
+// events.PaymentCompletedV1
+type PaymentEvent struct {
+	EventID        string    `json:"event_id"`
+	EventType      string    `json:"event_type"`
+	Timestamp      time.Time `json:"timestamp"`
+	TransactionID  string    `json:"transaction_id,omitempty"`
+	OrderID        string    `json:"order_id"`
+	SubscriptionID string    `json:"subscription_id,omitempty"`
+	Cycle          int32     `json:"cycle,omitempty"`
+	DisputeID      string    `json:"dispute_id,omitempty"`
+	AmountCents    int64     `json:"amount_cents"`
+	Currency       string    `json:"currency"`
+	ErrorCode      string    `json:"error_code,omitempty"`
+	ErrorMessage   string    `json:"error_message,omitempty"`
+}
+
+// NewPaymentFailedEvent creates a PaymentEvent from a payment request and the
+// response that declined it
+func NewPaymentFailedEvent(req *PaymentRequest, resp *PaymentResponse) PaymentEvent {
+	return PaymentEvent{
+		EventID:       "evt_pay_" + req.IdempotencyKey,
+		EventType:     EventTypePaymentFailed,
+		Timestamp:     time.Now(),
+		TransactionID: resp.TransactionID,
+		OrderID:       req.OrderID,
+		AmountCents:   req.AmountCents,
+		Currency:      req.Currency,
+		ErrorCode:     resp.ErrorCode.String(),
+		ErrorMessage:  resp.ErrorMessage,
+	}
+}
+
+// NewDisputeEvent creates a PaymentEvent for the current state of a dispute
+func NewDisputeEvent(d *Dispute) PaymentEvent {
+	eventType := EventTypeDisputeOpened
+	switch d.Status {
+	case DisputeStatus_DISPUTE_STATUS_WON:
+		eventType = EventTypeDisputeWon
+	case DisputeStatus_DISPUTE_STATUS_LOST:
+		eventType = EventTypeDisputeLost
+	}
+
+	return PaymentEvent{
+		EventID:       "evt_" + d.DisputeID + "_" + d.Status.String(),
+		EventType:     eventType,
+		Timestamp:     time.Now(),
+		TransactionID: d.TransactionID,
+		OrderID:       d.OrderID,
+		DisputeID:     d.DisputeID,
+		AmountCents:   d.AmountCents,
+		Currency:      d.Currency,
+	}
+}
@@ -22,6 +22,39 @@ type PaymentServiceClient interface {
 	
 	// GetPaymentStatus retrieves the status of a previous payment
 	GetPaymentStatus(ctx context.Context, in *PaymentStatusRequest, opts ...grpc.CallOption) (*PaymentStatusResponse, error)
+
+	// CancelPayment voids a pending or completed payment
+	CancelPayment(ctx context.Context, in *CancelPaymentRequest, opts ...grpc.CallOption) (*PaymentStatusResponse, error)
+
+	// ListHeldPayments returns payments held for manual fraud review
+	ListHeldPayments(ctx context.Context, in *ListHeldPaymentsRequest, opts ...grpc.CallOption) (*ListHeldPaymentsResponse, error)
+
+	// ReviewPayment approves or denies a payment held for fraud review
+	ReviewPayment(ctx context.Context, in *ReviewPaymentRequest, opts ...grpc.CallOption) (*PaymentStatusResponse, error)
+
+	// CreateSubscription schedules a recurring charge
+	CreateSubscription(ctx context.Context, in *CreateSubscriptionRequest, opts ...grpc.CallOption) (*Subscription, error)
+
+	// CancelSubscription stops future charges of a subscription
+	CancelSubscription(ctx context.Context, in *CancelSubscriptionRequest, opts ...grpc.CallOption) (*Subscription, error)
+
+	// GetReconciliationReport aggregates transactions created in a date range
+	GetReconciliationReport(ctx context.Context, in *ReconciliationReportRequest, opts ...grpc.CallOption) (*ReconciliationReport, error)
+
+	// ExportTransactions streams transactions created in a date range as CSV or NDJSON
+	ExportTransactions(ctx context.Context, in *ExportTransactionsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExportChunk], error)
+
+	// GetTransactionHistory returns the audit trail of a transaction, oldest first
+	GetTransactionHistory(ctx context.Context, in *TransactionHistoryRequest, opts ...grpc.CallOption) (*TransactionHistoryResponse, error)
+
+	// CreateDispute opens a dispute and moves the payment to DISPUTED
+	CreateDispute(ctx context.Context, in *CreateDisputeRequest, opts ...grpc.CallOption) (*Dispute, error)
+
+	// ResolveDispute closes a dispute: WON restores COMPLETED, LOST charges the payment back
+	ResolveDispute(ctx context.Context, in *ResolveDisputeRequest, opts ...grpc.CallOption) (*Dispute, error)
+
+	// RefundPayment returns the funds of a completed payment
+	RefundPayment(ctx context.Context, in *RefundPaymentRequest, opts ...grpc.CallOption) (*PaymentStatusResponse, error)
 }
 
 type paymentServiceClient struct {
@@ -51,6 +84,111 @@ func (c *paymentServiceClient) GetPaymentStatus(ctx context.Context, in *Payment
 	return out, nil
 }
 
+func (c *paymentServiceClient) CancelPayment(ctx context.Context, in *CancelPaymentRequest, opts ...grpc.CallOption) (*PaymentStatusResponse, error) {
+	out := new(PaymentStatusResponse)
+	err := c.cc.Invoke(ctx, "/payment.PaymentService/CancelPayment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) ListHeldPayments(ctx context.Context, in *ListHeldPaymentsRequest, opts ...grpc.CallOption) (*ListHeldPaymentsResponse, error) {
+	out := new(ListHeldPaymentsResponse)
+	err := c.cc.Invoke(ctx, "/payment.PaymentService/ListHeldPayments", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) ReviewPayment(ctx context.Context, in *ReviewPaymentRequest, opts ...grpc.CallOption) (*PaymentStatusResponse, error) {
+	out := new(PaymentStatusResponse)
+	err := c.cc.Invoke(ctx, "/payment.PaymentService/ReviewPayment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) CreateSubscription(ctx context.Context, in *CreateSubscriptionRequest, opts ...grpc.CallOption) (*Subscription, error) {
+	out := new(Subscription)
+	err := c.cc.Invoke(ctx, "/payment.PaymentService/CreateSubscription", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) CancelSubscription(ctx context.Context, in *CancelSubscriptionRequest, opts ...grpc.CallOption) (*Subscription, error) {
+	out := new(Subscription)
+	err := c.cc.Invoke(ctx, "/payment.PaymentService/CancelSubscription", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) GetReconciliationReport(ctx context.Context, in *ReconciliationReportRequest, opts ...grpc.CallOption) (*ReconciliationReport, error) {
+	out := new(ReconciliationReport)
+	err := c.cc.Invoke(ctx, "/payment.PaymentService/GetReconciliationReport", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) ExportTransactions(ctx context.Context, in *ExportTransactionsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExportChunk], error) {
+	stream, err := c.cc.NewStream(ctx, &PaymentService_ServiceDesc.Streams[0], "/payment.PaymentService/ExportTransactions", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportTransactionsRequest, ExportChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+func (c *paymentServiceClient) GetTransactionHistory(ctx context.Context, in *TransactionHistoryRequest, opts ...grpc.CallOption) (*TransactionHistoryResponse, error) {
+	out := new(TransactionHistoryResponse)
+	err := c.cc.Invoke(ctx, "/payment.PaymentService/GetTransactionHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) CreateDispute(ctx context.Context, in *CreateDisputeRequest, opts ...grpc.CallOption) (*Dispute, error) {
+	out := new(Dispute)
+	err := c.cc.Invoke(ctx, "/payment.PaymentService/CreateDispute", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) ResolveDispute(ctx context.Context, in *ResolveDisputeRequest, opts ...grpc.CallOption) (*Dispute, error) {
+	out := new(Dispute)
+	err := c.cc.Invoke(ctx, "/payment.PaymentService/ResolveDispute", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) RefundPayment(ctx context.Context, in *RefundPaymentRequest, opts ...grpc.CallOption) (*PaymentStatusResponse, error) {
+	out := new(PaymentStatusResponse)
+	err := c.cc.Invoke(ctx, "/payment.PaymentService/RefundPayment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PaymentServiceServer is the server API for PaymentService.
 type PaymentServiceServer interface {
 	// ProcessPayment processes a payment for an order
@@ -58,6 +196,39 @@ type PaymentServiceServer interface {
 	
 	// GetPaymentStatus retrieves the status of a previous payment
 	GetPaymentStatus(context.Context, *PaymentStatusRequest) (*PaymentStatusResponse, error)
+
+	// CancelPayment voids a pending or completed payment
+	CancelPayment(context.Context, *CancelPaymentRequest) (*PaymentStatusResponse, error)
+
+	// ListHeldPayments returns payments held for manual fraud review
+	ListHeldPayments(context.Context, *ListHeldPaymentsRequest) (*ListHeldPaymentsResponse, error)
+
+	// ReviewPayment approves or denies a payment held for fraud review
+	ReviewPayment(context.Context, *ReviewPaymentRequest) (*PaymentStatusResponse, error)
+
+	// CreateSubscription schedules a recurring charge
+	CreateSubscription(context.Context, *CreateSubscriptionRequest) (*Subscription, error)
+
+	// CancelSubscription stops future charges of a subscription
+	CancelSubscription(context.Context, *CancelSubscriptionRequest) (*Subscription, error)
+
+	// GetReconciliationReport aggregates transactions created in a date range
+	GetReconciliationReport(context.Context, *ReconciliationReportRequest) (*ReconciliationReport, error)
+
+	// ExportTransactions streams transactions created in a date range as CSV or NDJSON
+	ExportTransactions(*ExportTransactionsRequest, grpc.ServerStreamingServer[ExportChunk]) error
+
+	// GetTransactionHistory returns the audit trail of a transaction, oldest first
+	GetTransactionHistory(context.Context, *TransactionHistoryRequest) (*TransactionHistoryResponse, error)
+
+	// CreateDispute opens a dispute and moves the payment to DISPUTED
+	CreateDispute(context.Context, *CreateDisputeRequest) (*Dispute, error)
+
+	// ResolveDispute closes a dispute: WON restores COMPLETED, LOST charges the payment back
+	ResolveDispute(context.Context, *ResolveDisputeRequest) (*Dispute, error)
+
+	// RefundPayment returns the funds of a completed payment
+	RefundPayment(context.Context, *RefundPaymentRequest) (*PaymentStatusResponse, error)
 	
 	mustEmbedUnimplementedPaymentServiceServer()
 }
@@ -73,6 +244,50 @@ func (UnimplementedPaymentServiceServer) GetPaymentStatus(context.Context, *Paym
 	return nil, status.Errorf(codes.Unimplemented, "method GetPaymentStatus not implemented")
 }
 
+func (UnimplementedPaymentServiceServer) CancelPayment(context.Context, *CancelPaymentRequest) (*PaymentStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelPayment not implemented")
+}
+
+func (UnimplementedPaymentServiceServer) ListHeldPayments(context.Context, *ListHeldPaymentsRequest) (*ListHeldPaymentsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListHeldPayments not implemented")
+}
+
+func (UnimplementedPaymentServiceServer) ReviewPayment(context.Context, *ReviewPaymentRequest) (*PaymentStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReviewPayment not implemented")
+}
+
+func (UnimplementedPaymentServiceServer) CreateSubscription(context.Context, *CreateSubscriptionRequest) (*Subscription, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSubscription not implemented")
+}
+
+func (UnimplementedPaymentServiceServer) CancelSubscription(context.Context, *CancelSubscriptionRequest) (*Subscription, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelSubscription not implemented")
+}
+
+func (UnimplementedPaymentServiceServer) GetReconciliationReport(context.Context, *ReconciliationReportRequest) (*ReconciliationReport, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReconciliationReport not implemented")
+}
+
+func (UnimplementedPaymentServiceServer) ExportTransactions(*ExportTransactionsRequest, grpc.ServerStreamingServer[ExportChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportTransactions not implemented")
+}
+
+func (UnimplementedPaymentServiceServer) GetTransactionHistory(context.Context, *TransactionHistoryRequest) (*TransactionHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransactionHistory not implemented")
+}
+
+func (UnimplementedPaymentServiceServer) CreateDispute(context.Context, *CreateDisputeRequest) (*Dispute, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateDispute not implemented")
+}
+
+func (UnimplementedPaymentServiceServer) ResolveDispute(context.Context, *ResolveDisputeRequest) (*Dispute, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResolveDispute not implemented")
+}
+
+func (UnimplementedPaymentServiceServer) RefundPayment(context.Context, *RefundPaymentRequest) (*PaymentStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefundPayment not implemented")
+}
+
 func (UnimplementedPaymentServiceServer) mustEmbedUnimplementedPaymentServiceServer() {}
 
 // UnsafePaymentServiceServer may be embedded to opt out of forward compatibility
@@ -121,6 +336,194 @@ func _PaymentService_GetPaymentStatus_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PaymentService_CancelPayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).CancelPayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.PaymentService/CancelPayment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).CancelPayment(ctx, req.(*CancelPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_ListHeldPayments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListHeldPaymentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).ListHeldPayments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.PaymentService/ListHeldPayments",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).ListHeldPayments(ctx, req.(*ListHeldPaymentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_ReviewPayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReviewPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).ReviewPayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.PaymentService/ReviewPayment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).ReviewPayment(ctx, req.(*ReviewPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_CreateSubscription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateSubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).CreateSubscription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.PaymentService/CreateSubscription",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).CreateSubscription(ctx, req.(*CreateSubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_CancelSubscription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelSubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).CancelSubscription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.PaymentService/CancelSubscription",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).CancelSubscription(ctx, req.(*CancelSubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_GetReconciliationReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReconciliationReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).GetReconciliationReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.PaymentService/GetReconciliationReport",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).GetReconciliationReport(ctx, req.(*ReconciliationReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_ExportTransactions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportTransactionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PaymentServiceServer).ExportTransactions(m, &grpc.GenericServerStream[ExportTransactionsRequest, ExportChunk]{ServerStream: stream})
+}
+
+func _PaymentService_GetTransactionHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactionHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).GetTransactionHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.PaymentService/GetTransactionHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).GetTransactionHistory(ctx, req.(*TransactionHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_CreateDispute_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateDisputeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).CreateDispute(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.PaymentService/CreateDispute",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).CreateDispute(ctx, req.(*CreateDisputeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_ResolveDispute_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResolveDisputeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).ResolveDispute(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.PaymentService/ResolveDispute",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).ResolveDispute(ctx, req.(*ResolveDisputeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_RefundPayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefundPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).RefundPayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.PaymentService/RefundPayment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).RefundPayment(ctx, req.(*RefundPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PaymentService_ServiceDesc is the grpc.ServiceDesc for PaymentService
 var PaymentService_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "payment.PaymentService",
@@ -134,6 +537,258 @@ var PaymentService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetPaymentStatus",
 			Handler:    _PaymentService_GetPaymentStatus_Handler,
 		},
+		{
+			MethodName: "CancelPayment",
+			Handler:    _PaymentService_CancelPayment_Handler,
+		},
+		{
+			MethodName: "ListHeldPayments",
+			Handler:    _PaymentService_ListHeldPayments_Handler,
+		},
+		{
+			MethodName: "ReviewPayment",
+			Handler:    _PaymentService_ReviewPayment_Handler,
+		},
+		{
+			MethodName: "CreateSubscription",
+			Handler:    _PaymentService_CreateSubscription_Handler,
+		},
+		{
+			MethodName: "CancelSubscription",
+			Handler:    _PaymentService_CancelSubscription_Handler,
+		},
+		{
+			MethodName: "GetReconciliationReport",
+			Handler:    _PaymentService_GetReconciliationReport_Handler,
+		},
+		{
+			MethodName: "GetTransactionHistory",
+			Handler:    _PaymentService_GetTransactionHistory_Handler,
+		},
+		{
+			MethodName: "CreateDispute",
+			Handler:    _PaymentService_CreateDispute_Handler,
+		},
+		{
+			MethodName: "ResolveDispute",
+			Handler:    _PaymentService_ResolveDispute_Handler,
+		},
+		{
+			MethodName: "RefundPayment",
+			Handler:    _PaymentService_RefundPayment_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportTransactions",
+			Handler:       _PaymentService_ExportTransactions_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "proto/payment/payment.proto",
+}
+
+// PaymentAdminClient is the client API for PaymentAdmin.
+type PaymentAdminClient interface {
+	// ListIdempotencyKeys returns the cached ProcessPayment responses of the tenant of the call
+	ListIdempotencyKeys(ctx context.Context, in *ListIdempotencyKeysRequest, opts ...grpc.CallOption) (*ListIdempotencyKeysResponse, error)
+
+	// EvictIdempotencyKey forgets a cached response, so a retry with its key charges again
+	EvictIdempotencyKey(ctx context.Context, in *EvictIdempotencyKeyRequest, opts ...grpc.CallOption) (*IdempotencyKey, error)
+
+	// SetConfig changes the tunables that are set and returns the configuration in effect
+	SetConfig(ctx context.Context, in *SetConfigRequest, opts ...grpc.CallOption) (*PaymentConfig, error)
+
+	// GetStats returns counters of the in-memory state
+	GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*PaymentStats, error)
+}
+
+type paymentAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewPaymentAdminClient creates a new PaymentAdmin client
+func NewPaymentAdminClient(cc grpc.ClientConnInterface) PaymentAdminClient {
+	return &paymentAdminClient{cc}
+}
+
+func (c *paymentAdminClient) ListIdempotencyKeys(ctx context.Context, in *ListIdempotencyKeysRequest, opts ...grpc.CallOption) (*ListIdempotencyKeysResponse, error) {
+	out := new(ListIdempotencyKeysResponse)
+	err := c.cc.Invoke(ctx, "/payment.PaymentAdmin/ListIdempotencyKeys", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentAdminClient) EvictIdempotencyKey(ctx context.Context, in *EvictIdempotencyKeyRequest, opts ...grpc.CallOption) (*IdempotencyKey, error) {
+	out := new(IdempotencyKey)
+	err := c.cc.Invoke(ctx, "/payment.PaymentAdmin/EvictIdempotencyKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentAdminClient) SetConfig(ctx context.Context, in *SetConfigRequest, opts ...grpc.CallOption) (*PaymentConfig, error) {
+	out := new(PaymentConfig)
+	err := c.cc.Invoke(ctx, "/payment.PaymentAdmin/SetConfig", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentAdminClient) GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*PaymentStats, error) {
+	out := new(PaymentStats)
+	err := c.cc.Invoke(ctx, "/payment.PaymentAdmin/GetStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PaymentAdminServer is the server API for PaymentAdmin.
+type PaymentAdminServer interface {
+	// ListIdempotencyKeys returns the cached ProcessPayment responses of the tenant of the call
+	ListIdempotencyKeys(context.Context, *ListIdempotencyKeysRequest) (*ListIdempotencyKeysResponse, error)
+
+	// EvictIdempotencyKey forgets a cached response, so a retry with its key charges again
+	EvictIdempotencyKey(context.Context, *EvictIdempotencyKeyRequest) (*IdempotencyKey, error)
+
+	// SetConfig changes the tunables that are set and returns the configuration in effect
+	SetConfig(context.Context, *SetConfigRequest) (*PaymentConfig, error)
+
+	// GetStats returns counters of the in-memory state
+	GetStats(context.Context, *GetStatsRequest) (*PaymentStats, error)
+
+	mustEmbedUnimplementedPaymentAdminServer()
+}
+
+// UnimplementedPaymentAdminServer must be embedded for forward compatibility
+type UnimplementedPaymentAdminServer struct{}
+
+func (UnimplementedPaymentAdminServer) ListIdempotencyKeys(context.Context, *ListIdempotencyKeysRequest) (*ListIdempotencyKeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListIdempotencyKeys not implemented")
+}
+
+func (UnimplementedPaymentAdminServer) EvictIdempotencyKey(context.Context, *EvictIdempotencyKeyRequest) (*IdempotencyKey, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EvictIdempotencyKey not implemented")
+}
+
+func (UnimplementedPaymentAdminServer) SetConfig(context.Context, *SetConfigRequest) (*PaymentConfig, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetConfig not implemented")
+}
+
+func (UnimplementedPaymentAdminServer) GetStats(context.Context, *GetStatsRequest) (*PaymentStats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
+}
+
+func (UnimplementedPaymentAdminServer) mustEmbedUnimplementedPaymentAdminServer() {}
+
+// UnsafePaymentAdminServer may be embedded to opt out of forward compatibility
+type UnsafePaymentAdminServer interface {
+	mustEmbedUnimplementedPaymentAdminServer()
+}
+
+// RegisterPaymentAdminServer registers a PaymentAdminServer with a grpc.Server
+func RegisterPaymentAdminServer(s grpc.ServiceRegistrar, srv PaymentAdminServer) {
+	s.RegisterService(&PaymentAdmin_ServiceDesc, srv)
+}
+
+func _PaymentAdmin_ListIdempotencyKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListIdempotencyKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentAdminServer).ListIdempotencyKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.PaymentAdmin/ListIdempotencyKeys",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentAdminServer).ListIdempotencyKeys(ctx, req.(*ListIdempotencyKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentAdmin_EvictIdempotencyKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EvictIdempotencyKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentAdminServer).EvictIdempotencyKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.PaymentAdmin/EvictIdempotencyKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentAdminServer).EvictIdempotencyKey(ctx, req.(*EvictIdempotencyKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentAdmin_SetConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentAdminServer).SetConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.PaymentAdmin/SetConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentAdminServer).SetConfig(ctx, req.(*SetConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentAdmin_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentAdminServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.PaymentAdmin/GetStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentAdminServer).GetStats(ctx, req.(*GetStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PaymentAdmin_ServiceDesc is the grpc.ServiceDesc for PaymentAdmin
+var PaymentAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "payment.PaymentAdmin",
+	HandlerType: (*PaymentAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListIdempotencyKeys",
+			Handler:    _PaymentAdmin_ListIdempotencyKeys_Handler,
+		},
+		{
+			MethodName: "EvictIdempotencyKey",
+			Handler:    _PaymentAdmin_EvictIdempotencyKey_Handler,
+		},
+		{
+			MethodName: "SetConfig",
+			Handler:    _PaymentAdmin_SetConfig_Handler,
+		},
+		{
+			MethodName: "GetStats",
+			Handler:    _PaymentAdmin_GetStats_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/payment/payment.proto",
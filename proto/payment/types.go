@@ -14,11 +14,14 @@ import (
 type PaymentStatus int32
 
 const (
-	PaymentStatus_PAYMENT_STATUS_UNSPECIFIED PaymentStatus = 0
-	PaymentStatus_PAYMENT_STATUS_PENDING     PaymentStatus = 1
-	PaymentStatus_PAYMENT_STATUS_COMPLETED   PaymentStatus = 2
-	PaymentStatus_PAYMENT_STATUS_FAILED      PaymentStatus = 3
-	PaymentStatus_PAYMENT_STATUS_REFUNDED    PaymentStatus = 4
+	PaymentStatus_PAYMENT_STATUS_UNSPECIFIED  PaymentStatus = 0
+	PaymentStatus_PAYMENT_STATUS_PENDING      PaymentStatus = 1
+	PaymentStatus_PAYMENT_STATUS_COMPLETED    PaymentStatus = 2
+	PaymentStatus_PAYMENT_STATUS_FAILED       PaymentStatus = 3
+	PaymentStatus_PAYMENT_STATUS_REFUNDED     PaymentStatus = 4
+	PaymentStatus_PAYMENT_STATUS_CANCELLED    PaymentStatus = 5
+	PaymentStatus_PAYMENT_STATUS_DISPUTED     PaymentStatus = 6
+	PaymentStatus_PAYMENT_STATUS_CHARGED_BACK PaymentStatus = 7
 )
 
 func (s PaymentStatus) String() string {
@@ -31,6 +34,58 @@ func (s PaymentStatus) String() string {
 		return "FAILED"
 	case PaymentStatus_PAYMENT_STATUS_REFUNDED:
 		return "REFUNDED"
+	case PaymentStatus_PAYMENT_STATUS_CANCELLED:
+		return "CANCELLED"
+	case PaymentStatus_PAYMENT_STATUS_DISPUTED:
+		return "DISPUTED"
+	case PaymentStatus_PAYMENT_STATUS_CHARGED_BACK:
+		return "CHARGED_BACK"
+	default:
+		return "UNSPECIFIED"
+	}
+}
+
+// DisputeStatus enum for dispute states
+type DisputeStatus int32
+
+const (
+	DisputeStatus_DISPUTE_STATUS_UNSPECIFIED DisputeStatus = 0
+	DisputeStatus_DISPUTE_STATUS_OPEN        DisputeStatus = 1
+	DisputeStatus_DISPUTE_STATUS_WON         DisputeStatus = 2
+	DisputeStatus_DISPUTE_STATUS_LOST        DisputeStatus = 3
+)
+
+func (s DisputeStatus) String() string {
+	switch s {
+	case DisputeStatus_DISPUTE_STATUS_OPEN:
+		return "OPEN"
+	case DisputeStatus_DISPUTE_STATUS_WON:
+		return "WON"
+	case DisputeStatus_DISPUTE_STATUS_LOST:
+		return "LOST"
+	default:
+		return "UNSPECIFIED"
+	}
+}
+
+// SubscriptionStatus enum for subscription states
+type SubscriptionStatus int32
+
+const (
+	SubscriptionStatus_SUBSCRIPTION_STATUS_UNSPECIFIED SubscriptionStatus = 0
+	SubscriptionStatus_SUBSCRIPTION_STATUS_ACTIVE      SubscriptionStatus = 1
+	SubscriptionStatus_SUBSCRIPTION_STATUS_CANCELLED   SubscriptionStatus = 2
+	SubscriptionStatus_SUBSCRIPTION_STATUS_COMPLETED   SubscriptionStatus = 3
+)
+
+func (s SubscriptionStatus) String() string {
+	switch s {
+	case SubscriptionStatus_SUBSCRIPTION_STATUS_ACTIVE:
+		return "ACTIVE"
+	case SubscriptionStatus_SUBSCRIPTION_STATUS_CANCELLED:
+		return "CANCELLED"
+	case SubscriptionStatus_SUBSCRIPTION_STATUS_COMPLETED:
+		return "COMPLETED"
 	default:
 		return "UNSPECIFIED"
 	}
@@ -46,6 +101,7 @@ const (
 	PaymentErrorCode_PAYMENT_ERROR_CODE_LIMIT_EXCEEDED     PaymentErrorCode = 3
 	PaymentErrorCode_PAYMENT_ERROR_CODE_PROCESSING_ERROR   PaymentErrorCode = 4
 	PaymentErrorCode_PAYMENT_ERROR_CODE_DUPLICATE_REQUEST  PaymentErrorCode = 5
+	PaymentErrorCode_PAYMENT_ERROR_CODE_SUSPECTED_FRAUD    PaymentErrorCode = 6
 )
 
 func (c PaymentErrorCode) String() string {
@@ -60,6 +116,8 @@ func (c PaymentErrorCode) String() string {
 		return "PROCESSING_ERROR"
 	case PaymentErrorCode_PAYMENT_ERROR_CODE_DUPLICATE_REQUEST:
 		return "DUPLICATE_REQUEST"
+	case PaymentErrorCode_PAYMENT_ERROR_CODE_SUSPECTED_FRAUD:
+		return "SUSPECTED_FRAUD"
 	default:
 		return "UNSPECIFIED"
 	}
@@ -71,6 +129,25 @@ var (
 	_ proto.Message = (*PaymentResponse)(nil)
 	_ proto.Message = (*PaymentStatusRequest)(nil)
 	_ proto.Message = (*PaymentStatusResponse)(nil)
+	_ proto.Message = (*CancelPaymentRequest)(nil)
+	_ proto.Message = (*ListHeldPaymentsRequest)(nil)
+	_ proto.Message = (*ListHeldPaymentsResponse)(nil)
+	_ proto.Message = (*ReviewPaymentRequest)(nil)
+	_ proto.Message = (*CreateSubscriptionRequest)(nil)
+	_ proto.Message = (*CancelSubscriptionRequest)(nil)
+	_ proto.Message = (*Subscription)(nil)
+	_ proto.Message = (*ReconciliationReportRequest)(nil)
+	_ proto.Message = (*ReconciliationReport)(nil)
+	_ proto.Message = (*ExportTransactionsRequest)(nil)
+	_ proto.Message = (*ExportChunk)(nil)
+	_ proto.Message = (*ListIdempotencyKeysRequest)(nil)
+	_ proto.Message = (*ListIdempotencyKeysResponse)(nil)
+	_ proto.Message = (*IdempotencyKey)(nil)
+	_ proto.Message = (*EvictIdempotencyKeyRequest)(nil)
+	_ proto.Message = (*SetConfigRequest)(nil)
+	_ proto.Message = (*PaymentConfig)(nil)
+	_ proto.Message = (*GetStatsRequest)(nil)
+	_ proto.Message = (*PaymentStats)(nil)
 )
 
 // PaymentRequest contains the data needed to process a payment
@@ -139,6 +216,12 @@ type PaymentResponse struct {
 	ErrorCode     PaymentErrorCode `protobuf:"varint,3,opt,name=error_code,proto3" json:"error_code,omitempty"`
 	ErrorMessage  string           `protobuf:"bytes,4,opt,name=error_message,proto3" json:"error_message,omitempty"`
 	ProcessedAt   time.Time        `protobuf:"bytes,5,opt,name=processed_at,proto3" json:"processed_at,omitempty"`
+
+	// Retriable tells whether a declined payment may succeed if made again
+	// under a new idempotency key, and RetryAfterSeconds, when set, how long
+	// after ProcessedAt to wait.
+	Retriable         bool  `protobuf:"varint,6,opt,name=retriable,proto3" json:"retriable,omitempty"`
+	RetryAfterSeconds int64 `protobuf:"varint,7,opt,name=retry_after_seconds,proto3" json:"retry_after_seconds,omitempty"`
 }
 
 func (x *PaymentResponse) Reset()                               { *x = PaymentResponse{} }
@@ -175,6 +258,21 @@ func (x *PaymentResponse) GetErrorMessage() string {
 	return ""
 }
 
+func (x *PaymentResponse) GetRetriable() bool {
+	if x != nil {
+		return x.Retriable
+	}
+	return false
+}
+
+// GetRetryAfter returns RetryAfterSeconds as a duration.
+func (x *PaymentResponse) GetRetryAfter() time.Duration {
+	if x != nil {
+		return time.Duration(x.RetryAfterSeconds) * time.Second
+	}
+	return 0
+}
+
 // PaymentStatusRequest for querying payment status
 type PaymentStatusRequest struct {
 	state         protoimpl.MessageState
@@ -207,8 +305,14 @@ type PaymentStatusResponse struct {
 	OrderID       string        `protobuf:"bytes,2,opt,name=order_id,proto3" json:"order_id,omitempty"`
 	AmountCents   int64         `protobuf:"varint,3,opt,name=amount_cents,proto3" json:"amount_cents,omitempty"`
 	Currency      string        `protobuf:"bytes,4,opt,name=currency,proto3" json:"currency,omitempty"`
-	Status        PaymentStatus `protobuf:"varint,5,opt,name=status,proto3" json:"status,omitempty"`
-	CreatedAt     time.Time     `protobuf:"bytes,6,opt,name=created_at,proto3" json:"created_at,omitempty"`
+	Status        PaymentStatus             `protobuf:"varint,5,opt,name=status,proto3" json:"status,omitempty"`
+	CreatedAt     time.Time                 `protobuf:"bytes,6,opt,name=created_at,proto3" json:"created_at,omitempty"`
+	UpdatedAt     time.Time                 `protobuf:"bytes,7,opt,name=updated_at,proto3" json:"updated_at,omitempty"`
+	Transitions   []PaymentStatusTransition `protobuf:"bytes,8,rep,name=transitions,proto3" json:"transitions,omitempty"`
+	Gateway       string                    `protobuf:"bytes,9,opt,name=gateway,proto3" json:"gateway,omitempty"`
+	TenantID      string                    `protobuf:"bytes,10,opt,name=tenant_id,proto3" json:"tenant_id,omitempty"`
+	RefundedCents int64                     `protobuf:"varint,11,opt,name=refunded_cents,proto3" json:"refunded_cents,omitempty"`
+	Refunds       []Refund                  `protobuf:"bytes,12,rep,name=refunds,proto3" json:"refunds,omitempty"`
 }
 
 func (x *PaymentStatusResponse) Reset()                               { *x = PaymentStatusResponse{} }
@@ -244,3 +348,1071 @@ func (x *PaymentStatusResponse) GetStatus() PaymentStatus {
 	}
 	return PaymentStatus_PAYMENT_STATUS_UNSPECIFIED
 }
+
+func (x *PaymentStatusResponse) GetTransitions() []PaymentStatusTransition {
+	if x != nil {
+		return x.Transitions
+	}
+	return nil
+}
+
+// PaymentStatusTransition records a single status change
+type PaymentStatusTransition struct {
+	From   PaymentStatus `protobuf:"varint,1,opt,name=from,proto3" json:"from"`
+	To     PaymentStatus `protobuf:"varint,2,opt,name=to,proto3" json:"to"`
+	At     time.Time     `protobuf:"bytes,3,opt,name=at,proto3" json:"at"`
+	Reason string        `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+// Refund records the funds returned by one RefundPayment call
+type Refund struct {
+	RefundID    string    `protobuf:"bytes,1,opt,name=refund_id,proto3" json:"refund_id,omitempty"`
+	AmountCents int64     `protobuf:"varint,2,opt,name=amount_cents,proto3" json:"amount_cents"`
+	Reason      string    `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	At          time.Time `protobuf:"bytes,4,opt,name=at,proto3" json:"at"`
+}
+
+// CancelPaymentRequest for voiding a payment
+type CancelPaymentRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TransactionID string `protobuf:"bytes,1,opt,name=transaction_id,proto3" json:"transaction_id,omitempty"`
+	Reason        string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (x *CancelPaymentRequest) Reset()                           { *x = CancelPaymentRequest{} }
+func (x *CancelPaymentRequest) String() string                   { return "CancelPaymentRequest" }
+func (*CancelPaymentRequest) ProtoMessage()                      {}
+func (*CancelPaymentRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*CancelPaymentRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *CancelPaymentRequest) GetTransactionID() string {
+	if x != nil {
+		return x.TransactionID
+	}
+	return ""
+}
+
+func (x *CancelPaymentRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// HeldPayment is a payment waiting for manual fraud review
+type HeldPayment struct {
+	TransactionID string    `protobuf:"bytes,1,opt,name=transaction_id,proto3" json:"transaction_id"`
+	OrderID       string    `protobuf:"bytes,2,opt,name=order_id,proto3" json:"order_id"`
+	AmountCents   int64     `protobuf:"varint,3,opt,name=amount_cents,proto3" json:"amount_cents"`
+	Currency      string    `protobuf:"bytes,4,opt,name=currency,proto3" json:"currency"`
+	CustomerEmail string    `protobuf:"bytes,5,opt,name=customer_email,proto3" json:"customer_email"`
+	FraudScore    float64   `protobuf:"fixed64,6,opt,name=fraud_score,proto3" json:"fraud_score"`
+	Reasons       []string  `protobuf:"bytes,7,rep,name=reasons,proto3" json:"reasons,omitempty"`
+	HeldAt        time.Time `protobuf:"bytes,8,opt,name=held_at,proto3" json:"held_at"`
+}
+
+// ListHeldPaymentsRequest for listing payments held for review
+type ListHeldPaymentsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListHeldPaymentsRequest) Reset()                           { *x = ListHeldPaymentsRequest{} }
+func (x *ListHeldPaymentsRequest) String() string                   { return "ListHeldPaymentsRequest" }
+func (*ListHeldPaymentsRequest) ProtoMessage()                      {}
+func (*ListHeldPaymentsRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*ListHeldPaymentsRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+// ListHeldPaymentsResponse with the current review queue
+type ListHeldPaymentsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Payments []HeldPayment `protobuf:"bytes,1,rep,name=payments,proto3" json:"payments,omitempty"`
+}
+
+func (x *ListHeldPaymentsResponse) Reset()                           { *x = ListHeldPaymentsResponse{} }
+func (x *ListHeldPaymentsResponse) String() string                   { return "ListHeldPaymentsResponse" }
+func (*ListHeldPaymentsResponse) ProtoMessage()                      {}
+func (*ListHeldPaymentsResponse) ProtoReflect() protoreflect.Message { return nil }
+func (*ListHeldPaymentsResponse) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *ListHeldPaymentsResponse) GetPayments() []HeldPayment {
+	if x != nil {
+		return x.Payments
+	}
+	return nil
+}
+
+// ReviewPaymentRequest resolves a held payment
+type ReviewPaymentRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TransactionID string `protobuf:"bytes,1,opt,name=transaction_id,proto3" json:"transaction_id,omitempty"`
+	Approve       bool   `protobuf:"varint,2,opt,name=approve,proto3" json:"approve,omitempty"`
+	Reviewer      string `protobuf:"bytes,3,opt,name=reviewer,proto3" json:"reviewer,omitempty"`
+	Note          string `protobuf:"bytes,4,opt,name=note,proto3" json:"note,omitempty"`
+}
+
+func (x *ReviewPaymentRequest) Reset()                           { *x = ReviewPaymentRequest{} }
+func (x *ReviewPaymentRequest) String() string                   { return "ReviewPaymentRequest" }
+func (*ReviewPaymentRequest) ProtoMessage()                      {}
+func (*ReviewPaymentRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*ReviewPaymentRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *ReviewPaymentRequest) GetTransactionID() string {
+	if x != nil {
+		return x.TransactionID
+	}
+	return ""
+}
+
+func (x *ReviewPaymentRequest) GetApprove() bool {
+	if x != nil {
+		return x.Approve
+	}
+	return false
+}
+
+func (x *ReviewPaymentRequest) GetReviewer() string {
+	if x != nil {
+		return x.Reviewer
+	}
+	return ""
+}
+
+func (x *ReviewPaymentRequest) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+// CreateSubscriptionRequest describes a recurring charge
+type CreateSubscriptionRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CustomerEmail   string `protobuf:"bytes,1,opt,name=customer_email,proto3" json:"customer_email,omitempty"`
+	AmountCents     int64  `protobuf:"varint,2,opt,name=amount_cents,proto3" json:"amount_cents,omitempty"`
+	Currency        string `protobuf:"bytes,3,opt,name=currency,proto3" json:"currency,omitempty"`
+	IntervalSeconds int64  `protobuf:"varint,4,opt,name=interval_seconds,proto3" json:"interval_seconds,omitempty"`
+	MaxCycles       int32  `protobuf:"varint,5,opt,name=max_cycles,proto3" json:"max_cycles,omitempty"`
+	Reference       string `protobuf:"bytes,6,opt,name=reference,proto3" json:"reference,omitempty"`
+}
+
+func (x *CreateSubscriptionRequest) Reset()                           { *x = CreateSubscriptionRequest{} }
+func (x *CreateSubscriptionRequest) String() string                   { return "CreateSubscriptionRequest" }
+func (*CreateSubscriptionRequest) ProtoMessage()                      {}
+func (*CreateSubscriptionRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*CreateSubscriptionRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *CreateSubscriptionRequest) GetCustomerEmail() string {
+	if x != nil {
+		return x.CustomerEmail
+	}
+	return ""
+}
+
+func (x *CreateSubscriptionRequest) GetAmountCents() int64 {
+	if x != nil {
+		return x.AmountCents
+	}
+	return 0
+}
+
+func (x *CreateSubscriptionRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *CreateSubscriptionRequest) GetIntervalSeconds() int64 {
+	if x != nil {
+		return x.IntervalSeconds
+	}
+	return 0
+}
+
+func (x *CreateSubscriptionRequest) GetMaxCycles() int32 {
+	if x != nil {
+		return x.MaxCycles
+	}
+	return 0
+}
+
+func (x *CreateSubscriptionRequest) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+// CancelSubscriptionRequest stops future charges of a subscription
+type CancelSubscriptionRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SubscriptionID string `protobuf:"bytes,1,opt,name=subscription_id,proto3" json:"subscription_id,omitempty"`
+}
+
+func (x *CancelSubscriptionRequest) Reset()                           { *x = CancelSubscriptionRequest{} }
+func (x *CancelSubscriptionRequest) String() string                   { return "CancelSubscriptionRequest" }
+func (*CancelSubscriptionRequest) ProtoMessage()                      {}
+func (*CancelSubscriptionRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*CancelSubscriptionRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *CancelSubscriptionRequest) GetSubscriptionID() string {
+	if x != nil {
+		return x.SubscriptionID
+	}
+	return ""
+}
+
+// Subscription is a recurring charge managed by the payment scheduler
+type Subscription struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SubscriptionID    string             `protobuf:"bytes,1,opt,name=subscription_id,proto3" json:"subscription_id,omitempty"`
+	CustomerEmail     string             `protobuf:"bytes,2,opt,name=customer_email,proto3" json:"customer_email,omitempty"`
+	AmountCents       int64              `protobuf:"varint,3,opt,name=amount_cents,proto3" json:"amount_cents,omitempty"`
+	Currency          string             `protobuf:"bytes,4,opt,name=currency,proto3" json:"currency,omitempty"`
+	IntervalSeconds   int64              `protobuf:"varint,5,opt,name=interval_seconds,proto3" json:"interval_seconds,omitempty"`
+	MaxCycles         int32              `protobuf:"varint,6,opt,name=max_cycles,proto3" json:"max_cycles,omitempty"`
+	Reference         string             `protobuf:"bytes,7,opt,name=reference,proto3" json:"reference,omitempty"`
+	Status            SubscriptionStatus `protobuf:"varint,8,opt,name=status,proto3" json:"status,omitempty"`
+	CyclesCharged     int32              `protobuf:"varint,9,opt,name=cycles_charged,proto3" json:"cycles_charged,omitempty"`
+	FailedAttempts    int32              `protobuf:"varint,10,opt,name=failed_attempts,proto3" json:"failed_attempts,omitempty"`
+	LastTransactionID string             `protobuf:"bytes,11,opt,name=last_transaction_id,proto3" json:"last_transaction_id,omitempty"`
+	NextChargeAt      time.Time          `protobuf:"bytes,12,opt,name=next_charge_at,proto3" json:"next_charge_at,omitempty"`
+	CreatedAt         time.Time          `protobuf:"bytes,13,opt,name=created_at,proto3" json:"created_at,omitempty"`
+}
+
+func (x *Subscription) Reset()                           { *x = Subscription{} }
+func (x *Subscription) String() string                   { return "Subscription" }
+func (*Subscription) ProtoMessage()                      {}
+func (*Subscription) ProtoReflect() protoreflect.Message { return nil }
+func (*Subscription) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *Subscription) GetSubscriptionID() string {
+	if x != nil {
+		return x.SubscriptionID
+	}
+	return ""
+}
+
+func (x *Subscription) GetCustomerEmail() string {
+	if x != nil {
+		return x.CustomerEmail
+	}
+	return ""
+}
+
+func (x *Subscription) GetAmountCents() int64 {
+	if x != nil {
+		return x.AmountCents
+	}
+	return 0
+}
+
+func (x *Subscription) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Subscription) GetIntervalSeconds() int64 {
+	if x != nil {
+		return x.IntervalSeconds
+	}
+	return 0
+}
+
+func (x *Subscription) GetMaxCycles() int32 {
+	if x != nil {
+		return x.MaxCycles
+	}
+	return 0
+}
+
+func (x *Subscription) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+func (x *Subscription) GetCyclesCharged() int32 {
+	if x != nil {
+		return x.CyclesCharged
+	}
+	return 0
+}
+
+func (x *Subscription) GetFailedAttempts() int32 {
+	if x != nil {
+		return x.FailedAttempts
+	}
+	return 0
+}
+
+func (x *Subscription) GetLastTransactionID() string {
+	if x != nil {
+		return x.LastTransactionID
+	}
+	return ""
+}
+
+func (x *Subscription) GetStatus() SubscriptionStatus {
+	if x != nil {
+		return x.Status
+	}
+	return SubscriptionStatus_SUBSCRIPTION_STATUS_UNSPECIFIED
+}
+
+// ReconciliationTotal aggregates transactions sharing a status and currency
+type ReconciliationTotal struct {
+	Status      PaymentStatus `protobuf:"varint,1,opt,name=status,proto3" json:"status"`
+	Currency    string        `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency"`
+	Count       int64         `protobuf:"varint,3,opt,name=count,proto3" json:"count"`
+	AmountCents int64         `protobuf:"varint,4,opt,name=amount_cents,proto3" json:"amount_cents"`
+}
+
+// ReconciliationReportRequest selects transactions by creation time
+type ReconciliationReportRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	From time.Time `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To   time.Time `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+}
+
+func (x *ReconciliationReportRequest) Reset()                           { *x = ReconciliationReportRequest{} }
+func (x *ReconciliationReportRequest) String() string                   { return "ReconciliationReportRequest" }
+func (*ReconciliationReportRequest) ProtoMessage()                      {}
+func (*ReconciliationReportRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*ReconciliationReportRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+// ReconciliationReport summarizes transactions for finance tooling
+type ReconciliationReport struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	From                   time.Time             `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To                     time.Time             `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	GeneratedAt            time.Time             `protobuf:"bytes,3,opt,name=generated_at,proto3" json:"generated_at,omitempty"`
+	Totals                 []ReconciliationTotal `protobuf:"bytes,4,rep,name=totals,proto3" json:"totals,omitempty"`
+	TransactionCount       int64                 `protobuf:"varint,5,opt,name=transaction_count,proto3" json:"transaction_count,omitempty"`
+	SettledCentsByCurrency map[string]int64      `protobuf:"bytes,6,rep,name=settled_cents_by_currency,proto3" json:"settled_cents_by_currency,omitempty"`
+}
+
+func (x *ReconciliationReport) Reset()                           { *x = ReconciliationReport{} }
+func (x *ReconciliationReport) String() string                   { return "ReconciliationReport" }
+func (*ReconciliationReport) ProtoMessage()                      {}
+func (*ReconciliationReport) ProtoReflect() protoreflect.Message { return nil }
+func (*ReconciliationReport) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *ReconciliationReport) GetTotals() []ReconciliationTotal {
+	if x != nil {
+		return x.Totals
+	}
+	return nil
+}
+
+func (x *ReconciliationReport) GetTransactionCount() int64 {
+	if x != nil {
+		return x.TransactionCount
+	}
+	return 0
+}
+
+func (x *ReconciliationReport) GetSettledCentsByCurrency() map[string]int64 {
+	if x != nil {
+		return x.SettledCentsByCurrency
+	}
+	return nil
+}
+
+// ExportTransactionsRequest selects transactions to export
+type ExportTransactionsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	From   time.Time `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To     time.Time `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Format string    `protobuf:"bytes,3,opt,name=format,proto3" json:"format,omitempty"`
+}
+
+func (x *ExportTransactionsRequest) Reset()                           { *x = ExportTransactionsRequest{} }
+func (x *ExportTransactionsRequest) String() string                   { return "ExportTransactionsRequest" }
+func (*ExportTransactionsRequest) ProtoMessage()                      {}
+func (*ExportTransactionsRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*ExportTransactionsRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *ExportTransactionsRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+// ExportChunk is a slice of the export file
+type ExportChunk struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *ExportChunk) Reset()                           { *x = ExportChunk{} }
+func (x *ExportChunk) String() string                   { return "ExportChunk" }
+func (*ExportChunk) ProtoMessage()                      {}
+func (*ExportChunk) ProtoReflect() protoreflect.Message { return nil }
+func (*ExportChunk) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *ExportChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// AuditEntry is an append-only record of a transaction status change
+type AuditEntry struct {
+	Sequence       int64         `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence"`
+	TransactionID  string        `protobuf:"bytes,2,opt,name=transaction_id,proto3" json:"transaction_id"`
+	Actor          string        `protobuf:"bytes,3,opt,name=actor,proto3" json:"actor"`
+	PreviousStatus PaymentStatus `protobuf:"varint,4,opt,name=previous_status,proto3" json:"previous_status"`
+	NewStatus      PaymentStatus `protobuf:"varint,5,opt,name=new_status,proto3" json:"new_status"`
+	Reason         string        `protobuf:"bytes,6,opt,name=reason,proto3" json:"reason,omitempty"`
+	RecordedAt     time.Time     `protobuf:"bytes,7,opt,name=recorded_at,proto3" json:"recorded_at"`
+}
+
+// TransactionHistoryRequest for fetching the audit trail of a transaction
+type TransactionHistoryRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TransactionID string `protobuf:"bytes,1,opt,name=transaction_id,proto3" json:"transaction_id,omitempty"`
+}
+
+func (x *TransactionHistoryRequest) Reset()                           { *x = TransactionHistoryRequest{} }
+func (x *TransactionHistoryRequest) String() string                   { return "TransactionHistoryRequest" }
+func (*TransactionHistoryRequest) ProtoMessage()                      {}
+func (*TransactionHistoryRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*TransactionHistoryRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *TransactionHistoryRequest) GetTransactionID() string {
+	if x != nil {
+		return x.TransactionID
+	}
+	return ""
+}
+
+// TransactionHistoryResponse with audit entries, oldest first
+type TransactionHistoryResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TransactionID string       `protobuf:"bytes,1,opt,name=transaction_id,proto3" json:"transaction_id,omitempty"`
+	Entries       []AuditEntry `protobuf:"bytes,2,rep,name=entries,proto3" json:"entries,omitempty"`
+}
+
+func (x *TransactionHistoryResponse) Reset()                           { *x = TransactionHistoryResponse{} }
+func (x *TransactionHistoryResponse) String() string                   { return "TransactionHistoryResponse" }
+func (*TransactionHistoryResponse) ProtoMessage()                      {}
+func (*TransactionHistoryResponse) ProtoReflect() protoreflect.Message { return nil }
+func (*TransactionHistoryResponse) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *TransactionHistoryResponse) GetTransactionID() string {
+	if x != nil {
+		return x.TransactionID
+	}
+	return ""
+}
+
+func (x *TransactionHistoryResponse) GetEntries() []AuditEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+// CreateDisputeRequest opens a dispute against a completed payment
+type CreateDisputeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TransactionID string `protobuf:"bytes,1,opt,name=transaction_id,proto3" json:"transaction_id,omitempty"`
+	Reason        string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (x *CreateDisputeRequest) Reset()                           { *x = CreateDisputeRequest{} }
+func (x *CreateDisputeRequest) String() string                   { return "CreateDisputeRequest" }
+func (*CreateDisputeRequest) ProtoMessage()                      {}
+func (*CreateDisputeRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*CreateDisputeRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *CreateDisputeRequest) GetTransactionID() string {
+	if x != nil {
+		return x.TransactionID
+	}
+	return ""
+}
+
+func (x *CreateDisputeRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// ResolveDisputeRequest closes an open dispute as won or lost
+type ResolveDisputeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DisputeID string        `protobuf:"bytes,1,opt,name=dispute_id,proto3" json:"dispute_id,omitempty"`
+	Outcome   DisputeStatus `protobuf:"varint,2,opt,name=outcome,proto3" json:"outcome,omitempty"`
+	Note      string        `protobuf:"bytes,3,opt,name=note,proto3" json:"note,omitempty"`
+}
+
+func (x *ResolveDisputeRequest) Reset()                           { *x = ResolveDisputeRequest{} }
+func (x *ResolveDisputeRequest) String() string                   { return "ResolveDisputeRequest" }
+func (*ResolveDisputeRequest) ProtoMessage()                      {}
+func (*ResolveDisputeRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*ResolveDisputeRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *ResolveDisputeRequest) GetDisputeID() string {
+	if x != nil {
+		return x.DisputeID
+	}
+	return ""
+}
+
+func (x *ResolveDisputeRequest) GetOutcome() DisputeStatus {
+	if x != nil {
+		return x.Outcome
+	}
+	return 0
+}
+
+func (x *ResolveDisputeRequest) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+// Dispute is a chargeback raised by the customer's bank against a payment
+type Dispute struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DisputeID     string        `protobuf:"bytes,1,opt,name=dispute_id,proto3" json:"dispute_id,omitempty"`
+	TransactionID string        `protobuf:"bytes,2,opt,name=transaction_id,proto3" json:"transaction_id,omitempty"`
+	OrderID       string        `protobuf:"bytes,3,opt,name=order_id,proto3" json:"order_id,omitempty"`
+	AmountCents   int64         `protobuf:"varint,4,opt,name=amount_cents,proto3" json:"amount_cents,omitempty"`
+	Currency      string        `protobuf:"bytes,5,opt,name=currency,proto3" json:"currency,omitempty"`
+	Reason        string        `protobuf:"bytes,6,opt,name=reason,proto3" json:"reason,omitempty"`
+	Status        DisputeStatus `protobuf:"varint,7,opt,name=status,proto3" json:"status,omitempty"`
+	Note          string        `protobuf:"bytes,8,opt,name=note,proto3" json:"note,omitempty"`
+	OpenedAt      time.Time     `protobuf:"bytes,9,opt,name=opened_at,proto3" json:"opened_at,omitempty"`
+	ResolvedAt    time.Time     `protobuf:"bytes,10,opt,name=resolved_at,proto3" json:"resolved_at,omitempty"`
+}
+
+func (x *Dispute) Reset()                           { *x = Dispute{} }
+func (x *Dispute) String() string                   { return "Dispute" }
+func (*Dispute) ProtoMessage()                      {}
+func (*Dispute) ProtoReflect() protoreflect.Message { return nil }
+func (*Dispute) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *Dispute) GetDisputeID() string {
+	if x != nil {
+		return x.DisputeID
+	}
+	return ""
+}
+
+func (x *Dispute) GetTransactionID() string {
+	if x != nil {
+		return x.TransactionID
+	}
+	return ""
+}
+
+func (x *Dispute) GetOrderID() string {
+	if x != nil {
+		return x.OrderID
+	}
+	return ""
+}
+
+func (x *Dispute) GetAmountCents() int64 {
+	if x != nil {
+		return x.AmountCents
+	}
+	return 0
+}
+
+func (x *Dispute) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Dispute) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *Dispute) GetStatus() DisputeStatus {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *Dispute) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+// RefundPaymentRequest returns the funds of a completed payment
+type RefundPaymentRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TransactionID string `protobuf:"bytes,1,opt,name=transaction_id,proto3" json:"transaction_id,omitempty"`
+	Reason        string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	AmountCents   int64  `protobuf:"varint,3,opt,name=amount_cents,proto3" json:"amount_cents,omitempty"`
+	RefundID      string `protobuf:"bytes,4,opt,name=refund_id,proto3" json:"refund_id,omitempty"`
+}
+
+func (x *RefundPaymentRequest) Reset()                           { *x = RefundPaymentRequest{} }
+func (x *RefundPaymentRequest) String() string                   { return "RefundPaymentRequest" }
+func (*RefundPaymentRequest) ProtoMessage()                      {}
+func (*RefundPaymentRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*RefundPaymentRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *RefundPaymentRequest) GetTransactionID() string {
+	if x != nil {
+		return x.TransactionID
+	}
+	return ""
+}
+
+func (x *RefundPaymentRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// ListIdempotencyKeysRequest lists the cached ProcessPayment responses of the tenant of the call
+type ListIdempotencyKeysRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Prefix limits the listing to keys starting with it
+	Prefix string `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// Limit caps the keys returned, sorted by key; 0 returns all
+	Limit int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+}
+
+func (x *ListIdempotencyKeysRequest) Reset()                           { *x = ListIdempotencyKeysRequest{} }
+func (x *ListIdempotencyKeysRequest) String() string                   { return "ListIdempotencyKeysRequest" }
+func (*ListIdempotencyKeysRequest) ProtoMessage()                      {}
+func (*ListIdempotencyKeysRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*ListIdempotencyKeysRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *ListIdempotencyKeysRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *ListIdempotencyKeysRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListIdempotencyKeysResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Keys []IdempotencyKey `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys"`
+	// Total counts the keys matching the prefix, limit aside
+	Total int32 `protobuf:"varint,2,opt,name=total,proto3" json:"total"`
+}
+
+func (x *ListIdempotencyKeysResponse) Reset()                           { *x = ListIdempotencyKeysResponse{} }
+func (x *ListIdempotencyKeysResponse) String() string                   { return "ListIdempotencyKeysResponse" }
+func (*ListIdempotencyKeysResponse) ProtoMessage()                      {}
+func (*ListIdempotencyKeysResponse) ProtoReflect() protoreflect.Message { return nil }
+func (*ListIdempotencyKeysResponse) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *ListIdempotencyKeysResponse) GetKeys() []IdempotencyKey {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+func (x *ListIdempotencyKeysResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+// IdempotencyKey is a ProcessPayment response cached under its key
+type IdempotencyKey struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key           string           `protobuf:"bytes,1,opt,name=key,proto3" json:"key"`
+	TransactionID string           `protobuf:"bytes,2,opt,name=transaction_id,proto3" json:"transaction_id,omitempty"`
+	Success       bool             `protobuf:"varint,3,opt,name=success,proto3" json:"success"`
+	ErrorCode     PaymentErrorCode `protobuf:"varint,4,opt,name=error_code,proto3" json:"error_code,omitempty"`
+	ProcessedAt   time.Time        `protobuf:"bytes,5,opt,name=processed_at,proto3" json:"processed_at"`
+}
+
+func (x *IdempotencyKey) Reset()                           { *x = IdempotencyKey{} }
+func (x *IdempotencyKey) String() string                   { return "IdempotencyKey" }
+func (*IdempotencyKey) ProtoMessage()                      {}
+func (*IdempotencyKey) ProtoReflect() protoreflect.Message { return nil }
+func (*IdempotencyKey) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *IdempotencyKey) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *IdempotencyKey) GetTransactionID() string {
+	if x != nil {
+		return x.TransactionID
+	}
+	return ""
+}
+
+func (x *IdempotencyKey) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *IdempotencyKey) GetErrorCode() PaymentErrorCode {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return PaymentErrorCode_PAYMENT_ERROR_CODE_UNSPECIFIED
+}
+
+func (x *IdempotencyKey) GetProcessedAt() time.Time {
+	if x != nil {
+		return x.ProcessedAt
+	}
+	return time.Time{}
+}
+
+type EvictIdempotencyKeyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+}
+
+func (x *EvictIdempotencyKeyRequest) Reset()                           { *x = EvictIdempotencyKeyRequest{} }
+func (x *EvictIdempotencyKeyRequest) String() string                   { return "EvictIdempotencyKeyRequest" }
+func (*EvictIdempotencyKeyRequest) ProtoMessage()                      {}
+func (*EvictIdempotencyKeyRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*EvictIdempotencyKeyRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *EvictIdempotencyKeyRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+// SetConfigRequest changes the tunables that are set; nil fields are kept
+type SetConfigRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MaxAmountCents    *int64   `protobuf:"varint,1,opt,name=max_amount_cents,proto3,oneof" json:"max_amount_cents,omitempty"`
+	FailureRate       *float64 `protobuf:"fixed64,2,opt,name=failure_rate,proto3,oneof" json:"failure_rate,omitempty"`
+	SimulateLatencyMs *int64   `protobuf:"varint,3,opt,name=simulate_latency_ms,proto3,oneof" json:"simulate_latency_ms,omitempty"`
+	// VelocityLimits uses the -velocity-limits syntax; empty removes the limits
+	VelocityLimits *string `protobuf:"bytes,4,opt,name=velocity_limits,proto3,oneof" json:"velocity_limits,omitempty"`
+	// TenantLimits replaces the limits of the tenants it names; 0 removes a tenant's limit
+	TenantLimits map[string]int64 `protobuf:"bytes,5,rep,name=tenant_limits,proto3" json:"tenant_limits,omitempty"`
+	// LatencyProfile uses the -simulate-latency syntax; empty removes the latency of payments
+	LatencyProfile *string `protobuf:"bytes,6,opt,name=latency_profile,proto3,oneof" json:"latency_profile,omitempty"`
+	// LatencyOverrides replaces the profiles of the RPCs it names; an empty profile removes the override
+	LatencyOverrides map[string]string `protobuf:"bytes,7,rep,name=latency_overrides,proto3" json:"latency_overrides,omitempty"`
+}
+
+func (x *SetConfigRequest) Reset()                           { *x = SetConfigRequest{} }
+func (x *SetConfigRequest) String() string                   { return "SetConfigRequest" }
+func (*SetConfigRequest) ProtoMessage()                      {}
+func (*SetConfigRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*SetConfigRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *SetConfigRequest) GetMaxAmountCents() int64 {
+	if x != nil && x.MaxAmountCents != nil {
+		return *x.MaxAmountCents
+	}
+	return 0
+}
+
+func (x *SetConfigRequest) GetFailureRate() float64 {
+	if x != nil && x.FailureRate != nil {
+		return *x.FailureRate
+	}
+	return 0
+}
+
+func (x *SetConfigRequest) GetSimulateLatencyMs() int64 {
+	if x != nil && x.SimulateLatencyMs != nil {
+		return *x.SimulateLatencyMs
+	}
+	return 0
+}
+
+func (x *SetConfigRequest) GetVelocityLimits() string {
+	if x != nil && x.VelocityLimits != nil {
+		return *x.VelocityLimits
+	}
+	return ""
+}
+
+func (x *SetConfigRequest) GetTenantLimits() map[string]int64 {
+	if x != nil {
+		return x.TenantLimits
+	}
+	return nil
+}
+
+func (x *SetConfigRequest) GetLatencyProfile() string {
+	if x != nil && x.LatencyProfile != nil {
+		return *x.LatencyProfile
+	}
+	return ""
+}
+
+func (x *SetConfigRequest) GetLatencyOverrides() map[string]string {
+	if x != nil {
+		return x.LatencyOverrides
+	}
+	return nil
+}
+
+// PaymentConfig holds the tunables in effect
+type PaymentConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MaxAmountCents        int64             `protobuf:"varint,1,opt,name=max_amount_cents,proto3" json:"max_amount_cents"`
+	FailureRate           float64           `protobuf:"fixed64,2,opt,name=failure_rate,proto3" json:"failure_rate"`
+	SimulateLatencyMs     int64             `protobuf:"varint,3,opt,name=simulate_latency_ms,proto3" json:"simulate_latency_ms"`
+	VelocityWindowSeconds int64             `protobuf:"varint,4,opt,name=velocity_window_seconds,proto3" json:"velocity_window_seconds"`
+	VelocityLimits        string            `protobuf:"bytes,5,opt,name=velocity_limits,proto3" json:"velocity_limits"`
+	VelocityBypass        []string          `protobuf:"bytes,6,rep,name=velocity_bypass,proto3" json:"velocity_bypass,omitempty"`
+	TenantLimits          map[string]int64  `protobuf:"bytes,7,rep,name=tenant_limits,proto3" json:"tenant_limits,omitempty"`
+	LatencyProfile        string            `protobuf:"bytes,8,opt,name=latency_profile,proto3" json:"latency_profile"`
+	LatencyOverrides      map[string]string `protobuf:"bytes,9,rep,name=latency_overrides,proto3" json:"latency_overrides,omitempty"`
+}
+
+func (x *PaymentConfig) Reset()                           { *x = PaymentConfig{} }
+func (x *PaymentConfig) String() string                   { return "PaymentConfig" }
+func (*PaymentConfig) ProtoMessage()                      {}
+func (*PaymentConfig) ProtoReflect() protoreflect.Message { return nil }
+func (*PaymentConfig) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *PaymentConfig) GetMaxAmountCents() int64 {
+	if x != nil {
+		return x.MaxAmountCents
+	}
+	return 0
+}
+
+func (x *PaymentConfig) GetFailureRate() float64 {
+	if x != nil {
+		return x.FailureRate
+	}
+	return 0
+}
+
+func (x *PaymentConfig) GetSimulateLatencyMs() int64 {
+	if x != nil {
+		return x.SimulateLatencyMs
+	}
+	return 0
+}
+
+func (x *PaymentConfig) GetVelocityWindowSeconds() int64 {
+	if x != nil {
+		return x.VelocityWindowSeconds
+	}
+	return 0
+}
+
+func (x *PaymentConfig) GetVelocityLimits() string {
+	if x != nil {
+		return x.VelocityLimits
+	}
+	return ""
+}
+
+func (x *PaymentConfig) GetVelocityBypass() []string {
+	if x != nil {
+		return x.VelocityBypass
+	}
+	return nil
+}
+
+func (x *PaymentConfig) GetTenantLimits() map[string]int64 {
+	if x != nil {
+		return x.TenantLimits
+	}
+	return nil
+}
+
+func (x *PaymentConfig) GetLatencyProfile() string {
+	if x != nil {
+		return x.LatencyProfile
+	}
+	return ""
+}
+
+func (x *PaymentConfig) GetLatencyOverrides() map[string]string {
+	if x != nil {
+		return x.LatencyOverrides
+	}
+	return nil
+}
+
+type GetStatsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetStatsRequest) Reset()                           { *x = GetStatsRequest{} }
+func (x *GetStatsRequest) String() string                   { return "GetStatsRequest" }
+func (*GetStatsRequest) ProtoMessage()                      {}
+func (*GetStatsRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*GetStatsRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+// PaymentStats counts the state held in memory
+type PaymentStats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TotalTransactions     int64 `protobuf:"varint,1,opt,name=total_transactions,proto3" json:"total_transactions"`
+	TotalAmountCents      int64 `protobuf:"varint,2,opt,name=total_amount_cents,proto3" json:"total_amount_cents"`
+	CachedIdempotencyKeys int64 `protobuf:"varint,3,opt,name=cached_idempotency_keys,proto3" json:"cached_idempotency_keys"`
+	HeldForReview         int64 `protobuf:"varint,4,opt,name=held_for_review,proto3" json:"held_for_review"`
+	ActiveSubscriptions   int64 `protobuf:"varint,5,opt,name=active_subscriptions,proto3" json:"active_subscriptions"`
+	OpenDisputes          int64 `protobuf:"varint,6,opt,name=open_disputes,proto3" json:"open_disputes"`
+	// TransactionsByStatus counts transactions by status name
+	TransactionsByStatus map[string]int64 `protobuf:"bytes,7,rep,name=transactions_by_status,proto3" json:"transactions_by_status,omitempty"`
+}
+
+func (x *PaymentStats) Reset()                           { *x = PaymentStats{} }
+func (x *PaymentStats) String() string                   { return "PaymentStats" }
+func (*PaymentStats) ProtoMessage()                      {}
+func (*PaymentStats) ProtoReflect() protoreflect.Message { return nil }
+func (*PaymentStats) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *PaymentStats) GetTotalTransactions() int64 {
+	if x != nil {
+		return x.TotalTransactions
+	}
+	return 0
+}
+
+func (x *PaymentStats) GetTotalAmountCents() int64 {
+	if x != nil {
+		return x.TotalAmountCents
+	}
+	return 0
+}
+
+func (x *PaymentStats) GetCachedIdempotencyKeys() int64 {
+	if x != nil {
+		return x.CachedIdempotencyKeys
+	}
+	return 0
+}
+
+func (x *PaymentStats) GetHeldForReview() int64 {
+	if x != nil {
+		return x.HeldForReview
+	}
+	return 0
+}
+
+func (x *PaymentStats) GetActiveSubscriptions() int64 {
+	if x != nil {
+		return x.ActiveSubscriptions
+	}
+	return 0
+}
+
+func (x *PaymentStats) GetOpenDisputes() int64 {
+	if x != nil {
+		return x.OpenDisputes
+	}
+	return 0
+}
+
+func (x *PaymentStats) GetTransactionsByStatus() map[string]int64 {
+	if x != nil {
+		return x.TransactionsByStatus
+	}
+	return nil
+}
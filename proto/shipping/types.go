@@ -0,0 +1,266 @@
+// Package shipping provides types and gRPC service definitions for order shipments.
+// NOTE: In production, these would be generated by protoc from shipping.proto
+package shipping
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/runtime/protoimpl"
+)
+
+// ShipmentStatus enum for shipment states
+type ShipmentStatus int32
+
+const (
+	ShipmentStatus_SHIPMENT_STATUS_UNSPECIFIED   ShipmentStatus = 0
+	ShipmentStatus_SHIPMENT_STATUS_LABEL_CREATED ShipmentStatus = 1
+	ShipmentStatus_SHIPMENT_STATUS_IN_TRANSIT    ShipmentStatus = 2
+	ShipmentStatus_SHIPMENT_STATUS_DELIVERED     ShipmentStatus = 3
+	ShipmentStatus_SHIPMENT_STATUS_CANCELLED     ShipmentStatus = 4
+)
+
+func (s ShipmentStatus) String() string {
+	switch s {
+	case ShipmentStatus_SHIPMENT_STATUS_LABEL_CREATED:
+		return "LABEL_CREATED"
+	case ShipmentStatus_SHIPMENT_STATUS_IN_TRANSIT:
+		return "IN_TRANSIT"
+	case ShipmentStatus_SHIPMENT_STATUS_DELIVERED:
+		return "DELIVERED"
+	case ShipmentStatus_SHIPMENT_STATUS_CANCELLED:
+		return "CANCELLED"
+	default:
+		return "UNSPECIFIED"
+	}
+}
+
+// Ensure we implement proto.Message interface
+var (
+	_ proto.Message = (*Shipment)(nil)
+	_ proto.Message = (*ShipmentCheckpoint)(nil)
+	_ proto.Message = (*GetShipmentRequest)(nil)
+	_ proto.Message = (*ListShipmentsRequest)(nil)
+	_ proto.Message = (*ListShipmentsResponse)(nil)
+)
+
+// Shipment is the delivery of one order by a carrier
+type Shipment struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ShipmentID     string                `protobuf:"bytes,1,opt,name=shipment_id,proto3" json:"shipment_id,omitempty"`
+	OrderID        string                `protobuf:"bytes,2,opt,name=order_id,proto3" json:"order_id,omitempty"`
+	CustomerID     string                `protobuf:"bytes,3,opt,name=customer_id,proto3" json:"customer_id,omitempty"`
+	Carrier        string                `protobuf:"bytes,4,opt,name=carrier,proto3" json:"carrier,omitempty"`
+	TrackingNumber string                `protobuf:"bytes,5,opt,name=tracking_number,proto3" json:"tracking_number,omitempty"`
+	Status         ShipmentStatus        `protobuf:"varint,6,opt,name=status,proto3" json:"status,omitempty"`
+	Checkpoints    []*ShipmentCheckpoint `protobuf:"bytes,7,rep,name=checkpoints,proto3" json:"checkpoints,omitempty"`
+	CreatedAt      time.Time             `protobuf:"bytes,8,opt,name=created_at,proto3" json:"created_at,omitempty"`
+	UpdatedAt      time.Time             `protobuf:"bytes,9,opt,name=updated_at,proto3" json:"updated_at,omitempty"`
+}
+
+func (x *Shipment) Reset()                           { *x = Shipment{} }
+func (x *Shipment) String() string                   { return "Shipment" }
+func (*Shipment) ProtoMessage()                      {}
+func (*Shipment) ProtoReflect() protoreflect.Message { return nil }
+func (*Shipment) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *Shipment) GetShipmentID() string {
+	if x != nil {
+		return x.ShipmentID
+	}
+	return ""
+}
+
+func (x *Shipment) GetOrderID() string {
+	if x != nil {
+		return x.OrderID
+	}
+	return ""
+}
+
+func (x *Shipment) GetCustomerID() string {
+	if x != nil {
+		return x.CustomerID
+	}
+	return ""
+}
+
+func (x *Shipment) GetCarrier() string {
+	if x != nil {
+		return x.Carrier
+	}
+	return ""
+}
+
+func (x *Shipment) GetTrackingNumber() string {
+	if x != nil {
+		return x.TrackingNumber
+	}
+	return ""
+}
+
+func (x *Shipment) GetStatus() ShipmentStatus {
+	if x != nil {
+		return x.Status
+	}
+	return ShipmentStatus_SHIPMENT_STATUS_UNSPECIFIED
+}
+
+func (x *Shipment) GetCheckpoints() []*ShipmentCheckpoint {
+	if x != nil {
+		return x.Checkpoints
+	}
+	return nil
+}
+
+// ShipmentCheckpoint is one carrier scan, oldest first
+type ShipmentCheckpoint struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status      ShipmentStatus `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Description string         `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	At          time.Time      `protobuf:"bytes,3,opt,name=at,proto3" json:"at,omitempty"`
+}
+
+func (x *ShipmentCheckpoint) Reset()                           { *x = ShipmentCheckpoint{} }
+func (x *ShipmentCheckpoint) String() string                   { return "ShipmentCheckpoint" }
+func (*ShipmentCheckpoint) ProtoMessage()                      {}
+func (*ShipmentCheckpoint) ProtoReflect() protoreflect.Message { return nil }
+func (*ShipmentCheckpoint) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *ShipmentCheckpoint) GetStatus() ShipmentStatus {
+	if x != nil {
+		return x.Status
+	}
+	return ShipmentStatus_SHIPMENT_STATUS_UNSPECIFIED
+}
+
+func (x *ShipmentCheckpoint) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+// GetShipmentRequest looks up a shipment by shipment_id or order_id
+type GetShipmentRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ShipmentID string `protobuf:"bytes,1,opt,name=shipment_id,proto3" json:"shipment_id,omitempty"`
+	OrderID    string `protobuf:"bytes,2,opt,name=order_id,proto3" json:"order_id,omitempty"`
+}
+
+func (x *GetShipmentRequest) Reset()                           { *x = GetShipmentRequest{} }
+func (x *GetShipmentRequest) String() string                   { return "GetShipmentRequest" }
+func (*GetShipmentRequest) ProtoMessage()                      {}
+func (*GetShipmentRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*GetShipmentRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *GetShipmentRequest) GetShipmentID() string {
+	if x != nil {
+		return x.ShipmentID
+	}
+	return ""
+}
+
+func (x *GetShipmentRequest) GetOrderID() string {
+	if x != nil {
+		return x.OrderID
+	}
+	return ""
+}
+
+// ListShipmentsRequest filters ListShipments
+type ListShipmentsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status     ShipmentStatus `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	CustomerID string         `protobuf:"bytes,2,opt,name=customer_id,proto3" json:"customer_id,omitempty"`
+}
+
+func (x *ListShipmentsRequest) Reset()                           { *x = ListShipmentsRequest{} }
+func (x *ListShipmentsRequest) String() string                   { return "ListShipmentsRequest" }
+func (*ListShipmentsRequest) ProtoMessage()                      {}
+func (*ListShipmentsRequest) ProtoReflect() protoreflect.Message { return nil }
+func (*ListShipmentsRequest) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *ListShipmentsRequest) GetStatus() ShipmentStatus {
+	if x != nil {
+		return x.Status
+	}
+	return ShipmentStatus_SHIPMENT_STATUS_UNSPECIFIED
+}
+
+func (x *ListShipmentsRequest) GetCustomerID() string {
+	if x != nil {
+		return x.CustomerID
+	}
+	return ""
+}
+
+// ListShipmentsResponse contains the matching shipments
+type ListShipmentsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Shipments []*Shipment `protobuf:"bytes,1,rep,name=shipments,proto3" json:"shipments,omitempty"`
+}
+
+func (x *ListShipmentsResponse) Reset()                           { *x = ListShipmentsResponse{} }
+func (x *ListShipmentsResponse) String() string                   { return "ListShipmentsResponse" }
+func (*ListShipmentsResponse) ProtoMessage()                      {}
+func (*ListShipmentsResponse) ProtoReflect() protoreflect.Message { return nil }
+func (*ListShipmentsResponse) Descriptor() ([]byte, []int)        { return nil, nil }
+
+func (x *ListShipmentsResponse) GetShipments() []*Shipment {
+	if x != nil {
+		return x.Shipments
+	}
+	return nil
+}
+
+// EventTypeShipmentUpdated is the message type of ShipmentUpdatedEvent
+const EventTypeShipmentUpdated = "shipment.updated"
+
+// ShipmentUpdatedEvent is published every time a shipment changes status
+type ShipmentUpdatedEvent struct {
+	EventID        string         `json:"event_id"`
+	EventType      string         `json:"event_type"`
+	Timestamp      time.Time      `json:"timestamp"`
+	ShipmentID     string         `json:"shipment_id"`
+	OrderID        string         `json:"order_id"`
+	Carrier        string         `json:"carrier"`
+	TrackingNumber string         `json:"tracking_number"`
+	Status         ShipmentStatus `json:"status"`
+	Description    string         `json:"description,omitempty"`
+}
+
+// NewShipmentUpdatedEvent creates a ShipmentUpdatedEvent for the latest
+// checkpoint of s
+func NewShipmentUpdatedEvent(s *Shipment) ShipmentUpdatedEvent {
+	e := ShipmentUpdatedEvent{
+		EventID:        "evt_" + s.ShipmentID + "_" + s.Status.String(),
+		EventType:      EventTypeShipmentUpdated,
+		Timestamp:      time.Now(),
+		ShipmentID:     s.ShipmentID,
+		OrderID:        s.OrderID,
+		Carrier:        s.Carrier,
+		TrackingNumber: s.TrackingNumber,
+		Status:         s.Status,
+	}
+	if n := len(s.Checkpoints); n > 0 {
+		e.Description = s.Checkpoints[n-1].Description
+	}
+	return e
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/config"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+)
+
+// Config holds the startup settings of the analytics service.
+type Config struct {
+	HTTP       config.HTTPServer `config:"http"`
+	OrderURL   string            `config:"order-url" usage:"Order Service base URL"`
+	OrderToken string            `config:"order-token,secret" usage:"Admin API key or JWT for the Order Service"`
+	StoreDSN   string            `config:"store-dsn" usage:"SQLite file the projections are stored in"`
+
+	// EventDedupWindow drops order events the order stream sends more
+	// than once. The projections ignore repeated events anyway; this saves
+	// the writes.
+	EventDedupWindow time.Duration `config:"event-dedup-window" usage:"How long forwarded order event IDs are remembered to drop repeated events, 0 disables"`
+
+	Broker broker.BrokerConfig `config:"broker"`
+	Log    logging.Config      `config:"log"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		HTTP:     config.DefaultHTTPServer(8085),
+		OrderURL: "http://localhost:8080",
+		StoreDSN: "analytics.db",
+		Broker:   broker.DefaultBrokerConfig(),
+		Log:      logging.DefaultConfig(),
+
+		EventDedupWindow: 10 * time.Minute,
+	}
+}
+
+func (c Config) Validate() error {
+	if c.OrderURL == "" {
+		return errors.New("order-url is required")
+	}
+	if c.StoreDSN == "" {
+		return errors.New("store-dsn is required")
+	}
+	if c.EventDedupWindow < 0 {
+		return errors.New("event-dedup-window must not be negative")
+	}
+	return nil
+}
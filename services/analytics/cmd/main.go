@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/config"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/sse"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/analytics/internal/handler"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/analytics/internal/projection"
+	_ "modernc.org/sqlite"
+)
+
+func main() {
+	cfg := defaultConfig()
+	loader := config.Register(flag.CommandLine, "analytics", &cfg)
+	flag.Parse()
+	if err := loader.Load(); err != nil {
+		logging.Fatal("invalid configuration", logging.Err(err))
+	}
+
+	if err := logging.Setup("analytics", cfg.Log); err != nil {
+		logging.Fatal("invalid logging configuration", logging.Err(err))
+	}
+	slog.Info("starting analytics service", "http_port", cfg.HTTP.Port)
+
+	db, err := sql.Open("sqlite", cfg.StoreDSN)
+	if err != nil {
+		logging.Fatal("failed to open analytics store", logging.Err(err))
+	}
+	defer db.Close()
+	// The projection worker is the only writer; one connection keeps the
+	// queries from finding the file locked by it.
+	db.SetMaxOpenConns(1)
+	initCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	store, err := projection.NewStore(initCtx, db)
+	cancel()
+	if err != nil {
+		logging.Fatal("failed to open analytics store", logging.Err(err))
+	}
+	slog.Info("analytics store opened", "dsn", cfg.StoreDSN)
+
+	msgBroker := broker.NewBroker(cfg.Broker)
+	msgBroker.CreateTopic("order.created", broker.WithDedupWindow(cfg.EventDedupWindow))
+	msgBroker.CreateTopic("order.status_changed", broker.WithDedupWindow(cfg.EventDedupWindow))
+	projectionQueue := msgBroker.CreateQueue("projections", broker.WithMaxRetries(5))
+	msgBroker.Subscribe("order.created", "projections")
+	msgBroker.Subscribe("order.status_changed", "projections")
+	slog.Info("message broker configured")
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	go broker.NewWorker("projection-worker", projectionQueue, store.HandleMessage).Start(ctx)
+	orderEvents := sse.NewStream(strings.TrimRight(cfg.OrderURL, "/")+"/orders/events", cfg.OrderToken)
+	go orderEvents.Follow(ctx, func(e sse.Event) {
+		forwardOrderEvent(ctx, msgBroker, e)
+	})
+	slog.Info("following order events", "url", cfg.OrderURL)
+
+	mux := http.NewServeMux()
+	handler.NewAnalyticsHandler(store).RegisterRoutes(mux)
+	server := cfg.HTTP.Server(logging.Middleware(mux))
+
+	go func() {
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+		<-sigChan
+		slog.Info("shutting down")
+		stop()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("analytics service ready", "url", fmt.Sprintf("http://localhost:%d", cfg.HTTP.Port))
+	slog.Info("endpoints: GET /analytics/revenue, GET /analytics/products/top, GET /analytics/declines, GET /health")
+
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		logging.Fatal("HTTP server error", logging.Err(err))
+	}
+}
+
+// forwardOrderEvent publishes an event read from the order stream on the
+// local topic of the same name.
+func forwardOrderEvent(ctx context.Context, b *broker.Broker, e sse.Event) {
+	if _, ok := b.GetTopic(e.Type); !ok {
+		return
+	}
+	msg := &broker.Message{
+		ID:        e.ID,
+		Type:      e.Type,
+		Payload:   e.Data,
+		Metadata:  map[string]string{broker.ContentTypeMetadata: broker.ContentTypeJSON},
+		Timestamp: time.Now(),
+	}
+	if err := b.Publish(ctx, e.Type, msg); err != nil {
+		slog.ErrorContext(ctx, "failed to forward order event", "event_type", e.Type, "event_id", e.ID, logging.Err(err))
+	}
+}
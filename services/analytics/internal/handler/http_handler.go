@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/analytics/internal/projection"
+)
+
+// defaultTopProducts is the number of products GET /analytics/products/top
+// lists without a limit, and maxTopProducts the most it lists.
+const (
+	defaultTopProducts = 10
+	maxTopProducts     = 100
+)
+
+var logger = logging.Component("http")
+
+// AnalyticsHandler serves the projections over HTTP.
+type AnalyticsHandler struct {
+	store *projection.Store
+}
+
+func NewAnalyticsHandler(store *projection.Store) *AnalyticsHandler {
+	return &AnalyticsHandler{store: store}
+}
+
+func (h *AnalyticsHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /analytics/revenue", h.revenue)
+	mux.HandleFunc("GET /analytics/products/top", h.topProducts)
+	mux.HandleFunc("GET /analytics/declines", h.declines)
+	mux.HandleFunc("/health", h.handleHealth)
+}
+
+// parseRange reads the from and to query parameters, days such as
+// 2026-10-16.
+func parseRange(r *http.Request) (projection.Range, bool) {
+	q := r.URL.Query()
+	rng := projection.Range{From: q.Get("from"), To: q.Get("to")}
+	for _, day := range []string{rng.From, rng.To} {
+		if _, err := time.Parse(time.DateOnly, day); day != "" && err != nil {
+			return rng, false
+		}
+	}
+	return rng, true
+}
+
+// revenue serves GET /analytics/revenue, one entry per day and currency.
+func (h *AnalyticsHandler) revenue(w http.ResponseWriter, r *http.Request) {
+	rng, ok := parseRange(r)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "from and to must be days such as 2026-10-16")
+		return
+	}
+
+	days, err := h.store.Revenue(r.Context(), rng)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "querying revenue failed", logging.Err(err))
+		respondError(w, http.StatusInternalServerError, "Failed to query revenue")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"days": days})
+}
+
+// topProducts serves GET /analytics/products/top, best selling first.
+func (h *AnalyticsHandler) topProducts(w http.ResponseWriter, r *http.Request) {
+	rng, ok := parseRange(r)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "from and to must be days such as 2026-10-16")
+		return
+	}
+	limit := defaultTopProducts
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxTopProducts {
+			respondError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxTopProducts))
+			return
+		}
+		limit = n
+	}
+
+	products, err := h.store.TopProducts(r.Context(), rng, limit)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "querying top products failed", logging.Err(err))
+		respondError(w, http.StatusInternalServerError, "Failed to query products")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"products": products})
+}
+
+// declines serves GET /analytics/declines, most frequent reason first.
+func (h *AnalyticsHandler) declines(w http.ResponseWriter, r *http.Request) {
+	rng, ok := parseRange(r)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "from and to must be days such as 2026-10-16")
+		return
+	}
+
+	reasons, err := h.store.Declines(r.Context(), rng)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "querying declines failed", logging.Err(err))
+		respondError(w, http.StatusInternalServerError, "Failed to query declines")
+		return
+	}
+	var total int64
+	for _, d := range reasons {
+		total += d.Count
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"reasons": reasons, "total": total})
+}
+
+func (h *AnalyticsHandler) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if err := h.store.Ping(r.Context()); err != nil {
+		respondJSON(w, http.StatusServiceUnavailable, map[string]string{
+			"status":  "unhealthy",
+			"service": "analytics-service",
+			"error":   err.Error(),
+		})
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{
+		"status":  "healthy",
+		"service": "analytics-service",
+	})
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,123 @@
+package projection
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/events"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+)
+
+var logger = logging.Component("projection")
+
+// declinedPrefix starts the reason of an order cancelled because its
+// payment was declined; the payment error code follows it.
+const declinedPrefix = "payment declined: "
+
+// HandleMessage is a broker.MessageHandler applying order.created and
+// order.status_changed to the projections. Other messages are acknowledged
+// and ignored; a store error is returned so the queue redelivers the
+// message.
+func (s *Store) HandleMessage(msg *broker.Message) error {
+	ctx, cancel := context.WithTimeout(msg.Context(context.Background()), 10*time.Second)
+	defer cancel()
+
+	switch msg.Type {
+	case events.TypeOrderCreated:
+		var created events.OrderCreatedV1
+		if err := events.Decode(msg, &created); err != nil {
+			return err
+		}
+		return s.orderCreated(ctx, created)
+	case "order.status_changed":
+		var changed order.OrderStatusChangedEvent
+		if err := msg.Decode(&changed); err != nil {
+			return err
+		}
+		return s.statusChanged(ctx, changed)
+	}
+	return nil
+}
+
+// orderCreated stores the total and the items of the order, and its payment
+// when it was created paid.
+func (s *Store) orderCreated(ctx context.Context, created events.OrderCreatedV1) error {
+	o := created.Order
+	var paidAt any
+	if o.Status == order.OrderStatus_ORDER_STATUS_PAID {
+		paidAt = formatTime(created.Timestamp)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO analytics_orders (order_id, total_cents, currency, created_at, paid_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (order_id) DO UPDATE SET total_cents = excluded.total_cents, currency = excluded.currency,
+			created_at = excluded.created_at, paid_at = COALESCE(analytics_orders.paid_at, excluded.paid_at)`,
+		o.ID, o.TotalCents, o.Currency, formatTime(o.CreatedAt), paidAt)
+	if err != nil {
+		return fmt.Errorf("store order %s: %w", o.ID, err)
+	}
+	for i, item := range o.Items {
+		_, err = tx.ExecContext(ctx, `INSERT INTO analytics_items (order_id, item, product_id, product_name, quantity, amount_cents)
+			VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT (order_id, item) DO NOTHING`,
+			o.ID, i, item.ProductID, item.ProductName, item.Quantity, int64(item.Quantity)*item.UnitPriceCents)
+		if err != nil {
+			return fmt.Errorf("store items of order %s: %w", o.ID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	logger.DebugContext(ctx, "order projected", "order_id", o.ID, "status", o.Status.String())
+	return nil
+}
+
+// statusChanged records the payment of an order charged in the background
+// and the reason of a declined payment. An order paid before its
+// order.created is applied gets its total from it later.
+func (s *Store) statusChanged(ctx context.Context, changed order.OrderStatusChangedEvent) error {
+	at := formatTime(changed.Timestamp)
+
+	switch {
+	case changed.To == order.OrderStatus_ORDER_STATUS_PAID:
+		_, err := s.db.ExecContext(ctx, `INSERT INTO analytics_orders (order_id, paid_at) VALUES (?, ?)
+			ON CONFLICT (order_id) DO UPDATE SET paid_at = COALESCE(analytics_orders.paid_at, excluded.paid_at)`,
+			changed.OrderID, at)
+		if err != nil {
+			return fmt.Errorf("store payment of order %s: %w", changed.OrderID, err)
+		}
+	case changed.From == order.OrderStatus_ORDER_STATUS_PENDING && changed.To == order.OrderStatus_ORDER_STATUS_CANCELLED:
+		reason, ok := declineReason(changed.Reason)
+		if !ok {
+			return nil
+		}
+		_, err := s.db.ExecContext(ctx, `INSERT INTO analytics_declines (order_id, reason, declined_at) VALUES (?, ?, ?)
+			ON CONFLICT (order_id) DO NOTHING`, changed.OrderID, reason, at)
+		if err != nil {
+			return fmt.Errorf("store decline of order %s: %w", changed.OrderID, err)
+		}
+		logger.DebugContext(ctx, "decline projected", "order_id", changed.OrderID, "reason", reason)
+	}
+	return nil
+}
+
+// declineReason returns the payment error code in the reason of a
+// cancelled order, or the reason itself for payments that failed without
+// one, such as "payment failed" when the payment service was unreachable.
+// It reports false for orders cancelled for another reason.
+func declineReason(reason string) (string, bool) {
+	if code, ok := strings.CutPrefix(reason, declinedPrefix); ok {
+		return code, true
+	}
+	return reason, strings.HasPrefix(reason, "payment ")
+}
@@ -0,0 +1,180 @@
+// Package projection builds the analytics read model from order events:
+// revenue by day, the best selling products and why payments were
+// declined. The projections are SQLite tables updated by upserts, so an
+// event applied twice, or out of order with the events of the same order,
+// leaves them as applying it once would.
+package projection
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// sqlTimeLayout has a fixed width so that timestamps sort as text, and
+// their first 10 characters are the UTC day.
+const sqlTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// Store keeps the projections in the analytics_orders, analytics_items and
+// analytics_declines tables.
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore creates the tables if needed.
+func NewStore(ctx context.Context, db *sql.DB) (*Store, error) {
+	statements := []struct{ name, sql string }{
+		{"analytics_orders table", `CREATE TABLE IF NOT EXISTS analytics_orders (
+			order_id    TEXT PRIMARY KEY,
+			total_cents BIGINT NOT NULL DEFAULT 0,
+			currency    TEXT NOT NULL DEFAULT '',
+			created_at  TEXT,
+			paid_at     TEXT
+		)`},
+		{"analytics_orders paid index", `CREATE INDEX IF NOT EXISTS analytics_orders_paid_at ON analytics_orders (paid_at)`},
+		{"analytics_items table", `CREATE TABLE IF NOT EXISTS analytics_items (
+			order_id     TEXT NOT NULL,
+			item         INTEGER NOT NULL,
+			product_id   TEXT NOT NULL,
+			product_name TEXT NOT NULL,
+			quantity     INTEGER NOT NULL,
+			amount_cents BIGINT NOT NULL,
+			PRIMARY KEY (order_id, item)
+		)`},
+		{"analytics_declines table", `CREATE TABLE IF NOT EXISTS analytics_declines (
+			order_id    TEXT PRIMARY KEY,
+			reason      TEXT NOT NULL,
+			declined_at TEXT NOT NULL
+		)`},
+	}
+	for _, st := range statements {
+		if _, err := db.ExecContext(ctx, st.sql); err != nil {
+			return nil, fmt.Errorf("create %s: %w", st.name, err)
+		}
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(sqlTimeLayout)
+}
+
+// DayRevenue is the gross revenue of the orders paid on Day in Currency.
+type DayRevenue struct {
+	Day          string `json:"day"`
+	Currency     string `json:"currency"`
+	Orders       int64  `json:"orders"`
+	RevenueCents int64  `json:"revenue_cents"`
+}
+
+// Range bounds a query to the days from From to To, both included; an
+// empty bound is open.
+type Range struct {
+	From string
+	To   string
+}
+
+// where returns the condition on column, a timestamp, for r and its
+// arguments.
+func (r Range) where(column string) (string, []any) {
+	cond, args := "", []any{}
+	if r.From != "" {
+		cond += " AND substr(" + column + ", 1, 10) >= ?"
+		args = append(args, r.From)
+	}
+	if r.To != "" {
+		cond += " AND substr(" + column + ", 1, 10) <= ?"
+		args = append(args, r.To)
+	}
+	return cond, args
+}
+
+// Revenue returns the revenue of each day of r with a paid order, oldest
+// first. Orders cancelled or charged back after they were paid still count.
+func (s *Store) Revenue(ctx context.Context, r Range) ([]DayRevenue, error) {
+	cond, args := r.where("paid_at")
+	rows, err := s.db.QueryContext(ctx, `SELECT substr(paid_at, 1, 10) AS day, currency, COUNT(*), SUM(total_cents)
+		FROM analytics_orders WHERE paid_at IS NOT NULL`+cond+`
+		GROUP BY day, currency ORDER BY day, currency`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []DayRevenue{}
+	for rows.Next() {
+		var d DayRevenue
+		if err := rows.Scan(&d.Day, &d.Currency, &d.Orders, &d.RevenueCents); err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
+
+// ProductSales are the units of a product sold in paid orders and the
+// revenue they brought in Currency.
+type ProductSales struct {
+	ProductID    string `json:"product_id"`
+	ProductName  string `json:"product_name"`
+	Currency     string `json:"currency"`
+	Units        int64  `json:"units"`
+	RevenueCents int64  `json:"revenue_cents"`
+}
+
+// TopProducts returns the limit products that sold the most units in the
+// orders paid within r, best first.
+func (s *Store) TopProducts(ctx context.Context, r Range, limit int) ([]ProductSales, error) {
+	cond, args := r.where("o.paid_at")
+	rows, err := s.db.QueryContext(ctx, `SELECT i.product_id, MAX(i.product_name), o.currency, SUM(i.quantity), SUM(i.amount_cents)
+		FROM analytics_items i JOIN analytics_orders o ON o.order_id = i.order_id
+		WHERE o.paid_at IS NOT NULL`+cond+`
+		GROUP BY i.product_id, o.currency ORDER BY SUM(i.quantity) DESC, i.product_id LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []ProductSales{}
+	for rows.Next() {
+		var p ProductSales
+		if err := rows.Scan(&p.ProductID, &p.ProductName, &p.Currency, &p.Units, &p.RevenueCents); err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
+
+// DeclineCount is the number of orders cancelled for Reason.
+type DeclineCount struct {
+	Reason string `json:"reason"`
+	Count  int64  `json:"count"`
+}
+
+// Declines returns the reasons of the payments declined within r, most
+// frequent first.
+func (s *Store) Declines(ctx context.Context, r Range) ([]DeclineCount, error) {
+	cond, args := r.where("declined_at")
+	rows, err := s.db.QueryContext(ctx, `SELECT reason, COUNT(*) FROM analytics_declines WHERE 1 = 1`+cond+`
+		GROUP BY reason ORDER BY COUNT(*) DESC, reason`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reasons := []DeclineCount{}
+	for rows.Next() {
+		var d DeclineCount
+		if err := rows.Scan(&d.Reason, &d.Count); err != nil {
+			return nil, err
+		}
+		reasons = append(reasons, d)
+	}
+	return reasons, rows.Err()
+}
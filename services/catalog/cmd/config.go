@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/config"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/catalog/internal/service"
+)
+
+// Config holds the startup settings of the catalog service.
+type Config struct {
+	Port     int            `config:"port" usage:"gRPC server port"`
+	Products string         `config:"products" usage:"Products for sale as id:name:CUR=cents[|CUR=cents] entries"`
+	APIKeys  string         `config:"api-keys,secret" usage:"Comma separated key:name pairs accepted as credentials"`
+	Log      logging.Config `config:"log"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		Port:     50055,
+		Products: "laptop:Laptop Pro:BRL=249900|USD=49900|EUR=45900,mouse:Wireless Mouse:BRL=9900|USD=1990|EUR=1790,keyboard:Mechanical Keyboard:BRL=34900|USD=6990|EUR=6490",
+		Log:      logging.DefaultConfig(),
+	}
+}
+
+func (c Config) Validate() error {
+	if err := config.CheckPort("port", c.Port); err != nil {
+		return err
+	}
+	if _, err := service.ParseSeed(c.Products); err != nil {
+		return fmt.Errorf("products: %w", err)
+	}
+	if _, err := auth.ParseStaticKeys(c.APIKeys); err != nil {
+		return fmt.Errorf("api-keys: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/catalog/internal/service"
+	"google.golang.org/grpc/codes"
+)
+
+// ErrorDomain is the google.rpc.ErrorInfo domain of catalog errors.
+const ErrorDomain = "catalog.CatalogService"
+
+// Error reasons reported in google.rpc.ErrorInfo.
+const (
+	ReasonProductNotFound = "PRODUCT_NOT_FOUND"
+)
+
+// toStatus maps service errors to gRPC status errors with machine-readable
+// details. Unknown errors are logged and reported as codes.Internal with
+// the fallback message.
+func toStatus(err error, fallback string) error {
+	switch {
+	case errors.Is(err, service.ErrProductNotFound):
+		return grpcmw.ErrorInfo(codes.NotFound, err.Error(), ReasonProductNotFound, ErrorDomain, nil)
+	default:
+		logger.Error(fallback, logging.Err(err))
+		return grpcmw.ErrorInfo(codes.Internal, fallback, grpcmw.ReasonInternal, ErrorDomain, nil)
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/catalog"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/catalog/internal/service"
+)
+
+var logger = logging.Component("grpc")
+
+type CatalogServer struct {
+	catalog.UnimplementedCatalogServiceServer
+	svc *service.CatalogService
+}
+
+func NewCatalogServer(svc *service.CatalogService) *CatalogServer {
+	return &CatalogServer{svc: svc}
+}
+
+func (s *CatalogServer) GetProduct(ctx context.Context, req *catalog.GetProductRequest) (*catalog.Product, error) {
+	logger.DebugContext(ctx, "GetProduct", "product_id", req.ProductID)
+
+	if req.ProductID == "" {
+		return nil, grpcmw.Required("product_id")
+	}
+
+	p, err := s.svc.GetProduct(ctx, req)
+	if err != nil {
+		return nil, toStatus(err, "failed to get product")
+	}
+	return p, nil
+}
+
+func (s *CatalogServer) GetPrices(ctx context.Context, req *catalog.GetPricesRequest) (*catalog.GetPricesResponse, error) {
+	logger.DebugContext(ctx, "GetPrices", "products", len(req.ProductIDs), "currency", req.Currency)
+
+	if req.Currency == "" {
+		return nil, grpcmw.Required("currency")
+	}
+
+	resp, err := s.svc.GetPrices(ctx, req)
+	if err != nil {
+		return nil, toStatus(err, "failed to get prices")
+	}
+	return resp, nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/money"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/catalog"
+)
+
+// CatalogService keeps the products for sale in memory. Products are set at
+// startup and read-only afterwards.
+type CatalogService struct {
+	products map[string]*catalog.Product
+}
+
+func NewCatalogService(products []*catalog.Product) *CatalogService {
+	s := &CatalogService{products: make(map[string]*catalog.Product, len(products))}
+	for _, p := range products {
+		s.products[p.ID] = cloneProduct(p)
+	}
+	return s
+}
+
+// ParseSeed parses a comma separated "id:name:CUR=cents[|CUR=cents]" list
+// such as "laptop:Laptop Pro:BRL=249900|USD=49900". Every product needs at
+// least one price.
+func ParseSeed(spec string) ([]*catalog.Product, error) {
+	var products []*catalog.Product
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) < 3 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid product entry %q, expected id:name:CUR=cents[|CUR=cents]", entry)
+		}
+		if seen[parts[0]] {
+			return nil, fmt.Errorf("product %q listed twice", parts[0])
+		}
+		seen[parts[0]] = true
+
+		p := &catalog.Product{ID: parts[0], Name: parts[1]}
+		for _, price := range strings.Split(parts[2], "|") {
+			code, cents, ok := strings.Cut(price, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid price %q of product %q, expected CUR=cents", price, p.ID)
+			}
+			currency, err := money.ParseCurrency(strings.TrimSpace(code))
+			if err != nil {
+				return nil, fmt.Errorf("product %q: %w", p.ID, err)
+			}
+			amount, err := strconv.ParseInt(strings.TrimSpace(cents), 10, 64)
+			if err != nil || amount <= 0 {
+				return nil, fmt.Errorf("invalid price %q of product %q, must be a positive number of cents", price, p.ID)
+			}
+			if priceIn(p, currency) != nil {
+				return nil, fmt.Errorf("product %q has two %s prices", p.ID, currency)
+			}
+			p.Prices = append(p.Prices, &catalog.Price{Currency: currency, UnitPriceCents: amount})
+		}
+		products = append(products, p)
+	}
+	return products, nil
+}
+
+func (s *CatalogService) GetProduct(ctx context.Context, req *catalog.GetProductRequest) (*catalog.Product, error) {
+	p, ok := s.products[req.ProductID]
+	if !ok {
+		return nil, ErrProductNotFound
+	}
+	return cloneProduct(p), nil
+}
+
+// GetPrices prices every distinct requested product in req.Currency. The
+// prices keep the order of the request; products without a price are
+// listed instead, sorted by ID.
+func (s *CatalogService) GetPrices(ctx context.Context, req *catalog.GetPricesRequest) (*catalog.GetPricesResponse, error) {
+	currency := strings.ToUpper(req.Currency)
+	resp := &catalog.GetPricesResponse{}
+	seen := make(map[string]bool, len(req.ProductIDs))
+	for _, id := range req.ProductIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		p, ok := s.products[id]
+		if !ok {
+			resp.UnknownProductIDs = append(resp.UnknownProductIDs, id)
+			continue
+		}
+		price := priceIn(p, currency)
+		if price == nil {
+			resp.UnpricedProductIDs = append(resp.UnpricedProductIDs, id)
+			continue
+		}
+		resp.Prices = append(resp.Prices, &catalog.ProductPrice{
+			ProductID:      p.ID,
+			Name:           p.Name,
+			Currency:       price.Currency,
+			UnitPriceCents: price.UnitPriceCents,
+		})
+	}
+	sort.Strings(resp.UnknownProductIDs)
+	sort.Strings(resp.UnpricedProductIDs)
+	return resp, nil
+}
+
+// Count returns the number of products.
+func (s *CatalogService) Count() int {
+	return len(s.products)
+}
+
+func priceIn(p *catalog.Product, currency string) *catalog.Price {
+	for _, price := range p.Prices {
+		if price.Currency == currency {
+			return price
+		}
+	}
+	return nil
+}
+
+func cloneProduct(p *catalog.Product) *catalog.Product {
+	out := &catalog.Product{ID: p.ID, Name: p.Name, Prices: make([]*catalog.Price, len(p.Prices))}
+	for i, price := range p.Prices {
+		out.Prices[i] = &catalog.Price{Currency: price.Currency, UnitPriceCents: price.UnitPriceCents}
+	}
+	return out
+}
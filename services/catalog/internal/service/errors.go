@@ -0,0 +1,8 @@
+package service
+
+import "errors"
+
+var (
+	// ErrProductNotFound is returned when a product doesn't exist
+	ErrProductNotFound = errors.New("product not found")
+)
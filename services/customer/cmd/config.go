@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/config"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/customer/internal/service"
+)
+
+// Config holds the startup settings of the customer service.
+type Config struct {
+	Port    int            `config:"port" usage:"gRPC server port"`
+	Seed    string         `config:"seed" usage:"Customers created at startup as id:email[:name] entries"`
+	APIKeys string         `config:"api-keys,secret" usage:"Comma separated key:name pairs accepted as credentials"`
+	Log     logging.Config `config:"log"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		Port: 50054,
+		Seed: "cus_alice:alice@example.com:Alice,cus_bob:bob@example.com:Bob",
+		Log:  logging.DefaultConfig(),
+	}
+}
+
+func (c Config) Validate() error {
+	if err := config.CheckPort("port", c.Port); err != nil {
+		return err
+	}
+	if _, err := service.ParseSeed(c.Seed); err != nil {
+		return fmt.Errorf("seed: %w", err)
+	}
+	if _, err := auth.ParseStaticKeys(c.APIKeys); err != nil {
+		return fmt.Errorf("api-keys: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/customer/internal/service"
+	"google.golang.org/grpc/codes"
+)
+
+// ErrorDomain is the google.rpc.ErrorInfo domain of customer errors.
+const ErrorDomain = "customer.CustomerService"
+
+// Error reasons reported in google.rpc.ErrorInfo.
+const (
+	ReasonCustomerNotFound = "CUSTOMER_NOT_FOUND"
+	ReasonCustomerExists   = "CUSTOMER_EXISTS"
+	ReasonEmailTaken       = "EMAIL_TAKEN"
+)
+
+// toStatus maps service errors to gRPC status errors with machine-readable
+// details. Unknown errors are logged and reported as codes.Internal with
+// the fallback message.
+func toStatus(err error, fallback string) error {
+	var verr *service.ValidationError
+
+	switch {
+	case errors.As(err, &verr):
+		violations := make([]grpcmw.FieldViolation, len(verr.Fields))
+		for i, f := range verr.Fields {
+			violations[i] = grpcmw.FieldViolation{Field: f.Field, Description: f.Message}
+		}
+		return grpcmw.BadRequest(err.Error(), violations...)
+	case errors.Is(err, service.ErrCustomerNotFound):
+		return grpcmw.ErrorInfo(codes.NotFound, err.Error(), ReasonCustomerNotFound, ErrorDomain, nil)
+	case errors.Is(err, service.ErrCustomerExists):
+		return grpcmw.ErrorInfo(codes.AlreadyExists, err.Error(), ReasonCustomerExists, ErrorDomain, nil)
+	case errors.Is(err, service.ErrEmailTaken):
+		return grpcmw.ErrorInfo(codes.AlreadyExists, err.Error(), ReasonEmailTaken, ErrorDomain, nil)
+	default:
+		logger.Error(fallback, logging.Err(err))
+		return grpcmw.ErrorInfo(codes.Internal, fallback, grpcmw.ReasonInternal, ErrorDomain, nil)
+	}
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/customer"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/customer/internal/service"
+)
+
+var logger = logging.Component("grpc")
+
+type CustomerServer struct {
+	customer.UnimplementedCustomerServiceServer
+	svc *service.CustomerService
+}
+
+func NewCustomerServer(svc *service.CustomerService) *CustomerServer {
+	return &CustomerServer{svc: svc}
+}
+
+func (s *CustomerServer) CreateCustomer(ctx context.Context, req *customer.CreateCustomerRequest) (*customer.Customer, error) {
+	logger.InfoContext(ctx, "CreateCustomer", "customer_id", req.ID, "email", req.Email)
+
+	c, err := s.svc.CreateCustomer(ctx, req)
+	if err != nil {
+		return nil, toStatus(err, "failed to create customer")
+	}
+	return c, nil
+}
+
+func (s *CustomerServer) GetCustomer(ctx context.Context, req *customer.GetCustomerRequest) (*customer.Customer, error) {
+	logger.DebugContext(ctx, "GetCustomer", "customer_id", req.CustomerID)
+
+	if req.CustomerID == "" {
+		return nil, grpcmw.Required("customer_id")
+	}
+
+	c, err := s.svc.GetCustomer(ctx, req)
+	if err != nil {
+		return nil, toStatus(err, "failed to get customer")
+	}
+	return c, nil
+}
+
+func (s *CustomerServer) UpdateCustomer(ctx context.Context, req *customer.UpdateCustomerRequest) (*customer.Customer, error) {
+	logger.InfoContext(ctx, "UpdateCustomer", "customer_id", req.CustomerID)
+
+	if req.CustomerID == "" {
+		return nil, grpcmw.Required("customer_id")
+	}
+
+	c, err := s.svc.UpdateCustomer(ctx, req)
+	if err != nil {
+		return nil, toStatus(err, "failed to update customer")
+	}
+	return c, nil
+}
+
+func (s *CustomerServer) ListCustomers(ctx context.Context, req *customer.ListCustomersRequest) (*customer.ListCustomersResponse, error) {
+	logger.DebugContext(ctx, "ListCustomers", "email", req.Email)
+
+	resp, err := s.svc.ListCustomers(ctx, req)
+	if err != nil {
+		return nil, toStatus(err, "failed to list customers")
+	}
+	return resp, nil
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/customer"
+	"github.com/google/uuid"
+)
+
+// PaymentMethods lists the accepted PaymentPreferences.Method values.
+var PaymentMethods = []string{"card", "pix", "boleto"}
+
+// CustomerService keeps customer profiles in memory. Emails are unique
+// across customers, compared case-insensitively.
+type CustomerService struct {
+	mu        sync.RWMutex
+	customers map[string]*customer.Customer
+	byEmail   map[string]string
+}
+
+func NewCustomerService() *CustomerService {
+	return &CustomerService{
+		customers: make(map[string]*customer.Customer),
+		byEmail:   make(map[string]string),
+	}
+}
+
+// ParseSeed parses a comma separated "id:email[:name]" list such as
+// "cus_alice:alice@example.com:Alice".
+func ParseSeed(spec string) ([]*customer.CreateCustomerRequest, error) {
+	var reqs []*customer.CreateCustomerRequest
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid customer entry %q, expected id:email[:name]", entry)
+		}
+		req := &customer.CreateCustomerRequest{ID: parts[0], Email: parts[1]}
+		if len(parts) == 3 {
+			req.Name = parts[2]
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
+
+func (s *CustomerService) CreateCustomer(ctx context.Context, req *customer.CreateCustomerRequest) (*customer.Customer, error) {
+	now := time.Now()
+	c := &customer.Customer{
+		ID:                 req.ID,
+		Email:              strings.TrimSpace(req.Email),
+		Name:               req.Name,
+		Phone:              req.Phone,
+		Addresses:          cloneAddresses(req.Addresses),
+		PaymentPreferences: normalizePreferences(req.PaymentPreferences),
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	if c.ID == "" {
+		c.ID = "cus_" + uuid.New().String()[:8]
+	}
+	if err := validate(c); err != nil {
+		return nil, err
+	}
+	markDefaultAddress(c.Addresses)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.customers[c.ID]; ok {
+		return nil, ErrCustomerExists
+	}
+	if _, ok := s.byEmail[emailKey(c.Email)]; ok {
+		return nil, ErrEmailTaken
+	}
+	s.customers[c.ID] = c
+	s.byEmail[emailKey(c.Email)] = c.ID
+	return cloneCustomer(c), nil
+}
+
+func (s *CustomerService) GetCustomer(ctx context.Context, req *customer.GetCustomerRequest) (*customer.Customer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.customers[req.CustomerID]
+	if !ok {
+		return nil, ErrCustomerNotFound
+	}
+	return cloneCustomer(c), nil
+}
+
+func (s *CustomerService) UpdateCustomer(ctx context.Context, req *customer.UpdateCustomerRequest) (*customer.Customer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.customers[req.CustomerID]
+	if !ok {
+		return nil, ErrCustomerNotFound
+	}
+
+	c := cloneCustomer(current)
+	if email := strings.TrimSpace(req.Email); email != "" {
+		c.Email = email
+	}
+	if req.Name != "" {
+		c.Name = req.Name
+	}
+	if req.Phone != "" {
+		c.Phone = req.Phone
+	}
+	if len(req.Addresses) > 0 {
+		c.Addresses = cloneAddresses(req.Addresses)
+	}
+	if req.PaymentPreferences != nil {
+		c.PaymentPreferences = normalizePreferences(req.PaymentPreferences)
+	}
+	if err := validate(c); err != nil {
+		return nil, err
+	}
+	markDefaultAddress(c.Addresses)
+
+	if owner, ok := s.byEmail[emailKey(c.Email)]; ok && owner != c.ID {
+		return nil, ErrEmailTaken
+	}
+	delete(s.byEmail, emailKey(current.Email))
+	s.byEmail[emailKey(c.Email)] = c.ID
+
+	c.UpdatedAt = time.Now()
+	s.customers[c.ID] = c
+	return cloneCustomer(c), nil
+}
+
+// ListCustomers returns the customers sorted by ID. A non-empty email
+// returns at most the one customer with that address.
+func (s *CustomerService) ListCustomers(ctx context.Context, req *customer.ListCustomersRequest) (*customer.ListCustomersResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resp := &customer.ListCustomersResponse{}
+	if req.Email != "" {
+		if id, ok := s.byEmail[emailKey(req.Email)]; ok {
+			resp.Customers = append(resp.Customers, cloneCustomer(s.customers[id]))
+		}
+		return resp, nil
+	}
+
+	for _, c := range s.customers {
+		resp.Customers = append(resp.Customers, cloneCustomer(c))
+	}
+	sort.Slice(resp.Customers, func(i, j int) bool {
+		return resp.Customers[i].ID < resp.Customers[j].ID
+	})
+	return resp, nil
+}
+
+// Count returns the number of stored customers.
+func (s *CustomerService) Count() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.customers)
+}
+
+func validate(c *customer.Customer) error {
+	verr := &ValidationError{}
+
+	if c.Email == "" {
+		verr.add("email", "is required")
+	} else if addr, err := mail.ParseAddress(c.Email); err != nil || addr.Address != c.Email {
+		verr.add("email", "is not a valid email address")
+	}
+
+	defaults := 0
+	for i, a := range c.Addresses {
+		field := fmt.Sprintf("addresses[%d]", i)
+		if a.Line1 == "" {
+			verr.add(field+".line1", "is required")
+		}
+		if a.City == "" {
+			verr.add(field+".city", "is required")
+		}
+		if len(a.Country) != 2 {
+			verr.add(field+".country", "must be an ISO 3166-1 alpha-2 code")
+		}
+		if a.Default {
+			defaults++
+		}
+	}
+	if defaults > 1 {
+		verr.add("addresses", "must have at most one default address")
+	}
+
+	if p := c.PaymentPreferences; p != nil {
+		if p.Method != "" && !slices.Contains(PaymentMethods, p.Method) {
+			verr.add("payment_preferences.method", "must be one of %s", strings.Join(PaymentMethods, ", "))
+		}
+		if p.Currency != "" && len(p.Currency) != 3 {
+			verr.add("payment_preferences.currency", "must be an ISO 4217 code")
+		}
+	}
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// markDefaultAddress makes the first address the default when none is.
+func markDefaultAddress(addresses []*customer.Address) {
+	for _, a := range addresses {
+		if a.Default {
+			return
+		}
+	}
+	if len(addresses) > 0 {
+		addresses[0].Default = true
+	}
+}
+
+func normalizePreferences(p *customer.PaymentPreferences) *customer.PaymentPreferences {
+	if p == nil {
+		return nil
+	}
+	return &customer.PaymentPreferences{
+		Method:   strings.ToLower(p.Method),
+		Currency: strings.ToUpper(p.Currency),
+	}
+}
+
+func emailKey(email string) string {
+	return strings.ToLower(email)
+}
+
+func cloneAddresses(addresses []*customer.Address) []*customer.Address {
+	out := make([]*customer.Address, len(addresses))
+	for i, a := range addresses {
+		out[i] = &customer.Address{
+			Label:      a.Label,
+			Line1:      a.Line1,
+			Line2:      a.Line2,
+			City:       a.City,
+			State:      a.State,
+			PostalCode: a.PostalCode,
+			Country:    strings.ToUpper(a.Country),
+			Default:    a.Default,
+		}
+	}
+	return out
+}
+
+func cloneCustomer(c *customer.Customer) *customer.Customer {
+	return &customer.Customer{
+		ID:                 c.ID,
+		Email:              c.Email,
+		Name:               c.Name,
+		Phone:              c.Phone,
+		Addresses:          cloneAddresses(c.Addresses),
+		PaymentPreferences: normalizePreferences(c.PaymentPreferences),
+		CreatedAt:          c.CreatedAt,
+		UpdatedAt:          c.UpdatedAt,
+	}
+}
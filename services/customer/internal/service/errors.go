@@ -0,0 +1,41 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrCustomerNotFound is returned when a customer doesn't exist
+	ErrCustomerNotFound = errors.New("customer not found")
+
+	// ErrCustomerExists is returned when creating a customer with an ID in use
+	ErrCustomerExists = errors.New("customer already exists")
+
+	// ErrEmailTaken is returned when another customer has the email
+	ErrEmailTaken = errors.New("email belongs to another customer")
+)
+
+// FieldError describes one invalid field of a request
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError is returned when a profile has one or more invalid fields
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + " " + f.Message
+	}
+	return "invalid customer: " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) add(field, format string, args ...any) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/config"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/ratelimit"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/gateway/internal/gateway"
+)
+
+// Config holds the startup settings of the API gateway.
+type Config struct {
+	HTTP config.HTTPServer `config:"http"`
+
+	OrderURL        string `config:"order-url" usage:"Order Service base URL"`
+	ShippingURL     string `config:"shipping-url" usage:"Shipping Service HTTP URL, empty disables /api/shipments"`
+	NotificationURL string `config:"notification-url" usage:"Notification Service HTTP URL, empty disables /api/deliveries"`
+	Routes          string `config:"routes" usage:"Additional comma separated /api/prefix=url routes"`
+
+	PaymentAddr    string        `config:"payment-addr" usage:"Payment service gRPC address, comma separated addresses or a dns:/// target balanced round robin, empty disables /api/payments"`
+	PaymentToken   string        `config:"payment-token,secret" usage:"API key or JWT sent to the payment service"`
+	PaymentTimeout time.Duration `config:"payment-timeout" usage:"Deadline for transcoded payment calls"`
+
+	APIKeys     string `config:"api-keys,secret" usage:"Comma separated key:name[:role|role] entries accepted as credentials"`
+	JWTSecret   string `config:"jwt-secret,secret" usage:"HMAC secret for JWT validation"`
+	JWKSURL     string `config:"jwks-url" usage:"JWKS URL for RSA/ECDSA signed JWTs"`
+	JWTIssuer   string `config:"jwt-issuer" usage:"Required JWT issuer"`
+	JWTAudience string `config:"jwt-audience" usage:"Required JWT audience"`
+
+	RateLimit ratelimit.Config `config:",inline"`
+	Log       logging.Config   `config:"log"`
+}
+
+func defaultConfig() Config {
+	httpCfg := config.DefaultHTTPServer(8000)
+	httpCfg.WriteTimeout = 30 * time.Second
+
+	return Config{
+		HTTP:            httpCfg,
+		OrderURL:        "http://localhost:8080",
+		ShippingURL:     "http://localhost:8082",
+		NotificationURL: "http://localhost:8083",
+		PaymentAddr:     "localhost:50051",
+		PaymentTimeout:  5 * time.Second,
+		RateLimit:       ratelimit.DefaultConfig(),
+		Log:             logging.DefaultConfig(),
+	}
+}
+
+func (c Config) Validate() error {
+	if _, err := gateway.ParseRoutes(c.Routes); err != nil {
+		return fmt.Errorf("routes: %w", err)
+	}
+	if _, err := auth.ParseStaticKeys(c.APIKeys); err != nil {
+		return fmt.Errorf("api-keys: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	_ "github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/codec"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/config"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcconn"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/ratelimit"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/reqmeta"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/gateway/internal/gateway"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func main() {
+	cfg := defaultConfig()
+	loader := config.Register(flag.CommandLine, "gateway", &cfg)
+	flag.Parse()
+	if err := loader.Load(); err != nil {
+		logging.Fatal("invalid configuration", logging.Err(err))
+	}
+	limitCfg := cfg.RateLimit
+
+	if err := logging.Setup("gateway", cfg.Log); err != nil {
+		logging.Fatal("invalid logging configuration", logging.Err(err))
+	}
+	slog.Info("starting api gateway", "port", cfg.HTTP.Port)
+
+	routes, err := gateway.ParseRoutes(cfg.Routes)
+	if err != nil {
+		logging.Fatal("invalid routes", logging.Err(err))
+	}
+	for _, r := range []struct{ name, prefix, url string }{
+		{"order", "/api/orders", cfg.OrderURL},
+		{"shipping", "/api/shipments", cfg.ShippingURL},
+		{"notification", "/api/deliveries", cfg.NotificationURL},
+	} {
+		if r.url == "" {
+			continue
+		}
+		route, err := gateway.NewRoute(r.name, r.prefix, r.url)
+		if err != nil {
+			logging.Fatal("invalid route", "route", r.name, logging.Err(err))
+		}
+		routes = append(routes, route)
+	}
+
+	var opts []gateway.Option
+
+	if cfg.PaymentAddr != "" {
+		paymentTarget, dialOpts := grpcconn.Target(cfg.PaymentAddr)
+		dialOpts = append(dialOpts, grpcconn.BalancingOptions(grpcconn.DefaultBalancingConfig(), "payment.PaymentService")...)
+		paymentConn, err := grpc.NewClient(paymentTarget, append(append(dialOpts,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithDefaultCallOptions(grpc.CallContentSubtype("json")),
+			grpc.WithChainUnaryInterceptor(
+				reqmeta.UnaryClientInterceptor(),
+				auth.UnaryClientInterceptor(cfg.PaymentToken),
+			),
+		), grpcconn.DialOptions(grpcconn.DefaultClientConfig())...)...)
+		if err != nil {
+			logging.Fatal("failed to connect to payment service", logging.Err(err))
+		}
+		defer paymentConn.Close()
+
+		connCtx, stopConnLog := context.WithCancel(context.Background())
+		defer stopConnLog()
+		go grpcconn.LogStateChanges(connCtx, paymentConn, "payment")
+
+		paymentRoutes := gateway.NewPaymentRoutes(payment.NewPaymentServiceClient(paymentConn), cfg.PaymentTimeout)
+		opts = append(opts, gateway.WithPayments(paymentRoutes))
+		slog.Info("payment service transcoded under /api/payments", "addr", cfg.PaymentAddr)
+	}
+
+	authn, err := buildAuthenticator(cfg.APIKeys, cfg.JWTSecret, cfg.JWKSURL, cfg.JWTIssuer, cfg.JWTAudience)
+	if err != nil {
+		logging.Fatal("invalid auth configuration", logging.Err(err))
+	}
+	if authn != nil {
+		opts = append(opts, gateway.WithAuthenticator(authn))
+		slog.Info("authentication enabled")
+	} else {
+		slog.Info("authentication disabled")
+	}
+
+	if limitCfg.Rate > 0 {
+		opts = append(opts, gateway.WithRateLimiter(ratelimit.NewLimiter(limitCfg)))
+		slog.Info("rate limit enabled", "rate", limitCfg.Rate, "burst", limitCfg.Burst)
+	}
+
+	gw := gateway.New(routes, opts...)
+
+	server := cfg.HTTP.Server(gw.Handler())
+
+	go func() {
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+		<-sigChan
+		slog.Info("shutting down")
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	slog.Info("api gateway ready", "addr", server.Addr)
+
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		logging.Fatal("http server error", logging.Err(err))
+	}
+}
+
+func buildAuthenticator(apiKeys, jwtSecret, jwksURL, jwtIssuer, jwtAudience string) (auth.Authenticator, error) {
+	var chain auth.Chain
+
+	if apiKeys != "" {
+		keys, err := auth.ParseStaticKeys(apiKeys)
+		if err != nil {
+			return nil, err
+		}
+		if keys.Len() > 0 {
+			chain = append(chain, keys)
+		}
+	}
+
+	var jwtOpts []auth.JWTOption
+	if jwtAudience != "" {
+		jwtOpts = append(jwtOpts, auth.WithAudience(jwtAudience))
+	}
+	if jwtSecret != "" {
+		chain = append(chain, auth.NewJWTValidator([]byte(jwtSecret), jwtIssuer, jwtOpts...))
+	}
+	if jwksURL != "" {
+		chain = append(chain, auth.NewJWKSValidator(jwksURL, jwtIssuer, jwtOpts...))
+	}
+
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	return chain, nil
+}
@@ -0,0 +1,73 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorResponse is the body of every error the gateway produces itself, so
+// clients see one format regardless of which service failed.
+type ErrorResponse struct {
+	Error     string                  `json:"error"`
+	Code      string                  `json:"code"`
+	Reason    string                  `json:"reason,omitempty"`
+	Metadata  map[string]string       `json:"metadata,omitempty"`
+	Fields    []grpcmw.FieldViolation `json:"fields,omitempty"`
+	RequestID string                  `json:"request_id,omitempty"`
+}
+
+// Error codes used for failures that do not come from a gRPC status.
+const (
+	CodeNotFound           = "NOT_FOUND"
+	CodeUnauthenticated    = "UNAUTHENTICATED"
+	CodePermissionDenied   = "PERMISSION_DENIED"
+	CodeRateLimited        = "RESOURCE_EXHAUSTED"
+	CodeInvalidArgument    = "INVALID_ARGUMENT"
+	CodeBadGateway         = "BAD_GATEWAY"
+	CodeInternal           = "INTERNAL"
+	CodeServiceUnavailable = "UNAVAILABLE"
+)
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	writeJSON(w, status, ErrorResponse{
+		Error:     message,
+		Code:      code,
+		RequestID: RequestIDFromRequest(r),
+	})
+}
+
+// writeGRPCError translates a gRPC status into an HTTP response, keeping the
+// ErrorInfo reason and field violations the upstream attached.
+func writeGRPCError(w http.ResponseWriter, r *http.Request, err error) {
+	st := status.Convert(err)
+	resp := ErrorResponse{
+		Error:     st.Message(),
+		Code:      grpcmw.CodeName(st.Code()),
+		Fields:    grpcmw.FieldViolations(err),
+		RequestID: RequestIDFromRequest(r),
+	}
+	if info, ok := grpcmw.Reason(err); ok {
+		resp.Reason = info.Reason
+		resp.Metadata = info.Metadata
+	}
+
+	httpStatus := grpcmw.HTTPStatus(st.Code())
+	if httpStatus == http.StatusServiceUnavailable {
+		w.Header().Set("Retry-After", "1")
+	}
+	writeJSON(w, httpStatus, resp)
+}
+
+func retryAfterSeconds(w http.ResponseWriter, seconds float64) {
+	w.Header().Set("Retry-After", strconv.Itoa(max(1, int(seconds+0.999))))
+}
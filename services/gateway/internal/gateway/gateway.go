@@ -0,0 +1,97 @@
+// Package gateway implements the HTTP entry point in front of the services:
+// path based routing to HTTP upstreams, JSON transcoding for gRPC-only
+// services, authentication, rate limiting and request IDs.
+package gateway
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/ratelimit"
+)
+
+// Gateway routes /api requests to the services behind it.
+type Gateway struct {
+	mux     *http.ServeMux
+	routes  []Route
+	authn   auth.Authenticator
+	limiter *ratelimit.Limiter
+}
+
+type Option func(*Gateway)
+
+// WithAuthenticator requires credentials on every route except /health.
+func WithAuthenticator(authn auth.Authenticator) Option {
+	return func(g *Gateway) {
+		g.authn = authn
+	}
+}
+
+// WithRateLimiter limits requests per principal, or per remote address for
+// unauthenticated clients.
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(g *Gateway) {
+		g.limiter = limiter
+	}
+}
+
+// WithPayments serves the payment routes through gRPC transcoding.
+func WithPayments(p *PaymentRoutes) Option {
+	return func(g *Gateway) {
+		p.RegisterRoutes(g.mux)
+	}
+}
+
+func New(routes []Route, opts ...Option) *Gateway {
+	g := &Gateway{mux: http.NewServeMux()}
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	// Longer prefixes first, so the log lists the most specific routes at
+	// the top; ServeMux picks the longest match regardless.
+	sort.SliceStable(routes, func(i, j int) bool { return len(routes[i].Prefix) > len(routes[j].Prefix) })
+	for _, route := range routes {
+		var h http.Handler = newProxy(route)
+		if route.AdminOnly {
+			h = RequireRole(auth.RoleAdmin, h)
+		}
+		g.mux.Handle(route.Prefix, h)
+		g.mux.Handle(route.Prefix+"/", h)
+		g.routes = append(g.routes, route)
+		logger.Info("route registered", "prefix", route.Prefix, "upstream", route.Upstream.String(), "route", route.Name)
+	}
+
+	g.mux.HandleFunc("GET /health", g.handleHealth)
+	g.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "No route for "+r.URL.Path)
+	})
+	return g
+}
+
+// Handler returns the gateway with its middleware. Request IDs are assigned
+// and requests logged first so every error, including authentication
+// failures, carries one.
+func (g *Gateway) Handler() http.Handler {
+	var h http.Handler = g.mux
+	if g.limiter != nil {
+		h = RateLimit(g.limiter, "/health")(h)
+	}
+	h = Tenant(h)
+	h = Authenticate(g.authn, "/health")(h)
+	return logging.Middleware(Recovery(h))
+}
+
+func (g *Gateway) handleHealth(w http.ResponseWriter, r *http.Request) {
+	routes := make(map[string]string, len(g.routes))
+	for _, route := range g.routes {
+		routes[route.Prefix] = route.Upstream.String()
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":  "healthy",
+		"service": "gateway",
+		"routes":  routes,
+	})
+}
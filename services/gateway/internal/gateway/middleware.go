@@ -0,0 +1,129 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/ratelimit"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+)
+
+var logger = logging.Component("gateway")
+
+// RequestIDFromRequest returns the ID assigned by logging.Middleware, or an
+// empty string for requests that did not pass through it.
+func RequestIDFromRequest(r *http.Request) string {
+	return logging.RequestID(r.Context())
+}
+
+// Authenticate rejects requests without valid credentials and attaches the
+// principal to the context. A nil authenticator lets every request through.
+func Authenticate(authn auth.Authenticator, exempt ...string) func(http.Handler) http.Handler {
+	skip := make(map[string]bool, len(exempt))
+	for _, p := range exempt {
+		skip[p] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		if authn == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skip[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token := auth.TokenFromRequest(r)
+			if token == "" {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, r, http.StatusUnauthorized, CodeUnauthenticated, "Missing credentials")
+				return
+			}
+			principal, err := authn.Authenticate(r.Context(), token)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, r, http.StatusUnauthorized, CodeUnauthenticated, "Invalid credentials")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), principal)))
+		})
+	}
+}
+
+// Tenant resolves the tenant of the request from its header and principal,
+// so transcoded calls carry it upstream. Proxied requests keep the header.
+func Tenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := tenant.Resolve(r.Context(), r.Header.Get(tenant.Header))
+		switch {
+		case errors.Is(err, tenant.ErrForbiddenTenant):
+			writeError(w, r, http.StatusForbidden, CodePermissionDenied, "Tenant not allowed for these credentials")
+			return
+		case err != nil:
+			writeError(w, r, http.StatusBadRequest, CodeInvalidArgument, "Invalid "+tenant.Header+" header")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(tenant.NewContext(r.Context(), id)))
+	})
+}
+
+// RequireRole answers 403 unless the principal has role. Requests without a
+// principal pass, since they only occur when authentication is disabled.
+func RequireRole(role string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := auth.FromContext(r.Context()); ok && !p.HasRole(role) {
+			writeError(w, r, http.StatusForbidden, CodePermissionDenied, "Requires the "+role+" role")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RateLimit answers 429 with Retry-After once a client runs out of tokens.
+// Clients are keyed by ratelimit.HTTPClientKey.
+func RateLimit(limiter *ratelimit.Limiter, exempt ...string) func(http.Handler) http.Handler {
+	skip := make(map[string]bool, len(exempt))
+	for _, p := range exempt {
+		skip[p] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skip[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key := ratelimit.HTTPClientKey(r)
+			if ok, wait := limiter.Allow(key); !ok {
+				logger.WarnContext(r.Context(), "rate limit exceeded", "client", key, "method", r.Method, "path", r.URL.Path)
+				retryAfterSeconds(w, wait.Seconds())
+				writeError(w, r, http.StatusTooManyRequests, CodeRateLimited, "Rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Recovery answers 500 instead of dropping the connection when a handler
+// panics.
+func Recovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				logger.ErrorContext(r.Context(), "panic in handler",
+					"method", r.Method, "path", r.URL.Path, "panic", fmt.Sprint(rec), "stack", string(debug.Stack()))
+				writeError(w, r, http.StatusInternalServerError, CodeInternal, "Internal error")
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,125 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+)
+
+// maxBodyBytes bounds the JSON bodies decoded by transcoded routes.
+const maxBodyBytes = 1 << 20
+
+// PaymentRoutes exposes payment RPCs as JSON over HTTP. The payment service
+// only speaks gRPC, so each route builds the request message from the path
+// and body, calls the RPC and writes the response message as JSON.
+type PaymentRoutes struct {
+	client  payment.PaymentServiceClient
+	timeout time.Duration
+}
+
+func NewPaymentRoutes(client payment.PaymentServiceClient, timeout time.Duration) *PaymentRoutes {
+	return &PaymentRoutes{client: client, timeout: timeout}
+}
+
+// RegisterRoutes registers the payment routes. Payments are back office
+// data, so every route requires the admin role.
+func (p *PaymentRoutes) RegisterRoutes(mux *http.ServeMux) {
+	handle := func(pattern string, h http.HandlerFunc) {
+		mux.Handle(pattern, RequireRole(auth.RoleAdmin, h))
+	}
+	handle("GET /api/payments/held", p.listHeld)
+	handle("GET /api/payments/{id}", p.getStatus)
+	handle("GET /api/payments/{id}/history", p.getHistory)
+	handle("POST /api/payments/{id}/cancel", p.cancel)
+	handle("POST /api/payments/{id}/refund", p.refund)
+	handle("POST /api/payments/{id}/review", p.review)
+}
+
+func (p *PaymentRoutes) listHeld(w http.ResponseWriter, r *http.Request) {
+	p.transcode(w, r, func(ctx context.Context) (interface{}, error) {
+		return p.client.ListHeldPayments(ctx, &payment.ListHeldPaymentsRequest{})
+	})
+}
+
+func (p *PaymentRoutes) getStatus(w http.ResponseWriter, r *http.Request) {
+	p.transcode(w, r, func(ctx context.Context) (interface{}, error) {
+		return p.client.GetPaymentStatus(ctx, &payment.PaymentStatusRequest{TransactionID: r.PathValue("id")})
+	})
+}
+
+func (p *PaymentRoutes) getHistory(w http.ResponseWriter, r *http.Request) {
+	p.transcode(w, r, func(ctx context.Context) (interface{}, error) {
+		return p.client.GetTransactionHistory(ctx, &payment.TransactionHistoryRequest{TransactionID: r.PathValue("id")})
+	})
+}
+
+func (p *PaymentRoutes) cancel(w http.ResponseWriter, r *http.Request) {
+	req := &payment.CancelPaymentRequest{}
+	if !decodeBody(w, r, req) {
+		return
+	}
+	req.TransactionID = r.PathValue("id")
+	p.transcode(w, r, func(ctx context.Context) (interface{}, error) {
+		return p.client.CancelPayment(ctx, req)
+	})
+}
+
+func (p *PaymentRoutes) refund(w http.ResponseWriter, r *http.Request) {
+	req := &payment.RefundPaymentRequest{}
+	if !decodeBody(w, r, req) {
+		return
+	}
+	req.TransactionID = r.PathValue("id")
+	p.transcode(w, r, func(ctx context.Context) (interface{}, error) {
+		return p.client.RefundPayment(ctx, req)
+	})
+}
+
+// review defaults the reviewer to the authenticated subject.
+func (p *PaymentRoutes) review(w http.ResponseWriter, r *http.Request) {
+	req := &payment.ReviewPaymentRequest{}
+	if !decodeBody(w, r, req) {
+		return
+	}
+	req.TransactionID = r.PathValue("id")
+	if principal, ok := auth.FromContext(r.Context()); ok && req.Reviewer == "" {
+		req.Reviewer = principal.Subject
+	}
+	p.transcode(w, r, func(ctx context.Context) (interface{}, error) {
+		return p.client.ReviewPayment(ctx, req)
+	})
+}
+
+func (p *PaymentRoutes) transcode(w http.ResponseWriter, r *http.Request, call func(ctx context.Context) (interface{}, error)) {
+	ctx := r.Context()
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	resp, err := call(ctx)
+	if err != nil {
+		writeGRPCError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// decodeBody decodes an optional JSON body into msg. An empty body leaves
+// msg unchanged.
+func decodeBody(w http.ResponseWriter, r *http.Request, msg interface{}) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(msg); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidArgument, "Invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
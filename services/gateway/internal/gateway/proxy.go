@@ -0,0 +1,98 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+)
+
+// APIPrefix is the path prefix of all routed requests. It is removed before
+// a request is proxied, so /api/orders/123 reaches the order service as
+// /orders/123.
+const APIPrefix = "/api"
+
+// Route sends every request under Prefix to an HTTP upstream.
+type Route struct {
+	Name     string
+	Prefix   string
+	Upstream *url.URL
+
+	// AdminOnly restricts the route to principals with the admin role.
+	AdminOnly bool
+}
+
+// ParseRoutes parses comma separated prefix=url entries such as
+// "/api/catalog=http://localhost:8090". The route name is the last element
+// of the prefix.
+func ParseRoutes(s string) ([]Route, error) {
+	var routes []Route
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		prefix, raw, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("route %q: expected prefix=url", entry)
+		}
+		route, err := NewRoute(prefix[strings.LastIndex(prefix, "/")+1:], prefix, raw)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// NewRoute validates prefix and the upstream URL.
+func NewRoute(name, prefix, upstream string) (Route, error) {
+	prefix = "/" + strings.Trim(prefix, "/")
+	if !strings.HasPrefix(prefix, APIPrefix+"/") {
+		return Route{}, fmt.Errorf("route %q: prefix must start with %s/", prefix, APIPrefix)
+	}
+	u, err := url.Parse(upstream)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return Route{}, fmt.Errorf("route %q: invalid upstream %q", prefix, upstream)
+	}
+	return Route{Name: name, Prefix: prefix, Upstream: u}, nil
+}
+
+// newProxy returns a reverse proxy to the route's upstream. Client
+// credentials and X-Request-Id are passed through, so upstream services can
+// apply their own authorization and log the same request ID.
+func newProxy(route Route) http.Handler {
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.Out.URL.Path = strings.TrimPrefix(pr.In.URL.Path, APIPrefix)
+			pr.Out.URL.RawPath = ""
+			pr.SetURL(route.Upstream)
+			pr.SetXForwarded()
+		},
+		// Flush immediately so event streams are not buffered.
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if errors.Is(err, r.Context().Err()) {
+				return
+			}
+			logger.ErrorContext(r.Context(), "upstream failed", "route", route.Name,
+				"upstream", route.Upstream.Host, "method", r.Method, "path", r.URL.Path, logging.Err(err))
+			writeError(w, r, http.StatusBadGateway, CodeBadGateway, route.Name+" service unavailable")
+		},
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			// The server write timeout would otherwise end the stream.
+			if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+				logger.WarnContext(r.Context(), "cannot clear write deadline", "path", r.URL.Path, logging.Err(err))
+			}
+		}
+		proxy.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/config"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/inventory/internal/service"
+)
+
+// Config holds the startup settings of the inventory service.
+type Config struct {
+	Port    int            `config:"port" usage:"gRPC server port"`
+	Stock   string         `config:"stock" usage:"Initial stock as product_id:quantity pairs"`
+	APIKeys string         `config:"api-keys,secret" usage:"Comma separated key:name pairs accepted as credentials"`
+	Log     logging.Config `config:"log"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		Port:  50052,
+		Stock: "laptop:10,mouse:100,keyboard:50",
+		Log:   logging.DefaultConfig(),
+	}
+}
+
+func (c Config) Validate() error {
+	if err := config.CheckPort("port", c.Port); err != nil {
+		return err
+	}
+	if _, err := service.ParseStock(c.Stock); err != nil {
+		return fmt.Errorf("stock: %w", err)
+	}
+	if _, err := auth.ParseStaticKeys(c.APIKeys); err != nil {
+		return fmt.Errorf("api-keys: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	_ "github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/codec"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/config"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/reqmeta"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/inventory"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/inventory/internal/server"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/inventory/internal/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+func main() {
+	cfg := defaultConfig()
+	loader := config.Register(flag.CommandLine, "inventory", &cfg)
+	flag.Parse()
+	if err := loader.Load(); err != nil {
+		logging.Fatal("invalid configuration", logging.Err(err))
+	}
+
+	if err := logging.Setup("inventory", cfg.Log); err != nil {
+		logging.Fatal("invalid logging configuration", logging.Err(err))
+	}
+	slog.Info("starting inventory service", "port", cfg.Port)
+
+	stock, err := service.ParseStock(cfg.Stock)
+	if err != nil {
+		logging.Fatal("invalid stock", logging.Err(err))
+	}
+	slog.Info("stock loaded", "products", len(stock))
+
+	inventorySvc := service.NewInventoryService(stock)
+
+	interceptors := []grpc.UnaryServerInterceptor{
+		reqmeta.UnaryServerInterceptor(),
+		grpcmw.UnaryLoggingInterceptor(),
+		grpcmw.UnaryRecoveryInterceptor(),
+	}
+	if cfg.APIKeys != "" {
+		keys, err := auth.ParseStaticKeys(cfg.APIKeys)
+		if err != nil {
+			logging.Fatal("invalid auth configuration", logging.Err(err))
+		}
+		interceptors = append(interceptors, auth.UnaryServerInterceptor(keys, auth.HealthMethods...))
+		slog.Info("authentication enabled for inventory RPCs")
+	}
+
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+	inventory.RegisterInventoryServiceServer(grpcServer, server.NewInventoryServer(inventorySvc))
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	healthServer.SetServingStatus("inventory.InventoryService", healthpb.HealthCheckResponse_SERVING)
+
+	reflection.Register(grpcServer)
+
+	addr := fmt.Sprintf(":%d", cfg.Port)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		logging.Fatal("failed to listen", "addr", addr, logging.Err(err))
+	}
+
+	go func() {
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+		<-sigChan
+		slog.Info("shutting down")
+		healthServer.Shutdown()
+		grpcServer.GracefulStop()
+	}()
+
+	slog.Info("inventory service ready", "addr", addr)
+
+	if err := grpcServer.Serve(listener); err != nil {
+		logging.Fatal("failed to serve", logging.Err(err))
+	}
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/inventory/internal/service"
+	"google.golang.org/grpc/codes"
+)
+
+// ErrorDomain is the google.rpc.ErrorInfo domain of inventory errors.
+const ErrorDomain = "inventory.InventoryService"
+
+// Error reasons reported in google.rpc.ErrorInfo.
+const (
+	ReasonInsufficientStock   = "INSUFFICIENT_STOCK"
+	ReasonReservationNotFound = "RESERVATION_NOT_FOUND"
+)
+
+// toStatus maps service errors to gRPC status errors with machine-readable
+// details. Unknown errors are logged and reported as codes.Internal with
+// the fallback message.
+func toStatus(err error, fallback string) error {
+	var short *service.InsufficientStockError
+
+	switch {
+	case errors.As(err, &short):
+		// Metadata maps each short product to "requested/available".
+		metadata := make(map[string]string, len(short.Shortages))
+		for _, s := range short.Shortages {
+			metadata[s.ProductID] = strconv.Itoa(int(s.Requested)) + "/" + strconv.Itoa(int(s.Available))
+		}
+		return grpcmw.ErrorInfo(codes.FailedPrecondition, err.Error(), ReasonInsufficientStock, ErrorDomain, metadata)
+	case errors.Is(err, service.ErrReservationNotFound):
+		return grpcmw.ErrorInfo(codes.NotFound, err.Error(), ReasonReservationNotFound, ErrorDomain, nil)
+	case errors.Is(err, service.ErrNoItems):
+		return grpcmw.BadRequest(err.Error(), grpcmw.FieldViolation{Field: "items", Description: "must contain at least one item"})
+	case errors.Is(err, service.ErrInvalidItem):
+		return grpcmw.BadRequest(err.Error(), grpcmw.FieldViolation{Field: "items", Description: "need a product_id and a positive quantity"})
+	default:
+		logger.Error(fallback, logging.Err(err))
+		return grpcmw.ErrorInfo(codes.Internal, fallback, grpcmw.ReasonInternal, ErrorDomain, nil)
+	}
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/inventory"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/inventory/internal/service"
+)
+
+var logger = logging.Component("grpc")
+
+type InventoryServer struct {
+	inventory.UnimplementedInventoryServiceServer
+	svc *service.InventoryService
+}
+
+func NewInventoryServer(svc *service.InventoryService) *InventoryServer {
+	return &InventoryServer{svc: svc}
+}
+
+func (s *InventoryServer) CheckStock(ctx context.Context, req *inventory.CheckStockRequest) (*inventory.CheckStockResponse, error) {
+	logger.DebugContext(ctx, "CheckStock", "items", len(req.Items))
+
+	resp, err := s.svc.CheckStock(ctx, req)
+	if err != nil {
+		return nil, toStatus(err, "failed to check stock")
+	}
+	return resp, nil
+}
+
+func (s *InventoryServer) ReserveStock(ctx context.Context, req *inventory.ReserveStockRequest) (*inventory.Reservation, error) {
+	ctx = logging.WithAttrs(ctx, "order_id", req.OrderID)
+	logger.InfoContext(ctx, "ReserveStock", "items", len(req.Items))
+
+	if req.OrderID == "" {
+		return nil, grpcmw.Required("order_id")
+	}
+
+	reservation, err := s.svc.ReserveStock(ctx, req)
+	if err != nil {
+		return nil, toStatus(err, "failed to reserve stock")
+	}
+	return reservation, nil
+}
+
+func (s *InventoryServer) ReleaseReservation(ctx context.Context, req *inventory.ReleaseReservationRequest) (*inventory.Reservation, error) {
+	logger.InfoContext(ctx, "ReleaseReservation", "reservation_id", req.ReservationID, "reason", req.Reason)
+
+	if req.ReservationID == "" {
+		return nil, grpcmw.Required("reservation_id")
+	}
+
+	reservation, err := s.svc.ReleaseReservation(ctx, req)
+	if err != nil {
+		return nil, toStatus(err, "failed to release reservation")
+	}
+	return reservation, nil
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrReservationNotFound is returned when a reservation doesn't exist
+	ErrReservationNotFound = errors.New("reservation not found")
+
+	// ErrNoItems is returned when a request lists no items
+	ErrNoItems = errors.New("at least one item is required")
+
+	// ErrInvalidItem is returned for items without a product or with a non-positive quantity
+	ErrInvalidItem = errors.New("items need a product_id and a positive quantity")
+)
+
+// Shortage is a product that cannot cover the requested quantity
+type Shortage struct {
+	ProductID string
+	Requested int32
+	Available int32
+}
+
+// InsufficientStockError is returned when a reservation cannot be covered
+type InsufficientStockError struct {
+	Shortages []Shortage
+}
+
+func (e *InsufficientStockError) Error() string {
+	parts := make([]string, len(e.Shortages))
+	for i, s := range e.Shortages {
+		parts[i] = fmt.Sprintf("%s (requested %d, available %d)", s.ProductID, s.Requested, s.Available)
+	}
+	return "insufficient stock: " + strings.Join(parts, ", ")
+}
+
+// IsInsufficientStock checks if an error is an insufficient stock error
+func IsInsufficientStock(err error) bool {
+	var target *InsufficientStockError
+	return errors.As(err, &target)
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/inventory"
+	"github.com/google/uuid"
+)
+
+var logger = logging.Component("inventory")
+
+type stockLevel struct {
+	onHand   int32
+	reserved int32
+}
+
+func (l *stockLevel) available() int32 {
+	return l.onHand - l.reserved
+}
+
+// InventoryService keeps stock levels in memory. A reservation moves
+// quantities from available to reserved until it is released.
+type InventoryService struct {
+	mu           sync.Mutex
+	stock        map[string]*stockLevel
+	reservations map[string]*inventory.Reservation
+	byOrder      map[string]string
+}
+
+// NewInventoryService starts with the given on-hand quantities per product.
+func NewInventoryService(stock map[string]int32) *InventoryService {
+	s := &InventoryService{
+		stock:        make(map[string]*stockLevel, len(stock)),
+		reservations: make(map[string]*inventory.Reservation),
+		byOrder:      make(map[string]string),
+	}
+	for product, qty := range stock {
+		s.stock[product] = &stockLevel{onHand: qty}
+	}
+	return s
+}
+
+// ParseStock parses a comma separated "product_id:quantity" list such as
+// "laptop:10,mouse:50".
+func ParseStock(spec string) (map[string]int32, error) {
+	stock := make(map[string]int32)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		product, qty, ok := strings.Cut(entry, ":")
+		n, err := strconv.ParseInt(qty, 10, 32)
+		if !ok || product == "" || err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid stock entry %q, expected product_id:quantity", entry)
+		}
+		stock[product] = int32(n)
+	}
+	return stock, nil
+}
+
+// totals merges repeated products so that each is checked once.
+func totals(items []*inventory.StockItem) (map[string]int32, error) {
+	if len(items) == 0 {
+		return nil, ErrNoItems
+	}
+	out := make(map[string]int32, len(items))
+	for _, item := range items {
+		if item.GetProductID() == "" || item.GetQuantity() <= 0 {
+			return nil, ErrInvalidItem
+		}
+		out[item.ProductID] += item.Quantity
+	}
+	return out, nil
+}
+
+func sortedProducts(m map[string]int32) []string {
+	products := make([]string, 0, len(m))
+	for p := range m {
+		products = append(products, p)
+	}
+	sort.Strings(products)
+	return products
+}
+
+func (s *InventoryService) availableLocked(product string) int32 {
+	if l, ok := s.stock[product]; ok {
+		return l.available()
+	}
+	return 0
+}
+
+func (s *InventoryService) CheckStock(ctx context.Context, req *inventory.CheckStockRequest) (*inventory.CheckStockResponse, error) {
+	requested, err := totals(req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := &inventory.CheckStockResponse{Available: true}
+	for _, product := range sortedProducts(requested) {
+		level := &inventory.StockLevel{
+			ProductID: product,
+			Requested: requested[product],
+			Available: s.availableLocked(product),
+		}
+		if level.Available < level.Requested {
+			resp.Available = false
+		}
+		resp.Levels = append(resp.Levels, level)
+	}
+	return resp, nil
+}
+
+// ReserveStock holds every item for the order or fails with an
+// InsufficientStockError listing the short products. A second call for an
+// order with an active reservation returns that reservation.
+func (s *InventoryService) ReserveStock(ctx context.Context, req *inventory.ReserveStockRequest) (*inventory.Reservation, error) {
+	requested, err := totals(req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id, ok := s.byOrder[req.OrderID]; ok {
+		if r := s.reservations[id]; r.Status == inventory.ReservationStatus_RESERVATION_STATUS_ACTIVE {
+			return cloneReservation(r), nil
+		}
+	}
+
+	var shortages []Shortage
+	for _, product := range sortedProducts(requested) {
+		if available := s.availableLocked(product); available < requested[product] {
+			shortages = append(shortages, Shortage{ProductID: product, Requested: requested[product], Available: available})
+		}
+	}
+	if len(shortages) > 0 {
+		err := &InsufficientStockError{Shortages: shortages}
+		logger.InfoContext(ctx, "reservation refused", "order_id", req.OrderID, logging.Err(err))
+		return nil, err
+	}
+
+	r := &inventory.Reservation{
+		ReservationID: "res_" + uuid.New().String()[:8],
+		OrderID:       req.OrderID,
+		Status:        inventory.ReservationStatus_RESERVATION_STATUS_ACTIVE,
+		CreatedAt:     time.Now(),
+	}
+	for _, product := range sortedProducts(requested) {
+		s.stock[product].reserved += requested[product]
+		r.Items = append(r.Items, &inventory.StockItem{ProductID: product, Quantity: requested[product]})
+	}
+	s.reservations[r.ReservationID] = r
+	if req.OrderID != "" {
+		s.byOrder[req.OrderID] = r.ReservationID
+	}
+
+	logger.InfoContext(ctx, "stock reserved",
+		"order_id", req.OrderID,
+		"reservation_id", r.ReservationID,
+		"products", len(r.Items))
+	return cloneReservation(r), nil
+}
+
+// ReleaseReservation returns the reserved quantities. Releasing twice is a
+// no-op that returns the released reservation.
+func (s *InventoryService) ReleaseReservation(ctx context.Context, req *inventory.ReleaseReservationRequest) (*inventory.Reservation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.reservations[req.ReservationID]
+	if !ok {
+		return nil, ErrReservationNotFound
+	}
+	if r.Status == inventory.ReservationStatus_RESERVATION_STATUS_RELEASED {
+		return cloneReservation(r), nil
+	}
+
+	for _, item := range r.Items {
+		s.stock[item.ProductID].reserved -= item.Quantity
+	}
+	r.Status = inventory.ReservationStatus_RESERVATION_STATUS_RELEASED
+	r.Reason = req.Reason
+	r.ReleasedAt = time.Now()
+
+	logger.InfoContext(ctx, "reservation released",
+		"order_id", r.OrderID,
+		"reservation_id", r.ReservationID,
+		"reason", req.Reason)
+	return cloneReservation(r), nil
+}
+
+func cloneReservation(r *inventory.Reservation) *inventory.Reservation {
+	return &inventory.Reservation{
+		ReservationID: r.ReservationID,
+		OrderID:       r.OrderID,
+		Items:         append([]*inventory.StockItem(nil), r.Items...),
+		Status:        r.Status,
+		Reason:        r.Reason,
+		CreatedAt:     r.CreatedAt,
+		ReleasedAt:    r.ReleasedAt,
+	}
+}
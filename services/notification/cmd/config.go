@@ -0,0 +1,235 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/config"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/notification/internal/notifier"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/notification/internal/service"
+)
+
+// Config holds the startup settings of the notification service.
+type Config struct {
+	HTTP       config.HTTPServer `config:"http"`
+	OrderURL   string            `config:"order-url" usage:"Order Service base URL"`
+	OrderToken string            `config:"order-token,secret" usage:"Admin API key or JWT for the Order Service"`
+
+	Channels        []string      `config:"channels" usage:"Comma separated channels: email, sms, webhook"`
+	EmailFrom       string        `config:"email-from" usage:"Sender address of notification emails"`
+	WebhookURL      string        `config:"webhook-url" usage:"URL that receives webhook notifications"`
+	WebhookTimeout  time.Duration `config:"webhook-timeout" usage:"Timeout of each webhook request"`
+	EmailAttempts   int           `config:"email-attempts" usage:"Delivery attempts per email notification, including the first"`
+	SMSAttempts     int           `config:"sms-attempts" usage:"Delivery attempts per sms notification, including the first"`
+	WebhookAttempts int           `config:"webhook-attempts" usage:"Delivery attempts per webhook notification, including the first"`
+	DeliveryLogSize int           `config:"delivery-log-size" usage:"Number of deliveries kept for GET /deliveries"`
+
+	// EmailProvider and SMSProvider pick who delivers each channel; log
+	// only logs the messages.
+	EmailProvider   string                  `config:"email-provider" usage:"Provider of the email channel: log, smtp or sendgrid"`
+	SMSProvider     string                  `config:"sms-provider" usage:"Provider of the sms channel: log or twilio"`
+	SMTP            notifier.SMTPConfig     `config:"smtp"`
+	SendGrid        notifier.SendGridConfig `config:"sendgrid"`
+	Twilio          notifier.TwilioConfig   `config:"twilio"`
+	ProviderTimeout time.Duration           `config:"provider-timeout" usage:"Timeout of each SendGrid and Twilio API request"`
+
+	// EmailRate, SMSRate and WebhookRate throttle the sends of a channel,
+	// retries included.
+	EmailRate   float64 `config:"email-rate" usage:"Emails sent per second, 0 disables throttling"`
+	SMSRate     float64 `config:"sms-rate" usage:"Text messages sent per second, 0 disables throttling"`
+	WebhookRate float64 `config:"webhook-rate" usage:"Webhook requests per second, 0 disables throttling"`
+
+	// CallbackURL is where providers reach the status callbacks of this
+	// service, and CallbackToken the token they must pass.
+	CallbackURL   string `config:"callback-url" usage:"Public base URL of this service for provider delivery-status callbacks"`
+	CallbackToken string `config:"callback-token,secret" usage:"Token provider callbacks must pass as the token query parameter"`
+
+	// TemplatesDir adds templates to, or overrides, the built-in ones;
+	// TenantLocales are tenant=locale pairs for tenants that are not
+	// written to in DefaultLocale.
+	TemplatesDir  string   `config:"templates-dir" usage:"Directory of notification templates, see LoadDir"`
+	DefaultLocale string   `config:"default-locale" usage:"Locale of notifications, such as en or pt-BR"`
+	TenantLocales []string `config:"tenant-locales" usage:"Comma separated tenant=locale pairs overriding default-locale"`
+
+	// EventDedupWindow drops order events the order stream sends more
+	// than once, as it does for a redelivered message, so customers are not notified twice.
+	EventDedupWindow time.Duration `config:"event-dedup-window" usage:"How long forwarded order event IDs are remembered to drop repeated events, 0 disables"`
+
+	// QueueAlertDepth and QueueAlertAge are the limits of the notifications
+	// queue past which a warning is logged, sampled every
+	// QueueAlertInterval.
+	QueueAlertDepth    int           `config:"queue-alert-depth" usage:"Messages waiting in the notifications queue that raise an alert, 0 disables"`
+	QueueAlertAge      time.Duration `config:"queue-alert-age" usage:"Age of the oldest waiting notification that raises an alert, 0 disables"`
+	QueueAlertInterval time.Duration `config:"queue-alert-interval" usage:"How often the notifications queue is checked against its alert thresholds"`
+
+	Broker broker.BrokerConfig `config:"broker"`
+	Log    logging.Config      `config:"log"`
+}
+
+func defaultConfig() Config {
+	policies := service.DefaultRetryPolicies()
+	return Config{
+		HTTP:               config.DefaultHTTPServer(8083),
+		OrderURL:           "http://localhost:8080",
+		Channels:           []string{notifier.ChannelEmail, notifier.ChannelSMS},
+		EmailFrom:          "orders@example.com",
+		WebhookTimeout:     5 * time.Second,
+		EmailAttempts:      policies[notifier.ChannelEmail].MaxAttempts,
+		SMSAttempts:        policies[notifier.ChannelSMS].MaxAttempts,
+		WebhookAttempts:    policies[notifier.ChannelWebhook].MaxAttempts,
+		DeliveryLogSize:    1000,
+		EmailProvider:      notifier.ProviderLog,
+		SMSProvider:        notifier.ProviderLog,
+		SendGrid:           notifier.DefaultSendGridConfig(),
+		Twilio:             notifier.DefaultTwilioConfig(),
+		ProviderTimeout:    10 * time.Second,
+		DefaultLocale:      "en",
+		EventDedupWindow:   10 * time.Minute,
+		QueueAlertDepth:    1000,
+		QueueAlertAge:      5 * time.Minute,
+		QueueAlertInterval: 15 * time.Second,
+		Broker:             broker.DefaultBrokerConfig(),
+		Log:                logging.DefaultConfig(),
+	}
+}
+
+// Attempts returns the configured delivery attempts of channel.
+func (c Config) Attempts(channel string) int {
+	switch channel {
+	case notifier.ChannelEmail:
+		return c.EmailAttempts
+	case notifier.ChannelSMS:
+		return c.SMSAttempts
+	default:
+		return c.WebhookAttempts
+	}
+}
+
+// Rate returns the configured sends per second of channel.
+func (c Config) Rate(channel string) float64 {
+	switch channel {
+	case notifier.ChannelEmail:
+		return c.EmailRate
+	case notifier.ChannelSMS:
+		return c.SMSRate
+	default:
+		return c.WebhookRate
+	}
+}
+
+// statusCallback returns the URL Twilio posts message statuses to, empty
+// without callback-url.
+func (c Config) statusCallback() string {
+	if c.CallbackURL == "" {
+		return ""
+	}
+	callback := strings.TrimRight(c.CallbackURL, "/") + "/callbacks/twilio"
+	if c.CallbackToken != "" {
+		callback += "?token=" + url.QueryEscape(c.CallbackToken)
+	}
+	return callback
+}
+
+// tenantLocales parses TenantLocales into a map of tenant ID to locale.
+func (c Config) tenantLocales() (map[string]string, error) {
+	locales := make(map[string]string, len(c.TenantLocales))
+	for _, pair := range c.TenantLocales {
+		tenantID, locale, ok := strings.Cut(pair, "=")
+		if !ok || tenantID == "" || locale == "" {
+			return nil, fmt.Errorf("tenant-locales entry %q is not tenant=locale", pair)
+		}
+		locales[tenantID] = locale
+	}
+	return locales, nil
+}
+
+func (c Config) Validate() error {
+	if c.OrderURL == "" {
+		return errors.New("order-url is required")
+	}
+	if len(c.Channels) == 0 {
+		return errors.New("no notification channels configured")
+	}
+	for _, channel := range c.Channels {
+		switch channel {
+		case notifier.ChannelEmail, notifier.ChannelSMS:
+		case notifier.ChannelWebhook:
+			if c.WebhookURL == "" {
+				return errors.New("the webhook channel needs webhook-url")
+			}
+		default:
+			return fmt.Errorf("unknown channel %q", channel)
+		}
+		if c.Attempts(channel) < 1 {
+			return fmt.Errorf("%s-attempts must be at least 1", channel)
+		}
+		if c.Rate(channel) < 0 {
+			return fmt.Errorf("%s-rate must not be negative", channel)
+		}
+	}
+	if err := c.validateProviders(); err != nil {
+		return err
+	}
+	if c.CallbackURL != "" {
+		if u, err := url.Parse(c.CallbackURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("callback-url must be an absolute URL, got %q", c.CallbackURL)
+		}
+	}
+	if c.DefaultLocale == "" {
+		return errors.New("default-locale is required")
+	}
+	if _, err := c.tenantLocales(); err != nil {
+		return err
+	}
+	if c.EventDedupWindow < 0 {
+		return errors.New("event-dedup-window must not be negative")
+	}
+	if c.QueueAlertDepth < 0 || c.QueueAlertAge < 0 {
+		return errors.New("queue-alert-depth and queue-alert-age must not be negative")
+	}
+	if c.QueueAlertInterval <= 0 {
+		return errors.New("queue-alert-interval must be positive")
+	}
+	if c.DeliveryLogSize < 1 {
+		return errors.New("delivery-log-size must be at least 1")
+	}
+	return nil
+}
+
+// validateProviders checks the providers of the email and sms channels and
+// the settings they need.
+func (c Config) validateProviders() error {
+	switch c.EmailProvider {
+	case notifier.ProviderLog:
+	case notifier.ProviderSMTP:
+		if c.SMTP.Addr == "" {
+			return errors.New("the smtp email provider needs smtp-addr")
+		}
+	case notifier.ProviderSendGrid:
+		if c.SendGrid.APIKey == "" || c.SendGrid.URL == "" {
+			return errors.New("the sendgrid email provider needs sendgrid-api-key and sendgrid-url")
+		}
+	default:
+		return fmt.Errorf("unknown email-provider %q", c.EmailProvider)
+	}
+
+	switch c.SMSProvider {
+	case notifier.ProviderLog:
+	case notifier.ProviderTwilio:
+		if c.Twilio.AccountSID == "" || c.Twilio.AuthToken == "" || c.Twilio.From == "" || c.Twilio.URL == "" {
+			return errors.New("the twilio sms provider needs twilio-account-sid, twilio-auth-token, twilio-from and twilio-url")
+		}
+	default:
+		return fmt.Errorf("unknown sms-provider %q", c.SMSProvider)
+	}
+
+	if c.ProviderTimeout <= 0 {
+		return errors.New("provider-timeout must be positive")
+	}
+	return nil
+}
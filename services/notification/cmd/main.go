@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/config"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/sse"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/notification/internal/handler"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/notification/internal/notifier"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/notification/internal/orders"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/notification/internal/service"
+)
+
+func main() {
+	cfg := defaultConfig()
+	loader := config.Register(flag.CommandLine, "notification", &cfg)
+	flag.Parse()
+	if err := loader.Load(); err != nil {
+		logging.Fatal("invalid configuration", logging.Err(err))
+	}
+
+	if err := logging.Setup("notification", cfg.Log); err != nil {
+		logging.Fatal("invalid logging configuration", logging.Err(err))
+	}
+	slog.Info("starting notification service", "http_port", cfg.HTTP.Port)
+
+	var notifiers []notifier.Notifier
+	for _, channel := range cfg.Channels {
+		n := newNotifier(cfg, channel)
+		notifiers = append(notifiers, notifier.Throttle(n, cfg.Rate(channel), max(1, int(cfg.Rate(channel)))))
+	}
+
+	policies := service.DefaultRetryPolicies()
+	opts := []service.Option{}
+	for _, n := range notifiers {
+		policy := policies[n.Channel()]
+		policy.MaxAttempts = cfg.Attempts(n.Channel())
+		opts = append(opts, service.WithRetryPolicy(n.Channel(), policy))
+		slog.Info("channel configured", "channel", n.Channel(), "provider", n.Provider(),
+			"max_attempts", policy.MaxAttempts, "rate", cfg.Rate(n.Channel()))
+	}
+
+	templates := service.DefaultTemplates()
+	templates.SetDefaultLocale("", cfg.DefaultLocale)
+	locales, _ := cfg.tenantLocales()
+	for tenantID, locale := range locales {
+		templates.SetDefaultLocale(tenantID, locale)
+	}
+	if cfg.TemplatesDir != "" {
+		loaded, err := templates.LoadDir(cfg.TemplatesDir)
+		if err != nil {
+			logging.Fatal("failed to load templates", "dir", cfg.TemplatesDir, logging.Err(err))
+		}
+		slog.Info("templates loaded", "dir", cfg.TemplatesDir, "count", loaded)
+	}
+	opts = append(opts, service.WithTemplates(templates))
+
+	orderClient := orders.NewClient(cfg.OrderURL, cfg.OrderToken)
+	opts = append(opts, service.WithOrderLookup(orderClient))
+
+	deliveries := service.NewDeliveryLog(cfg.DeliveryLogSize)
+	notificationSvc := service.NewNotificationService(deliveries, notifiers, opts...)
+
+	msgBroker := broker.NewBroker(cfg.Broker)
+	msgBroker.CreateTopic("order.created", broker.WithDedupWindow(cfg.EventDedupWindow))
+	msgBroker.CreateTopic("order.status_changed", broker.WithDedupWindow(cfg.EventDedupWindow))
+	notificationQueue := msgBroker.CreateQueue("notifications", broker.WithMaxRetries(3))
+	msgBroker.Subscribe("order.created", "notifications")
+	msgBroker.Subscribe("order.status_changed", "notifications")
+	// Return events reach the stream under their own types.
+	for _, eventType := range []string{order.EventTypeReturnApproved, order.EventTypeReturnRejected, order.EventTypeReturnRefunded} {
+		msgBroker.CreateTopic(eventType, broker.WithDedupWindow(cfg.EventDedupWindow))
+		msgBroker.Subscribe(eventType, "notifications")
+	}
+	slog.Info("message broker configured")
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	if err := msgBroker.SetAlertThresholds("notifications", cfg.QueueAlertDepth, cfg.QueueAlertAge); err != nil {
+		logging.Fatal("failed to set queue alert thresholds", logging.Err(err))
+	}
+	go msgBroker.Monitor(ctx, cfg.QueueAlertInterval)
+	go broker.NewWorker("notification-worker", notificationQueue, notificationSvc.HandleMessage).Start(ctx)
+	go orderClient.Follow(ctx, func(e sse.Event) {
+		forwardOrderEvent(ctx, msgBroker, e)
+	})
+	slog.Info("following order events", "url", cfg.OrderURL)
+
+	mux := http.NewServeMux()
+	handler.NewNotificationHandler(deliveries,
+		handler.WithTemplates(templates),
+		handler.WithCallbackToken(cfg.CallbackToken),
+	).RegisterRoutes(mux)
+	server := cfg.HTTP.Server(logging.Middleware(mux))
+
+	go func() {
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+		<-sigChan
+		slog.Info("shutting down")
+		stop()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("notification service ready", "url", fmt.Sprintf("http://localhost:%d", cfg.HTTP.Port))
+	slog.Info("endpoints: GET /deliveries, GET /templates, POST /templates/preview, POST /callbacks/sendgrid, POST /callbacks/twilio, GET /health")
+
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		logging.Fatal("HTTP server error", logging.Err(err))
+	}
+}
+
+// newNotifier returns the notifier of channel with its configured provider.
+func newNotifier(cfg Config, channel string) notifier.Notifier {
+	switch {
+	case channel == notifier.ChannelWebhook:
+		return notifier.NewWebhookNotifier(cfg.WebhookURL, cfg.WebhookTimeout)
+	case channel == notifier.ChannelSMS && cfg.SMSProvider == notifier.ProviderTwilio:
+		return notifier.NewTwilioNotifier(cfg.Twilio, cfg.statusCallback(), cfg.ProviderTimeout)
+	case channel == notifier.ChannelSMS:
+		return notifier.NewSMSNotifier()
+	case cfg.EmailProvider == notifier.ProviderSMTP:
+		return notifier.NewSMTPNotifier(cfg.SMTP, cfg.EmailFrom)
+	case cfg.EmailProvider == notifier.ProviderSendGrid:
+		return notifier.NewSendGridNotifier(cfg.SendGrid, cfg.EmailFrom, cfg.ProviderTimeout)
+	default:
+		return notifier.NewEmailNotifier(cfg.EmailFrom)
+	}
+}
+
+// forwardOrderEvent publishes an event read from the order stream on the
+// local topic of the same name.
+func forwardOrderEvent(ctx context.Context, b *broker.Broker, e sse.Event) {
+	if _, ok := b.GetTopic(e.Type); !ok {
+		return
+	}
+	msg := &broker.Message{
+		ID:        e.ID,
+		Type:      e.Type,
+		Payload:   e.Data,
+		Metadata:  map[string]string{broker.ContentTypeMetadata: broker.ContentTypeJSON},
+		Timestamp: time.Now(),
+	}
+	if err := b.Publish(ctx, e.Type, msg); err != nil {
+		slog.ErrorContext(ctx, "failed to forward order event", "event_type", e.Type, "event_id", e.ID, logging.Err(err))
+	}
+}
@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/notification/internal/notifier"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/notification/internal/service"
+)
+
+// defaultDeliveryLimit is the page size of GET /deliveries without a limit.
+const defaultDeliveryLimit = 50
+
+// maxCallbackBytes bounds the body of a provider status callback.
+const maxCallbackBytes = 1 << 20
+
+var logger = logging.Component("callbacks")
+
+// maxPreviewBytes bounds the body of a template preview request.
+const maxPreviewBytes = 1 << 16
+
+// NotificationHandler serves the delivery log and the notification
+// templates over HTTP.
+type NotificationHandler struct {
+	deliveries    *service.DeliveryLog
+	templates     *service.TemplateRegistry
+	callbackToken string
+}
+
+type Option func(*NotificationHandler)
+
+// WithCallbackToken requires token as the token query parameter of the
+// provider status callbacks.
+func WithCallbackToken(token string) Option {
+	return func(h *NotificationHandler) {
+		h.callbackToken = token
+	}
+}
+
+// WithTemplates serves templates instead of DefaultTemplates under
+// /templates.
+func WithTemplates(templates *service.TemplateRegistry) Option {
+	return func(h *NotificationHandler) {
+		h.templates = templates
+	}
+}
+
+func NewNotificationHandler(deliveries *service.DeliveryLog, opts ...Option) *NotificationHandler {
+	h := &NotificationHandler{deliveries: deliveries, templates: service.DefaultTemplates()}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+func (h *NotificationHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /deliveries", h.listDeliveries)
+	mux.HandleFunc("GET /templates", h.listTemplates)
+	mux.HandleFunc("POST /templates/preview", h.previewTemplate)
+	mux.HandleFunc("POST /callbacks/sendgrid", h.sendGridCallback)
+	mux.HandleFunc("POST /callbacks/twilio", h.twilioCallback)
+	mux.HandleFunc("/health", h.handleHealth)
+}
+
+// listDeliveries serves GET /deliveries, newest first, filtered by the
+// optional channel, status and order_id query parameters.
+func (h *NotificationHandler) listDeliveries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := service.DeliveryFilter{
+		Channel: q.Get("channel"),
+		Status:  q.Get("status"),
+		OrderID: q.Get("order_id"),
+		Limit:   defaultDeliveryLimit,
+	}
+	if s := q.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 0 {
+			respondError(w, http.StatusBadRequest, "limit must be a non-negative integer")
+			return
+		}
+		filter.Limit = limit
+	}
+	if filter.Status != "" && filter.Status != service.DeliverySent && filter.Status != service.DeliveryFailed {
+		respondError(w, http.StatusBadRequest, "status must be sent or failed")
+		return
+	}
+
+	deliveries := h.deliveries.List(filter)
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	})
+}
+
+// listTemplates serves GET /templates.
+func (h *NotificationHandler) listTemplates(w http.ResponseWriter, r *http.Request) {
+	templates := h.templates.List()
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"templates": templates,
+		"count":     len(templates),
+	})
+}
+
+type previewRequest struct {
+	Name     string                `json:"name"`
+	Locale   string                `json:"locale"`
+	TenantID string                `json:"tenant_id"`
+	Data     *service.TemplateData `json:"data"`
+}
+
+// previewTemplate serves POST /templates/preview: it renders a template
+// the way a notification would be, with sample data when none is given.
+func (h *NotificationHandler) previewTemplate(w http.ResponseWriter, r *http.Request) {
+	var req previewRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPreviewBytes)).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Name == "" {
+		respondError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	data := service.SampleData(req.Name)
+	if req.Data != nil {
+		data = *req.Data
+	}
+	if data.Order.TenantID == "" {
+		data.Order.TenantID = req.TenantID
+	}
+
+	rendered, err := h.templates.Render(req.TenantID, req.Name, req.Locale, data)
+	if errors.Is(err, service.ErrTemplateNotFound) {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, rendered)
+}
+
+// sendGridCallback serves POST /callbacks/sendgrid, the SendGrid event
+// webhook.
+func (h *NotificationHandler) sendGridCallback(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeCallback(w, r) {
+		return
+	}
+	updates, err := notifier.ParseSendGridEvents(http.MaxBytesReader(w, r.Body, maxCallbackBytes))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	matched := 0
+	for _, u := range updates {
+		if h.deliveries.UpdateStatus(u) {
+			matched++
+		}
+	}
+	respondJSON(w, http.StatusOK, map[string]int{"events": len(updates), "matched": matched})
+}
+
+// twilioCallback serves POST /callbacks/twilio, the StatusCallback of the
+// text messages sent with Twilio.
+func (h *NotificationHandler) twilioCallback(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeCallback(w, r) {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxCallbackBytes)
+	if err := r.ParseForm(); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid form")
+		return
+	}
+	u, err := notifier.ParseTwilioStatus(r.PostForm)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !h.deliveries.UpdateStatus(u) {
+		logger.WarnContext(r.Context(), "status of an unknown message", "provider", u.Provider, "message_id", u.MessageID)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorizeCallback checks the token of a callback and answers 401 when it
+// does not match.
+func (h *NotificationHandler) authorizeCallback(w http.ResponseWriter, r *http.Request) bool {
+	if h.callbackToken == "" || subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(h.callbackToken)) == 1 {
+		return true
+	}
+	respondError(w, http.StatusUnauthorized, "invalid callback token")
+	return false
+}
+
+func (h *NotificationHandler) handleHealth(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, map[string]string{
+		"status":  "healthy",
+		"service": "notification-service",
+	})
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, map[string]string{"error": message})
+}
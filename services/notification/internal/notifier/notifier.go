@@ -0,0 +1,66 @@
+// Package notifier delivers rendered notifications over a channel such as
+// email, SMS or a webhook.
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/retry"
+)
+
+// Channel names.
+const (
+	ChannelEmail   = "email"
+	ChannelSMS     = "sms"
+	ChannelWebhook = "webhook"
+)
+
+// Message is a rendered notification for one recipient.
+type Message struct {
+	Recipient string
+	Subject   string
+	Body      string
+
+	// HTML is the HTML version of Body for email, empty for text only.
+	HTML string
+
+	// EventID, EventType and OrderID identify the event that caused the
+	// message. Payload is the raw event.
+	EventID   string
+	EventType string
+	OrderID   string
+	Payload   json.RawMessage
+}
+
+// Provider names.
+const (
+	ProviderLog      = "log"
+	ProviderSMTP     = "smtp"
+	ProviderSendGrid = "sendgrid"
+	ProviderTwilio   = "twilio"
+	ProviderWebhook  = "webhook"
+)
+
+// Notifier sends messages over one channel. Send returns the ID the
+// provider gave the message, empty when it gives none, and an error
+// wrapped with Permanent when retrying cannot help.
+type Notifier interface {
+	Channel() string
+
+	// Provider names the service delivering the messages, such as smtp
+	// or twilio.
+	Provider() string
+
+	Send(ctx context.Context, msg Message) (string, error)
+}
+
+// Permanent marks err as not worth retrying.
+func Permanent(err error) error {
+	return retry.Permanent(err)
+}
+
+// IsPermanent checks if an error was marked with Permanent
+func IsPermanent(err error) bool {
+	return retry.IsPermanent(err)
+}
@@ -0,0 +1,115 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// SendGridConfig configures delivery through the SendGrid v3 mail API.
+type SendGridConfig struct {
+	APIKey string `config:"api-key,secret" usage:"SendGrid API key"`
+	URL    string `config:"url" usage:"SendGrid API base URL"`
+}
+
+func DefaultSendGridConfig() SendGridConfig {
+	return SendGridConfig{URL: "https://api.sendgrid.com"}
+}
+
+// SendGridNotifier sends email with the SendGrid mail send API. The event
+// and order IDs travel as custom arguments, so they come back with the
+// delivery events SendGrid posts to the status callback.
+type SendGridNotifier struct {
+	config SendGridConfig
+	from   string
+	client *http.Client
+}
+
+func NewSendGridNotifier(config SendGridConfig, from string, timeout time.Duration) *SendGridNotifier {
+	return &SendGridNotifier{config: config, from: from, client: &http.Client{Timeout: timeout}}
+}
+
+func (n *SendGridNotifier) Channel() string { return ChannelEmail }
+
+func (n *SendGridNotifier) Provider() string { return ProviderSendGrid }
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridMail struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+}
+
+type sendGridPersonalization struct {
+	To         []sendGridAddress `json:"to"`
+	CustomArgs map[string]string `json:"custom_args,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Send returns the X-Message-Id SendGrid answers with.
+func (n *SendGridNotifier) Send(ctx context.Context, msg Message) (string, error) {
+	if msg.Recipient == "" {
+		return "", Permanent(errors.New("no email address"))
+	}
+	sender, err := mail.ParseAddress(n.from)
+	if err != nil {
+		return "", Permanent(fmt.Errorf("invalid sender address: %w", err))
+	}
+	content := []sendGridContent{{Type: "text/plain", Value: msg.Body}}
+	if msg.HTML != "" {
+		content = append(content, sendGridContent{Type: "text/html", Value: msg.HTML})
+	}
+	body, err := json.Marshal(sendGridMail{
+		Personalizations: []sendGridPersonalization{{
+			To:         []sendGridAddress{{Email: msg.Recipient}},
+			CustomArgs: map[string]string{"event_id": msg.EventID, "order_id": msg.OrderID},
+		}},
+		From:    sendGridAddress{Email: sender.Address, Name: sender.Name},
+		Subject: msg.Subject,
+		Content: content,
+	})
+	if err != nil {
+		return "", Permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(n.config.URL, "/")+"/v3/mail/send", bytes.NewReader(body))
+	if err != nil {
+		return "", Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+n.config.APIKey)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if err := statusError("sendgrid", resp); err != nil {
+		return "", err
+	}
+
+	id := resp.Header.Get("X-Message-Id")
+	logger.InfoContext(ctx, "email sent",
+		"order_id", msg.OrderID,
+		"provider", ProviderSendGrid,
+		"to", msg.Recipient,
+		"message_id", id)
+	return id, nil
+}
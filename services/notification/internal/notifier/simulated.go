@@ -0,0 +1,57 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+)
+
+var logger = logging.Component("notifier")
+
+// EmailNotifier simulates sending email by logging it.
+type EmailNotifier struct {
+	from string
+}
+
+func NewEmailNotifier(from string) *EmailNotifier {
+	return &EmailNotifier{from: from}
+}
+
+func (n *EmailNotifier) Channel() string { return ChannelEmail }
+
+func (n *EmailNotifier) Provider() string { return ProviderLog }
+
+func (n *EmailNotifier) Send(ctx context.Context, msg Message) (string, error) {
+	if msg.Recipient == "" {
+		return "", Permanent(errors.New("no email address"))
+	}
+	logger.InfoContext(ctx, "email sent",
+		"order_id", msg.OrderID,
+		"from", n.from,
+		"to", msg.Recipient,
+		"subject", msg.Subject)
+	return "", nil
+}
+
+// SMSNotifier simulates sending text messages by logging them.
+type SMSNotifier struct{}
+
+func NewSMSNotifier() *SMSNotifier {
+	return &SMSNotifier{}
+}
+
+func (n *SMSNotifier) Channel() string { return ChannelSMS }
+
+func (n *SMSNotifier) Provider() string { return ProviderLog }
+
+func (n *SMSNotifier) Send(ctx context.Context, msg Message) (string, error) {
+	if msg.Recipient == "" {
+		return "", Permanent(errors.New("no recipient"))
+	}
+	logger.InfoContext(ctx, "sms sent",
+		"order_id", msg.OrderID,
+		"to", msg.Recipient,
+		"body", msg.Body)
+	return "", nil
+}
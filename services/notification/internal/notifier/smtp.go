@@ -0,0 +1,198 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SMTPConfig configures delivery through an SMTP relay.
+type SMTPConfig struct {
+	Addr     string `config:"addr" usage:"SMTP server host:port"`
+	Username string `config:"username" usage:"SMTP user, empty sends without authentication"`
+	Password string `config:"password,secret" usage:"SMTP password"`
+}
+
+func (c SMTPConfig) Validate() error {
+	if c.Addr == "" {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(c.Addr); err != nil {
+		return fmt.Errorf("smtp-addr must be host:port: %w", err)
+	}
+	return nil
+}
+
+// SMTPNotifier sends email through an SMTP server, upgrading the connection
+// with STARTTLS when the server offers it. 5xx replies are permanent
+// failures.
+type SMTPNotifier struct {
+	config SMTPConfig
+	from   string
+}
+
+func NewSMTPNotifier(config SMTPConfig, from string) *SMTPNotifier {
+	return &SMTPNotifier{config: config, from: from}
+}
+
+func (n *SMTPNotifier) Channel() string { return ChannelEmail }
+
+func (n *SMTPNotifier) Provider() string { return ProviderSMTP }
+
+// Send returns the Message-ID header of the email.
+func (n *SMTPNotifier) Send(ctx context.Context, msg Message) (string, error) {
+	if msg.Recipient == "" {
+		return "", Permanent(errors.New("no email address"))
+	}
+	sender, err := mail.ParseAddress(n.from)
+	if err != nil {
+		return "", Permanent(fmt.Errorf("invalid sender address: %w", err))
+	}
+	to, err := mail.ParseAddress(msg.Recipient)
+	if err != nil {
+		return "", Permanent(fmt.Errorf("invalid email address: %w", err))
+	}
+	id := messageID(sender.Address)
+	content, err := n.compose(id, to.Address, msg)
+	if err != nil {
+		return "", Permanent(err)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", n.config.Addr)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	host, _, _ := net.SplitHostPort(n.config.Addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return "", err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return "", err
+		}
+	}
+	if n.config.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", n.config.Username, n.config.Password, host)); err != nil {
+			return "", smtpError(err)
+		}
+	}
+	if err := c.Mail(sender.Address); err != nil {
+		return "", smtpError(err)
+	}
+	if err := c.Rcpt(to.Address); err != nil {
+		return "", smtpError(err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return "", smtpError(err)
+	}
+	if _, err := w.Write(content); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", smtpError(err)
+	}
+	c.Quit()
+
+	logger.InfoContext(ctx, "email sent",
+		"order_id", msg.OrderID,
+		"provider", ProviderSMTP,
+		"to", msg.Recipient,
+		"message_id", id)
+	return id, nil
+}
+
+// compose renders the headers and body of msg: quoted-printable text, or a
+// multipart/alternative of the text and HTML versions when msg has HTML.
+func (n *SMTPNotifier) compose(id, to string, msg Message) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", n.from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: %s\r\n", id)
+	if msg.EventID != "" {
+		fmt.Fprintf(&b, "X-Event-Id: %s\r\n", msg.EventID)
+	}
+	b.WriteString("MIME-Version: 1.0\r\n")
+
+	if msg.HTML == "" {
+		b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&b, msg.Body); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&b)
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", msg.Body},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, content string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// messageID returns a new Message-ID in the domain of the sender address.
+func messageID(sender string) string {
+	domain := "localhost"
+	if _, d, ok := strings.Cut(sender, "@"); ok && d != "" {
+		domain = d
+	}
+	return "<" + uuid.New().String() + "@" + domain + ">"
+}
+
+// smtpError marks permanent (5xx) SMTP replies with Permanent.
+func smtpError(err error) error {
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return Permanent(err)
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+package notifier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// StatusUpdate is a delivery status reported by a provider after Send,
+// through its status callback.
+type StatusUpdate struct {
+	Provider  string
+	MessageID string
+	Status    string
+
+	// Failed is set for statuses meaning the message will not arrive,
+	// such as a bounce.
+	Failed bool
+	Reason string
+	Time   time.Time
+}
+
+// sendGridStatuses are the SendGrid events about delivery, and whether they
+// are failures. Engagement events such as open and click are ignored.
+var sendGridStatuses = map[string]bool{
+	"processed": false,
+	"deferred":  false,
+	"delivered": false,
+	"bounce":    true,
+	"dropped":   true,
+}
+
+// ParseSendGridEvents decodes a batch of the SendGrid event webhook into the
+// updates of the delivery events it holds.
+func ParseSendGridEvents(r io.Reader) ([]StatusUpdate, error) {
+	var events []struct {
+		Event     string `json:"event"`
+		MessageID string `json:"sg_message_id"`
+		Timestamp int64  `json:"timestamp"`
+		Reason    string `json:"reason"`
+		Response  string `json:"response"`
+	}
+	if err := json.NewDecoder(r).Decode(&events); err != nil {
+		return nil, fmt.Errorf("invalid SendGrid events: %w", err)
+	}
+
+	var updates []StatusUpdate
+	for _, e := range events {
+		failed, ok := sendGridStatuses[e.Event]
+		if !ok || e.MessageID == "" {
+			continue
+		}
+		// sg_message_id is the X-Message-Id of the send request followed
+		// by a suffix per recipient.
+		id, _, _ := strings.Cut(e.MessageID, ".")
+		reason := e.Reason
+		if reason == "" {
+			reason = e.Response
+		}
+		updates = append(updates, StatusUpdate{
+			Provider:  ProviderSendGrid,
+			MessageID: id,
+			Status:    e.Event,
+			Failed:    failed,
+			Reason:    reason,
+			Time:      time.Unix(e.Timestamp, 0).UTC(),
+		})
+	}
+	return updates, nil
+}
+
+// ParseTwilioStatus reads the form Twilio posts to the StatusCallback of a
+// message.
+func ParseTwilioStatus(form url.Values) (StatusUpdate, error) {
+	sid, status := form.Get("MessageSid"), form.Get("MessageStatus")
+	if sid == "" || status == "" {
+		return StatusUpdate{}, errors.New("MessageSid and MessageStatus are required")
+	}
+	u := StatusUpdate{
+		Provider:  ProviderTwilio,
+		MessageID: sid,
+		Status:    status,
+		Failed:    status == "failed" || status == "undelivered",
+		Time:      time.Now().UTC(),
+	}
+	if code := form.Get("ErrorCode"); code != "" {
+		u.Reason = "twilio error " + code
+	}
+	return u, nil
+}
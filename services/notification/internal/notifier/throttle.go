@@ -0,0 +1,32 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/ratelimit"
+)
+
+// Throttle returns n sending at most rate messages per second, in bursts
+// of up to burst. Sends over the limit wait for their turn, or fail with
+// the error of their context. A rate of 0 returns n unchanged.
+func Throttle(n Notifier, rate float64, burst int) Notifier {
+	if rate <= 0 {
+		return n
+	}
+	return &throttled{
+		Notifier: n,
+		limiter:  ratelimit.NewLimiter(ratelimit.Config{Rate: rate, Burst: burst}),
+	}
+}
+
+type throttled struct {
+	Notifier
+	limiter *ratelimit.Limiter
+}
+
+func (t *throttled) Send(ctx context.Context, msg Message) (string, error) {
+	if err := t.limiter.Wait(ctx, t.Provider()); err != nil {
+		return "", err
+	}
+	return t.Notifier.Send(ctx, msg)
+}
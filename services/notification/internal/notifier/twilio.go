@@ -0,0 +1,92 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+)
+
+// TwilioConfig configures text messages sent with the Twilio Messages API.
+type TwilioConfig struct {
+	AccountSID string `config:"account-sid" usage:"Twilio account SID"`
+	AuthToken  string `config:"auth-token,secret" usage:"Twilio auth token"`
+	From       string `config:"from" usage:"Twilio phone number or messaging service SID text messages are sent from"`
+	URL        string `config:"url" usage:"Twilio API base URL"`
+}
+
+func DefaultTwilioConfig() TwilioConfig {
+	return TwilioConfig{URL: "https://api.twilio.com"}
+}
+
+// TwilioNotifier sends text messages through Twilio. When statusCallback
+// is set, Twilio posts every status change of a message to it.
+type TwilioNotifier struct {
+	config         TwilioConfig
+	statusCallback string
+	client         *http.Client
+}
+
+func NewTwilioNotifier(config TwilioConfig, statusCallback string, timeout time.Duration) *TwilioNotifier {
+	return &TwilioNotifier{config: config, statusCallback: statusCallback, client: &http.Client{Timeout: timeout}}
+}
+
+func (n *TwilioNotifier) Channel() string { return ChannelSMS }
+
+func (n *TwilioNotifier) Provider() string { return ProviderTwilio }
+
+// Send returns the SID of the message.
+func (n *TwilioNotifier) Send(ctx context.Context, msg Message) (string, error) {
+	if msg.Recipient == "" {
+		return "", Permanent(errors.New("no recipient"))
+	}
+	form := url.Values{"To": {msg.Recipient}, "Body": {msg.Body}}
+	if strings.HasPrefix(n.config.From, "MG") {
+		form.Set("MessagingServiceSid", n.config.From)
+	} else {
+		form.Set("From", n.config.From)
+	}
+	if n.statusCallback != "" {
+		form.Set("StatusCallback", n.statusCallback)
+	}
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json",
+		strings.TrimRight(n.config.URL, "/"), url.PathEscape(n.config.AccountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(n.config.AccountSID, n.config.AuthToken)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := statusError("twilio", resp); err != nil {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		return "", err
+	}
+
+	var created struct {
+		SID string `json:"sid"`
+	}
+	// The message was accepted: failing now would send it twice.
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&created); err != nil {
+		logger.WarnContext(ctx, "sms sent but the twilio response is unreadable", "order_id", msg.OrderID, logging.Err(err))
+	}
+	logger.InfoContext(ctx, "sms sent",
+		"order_id", msg.OrderID,
+		"provider", ProviderTwilio,
+		"to", msg.Recipient,
+		"message_id", created.SID)
+	return created.SID, nil
+}
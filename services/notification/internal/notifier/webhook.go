@@ -0,0 +1,82 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/retry"
+)
+
+// WebhookNotifier POSTs every message as JSON to a fixed URL. 4xx responses
+// are permanent failures; transport errors and 5xx responses are retried.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string, timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (n *WebhookNotifier) Channel() string { return ChannelWebhook }
+
+// webhookPayload is the body sent to the webhook URL.
+type webhookPayload struct {
+	EventID   string          `json:"event_id"`
+	EventType string          `json:"event_type"`
+	OrderID   string          `json:"order_id"`
+	Subject   string          `json:"subject"`
+	Body      string          `json:"body"`
+	HTML      string          `json:"html,omitempty"`
+	Event     json.RawMessage `json:"event,omitempty"`
+}
+
+func (n *WebhookNotifier) Provider() string { return ProviderWebhook }
+
+func (n *WebhookNotifier) Send(ctx context.Context, msg Message) (string, error) {
+	body, err := json.Marshal(webhookPayload{
+		EventID:   msg.EventID,
+		EventType: msg.EventType,
+		OrderID:   msg.OrderID,
+		Subject:   msg.Subject,
+		Body:      msg.Body,
+		HTML:      msg.HTML,
+		Event:     msg.Payload,
+	})
+	if err != nil {
+		return "", Permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return "", Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Id", msg.EventID)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	return "", statusError("webhook", resp)
+}
+
+// statusError returns nil for 2xx responses, and otherwise an error naming
+// the provider that is Permanent unless retry.TransientHTTP holds.
+func statusError(provider string, resp *http.Response) error {
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case !retry.TransientHTTP(resp.StatusCode):
+		return Permanent(fmt.Errorf("%s returned %s", provider, resp.Status))
+	default:
+		return fmt.Errorf("%s returned %s", provider, resp.Status)
+	}
+}
@@ -0,0 +1,75 @@
+// Package orders reads from the Order Service HTTP API: the order event
+// stream and single orders.
+package orders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/sse"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/notification/internal/service"
+)
+
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+	events  *sse.Stream
+}
+
+// NewClient creates a client for the order service at baseURL. A non-empty
+// token is sent as a bearer token; it needs the admin role to see the
+// orders of every customer.
+func NewClient(baseURL, token string) *Client {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &Client{
+		baseURL: baseURL,
+		token:   token,
+		http:    &http.Client{Timeout: 10 * time.Second, Transport: &logging.Transport{}},
+		events:  sse.NewStream(baseURL+"/orders/events", token),
+	}
+}
+
+// GetOrder calls GET /orders/{id}. It implements service.OrderLookup.
+func (c *Client) GetOrder(ctx context.Context, orderID string) (*order.Order, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/orders/"+url.PathEscape(orderID), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set(auth.AuthorizationHeader, "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var o order.Order
+		if err := json.NewDecoder(resp.Body).Decode(&o); err != nil {
+			return nil, err
+		}
+		return &o, nil
+	case http.StatusNotFound:
+		return nil, service.ErrOrderNotFound
+	default:
+		return nil, fmt.Errorf("GET /orders/%s: %s", orderID, resp.Status)
+	}
+}
+
+// Follow reads GET /orders/events until ctx is done, reconnecting whenever
+// the stream ends.
+func (c *Client) Follow(ctx context.Context, handle func(sse.Event)) {
+	c.events.Follow(ctx, handle)
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/notification/internal/notifier"
+)
+
+// Delivery statuses.
+const (
+	DeliverySent   = "sent"
+	DeliveryFailed = "failed"
+)
+
+// Delivery records the outcome of one notification on one channel.
+type Delivery struct {
+	ID        string    `json:"id"`
+	EventID   string    `json:"event_id"`
+	EventType string    `json:"event_type"`
+	Template  string    `json:"template"`
+	OrderID   string    `json:"order_id"`
+	Channel   string    `json:"channel"`
+	Provider  string    `json:"provider"`
+	Recipient string    `json:"recipient,omitempty"`
+	Subject   string    `json:"subject"`
+	Status    string    `json:"status"`
+	Attempts  int       `json:"attempts"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// ProviderMessageID is the ID the provider gave the message. Its
+	// status callbacks name the message by it and set ProviderStatus,
+	// such as delivered or bounce.
+	ProviderMessageID string     `json:"provider_message_id,omitempty"`
+	ProviderStatus    string     `json:"provider_status,omitempty"`
+	StatusUpdatedAt   *time.Time `json:"status_updated_at,omitempty"`
+}
+
+// DeliveryFilter selects deliveries; empty fields match everything.
+type DeliveryFilter struct {
+	Channel string
+	Status  string
+	OrderID string
+
+	// Limit caps the result; 0 returns every match.
+	Limit int
+}
+
+func (f DeliveryFilter) matches(d *Delivery) bool {
+	return (f.Channel == "" || d.Channel == f.Channel) &&
+		(f.Status == "" || d.Status == f.Status) &&
+		(f.OrderID == "" || d.OrderID == f.OrderID)
+}
+
+// DeliveryLog keeps the most recent deliveries in a ring buffer.
+type DeliveryLog struct {
+	mu      sync.Mutex
+	entries []*Delivery
+	next    int
+	full    bool
+}
+
+func NewDeliveryLog(size int) *DeliveryLog {
+	if size <= 0 {
+		size = 1000
+	}
+	return &DeliveryLog{entries: make([]*Delivery, size)}
+}
+
+func (l *DeliveryLog) Record(d Delivery) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[l.next] = &d
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// UpdateStatus records a status reported by the provider of a sent
+// delivery. A failure marks the delivery failed. Updates older than the
+// last one recorded are ignored. It reports false when no delivery of the
+// log has the message ID.
+func (l *DeliveryLog) UpdateStatus(u notifier.StatusUpdate) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, d := range l.entries {
+		if d == nil || d.Provider != u.Provider || d.ProviderMessageID != u.MessageID {
+			continue
+		}
+		if d.StatusUpdatedAt != nil && u.Time.Before(*d.StatusUpdatedAt) {
+			return true
+		}
+		at := u.Time
+		d.ProviderStatus, d.StatusUpdatedAt = u.Status, &at
+		if u.Failed {
+			d.Status = DeliveryFailed
+			d.Error = "provider reported " + u.Status
+			if u.Reason != "" {
+				d.Error += ": " + u.Reason
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// List returns the matching deliveries, newest first.
+func (l *DeliveryLog) List(filter DeliveryFilter) []Delivery {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n := l.next
+	if l.full {
+		n = len(l.entries)
+	}
+
+	out := []Delivery{}
+	for i := 1; i <= n; i++ {
+		d := l.entries[(l.next-i+len(l.entries))%len(l.entries)]
+		if !filter.matches(d) {
+			continue
+		}
+		out = append(out, *d)
+		if filter.Limit > 0 && len(out) == filter.Limit {
+			break
+		}
+	}
+	return out
+}
@@ -0,0 +1,6 @@
+package service
+
+import "errors"
+
+// ErrOrderNotFound is returned by an OrderLookup for unknown orders
+var ErrOrderNotFound = errors.New("order not found")
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/events"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/retry"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/notification/internal/notifier"
+	"github.com/google/uuid"
+)
+
+var logger = logging.Component("notification")
+
+// DefaultRetryPolicies returns the retry policy of each channel. Permanent
+// failures are never retried.
+func DefaultRetryPolicies() map[string]retry.Config {
+	return map[string]retry.Config{
+		notifier.ChannelEmail:   {MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: 10 * time.Second},
+		notifier.ChannelSMS:     {MaxAttempts: 2, BaseDelay: 2 * time.Second, MaxDelay: 10 * time.Second},
+		notifier.ChannelWebhook: {MaxAttempts: 5, BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second},
+	}
+}
+
+// OrderLookup fetches orders that are not known from an order.created
+// event, so that status changes can still be addressed to the customer.
+type OrderLookup interface {
+	GetOrder(ctx context.Context, orderID string) (*order.Order, error)
+}
+
+// NotificationService turns order events into notifications on every
+// configured channel and records each delivery.
+type NotificationService struct {
+	notifiers  []notifier.Notifier
+	templates  *TemplateRegistry
+	policies   map[string]retry.Config
+	deliveries *DeliveryLog
+	orders     OrderLookup
+
+	mu    sync.Mutex
+	known map[string]OrderView
+}
+
+type Option func(*NotificationService)
+
+// WithTemplates replaces DefaultTemplates.
+func WithTemplates(templates *TemplateRegistry) Option {
+	return func(s *NotificationService) {
+		s.templates = templates
+	}
+}
+
+// WithRetryPolicy replaces the retry policy of one channel.
+func WithRetryPolicy(channel string, policy retry.Config) Option {
+	return func(s *NotificationService) {
+		s.policies[channel] = policy
+	}
+}
+
+// WithOrderLookup resolves the customer of orders created before the
+// service started.
+func WithOrderLookup(orders OrderLookup) Option {
+	return func(s *NotificationService) {
+		s.orders = orders
+	}
+}
+
+func NewNotificationService(deliveries *DeliveryLog, notifiers []notifier.Notifier, opts ...Option) *NotificationService {
+	s := &NotificationService{
+		notifiers:  notifiers,
+		templates:  DefaultTemplates(),
+		policies:   DefaultRetryPolicies(),
+		deliveries: deliveries,
+		known:      make(map[string]OrderView),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// HandleMessage is a broker.MessageHandler for order.created,
+// order.status_changed and the order.return_* events. Events without a template are acknowledged and
+// ignored. Delivery failures are recorded in the delivery log rather than
+// returned, so one failing channel does not resend the others.
+func (s *NotificationService) HandleMessage(msg *broker.Message) error {
+	var (
+		name string
+		data TemplateData
+	)
+	ctx := msg.Context(context.Background())
+
+	switch msg.Type {
+	case events.TypeOrderCreated:
+		var created events.OrderCreatedV1
+		if err := events.Decode(msg, &created); err != nil {
+			return err
+		}
+		name = created.EventType
+		data.Order = viewOf(&created.Order)
+		s.remember(data.Order)
+	case "order.status_changed":
+		var changed order.OrderStatusChangedEvent
+		if err := msg.Decode(&changed); err != nil {
+			return err
+		}
+		name = "order." + strings.ToLower(changed.To.String())
+		if !s.templates.Has(tenant.FromContext(ctx), name) {
+			return nil
+		}
+		view, err := s.lookup(ctx, changed.OrderID)
+		if err != nil {
+			return err
+		}
+		if changed.Customer != nil {
+			view = withCustomer(view, changed.Customer)
+		}
+		data = TemplateData{
+			Order:  view,
+			From:   changed.From.String(),
+			To:     changed.To.String(),
+			Reason: changed.Reason,
+		}
+		if changed.To == order.OrderStatus_ORDER_STATUS_DELIVERED || changed.To == order.OrderStatus_ORDER_STATUS_CANCELLED {
+			defer s.forget(changed.OrderID)
+		}
+	case order.EventTypeReturnApproved, order.EventTypeReturnRejected, order.EventTypeReturnRefunded:
+		var returned order.OrderReturnEvent
+		if err := msg.Decode(&returned); err != nil {
+			return err
+		}
+		name = returned.EventType
+		if !s.templates.Has(tenant.FromContext(ctx), name) {
+			return nil
+		}
+		view, err := s.lookup(ctx, returned.OrderID)
+		if err != nil {
+			return err
+		}
+		if view.CustomerEmail == "" {
+			view.CustomerID, view.CustomerEmail = returned.CustomerID, returned.CustomerEmail
+		}
+		if view.Currency == "" {
+			view.Currency = returned.Currency
+		}
+		units := 0
+		for _, item := range returned.Return.Items {
+			units += int(item.Quantity)
+		}
+		data = TemplateData{
+			Order:  view,
+			From:   returned.From.String(),
+			To:     returned.To.String(),
+			Reason: returned.Return.Note,
+			Return: &ReturnView{ID: returned.Return.ID, Items: units, RefundCents: returned.Return.RefundCents},
+		}
+		defer s.forget(returned.OrderID)
+	default:
+		return nil
+	}
+
+	tenantID := data.Order.TenantID
+	if tenantID == "" {
+		tenantID = tenant.FromContext(ctx)
+	}
+	content, err := s.templates.Render(tenantID, name, "", data)
+	if errors.Is(err, ErrTemplateNotFound) {
+		return nil
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "template failed", "template", name, logging.Err(err))
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for _, n := range s.notifiers {
+		to, ok := recipient(n.Channel(), data.Order)
+		if !ok {
+			continue
+		}
+		m := notifier.Message{
+			Recipient: to,
+			Subject:   content.Subject,
+			Body:      content.Text,
+			HTML:      content.HTML,
+			EventID:   msg.ID,
+			EventType: msg.Type,
+			OrderID:   data.Order.ID,
+			Payload:   msg.Payload,
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.deliver(ctx, n, name, m)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// deliver sends m with the channel's retry policy and records the outcome.
+func (s *NotificationService) deliver(ctx context.Context, n notifier.Notifier, name string, m notifier.Message) {
+	policy, ok := s.policies[n.Channel()]
+	if !ok || policy.MaxAttempts < 1 {
+		policy = retry.Config{MaxAttempts: 1}
+	}
+
+	d := Delivery{
+		ID:        "dlv_" + uuid.New().String()[:8],
+		EventID:   m.EventID,
+		EventType: m.EventType,
+		Template:  name,
+		OrderID:   m.OrderID,
+		Channel:   n.Channel(),
+		Provider:  n.Provider(),
+		Recipient: m.Recipient,
+		Subject:   m.Subject,
+		Status:    DeliverySent,
+	}
+
+	err := retry.Do(ctx, policy, func(ctx context.Context) error {
+		d.Attempts++
+		sendCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		id, err := n.Send(sendCtx, m)
+		d.ProviderMessageID = id
+		return err
+	}, retry.OnRetry(func(attempt int, err error, wait time.Duration) {
+		logger.WarnContext(ctx, "delivery failed, retrying",
+			"channel", n.Channel(),
+			"order_id", m.OrderID,
+			"attempt", attempt,
+			"max_attempts", policy.MaxAttempts,
+			"retry_in", wait.String(),
+			logging.Err(err))
+	}))
+
+	if err != nil {
+		d.Status = DeliveryFailed
+		d.Error = err.Error()
+		logger.ErrorContext(ctx, "delivery failed",
+			"channel", n.Channel(),
+			"template", name,
+			"order_id", m.OrderID,
+			"attempts", d.Attempts,
+			logging.Err(err))
+	}
+	d.CreatedAt = time.Now()
+	s.deliveries.Record(d)
+}
+
+// recipient picks the address of a channel and reports false when the
+// order has none. Text messages go to the phone of the customer profile, or
+// to the customer account when the order has no profile.
+func recipient(channel string, o OrderView) (string, bool) {
+	switch channel {
+	case notifier.ChannelEmail:
+		return o.CustomerEmail, o.CustomerEmail != ""
+	case notifier.ChannelSMS:
+		if o.CustomerPhone != "" {
+			return o.CustomerPhone, true
+		}
+		return o.CustomerID, o.CustomerID != ""
+	default:
+		return "", true
+	}
+}
+
+func (s *NotificationService) remember(o OrderView) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.known[o.ID] = o
+}
+
+func (s *NotificationService) forget(orderID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.known, orderID)
+}
+
+// lookup returns a remembered order, or fetches it through OrderLookup.
+func (s *NotificationService) lookup(ctx context.Context, orderID string) (OrderView, error) {
+	s.mu.Lock()
+	o, ok := s.known[orderID]
+	s.mu.Unlock()
+	if ok {
+		return o, nil
+	}
+	if s.orders == nil {
+		return OrderView{ID: orderID}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	fetched, err := s.orders.GetOrder(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, ErrOrderNotFound) {
+			return OrderView{ID: orderID}, nil
+		}
+		return OrderView{}, err
+	}
+	o = viewOf(fetched)
+	s.remember(o)
+	return o, nil
+}
+
+func viewOf(o *order.Order) OrderView {
+	v := OrderView{
+		ID:            o.ID,
+		TenantID:      o.TenantID,
+		CustomerID:    o.CustomerID,
+		CustomerEmail: o.CustomerEmail,
+		TotalCents:    o.TotalCents,
+		Currency:      o.Currency,
+		Items:         len(o.Items),
+	}
+	if o.Customer != nil {
+		v = withCustomer(v, o.Customer)
+	}
+	return v
+}
+
+// withCustomer fills the contact details from a customer snapshot.
+func withCustomer(v OrderView, c *order.CustomerSnapshot) OrderView {
+	v.CustomerName = c.Name
+	v.CustomerPhone = c.Phone
+	if c.Email != "" {
+		v.CustomerEmail = c.Email
+	}
+	return v
+}
@@ -0,0 +1,533 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// ErrTemplateNotFound is returned by Render for names without a template.
+var ErrTemplateNotFound = errors.New("template not found")
+
+// Template renders the subject, text body and optional HTML body of one
+// kind of notification. All are executed with the TemplateData of the
+// event; the text body is the fallback of clients that do not show HTML.
+type Template struct {
+	Subject *template.Template
+	Body    *template.Template
+	HTML    *htmltemplate.Template
+}
+
+// TemplateData is what templates can refer to. Order is the full order;
+// for status changes, From, To and Reason describe the transition. Return
+// is set for return events, whose From and To are return statuses.
+type TemplateData struct {
+	Order  OrderView   `json:"order"`
+	From   string      `json:"from,omitempty"`
+	To     string      `json:"to,omitempty"`
+	Reason string      `json:"reason,omitempty"`
+	Return *ReturnView `json:"return,omitempty"`
+}
+
+// ReturnView is the part of a return templates use. Items is the number
+// of units sent back.
+type ReturnView struct {
+	ID          string `json:"id"`
+	Items       int    `json:"items"`
+	RefundCents int64  `json:"refund_cents"`
+}
+
+// OrderView is the part of an order templates use. The customer name and
+// phone are only known for orders placed with a customer profile.
+type OrderView struct {
+	ID            string `json:"id"`
+	TenantID      string `json:"tenant_id,omitempty"`
+	CustomerID    string `json:"customer_id"`
+	CustomerEmail string `json:"customer_email"`
+	CustomerName  string `json:"customer_name,omitempty"`
+	CustomerPhone string `json:"customer_phone,omitempty"`
+	TotalCents    int64  `json:"total_cents"`
+	Currency      string `json:"currency"`
+	Items         int    `json:"items"`
+}
+
+// SampleData returns made up data for previews of the template name.
+func SampleData(name string) TemplateData {
+	data := TemplateData{Order: OrderView{
+		ID:            "ord_preview",
+		CustomerID:    "cust_preview",
+		CustomerEmail: "customer@example.com",
+		CustomerName:  "Ada Lovelace",
+		TotalCents:    12990,
+		Currency:      "BRL",
+		Items:         3,
+	}}
+	if to, ok := strings.CutPrefix(name, "order.return_"); ok {
+		data.From, data.To = "REQUESTED", strings.ToUpper(to)
+		data.Return = &ReturnView{ID: "ret_preview", Items: 1, RefundCents: 4990}
+		if to == "rejected" {
+			data.Reason = "the return window has closed"
+		}
+		return data
+	}
+	if to, ok := strings.CutPrefix(name, "order."); ok && name != "order.created" {
+		data.From, data.To = "PAID", strings.ToUpper(to)
+		if to == "cancelled" {
+			data.Reason = "requested by the customer"
+		}
+	}
+	return data
+}
+
+var templateFuncs = template.FuncMap{
+	"money": money,
+	"lower": strings.ToLower,
+}
+
+// htmlLayout frames the HTML body of every built-in template, and of
+// loaded HTML templates that define a "content" block instead of a whole
+// document. "title" is the heading above the content.
+const htmlLayout = `{{define "layout"}}<!DOCTYPE html>
+<html>
+<body style="margin:0;padding:0;background:#f4f4f5;font-family:Helvetica,Arial,sans-serif">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0"><tr><td align="center" style="padding:24px">
+<table role="presentation" width="600" cellpadding="0" cellspacing="0" style="background:#ffffff;border-radius:6px">
+<tr><td style="padding:24px 24px 8px;font-size:20px;font-weight:bold;color:#111827">{{template "title" .}}</td></tr>
+<tr><td style="padding:8px 24px 24px;font-size:15px;line-height:1.5;color:#374151">{{template "content" .}}</td></tr>
+<tr><td style="padding:16px 24px;font-size:12px;color:#9ca3af;border-top:1px solid #e5e7eb">{{.Order.ID}}</td></tr>
+</table>
+</td></tr></table>
+</body>
+</html>
+{{end}}`
+
+// defaultTemplates are keyed by locale, then by template name: the event
+// type, or "order.<status>" for status changes. Each holds the subject,
+// the text body and the content of the HTML layout.
+var defaultTemplates = map[string]map[string][3]string{
+	"en": {
+		"order.created": {
+			"Order {{.Order.ID}} confirmed",
+			"Thanks for your order{{with .Order.CustomerName}}, {{.}}{{end}}! We received {{.Order.Items}} item(s) totalling {{money .Order.TotalCents .Order.Currency}}.",
+			`<p>Thanks for your order{{with .Order.CustomerName}}, {{.}}{{end}}!</p><p>We received <strong>{{.Order.Items}} item(s)</strong> totalling <strong>{{money .Order.TotalCents .Order.Currency}}</strong>.</p>`,
+		},
+		"order.shipped": {
+			"Order {{.Order.ID}} shipped",
+			"Your order {{.Order.ID}} is on its way. {{.Reason}}",
+			`<p>Your order is on its way.</p>{{with .Reason}}<p>{{.}}</p>{{end}}`,
+		},
+		"order.delivered": {
+			"Order {{.Order.ID}} delivered",
+			"Your order {{.Order.ID}} was delivered. Enjoy!",
+			`<p>Your order was delivered. Enjoy!</p>`,
+		},
+		"order.cancelled": {
+			"Order {{.Order.ID}} cancelled",
+			"Your order {{.Order.ID}} was cancelled: {{.Reason}}.",
+			`<p>Your order was cancelled{{with .Reason}}: {{.}}{{end}}.</p>`,
+		},
+		"order.charged_back": {
+			"Order {{.Order.ID}} charged back",
+			"The payment of order {{.Order.ID}} ({{money .Order.TotalCents .Order.Currency}}) was charged back.",
+			`<p>The payment of your order (<strong>{{money .Order.TotalCents .Order.Currency}}</strong>) was charged back.</p>`,
+		},
+		"order.return_approved": {
+			"Return {{.Return.ID}} approved",
+			"Your return of {{.Return.Items}} item(s) from order {{.Order.ID}} was approved. Please send the items back.",
+			`<p>Your return of <strong>{{.Return.Items}} item(s)</strong> was approved.</p><p>Please send the items back.</p>`,
+		},
+		"order.return_rejected": {
+			"Return {{.Return.ID}} rejected",
+			"Your return of {{.Return.Items}} item(s) from order {{.Order.ID}} was rejected{{with .Reason}}: {{.}}{{end}}.",
+			`<p>Your return of <strong>{{.Return.Items}} item(s)</strong> was rejected{{with .Reason}}: {{.}}{{end}}.</p>`,
+		},
+		"order.return_refunded": {
+			"Return {{.Return.ID}} refunded",
+			"We refunded {{money .Return.RefundCents .Order.Currency}} for the items you returned from order {{.Order.ID}}.",
+			`<p>We refunded <strong>{{money .Return.RefundCents .Order.Currency}}</strong> for the items you returned.</p>`,
+		},
+	},
+	"pt-BR": {
+		"order.created": {
+			"Pedido {{.Order.ID}} confirmado",
+			"Obrigado pelo seu pedido{{with .Order.CustomerName}}, {{.}}{{end}}! Recebemos {{.Order.Items}} item(ns) no total de {{money .Order.TotalCents .Order.Currency}}.",
+			`<p>Obrigado pelo seu pedido{{with .Order.CustomerName}}, {{.}}{{end}}!</p><p>Recebemos <strong>{{.Order.Items}} item(ns)</strong> no total de <strong>{{money .Order.TotalCents .Order.Currency}}</strong>.</p>`,
+		},
+		"order.shipped": {
+			"Pedido {{.Order.ID}} enviado",
+			"Seu pedido {{.Order.ID}} está a caminho. {{.Reason}}",
+			`<p>Seu pedido está a caminho.</p>{{with .Reason}}<p>{{.}}</p>{{end}}`,
+		},
+		"order.delivered": {
+			"Pedido {{.Order.ID}} entregue",
+			"Seu pedido {{.Order.ID}} foi entregue. Aproveite!",
+			`<p>Seu pedido foi entregue. Aproveite!</p>`,
+		},
+		"order.cancelled": {
+			"Pedido {{.Order.ID}} cancelado",
+			"Seu pedido {{.Order.ID}} foi cancelado: {{.Reason}}.",
+			`<p>Seu pedido foi cancelado{{with .Reason}}: {{.}}{{end}}.</p>`,
+		},
+		"order.charged_back": {
+			"Pedido {{.Order.ID}} estornado",
+			"O pagamento do pedido {{.Order.ID}} ({{money .Order.TotalCents .Order.Currency}}) foi estornado.",
+			`<p>O pagamento do seu pedido (<strong>{{money .Order.TotalCents .Order.Currency}}</strong>) foi estornado.</p>`,
+		},
+		"order.return_approved": {
+			"Devolução {{.Return.ID}} aprovada",
+			"Sua devolução de {{.Return.Items}} item(ns) do pedido {{.Order.ID}} foi aprovada. Envie os itens de volta.",
+			`<p>Sua devolução de <strong>{{.Return.Items}} item(ns)</strong> foi aprovada.</p><p>Envie os itens de volta.</p>`,
+		},
+		"order.return_rejected": {
+			"Devolução {{.Return.ID}} recusada",
+			"Sua devolução de {{.Return.Items}} item(ns) do pedido {{.Order.ID}} foi recusada{{with .Reason}}: {{.}}{{end}}.",
+			`<p>Sua devolução de <strong>{{.Return.Items}} item(ns)</strong> foi recusada{{with .Reason}}: {{.}}{{end}}.</p>`,
+		},
+		"order.return_refunded": {
+			"Devolução {{.Return.ID}} reembolsada",
+			"Reembolsamos {{money .Return.RefundCents .Order.Currency}} pelos itens devolvidos do pedido {{.Order.ID}}.",
+			`<p>Reembolsamos <strong>{{money .Return.RefundCents .Order.Currency}}</strong> pelos itens devolvidos.</p>`,
+		},
+	},
+}
+
+// DefaultTemplates returns a registry with the built-in templates, in
+// English and Brazilian Portuguese, and en as default locale.
+func DefaultTemplates() *TemplateRegistry {
+	r := NewTemplateRegistry("en")
+	for locale, templates := range defaultTemplates {
+		for name, t := range templates {
+			html := `{{define "title"}}` + t[0] + `{{end}}{{define "content"}}` + t[2] + `{{end}}`
+			r.Add("", locale, name, MustTemplate(name, t[0], t[1], html))
+		}
+	}
+	return r
+}
+
+// MustTemplate parses a subject, text body and optional HTML body template
+// and panics on errors.
+func MustTemplate(name, subject, body, html string) Template {
+	t, err := ParseTemplate(name, subject, body, html)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// ParseTemplate parses a subject, text body and optional HTML body
+// template. An HTML body that defines a "content" block, and optionally a
+// "title", is rendered inside the built-in email layout; any other HTML
+// body is a whole document.
+func ParseTemplate(name, subject, body, html string) (Template, error) {
+	var t Template
+	var err error
+	if t.Subject, err = template.New(name + ".subject").Funcs(templateFuncs).Parse(subject); err != nil {
+		return Template{}, err
+	}
+	if t.Body, err = template.New(name + ".body").Funcs(templateFuncs).Parse(body); err != nil {
+		return Template{}, err
+	}
+	if html == "" {
+		return t, nil
+	}
+	t.HTML = htmltemplate.Must(htmltemplate.New(name + ".html").Funcs(htmltemplate.FuncMap(templateFuncs)).Parse(htmlLayout))
+	if t.HTML, err = t.HTML.Parse(html); err != nil {
+		return Template{}, err
+	}
+	if t.HTML.Lookup("content") != nil && t.HTML.Lookup("title") == nil {
+		t.HTML = htmltemplate.Must(t.HTML.Parse(`{{define "title"}}{{end}}`))
+	}
+	return t, nil
+}
+
+// Rendered is the content of a notification.
+type Rendered struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html,omitempty"`
+
+	// Locale is the locale of the template used, and TenantID the tenant
+	// it overrides the shared templates for, empty for a shared one.
+	Locale   string `json:"locale"`
+	TenantID string `json:"tenant_id,omitempty"`
+}
+
+func (t Template) render(data TemplateData) (Rendered, error) {
+	var b bytes.Buffer
+	if err := t.Subject.Execute(&b, data); err != nil {
+		return Rendered{}, err
+	}
+	out := Rendered{Subject: b.String()}
+
+	b.Reset()
+	if err := t.Body.Execute(&b, data); err != nil {
+		return Rendered{}, err
+	}
+	out.Text = strings.TrimSpace(b.String())
+
+	if t.HTML != nil {
+		b.Reset()
+		name := t.HTML.Name()
+		if t.HTML.Lookup("content") != nil {
+			name = "layout"
+		}
+		if err := t.HTML.ExecuteTemplate(&b, name, data); err != nil {
+			return Rendered{}, err
+		}
+		out.HTML = strings.TrimSpace(b.String())
+	}
+	return out, nil
+}
+
+type templateKey struct {
+	tenant string
+	locale string
+	name   string
+}
+
+// TemplateInfo describes a template of a registry.
+type TemplateInfo struct {
+	Name     string `json:"name"`
+	Locale   string `json:"locale"`
+	TenantID string `json:"tenant_id,omitempty"`
+	HTML     bool   `json:"html"`
+}
+
+// TemplateRegistry holds templates by name and locale, shared by every
+// tenant or overridden for one. Lookups fall back from a locale such as
+// pt-BR to its language, pt, then to the default locale, trying the
+// tenant's templates before the shared ones at each step.
+type TemplateRegistry struct {
+	defaultLocale string
+
+	mu        sync.RWMutex
+	templates map[templateKey]Template
+	locales   map[string]string
+}
+
+func NewTemplateRegistry(defaultLocale string) *TemplateRegistry {
+	return &TemplateRegistry{
+		defaultLocale: NormalizeLocale(defaultLocale),
+		templates:     make(map[templateKey]Template),
+		locales:       make(map[string]string),
+	}
+}
+
+// Add registers t as the template name in locale, for tenantID only when
+// it is set. It replaces a template of the same key.
+func (r *TemplateRegistry) Add(tenantID, locale, name string, t Template) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.templates[templateKey{tenantID, NormalizeLocale(locale), name}] = t
+}
+
+// SetDefaultLocale makes locale the language of the notifications of
+// tenantID, or of every tenant without one of its own when tenantID is
+// empty.
+func (r *TemplateRegistry) SetDefaultLocale(tenantID, locale string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if tenantID == "" {
+		r.defaultLocale = NormalizeLocale(locale)
+		return
+	}
+	r.locales[tenantID] = NormalizeLocale(locale)
+}
+
+// Locale returns the locale notifications of tenantID are written in.
+func (r *TemplateRegistry) Locale(tenantID string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if locale, ok := r.locales[tenantID]; ok {
+		return locale
+	}
+	return r.defaultLocale
+}
+
+// Has reports whether tenantID has a template called name in any locale.
+func (r *TemplateRegistry) Has(tenantID, name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for key := range r.templates {
+		if key.name == name && (key.tenant == "" || key.tenant == tenantID) {
+			return true
+		}
+	}
+	return false
+}
+
+// Render renders the template name of tenantID in locale, or in the
+// tenant's locale when locale is empty.
+func (r *TemplateRegistry) Render(tenantID, name, locale string, data TemplateData) (Rendered, error) {
+	if locale == "" {
+		locale = r.Locale(tenantID)
+	}
+	t, key, ok := r.lookup(tenantID, name, NormalizeLocale(locale))
+	if !ok {
+		return Rendered{}, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+	out, err := t.render(data)
+	if err != nil {
+		return Rendered{}, err
+	}
+	out.Locale, out.TenantID = key.locale, key.tenant
+	return out, nil
+}
+
+func (r *TemplateRegistry) lookup(tenantID, name, locale string) (Template, templateKey, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	candidates := []string{locale}
+	if lang, _, ok := strings.Cut(locale, "-"); ok {
+		candidates = append(candidates, lang)
+	}
+	candidates = append(candidates, r.defaultLocale)
+	for _, l := range candidates {
+		for _, tenant := range []string{tenantID, ""} {
+			key := templateKey{tenant, l, name}
+			if t, ok := r.templates[key]; ok {
+				return t, key, true
+			}
+		}
+	}
+	return Template{}, templateKey{}, false
+}
+
+// List returns every template, sorted by name, locale and tenant.
+func (r *TemplateRegistry) List() []TemplateInfo {
+	r.mu.RLock()
+	out := make([]TemplateInfo, 0, len(r.templates))
+	for key, t := range r.templates {
+		out = append(out, TemplateInfo{Name: key.name, Locale: key.locale, TenantID: key.tenant, HTML: t.HTML != nil})
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Locale != b.Locale {
+			return a.Locale < b.Locale
+		}
+		return a.TenantID < b.TenantID
+	})
+	return out
+}
+
+// LoadDir adds the templates of dir, laid out as
+//
+//	<locale>/<name>.subject.tmpl
+//	<locale>/<name>.txt.tmpl
+//	<locale>/<name>.html.tmpl            (optional)
+//	tenants/<tenant>/<locale>/<name>.*   (overrides for one tenant)
+//
+// A template needs its subject and text body. Loaded templates replace
+// built-in ones of the same name and locale.
+func (r *TemplateRegistry) LoadDir(dir string) (int, error) {
+	loaded, err := r.loadLocales(dir, "")
+	if err != nil {
+		return loaded, err
+	}
+	tenants, err := os.ReadDir(filepath.Join(dir, "tenants"))
+	if errors.Is(err, os.ErrNotExist) {
+		return loaded, nil
+	}
+	if err != nil {
+		return loaded, err
+	}
+	for _, t := range tenants {
+		if !t.IsDir() {
+			continue
+		}
+		n, err := r.loadLocales(filepath.Join(dir, "tenants", t.Name()), t.Name())
+		loaded += n
+		if err != nil {
+			return loaded, err
+		}
+	}
+	return loaded, nil
+}
+
+// loadLocales loads the locale directories of dir for tenantID.
+func (r *TemplateRegistry) loadLocales(dir, tenantID string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	loaded := 0
+	for _, e := range entries {
+		if !e.IsDir() || e.Name() == "tenants" {
+			continue
+		}
+		locale := e.Name()
+		files, err := filepath.Glob(filepath.Join(dir, locale, "*.subject.tmpl"))
+		if err != nil {
+			return loaded, err
+		}
+		for _, subjectFile := range files {
+			name := strings.TrimSuffix(filepath.Base(subjectFile), ".subject.tmpl")
+			t, err := parseTemplateFiles(filepath.Join(dir, locale), name)
+			if err != nil {
+				return loaded, err
+			}
+			r.Add(tenantID, locale, name, t)
+			loaded++
+		}
+	}
+	return loaded, nil
+}
+
+func parseTemplateFiles(dir, name string) (Template, error) {
+	read := func(suffix string, required bool) (string, error) {
+		b, err := os.ReadFile(filepath.Join(dir, name+suffix))
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return "", nil
+		}
+		return string(b), err
+	}
+	subject, err := read(".subject.tmpl", true)
+	if err != nil {
+		return Template{}, err
+	}
+	body, err := read(".txt.tmpl", true)
+	if err != nil {
+		return Template{}, err
+	}
+	html, err := read(".html.tmpl", false)
+	if err != nil {
+		return Template{}, err
+	}
+	t, err := ParseTemplate(name, strings.TrimSpace(subject), body, html)
+	if err != nil {
+		return Template{}, fmt.Errorf("template %s in %s: %w", name, dir, err)
+	}
+	return t, nil
+}
+
+// NormalizeLocale returns locale as a lower case language with an upper
+// case region, such as pt-BR for pt_br.
+func NormalizeLocale(locale string) string {
+	lang, region, ok := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	if !ok {
+		return strings.ToLower(lang)
+	}
+	return strings.ToLower(lang) + "-" + strings.ToUpper(region)
+}
+
+// money formats cents as "BRL 12.34".
+func money(cents int64, currency string) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s %s%d.%02d", currency, sign, cents/100, cents%100)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/config"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/orchestrator/internal/placeorder"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/orchestrator/internal/saga"
+)
+
+// Config holds the startup settings of the orchestrator service.
+type Config struct {
+	HTTP config.HTTPServer `config:"http"`
+
+	OrderURL       string `config:"order-url" usage:"Order Service base URL"`
+	OrderToken     string `config:"order-token,secret" usage:"Admin API key or JWT for the Order Service"`
+	PaymentAddr    string `config:"payment-addr" usage:"Payment service gRPC address"`
+	PaymentToken   string `config:"payment-token,secret" usage:"API key or JWT sent to the payment service"`
+	InventoryAddr  string `config:"inventory-addr" usage:"Inventory service gRPC address"`
+	InventoryToken string `config:"inventory-token,secret" usage:"API key or JWT sent to the inventory service"`
+	ShippingAddr   string `config:"shipping-addr" usage:"Shipping service gRPC address"`
+
+	Store    string `config:"store" usage:"Workflow store: memory, sqlite or postgres"`
+	StoreDSN string `config:"store-dsn,secret" usage:"SQLite file or Postgres connection string"`
+
+	Saga       saga.Config       `config:",inline"`
+	PlaceOrder placeorder.Config `config:",inline"`
+
+	Broker broker.BrokerConfig `config:"broker"`
+	Log    logging.Config      `config:"log"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		HTTP:          config.DefaultHTTPServer(8084),
+		OrderURL:      "http://localhost:8080",
+		PaymentAddr:   "localhost:50051",
+		InventoryAddr: "localhost:50052",
+		ShippingAddr:  "localhost:50053",
+		Store:         "memory",
+		Saga:          saga.DefaultConfig(),
+		PlaceOrder:    placeorder.DefaultConfig(),
+		Broker:        broker.DefaultBrokerConfig(),
+		Log:           logging.DefaultConfig(),
+	}
+}
+
+func (c Config) Validate() error {
+	switch {
+	case c.OrderURL == "":
+		return errors.New("order-url is required")
+	case c.PaymentAddr == "":
+		return errors.New("payment-addr is required")
+	case c.InventoryAddr == "":
+		return errors.New("inventory-addr is required")
+	case c.ShippingAddr == "":
+		return errors.New("shipping-addr is required")
+	}
+
+	switch c.Store {
+	case "memory":
+	case "sqlite", "postgres":
+		if c.StoreDSN == "" {
+			return fmt.Errorf("store %s requires store-dsn", c.Store)
+		}
+	default:
+		return fmt.Errorf("unknown store %q", c.Store)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	_ "github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/codec"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/config"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/reqmeta"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/inventory"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/shipping"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/orchestrator/internal/handler"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/orchestrator/internal/placeorder"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/orchestrator/internal/saga"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	_ "modernc.org/sqlite"
+)
+
+func main() {
+	cfg := defaultConfig()
+	loader := config.Register(flag.CommandLine, "orchestrator", &cfg)
+	flag.Parse()
+	if err := loader.Load(); err != nil {
+		logging.Fatal("invalid configuration", logging.Err(err))
+	}
+
+	if err := logging.Setup("orchestrator", cfg.Log); err != nil {
+		logging.Fatal("invalid logging configuration", logging.Err(err))
+	}
+	slog.Info("starting orchestrator service", "port", cfg.HTTP.Port)
+
+	store, closeStore, err := buildWorkflowStore(cfg.Store, cfg.StoreDSN)
+	if err != nil {
+		logging.Fatal("failed to open workflow store", logging.Err(err))
+	}
+	defer closeStore()
+	slog.Info("workflow store configured", "store", cfg.Store)
+
+	paymentConn := dial("payment", cfg.PaymentAddr, cfg.PaymentToken)
+	defer paymentConn.Close()
+	inventoryConn := dial("inventory", cfg.InventoryAddr, cfg.InventoryToken)
+	defer inventoryConn.Close()
+	shippingConn := dial("shipping", cfg.ShippingAddr, "")
+	defer shippingConn.Close()
+	orderClient := placeorder.NewOrderClient(cfg.OrderURL, cfg.OrderToken)
+
+	// Every step has a command topic with a queue of its own, served by the
+	// participant of the step; replies come back through one queue.
+	steps := map[string]saga.Participant{
+		placeorder.StepOrder:     &placeorder.OrderStep{Client: orderClient},
+		placeorder.StepInventory: &placeorder.InventoryStep{Client: inventory.NewInventoryServiceClient(inventoryConn)},
+		placeorder.StepPayment:   &placeorder.PaymentStep{Client: payment.NewPaymentServiceClient(paymentConn)},
+		placeorder.StepShipping: &placeorder.ShippingStep{
+			Orders:   orderClient,
+			Client:   shipping.NewShippingServiceClient(shippingConn),
+			Interval: 500 * time.Millisecond,
+		},
+	}
+
+	msgBroker := broker.NewBroker(cfg.Broker)
+	msgBroker.CreateTopic(saga.RepliesTopic)
+	repliesQueue := msgBroker.CreateQueue("saga-replies", broker.WithMaxRetries(5))
+	msgBroker.Subscribe(saga.RepliesTopic, "saga-replies")
+
+	def := placeorder.Definition(cfg.PlaceOrder)
+	commandQueues := make(map[string]*broker.Queue, len(def.Steps))
+	for _, step := range def.Steps {
+		topic := saga.CommandTopic(step.Name)
+		msgBroker.CreateTopic(topic)
+		commandQueues[step.Name] = msgBroker.CreateQueue(topic, broker.WithMaxRetries(3))
+		msgBroker.Subscribe(topic, topic)
+	}
+	slog.Info("message broker configured")
+
+	orchestrator := saga.NewOrchestrator(store, msgBroker, cfg.Saga, def)
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	go broker.NewWorker("saga-reply-worker", repliesQueue, orchestrator.HandleReply).Start(ctx)
+	for _, step := range def.Steps {
+		handle := saga.Serve(msgBroker, steps[step.Name], step.Timeout)
+		go broker.NewWorker("saga-"+step.Name+"-worker", commandQueues[step.Name], handle).Start(ctx)
+	}
+
+	if err := orchestrator.Resume(ctx); err != nil {
+		logging.Fatal("failed to resume workflows", logging.Err(err))
+	}
+	go orchestrator.Run(ctx)
+
+	mux := http.NewServeMux()
+	handler.NewWorkflowHandler(orchestrator).RegisterRoutes(mux)
+	httpServer := cfg.HTTP.Server(logging.Middleware(reqmeta.Middleware(tenant.Middleware(mux))))
+
+	go func() {
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+		<-sigChan
+		slog.Info("shutting down")
+		stop()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("orchestrator service ready", "addr", httpServer.Addr)
+	slog.Info("endpoints: POST /workflows/place-order, GET /workflows, GET /workflows/{id}, GET /health")
+
+	if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+		logging.Fatal("HTTP server error", logging.Err(err))
+	}
+}
+
+// dial connects to the gRPC service name at addr, passing on the request
+// ID, tenant and actor of each call and sending token when it is set.
+func dial(name, addr, token string) *grpc.ClientConn {
+	conn, err := grpc.NewClient(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype("json")),
+		grpc.WithChainUnaryInterceptor(
+			reqmeta.UnaryClientInterceptor(),
+			auth.UnaryClientInterceptor(token),
+		),
+	)
+	if err != nil {
+		logging.Fatal("failed to connect to "+name+" service", logging.Err(err))
+	}
+	slog.Info(name+" service configured", "addr", addr)
+	return conn
+}
+
+func buildWorkflowStore(backend, dsn string) (saga.Store, func() error, error) {
+	noop := func() error { return nil }
+
+	switch backend {
+	case "", "memory":
+		return saga.NewMemoryStore(), noop, nil
+	case "sqlite", "postgres":
+		driver := "sqlite"
+		if backend == "postgres" {
+			driver = "pgx"
+		}
+		db, err := sql.Open(driver, dsn)
+		if err != nil {
+			return nil, nil, err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		s, err := saga.NewSQLStore(ctx, db, driver)
+		if err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+		return s, db.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown workflow store %q", backend)
+	}
+}
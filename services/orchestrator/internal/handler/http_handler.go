@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/orchestrator/internal/placeorder"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/orchestrator/internal/saga"
+)
+
+var logger = logging.Component("handler")
+
+// maxListLimit caps the limit parameter of GET /workflows.
+const maxListLimit = 500
+
+// WorkflowHandler starts workflows and reports their progress over HTTP.
+// Workflows are only visible to the tenant that started them.
+type WorkflowHandler struct {
+	orchestrator *saga.Orchestrator
+}
+
+func NewWorkflowHandler(o *saga.Orchestrator) *WorkflowHandler {
+	return &WorkflowHandler{orchestrator: o}
+}
+
+func (h *WorkflowHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /workflows/place-order", h.placeOrder)
+	mux.HandleFunc("GET /workflows", h.listWorkflows)
+	mux.HandleFunc("GET /workflows/{id}", h.getWorkflow)
+	mux.HandleFunc("GET /health", h.handleHealth)
+}
+
+// placeOrder serves POST /workflows/place-order. The body is an order as
+// POST /orders of the order service takes it; the workflow placing it is
+// answered with 202 and moves on in the background.
+func (h *WorkflowHandler) placeOrder(w http.ResponseWriter, r *http.Request) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	var in placeorder.Input
+	if err := json.Unmarshal(raw, &in); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := in.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	wf, err := h.orchestrator.Start(r.Context(), placeorder.Type, raw)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "starting workflow failed", logging.Err(err))
+		respondError(w, http.StatusInternalServerError, "Internal error")
+		return
+	}
+
+	w.Header().Set("Location", "/workflows/"+wf.ID)
+	respondJSON(w, http.StatusAccepted, wf)
+}
+
+// listWorkflows serves GET /workflows, newest first, optionally filtered
+// by status and capped by limit.
+func (h *WorkflowHandler) listWorkflows(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	limit := 50
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxListLimit {
+			respondError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxListLimit))
+			return
+		}
+		limit = n
+	}
+
+	status := saga.Status(q.Get("status"))
+	switch status {
+	case "", saga.StatusRunning, saga.StatusCompensating, saga.StatusCompleted, saga.StatusCompensated, saga.StatusFailed:
+	default:
+		respondError(w, http.StatusBadRequest, "Unknown status "+string(status))
+		return
+	}
+
+	workflows, err := h.orchestrator.List(r.Context(), status, limit)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "listing workflows failed", logging.Err(err))
+		respondError(w, http.StatusInternalServerError, "Internal error")
+		return
+	}
+	if workflows == nil {
+		workflows = []*saga.Workflow{}
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"workflows": workflows,
+		"count":     len(workflows),
+	})
+}
+
+func (h *WorkflowHandler) getWorkflow(w http.ResponseWriter, r *http.Request) {
+	wf, err := h.orchestrator.Get(r.Context(), r.PathValue("id"))
+	if err == nil && wf.TenantID != tenant.FromContext(r.Context()) {
+		err = saga.ErrWorkflowNotFound
+	}
+	switch {
+	case errors.Is(err, saga.ErrWorkflowNotFound):
+		respondError(w, http.StatusNotFound, "Workflow not found")
+	case err != nil:
+		logger.ErrorContext(r.Context(), "workflow lookup failed", logging.Err(err))
+		respondError(w, http.StatusInternalServerError, "Internal error")
+	default:
+		respondJSON(w, http.StatusOK, wf)
+	}
+}
+
+func (h *WorkflowHandler) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if err := h.orchestrator.Ping(r.Context()); err != nil {
+		logger.WarnContext(r.Context(), "workflow store unavailable", logging.Err(err))
+		respondJSON(w, http.StatusServiceUnavailable, map[string]string{
+			"status":  "unhealthy",
+			"service": "orchestrator-service",
+		})
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{
+		"status":  "healthy",
+		"service": "orchestrator-service",
+	})
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,189 @@
+package placeorder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/reqmeta"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/orchestrator/internal/saga"
+)
+
+// OrderClient calls the order service HTTP API for the tenant of each
+// request context. Its token needs the admin role.
+type OrderClient struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func NewOrderClient(baseURL, token string) *OrderClient {
+	return &OrderClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &reqmeta.Transport{},
+		},
+	}
+}
+
+// errStatus is returned for responses other than the expected one.
+type errStatus struct {
+	code    int
+	message string
+}
+
+func (e *errStatus) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.code, http.StatusText(e.code), e.message)
+}
+
+// do sends body as JSON and decodes a 200 or 201 response into out.
+func (c *OrderClient) do(ctx context.Context, method, path string, body, out any) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set(auth.AuthorizationHeader, "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return &errStatus{code: resp.StatusCode, message: errorMessage(resp.Body)}
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// errorMessage extracts the "error" field of a JSON error response.
+func errorMessage(body io.Reader) string {
+	var e struct {
+		Error string `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(body, 4096))
+	if json.Unmarshal(data, &e) == nil && e.Error != "" {
+		return e.Error
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// httpError wraps the 4xx answers of the order service, but for 408 and
+// 429, in saga.ErrRejected.
+func httpError(err error) error {
+	var se *errStatus
+	if errors.As(err, &se) && se.code >= 400 && se.code < 500 &&
+		se.code != http.StatusRequestTimeout && se.code != http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %s", saga.ErrRejected, se.message)
+	}
+	return err
+}
+
+// CreatePending calls POST /orders/pending with the input of a workflow
+// and the order ID.
+func (c *OrderClient) CreatePending(ctx context.Context, orderID string, input json.RawMessage) (*order.Order, error) {
+	body := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(input, &body); err != nil {
+		return nil, fmt.Errorf("%w: invalid order: %v", saga.ErrRejected, err)
+	}
+	body["order_id"], _ = json.Marshal(orderID)
+
+	var o order.Order
+	if err := c.do(ctx, http.MethodPost, "/orders/pending", body, &o); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// RecordPayment calls POST /orders/{id}/payment.
+func (c *OrderClient) RecordPayment(ctx context.Context, orderID, transactionID string) (*order.Order, error) {
+	var o order.Order
+	err := c.do(ctx, http.MethodPost, "/orders/"+url.PathEscape(orderID)+"/payment",
+		map[string]string{"transaction_id": transactionID}, &o)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// Cancel calls POST /orders/{id}/cancel.
+func (c *OrderClient) Cancel(ctx context.Context, orderID, reason string) (*order.Order, error) {
+	var o order.Order
+	err := c.do(ctx, http.MethodPost, "/orders/"+url.PathEscape(orderID)+"/cancel",
+		map[string]string{"reason": reason}, &o)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// Get calls GET /orders/{id}.
+func (c *OrderClient) Get(ctx context.Context, orderID string) (*order.Order, error) {
+	var o order.Order
+	if err := c.do(ctx, http.MethodGet, "/orders/"+url.PathEscape(orderID), nil, &o); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// OrderStep stores the order PENDING and cancels it.
+type OrderStep struct {
+	Client *OrderClient
+}
+
+func (s *OrderStep) Do(ctx context.Context, cmd saga.Command) (map[string]string, error) {
+	o, err := s.Client.CreatePending(ctx, OrderID(cmd.WorkflowID), cmd.Input)
+	if err != nil {
+		return nil, httpError(err)
+	}
+	return map[string]string{
+		"order_id":       o.ID,
+		"total_cents":    strconv.FormatInt(o.TotalCents, 10),
+		"currency":       o.Currency,
+		"customer_email": o.CustomerEmail,
+	}, nil
+}
+
+// Undo cancels the order. An order the order service refuses to cancel
+// because it already is counts as compensated.
+func (s *OrderStep) Undo(ctx context.Context, cmd saga.Command) error {
+	orderID := cmd.Data["order_id"]
+	_, err := s.Client.Cancel(ctx, orderID, "order placement failed")
+
+	var se *errStatus
+	if errors.As(err, &se) && se.code == http.StatusConflict {
+		o, getErr := s.Client.Get(ctx, orderID)
+		if getErr != nil {
+			return getErr
+		}
+		if o.Status == order.OrderStatus_ORDER_STATUS_CANCELLED {
+			return nil
+		}
+	}
+	return httpError(err)
+}
@@ -0,0 +1,104 @@
+// Package placeorder defines the place_order workflow: the order is stored
+// PENDING, its stock reserved, its payment charged and, once the order is
+// marked PAID, its shipment awaited. A failed step undoes the earlier ones
+// in reverse order: the payment is refunded, the reservation released and
+// the order cancelled.
+package placeorder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/orchestrator/internal/saga"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Type is the workflow type of order placements.
+const Type = "place_order"
+
+// Step names, which are also the suffixes of their command topics.
+const (
+	StepOrder     = "order"
+	StepInventory = "inventory"
+	StepPayment   = "payment"
+	StepShipping  = "shipping"
+)
+
+// Config sets how long the steps may take.
+type Config struct {
+	StepTimeout     time.Duration `config:"step-timeout" usage:"Time allowed for the order, inventory and payment steps before their command is resent"`
+	ShipmentTimeout time.Duration `config:"shipment-timeout" usage:"Time allowed for the shipping service to create the shipment of a paid order"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		StepTimeout:     10 * time.Second,
+		ShipmentTimeout: 30 * time.Second,
+	}
+}
+
+func (c Config) Validate() error {
+	if c.StepTimeout <= 0 {
+		return errors.New("step-timeout must be positive")
+	}
+	if c.ShipmentTimeout <= 0 {
+		return errors.New("shipment-timeout must be positive")
+	}
+	return nil
+}
+
+// Definition returns the steps of the workflow. The shipment cannot be
+// undone, so it comes last.
+func Definition(cfg Config) saga.Definition {
+	return saga.Definition{
+		Type: Type,
+		Steps: []saga.Step{
+			{Name: StepOrder, Timeout: cfg.StepTimeout, Compensable: true},
+			{Name: StepInventory, Timeout: cfg.StepTimeout, Compensable: true},
+			{Name: StepPayment, Timeout: cfg.StepTimeout, Compensable: true},
+			{Name: StepShipping, Timeout: cfg.ShipmentTimeout},
+		},
+	}
+}
+
+// Input is the order a workflow places, in the body format of POST /orders
+// of the order service. Fields the orchestrator does not read are passed on
+// to the order service as sent.
+type Input struct {
+	CustomerEmail string            `json:"customer_email"`
+	Items         []order.OrderItem `json:"items"`
+}
+
+// Validate catches orders the order service would reject before a
+// workflow is started for them.
+func (in Input) Validate() error {
+	if len(in.Items) == 0 {
+		return errors.New("items is required")
+	}
+	return nil
+}
+
+// OrderID is the ID of the order placed by workflow workflowID, so that a
+// resent command stores the same order.
+func OrderID(workflowID string) string {
+	return "ord_" + strings.TrimPrefix(workflowID, "wf_")
+}
+
+// grpcError wraps the refusals of a gRPC service in saga.ErrRejected and
+// returns other errors, such as an unavailable service, as they are.
+func grpcError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	switch st.Code() {
+	case codes.InvalidArgument, codes.NotFound, codes.FailedPrecondition, codes.AlreadyExists, codes.PermissionDenied:
+		return fmt.Errorf("%w: %s", saga.ErrRejected, st.Message())
+	default:
+		return err
+	}
+}
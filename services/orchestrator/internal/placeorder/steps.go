@@ -0,0 +1,143 @@
+package placeorder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/inventory"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/shipping"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/orchestrator/internal/saga"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// InventoryStep reserves the items of the order and releases them. The
+// order ID makes the reservation idempotent.
+type InventoryStep struct {
+	Client inventory.InventoryServiceClient
+}
+
+func (s *InventoryStep) Do(ctx context.Context, cmd saga.Command) (map[string]string, error) {
+	var in Input
+	if err := json.Unmarshal(cmd.Input, &in); err != nil {
+		return nil, fmt.Errorf("%w: invalid order: %v", saga.ErrRejected, err)
+	}
+	items := make([]*inventory.StockItem, len(in.Items))
+	for i, item := range in.Items {
+		items[i] = &inventory.StockItem{ProductID: item.ProductID, Quantity: item.Quantity}
+	}
+
+	reservation, err := s.Client.ReserveStock(ctx, &inventory.ReserveStockRequest{
+		OrderID: cmd.Data["order_id"],
+		Items:   items,
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return map[string]string{"reservation_id": reservation.ReservationID}, nil
+}
+
+func (s *InventoryStep) Undo(ctx context.Context, cmd saga.Command) error {
+	_, err := s.Client.ReleaseReservation(ctx, &inventory.ReleaseReservationRequest{
+		ReservationID: cmd.Data["reservation_id"],
+		Reason:        "order placement failed",
+	})
+	return grpcError(err)
+}
+
+// PaymentStep charges the order total and refunds it. The order ID is the
+// idempotency key, so a resent command cannot charge twice.
+type PaymentStep struct {
+	Client payment.PaymentServiceClient
+}
+
+func (s *PaymentStep) Do(ctx context.Context, cmd saga.Command) (map[string]string, error) {
+	orderID := cmd.Data["order_id"]
+	amount, err := strconv.ParseInt(cmd.Data["total_cents"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid order total %q", saga.ErrRejected, cmd.Data["total_cents"])
+	}
+
+	resp, err := s.Client.ProcessPayment(ctx, &payment.PaymentRequest{
+		IdempotencyKey: orderID,
+		OrderID:        orderID,
+		AmountCents:    amount,
+		Currency:       cmd.Data["currency"],
+		CustomerEmail:  cmd.Data["customer_email"],
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("%w: payment declined: %s: %s", saga.ErrRejected, resp.ErrorCode, resp.ErrorMessage)
+	}
+	return map[string]string{"transaction_id": resp.TransactionID}, nil
+}
+
+// Undo refunds a completed payment and voids a pending one. A payment that
+// is already refunded or cancelled counts as compensated.
+func (s *PaymentStep) Undo(ctx context.Context, cmd saga.Command) error {
+	txID := cmd.Data["transaction_id"]
+	current, err := s.Client.GetPaymentStatus(ctx, &payment.PaymentStatusRequest{TransactionID: txID})
+	if err != nil {
+		return grpcError(err)
+	}
+
+	switch current.Status {
+	case payment.PaymentStatus_PAYMENT_STATUS_REFUNDED, payment.PaymentStatus_PAYMENT_STATUS_CANCELLED:
+		return nil
+	case payment.PaymentStatus_PAYMENT_STATUS_PENDING:
+		_, err = s.Client.CancelPayment(ctx, &payment.CancelPaymentRequest{
+			TransactionID: txID,
+			Reason:        "order placement failed",
+		})
+	default:
+		_, err = s.Client.RefundPayment(ctx, &payment.RefundPaymentRequest{
+			TransactionID: txID,
+			Reason:        "order placement failed",
+		})
+	}
+	return grpcError(err)
+}
+
+// ShippingStep marks the order PAID, which has the shipping service create
+// its shipment, and waits for the shipment. A shipment is not undone.
+type ShippingStep struct {
+	Orders   *OrderClient
+	Client   shipping.ShippingServiceClient
+	Interval time.Duration
+}
+
+func (s *ShippingStep) Do(ctx context.Context, cmd saga.Command) (map[string]string, error) {
+	orderID := cmd.Data["order_id"]
+	if _, err := s.Orders.RecordPayment(ctx, orderID, cmd.Data["transaction_id"]); err != nil {
+		return nil, httpError(err)
+	}
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		shipment, err := s.Client.GetShipment(ctx, &shipping.GetShipmentRequest{OrderID: orderID})
+		if err == nil {
+			return map[string]string{"shipment_id": shipment.ShipmentID}, nil
+		}
+		if status.Code(err) != codes.NotFound {
+			return nil, grpcError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.New("shipment not created yet")
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ShippingStep) Undo(ctx context.Context, cmd saga.Command) error {
+	return nil
+}
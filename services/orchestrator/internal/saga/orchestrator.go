@@ -0,0 +1,414 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+	"github.com/google/uuid"
+)
+
+var logger = logging.Component("saga")
+
+// Config tunes how the orchestrator waits on participants.
+type Config struct {
+	StepAttempts int           `config:"step-attempts" usage:"Commands sent for a step or its compensation before it counts as failed"`
+	TimeoutCheck time.Duration `config:"timeout-check" usage:"How often workflows are checked for commands that timed out"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		StepAttempts: 3,
+		TimeoutCheck: time.Second,
+	}
+}
+
+func (c Config) Validate() error {
+	if c.StepAttempts < 1 {
+		return errors.New("step-attempts must be at least 1")
+	}
+	if c.TimeoutCheck <= 0 {
+		return errors.New("timeout-check must be positive")
+	}
+	return nil
+}
+
+// Orchestrator starts workflows and moves them along as replies arrive and
+// commands time out. Transitions are serialized, so one orchestrator
+// process may drive any number of workflows.
+type Orchestrator struct {
+	store  Store
+	broker broker.Publisher
+	config Config
+	defs   map[string]Definition
+	now    func() time.Time
+
+	mu sync.Mutex
+}
+
+func NewOrchestrator(store Store, b broker.Publisher, cfg Config, defs ...Definition) *Orchestrator {
+	o := &Orchestrator{
+		store:  store,
+		broker: b,
+		config: cfg,
+		defs:   make(map[string]Definition, len(defs)),
+		now:    time.Now,
+	}
+	for _, d := range defs {
+		o.defs[d.Type] = d
+	}
+	return o
+}
+
+// Start creates a workflow of type typ for the tenant of ctx and sends the
+// command of its first step.
+func (o *Orchestrator) Start(ctx context.Context, typ string, input any) (*Workflow, error) {
+	def, ok := o.defs[typ]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownWorkflowType, typ)
+	}
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("encode input: %w", err)
+	}
+
+	now := o.now()
+	w := &Workflow{
+		ID:        "wf_" + uuid.New().String()[:8],
+		TenantID:  tenant.FromContext(ctx),
+		Type:      typ,
+		Status:    StatusRunning,
+		Steps:     make([]StepState, len(def.Steps)),
+		Input:     raw,
+		Data:      make(map[string]string),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for i, s := range def.Steps {
+		w.Steps[i] = StepState{Name: s.Name, Status: StepPending}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := o.store.Create(ctx, w); err != nil {
+		return nil, fmt.Errorf("store workflow: %w", err)
+	}
+	ctx = logging.WithAttrs(ctx, "workflow_id", w.ID)
+	logger.InfoContext(ctx, "workflow started", "type", typ)
+	if err := o.send(ctx, def, w, false); err != nil {
+		return nil, err
+	}
+	return cloneWorkflow(w), nil
+}
+
+// Get returns the workflow id.
+func (o *Orchestrator) Get(ctx context.Context, id string) (*Workflow, error) {
+	return o.store.Get(ctx, id)
+}
+
+// List returns the workflows of the tenant of ctx, newest first.
+func (o *Orchestrator) List(ctx context.Context, status Status, limit int) ([]*Workflow, error) {
+	return o.store.List(ctx, tenant.FromContext(ctx), status, limit)
+}
+
+// Ping checks the workflow store.
+func (o *Orchestrator) Ping(ctx context.Context) error {
+	return o.store.Ping(ctx)
+}
+
+// HandleReply is the broker handler of RepliesTopic. Replies to commands
+// the workflow is no longer waiting on, such as those to a resent command,
+// are ignored.
+func (o *Orchestrator) HandleReply(msg *broker.Message) error {
+	var r Reply
+	if err := msg.Decode(&r); err != nil {
+		return err
+	}
+	ctx := logging.WithAttrs(msg.Context(context.Background()),
+		"workflow_id", r.WorkflowID, "step", r.Step, "compensate", r.Compensate)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	w, err := o.store.Get(ctx, r.WorkflowID)
+	if errors.Is(err, ErrWorkflowNotFound) {
+		logger.WarnContext(ctx, "reply for unknown workflow")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	def, ok := o.defs[w.Type]
+	if !ok {
+		logger.WarnContext(ctx, "reply for workflow of unknown type", "type", w.Type)
+		return nil
+	}
+	i := def.index(r.Step)
+	if i < 0 {
+		logger.WarnContext(ctx, "reply for unknown step")
+		return nil
+	}
+
+	st := w.Steps[i]
+	switch {
+	case !r.Compensate && w.Status == StatusRunning && i == w.Step && st.Status == StepRunning:
+		return o.stepReplied(ctx, def, w, r)
+	case r.Compensate && w.Status == StatusCompensating && i == w.Step && st.Status == StepCompensating:
+		return o.compensationReplied(ctx, def, w, r)
+	case !r.Compensate && r.Success && st.Status == StepFailed && def.Steps[i].Compensable:
+		// The step timed out but went through after all, so it has to be
+		// undone with the others.
+		logger.WarnContext(ctx, "late reply for failed step, compensating it")
+		o.merge(w, r.Output)
+		w.Steps[i].Status = StepDone
+		if w.Status == StatusCompensating {
+			return o.update(ctx, w)
+		}
+		w.Status = StatusCompensating
+		return o.compensateNext(ctx, def, w)
+	default:
+		logger.DebugContext(ctx, "ignoring stale reply", "workflow_status", string(w.Status), "step_status", string(st.Status))
+		return nil
+	}
+}
+
+func (o *Orchestrator) stepReplied(ctx context.Context, def Definition, w *Workflow, r Reply) error {
+	now := o.now()
+	st := &w.Steps[w.Step]
+	st.FinishedAt = &now
+
+	if !r.Success {
+		st.Status = StepFailed
+		st.Error = r.Error
+		logger.WarnContext(ctx, "step failed", "error", r.Error)
+		return o.compensate(ctx, def, w, fmt.Sprintf("%s: %s", st.Name, r.Error))
+	}
+
+	st.Status = StepDone
+	st.Error = ""
+	o.merge(w, r.Output)
+	logger.InfoContext(ctx, "step done")
+
+	if w.Step == len(def.Steps)-1 {
+		w.Status = StatusCompleted
+		w.Deadline = time.Time{}
+		logger.InfoContext(ctx, "workflow completed")
+		return o.update(ctx, w)
+	}
+	w.Step++
+	return o.send(ctx, def, w, false)
+}
+
+func (o *Orchestrator) compensationReplied(ctx context.Context, def Definition, w *Workflow, r Reply) error {
+	now := o.now()
+	st := &w.Steps[w.Step]
+	st.FinishedAt = &now
+
+	if r.Success {
+		st.Status = StepCompensated
+		st.Error = ""
+		logger.InfoContext(ctx, "step compensated")
+	} else {
+		st.Status = StepCompensationFailed
+		st.Error = r.Error
+		logger.ErrorContext(ctx, "compensation failed", "error", r.Error)
+	}
+	return o.compensateNext(ctx, def, w)
+}
+
+// compensate starts undoing the done steps of w because of reason.
+func (o *Orchestrator) compensate(ctx context.Context, def Definition, w *Workflow, reason string) error {
+	w.Status = StatusCompensating
+	w.Error = reason
+	return o.compensateNext(ctx, def, w)
+}
+
+// compensateNext sends the compensation of the last done step, or finishes
+// w when no step is left to undo.
+func (o *Orchestrator) compensateNext(ctx context.Context, def Definition, w *Workflow) error {
+	for i := len(def.Steps) - 1; i >= 0; i-- {
+		if w.Steps[i].Status == StepDone && def.Steps[i].Compensable {
+			w.Step = i
+			w.Steps[i].Attempts = 0
+			return o.send(ctx, def, w, true)
+		}
+	}
+
+	w.Status = StatusCompensated
+	for _, st := range w.Steps {
+		if st.Status == StepCompensationFailed {
+			w.Status = StatusFailed
+		}
+	}
+	w.Deadline = time.Time{}
+	if w.Status == StatusFailed {
+		logger.ErrorContext(ctx, "workflow failed, a compensation did not go through", "reason", w.Error)
+	} else {
+		logger.InfoContext(ctx, "workflow compensated", "reason", w.Error)
+	}
+	return o.update(ctx, w)
+}
+
+// send stores w waiting on the command of its current step, or of that
+// step's compensation, and publishes the command. A command that cannot be
+// published is sent again when it times out.
+func (o *Orchestrator) send(ctx context.Context, def Definition, w *Workflow, compensate bool) error {
+	now := o.now()
+	step := def.Steps[w.Step]
+	st := &w.Steps[w.Step]
+
+	st.Attempts++
+	if compensate {
+		st.Status = StepCompensating
+	} else {
+		st.Status = StepRunning
+		if st.StartedAt == nil {
+			st.StartedAt = &now
+		}
+	}
+	w.Deadline = now.Add(step.Timeout)
+	if err := o.update(ctx, w); err != nil {
+		return err
+	}
+
+	if err := o.publish(ctx, w, step.Name, compensate); err != nil {
+		logger.ErrorContext(ctx, "failed to publish command", "step", step.Name, logging.Err(err))
+	}
+	return nil
+}
+
+func (o *Orchestrator) publish(ctx context.Context, w *Workflow, step string, compensate bool) error {
+	msg, err := broker.NewMessage(CommandMessage, Command{
+		WorkflowID: w.ID,
+		Step:       step,
+		Compensate: compensate,
+		Input:      w.Input,
+		Data:       w.Data,
+	})
+	if err != nil {
+		return err
+	}
+	msg.SetHeaders(broker.MessageHeaders{CorrelationID: w.ID, ReplyTo: RepliesTopic})
+	return o.broker.Publish(tenant.NewContext(ctx, w.TenantID), CommandTopic(step), msg)
+}
+
+func (o *Orchestrator) update(ctx context.Context, w *Workflow) error {
+	w.UpdatedAt = o.now()
+	if err := o.store.Update(ctx, w); err != nil {
+		return fmt.Errorf("store workflow: %w", err)
+	}
+	return nil
+}
+
+func (o *Orchestrator) merge(w *Workflow, output map[string]string) {
+	if w.Data == nil {
+		w.Data = make(map[string]string, len(output))
+	}
+	for k, v := range output {
+		w.Data[k] = v
+	}
+}
+
+// CheckTimeouts resends the commands that were not answered in time. A
+// step whose command went unanswered StepAttempts times fails and the
+// workflow is compensated; a compensation that went unanswered as often
+// is marked failed.
+func (o *Orchestrator) CheckTimeouts(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	active, err := o.store.Active(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := o.now()
+	for _, w := range active {
+		if w.Deadline.IsZero() || now.Before(w.Deadline) {
+			continue
+		}
+		def, ok := o.defs[w.Type]
+		if !ok {
+			continue
+		}
+
+		wctx := logging.WithAttrs(tenant.NewContext(ctx, w.TenantID), "workflow_id", w.ID)
+		if err := o.timedOut(wctx, def, w); err != nil {
+			logger.ErrorContext(wctx, "failed to handle timed out command", logging.Err(err))
+		}
+	}
+	return nil
+}
+
+func (o *Orchestrator) timedOut(ctx context.Context, def Definition, w *Workflow) error {
+	st := &w.Steps[w.Step]
+	compensating := w.Status == StatusCompensating
+
+	if st.Attempts < o.config.StepAttempts {
+		logger.WarnContext(ctx, "command timed out, resending",
+			"step", st.Name, "compensate", compensating, "attempts", st.Attempts)
+		return o.send(ctx, def, w, compensating)
+	}
+
+	now := o.now()
+	st.FinishedAt = &now
+	st.Error = fmt.Sprintf("no reply after %d attempts", st.Attempts)
+	if compensating {
+		st.Status = StepCompensationFailed
+		logger.ErrorContext(ctx, "compensation timed out", "step", st.Name)
+		return o.compensateNext(ctx, def, w)
+	}
+	st.Status = StepFailed
+	logger.WarnContext(ctx, "step timed out", "step", st.Name)
+	return o.compensate(ctx, def, w, fmt.Sprintf("%s: %s", st.Name, st.Error))
+}
+
+// Resume publishes again the commands that active workflows are waiting
+// on, for those a previous process sent but did not see answered.
+func (o *Orchestrator) Resume(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	active, err := o.store.Active(ctx)
+	if err != nil {
+		return err
+	}
+	for _, w := range active {
+		def, ok := o.defs[w.Type]
+		if !ok {
+			continue
+		}
+		step := def.Steps[w.Step].Name
+		if err := o.publish(ctx, w, step, w.Status == StatusCompensating); err != nil {
+			return fmt.Errorf("resume workflow %s: %w", w.ID, err)
+		}
+	}
+	if len(active) > 0 {
+		logger.InfoContext(ctx, "workflows resumed", "count", len(active))
+	}
+	return nil
+}
+
+// Run checks for timed out commands every TimeoutCheck until ctx is done.
+func (o *Orchestrator) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.config.TimeoutCheck)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := o.CheckTimeouts(ctx); err != nil {
+				logger.ErrorContext(ctx, "failed to check workflow timeouts", logging.Err(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+)
+
+// Participant carries out one step of a workflow and undoes it. Both may
+// be called more than once for the same workflow and must not repeat their
+// effect. Errors wrapping ErrRejected are answered with a failed reply;
+// other errors leave the command to be retried.
+type Participant interface {
+	// Do carries out the step, returning what to merge into the data of
+	// the workflow.
+	Do(ctx context.Context, cmd Command) (map[string]string, error)
+
+	// Undo compensates the step. It is only called after Do succeeded.
+	Undo(ctx context.Context, cmd Command) error
+}
+
+// Serve returns the broker handler that answers the commands of a step with
+// p. Each command is given timeout to complete. Replies go to the ReplyTo
+// topic of the command, RepliesTopic for commands without one.
+func Serve(b broker.Publisher, p Participant, timeout time.Duration) broker.MessageHandler {
+	return func(msg *broker.Message) error {
+		var cmd Command
+		if err := msg.Decode(&cmd); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(msg.Context(context.Background()), timeout)
+		defer cancel()
+		ctx = logging.WithAttrs(ctx, "workflow_id", cmd.WorkflowID, "step", cmd.Step, "compensate", cmd.Compensate)
+
+		reply := Reply{
+			WorkflowID: cmd.WorkflowID,
+			Step:       cmd.Step,
+			Compensate: cmd.Compensate,
+			Success:    true,
+		}
+		var err error
+		if cmd.Compensate {
+			err = p.Undo(ctx, cmd)
+		} else {
+			reply.Output, err = p.Do(ctx, cmd)
+		}
+		switch {
+		case errors.Is(err, ErrRejected):
+			logger.WarnContext(ctx, "command rejected", logging.Err(err))
+			reply.Success = false
+			reply.Output = nil
+			reply.Error = err.Error()
+		case err != nil:
+			logger.ErrorContext(ctx, "command failed", logging.Err(err))
+			return err
+		}
+
+		out, err := broker.NewMessage(ReplyMessage, reply)
+		if err != nil {
+			return err
+		}
+		replyTo := msg.Headers().ReplyTo
+		if replyTo == "" {
+			replyTo = RepliesTopic
+		}
+		return b.Publish(ctx, replyTo, out)
+	}
+}
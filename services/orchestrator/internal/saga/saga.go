@@ -0,0 +1,168 @@
+// Package saga runs workflows that span several services as sagas. A
+// workflow is a sequence of steps; each step is a command published on the
+// broker and answered by a reply, and has a compensation that undoes it.
+// When a step fails or times out, the steps done before it are compensated
+// in reverse order.
+//
+// The Orchestrator stores a workflow after every transition, so a restarted
+// orchestrator resends the command it was waiting on and carries on.
+// Participants must therefore handle a command more than once with the
+// same result.
+package saga
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Status is the state of a workflow.
+type Status string
+
+const (
+	// StatusRunning workflows are carrying out their steps.
+	StatusRunning Status = "running"
+
+	// StatusCompensating workflows had a step fail and are undoing the
+	// steps done before it.
+	StatusCompensating Status = "compensating"
+
+	// StatusCompleted workflows carried out every step.
+	StatusCompleted Status = "completed"
+
+	// StatusCompensated workflows failed and had every done step undone.
+	StatusCompensated Status = "compensated"
+
+	// StatusFailed workflows could not undo a step; they need an operator.
+	StatusFailed Status = "failed"
+)
+
+// Terminal reports whether a workflow in s is finished.
+func (s Status) Terminal() bool {
+	return s == StatusCompleted || s == StatusCompensated || s == StatusFailed
+}
+
+// StepStatus is the state of one step of a workflow.
+type StepStatus string
+
+const (
+	StepPending            StepStatus = "pending"
+	StepRunning            StepStatus = "running"
+	StepDone               StepStatus = "done"
+	StepFailed             StepStatus = "failed"
+	StepCompensating       StepStatus = "compensating"
+	StepCompensated        StepStatus = "compensated"
+	StepCompensationFailed StepStatus = "compensation_failed"
+)
+
+// StepState records the progress of one step.
+type StepState struct {
+	Name       string     `json:"name"`
+	Status     StepStatus `json:"status"`
+	Attempts   int        `json:"attempts"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Workflow is one run of a Definition.
+type Workflow struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+	Type     string `json:"type"`
+	Status   Status `json:"status"`
+
+	// Step is the index of the step being carried out or compensated.
+	Step  int         `json:"step"`
+	Steps []StepState `json:"steps"`
+
+	// Input is what the workflow was started with. Data collects the
+	// outputs of its steps, such as the IDs later steps and compensations
+	// act on.
+	Input json.RawMessage   `json:"input"`
+	Data  map[string]string `json:"data"`
+
+	// Error is why the workflow is compensating or finished without
+	// completing.
+	Error string `json:"error,omitempty"`
+
+	// Deadline is when the command being waited on times out.
+	Deadline  time.Time `json:"deadline"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Step describes one step of a Definition.
+type Step struct {
+	Name string
+
+	// Timeout is how long the orchestrator waits for a reply before it
+	// resends the command.
+	Timeout time.Duration
+
+	// Compensable steps are undone when a later step fails.
+	Compensable bool
+}
+
+// Definition is the ordered list of steps of a workflow type.
+type Definition struct {
+	Type  string
+	Steps []Step
+}
+
+func (d Definition) index(step string) int {
+	for i, s := range d.Steps {
+		if s.Name == step {
+			return i
+		}
+	}
+	return -1
+}
+
+// Broker topics. Commands for a step go to CommandTopic(step); every reply
+// goes to RepliesTopic, which commands name in their ReplyTo header. Both
+// carry the workflow ID as correlation ID.
+const RepliesTopic = "saga.replies"
+
+func CommandTopic(step string) string {
+	return "saga.command." + step
+}
+
+// Message types of commands and replies.
+const (
+	CommandMessage = "saga.command"
+	ReplyMessage   = "saga.reply"
+)
+
+// Command asks the participant of Step to carry it out, or to undo it when
+// Compensate is set.
+type Command struct {
+	WorkflowID string            `json:"workflow_id"`
+	Step       string            `json:"step"`
+	Compensate bool              `json:"compensate"`
+	Input      json.RawMessage   `json:"input"`
+	Data       map[string]string `json:"data"`
+}
+
+// Reply answers a Command. Output is merged into the data of the workflow.
+type Reply struct {
+	WorkflowID string            `json:"workflow_id"`
+	Step       string            `json:"step"`
+	Compensate bool              `json:"compensate"`
+	Success    bool              `json:"success"`
+	Error      string            `json:"error,omitempty"`
+	Output     map[string]string `json:"output,omitempty"`
+}
+
+var (
+	// ErrWorkflowNotFound is returned for an unknown workflow ID.
+	ErrWorkflowNotFound = errors.New("workflow not found")
+
+	// ErrUnknownWorkflowType is returned by Start for a type without a
+	// registered definition.
+	ErrUnknownWorkflowType = errors.New("unknown workflow type")
+
+	// ErrRejected is wrapped by participants that refuse a command for
+	// good, such as a declined payment. Other errors are retried.
+	ErrRejected = errors.New("rejected")
+)
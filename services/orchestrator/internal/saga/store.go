@@ -0,0 +1,269 @@
+package saga
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Store persists workflows. Implementations return copies, so callers may
+// modify the workflows they receive.
+type Store interface {
+	Create(ctx context.Context, w *Workflow) error
+	Get(ctx context.Context, id string) (*Workflow, error)
+	Update(ctx context.Context, w *Workflow) error
+
+	// List returns the workflows of tenantID, newest first, keeping those
+	// in status unless it is empty, up to limit of them; 0 keeps all.
+	List(ctx context.Context, tenantID string, status Status, limit int) ([]*Workflow, error)
+
+	// Active returns the running and compensating workflows of every
+	// tenant.
+	Active(ctx context.Context) ([]*Workflow, error)
+
+	Ping(ctx context.Context) error
+}
+
+func cloneWorkflow(w *Workflow) *Workflow {
+	c := *w
+	c.Steps = append([]StepState(nil), w.Steps...)
+	c.Input = append(json.RawMessage(nil), w.Input...)
+	c.Data = make(map[string]string, len(w.Data))
+	for k, v := range w.Data {
+		c.Data[k] = v
+	}
+	return &c
+}
+
+// MemoryStore keeps workflows for the lifetime of the process.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	workflows map[string]*Workflow
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{workflows: make(map[string]*Workflow)}
+}
+
+func (s *MemoryStore) Create(ctx context.Context, w *Workflow) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.workflows[w.ID]; ok {
+		return fmt.Errorf("workflow %s already exists", w.ID)
+	}
+	s.workflows[w.ID] = cloneWorkflow(w)
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Workflow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	w, ok := s.workflows[id]
+	if !ok {
+		return nil, ErrWorkflowNotFound
+	}
+	return cloneWorkflow(w), nil
+}
+
+func (s *MemoryStore) Update(ctx context.Context, w *Workflow) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.workflows[w.ID]; !ok {
+		return ErrWorkflowNotFound
+	}
+	s.workflows[w.ID] = cloneWorkflow(w)
+	return nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, tenantID string, status Status, limit int) ([]*Workflow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []*Workflow
+	for _, w := range s.workflows {
+		if w.TenantID == tenantID && (status == "" || w.Status == status) {
+			out = append(out, cloneWorkflow(w))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) Active(ctx context.Context) ([]*Workflow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []*Workflow
+	for _, w := range s.workflows {
+		if !w.Status.Terminal() {
+			out = append(out, cloneWorkflow(w))
+		}
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+// sqlTimeLayout has a fixed width so that timestamps sort as text.
+const sqlTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// SQLStore stores workflows in a saga_workflows table, the whole workflow
+// as a JSON column next to the columns it is looked up by.
+type SQLStore struct {
+	db       *sql.DB
+	postgres bool
+}
+
+// NewSQLStore creates the table if needed. driver is the name passed to
+// sql.Open and selects the placeholder style.
+func NewSQLStore(ctx context.Context, db *sql.DB, driver string) (*SQLStore, error) {
+	s := &SQLStore{
+		db:       db,
+		postgres: driver == "pgx" || driver == "postgres",
+	}
+
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS saga_workflows (
+		id         TEXT PRIMARY KEY,
+		tenant_id  TEXT NOT NULL,
+		status     TEXT NOT NULL,
+		state      TEXT NOT NULL,
+		created_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create workflow table: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS saga_workflows_tenant_created_at ON saga_workflows (tenant_id, created_at)`)
+	if err != nil {
+		return nil, fmt.Errorf("create workflow index: %w", err)
+	}
+	_, err = db.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS saga_workflows_status ON saga_workflows (status)`)
+	if err != nil {
+		return nil, fmt.Errorf("create workflow index: %w", err)
+	}
+
+	return s, nil
+}
+
+// rebind rewrites ? placeholders as $n for Postgres.
+func (s *SQLStore) rebind(query string) string {
+	if !s.postgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (s *SQLStore) Create(ctx context.Context, w *Workflow) error {
+	state, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, s.rebind(`INSERT INTO saga_workflows (id, tenant_id, status, state, created_at)
+		VALUES (?, ?, ?, ?, ?)`),
+		w.ID, w.TenantID, string(w.Status), string(state), w.CreatedAt.UTC().Format(sqlTimeLayout))
+	return err
+}
+
+func (s *SQLStore) Get(ctx context.Context, id string) (*Workflow, error) {
+	row := s.db.QueryRowContext(ctx, s.rebind(`SELECT state FROM saga_workflows WHERE id = ?`), id)
+	w, err := scanWorkflow(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrWorkflowNotFound
+	}
+	return w, err
+}
+
+func (s *SQLStore) Update(ctx context.Context, w *Workflow) error {
+	state, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, s.rebind(`UPDATE saga_workflows SET status = ?, state = ? WHERE id = ?`),
+		string(w.Status), string(state), w.ID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrWorkflowNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) List(ctx context.Context, tenantID string, status Status, limit int) ([]*Workflow, error) {
+	query := `SELECT state FROM saga_workflows WHERE tenant_id = ?`
+	args := []any{tenantID}
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, string(status))
+	}
+	query += ` ORDER BY created_at DESC`
+	if limit > 0 {
+		query += fmt.Sprintf(` LIMIT %d`, limit)
+	}
+	return s.query(ctx, query, args...)
+}
+
+func (s *SQLStore) Active(ctx context.Context) ([]*Workflow, error) {
+	return s.query(ctx, `SELECT state FROM saga_workflows WHERE status IN (?, ?)`,
+		string(StatusRunning), string(StatusCompensating))
+}
+
+func (s *SQLStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *SQLStore) query(ctx context.Context, query string, args ...any) ([]*Workflow, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Workflow
+	for rows.Next() {
+		w, err := scanWorkflow(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWorkflow(row rowScanner) (*Workflow, error) {
+	var state string
+	if err := row.Scan(&state); err != nil {
+		return nil, err
+	}
+	var w Workflow
+	if err := json.Unmarshal([]byte(state), &w); err != nil {
+		return nil, fmt.Errorf("decode workflow: %w", err)
+	}
+	return &w, nil
+}
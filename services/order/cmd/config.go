@@ -0,0 +1,204 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/chaos"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/circuitbreaker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/config"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/discovery"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcconn"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/ratelimit"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/order/internal/handler"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/order/internal/service"
+)
+
+// Config holds the startup settings of the order service.
+type Config struct {
+	HTTP      config.HTTPServer `config:"http"`
+	Payment   PaymentConfig     `config:"payment"`
+	Inventory UpstreamConfig    `config:"inventory"`
+	Customer  UpstreamConfig    `config:"customer"`
+	Catalog   CatalogConfig     `config:"catalog"`
+	Discovery discovery.Config  `config:",inline"`
+
+	APIKeys     string `config:"api-keys,secret" usage:"Comma separated key:name[:role|role] entries accepted as credentials"`
+	JWTSecret   string `config:"jwt-secret,secret" usage:"HMAC secret for JWT validation"`
+	JWKSURL     string `config:"jwks-url" usage:"JWKS URL for RSA/ECDSA signed JWTs"`
+	JWTIssuer   string `config:"jwt-issuer" usage:"Required JWT issuer"`
+	JWTAudience string `config:"jwt-audience" usage:"Required JWT audience"`
+
+	CORS         handler.CORSConfig `config:"cors"`
+	RateLimit    ratelimit.Config   `config:",inline"`
+	MaxBodyBytes int64              `config:"max-body-bytes" usage:"Largest request body accepted, larger ones get 413"`
+
+	RequestTimeout time.Duration `config:"request-timeout" usage:"Deadline of each API request, shared by the payment calls it makes; 0 disables"`
+	SSEHeartbeat   time.Duration `config:"sse-heartbeat" usage:"Interval between heartbeats on GET /orders/events"`
+	ReadyTimeout   time.Duration `config:"ready-timeout" usage:"Time allowed for the dependency checks of GET /readyz"`
+	Store          string        `config:"store" usage:"Order store: memory, sqlite or postgres"`
+	StoreDSN       string        `config:"store-dsn,secret" usage:"SQLite file or Postgres connection string"`
+
+	Cache service.CacheConfig `config:",inline"`
+
+	Validation    service.ValidationConfig `config:",inline"`
+	Coupons       string                   `config:"coupons" usage:"Comma separated CODE:percent%|amount_cents[:min_subtotal_cents] coupons"`
+	TaxRates      string                   `config:"tax-rates" usage:"Comma separated region:percent tax rates, region is COUNTRY or COUNTRY-STATE"`
+	LineDiscounts string                   `config:"line-discounts" usage:"Comma separated [product_id:]min_quantity:percent volume discounts"`
+
+	AsyncOrders    bool `config:"async-orders" usage:"Answer POST /orders with 202 and charge every order in the background, not only those sent with Prefer: respond-async"`
+	RequestWorkers int  `config:"request-workers" usage:"Workers charging orders accepted with 202"`
+
+	Bulk        service.ImporterConfig    `config:",inline"`
+	Expiry      service.ExpiryConfig      `config:",inline"`
+	Degradation service.DegradationConfig `config:"degradation"`
+
+	ShutdownTimeout time.Duration `config:"shutdown-timeout" usage:"Time allowed for a graceful shutdown on SIGINT or SIGTERM"`
+	DrainTimeout    time.Duration `config:"drain-timeout" usage:"Time workers may spend on queued messages during shutdown, within shutdown-timeout"`
+
+	BrokerTopology string `config:"broker-topology" usage:"YAML or JSON file declaring the topics, queues and subscriptions of the broker, replacing the built-in topology; GET /admin/topology exports the current one"`
+
+	Chaos  chaos.Config        `config:",inline"`
+	Broker broker.BrokerConfig `config:"broker"`
+	Log    logging.Config      `config:"log"`
+}
+
+// PaymentConfig configures the connection to the payment service. Its
+// keepalive and backoff settings also apply to the other upstreams.
+type PaymentConfig struct {
+	Addr  string           `config:"addr" usage:"Payment service gRPC address, a comma separated list of addresses, a target such as dns:///payment:50051 or a service name resolved through discovery"`
+	Token string           `config:"token,secret" usage:"API key or JWT sent to the payment service"`
+	TLS   config.ClientTLS `config:"tls"`
+
+	Conn      grpcconn.ClientConfig    `config:",inline"`
+	Balancing grpcconn.BalancingConfig `config:",inline"`
+	Retry     service.RetryConfig      `config:",inline"`
+	Breaker   circuitbreaker.Config    `config:",inline"`
+
+	Client grpcmw.ClientConfig `config:"client"`
+}
+
+// UpstreamConfig configures an optional gRPC dependency; an empty address
+// disables it.
+type UpstreamConfig struct {
+	Addr  string `config:"addr" usage:"gRPC address or service name resolved through discovery, empty disables the service"`
+	Token string `config:"token,secret" usage:"API key or JWT sent to the service"`
+
+	Client grpcmw.ClientConfig `config:"client"`
+}
+
+// CatalogConfig configures the optional catalog service and how the unit
+// prices of new orders are reconciled with it.
+type CatalogConfig struct {
+	Upstream UpstreamConfig           `config:",inline"`
+	Prices   service.PriceCheckConfig `config:",inline"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		HTTP: config.DefaultHTTPServer(8080),
+		Payment: PaymentConfig{
+			Addr:      "localhost:50051",
+			Conn:      grpcconn.DefaultClientConfig(),
+			Balancing: grpcconn.DefaultBalancingConfig(),
+			Retry:     service.DefaultRetryConfig(),
+			Breaker:   circuitbreaker.DefaultConfig(),
+			Client:    grpcmw.DefaultClientConfig(),
+		},
+		Catalog: CatalogConfig{
+			Upstream: UpstreamConfig{Client: grpcmw.DefaultClientConfig()},
+			Prices:   service.DefaultPriceCheckConfig(),
+		},
+		Inventory:       UpstreamConfig{Client: grpcmw.DefaultClientConfig()},
+		Customer:        UpstreamConfig{Client: grpcmw.DefaultClientConfig()},
+		Discovery:       discovery.DefaultConfig(),
+		CORS:            handler.DefaultCORSConfig(),
+		RateLimit:       ratelimit.DefaultConfig(),
+		MaxBodyBytes:    1 << 20,
+		RequestTimeout:  10 * time.Second,
+		SSEHeartbeat:    handler.DefaultHeartbeatInterval,
+		ReadyTimeout:    2 * time.Second,
+		Store:           "memory",
+		Cache:           service.DefaultCacheConfig(),
+		Validation:      service.DefaultValidationConfig(),
+		Coupons:         "WELCOME10:10%,SAVE20:2000:10000",
+		TaxRates:        "BR:17,BR-SP:18,US-CA:7.25,US-NY:4,DE:19",
+		RequestWorkers:  4,
+		Bulk:            service.DefaultImporterConfig(),
+		Expiry:          service.DefaultExpiryConfig(),
+		Degradation:     service.DefaultDegradationConfig(),
+		ShutdownTimeout: 30 * time.Second,
+		DrainTimeout:    10 * time.Second,
+		Chaos:           chaos.DefaultConfig(),
+		Broker:          broker.DefaultBrokerConfig(),
+		Log:             logging.DefaultConfig(),
+	}
+}
+
+func (c Config) Validate() error {
+	switch c.Store {
+	case "memory":
+	case "sqlite", "postgres":
+		if c.StoreDSN == "" {
+			return fmt.Errorf("store %s requires store-dsn", c.Store)
+		}
+	default:
+		return fmt.Errorf("unknown store %q", c.Store)
+	}
+	if c.RequestWorkers < 1 {
+		return errors.New("request-workers must be at least 1")
+	}
+	if c.MaxBodyBytes < 1 {
+		return errors.New("max-body-bytes must be at least 1")
+	}
+	if c.RequestTimeout < 0 {
+		return errors.New("request-timeout must not be negative")
+	}
+	if c.HTTP.WriteTimeout > 0 && c.RequestTimeout > c.HTTP.WriteTimeout {
+		return errors.New("request-timeout must not exceed http-write-timeout")
+	}
+	if _, err := c.Pricing(); err != nil {
+		return err
+	}
+	if _, err := auth.ParseStaticKeys(c.APIKeys); err != nil {
+		return fmt.Errorf("api-keys: %w", err)
+	}
+	if !c.Discovery.Enabled() {
+		for name, addr := range map[string]string{"payment": c.Payment.Addr, "inventory": c.Inventory.Addr, "customer": c.Customer.Addr, "catalog": c.Catalog.Upstream.Addr} {
+			if discovery.IsName(addr) {
+				return fmt.Errorf("%s-addr %q has no port; service names require discovery", name, addr)
+			}
+		}
+	}
+	return nil
+}
+
+func (c PaymentConfig) Validate() error {
+	if c.Addr == "" {
+		return errors.New("addr is required")
+	}
+	return nil
+}
+
+// Pricing parses the coupon, tax rate and line discount settings.
+func (c Config) Pricing() (service.PricingConfig, error) {
+	var (
+		cfg service.PricingConfig
+		err error
+	)
+	if cfg.Coupons, err = service.ParseCoupons(c.Coupons); err != nil {
+		return cfg, fmt.Errorf("coupons: %w", err)
+	}
+	if cfg.TaxRates, err = service.ParseTaxRates(c.TaxRates); err != nil {
+		return cfg, fmt.Errorf("tax-rates: %w", err)
+	}
+	if cfg.LineDiscounts, err = service.ParseLineDiscounts(c.LineDiscounts); err != nil {
+		return cfg, fmt.Errorf("line-discounts: %w", err)
+	}
+	return cfg, nil
+}
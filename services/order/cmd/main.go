@@ -2,145 +2,630 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
 	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/cache"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/chaos"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/circuitbreaker"
 	_ "github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/codec"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/config"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/discovery"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcconn"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/metrics"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/ratelimit"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/reqmeta"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tlsutil"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/catalog"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/customer"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/inventory"
 	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/order/internal/audit"
 	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/order/internal/handler"
 	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/order/internal/service"
+	_ "github.com/jackc/pgx/v5/stdlib"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	_ "modernc.org/sqlite"
 )
 
 func main() {
-	httpPort := flag.Int("http-port", 8080, "HTTP server port")
-	paymentAddr := flag.String("payment-addr", "localhost:50051", "Payment service gRPC address")
+	cfg := defaultConfig()
+	loader := config.Register(flag.CommandLine, "order", &cfg)
 	flag.Parse()
+	if err := loader.Load(); err != nil {
+		logging.Fatal("invalid configuration", logging.Err(err))
+	}
+
+	if err := logging.Setup("order", cfg.Log); err != nil {
+		logging.Fatal("invalid logging configuration", logging.Err(err))
+	}
+	connCfg := cfg.Payment.Conn
+	retryCfg := cfg.Payment.Retry
+	breakerCfg := cfg.Payment.Breaker
+	validationCfg := cfg.Validation
+	for i, code := range validationCfg.Currencies {
+		validationCfg.Currencies[i] = strings.ToUpper(code)
+	}
 
-	log.SetPrefix("[ORDER] ")
-	log.Printf("Starting Order Service on port %d", *httpPort)
-	log.Printf("Payment service at %s", *paymentAddr)
+	pricingCfg, err := cfg.Pricing()
+	if err != nil {
+		logging.Fatal("invalid pricing configuration", logging.Err(err))
+	}
 
-	paymentConn, err := grpc.NewClient(
-		*paymentAddr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	slog.Info("starting order service", "port", cfg.HTTP.Port)
+	slog.Info("payment service configured", "addr", cfg.Payment.Addr, "lb_policy", cfg.Payment.Balancing.Policy)
+
+	paymentTLS := cfg.Payment.TLS.Config()
+	paymentCreds, err := tlsutil.ClientCredentials(paymentTLS)
+	if err != nil {
+		logging.Fatal("failed to load payment TLS credentials", logging.Err(err))
+	}
+	if paymentTLS.Enabled() {
+		slog.Info("payment connection uses TLS")
+	}
+
+	registry := metrics.NewRegistry()
+
+	faults := chaos.NewInjector(cfg.Chaos)
+	if cfg.Chaos.Enabled {
+		slog.Warn("fault injection enabled", "latency", cfg.Chaos.Latency.String(), "error_rate", cfg.Chaos.ErrorRate,
+			"drop_rate", cfg.Chaos.DropRate, "duplicate_rate", cfg.Chaos.DuplicateRate, "reorder_rate", cfg.Chaos.ReorderRate)
+	}
+
+	var services *discovery.Builder
+	if r, err := discovery.New(cfg.Discovery); err != nil {
+		logging.Fatal("failed to set up service discovery", logging.Err(err))
+	} else if r != nil {
+		services = discovery.NewBuilder(r, cfg.Discovery.Refresh)
+		slog.Info("service discovery configured", "backend", cfg.Discovery.Backend, "refresh", cfg.Discovery.Refresh.String())
+	}
+
+	// Several payment replicas share the connection: the target resolves
+	// to all of them and the balancing policy spreads calls over the
+	// healthy ones.
+	clientLatency := grpcmw.NewClientHandlingHistogram(registry)
+	paymentTarget, dialOpts := dialTarget(cfg.Payment.Addr, services)
+	dialOpts = append(dialOpts, grpcconn.BalancingOptions(cfg.Payment.Balancing, "payment.PaymentService")...)
+	dialOpts = append(append(append(dialOpts,
+		grpc.WithTransportCredentials(paymentCreds),
 		grpc.WithDefaultCallOptions(grpc.CallContentSubtype("json")),
-	)
+	), clientInterceptors(cfg.Payment.Client, cfg.Payment.Token, clientLatency, faults)...), grpcconn.DialOptions(connCfg)...)
+
+	paymentConn, err := grpc.NewClient(paymentTarget, dialOpts...)
 	if err != nil {
-		log.Fatalf("Failed to connect to Payment service: %v", err)
+		logging.Fatal("failed to connect to payment service", logging.Err(err))
 	}
 	defer paymentConn.Close()
 
+	connCtx, stopConnLog := context.WithCancel(context.Background())
+	defer stopConnLog()
+	go grpcconn.LogStateChanges(connCtx, paymentConn, "payment")
+
+	readiness := handler.NewReadiness(cfg.ReadyTimeout)
+	readiness.Add("payment", func(ctx context.Context) error {
+		return grpcconn.CheckHealth(ctx, paymentConn, "payment.PaymentService")
+	})
+
 	paymentClient := payment.NewPaymentServiceClient(paymentConn)
-	log.Println("Connected to Payment service")
+	slog.Info("connected to payment service")
+
+	var inventoryClient inventory.InventoryServiceClient
+	if cfg.Inventory.Addr != "" {
+		inventoryTarget, inventoryOpts := dialTarget(cfg.Inventory.Addr, services)
+		inventoryConn, err := grpc.NewClient(inventoryTarget, append(append(append(inventoryOpts,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithDefaultCallOptions(grpc.CallContentSubtype("json")),
+		), clientInterceptors(cfg.Inventory.Client, cfg.Inventory.Token, clientLatency, faults)...), grpcconn.DialOptions(connCfg)...)...)
+		if err != nil {
+			logging.Fatal("failed to connect to inventory service", logging.Err(err))
+		}
+		defer inventoryConn.Close()
+		go grpcconn.LogStateChanges(connCtx, inventoryConn, "inventory")
+		readiness.Add("inventory", func(ctx context.Context) error {
+			return grpcconn.CheckHealth(ctx, inventoryConn, "inventory.InventoryService")
+		})
+
+		inventoryClient = inventory.NewInventoryServiceClient(inventoryConn)
+		slog.Info("inventory service configured, stock is reserved before payment", "addr", cfg.Inventory.Addr)
+	}
+
+	var customerClient customer.CustomerServiceClient
+	if cfg.Customer.Addr != "" {
+		customerTarget, customerOpts := dialTarget(cfg.Customer.Addr, services)
+		customerConn, err := grpc.NewClient(customerTarget, append(append(append(customerOpts,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithDefaultCallOptions(grpc.CallContentSubtype("json")),
+		), clientInterceptors(cfg.Customer.Client, cfg.Customer.Token, clientLatency, faults)...), grpcconn.DialOptions(connCfg)...)...)
+		if err != nil {
+			logging.Fatal("failed to connect to customer service", logging.Err(err))
+		}
+		defer customerConn.Close()
+		go grpcconn.LogStateChanges(connCtx, customerConn, "customer")
+		readiness.Add("customer", func(ctx context.Context) error {
+			return grpcconn.CheckHealth(ctx, customerConn, "customer.CustomerService")
+		})
+
+		customerClient = customer.NewCustomerServiceClient(customerConn)
+		slog.Info("customer service configured, customer_id is checked for new orders", "addr", cfg.Customer.Addr)
+	}
 
-	msgBroker := broker.NewBroker(broker.DefaultBrokerConfig())
-	msgBroker.CreateTopic("order.created")
+	var catalogClient catalog.CatalogServiceClient
+	if cfg.Catalog.Upstream.Addr != "" {
+		catalogTarget, catalogOpts := dialTarget(cfg.Catalog.Upstream.Addr, services)
+		catalogConn, err := grpc.NewClient(catalogTarget, append(append(append(catalogOpts,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithDefaultCallOptions(grpc.CallContentSubtype("json")),
+		), clientInterceptors(cfg.Catalog.Upstream.Client, cfg.Catalog.Upstream.Token, clientLatency, faults)...), grpcconn.DialOptions(connCfg)...)...)
+		if err != nil {
+			logging.Fatal("failed to connect to catalog service", logging.Err(err))
+		}
+		defer catalogConn.Close()
+		go grpcconn.LogStateChanges(connCtx, catalogConn, "catalog")
+		readiness.Add("catalog", func(ctx context.Context) error {
+			return grpcconn.CheckHealth(ctx, catalogConn, "catalog.CatalogService")
+		})
 
-	notificationQueue := msgBroker.CreateQueue("notifications", broker.WithMaxRetries(3))
-	auditQueue := msgBroker.CreateQueue("audit", broker.WithMaxRetries(5))
+		catalogClient = catalog.NewCatalogServiceClient(catalogConn)
+		slog.Info("catalog service configured, item prices are checked for new orders", "addr", cfg.Catalog.Upstream.Addr,
+			"price_tolerance", cfg.Catalog.Prices.Tolerance, "price_mismatch", cfg.Catalog.Prices.Mismatch)
+	}
 
-	msgBroker.Subscribe("order.created", "notifications")
-	msgBroker.Subscribe("order.created", "audit")
-	log.Println("Message broker configured")
+	msgBroker := broker.NewBroker(cfg.Broker)
+	topology := defaultTopology()
+	if cfg.BrokerTopology != "" {
+		if topology, err = broker.LoadTopology(cfg.BrokerTopology); err != nil {
+			logging.Fatal("invalid broker topology", logging.Err(err))
+		}
+		slog.Info("broker topology loaded", "path", cfg.BrokerTopology)
+	}
+	brokerQueues, err := applyTopology(msgBroker, topology, faults.QueueOption)
+	if err != nil {
+		logging.Fatal("invalid broker topology", logging.Err(err))
+	}
+	auditQueue := brokerQueues[auditQueueName]
+	streamQueue := brokerQueues[streamQueueName]
+	requestsQueue := brokerQueues[requestsQueueName]
+	paymentRetriesQueue := brokerQueues[paymentRetriesQueueName]
+	slog.Info("message broker configured", "topic_retention", cfg.Broker.TopicRetention, "topic_retention_messages", cfg.Broker.TopicRetentionMessages)
 
-	go startNotificationWorker(notificationQueue)
-	go startAuditWorker(auditQueue)
+	repo, auditStore, closeRepo, err := buildStores(cfg.Store, cfg.StoreDSN)
+	if err != nil {
+		logging.Fatal("failed to open order store", logging.Err(err))
+	}
+	defer closeRepo()
+	if n, err := repo.Count(context.Background()); err == nil {
+		slog.Info("order store opened", "backend", cfg.Store, "orders", n)
+	}
+	readiness.Add("repository", repo.Ping)
+
+	switch cfg.Cache.Backend {
+	case "memory":
+		repo = service.NewCachedOrderRepository(repo, cache.NewLRU(cfg.Cache.Size), cfg.Cache.TTL, cfg.Cache.ListTTL, service.NewCacheMetrics(registry))
+		slog.Info("order cache enabled", "backend", "memory", "size", cfg.Cache.Size, "ttl", cfg.Cache.TTL)
+	case "redis":
+		redis := cache.NewRedis(cfg.Cache.Redis)
+		defer redis.Close()
+		repo = service.NewCachedOrderRepository(repo, redis, cfg.Cache.TTL, cfg.Cache.ListTTL, service.NewCacheMetrics(registry))
+		slog.Info("order cache enabled", "backend", "redis", "addr", cfg.Cache.Redis.Addr, "ttl", cfg.Cache.TTL)
+	}
+
+	auditWorker := broker.NewWorker("audit-worker", auditQueue, audit.NewRecorder(auditStore).HandleMessage)
+	go auditWorker.Start(context.Background())
 
-	orderSvc := service.NewOrderService(paymentClient, msgBroker, "order.created")
-	orderHandler := handler.NewOrderHandler(orderSvc)
+	eventHub := handler.NewEventHub(cfg.SSEHeartbeat)
+	streamWorker := broker.NewWorker("event-stream-worker", streamQueue, eventHub.HandleMessage)
+	go streamWorker.Start(context.Background())
+
+	eventWorkers := map[string]*broker.Worker{
+		"audit-worker":        auditWorker,
+		"event-stream-worker": streamWorker,
+	}
+
+	orderSvc := service.NewOrderService(service.GRPCPayments(paymentClient), msgBroker, "order.created",
+		service.WithRepository(repo),
+		service.WithRetry(retryCfg),
+		service.WithValidation(validationCfg),
+		service.WithCircuitBreaker(circuitbreaker.New("payment", breakerCfg, circuitbreaker.WithMetrics(circuitbreaker.NewMetrics(registry)))),
+		service.WithInventory(inventoryClient),
+		service.WithCustomers(customerClient),
+		service.WithCatalog(catalogClient, cfg.Catalog.Prices),
+		service.WithPricing(pricingCfg),
+		service.WithMetrics(service.NewMetrics(registry)),
+		service.WithDegradation(cfg.Degradation),
+	)
+	orderSvc.RegisterGauges(registry)
+
+	requestWorkers := make(map[string]*broker.Worker, cfg.RequestWorkers)
+	for i := range cfg.RequestWorkers {
+		name := fmt.Sprintf("order-request-worker-%d", i+1)
+		requestWorkers[name] = broker.NewWorker(name, requestsQueue, orderSvc.HandleRequested)
+		go requestWorkers[name].Start(context.Background())
+	}
+	if cfg.Degradation.Policy == service.DegradationQueue {
+		requestWorkers["payment-retry-worker"] = broker.NewWorker("payment-retry-worker", paymentRetriesQueue, orderSvc.HandleDeferredPayment)
+		go requestWorkers["payment-retry-worker"].Start(context.Background())
+		slog.Info("orders are queued while the payment service is down", "max_pending", cfg.Degradation.MaxPending)
+	}
+	workers := broker.NewWorkerRegistry()
+	for _, w := range eventWorkers {
+		workers.Register(w)
+	}
+	for _, w := range requestWorkers {
+		workers.Register(w)
+	}
+	registerBrokerMetrics(registry, msgBroker, workers)
+	// Monitor notices the lapsed deliveries of a worker that hangs, which
+	// no receive of its queue would.
+	go msgBroker.Monitor(context.Background(), 5*time.Second)
+	readiness.Add("broker", func(ctx context.Context) error {
+		return checkWorkers(workers.Workers())
+	})
+	if cfg.AsyncOrders {
+		slog.Info("orders are charged in the background", "workers", cfg.RequestWorkers)
+	}
+	slog.Info("payment calls configured",
+		"attempts", retryCfg.MaxAttempts,
+		"timeout", retryCfg.CallTimeout.String(),
+		"request_timeout", cfg.RequestTimeout.String(),
+		"breaker_failures", breakerCfg.Failures,
+		"breaker_ratio", breakerCfg.FailureRatio,
+		"breaker_cooldown", breakerCfg.Cooldown.String())
+	slog.Info("pricing configured",
+		"coupons", len(pricingCfg.Coupons),
+		"line_discounts", len(pricingCfg.LineDiscounts),
+		"tax_regions", pricingCfg.Regions())
+
+	expiryCtx, stopExpiry := context.WithCancel(context.Background())
+	expiryDone := make(chan struct{})
+	go func() {
+		defer close(expiryDone)
+		orderSvc.StartExpirer(expiryCtx, cfg.Expiry)
+	}()
+	stopExpirer := func() {
+		stopExpiry()
+		<-expiryDone
+	}
+	if cfg.Expiry.PendingMaxAge > 0 {
+		slog.Info("pending orders expire",
+			"max_age", cfg.Expiry.PendingMaxAge.String(),
+			"interval", cfg.Expiry.Interval.String())
+	}
+
+	importer := service.NewImporter(orderSvc, cfg.Bulk)
+	handlerOpts := []handler.Option{
+		handler.WithEventHub(eventHub),
+		handler.WithReadiness(readiness),
+		handler.WithAsyncCreate(cfg.AsyncOrders),
+		handler.WithImporter(importer),
+		handler.WithDeadLetters(msgBroker),
+		handler.WithWorkers(workers),
+		handler.WithAudit(auditStore),
+	}
+	if cfg.Chaos.Enabled {
+		handlerOpts = append(handlerOpts, handler.WithChaos(faults))
+	}
+	orderHandler := handler.NewOrderHandler(orderSvc, handlerOpts...)
 
 	mux := http.NewServeMux()
 	orderHandler.RegisterRoutes(mux)
+	mux.Handle("GET /metrics", registry.Handler())
+
+	// The tenant is resolved and clients are rate limited after
+	// authentication, which may bind the tenant and names the client.
+	var routes http.Handler = reqmeta.Middleware(tenant.Middleware(mux))
+	routes = handler.RequestDeadline(cfg.RequestTimeout, "/orders/events", "/orders/export")(routes)
+	if cfg.RateLimit.Rate > 0 {
+		routes = ratelimit.HTTPMiddleware(ratelimit.NewLimiter(cfg.RateLimit), "/healthz", "/readyz", "/metrics", "/openapi.json", "/docs")(routes)
+		slog.Info("rate limiting enabled", "rate", cfg.RateLimit.Rate, "burst", cfg.RateLimit.Burst)
+	}
+	routes = http.MaxBytesHandler(routes, cfg.MaxBodyBytes)
+	authn, err := buildAuthenticator(cfg.APIKeys, cfg.JWTSecret, cfg.JWKSURL, cfg.JWTIssuer, cfg.JWTAudience)
+	if err != nil {
+		logging.Fatal("invalid auth configuration", logging.Err(err))
+	}
+	if authn != nil {
+		routes = auth.HTTPMiddleware(authn, "/healthz", "/readyz", "/metrics", "/openapi.json", "/docs")(routes)
+		slog.Info("authentication enabled for the HTTP API")
+	} else {
+		slog.Info("authentication disabled")
+	}
 
-	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", *httpPort),
-		Handler:      loggingMiddleware(mux),
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+	// CORS answers preflights before authentication, and with the security
+	// headers also covers the responses of the middlewares above.
+	routes = handler.SecurityHeaders(handler.CORS(cfg.CORS)(routes))
+	if len(cfg.CORS.Origins) > 0 {
+		slog.Info("CORS enabled", "origins", cfg.CORS.Origins)
 	}
 
+	server := cfg.HTTP.Server(logging.Middleware(handler.NewHTTPMetrics(registry).Middleware(mux, recoveryMiddleware(routes))))
+	server.RegisterOnShutdown(eventHub.Close)
+
+	stopped := make(chan struct{})
 	go func() {
+		defer close(stopped)
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 		<-sigChan
-		log.Println("Shutting down...")
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		slog.Info("shutting down", "timeout", cfg.ShutdownTimeout.String(), "drain_timeout", cfg.DrainTimeout.String())
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 		defer cancel()
-		server.Shutdown(ctx)
+		shutdown(ctx, server, orderSvc, importer, stopExpirer, requestWorkers, eventWorkers, cfg.DrainTimeout)
 	}()
 
-	log.Printf("Order Service ready at http://localhost:%d", *httpPort)
-	log.Println("Endpoints: POST /orders, GET /orders, GET /orders/{id}, GET /health, GET /stats")
+	slog.Info("order service ready", "url", fmt.Sprintf("http://localhost:%d", cfg.HTTP.Port))
+	slog.Info("endpoints: POST /orders, GET /orders, GET /orders/{id}, GET /orders/events, GET /orders/search, GET /customers/{id}/orders, POST /orders/pending, POST /orders/{id}/payment, POST /orders/{id}/fulfillments, PATCH /orders/{id}/fulfillments/{id}, POST /orders/{id}/returns, PATCH /orders/{id}/returns/{id}, POST /orders/bulk, GET /orders/bulk/{id}, PATCH /orders/{id}/status, POST /orders/{id}/cancel, POST /orders/{id}/dispute, POST /orders/{id}/dispute/resolve, GET /admin/queues, GET /admin/topology, GET /admin/dlq/{queue}, POST /admin/dlq/{queue}/redrive, GET /admin/audit, GET /admin/audit/verify, GET /healthz, GET /readyz, GET /metrics, GET /stats, GET /openapi.json, GET /docs")
 
 	if err := server.ListenAndServe(); err != http.ErrServerClosed {
-		log.Fatalf("HTTP server error: %v", err)
+		logging.Fatal("HTTP server error", logging.Err(err))
 	}
+	<-stopped
+	slog.Info("order service stopped")
 }
 
-func startNotificationWorker(queue *broker.Queue) {
-	log.Println("[WORKER] Starting notification worker")
+// shutdown stops the service in dependency order: the HTTP server finishes
+// the requests in flight, the expiration sweeper stops, running bulk imports
+// and the request workers create and charge the orders already accepted,
+// the events published in the background reach the broker, and then the
+// event workers drain their queues. Draining takes up to drainTimeout in
+// total. Each step is logged rather than aborting the next one.
+func shutdown(ctx context.Context, server *http.Server, orderSvc *service.OrderService, importer *service.Importer, stopExpirer func(), requestWorkers, eventWorkers map[string]*broker.Worker, drainTimeout time.Duration) {
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Warn("HTTP server did not finish in-flight requests", logging.Err(err))
+	}
 
-	worker := broker.NewWorker("notification-worker", queue, func(msg *broker.Message) error {
-		var event struct {
-			Order struct {
-				ID            string `json:"id"`
-				CustomerEmail string `json:"customer_email"`
-				TotalCents    int64  `json:"total_cents"`
-			} `json:"order"`
-		}
-		if err := msg.Decode(&event); err != nil {
-			return err
+	stopExpirer()
+
+	drainCtx, cancel := context.WithTimeout(ctx, drainTimeout)
+	defer cancel()
+
+	if err := importer.Shutdown(drainCtx); err != nil {
+		slog.Warn("bulk imports still running were abandoned", logging.Err(err))
+	}
+	drainWorkers(drainCtx, requestWorkers)
+	if err := orderSvc.Flush(ctx); err != nil {
+		slog.Warn("events still being published were dropped", logging.Err(err))
+	}
+	drainWorkers(drainCtx, eventWorkers)
+}
+
+// drainWorkers shuts the workers down concurrently and waits for them.
+func drainWorkers(ctx context.Context, workers map[string]*broker.Worker) {
+	var wg sync.WaitGroup
+	for name, w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := w.Shutdown(ctx); err != nil {
+				slog.Warn("worker stopped before its queue was drained", "worker", name, logging.Err(err))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// checkWorkers fails unless every worker is consuming its queue.
+func checkWorkers(workers map[string]*broker.Worker) error {
+	var stopped []string
+	for name, w := range workers {
+		if !w.Running() {
+			stopped = append(stopped, name)
 		}
+	}
+	if len(stopped) > 0 {
+		sort.Strings(stopped)
+		return fmt.Errorf("workers not running: %s", strings.Join(stopped, ", "))
+	}
+	return nil
+}
 
-		log.Printf("[NOTIFICATION] 📧 Email to %s for order %s (R$ %.2f)",
-			event.Order.CustomerEmail, event.Order.ID, float64(event.Order.TotalCents)/100)
-		log.Printf("[NOTIFICATION] 📱 SMS for order %s", event.Order.ID)
+// registerBrokerMetrics exposes the depth and totals of every queue of b and
+// the totals and state of workers, keyed by worker name, on r.
+func registerBrokerMetrics(r *metrics.Registry, b *broker.Broker, workers *broker.WorkerRegistry) {
+	queues := func(value func(broker.QueueStats) float64) metrics.CollectFunc {
+		return func(emit func(float64, ...string)) {
+			for name, stats := range b.Stats().Queues {
+				emit(value(stats), name)
+			}
+		}
+	}
+	r.NewGaugeVecFunc("broker_queue_depth", "Messages waiting in each broker queue.",
+		queues(func(s broker.QueueStats) float64 { return float64(s.CurrentSize) }), "queue")
+	r.NewGaugeVecFunc("broker_queue_oldest_message_age_seconds", "Age of the oldest message waiting in each broker queue.",
+		queues(func(s broker.QueueStats) float64 { return s.OldestAge.Seconds() }), "queue")
+	r.NewCounterVecFunc("broker_queue_received_total", "Messages enqueued on each broker queue.",
+		queues(func(s broker.QueueStats) float64 { return float64(s.TotalReceived) }), "queue")
+	r.NewCounterVecFunc("broker_queue_processed_total", "Messages acknowledged on each broker queue.",
+		queues(func(s broker.QueueStats) float64 { return float64(s.TotalProcessed) }), "queue")
+	r.NewCounterVecFunc("broker_queue_failed_total", "Messages that exhausted their retries on each broker queue.",
+		queues(func(s broker.QueueStats) float64 { return float64(s.TotalFailed) }), "queue")
+	r.NewCounterVecFunc("broker_queue_visibility_expired_total", "Deliveries on each broker queue whose visibility timeout lapsed before they were acknowledged.",
+		queues(func(s broker.QueueStats) float64 { return float64(s.TotalExpired) }), "queue")
 
-		return nil
-	})
+	worker := func(value func(broker.WorkerStats) float64) metrics.CollectFunc {
+		return func(emit func(float64, ...string)) {
+			for name, w := range workers.Workers() {
+				emit(value(w.Stats()), name)
+			}
+		}
+	}
+	r.NewCounterVecFunc("broker_worker_processed_total", "Messages handled successfully by each worker.",
+		worker(func(s broker.WorkerStats) float64 { return float64(s.MessagesProcessed) }), "worker")
+	r.NewCounterVecFunc("broker_worker_failed_total", "Messages each worker failed to handle.",
+		worker(func(s broker.WorkerStats) float64 { return float64(s.MessagesFailed) }), "worker")
+	r.NewCounterVecFunc("broker_worker_panics_total", "Messages whose handler panicked, by worker.",
+		worker(func(s broker.WorkerStats) float64 { return float64(s.MessagesPanicked) }), "worker")
+	r.NewCounterVecFunc("broker_worker_visibility_expired_total", "Messages each worker held past the visibility timeout without acknowledging them.",
+		worker(func(s broker.WorkerStats) float64 { return float64(s.MessagesExpired) }), "worker")
+	r.NewCounterVecFunc("broker_worker_processing_seconds_total", "Time each worker spent on successfully handled messages.",
+		worker(func(s broker.WorkerStats) float64 { return s.TotalProcessTime.Seconds() }), "worker")
+	r.NewGaugeVecFunc("broker_worker_last_processed_timestamp_seconds", "Unix time each worker last handled a message successfully, 0 before the first.",
+		worker(func(s broker.WorkerStats) float64 { return unixSeconds(s.LastProcessedAt) }), "worker")
+	r.NewGaugeVecFunc("broker_worker_last_error_timestamp_seconds", "Unix time the handler of each worker last failed or panicked, 0 if it never did.",
+		worker(func(s broker.WorkerStats) float64 { return unixSeconds(s.LastErrorAt) }), "worker")
+	r.NewGaugeVecFunc("broker_worker_info", "Queue and concurrency of each worker, and 1 while it runs or 0 once it stopped.",
+		func(emit func(float64, ...string)) {
+			for _, info := range workers.Info() {
+				running := 0.0
+				if info.Running {
+					running = 1
+				}
+				emit(running, info.Name, info.Queue, strconv.Itoa(info.Concurrency))
+			}
+		}, "worker", "queue", "concurrency")
+}
 
-	worker.Start(context.Background())
+func unixSeconds(t time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	return float64(t.UnixNano()) / 1e9
 }
 
-func startAuditWorker(queue *broker.Queue) {
-	log.Println("[WORKER] Starting audit worker")
-
-	worker := broker.NewWorker("audit-worker", queue, func(msg *broker.Message) error {
-		var event struct {
-			EventType string `json:"event_type"`
-			Order     struct {
-				ID         string `json:"id"`
-				TotalCents int64  `json:"total_cents"`
-				Status     int    `json:"status"`
-			} `json:"order"`
+// buildStores opens the order repository and the audit trail of backend,
+// which share the database of the SQL backends.
+func buildStores(backend, dsn string) (service.OrderRepository, audit.Store, func() error, error) {
+	noop := func() error { return nil }
+
+	switch backend {
+	case "", "memory":
+		return service.NewInMemoryOrderRepository(), audit.NewMemoryStore(), noop, nil
+	case "sqlite", "postgres":
+		driver := "sqlite"
+		if backend == "postgres" {
+			driver = "pgx"
+		}
+		if dsn == "" {
+			return nil, nil, nil, fmt.Errorf("order store %s requires -store-dsn", backend)
 		}
-		if err := msg.Decode(&event); err != nil {
-			return err
+		db, err := sql.Open(driver, dsn)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		r, err := service.NewSQLOrderRepository(ctx, db, driver)
+		if err != nil {
+			db.Close()
+			return nil, nil, nil, err
 		}
+		a, err := audit.NewSQLStore(ctx, db, driver)
+		if err != nil {
+			db.Close()
+			return nil, nil, nil, err
+		}
+		return r, a, db.Close, nil
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown order store %q", backend)
+	}
+}
 
-		log.Printf("[AUDIT] 📝 %s | Order: %s | R$ %.2f | Status: %d",
-			event.EventType, event.Order.ID, float64(event.Order.TotalCents)/100, event.Order.Status)
+func buildAuthenticator(apiKeys, jwtSecret, jwksURL, jwtIssuer, jwtAudience string) (auth.Authenticator, error) {
+	var chain auth.Chain
 
-		return nil
-	})
+	if apiKeys != "" {
+		keys, err := auth.ParseStaticKeys(apiKeys)
+		if err != nil {
+			return nil, err
+		}
+		if keys.Len() > 0 {
+			chain = append(chain, keys)
+		}
+	}
+
+	var jwtOpts []auth.JWTOption
+	if jwtAudience != "" {
+		jwtOpts = append(jwtOpts, auth.WithAudience(jwtAudience))
+	}
+	if jwtSecret != "" {
+		chain = append(chain, auth.NewJWTValidator([]byte(jwtSecret), jwtIssuer, jwtOpts...))
+	}
+	if jwksURL != "" {
+		chain = append(chain, auth.NewJWKSValidator(jwksURL, jwtIssuer, jwtOpts...))
+	}
 
-	worker.Start(context.Background())
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	return chain, nil
 }
 
-func loggingMiddleware(next http.Handler) http.Handler {
+// recoveryMiddleware answers 500 instead of dropping the connection when a
+// handler panics.
+func recoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
+		defer func() {
+			if rec := recover(); rec != nil {
+				if rec == http.ErrAbortHandler {
+					// A handler ending a response it already started.
+					panic(rec)
+				}
+				slog.ErrorContext(r.Context(), "panic in handler",
+					"method", r.Method, "path", r.URL.Path, "panic", fmt.Sprint(rec), "stack", string(debug.Stack()))
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(`{"error":"Internal error"}`))
+			}
+		}()
 		next.ServeHTTP(w, r)
-		log.Printf("%s %s %v", r.Method, r.URL.Path, time.Since(start))
 	})
 }
+
+// clientInterceptors builds the interceptor chain of a connection to an
+// upstream, the client side of the chain the gRPC servers run. The request
+// ID, tenant, actor and token are attached once; the deadline covers every
+// attempt; logging, latency and injected faults apply to each attempt. The
+// forwarded request ID is what ties the records of both sides together.
+func clientInterceptors(cfg grpcmw.ClientConfig, token string, latency *metrics.HistogramVec, faults *chaos.Injector) []grpc.DialOption {
+	interceptors := []grpc.UnaryClientInterceptor{
+		reqmeta.UnaryClientInterceptor(),
+		auth.UnaryClientInterceptor(token),
+		grpcmw.UnaryClientTimeoutInterceptor(cfg.Timeout),
+	}
+	streamInterceptors := []grpc.StreamClientInterceptor{
+		reqmeta.StreamClientInterceptor(),
+		auth.StreamClientInterceptor(token),
+	}
+
+	if cfg.Retry.MaxAttempts > 1 {
+		interceptors = append(interceptors, grpcmw.UnaryClientRetryInterceptor(cfg.Retry))
+	}
+	interceptors = append(interceptors,
+		grpcmw.UnaryClientLoggingInterceptor(),
+		grpcmw.UnaryClientMetricsInterceptor(latency),
+		faults.UnaryClientInterceptor(),
+	)
+
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(interceptors...),
+		grpc.WithChainStreamInterceptor(streamInterceptors...),
+	}
+}
+
+// dialTarget returns the target and resolver options of addr: a service
+// name resolved through services when discovery is enabled, otherwise an
+// address, a list of them or a gRPC target as grpcconn.Target takes it.
+func dialTarget(addr string, services *discovery.Builder) (string, []grpc.DialOption) {
+	if services != nil && discovery.IsName(addr) {
+		target, opt := services.Target(addr)
+		return target, []grpc.DialOption{opt}
+	}
+	return grpcconn.Target(addr)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/order/internal/service"
+)
+
+// Queues the workers of the service consume, which a topology file must
+// declare.
+const (
+	auditQueueName          = "audit"
+	streamQueueName         = "event-stream"
+	requestsQueueName       = "order-requests"
+	paymentRetriesQueueName = "payment-retries"
+)
+
+// defaultTopology is the broker topology used without -broker-topology.
+func defaultTopology() broker.Topology {
+	// Deliveries lost to a restart do not count against the retries of
+	// the dead-lettered queues, within a bound for messages that crash it.
+	redrive := &broker.RedrivePolicy{MaxFailures: 5, MaxReceives: 10}
+
+	return broker.Topology{
+		Topics: []broker.TopicSpec{
+			{Name: "order.created"},
+			{Name: service.DisputesTopic},
+			{Name: service.CancellationsTopic},
+			{Name: service.StatusTopic},
+			{Name: service.RequestsTopic},
+			{Name: service.ExpiredTopic},
+			{Name: service.FulfillmentsTopic},
+			{Name: service.ReturnsTopic},
+			{Name: service.ErasuresTopic},
+			{Name: service.PaymentRetriesTopic},
+		},
+		Queues: []broker.QueueSpec{
+			{
+				Name: auditQueueName,
+				Topics: []string{
+					"order.created",
+					service.DisputesTopic,
+					service.CancellationsTopic,
+					service.StatusTopic,
+					service.ExpiredTopic,
+					service.FulfillmentsTopic,
+					service.ReturnsTopic,
+					service.ErasuresTopic,
+				},
+				Redrive:         redrive,
+				DeadLetterQueue: "audit-dlq",
+			},
+			{Name: "audit-dlq"},
+			{
+				Name:    streamQueueName,
+				Topics:  []string{"order.created", service.StatusTopic, service.ReturnsTopic},
+				Redrive: &broker.RedrivePolicy{MaxReceives: 1},
+			},
+			{
+				Name:            requestsQueueName,
+				Topics:          []string{service.RequestsTopic},
+				Redrive:         redrive,
+				DeadLetterQueue: "order-requests-dlq",
+			},
+			{Name: "order-requests-dlq"},
+			{
+				// Deferred payments are retried until payment recovers or
+				// the pending expiration cancels their orders, so the
+				// queue has no redrive limit.
+				Name:    paymentRetriesQueueName,
+				Topics:  []string{service.PaymentRetriesTopic},
+				Redrive: &broker.RedrivePolicy{},
+			},
+		},
+	}
+}
+
+// applyTopology applies topology to b, adding queueOption to its queues as
+// ApplyTopology does, and returns the queues the workers consume. The
+// topics the service publishes to must exist, as publishing to a missing
+// topic fails.
+func applyTopology(b *broker.Broker, topology broker.Topology, queueOption func(string) broker.QueueOption) (map[string]*broker.Queue, error) {
+	if err := b.ApplyTopology(topology, queueOption); err != nil {
+		return nil, err
+	}
+	for _, topic := range defaultTopology().Topics {
+		if _, ok := b.GetTopic(topic.Name); !ok {
+			return nil, fmt.Errorf("topic %q: %w", topic.Name, broker.ErrTopicNotFound)
+		}
+	}
+	queues := make(map[string]*broker.Queue)
+	for _, name := range []string{auditQueueName, streamQueueName, requestsQueueName, paymentRetriesQueueName} {
+		q, ok := b.GetQueue(name)
+		if !ok {
+			return nil, fmt.Errorf("queue %q: %w", name, broker.ErrQueueNotFound)
+		}
+		queues[name] = q
+	}
+	return queues, nil
+}
@@ -0,0 +1,194 @@
+// Package audit keeps an append-only trail of what happened to orders and
+// their payments. Every event is chained to the previous one by a SHA-256
+// hash, so editing, removing or reordering stored events breaks the chain
+// and Verify reports where. The one edit allowed is Redact, which erases
+// personal data from an event and marks it, keeping its hash and link.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// SystemActor is the actor of events not caused by an API request, such as
+// orders expired by the sweeper.
+const SystemActor = "system"
+
+// ErrDuplicate is returned by Append for a message already recorded.
+var ErrDuplicate = errors.New("audit event already recorded")
+
+// Event is an entry of the audit trail.
+type Event struct {
+	// Seq numbers the events of a store from 1 without gaps.
+	Seq int64 `json:"seq"`
+
+	Time       time.Time `json:"time"`
+	TenantID   string    `json:"tenant_id"`
+	Type       string    `json:"type"`
+	EntityType string    `json:"entity_type"`
+	EntityID   string    `json:"entity_id"`
+	Actor      string    `json:"actor"`
+	RequestID  string    `json:"request_id,omitempty"`
+
+	// MessageID is the broker message the event was recorded from; a
+	// redelivered message is recorded once.
+	MessageID string `json:"message_id"`
+
+	// Data holds the details of the event, such as the old and new status
+	// of a status change.
+	Data json.RawMessage `json:"data,omitempty"`
+
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+
+	// Redacted is set once personal data was erased from Data or Actor,
+	// after which Hash no longer matches the content of the event.
+	Redacted bool `json:"redacted,omitempty"`
+}
+
+// computeHash returns the hash of e chained to e.PrevHash. Every field but
+// Hash and Redacted is covered.
+func (e *Event) computeHash() string {
+	content, _ := json.Marshal(struct {
+		Seq        int64           `json:"seq"`
+		Time       string          `json:"time"`
+		TenantID   string          `json:"tenant_id"`
+		Type       string          `json:"type"`
+		EntityType string          `json:"entity_type"`
+		EntityID   string          `json:"entity_id"`
+		Actor      string          `json:"actor"`
+		RequestID  string          `json:"request_id"`
+		MessageID  string          `json:"message_id"`
+		Data       json.RawMessage `json:"data"`
+	}{
+		e.Seq, e.Time.UTC().Format(time.RFC3339Nano), e.TenantID, e.Type, e.EntityType, e.EntityID,
+		e.Actor, e.RequestID, e.MessageID, e.Data,
+	})
+	sum := sha256.Sum256(append([]byte(e.PrevHash+"\n"), content...))
+	return hex.EncodeToString(sum[:])
+}
+
+// seal numbers e after prev, the last event of the store or nil, and
+// chains it to prev.
+func (e *Event) seal(prev *Event) {
+	e.Seq, e.PrevHash = 1, ""
+	if prev != nil {
+		e.Seq, e.PrevHash = prev.Seq+1, prev.Hash
+	}
+	e.Time = e.Time.UTC().Truncate(time.Microsecond)
+	if len(e.Data) == 0 {
+		e.Data = nil
+	}
+	e.Hash = e.computeHash()
+}
+
+// Filter selects the events of the tenant of the query's context. Zero
+// fields match every event.
+type Filter struct {
+	EntityType string
+	EntityID   string
+	Actor      string
+	Type       string
+
+	// From is inclusive and To exclusive.
+	From time.Time
+	To   time.Time
+
+	// After skips the events up to this sequence number, for paging.
+	After int64
+	Limit int
+}
+
+func (f Filter) matches(e *Event, tenantID string) bool {
+	switch {
+	case e.TenantID != tenantID, e.Seq <= f.After:
+		return false
+	case f.EntityType != "" && e.EntityType != f.EntityType,
+		f.EntityID != "" && e.EntityID != f.EntityID,
+		f.Actor != "" && e.Actor != f.Actor,
+		f.Type != "" && e.Type != f.Type:
+		return false
+	case !f.From.IsZero() && e.Time.Before(f.From),
+		!f.To.IsZero() && !e.Time.Before(f.To):
+		return false
+	}
+	return true
+}
+
+// Verification is the outcome of checking the hash chain.
+type Verification struct {
+	Valid  bool  `json:"valid"`
+	Events int64 `json:"events"`
+
+	// Redacted counts the events whose content could not be checked
+	// because it was redacted; their links still are.
+	Redacted int64 `json:"redacted,omitempty"`
+
+	// BrokenAt is the first event whose hash or link does not match, when
+	// the chain is not valid.
+	BrokenAt int64  `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// verifier checks events handed to it in sequence order.
+type verifier struct {
+	result Verification
+	prev   *Event
+}
+
+func newVerifier() *verifier {
+	return &verifier{result: Verification{Valid: true}}
+}
+
+// check reports false once the chain is broken.
+func (v *verifier) check(e *Event) bool {
+	want := int64(1)
+	prevHash := ""
+	if v.prev != nil {
+		want, prevHash = v.prev.Seq+1, v.prev.Hash
+	}
+	switch {
+	case e.Seq != want:
+		v.fail(want, "event missing")
+	case e.PrevHash != prevHash:
+		v.fail(e.Seq, "previous hash does not match")
+	case !e.Redacted && e.computeHash() != e.Hash:
+		v.fail(e.Seq, "event content does not match its hash")
+	default:
+		v.result.Events++
+		if e.Redacted {
+			v.result.Redacted++
+		}
+		v.prev = e
+		return true
+	}
+	return false
+}
+
+func (v *verifier) fail(seq int64, reason string) {
+	v.result.Valid = false
+	v.result.BrokenAt = seq
+	v.result.Reason = reason
+}
+
+// Store is an append-only audit trail.
+type Store interface {
+	// Append seals e after the last stored event and stores it. It
+	// returns ErrDuplicate when e.MessageID was already recorded.
+	Append(ctx context.Context, e *Event) error
+
+	// Query returns the matching events oldest first.
+	Query(ctx context.Context, f Filter) ([]*Event, error)
+
+	// Verify checks the hash chain of every stored event.
+	Verify(ctx context.Context) (Verification, error)
+
+	// Redact hands redact a copy of each event matching f, which may
+	// erase personal data from its Data and Actor, and stores the events
+	// it changed marked Redacted. It returns the number of events changed.
+	Redact(ctx context.Context, f Filter, redact func(*Event) bool) (int, error)
+}
@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+)
+
+// MemoryStore keeps the audit trail in memory; it is lost on restart.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	events   []*Event
+	messages map[string]bool
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{messages: make(map[string]bool)}
+}
+
+func (s *MemoryStore) Append(ctx context.Context, e *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e.MessageID != "" && s.messages[e.MessageID] {
+		return ErrDuplicate
+	}
+	var prev *Event
+	if n := len(s.events); n > 0 {
+		prev = s.events[n-1]
+	}
+	e.seal(prev)
+
+	stored := *e
+	s.events = append(s.events, &stored)
+	if e.MessageID != "" {
+		s.messages[e.MessageID] = true
+	}
+	return nil
+}
+
+func (s *MemoryStore) Query(ctx context.Context, f Filter) ([]*Event, error) {
+	tenantID := tenant.FromContext(ctx)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := []*Event{}
+	for _, e := range s.events {
+		if !f.matches(e, tenantID) {
+			continue
+		}
+		c := *e
+		events = append(events, &c)
+		if f.Limit > 0 && len(events) == f.Limit {
+			break
+		}
+	}
+	return events, nil
+}
+
+func (s *MemoryStore) Verify(ctx context.Context) (Verification, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v := newVerifier()
+	for _, e := range s.events {
+		if !v.check(e) {
+			break
+		}
+	}
+	return v.result, nil
+}
+
+func (s *MemoryStore) Redact(ctx context.Context, f Filter, redact func(*Event) bool) (int, error) {
+	tenantID := tenant.FromContext(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for i, e := range s.events {
+		if !f.matches(e, tenantID) {
+			continue
+		}
+		c := *e
+		if !redact(&c) {
+			continue
+		}
+		c.Redacted = true
+		s.events[i] = &c
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,180 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/events"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/reqmeta"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+)
+
+var logger = logging.Component("audit")
+
+// EntityOrder is the entity type of events about an order, its payment
+// and its disputes, and EntityCustomer of those about a customer.
+const (
+	EntityOrder    = "order"
+	EntityCustomer = "customer"
+)
+
+// Recorder turns order events from the broker into audit events. It logs
+// the deliveries of the audit queue that arrive out of order, as the trail
+// then records the events in another order than they were published.
+type Recorder struct {
+	store    Store
+	now      func() time.Time
+	sequence broker.SequenceTracker
+}
+
+func NewRecorder(store Store) *Recorder {
+	return &Recorder{store: store, now: time.Now}
+}
+
+// HandleMessage is the broker handler of the audit queue. Messages of
+// unknown types fail, so they end up in the dead-letter queue rather than
+// missing from the trail.
+func (r *Recorder) HandleMessage(msg *broker.Message) error {
+	ctx := msg.Context(context.Background())
+
+	switch check := r.sequence.Observe(msg); {
+	case check.Skipped > 0:
+		logger.WarnContext(ctx, "audit queue skipped messages", "sequence", msg.Sequence, "skipped", check.Skipped)
+	case check.Late:
+		logger.InfoContext(ctx, "audit message delivered late", "sequence", msg.Sequence, "retry", msg.RetryCount)
+	}
+
+	e, err := eventOf(msg)
+	if err != nil {
+		return err
+	}
+	e.Time = msg.Timestamp
+	if e.Time.IsZero() {
+		e.Time = r.now()
+	}
+	meta := reqmeta.FromContext(ctx)
+	e.TenantID, e.Actor, e.RequestID = meta.TenantID, meta.Actor, meta.RequestID
+	if e.Actor == "" {
+		e.Actor = SystemActor
+	}
+	e.MessageID = msg.ID
+
+	err = r.store.Append(ctx, e)
+	if errors.Is(err, ErrDuplicate) {
+		logger.DebugContext(ctx, "audit event already recorded", "type", e.Type)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	logger.InfoContext(ctx, e.Type, "seq", e.Seq, "order_id", e.EntityID, "actor", e.Actor)
+	return nil
+}
+
+// eventOf decodes the order event of msg into an audit event without its
+// envelope fields.
+func eventOf(msg *broker.Message) (*Event, error) {
+	switch msg.Type {
+	case events.TypeOrderCreated:
+		var created events.OrderCreatedV1
+		if err := events.Decode(msg, &created); err != nil {
+			return nil, err
+		}
+		o := created.Order
+		return newEvent(created.EventType, o.ID, map[string]any{
+			"total_cents":    o.TotalCents,
+			"currency":       o.Currency,
+			"status":         o.Status.String(),
+			"transaction_id": o.PaymentTransactionID,
+		})
+	case events.TypeOrderCancelled:
+		var cancelled events.OrderCancelledV1
+		if err := events.Decode(msg, &cancelled); err != nil {
+			return nil, err
+		}
+		return newEvent(cancelled.EventType, cancelled.OrderID, map[string]any{"reason": cancelled.Reason})
+	case "order.expired":
+		var expired order.OrderExpiredEvent
+		if err := msg.Decode(&expired); err != nil {
+			return nil, err
+		}
+		return newEvent(expired.EventType, expired.OrderID, map[string]any{
+			"created_at":     expired.CreatedAt,
+			"transaction_id": expired.TransactionID,
+		})
+	case "order.status_changed":
+		var changed order.OrderStatusChangedEvent
+		if err := msg.Decode(&changed); err != nil {
+			return nil, err
+		}
+		return newEvent(changed.EventType, changed.OrderID, map[string]any{
+			"from":   changed.From.String(),
+			"to":     changed.To.String(),
+			"reason": changed.Reason,
+		})
+	case order.EventTypeOrderDisputed, order.EventTypeOrderDisputeWon, order.EventTypeOrderChargedBack:
+		var dispute order.OrderDisputeEvent
+		if err := msg.Decode(&dispute); err != nil {
+			return nil, err
+		}
+		return newEvent(dispute.EventType, dispute.OrderID, map[string]any{
+			"dispute_id":     dispute.DisputeID,
+			"transaction_id": dispute.TransactionID,
+			"amount_cents":   dispute.AmountCents,
+			"reason":         dispute.Reason,
+		})
+	case order.EventTypeFulfillmentCreated, order.EventTypeFulfillmentStatusChanged:
+		var fulfillment order.OrderFulfillmentEvent
+		if err := msg.Decode(&fulfillment); err != nil {
+			return nil, err
+		}
+		return newEvent(fulfillment.EventType, fulfillment.OrderID, map[string]any{
+			"fulfillment_id":  fulfillment.Fulfillment.ID,
+			"items":           fulfillment.Fulfillment.Items,
+			"from":            fulfillment.From.String(),
+			"to":              fulfillment.To.String(),
+			"tracking_number": fulfillment.Fulfillment.TrackingNumber,
+			"reason":          fulfillment.Reason,
+		})
+	case order.EventTypeReturnRequested, order.EventTypeReturnApproved, order.EventTypeReturnRejected,
+		order.EventTypeReturnReceived, order.EventTypeReturnRefunded:
+		var returned order.OrderReturnEvent
+		if err := msg.Decode(&returned); err != nil {
+			return nil, err
+		}
+		return newEvent(returned.EventType, returned.OrderID, map[string]any{
+			"return_id":    returned.Return.ID,
+			"from":         returned.From.String(),
+			"to":           returned.To.String(),
+			"refund_cents": returned.Return.RefundCents,
+			"reason":       returned.Return.Reason,
+			"note":         returned.Return.Note,
+		})
+	case order.EventTypeCustomerErased:
+		var erased order.CustomerErasedEvent
+		if err := msg.Decode(&erased); err != nil {
+			return nil, err
+		}
+		e, err := newEvent(erased.EventType, erased.CustomerID, map[string]any{"orders": erased.OrderIDs})
+		if err != nil {
+			return nil, err
+		}
+		e.EntityType = EntityCustomer
+		return e, nil
+	default:
+		return nil, fmt.Errorf("unknown audit message type %q", msg.Type)
+	}
+}
+
+func newEvent(typ, orderID string, data map[string]any) (*Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{Type: typ, EntityType: EntityOrder, EntityID: orderID, Data: raw}, nil
+}
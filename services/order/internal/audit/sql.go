@@ -0,0 +1,250 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+)
+
+// sqlTimeLayout has a fixed width so that timestamps sort as text.
+const sqlTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// SQLStore keeps the audit trail in an audit_events table. Rows are only
+// ever inserted, and updated by Redact; the sequence number is the primary key, so two processes
+// appending at once cannot fork the chain.
+type SQLStore struct {
+	db       *sql.DB
+	postgres bool
+}
+
+// NewSQLStore creates the table if needed. driver is the name passed to
+// sql.Open and selects the placeholder style.
+func NewSQLStore(ctx context.Context, db *sql.DB, driver string) (*SQLStore, error) {
+	s := &SQLStore{
+		db:       db,
+		postgres: driver == "pgx" || driver == "postgres",
+	}
+
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS audit_events (
+		seq         BIGINT PRIMARY KEY,
+		time        TEXT NOT NULL,
+		tenant_id   TEXT NOT NULL,
+		type        TEXT NOT NULL,
+		entity_type TEXT NOT NULL,
+		entity_id   TEXT NOT NULL,
+		actor       TEXT NOT NULL,
+		request_id  TEXT NOT NULL,
+		message_id  TEXT NOT NULL,
+		data        TEXT NOT NULL,
+		prev_hash   TEXT NOT NULL,
+		hash        TEXT NOT NULL,
+		redacted    INTEGER NOT NULL DEFAULT 0
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create audit_events table: %w", err)
+	}
+	// Tables created by earlier versions lack the redacted column.
+	if _, err := db.ExecContext(ctx, `SELECT redacted FROM audit_events LIMIT 1`); err != nil {
+		if _, err := db.ExecContext(ctx, `ALTER TABLE audit_events ADD COLUMN redacted INTEGER NOT NULL DEFAULT 0`); err != nil {
+			return nil, fmt.Errorf("add audit_events.redacted column: %w", err)
+		}
+	}
+	_, err = db.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS audit_events_tenant_entity ON audit_events (tenant_id, entity_id)`)
+	if err != nil {
+		return nil, fmt.Errorf("create audit_events entity index: %w", err)
+	}
+	_, err = db.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS audit_events_message ON audit_events (message_id)`)
+	if err != nil {
+		return nil, fmt.Errorf("create audit_events message index: %w", err)
+	}
+	return s, nil
+}
+
+// rebind rewrites ? placeholders as $n for Postgres.
+func (s *SQLStore) rebind(query string) string {
+	if !s.postgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+const eventColumns = `seq, time, tenant_id, type, entity_type, entity_id, actor, request_id, message_id, data, prev_hash, hash, redacted`
+
+func (s *SQLStore) Append(ctx context.Context, e *Event) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if e.MessageID != "" {
+		var one int
+		err := tx.QueryRowContext(ctx, s.rebind(`SELECT 1 FROM audit_events WHERE message_id = ? LIMIT 1`), e.MessageID).Scan(&one)
+		if err == nil {
+			return ErrDuplicate
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+	}
+
+	var prev *Event
+	last := &Event{}
+	err = tx.QueryRowContext(ctx, `SELECT seq, hash FROM audit_events ORDER BY seq DESC LIMIT 1`).Scan(&last.Seq, &last.Hash)
+	switch {
+	case err == nil:
+		prev = last
+	case !errors.Is(err, sql.ErrNoRows):
+		return err
+	}
+	e.seal(prev)
+
+	_, err = tx.ExecContext(ctx, s.rebind(`INSERT INTO audit_events (`+eventColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0)`),
+		e.Seq,
+		e.Time.Format(sqlTimeLayout),
+		e.TenantID,
+		e.Type,
+		e.EntityType,
+		e.EntityID,
+		e.Actor,
+		e.RequestID,
+		e.MessageID,
+		string(e.Data),
+		e.PrevHash,
+		e.Hash,
+	)
+	if err != nil {
+		return fmt.Errorf("insert audit event %d: %w", e.Seq, err)
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) Query(ctx context.Context, f Filter) ([]*Event, error) {
+	where := []string{"tenant_id = ?"}
+	args := []any{tenant.FromContext(ctx)}
+	for column, value := range map[string]string{
+		"entity_type": f.EntityType,
+		"entity_id":   f.EntityID,
+		"actor":       f.Actor,
+		"type":        f.Type,
+	} {
+		if value != "" {
+			where = append(where, column+" = ?")
+			args = append(args, value)
+		}
+	}
+	if !f.From.IsZero() {
+		where = append(where, "time >= ?")
+		args = append(args, f.From.UTC().Format(sqlTimeLayout))
+	}
+	if !f.To.IsZero() {
+		where = append(where, "time < ?")
+		args = append(args, f.To.UTC().Format(sqlTimeLayout))
+	}
+	if f.After > 0 {
+		where = append(where, "seq > ?")
+		args = append(args, f.After)
+	}
+
+	query := `SELECT ` + eventColumns + ` FROM audit_events WHERE ` + strings.Join(where, " AND ") + ` ORDER BY seq`
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*Event{}
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func (s *SQLStore) Verify(ctx context.Context) (Verification, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+eventColumns+` FROM audit_events ORDER BY seq`)
+	if err != nil {
+		return Verification{}, err
+	}
+	defer rows.Close()
+
+	v := newVerifier()
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return Verification{}, err
+		}
+		if !v.check(e) {
+			break
+		}
+	}
+	return v.result, rows.Err()
+}
+
+func (s *SQLStore) Redact(ctx context.Context, f Filter, redact func(*Event) bool) (int, error) {
+	f.Limit = 0
+	events, err := s.Query(ctx, f)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, e := range events {
+		if !redact(e) {
+			continue
+		}
+		_, err := s.db.ExecContext(ctx, s.rebind(`UPDATE audit_events SET actor = ?, data = ?, redacted = 1 WHERE seq = ?`),
+			e.Actor, string(e.Data), e.Seq)
+		if err != nil {
+			return n, fmt.Errorf("redact audit event %d: %w", e.Seq, err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+func scanEvent(rows *sql.Rows) (*Event, error) {
+	var (
+		e         Event
+		timestamp string
+		data      string
+		redacted  int
+	)
+	err := rows.Scan(&e.Seq, &timestamp, &e.TenantID, &e.Type, &e.EntityType, &e.EntityID,
+		&e.Actor, &e.RequestID, &e.MessageID, &data, &e.PrevHash, &e.Hash, &redacted)
+	if err != nil {
+		return nil, err
+	}
+	e.Redacted = redacted != 0
+	if e.Time, err = time.Parse(sqlTimeLayout, timestamp); err != nil {
+		return nil, fmt.Errorf("audit event %d: %w", e.Seq, err)
+	}
+	if data != "" {
+		e.Data = []byte(data)
+	}
+	return &e, nil
+}
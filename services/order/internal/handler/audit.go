@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/order/internal/audit"
+)
+
+// Limits of the events listed by GET /admin/audit.
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// WithAudit serves GET /admin/audit, the audit trail of the tenant
+// filtered by entity, actor, type and time, and GET /admin/audit/verify,
+// which checks its hash chain, from store.
+func WithAudit(store audit.Store) Option {
+	return func(h *OrderHandler) {
+		h.audit = store
+	}
+}
+
+// listAudit serves GET /admin/audit, oldest event first. A full page sets
+// next_after, the value of after that returns the following page.
+func (h *OrderHandler) listAudit(w http.ResponseWriter, r *http.Request) {
+	if _, scoped := customerScope(r); scoped {
+		respondError(w, http.StatusForbidden, "The audit trail requires the admin role")
+		return
+	}
+	f, err := parseAuditFilter(r.URL.Query())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	events, err := h.audit.Query(r.Context(), f)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "audit query failed", logging.Err(err))
+		respondError(w, http.StatusInternalServerError, "Failed to query the audit trail")
+		return
+	}
+
+	resp := map[string]any{
+		"events": events,
+		"count":  len(events),
+	}
+	if len(events) == f.Limit {
+		resp["next_after"] = events[len(events)-1].Seq
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// verifyAudit serves GET /admin/audit/verify.
+func (h *OrderHandler) verifyAudit(w http.ResponseWriter, r *http.Request) {
+	if _, scoped := customerScope(r); scoped {
+		respondError(w, http.StatusForbidden, "The audit trail requires the admin role")
+		return
+	}
+
+	result, err := h.audit.Verify(r.Context())
+	if err != nil {
+		logger.ErrorContext(r.Context(), "audit verification failed", logging.Err(err))
+		respondError(w, http.StatusInternalServerError, "Failed to verify the audit trail")
+		return
+	}
+	if !result.Valid {
+		logger.WarnContext(r.Context(), "audit trail tampered with", "broken_at", result.BrokenAt, "reason", result.Reason)
+	}
+	respondJSON(w, http.StatusOK, result)
+}
+
+// parseAuditFilter reads entity_type, entity_id, actor, type, from, to,
+// after and limit.
+func parseAuditFilter(q url.Values) (audit.Filter, error) {
+	f := audit.Filter{
+		EntityType: q.Get("entity_type"),
+		EntityID:   q.Get("entity_id"),
+		Actor:      q.Get("actor"),
+		Type:       q.Get("type"),
+		Limit:      defaultAuditLimit,
+	}
+
+	var err error
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit < 1 || f.Limit > maxAuditLimit {
+			return f, fmt.Errorf("limit must be between 1 and %d", maxAuditLimit)
+		}
+	}
+	if v := q.Get("after"); v != "" {
+		if f.After, err = strconv.ParseInt(v, 10, 64); err != nil || f.After < 0 {
+			return f, fmt.Errorf("after must be a non-negative integer")
+		}
+	}
+	if v := q.Get("from"); v != "" {
+		if f.From, err = parseTime(v); err != nil {
+			return f, fmt.Errorf("from: %v", err)
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if f.To, err = parseTime(v); err != nil {
+			return f, fmt.Errorf("to: %v", err)
+		}
+	}
+	return f, nil
+}
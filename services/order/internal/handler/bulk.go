@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"unicode"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/order/internal/service"
+)
+
+var errTooManyOrders = errors.New("too many orders")
+
+// importOrders serves POST /orders/bulk. The body is a JSON array of
+// CreateOrderRequest or one request per line (NDJSON). The orders are
+// created in the background; the answer is 202 with the job, whose
+// Location is polled for per-order results.
+func (h *OrderHandler) importOrders(w http.ResponseWriter, r *http.Request) {
+	if _, scoped := customerScope(r); scoped {
+		respondError(w, http.StatusForbidden, "Bulk import requires the admin role")
+		return
+	}
+
+	reqs, err := decodeOrders(r.Body, h.importer.MaxOrders())
+	switch {
+	case errors.Is(err, errTooManyOrders):
+		respondError(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("A bulk import takes at most %d orders", h.importer.MaxOrders()))
+		return
+	case errors.As(err, new(*http.MaxBytesError)):
+		respondBodyError(w, err)
+		return
+	case err != nil:
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	case len(reqs) == 0:
+		respondError(w, http.StatusBadRequest, "No orders to import")
+		return
+	}
+
+	job := h.importer.Start(r.Context(), reqs)
+
+	w.Header().Set("Location", "/orders/bulk/"+job.ID)
+	respondJSON(w, http.StatusAccepted, job)
+}
+
+// decodeOrders reads a JSON array or a stream of JSON objects, failing with
+// errTooManyOrders once more than limit orders have been read.
+func decodeOrders(body io.Reader, limit int) ([]service.CreateOrderRequest, error) {
+	br := bufio.NewReader(body)
+	first, err := firstByte(br)
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(br)
+	array := first == '['
+	if array {
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	}
+
+	var reqs []service.CreateOrderRequest
+	for {
+		if array && !dec.More() {
+			break
+		}
+		var req CreateOrderRequest
+		err := dec.Decode(&req)
+		if !array && err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("order %d: %w", len(reqs), err)
+		}
+		if len(reqs) == limit {
+			return nil, errTooManyOrders
+		}
+		reqs = append(reqs, req.toService())
+	}
+	if array {
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	}
+	return reqs, nil
+}
+
+// firstByte returns the first non-space byte of br without consuming it.
+func firstByte(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if !unicode.IsSpace(rune(b)) {
+			return b, br.UnreadByte()
+		}
+	}
+}
+
+// getImport serves GET /orders/bulk/{jobID}.
+func (h *OrderHandler) getImport(w http.ResponseWriter, r *http.Request) {
+	if _, scoped := customerScope(r); scoped {
+		respondError(w, http.StatusForbidden, "Bulk import requires the admin role")
+		return
+	}
+
+	job, err := h.importer.Job(r.Context(), r.PathValue("jobID"))
+	if err != nil {
+		if errors.Is(err, service.ErrImportNotFound) {
+			respondError(w, http.StatusNotFound, "Import job not found")
+			return
+		}
+		logger.ErrorContext(r.Context(), "getting import job failed", logging.Err(err))
+		respondError(w, http.StatusInternalServerError, "Failed to get import job")
+		return
+	}
+	respondJSON(w, http.StatusOK, job)
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/chaos"
+)
+
+// WithChaos serves the faults injected by inj at /admin/chaos: GET shows
+// them, PUT changes them and DELETE turns injection off.
+func WithChaos(inj *chaos.Injector) Option {
+	return func(h *OrderHandler) {
+		h.chaos = inj
+	}
+}
+
+// serveChaos serves /admin/chaos to admins.
+func (h *OrderHandler) serveChaos(w http.ResponseWriter, r *http.Request) {
+	if _, scoped := customerScope(r); scoped {
+		respondError(w, http.StatusForbidden, "Fault injection requires the admin role")
+		return
+	}
+	chaos.Handler(h.chaos).ServeHTTP(w, r)
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig lists what browsers on other origins may do with the API. No
+// origins disables CORS, so only same-origin pages can read responses.
+type CORSConfig struct {
+	Origins     []string      `config:"origins" usage:"Comma separated origins allowed to call the API from a browser, * for any; empty disables CORS"`
+	Methods     []string      `config:"methods" usage:"Methods allowed in cross-origin requests"`
+	Headers     []string      `config:"headers" usage:"Request headers allowed in cross-origin requests"`
+	Expose      []string      `config:"expose-headers" usage:"Response headers readable by cross-origin callers"`
+	Credentials bool          `config:"credentials" usage:"Let browsers send cookies and HTTP authentication with cross-origin requests"`
+	MaxAge      time.Duration `config:"max-age" usage:"How long browsers may cache a preflight response"`
+}
+
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		Methods: []string{http.MethodGet, http.MethodPost, http.MethodPatch},
+		Headers: []string{"Authorization", "Content-Type", "X-API-Key", "X-Tenant-Id", "X-Request-Id", "Prefer", "If-Match"},
+		Expose:  []string{"Location", "Retry-After", "X-Request-Id", "Preference-Applied", "ETag"},
+		MaxAge:  10 * time.Minute,
+	}
+}
+
+func (c CORSConfig) Validate() error {
+	if c.Credentials && slices.Contains(c.Origins, "*") {
+		return errors.New("credentials cannot be allowed for origin *")
+	}
+	if c.MaxAge < 0 {
+		return errors.New("max-age must not be negative")
+	}
+	return nil
+}
+
+// CORS answers preflight requests from the configured origins and lets
+// browsers read the responses sent to them. Install it outside the
+// authentication middleware: browsers send preflights without credentials.
+func CORS(cfg CORSConfig) func(http.Handler) http.Handler {
+	anyOrigin := slices.Contains(cfg.Origins, "*")
+	methods := strings.Join(cfg.Methods, ", ")
+	headers := strings.Join(cfg.Headers, ", ")
+	expose := strings.Join(cfg.Expose, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		if len(cfg.Origins) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			if !anyOrigin && !slices.Contains(cfg.Origins, origin) {
+				if preflight {
+					respondError(w, http.StatusForbidden, "Origin not allowed")
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if anyOrigin && !cfg.Credentials {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.Credentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if preflight {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+				h.Set("Access-Control-Allow-Methods", methods)
+				h.Set("Access-Control-Allow-Headers", headers)
+				h.Set("Access-Control-Max-Age", maxAge)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			if expose != "" {
+				h.Set("Access-Control-Expose-Headers", expose)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// contentSecurityPolicy forbids every resource for API responses; /docs sets
+// its own to load Swagger UI.
+const contentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+
+// SecurityHeaders sets headers that stop browsers from sniffing, framing or
+// leaking API responses.
+func SecurityHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", "DENY")
+		h.Set("Referrer-Policy", "no-referrer")
+		h.Set("Content-Security-Policy", contentSecurityPolicy)
+		h.Set("Cross-Origin-Resource-Policy", "same-site")
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RequestDeadline gives the context of every request but those to the
+// exempt paths a deadline of timeout, which payment calls made for the
+// request inherit. A timeout of 0 leaves requests without a deadline.
+func RequestDeadline(timeout time.Duration, exempt ...string) func(http.Handler) http.Handler {
+	skip := make(map[string]bool, len(exempt))
+	for _, p := range exempt {
+		skip[p] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		if timeout <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skip[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+)
+
+// defaultDLQLimit is the number of dead-lettered messages listed when the
+// request names no limit.
+const defaultDLQLimit = 100
+
+// DeadLetter is a message of a dead-letter queue as listed by
+// GET /admin/dlq/{queue}.
+type DeadLetter struct {
+	ID            string            `json:"id"`
+	Type          string            `json:"type"`
+	Timestamp     time.Time         `json:"timestamp"`
+	FailureReason string            `json:"failure_reason"`
+	LastError     string            `json:"last_error,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	Payload       json.RawMessage   `json:"payload"`
+
+	// Trace is the delivery history of the message when the broker keeps
+	// traces.
+	Trace []broker.TraceEvent `json:"trace,omitempty"`
+}
+
+// QueueInfo describes a broker queue as listed by GET /admin/queues.
+type QueueInfo struct {
+	Name      string `json:"name"`
+	Size      int    `json:"size"`
+	Received  int64  `json:"received"`
+	Processed int64  `json:"processed"`
+	Failed    int64  `json:"failed"`
+	DLQ       string `json:"dlq,omitempty"`
+	DLQSize   int    `json:"dlq_size,omitempty"`
+}
+
+// WithDeadLetters serves GET /admin/queues for the queues of b, and
+// GET /admin/dlq/{queue} and POST /admin/dlq/{queue}/redrive for those
+// that have a dead-letter queue. It also serves GET /admin/topics/{topic}
+// and POST /admin/topics/{topic}/seek for the topics of b, and
+// GET /admin/topology for its whole topology.
+func WithDeadLetters(b *broker.Broker) Option {
+	return func(h *OrderHandler) {
+		h.broker = b
+	}
+}
+
+// deadLetterQueue resolves the {queue} path value to the dead-letter queue
+// of that queue, answering 403 or 404 when it cannot.
+func (h *OrderHandler) deadLetterQueue(w http.ResponseWriter, r *http.Request) (*broker.Queue, bool) {
+	if _, scoped := customerScope(r); scoped {
+		respondError(w, http.StatusForbidden, "DLQ management requires the admin role")
+		return nil, false
+	}
+
+	name := r.PathValue("queue")
+	q, ok := h.broker.GetQueue(name)
+	if !ok || q.DeadLetterQueue() == nil {
+		respondError(w, http.StatusNotFound, "No dead-letter queue for "+name)
+		return nil, false
+	}
+	return q.DeadLetterQueue(), true
+}
+
+// listQueues serves GET /admin/queues, sorted by name.
+func (h *OrderHandler) listQueues(w http.ResponseWriter, r *http.Request) {
+	if _, scoped := customerScope(r); scoped {
+		respondError(w, http.StatusForbidden, "Queue inspection requires the admin role")
+		return
+	}
+
+	stats := h.broker.Stats()
+	queues := make([]QueueInfo, 0, len(stats.Queues))
+	for name, qs := range stats.Queues {
+		info := QueueInfo{
+			Name:      name,
+			Size:      qs.CurrentSize,
+			Received:  qs.TotalReceived,
+			Processed: qs.TotalProcessed,
+			Failed:    qs.TotalFailed,
+		}
+		if q, ok := h.broker.GetQueue(name); ok && q.DeadLetterQueue() != nil {
+			info.DLQ = q.DeadLetterQueue().Name()
+			info.DLQSize = q.DeadLetterQueue().Size()
+		}
+		queues = append(queues, info)
+	}
+	slices.SortFunc(queues, func(a, b QueueInfo) int { return strings.Compare(a.Name, b.Name) })
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"queues": queues,
+		"count":  len(queues),
+	})
+}
+
+// parseDLQLimit reads the limit query parameter; 0 means every message.
+func parseDLQLimit(r *http.Request, def int) (int, bool) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(v)
+	return n, err == nil && n >= 0
+}
+
+// listDeadLetters serves GET /admin/dlq/{queue}, oldest message first.
+func (h *OrderHandler) listDeadLetters(w http.ResponseWriter, r *http.Request) {
+	dlq, ok := h.deadLetterQueue(w, r)
+	if !ok {
+		return
+	}
+	limit, ok := parseDLQLimit(r, defaultDLQLimit)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "limit must be a non-negative integer")
+		return
+	}
+
+	msgs := dlq.Peek(limit)
+	letters := make([]DeadLetter, 0, len(msgs))
+	for _, msg := range msgs {
+		letters = append(letters, DeadLetter{
+			ID:            msg.ID,
+			Type:          msg.Type,
+			Timestamp:     msg.Timestamp,
+			FailureReason: msg.GetMetadata(broker.FailureReasonMetadata),
+			LastError:     msg.GetMetadata(broker.LastErrorMetadata),
+			Metadata:      msg.Metadata,
+			Payload:       msg.Payload,
+			Trace:         h.broker.MessageTrace(msg.ID),
+		})
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"queue":    r.PathValue("queue"),
+		"dlq":      dlq.Name(),
+		"total":    dlq.Size(),
+		"messages": letters,
+	})
+}
+
+// redriveDeadLetters serves POST /admin/dlq/{queue}/redrive. Without a
+// limit every dead-lettered message is requeued.
+func (h *OrderHandler) redriveDeadLetters(w http.ResponseWriter, r *http.Request) {
+	dlq, ok := h.deadLetterQueue(w, r)
+	if !ok {
+		return
+	}
+	limit, ok := parseDLQLimit(r, 0)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "limit must be a non-negative integer")
+		return
+	}
+
+	n, err := h.broker.Redrive(r.Context(), dlq.Name(), limit)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "redriving dead letters failed", "dlq", dlq.Name(), logging.Err(err))
+		respondError(w, http.StatusInternalServerError, "Failed to redrive messages")
+		return
+	}
+	logger.InfoContext(r.Context(), "dead letters redriven", "dlq", dlq.Name(), "count", n)
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"queue":     r.PathValue("queue"),
+		"dlq":       dlq.Name(),
+		"redriven":  n,
+		"remaining": dlq.Size(),
+	})
+}
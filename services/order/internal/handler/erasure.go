@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/order/internal/audit"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/order/internal/service"
+)
+
+// ErasureReport is the body of DELETE /customers/{id}/data: what the
+// erasure touched.
+type ErasureReport struct {
+	CustomerID string `json:"customer_id"`
+
+	// Orders are the orders anonymized.
+	Orders []string `json:"orders"`
+
+	// AuditEvents counts the audit events redacted.
+	AuditEvents int `json:"audit_events"`
+
+	// DeadLetters and RetainedMessages count the broker messages scrubbed,
+	// by dead-letter queue and by topic.
+	DeadLetters      map[string]int `json:"dead_letters"`
+	RetainedMessages map[string]int `json:"retained_messages"`
+}
+
+// eraseCustomer serves DELETE /customers/{id}/data, which erases the
+// personal data of a customer. The copies kept in the audit trail and in
+// the broker are scrubbed first, while the orders still tell what to look
+// for, so a request that fails part way can be repeated; the orders are
+// anonymized last.
+func (h *OrderHandler) eraseCustomer(w http.ResponseWriter, r *http.Request) {
+	if _, scoped := customerScope(r); scoped {
+		respondError(w, http.StatusForbidden, "Erasing customer data requires the admin role")
+		return
+	}
+	customerID := r.PathValue("id")
+	ctx := r.Context()
+
+	scrubber, err := h.svc.CustomerScrubber(ctx, customerID)
+	if err != nil {
+		logger.ErrorContext(ctx, "reading customer data failed", "customer_id", customerID, logging.Err(err))
+		respondError(w, http.StatusInternalServerError, "Failed to erase customer data")
+		return
+	}
+	report := ErasureReport{
+		CustomerID:       customerID,
+		DeadLetters:      map[string]int{},
+		RetainedMessages: map[string]int{},
+	}
+
+	if !scrubber.Empty() {
+		if h.audit != nil {
+			if report.AuditEvents, err = h.redactAudit(r, customerID, scrubber); err != nil {
+				logger.ErrorContext(ctx, "redacting audit trail failed", "customer_id", customerID, logging.Err(err))
+				respondError(w, http.StatusInternalServerError, "Failed to redact the audit trail")
+				return
+			}
+		}
+		if h.broker != nil {
+			scrubbed := h.broker.Scrub(ctx, broker.Scrubber(scrubber.Message))
+			report.DeadLetters, report.RetainedMessages = scrubbed.DeadLetters, scrubbed.Retained
+		}
+	}
+
+	if report.Orders, err = h.svc.EraseCustomer(ctx, customerID); err != nil {
+		logger.ErrorContext(ctx, "erasing customer orders failed", "customer_id", customerID, logging.Err(err))
+		respondError(w, http.StatusInternalServerError, "Failed to erase customer data")
+		return
+	}
+	respondJSON(w, http.StatusOK, report)
+}
+
+// redactAudit scrubs the data and actor of the audit events of the orders
+// of customerID.
+func (h *OrderHandler) redactAudit(r *http.Request, customerID string, scrubber *service.Scrubber) (int, error) {
+	redact := func(e *audit.Event) bool {
+		data, dataChanged := scrubber.JSON(e.Data)
+		actor, actorChanged := scrubber.String(e.Actor)
+		e.Data, e.Actor = data, actor
+		return dataChanged || actorChanged
+	}
+
+	var orderIDs []string
+	err := h.svc.ExportOrders(r.Context(), service.ListOptions{CustomerID: customerID}, func(o *order.Order) error {
+		orderIDs = append(orderIDs, o.ID)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, id := range orderIDs {
+		n, err := h.audit.Redact(r.Context(), audit.Filter{EntityType: audit.EntityOrder, EntityID: id}, redact)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/events"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+)
+
+// DefaultHeartbeatInterval is how often idle event streams receive a comment
+// line so proxies keep the connection open.
+const DefaultHeartbeatInterval = 15 * time.Second
+
+// streamBuffer is the number of events held for a slow client before new
+// ones are dropped.
+const streamBuffer = 64
+
+// StreamEvent is one order event sent to GET /orders/events clients.
+type StreamEvent struct {
+	ID         string
+	Type       string
+	TenantID   string
+	OrderID    string
+	CustomerID string
+	Status     order.OrderStatus
+	Data       json.RawMessage
+}
+
+type eventFilter struct {
+	tenantID   string
+	customerID string
+	status     order.OrderStatus
+}
+
+func (f eventFilter) matches(e StreamEvent) bool {
+	if e.TenantID != f.tenantID {
+		return false
+	}
+	if f.customerID != "" && e.CustomerID != f.customerID {
+		return false
+	}
+	if f.status != order.OrderStatus_ORDER_STATUS_UNSPECIFIED && e.Status != f.status {
+		return false
+	}
+	return true
+}
+
+type streamClient struct {
+	filter  eventFilter
+	events  chan StreamEvent
+	dropped int
+}
+
+// EventHub fans order events from a broker queue out to the connected
+// event stream clients.
+type EventHub struct {
+	heartbeat time.Duration
+
+	mu      sync.Mutex
+	clients map[*streamClient]struct{}
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func NewEventHub(heartbeat time.Duration) *EventHub {
+	if heartbeat <= 0 {
+		heartbeat = DefaultHeartbeatInterval
+	}
+	return &EventHub{
+		heartbeat: heartbeat,
+		clients:   make(map[*streamClient]struct{}),
+		closed:    make(chan struct{}),
+	}
+}
+
+// Close ends every open event stream. Register it with
+// http.Server.RegisterOnShutdown: streams never go idle on their own, so
+// Shutdown would otherwise wait for them until its deadline.
+func (hub *EventHub) Close() {
+	hub.closeOnce.Do(func() { close(hub.closed) })
+}
+
+// HandleMessage is a broker.MessageHandler that forwards order.created,
+// order.status_changed and return messages to the matching clients. Other
+// message types are acknowledged and ignored. Return events carry no order
+// status and only reach clients that do not filter by status.
+func (hub *EventHub) HandleMessage(msg *broker.Message) error {
+	e := StreamEvent{
+		ID:       msg.ID,
+		Type:     msg.Type,
+		TenantID: msg.GetMetadata(broker.TenantMetadata),
+		Data:     msg.Payload,
+	}
+	if e.TenantID == "" {
+		e.TenantID = tenant.Default
+	}
+
+	switch msg.Type {
+	case events.TypeOrderCreated:
+		var created events.OrderCreatedV1
+		if err := events.Decode(msg, &created); err != nil {
+			return err
+		}
+		e.OrderID = created.Order.ID
+		e.CustomerID = created.Order.CustomerID
+		e.Status = created.Order.Status
+	case "order.status_changed":
+		var changed order.OrderStatusChangedEvent
+		if err := msg.Decode(&changed); err != nil {
+			return err
+		}
+		e.OrderID = changed.OrderID
+		e.CustomerID = changed.CustomerID
+		e.Status = changed.To
+	case order.EventTypeReturnRequested, order.EventTypeReturnApproved, order.EventTypeReturnRejected,
+		order.EventTypeReturnReceived, order.EventTypeReturnRefunded:
+		var returned order.OrderReturnEvent
+		if err := msg.Decode(&returned); err != nil {
+			return err
+		}
+		e.OrderID = returned.OrderID
+		e.CustomerID = returned.CustomerID
+	default:
+		return nil
+	}
+
+	hub.Publish(e)
+	return nil
+}
+
+// Publish delivers e to every client whose filter matches. Clients that do
+// not keep up lose events rather than blocking the others.
+func (hub *EventHub) Publish(e StreamEvent) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for c := range hub.clients {
+		if !c.filter.matches(e) {
+			continue
+		}
+		select {
+		case c.events <- e:
+		default:
+			c.dropped++
+		}
+	}
+}
+
+// Clients returns the number of connected clients.
+func (hub *EventHub) Clients() int {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	return len(hub.clients)
+}
+
+func (hub *EventHub) subscribe(filter eventFilter) *streamClient {
+	c := &streamClient{filter: filter, events: make(chan StreamEvent, streamBuffer)}
+
+	hub.mu.Lock()
+	hub.clients[c] = struct{}{}
+	hub.mu.Unlock()
+	return c
+}
+
+func (hub *EventHub) unsubscribe(c *streamClient) int {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	delete(hub.clients, c)
+	return c.dropped
+}
+
+// streamEvents serves GET /orders/events as Server-Sent Events of the
+// tenant of the request, filtered by the optional customer_id and status
+// query parameters.
+func (h *OrderHandler) streamEvents(w http.ResponseWriter, r *http.Request) {
+	if h.events == nil {
+		respondError(w, http.StatusNotFound, "Event stream not enabled")
+		return
+	}
+
+	q := r.URL.Query()
+	filter := eventFilter{tenantID: tenant.FromContext(r.Context()), customerID: q.Get("customer_id")}
+	if s := q.Get("status"); s != "" {
+		status, ok := parseOrderStatus(s)
+		if !ok {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("Unknown status %q", s))
+			return
+		}
+		filter.status = status
+	}
+	if customerID, scoped := customerScope(r); scoped {
+		if filter.customerID != "" && filter.customerID != customerID {
+			respondError(w, http.StatusForbidden, "Cannot watch orders of another customer")
+			return
+		}
+		filter.customerID = customerID
+	}
+
+	// The server write timeout would otherwise end the stream.
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logger.WarnContext(r.Context(), "cannot clear write deadline of event stream", logging.Err(err))
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", time.Second.Milliseconds()*3)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	client := h.events.subscribe(filter)
+	logger.InfoContext(r.Context(), "event stream opened",
+		"customer_id", filter.customerID, "status", filter.status.String(), "clients", h.events.Clients())
+	defer func() {
+		dropped := h.events.unsubscribe(client)
+		logger.InfoContext(r.Context(), "event stream closed", "dropped", dropped)
+	}()
+
+	heartbeat := time.NewTicker(h.events.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.events.closed:
+			return
+		case e := <-client.events:
+			if err := writeStreamEvent(w, e); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprintf(w, ": heartbeat %s\n\n", time.Now().UTC().Format(time.RFC3339)); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeStreamEvent(w http.ResponseWriter, e StreamEvent) error {
+	// Payloads are compact JSON, but guard against embedded newlines,
+	// which would end the data field early.
+	data := strings.ReplaceAll(string(e.Data), "\n", "")
+	_, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
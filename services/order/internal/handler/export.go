@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/order/internal/service"
+)
+
+// exportFlushEvery is the number of orders written between flushes of an
+// export, so the client receives it in chunks as it is read.
+const exportFlushEvery = 100
+
+// exportColumns are the columns of a CSV export.
+var exportColumns = []string{
+	"id", "customer_id", "customer_email", "status", "total_cents", "currency",
+	"items", "payment_transaction_id", "created_at", "updated_at",
+}
+
+// exportWriter writes the orders of an export in one format.
+type exportWriter interface {
+	write(o *order.Order) error
+	// flush sends what was buffered to the response writer.
+	flush() error
+}
+
+type csvExport struct{ w *csv.Writer }
+
+func newCSVExport(w io.Writer) (*csvExport, error) {
+	e := &csvExport{w: csv.NewWriter(w)}
+	return e, e.w.Write(exportColumns)
+}
+
+func (e *csvExport) write(o *order.Order) error {
+	return e.w.Write([]string{
+		o.ID, o.CustomerID, o.CustomerEmail, o.Status.String(),
+		strconv.FormatInt(o.TotalCents, 10), o.Currency,
+		strconv.Itoa(len(o.Items)), o.PaymentTransactionID,
+		o.CreatedAt.UTC().Format(time.RFC3339Nano), o.UpdatedAt.UTC().Format(time.RFC3339Nano),
+	})
+}
+
+func (e *csvExport) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonExport struct{ enc *json.Encoder }
+
+func (e ndjsonExport) write(o *order.Order) error { return e.enc.Encode(o) }
+func (e ndjsonExport) flush() error               { return nil }
+
+// exportOrders serves GET /orders/export?format=csv|ndjson, the orders
+// created between from and to, oldest first. It takes the status and
+// customer_id filters of GET /orders; customers only export their own
+// orders.
+//
+// The orders are written as the store reads them with chunked transfer
+// encoding, so an export is never held in memory whole, and a client that
+// reads slowly slows the store down. A store error after the first orders
+// were sent aborts the response, which the client sees as a truncated
+// transfer rather than a complete file.
+func (h *OrderHandler) exportOrders(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "ndjson" {
+		respondError(w, http.StatusBadRequest, `format must be "csv" or "ndjson"`)
+		return
+	}
+
+	opts := service.ListOptions{CustomerID: q.Get("customer_id")}
+	var err error
+	if v := q.Get("status"); v != "" {
+		status, ok := parseOrderStatus(v)
+		if !ok {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("unknown status %q", v))
+			return
+		}
+		opts.Status = status
+	}
+	if v := q.Get("from"); v != "" {
+		if opts.CreatedFrom, err = parseTime(v); err != nil {
+			respondError(w, http.StatusBadRequest, "from: "+err.Error())
+			return
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if opts.CreatedTo, err = parseTime(v); err != nil {
+			respondError(w, http.StatusBadRequest, "to: "+err.Error())
+			return
+		}
+	}
+	if customerID, scoped := customerScope(r); scoped {
+		if opts.CustomerID != "" && opts.CustomerID != customerID {
+			respondError(w, http.StatusForbidden, "Cannot export orders of another customer")
+			return
+		}
+		opts.CustomerID = customerID
+	}
+
+	rc := http.NewResponseController(w)
+	var (
+		out     exportWriter
+		written int
+	)
+	// start sends the headers once the store has accepted the query, so
+	// that an invalid range or an unavailable store still gets a status.
+	start := func() error {
+		// The server write timeout would otherwise end long exports.
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+			logger.WarnContext(r.Context(), "cannot clear write deadline of export", logging.Err(err))
+		}
+		w.Header().Set("Content-Disposition", `attachment; filename="orders.`+format+`"`)
+		if format == "csv" {
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			e, err := newCSVExport(w)
+			out = e
+			return err
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		out = ndjsonExport{enc: json.NewEncoder(w)}
+		return nil
+	}
+
+	err = h.svc.ExportOrders(r.Context(), opts, func(o *order.Order) error {
+		if out == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := out.write(o); err != nil {
+			return err
+		}
+		if written++; written%exportFlushEvery == 0 {
+			if err := out.flush(); err != nil {
+				return err
+			}
+			return rc.Flush()
+		}
+		return nil
+	})
+	if err != nil && out == nil {
+		if errors.Is(err, service.ErrInvalidListOptions) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		logger.ErrorContext(r.Context(), "exporting orders failed", logging.Err(err))
+		respondError(w, http.StatusInternalServerError, "Failed to export orders")
+		return
+	}
+	if err != nil {
+		if r.Context().Err() == nil {
+			logger.ErrorContext(r.Context(), "export aborted", "written", written, logging.Err(err))
+		}
+		panic(http.ErrAbortHandler)
+	}
+
+	if out == nil {
+		if err := start(); err != nil {
+			return
+		}
+	}
+	if out.flush() == nil {
+		rc.Flush()
+	}
+	logger.InfoContext(r.Context(), "orders exported", "format", format, "orders", written)
+}
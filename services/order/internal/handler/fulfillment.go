@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/order/internal/service"
+)
+
+type CreateFulfillmentRequest struct {
+	// Items are indexes into the items of the order
+	Items  []int  `json:"items"`
+	Reason string `json:"reason"`
+}
+
+type UpdateFulfillmentRequest struct {
+	// Status is "shipped" or "delivered"; empty keeps the current one
+	Status         string `json:"status"`
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"tracking_number"`
+	Reason         string `json:"reason"`
+}
+
+// createFulfillment serves POST /orders/{id}/fulfillments. Like the other
+// fulfilment updates it needs the admin role; an If-Match header makes it
+// conditional on the order version.
+func (h *OrderHandler) createFulfillment(w http.ResponseWriter, r *http.Request) {
+	if _, scoped := customerScope(r); scoped {
+		respondError(w, http.StatusForbidden, "Managing fulfillments requires the admin role")
+		return
+	}
+	version, _, err := ifMatchVersion(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var req CreateFulfillmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	o, err := h.svc.CreateFulfillment(r.Context(), r.PathValue("id"), req.Items, req.Reason, version)
+	if err != nil {
+		logger.WarnContext(r.Context(), "creating fulfillment failed", logging.Err(err))
+		respondFulfillmentError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", orderETag(o.Version))
+	respondJSON(w, http.StatusCreated, o)
+}
+
+// updateFulfillment serves PATCH /orders/{id}/fulfillments/{fulfillmentID}.
+func (h *OrderHandler) updateFulfillment(w http.ResponseWriter, r *http.Request) {
+	if _, scoped := customerScope(r); scoped {
+		respondError(w, http.StatusForbidden, "Managing fulfillments requires the admin role")
+		return
+	}
+	version, _, err := ifMatchVersion(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var req UpdateFulfillmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	change := service.FulfillmentChange{
+		ID:             r.PathValue("fulfillmentID"),
+		Carrier:        req.Carrier,
+		TrackingNumber: req.TrackingNumber,
+	}
+	if req.Status != "" {
+		status, ok := parseOrderStatus(req.Status)
+		if !ok {
+			respondError(w, http.StatusBadRequest, "Unknown status "+strconv.Quote(req.Status))
+			return
+		}
+		if status != order.OrderStatus_ORDER_STATUS_SHIPPED && status != order.OrderStatus_ORDER_STATUS_DELIVERED {
+			respondError(w, http.StatusBadRequest, "A fulfillment can only be marked shipped or delivered")
+			return
+		}
+		change.Status = status
+	}
+
+	o, err := h.svc.UpdateFulfillment(r.Context(), r.PathValue("id"), change, req.Reason, version)
+	if err != nil {
+		logger.WarnContext(r.Context(), "updating fulfillment failed", logging.Err(err))
+		respondFulfillmentError(w, err)
+		return
+	}
+
+	respondOrder(w, o)
+}
+
+func respondFulfillmentError(w http.ResponseWriter, err error) {
+	var (
+		conflict *service.VersionConflictError
+		verr     *service.ValidationError
+	)
+	switch {
+	case errors.As(err, &conflict):
+		respondVersionConflict(w, conflict)
+	case errors.As(err, &verr):
+		respondJSON(w, http.StatusBadRequest, ValidationErrorResponse{
+			Error:  "Invalid fulfillment",
+			Fields: verr.Fields,
+		})
+	case errors.Is(err, service.ErrOrderNotFound):
+		respondError(w, http.StatusNotFound, "Order not found")
+	case errors.Is(err, service.ErrFulfillmentNotFound):
+		respondError(w, http.StatusNotFound, "Fulfillment not found")
+	case service.IsInvalidTransition(err):
+		respondError(w, http.StatusConflict, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, "Internal error")
+	}
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Check reports whether a dependency can serve requests.
+type Check func(ctx context.Context) error
+
+// Readiness runs the dependency checks behind GET /readyz. The checks run
+// concurrently and share one timeout.
+type Readiness struct {
+	timeout time.Duration
+	names   []string
+	checks  map[string]Check
+}
+
+func NewReadiness(timeout time.Duration) *Readiness {
+	return &Readiness{timeout: timeout, checks: make(map[string]Check)}
+}
+
+// Add registers check under name; adding a name again replaces its check.
+func (rd *Readiness) Add(name string, check Check) {
+	if _, ok := rd.checks[name]; !ok {
+		rd.names = append(rd.names, name)
+	}
+	rd.checks[name] = check
+}
+
+// DependencyStatus is the outcome of one readiness check.
+type DependencyStatus struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Run runs every check and reports whether all of them passed.
+func (rd *Readiness) Run(ctx context.Context) (map[string]DependencyStatus, bool) {
+	ctx, cancel := context.WithTimeout(ctx, rd.timeout)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]DependencyStatus, len(rd.names))
+		ready   = true
+	)
+	for _, name := range rd.names {
+		check := rd.checks[name]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := check(ctx)
+			result := DependencyStatus{Status: "up", LatencyMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status = "down"
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = result
+			ready = ready && err == nil
+		}()
+	}
+	wg.Wait()
+	return results, ready
+}
+
+// handleLiveness answers as long as the process can serve HTTP; it does not
+// look at dependencies, so a failing upstream never gets the service
+// restarted.
+func (h *OrderHandler) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, map[string]string{
+		"status":  "ok",
+		"service": "order",
+	})
+}
+
+// handleReadiness answers 200 when every dependency check passes and 503
+// otherwise, with the status of each dependency.
+func (h *OrderHandler) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]DependencyStatus{}
+	ready := true
+	if h.readiness != nil {
+		checks, ready = h.readiness.Run(r.Context())
+	}
+
+	status, code := "ready", http.StatusOK
+	if !ready {
+		status, code = "not_ready", http.StatusServiceUnavailable
+		logger.WarnContext(r.Context(), "readiness check failed", "checks", checks)
+	}
+	respondJSON(w, code, map[string]any{
+		"status":  status,
+		"service": "order",
+		"checks":  checks,
+	})
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/order/internal/service"
+)
+
+// CustomerOrdersResponse is the body of GET /customers/{id}/orders: one page
+// of the orders of the customer and the summary of all of them.
+type CustomerOrdersResponse struct {
+	Summary service.CustomerSummary `json:"summary"`
+	ListOrdersResponse
+}
+
+// customerOrders serves GET /customers/{id}/orders. It takes the query
+// parameters of GET /orders but customer_id, and lists the newest orders
+// first unless order=asc is given.
+func (h *OrderHandler) customerOrders(w http.ResponseWriter, r *http.Request) {
+	customerID := r.PathValue("id")
+	if scopedID, scoped := customerScope(r); scoped && customerID != scopedID {
+		respondError(w, http.StatusForbidden, "Cannot list orders of another customer")
+		return
+	}
+
+	q := r.URL.Query()
+	opts, err := parseListOptions(q)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	opts.CustomerID = customerID
+	if q.Get("order") == "" {
+		opts.Descending = true
+	}
+
+	page, err := h.svc.ListOrders(r.Context(), opts)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidListOptions) || errors.Is(err, service.ErrInvalidCursor) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		logger.ErrorContext(r.Context(), "listing customer orders failed", "customer_id", customerID, logging.Err(err))
+		respondError(w, http.StatusInternalServerError, "Failed to list orders")
+		return
+	}
+
+	summary, err := h.svc.CustomerSummary(r.Context(), customerID)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "summarizing customer orders failed", "customer_id", customerID, logging.Err(err))
+		respondError(w, http.StatusInternalServerError, "Failed to summarize orders")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, CustomerOrdersResponse{
+		Summary: summary,
+		ListOrdersResponse: ListOrdersResponse{
+			Orders:     page.Orders,
+			Count:      len(page.Orders),
+			NextCursor: page.NextCursor,
+		},
+	})
+}
@@ -2,27 +2,124 @@ package handler
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/chaos"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
 	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/order/internal/audit"
 	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/order/internal/service"
 )
 
+var logger = logging.Component("http")
+
 type OrderHandler struct {
-	svc *service.OrderService
+	svc       *service.OrderService
+	events    *EventHub
+	readiness *Readiness
+	importer  *service.Importer
+	broker    *broker.Broker
+	chaos     *chaos.Injector
+	audit     audit.Store
+	workers   *broker.WorkerRegistry
+
+	// async enables 202 answers to POST /orders; asyncByDefault uses them
+	// for every request instead of only for Prefer: respond-async.
+	async          bool
+	asyncByDefault bool
 }
 
-func NewOrderHandler(svc *service.OrderService) *OrderHandler {
-	return &OrderHandler{svc: svc}
+type Option func(*OrderHandler)
+
+// WithEventHub serves GET /orders/events from hub.
+func WithEventHub(hub *EventHub) Option {
+	return func(h *OrderHandler) {
+		h.events = hub
+	}
+}
+
+// WithReadiness serves GET /readyz from the checks of rd. Without it the
+// service is always ready.
+func WithReadiness(rd *Readiness) Option {
+	return func(h *OrderHandler) {
+		h.readiness = rd
+	}
+}
+
+// WithImporter serves POST /orders/bulk and GET /orders/bulk/{jobID}
+// from im.
+func WithImporter(im *service.Importer) Option {
+	return func(h *OrderHandler) {
+		h.importer = im
+	}
+}
+
+// WithAsyncCreate lets POST /orders answer 202 Accepted with the PENDING
+// order and leave the payment to the workers of service.RequestsTopic.
+// Clients ask for it with Prefer: respond-async; byDefault applies it to
+// every request.
+func WithAsyncCreate(byDefault bool) Option {
+	return func(h *OrderHandler) {
+		h.async = true
+		h.asyncByDefault = byDefault
+	}
+}
+
+func NewOrderHandler(svc *service.OrderService, opts ...Option) *OrderHandler {
+	h := &OrderHandler{svc: svc}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 func (h *OrderHandler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/orders", h.handleOrders)
+	mux.HandleFunc("GET /orders/events", h.streamEvents)
+	mux.HandleFunc("GET /orders/search", h.searchOrders)
+	mux.HandleFunc("GET /orders/export", h.exportOrders)
+	mux.HandleFunc("GET /customers/{id}/orders", h.customerOrders)
+	mux.HandleFunc("DELETE /customers/{id}/data", h.eraseCustomer)
+	mux.HandleFunc("POST /orders/pending", h.createPendingOrder)
+	mux.HandleFunc("POST /orders/{id}/payment", h.recordPayment)
+	mux.HandleFunc("POST /orders/{id}/fulfillments", h.createFulfillment)
+	mux.HandleFunc("PATCH /orders/{id}/fulfillments/{fulfillmentID}", h.updateFulfillment)
+	mux.HandleFunc("POST /orders/{id}/returns", h.createReturn)
+	mux.HandleFunc("PATCH /orders/{id}/returns/{returnID}", h.updateReturn)
+	if h.importer != nil {
+		mux.HandleFunc("POST /orders/bulk", h.importOrders)
+		mux.HandleFunc("GET /orders/bulk/{jobID}", h.getImport)
+	}
+	if h.broker != nil {
+		mux.HandleFunc("GET /admin/queues", h.listQueues)
+		mux.HandleFunc("GET /admin/dlq/{queue}", h.listDeadLetters)
+		mux.HandleFunc("POST /admin/dlq/{queue}/redrive", h.redriveDeadLetters)
+		mux.HandleFunc("GET /admin/topics/{topic}", h.getTopicOffsets)
+		mux.HandleFunc("POST /admin/topics/{topic}/seek", h.seekTopic)
+		mux.HandleFunc("GET /admin/topology", h.getTopology)
+	}
+	if h.chaos != nil {
+		mux.HandleFunc("/admin/chaos", h.serveChaos)
+	}
+	if h.audit != nil {
+		mux.HandleFunc("GET /admin/audit", h.listAudit)
+		mux.HandleFunc("GET /admin/audit/verify", h.verifyAudit)
+	}
 	mux.HandleFunc("/orders/", h.handleOrderByID)
-	mux.HandleFunc("/health", h.handleHealth)
+	mux.HandleFunc("GET /healthz", h.handleLiveness)
+	mux.HandleFunc("GET /readyz", h.handleReadiness)
 	mux.HandleFunc("/stats", h.handleStats)
+	mux.HandleFunc("GET /openapi.json", h.handleOpenAPI)
+	mux.HandleFunc("GET /docs", h.handleDocs)
 }
 
 func (h *OrderHandler) handleOrders(w http.ResponseWriter, r *http.Request) {
@@ -43,6 +140,41 @@ func (h *OrderHandler) handleOrderByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	orderID := parts[2]
+	r = r.WithContext(logging.WithAttrs(r.Context(), "order_id", orderID))
+
+	if len(parts) == 4 && parts[3] == "cancel" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.cancelOrder(w, r, orderID)
+		return
+	}
+
+	if len(parts) == 4 && parts[3] == "status" {
+		if r.Method != http.MethodPatch {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.updateStatus(w, r, orderID)
+		return
+	}
+
+	if len(parts) > 3 && parts[3] == "dispute" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		switch {
+		case len(parts) == 4:
+			h.openDispute(w, r, orderID)
+		case len(parts) == 5 && parts[4] == "resolve":
+			h.resolveDispute(w, r, orderID)
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
 
 	switch r.Method {
 	case http.MethodGet:
@@ -57,6 +189,10 @@ type CreateOrderRequest struct {
 	CustomerEmail string      `json:"customer_email"`
 	Items         []OrderItem `json:"items"`
 	Currency      string      `json:"currency"`
+	CouponCodes   []string    `json:"coupon_codes"`
+
+	// ShippingAddress defaults to the customer profile's default address
+	ShippingAddress *order.Address `json:"shipping_address"`
 }
 
 type OrderItem struct {
@@ -66,16 +202,119 @@ type OrderItem struct {
 	UnitPriceCents int64  `json:"unit_price_cents"`
 }
 
+// ValidationErrorResponse lists every invalid field of a rejected request.
+type ValidationErrorResponse struct {
+	Error  string               `json:"error"`
+	Fields []service.FieldError `json:"fields"`
+}
+
+// OutOfStockResponse lists the products the inventory cannot supply.
+type OutOfStockResponse struct {
+	Error string                  `json:"error"`
+	Items []service.StockShortage `json:"items"`
+}
+
+// PaymentDeclinedResponse is the 402 body of a declined payment. Retriable
+// tells whether placing the order again may succeed, and RetryAfterSeconds
+// how long to wait first, also sent as the Retry-After header.
+type PaymentDeclinedResponse struct {
+	Error             string `json:"error"`
+	Code              string `json:"code"`
+	Retriable         bool   `json:"retriable"`
+	RetryAfterSeconds int64  `json:"retry_after_seconds,omitempty"`
+}
+
+// respondPaymentDeclined answers 402 with the decline of err.
+func respondPaymentDeclined(w http.ResponseWriter, err error) {
+	var declined *service.PaymentDeclinedError
+	errors.As(err, &declined)
+	resp := PaymentDeclinedResponse{
+		Error:     err.Error(),
+		Code:      declined.Code,
+		Retriable: declined.Retriable,
+	}
+	if declined.RetryAfter > 0 {
+		resp.RetryAfterSeconds = int64((declined.RetryAfter + time.Second - 1) / time.Second)
+		w.Header().Set("Retry-After", strconv.FormatInt(resp.RetryAfterSeconds, 10))
+	}
+	respondJSON(w, http.StatusPaymentRequired, resp)
+}
+
 func (h *OrderHandler) createOrder(w http.ResponseWriter, r *http.Request) {
 	var req CreateOrderRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondBodyError(w, err)
 		return
 	}
 
-	log.Printf("[HTTP] POST /orders: customer=%s email=%s items=%d",
-		req.CustomerID, req.CustomerEmail, len(req.Items))
+	// Customers only order for themselves, whatever the body names.
+	if customerID, scoped := customerScope(r); scoped {
+		req.CustomerID = customerID
+	}
+
+	logger.InfoContext(r.Context(), "creating order",
+		"customer_id", req.CustomerID, "items", len(req.Items))
+
+	create := h.svc.CreateOrder
+	async := h.async && (h.asyncByDefault || prefersAsync(r))
+	if async {
+		create = h.svc.SubmitOrder
+	}
+	result, err := create(r.Context(), req.toService())
+
+	if err != nil {
+		logger.WarnContext(r.Context(), "creating order failed", logging.Err(err))
+
+		switch {
+		case service.IsValidationError(err):
+			var verr *service.ValidationError
+			errors.As(err, &verr)
+			respondJSON(w, http.StatusBadRequest, ValidationErrorResponse{
+				Error:  "Invalid order",
+				Fields: verr.Fields,
+			})
+		case service.IsOutOfStock(err):
+			var out *service.OutOfStockError
+			errors.As(err, &out)
+			respondJSON(w, http.StatusConflict, OutOfStockResponse{
+				Error: "Out of stock",
+				Items: out.Items,
+			})
+		case err == service.ErrPaymentServiceUnavailable:
+			respondError(w, http.StatusServiceUnavailable, "Payment service unavailable")
+		case err == service.ErrPaymentTimeout:
+			respondError(w, http.StatusGatewayTimeout, "Payment service timed out")
+		case err == service.ErrInventoryServiceUnavailable:
+			respondError(w, http.StatusServiceUnavailable, "Inventory service unavailable")
+		case err == service.ErrCustomerServiceUnavailable:
+			respondError(w, http.StatusServiceUnavailable, "Customer service unavailable")
+		case err == service.ErrCatalogServiceUnavailable:
+			respondError(w, http.StatusServiceUnavailable, "Catalog service unavailable")
+		case service.IsPaymentDeclined(err):
+			respondPaymentDeclined(w, err)
+		default:
+			respondError(w, http.StatusInternalServerError, "Internal error")
+		}
+		return
+	}
+
+	logger.InfoContext(r.Context(), "order created", "order_id", result.ID, "status", result.Status.String())
+	if async {
+		w.Header().Set("Location", "/orders/"+result.ID)
+		w.Header().Set("Preference-Applied", "respond-async")
+		respondJSON(w, http.StatusAccepted, result)
+		return
+	}
+	// A PENDING order was queued until the payment service recovers.
+	if result.Status == order.OrderStatus_ORDER_STATUS_PENDING {
+		w.Header().Set("Location", "/orders/"+result.ID)
+		respondJSON(w, http.StatusAccepted, result)
+		return
+	}
+	respondJSON(w, http.StatusCreated, result)
+}
 
+func (req CreateOrderRequest) toService() service.CreateOrderRequest {
 	items := make([]order.OrderItem, len(req.Items))
 	for i, item := range req.Items {
 		items[i] = order.OrderItem{
@@ -85,59 +324,209 @@ func (h *OrderHandler) createOrder(w http.ResponseWriter, r *http.Request) {
 			UnitPriceCents: item.UnitPriceCents,
 		}
 	}
+	return service.CreateOrderRequest{
+		CustomerID:      req.CustomerID,
+		CustomerEmail:   req.CustomerEmail,
+		Items:           items,
+		Currency:        req.Currency,
+		CouponCodes:     req.CouponCodes,
+		ShippingAddress: req.ShippingAddress,
+	}
+}
 
-	currency := req.Currency
-	if currency == "" {
-		currency = "BRL"
+// prefersAsync reports whether the request carries Prefer: respond-async.
+func prefersAsync(r *http.Request) bool {
+	for _, v := range r.Header.Values("Prefer") {
+		for _, pref := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(pref), "respond-async") {
+				return true
+			}
+		}
 	}
+	return false
+}
 
-	result, err := h.svc.CreateOrder(r.Context(), service.CreateOrderRequest{
-		CustomerID:    req.CustomerID,
-		CustomerEmail: req.CustomerEmail,
-		Items:         items,
-		Currency:      currency,
-	})
+// ListOrdersResponse is the paginated envelope of GET /orders. Pass
+// NextCursor as ?cursor= to fetch the following page.
+type ListOrdersResponse struct {
+	Orders     []*order.Order `json:"orders"`
+	Count      int            `json:"count"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
 
+func (h *OrderHandler) listOrders(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r.URL.Query())
 	if err != nil {
-		log.Printf("[HTTP] POST /orders error: %v", err)
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-		switch {
-		case err == service.ErrNoItems:
-			respondError(w, http.StatusBadRequest, "At least one item is required")
-		case err == service.ErrMissingEmail:
-			respondError(w, http.StatusBadRequest, "Customer email is required")
-		case err == service.ErrPaymentServiceUnavailable:
-			respondError(w, http.StatusServiceUnavailable, "Payment service unavailable")
-		case service.IsPaymentDeclined(err):
-			respondError(w, http.StatusPaymentRequired, err.Error())
-		default:
-			respondError(w, http.StatusInternalServerError, "Internal error")
+	if customerID, scoped := customerScope(r); scoped {
+		if opts.CustomerID != "" && opts.CustomerID != customerID {
+			respondError(w, http.StatusForbidden, "Cannot list orders of another customer")
+			return
+		}
+		opts.CustomerID = customerID
+	}
+
+	page, err := h.svc.ListOrders(r.Context(), opts)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidListOptions) || errors.Is(err, service.ErrInvalidCursor) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
 		}
+		logger.ErrorContext(r.Context(), "listing orders failed", logging.Err(err))
+		respondError(w, http.StatusInternalServerError, "Failed to list orders")
 		return
 	}
 
-	log.Printf("[HTTP] POST /orders success: order=%s status=%s", result.ID, result.Status)
-	respondJSON(w, http.StatusCreated, result)
+	respondJSON(w, http.StatusOK, ListOrdersResponse{
+		Orders:     page.Orders,
+		Count:      len(page.Orders),
+		NextCursor: page.NextCursor,
+	})
 }
 
-func (h *OrderHandler) listOrders(w http.ResponseWriter, r *http.Request) {
-	log.Printf("[HTTP] GET /orders")
+// searchOrders serves GET /orders/search?q=...&limit=... for support
+// lookups. Customers only find their own orders.
+func (h *OrderHandler) searchOrders(w http.ResponseWriter, r *http.Request) {
+	q, err := service.ParseSearchQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if q.Limit, err = strconv.Atoi(v); err != nil || q.Limit < 1 {
+			respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+	}
+	if customerID, scoped := customerScope(r); scoped {
+		q.CustomerID = customerID
+	}
 
-	orders, err := h.svc.ListOrders(r.Context())
+	orders, err := h.svc.SearchOrders(r.Context(), q)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to list orders")
+		if errors.Is(err, service.ErrInvalidSearchQuery) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		logger.ErrorContext(r.Context(), "searching orders failed", logging.Err(err))
+		respondError(w, http.StatusInternalServerError, "Failed to search orders")
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"orders": orders,
-		"count":  len(orders),
+	respondJSON(w, http.StatusOK, ListOrdersResponse{
+		Orders: orders,
+		Count:  len(orders),
 	})
 }
 
-func (h *OrderHandler) getOrder(w http.ResponseWriter, r *http.Request, orderID string) {
-	log.Printf("[HTTP] GET /orders/%s", orderID)
+// customerScope returns the customer the authenticated caller is limited
+// to. Admins and unauthenticated servers are not scoped.
+func customerScope(r *http.Request) (string, bool) {
+	p, ok := auth.FromContext(r.Context())
+	if !ok || p.HasRole(auth.RoleAdmin) {
+		return "", false
+	}
+	return p.CustomerID, true
+}
+
+// callerOwnsOrder reports whether the caller may change orderID, answering
+// 404 when it belongs to a customer other than the one the caller is
+// limited to, as for an order that does not exist.
+func (h *OrderHandler) callerOwnsOrder(w http.ResponseWriter, r *http.Request, orderID string) bool {
+	customerID, scoped := customerScope(r)
+	if !scoped {
+		return true
+	}
+	o, err := h.svc.GetOrder(r.Context(), orderID)
+	switch {
+	case err != nil && !errors.Is(err, service.ErrOrderNotFound):
+		respondError(w, http.StatusInternalServerError, "Failed to get order")
+		return false
+	case err != nil || o.CustomerID != customerID:
+		respondError(w, http.StatusNotFound, "Order not found")
+		return false
+	}
+	return true
+}
+
+// parseListOptions reads limit, offset, cursor, status, customer_id, from,
+// to, sort (created_at or total) and order (asc or desc).
+func parseListOptions(q url.Values) (service.ListOptions, error) {
+	opts := service.ListOptions{
+		Cursor:     q.Get("cursor"),
+		CustomerID: q.Get("customer_id"),
+		SortBy:     service.SortField(q.Get("sort")),
+	}
+
+	var err error
+	if v := q.Get("limit"); v != "" {
+		if opts.Limit, err = strconv.Atoi(v); err != nil || opts.Limit < 1 {
+			return opts, fmt.Errorf("limit must be a positive integer")
+		}
+	}
+	if v := q.Get("offset"); v != "" {
+		if opts.Offset, err = strconv.Atoi(v); err != nil || opts.Offset < 0 {
+			return opts, fmt.Errorf("offset must be a non-negative integer")
+		}
+	}
+	if v := q.Get("status"); v != "" {
+		status, ok := parseOrderStatus(v)
+		if !ok {
+			return opts, fmt.Errorf("unknown status %q", v)
+		}
+		opts.Status = status
+	}
+	if v := q.Get("from"); v != "" {
+		if opts.CreatedFrom, err = parseTime(v); err != nil {
+			return opts, fmt.Errorf("from: %v", err)
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if opts.CreatedTo, err = parseTime(v); err != nil {
+			return opts, fmt.Errorf("to: %v", err)
+		}
+	}
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		opts.Descending = true
+	default:
+		return opts, fmt.Errorf(`order must be "asc" or "desc"`)
+	}
+
+	return opts, nil
+}
+
+// parseOrderStatus accepts a status name such as "paid" or its number.
+func parseOrderStatus(s string) (order.OrderStatus, bool) {
+	if n, err := strconv.Atoi(s); err == nil {
+		status := order.OrderStatus(n)
+		return status, status.String() != "UNSPECIFIED"
+	}
+	for status := order.OrderStatus_ORDER_STATUS_PENDING; status.String() != "UNSPECIFIED"; status++ {
+		if strings.EqualFold(status.String(), s) {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
+// parseTime accepts RFC 3339 timestamps and plain dates (midnight UTC).
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return t, fmt.Errorf("expected RFC 3339 timestamp or YYYY-MM-DD")
+	}
+	return t, nil
+}
 
+func (h *OrderHandler) getOrder(w http.ResponseWriter, r *http.Request, orderID string) {
 	o, err := h.svc.GetOrder(r.Context(), orderID)
 	if err != nil {
 		if err == service.ErrOrderNotFound {
@@ -147,20 +536,239 @@ func (h *OrderHandler) getOrder(w http.ResponseWriter, r *http.Request, orderID
 		respondError(w, http.StatusInternalServerError, "Failed to get order")
 		return
 	}
+	if customerID, scoped := customerScope(r); scoped && o.CustomerID != customerID {
+		respondError(w, http.StatusNotFound, "Order not found")
+		return
+	}
 
-	respondJSON(w, http.StatusOK, o)
+	respondOrder(w, o)
 }
 
-func (h *OrderHandler) handleHealth(w http.ResponseWriter, r *http.Request) {
-	respondJSON(w, http.StatusOK, map[string]string{
-		"status":  "healthy",
-		"service": "order",
-	})
+type CancelOrderRequest struct {
+	Reason string `json:"reason"`
+}
+
+// cancelOrder cancels unconditionally unless the request has an If-Match
+// header with the ETag of the order. Customers can cancel their own orders
+// only.
+func (h *OrderHandler) cancelOrder(w http.ResponseWriter, r *http.Request, orderID string) {
+	if !h.callerOwnsOrder(w, r, orderID) {
+		return
+	}
+	version, _, err := ifMatchVersion(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var req CancelOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		respondBodyError(w, err)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "cancelling order", "reason", req.Reason)
+
+	o, err := h.svc.CancelOrder(r.Context(), orderID, req.Reason, version)
+	if err != nil {
+		logger.WarnContext(r.Context(), "cancelling order failed", logging.Err(err))
+
+		var conflict *service.VersionConflictError
+		switch {
+		case errors.As(err, &conflict):
+			respondVersionConflict(w, conflict)
+		case errors.Is(err, service.ErrOrderNotFound):
+			respondError(w, http.StatusNotFound, "Order not found")
+		case errors.Is(err, service.ErrOrderNotCancellable), service.IsInvalidTransition(err):
+			respondError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, service.ErrPaymentServiceUnavailable):
+			respondError(w, http.StatusServiceUnavailable, "Payment service unavailable")
+		case errors.Is(err, service.ErrPaymentTimeout):
+			respondError(w, http.StatusGatewayTimeout, "Payment service timed out")
+		default:
+			respondError(w, http.StatusInternalServerError, "Internal error")
+		}
+		return
+	}
+
+	respondOrder(w, o)
+}
+
+type UpdateStatusRequest struct {
+	// Status is "processing", "shipped" or "delivered"
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// updateStatus requires an If-Match header with the ETag of the order, so
+// that a caller cannot move an order it has not seen in its current state,
+// such as shipping an order cancelled in the meantime.
+func (h *OrderHandler) updateStatus(w http.ResponseWriter, r *http.Request, orderID string) {
+	if _, scoped := customerScope(r); scoped {
+		respondError(w, http.StatusForbidden, "Updating the order status requires the admin role")
+		return
+	}
+	version, ok, err := ifMatchVersion(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !ok {
+		respondError(w, http.StatusPreconditionRequired, "If-Match header with the order ETag required")
+		return
+	}
+	var req UpdateStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "updating order status", "status", req.Status)
+
+	status, ok := parseOrderStatus(req.Status)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Unknown status "+strconv.Quote(req.Status))
+		return
+	}
+	switch status {
+	case order.OrderStatus_ORDER_STATUS_CANCELLED:
+		respondError(w, http.StatusBadRequest, "Use POST /orders/{id}/cancel to cancel an order")
+		return
+	case order.OrderStatus_ORDER_STATUS_DISPUTED, order.OrderStatus_ORDER_STATUS_CHARGED_BACK:
+		respondError(w, http.StatusBadRequest, "Use POST /orders/{id}/dispute to open or resolve disputes")
+		return
+	}
+
+	o, err := h.svc.UpdateOrderStatus(r.Context(), orderID, status, req.Reason, version)
+	if err != nil {
+		logger.WarnContext(r.Context(), "updating order status failed", logging.Err(err))
+
+		var conflict *service.VersionConflictError
+		switch {
+		case errors.As(err, &conflict):
+			respondVersionConflict(w, conflict)
+		case errors.Is(err, service.ErrOrderNotFound):
+			respondError(w, http.StatusNotFound, "Order not found")
+		case errors.Is(err, service.ErrStatusNotSettable):
+			respondError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrStatusDerived), service.IsInvalidTransition(err):
+			respondError(w, http.StatusConflict, err.Error())
+		default:
+			respondError(w, http.StatusInternalServerError, "Internal error")
+		}
+		return
+	}
+
+	respondOrder(w, o)
+}
+
+type OpenDisputeRequest struct {
+	Reason string `json:"reason"`
+}
+
+type ResolveDisputeRequest struct {
+	// Outcome is "won" or "lost"
+	Outcome string `json:"outcome"`
+	Note    string `json:"note"`
+}
+
+// openDispute lets customers dispute their own orders only.
+func (h *OrderHandler) openDispute(w http.ResponseWriter, r *http.Request, orderID string) {
+	if !h.callerOwnsOrder(w, r, orderID) {
+		return
+	}
+	var req OpenDisputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		respondBodyError(w, err)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "opening dispute", "reason", req.Reason)
+
+	o, err := h.svc.OpenDispute(r.Context(), orderID, req.Reason)
+	if err != nil {
+		respondDisputeError(w, r, err)
+		return
+	}
+
+	respondOrder(w, o)
+}
+
+func (h *OrderHandler) resolveDispute(w http.ResponseWriter, r *http.Request, orderID string) {
+	if _, scoped := customerScope(r); scoped {
+		respondError(w, http.StatusForbidden, "Resolving disputes requires the admin role")
+		return
+	}
+	var req ResolveDisputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "resolving dispute", "outcome", req.Outcome)
+
+	var won bool
+	switch req.Outcome {
+	case "won":
+		won = true
+	case "lost":
+	default:
+		respondError(w, http.StatusBadRequest, `Outcome must be "won" or "lost"`)
+		return
+	}
+
+	o, err := h.svc.ResolveDispute(r.Context(), orderID, won, req.Note)
+	if err != nil {
+		respondDisputeError(w, r, err)
+		return
+	}
+
+	respondOrder(w, o)
+}
+
+func respondDisputeError(w http.ResponseWriter, r *http.Request, err error) {
+	logger.WarnContext(r.Context(), "dispute request failed", logging.Err(err))
+
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound):
+		respondError(w, http.StatusNotFound, "Order not found")
+	case errors.Is(err, service.ErrOrderNotPaid), errors.Is(err, service.ErrNoOpenDispute), errors.Is(err, service.ErrDisputeRejected),
+		service.IsInvalidTransition(err):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrPaymentServiceUnavailable):
+		respondError(w, http.StatusServiceUnavailable, "Payment service unavailable")
+	case errors.Is(err, service.ErrPaymentTimeout):
+		respondError(w, http.StatusGatewayTimeout, "Payment service timed out")
+	default:
+		respondError(w, http.StatusInternalServerError, "Internal error")
+	}
+}
+
+// WithWorkers adds the broker workers of the service to GET /stats.
+func WithWorkers(workers *broker.WorkerRegistry) Option {
+	return func(h *OrderHandler) {
+		h.workers = workers
+	}
+}
+
+// StatsResponse is the answer of GET /stats. Workers, shared by every
+// tenant, are only listed for callers not limited to a customer.
+type StatsResponse struct {
+	service.OrderStats
+	Workers []broker.WorkerInfo `json:"workers,omitempty"`
 }
 
 func (h *OrderHandler) handleStats(w http.ResponseWriter, r *http.Request) {
-	stats := h.svc.Stats()
-	respondJSON(w, http.StatusOK, stats)
+	stats, err := h.svc.Stats(r.Context())
+	if err != nil {
+		logger.ErrorContext(r.Context(), "computing stats failed", logging.Err(err))
+		respondError(w, http.StatusInternalServerError, "Failed to compute stats")
+		return
+	}
+	resp := StatsResponse{OrderStats: stats}
+	if _, scoped := customerScope(r); !scoped && h.workers != nil {
+		resp.Workers = h.workers.Info()
+	}
+	respondJSON(w, http.StatusOK, resp)
 }
 
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -172,3 +780,63 @@ func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 func respondError(w http.ResponseWriter, status int, message string) {
 	respondJSON(w, status, map[string]string{"error": message})
 }
+
+// respondOrder answers 200 with o and its ETag.
+func respondOrder(w http.ResponseWriter, o *order.Order) {
+	w.Header().Set("ETag", orderETag(o.Version))
+	respondJSON(w, http.StatusOK, o)
+}
+
+// VersionConflictResponse is answered with 409 when an If-Match header
+// names a version the order has left. Version is the current one.
+type VersionConflictResponse struct {
+	Error   string `json:"error"`
+	Version int64  `json:"version"`
+}
+
+func respondVersionConflict(w http.ResponseWriter, err *service.VersionConflictError) {
+	w.Header().Set("ETag", orderETag(err.Actual))
+	respondJSON(w, http.StatusConflict, VersionConflictResponse{
+		Error:   err.Error(),
+		Version: err.Actual,
+	})
+}
+
+// orderETag is the entity tag of an order at version.
+func orderETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ifMatchVersion returns the order version named by the If-Match header of
+// r, an ETag as sent with the order. It reports false when the header is
+// absent and returns 0, which matches any version, for "*".
+func ifMatchVersion(r *http.Request) (int64, bool, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	switch value {
+	case "":
+		return 0, false, nil
+	case "*":
+		return 0, true, nil
+	}
+	unquoted, ok := strings.CutPrefix(value, `"`)
+	if ok {
+		unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if !ok || err != nil || version < 1 {
+		return 0, true, fmt.Errorf("If-Match must be the ETag of the order, such as %s, or *", orderETag(1))
+	}
+	return version, true, nil
+}
+
+// respondBodyError answers 413 when err comes from a body larger than the
+// limit set with http.MaxBytesHandler, and 400 otherwise.
+func respondBodyError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		respondError(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Request body exceeds %d bytes", tooLarge.Limit))
+		return
+	}
+	respondError(w, http.StatusBadRequest, "Invalid request body")
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/metrics"
+)
+
+// HTTPMetrics counts and times requests by method, route and status.
+type HTTPMetrics struct {
+	requests *metrics.CounterVec
+	duration *metrics.HistogramVec
+}
+
+func NewHTTPMetrics(r *metrics.Registry) *HTTPMetrics {
+	return &HTTPMetrics{
+		requests: r.NewCounterVec("http_requests_total",
+			"HTTP requests served, by method, route and status code.",
+			"method", "route", "status"),
+		duration: r.NewHistogramVec("http_request_duration_seconds",
+			"Time spent serving HTTP requests, by method and route.",
+			metrics.DefBuckets, "method", "route"),
+	}
+}
+
+// Middleware records every request served by next. Routes are the patterns
+// of mux, with order IDs replaced by {id}, so unknown paths share the
+// "unmatched" route instead of creating a series each.
+func (m *HTTPMetrics) Middleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		route := routeLabel(pattern, r.URL.Path)
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		m.requests.WithLabelValues(r.Method, route, strconv.Itoa(rec.status)).Inc()
+		m.duration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// orderSubroutes are the paths handleOrderByID serves below an order.
+var orderSubroutes = map[string]bool{
+	"":                true,
+	"cancel":          true,
+	"status":          true,
+	"dispute":         true,
+	"dispute/resolve": true,
+}
+
+func routeLabel(pattern, path string) string {
+	if pattern == "" {
+		return "unmatched"
+	}
+	// Drop the method of patterns such as "GET /orders/events".
+	if _, p, ok := strings.Cut(pattern, " "); ok {
+		pattern = p
+	}
+	if pattern != "/orders/" {
+		return pattern
+	}
+
+	_, sub, _ := strings.Cut(strings.TrimPrefix(path, "/orders/"), "/")
+	if !orderSubroutes[sub] {
+		return "unmatched"
+	}
+	if sub == "" {
+		return "/orders/{id}"
+	}
+	return "/orders/{id}/" + sub
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach Flush on the underlying
+// writer, which GET /orders/events needs.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
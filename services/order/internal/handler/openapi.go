@@ -0,0 +1,36 @@
+package handler
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec describes the HTTP API of the service. It is maintained by
+// hand: update it together with the routes and the request and response
+// types of this package.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// docsPage renders openAPISpec with Swagger UI, loaded from unpkg.
+//
+//go:embed docs.html
+var docsPage []byte
+
+// handleOpenAPI serves GET /openapi.json.
+func (h *OrderHandler) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+// docsPolicy lets the docs page run its inline script, load Swagger UI from
+// unpkg and fetch the spec.
+const docsPolicy = "default-src 'none'; script-src 'self' 'unsafe-inline' https://unpkg.com; " +
+	"style-src 'self' 'unsafe-inline' https://unpkg.com; img-src 'self' data:; connect-src 'self'; frame-ancestors 'none'"
+
+// handleDocs serves GET /docs.
+func (h *OrderHandler) handleDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", docsPolicy)
+	w.Write(docsPage)
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/order/internal/service"
+)
+
+// PendingOrderRequest is the body of POST /orders/pending: an order and
+// the ID it is stored under.
+type PendingOrderRequest struct {
+	OrderID string `json:"order_id"`
+	CreateOrderRequest
+}
+
+// RecordPaymentRequest is the body of POST /orders/{id}/payment.
+type RecordPaymentRequest struct {
+	TransactionID string `json:"transaction_id"`
+}
+
+// createPendingOrder serves POST /orders/pending for the saga orchestrator:
+// the order is stored PENDING, neither reserved nor charged, and answered
+// with 201. Sending an order ID that exists answers 201 with that order, so
+// the orchestrator may repeat the call.
+func (h *OrderHandler) createPendingOrder(w http.ResponseWriter, r *http.Request) {
+	if _, scoped := customerScope(r); scoped {
+		respondError(w, http.StatusForbidden, "Pending orders require the admin role")
+		return
+	}
+
+	var req PendingOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+	if req.OrderID == "" {
+		respondError(w, http.StatusBadRequest, "order_id is required")
+		return
+	}
+
+	svcReq := req.toService()
+	svcReq.ID = req.OrderID
+	o, err := h.svc.CreatePendingOrder(r.Context(), svcReq)
+	if err != nil {
+		logger.WarnContext(r.Context(), "creating pending order failed", logging.Err(err))
+
+		switch {
+		case service.IsValidationError(err):
+			var verr *service.ValidationError
+			errors.As(err, &verr)
+			respondJSON(w, http.StatusBadRequest, ValidationErrorResponse{
+				Error:  "Invalid order",
+				Fields: verr.Fields,
+			})
+		case errors.Is(err, service.ErrCustomerServiceUnavailable):
+			respondError(w, http.StatusServiceUnavailable, "Customer service unavailable")
+		case errors.Is(err, service.ErrCatalogServiceUnavailable):
+			respondError(w, http.StatusServiceUnavailable, "Catalog service unavailable")
+		default:
+			respondError(w, http.StatusInternalServerError, "Internal error")
+		}
+		return
+	}
+
+	logger.InfoContext(r.Context(), "pending order created", "order_id", o.ID)
+	w.Header().Set("Location", "/orders/"+o.ID)
+	respondJSON(w, http.StatusCreated, o)
+}
+
+// recordPayment serves POST /orders/{id}/payment, which marks a pending
+// order PAID with a transaction charged by the saga orchestrator.
+func (h *OrderHandler) recordPayment(w http.ResponseWriter, r *http.Request) {
+	if _, scoped := customerScope(r); scoped {
+		respondError(w, http.StatusForbidden, "Recording payments requires the admin role")
+		return
+	}
+
+	var req RecordPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+	if req.TransactionID == "" {
+		respondError(w, http.StatusBadRequest, "transaction_id is required")
+		return
+	}
+
+	o, err := h.svc.RecordPayment(r.Context(), r.PathValue("id"), req.TransactionID)
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound):
+		respondError(w, http.StatusNotFound, "Order not found")
+	case service.IsInvalidTransition(err):
+		respondError(w, http.StatusConflict, err.Error())
+	case err != nil:
+		respondError(w, http.StatusInternalServerError, "Internal error")
+	default:
+		respondJSON(w, http.StatusOK, o)
+	}
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/order/internal/service"
+)
+
+type CreateReturnRequest struct {
+	// Items name order items by index; a quantity of 0 returns every unit
+	Items  []order.ReturnItem `json:"items"`
+	Reason string             `json:"reason"`
+}
+
+type UpdateReturnRequest struct {
+	// Status is "approved", "rejected", "received" or "refunded"
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+// createReturn serves POST /orders/{id}/returns. Customers can return
+// items of their own orders only.
+func (h *OrderHandler) createReturn(w http.ResponseWriter, r *http.Request) {
+	var req CreateReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	customerID, _ := customerScope(r)
+	o, err := h.svc.RequestReturn(r.Context(), r.PathValue("id"), customerID, req.Items, req.Reason)
+	if err != nil {
+		logger.WarnContext(r.Context(), "requesting return failed", logging.Err(err))
+		respondReturnError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", orderETag(o.Version))
+	respondJSON(w, http.StatusCreated, o)
+}
+
+// updateReturn serves PATCH /orders/{id}/returns/{returnID}. Moving a
+// return to refunded pays its refund back.
+func (h *OrderHandler) updateReturn(w http.ResponseWriter, r *http.Request) {
+	if _, scoped := customerScope(r); scoped {
+		respondError(w, http.StatusForbidden, "Managing returns requires the admin role")
+		return
+	}
+	version, _, err := ifMatchVersion(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var req UpdateReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+	status, ok := parseReturnStatus(req.Status)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Unknown return status "+strconv.Quote(req.Status))
+		return
+	}
+
+	o, err := h.svc.UpdateReturn(r.Context(), r.PathValue("id"), r.PathValue("returnID"), status, req.Note, version)
+	if err != nil {
+		logger.WarnContext(r.Context(), "updating return failed", logging.Err(err))
+		respondReturnError(w, err)
+		return
+	}
+
+	respondOrder(w, o)
+}
+
+// parseReturnStatus accepts the statuses a return can be moved to, such as
+// "approved" or "RETURN_STATUS_APPROVED".
+func parseReturnStatus(s string) (order.ReturnStatus, bool) {
+	name := strings.TrimPrefix(strings.ToUpper(s), "RETURN_STATUS_")
+	for _, status := range []order.ReturnStatus{
+		order.ReturnStatus_RETURN_STATUS_APPROVED,
+		order.ReturnStatus_RETURN_STATUS_REJECTED,
+		order.ReturnStatus_RETURN_STATUS_RECEIVED,
+		order.ReturnStatus_RETURN_STATUS_REFUNDED,
+	} {
+		if status.String() == name {
+			return status, true
+		}
+	}
+	return order.ReturnStatus_RETURN_STATUS_UNSPECIFIED, false
+}
+
+func respondReturnError(w http.ResponseWriter, err error) {
+	var (
+		conflict *service.VersionConflictError
+		verr     *service.ValidationError
+	)
+	switch {
+	case errors.As(err, &conflict):
+		respondVersionConflict(w, conflict)
+	case errors.As(err, &verr):
+		respondJSON(w, http.StatusBadRequest, ValidationErrorResponse{
+			Error:  "Invalid return",
+			Fields: verr.Fields,
+		})
+	case errors.Is(err, service.ErrOrderNotFound):
+		respondError(w, http.StatusNotFound, "Order not found")
+	case errors.Is(err, service.ErrReturnNotFound):
+		respondError(w, http.StatusNotFound, "Return not found")
+	case errors.Is(err, service.ErrInvalidReturnTransition), errors.Is(err, service.ErrRefundRejected):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrPaymentServiceUnavailable):
+		respondError(w, http.StatusServiceUnavailable, "Payment service unavailable")
+	case errors.Is(err, service.ErrPaymentTimeout):
+		respondError(w, http.StatusGatewayTimeout, "Payment service timed out")
+	default:
+		respondError(w, http.StatusInternalServerError, "Internal error")
+	}
+}
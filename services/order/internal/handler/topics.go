@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+)
+
+// TopicOffsets describes the retained messages of a topic and where its
+// subscribers resume, as returned by GET /admin/topics/{topic}.
+type TopicOffsets struct {
+	Topic  string           `json:"topic"`
+	First  int64            `json:"first_offset"`
+	Next   int64            `json:"next_offset"`
+	Queues map[string]int64 `json:"queues"`
+}
+
+// SeekRequest is the body of POST /admin/topics/{topic}/seek: the offset or
+// the publish time to replay the topic to queue from.
+type SeekRequest struct {
+	Queue  string     `json:"queue"`
+	Offset *int64     `json:"offset,omitempty"`
+	Time   *time.Time `json:"time,omitempty"`
+}
+
+// getTopology serves GET /admin/topology, the topics, queues and
+// subscriptions of the broker in the format of -broker-topology files.
+func (h *OrderHandler) getTopology(w http.ResponseWriter, r *http.Request) {
+	if _, scoped := customerScope(r); scoped {
+		respondError(w, http.StatusForbidden, "Topic management requires the admin role")
+		return
+	}
+	respondJSON(w, http.StatusOK, h.broker.ExportTopology())
+}
+
+// retainedTopic resolves the {topic} path value, answering 403 or 404 when
+// it cannot.
+func (h *OrderHandler) retainedTopic(w http.ResponseWriter, r *http.Request) (*broker.Topic, bool) {
+	if _, scoped := customerScope(r); scoped {
+		respondError(w, http.StatusForbidden, "Topic management requires the admin role")
+		return nil, false
+	}
+
+	name := r.PathValue("topic")
+	topic, ok := h.broker.GetTopic(name)
+	if !ok {
+		respondError(w, http.StatusNotFound, "No topic "+name)
+		return nil, false
+	}
+	return topic, true
+}
+
+// getTopicOffsets serves GET /admin/topics/{topic}.
+func (h *OrderHandler) getTopicOffsets(w http.ResponseWriter, r *http.Request) {
+	topic, ok := h.retainedTopic(w, r)
+	if !ok {
+		return
+	}
+
+	offsets := topic.Offsets()
+	respondJSON(w, http.StatusOK, TopicOffsets{
+		Topic:  topic.Name(),
+		First:  offsets.First,
+		Next:   offsets.Next,
+		Queues: offsets.Queues,
+	})
+}
+
+// seekTopic serves POST /admin/topics/{topic}/seek.
+func (h *OrderHandler) seekTopic(w http.ResponseWriter, r *http.Request) {
+	topic, ok := h.retainedTopic(w, r)
+	if !ok {
+		return
+	}
+
+	var req SeekRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Queue == "" || (req.Offset == nil) == (req.Time == nil) {
+		respondError(w, http.StatusBadRequest, "queue and one of offset or time are required")
+		return
+	}
+
+	var n int
+	var err error
+	if req.Offset != nil {
+		n, err = topic.Seek(r.Context(), req.Queue, *req.Offset)
+	} else {
+		n, err = topic.SeekTime(r.Context(), req.Queue, *req.Time)
+	}
+	switch {
+	case errors.Is(err, broker.ErrQueueNotFound):
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, broker.ErrNoRetention), errors.Is(err, broker.ErrOffsetOutOfRange):
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		logger.ErrorContext(r.Context(), "seeking topic failed", "topic", topic.Name(), "queue", req.Queue, logging.Err(err))
+		respondError(w, http.StatusInternalServerError, "Failed to seek topic")
+		return
+	}
+
+	offsets := topic.Offsets()
+	respondJSON(w, http.StatusOK, map[string]any{
+		"topic":    topic.Name(),
+		"queue":    req.Queue,
+		"offset":   offsets.Queues[req.Queue],
+		"replayed": n,
+	})
+}
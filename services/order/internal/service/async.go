@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+)
+
+// RequestsTopic receives an OrderRequestedEvent for every order accepted by
+// SubmitOrder. Workers running HandleRequested charge those orders.
+const RequestsTopic = "order.requested"
+
+// SubmitOrder validates, prices and stores the order like CreateOrder but
+// returns it PENDING and leaves the payment to a worker consuming
+// RequestsTopic. The outcome is a status change to PAID or CANCELLED.
+func (s *OrderService) SubmitOrder(ctx context.Context, req CreateOrderRequest) (*order.Order, error) {
+	pending, err := s.prepareOrder(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+	ctx = logging.WithAttrs(ctx, "order_id", pending.ID)
+
+	msg, err := broker.NewMessage("order.requested", order.NewOrderRequestedEvent(pending.ID))
+	if err == nil {
+		msg.SetMetadata("order_id", pending.ID)
+		err = s.broker.Publish(ctx, RequestsTopic, msg)
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "queueing order failed", logging.Err(err))
+		s.updateOrderStatus(ctx, pending.ID, order.OrderStatus_ORDER_STATUS_CANCELLED, "order could not be queued")
+		s.releaseReservation(ctx, pending, "order could not be queued")
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "order queued for payment")
+	return pending, nil
+}
+
+// HandleRequested is the broker handler for RequestsTopic. It charges the
+// order if it is still PENDING, so a redelivered message for an order that
+// was already paid or cancelled is ignored. A declined or failed payment is
+// recorded on the order and does not fail the message; under the queue
+// degradation policy a failed payment defers the order instead.
+func (s *OrderService) HandleRequested(msg *broker.Message) error {
+	var event order.OrderRequestedEvent
+	if err := msg.Decode(&event); err != nil {
+		return err
+	}
+	ctx := logging.WithAttrs(msg.Context(context.Background()), "order_id", event.OrderID)
+
+	o, err := s.repo.Get(ctx, event.OrderID)
+	if errors.Is(err, ErrOrderNotFound) {
+		logger.WarnContext(ctx, "queued order no longer exists")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if o.Status != order.OrderStatus_ORDER_STATUS_PENDING {
+		logger.InfoContext(ctx, "queued order already processed", "status", o.Status.String())
+		return nil
+	}
+
+	if _, err := s.chargeOrder(ctx, o, false); err != nil {
+		logger.InfoContext(ctx, "queued order not paid", logging.Err(err))
+	}
+	return nil
+}
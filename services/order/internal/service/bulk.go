@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+	"github.com/google/uuid"
+)
+
+// ErrImportNotFound is returned for an unknown or expired import job ID.
+var ErrImportNotFound = errors.New("import job not found")
+
+// ImportStatus is the state of an import job.
+type ImportStatus string
+
+const (
+	ImportRunning   ImportStatus = "running"
+	ImportCompleted ImportStatus = "completed"
+)
+
+// Outcomes of one imported order.
+const (
+	ImportItemPending  = "pending"
+	ImportItemCreated  = "created"
+	ImportItemDeclined = "declined"
+	ImportItemError    = "error"
+)
+
+// ImportResult is the outcome of the order at Index of the import.
+type ImportResult struct {
+	Index   int    `json:"index"`
+	Status  string `json:"status"`
+	OrderID string `json:"order_id,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ImportJob reports the progress of a bulk import. Results has one entry
+// per submitted order, in submission order.
+type ImportJob struct {
+	ID          string         `json:"id"`
+	TenantID    string         `json:"tenant_id"`
+	Status      ImportStatus   `json:"status"`
+	Total       int            `json:"total"`
+	Created     int            `json:"created"`
+	Declined    int            `json:"declined"`
+	Failed      int            `json:"failed"`
+	StartedAt   time.Time      `json:"started_at"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty"`
+	Results     []ImportResult `json:"results"`
+}
+
+// ImporterConfig bounds the work of an Importer.
+type ImporterConfig struct {
+	Workers   int `config:"bulk-workers" usage:"Orders of a bulk import created concurrently"`
+	MaxOrders int `config:"bulk-max-orders" usage:"Largest number of orders accepted by one bulk import"`
+
+	// KeepJobs is how many finished jobs stay queryable; older ones are
+	// dropped.
+	KeepJobs int `config:"bulk-keep-jobs" usage:"Finished bulk import jobs kept for GET /orders/bulk/{id}"`
+}
+
+func DefaultImporterConfig() ImporterConfig {
+	return ImporterConfig{
+		Workers:   8,
+		MaxOrders: 1000,
+		KeepJobs:  100,
+	}
+}
+
+// Importer creates orders in bulk through CreateOrder, in the background,
+// and keeps the jobs in memory.
+type Importer struct {
+	svc    *OrderService
+	config ImporterConfig
+
+	mu       sync.Mutex
+	jobs     map[string]*ImportJob
+	finished []string
+	running  sync.WaitGroup
+}
+
+func (c ImporterConfig) Validate() error {
+	if c.Workers < 1 || c.MaxOrders < 1 || c.KeepJobs < 1 {
+		return errors.New("bulk-workers, bulk-max-orders and bulk-keep-jobs must be at least 1")
+	}
+	return nil
+}
+
+func NewImporter(svc *OrderService, config ImporterConfig) *Importer {
+	return &Importer{
+		svc:    svc,
+		config: config,
+		jobs:   make(map[string]*ImportJob),
+	}
+}
+
+// MaxOrders is the largest import Start accepts.
+func (im *Importer) MaxOrders() int {
+	return im.config.MaxOrders
+}
+
+// Start registers a job for reqs and creates the orders in the background.
+// The job keeps the request attributes of ctx, such as the request ID, but
+// not its cancellation.
+func (im *Importer) Start(ctx context.Context, reqs []CreateOrderRequest) ImportJob {
+	job := &ImportJob{
+		ID:        "imp_" + uuid.New().String()[:8],
+		TenantID:  tenant.FromContext(ctx),
+		Status:    ImportRunning,
+		Total:     len(reqs),
+		StartedAt: time.Now(),
+		Results:   make([]ImportResult, len(reqs)),
+	}
+	for i := range job.Results {
+		job.Results[i] = ImportResult{Index: i, Status: ImportItemPending}
+	}
+
+	im.mu.Lock()
+	im.jobs[job.ID] = job
+	snapshot := cloneJob(job)
+	im.mu.Unlock()
+
+	ctx = logging.WithAttrs(context.WithoutCancel(ctx), "import_id", job.ID)
+	logger.InfoContext(ctx, "bulk import started", "orders", len(reqs))
+
+	im.running.Add(1)
+	go im.run(ctx, job, reqs)
+	return snapshot
+}
+
+func (im *Importer) run(ctx context.Context, job *ImportJob, reqs []CreateOrderRequest) {
+	defer im.running.Done()
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range im.config.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				o, err := im.svc.CreateOrder(ctx, reqs[i])
+				orderID := ""
+				if o != nil {
+					orderID = o.ID
+				}
+				im.record(job, i, orderID, err)
+			}
+		}()
+	}
+	for i := range reqs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	im.mu.Lock()
+	now := time.Now()
+	job.Status = ImportCompleted
+	job.CompletedAt = &now
+	im.finished = append(im.finished, job.ID)
+	for len(im.finished) > im.config.KeepJobs {
+		delete(im.jobs, im.finished[0])
+		im.finished = im.finished[1:]
+	}
+	im.mu.Unlock()
+
+	logger.InfoContext(ctx, "bulk import completed",
+		"created", job.Created, "declined", job.Declined, "failed", job.Failed)
+}
+
+func (im *Importer) record(job *ImportJob, i int, orderID string, err error) {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	result := &job.Results[i]
+	switch {
+	case err == nil:
+		result.Status = ImportItemCreated
+		result.OrderID = orderID
+		job.Created++
+	case IsPaymentDeclined(err):
+		result.Status = ImportItemDeclined
+		result.Error = err.Error()
+		job.Declined++
+	default:
+		result.Status = ImportItemError
+		result.Error = err.Error()
+		job.Failed++
+	}
+}
+
+// Job returns a snapshot of the job with the given ID. Jobs of other
+// tenants than the one of ctx are not found.
+func (im *Importer) Job(ctx context.Context, id string) (ImportJob, error) {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	job, ok := im.jobs[id]
+	if !ok || job.TenantID != tenant.FromContext(ctx) {
+		return ImportJob{}, ErrImportNotFound
+	}
+	return cloneJob(job), nil
+}
+
+// Shutdown waits for the running jobs to finish, or until ctx is done.
+func (im *Importer) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		im.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func cloneJob(job *ImportJob) ImportJob {
+	c := *job
+	c.Results = append([]ImportResult(nil), job.Results...)
+	return c
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/cache"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/metrics"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+	"github.com/google/uuid"
+)
+
+// CacheConfig configures the read-through cache in front of the order
+// store.
+type CacheConfig struct {
+	Backend string            `config:"cache" usage:"Order cache: none, memory or redis"`
+	TTL     time.Duration     `config:"cache-ttl" usage:"Time a cached order is served without reading the store"`
+	ListTTL time.Duration     `config:"cache-list-ttl" usage:"Time a cached order list or count is served without reading the store"`
+	Size    int               `config:"cache-size" usage:"Orders, lists and counts kept by the memory cache"`
+	Redis   cache.RedisConfig `config:"cache-redis"`
+}
+
+func DefaultCacheConfig() CacheConfig {
+	return CacheConfig{
+		Backend: "none",
+		TTL:     time.Minute,
+		ListTTL: 5 * time.Second,
+		Size:    10000,
+		Redis:   cache.DefaultRedisConfig(),
+	}
+}
+
+func (c CacheConfig) Validate() error {
+	switch c.Backend {
+	case "none":
+		return nil
+	case "memory":
+		if c.Size < 1 {
+			return errors.New("cache-size must be at least 1")
+		}
+	case "redis":
+		if c.Redis.Addr == "" {
+			return errors.New("the redis cache needs cache-redis-addr")
+		}
+	default:
+		return fmt.Errorf("unknown cache %q, expected none, memory or redis", c.Backend)
+	}
+	if c.TTL <= 0 || c.ListTTL <= 0 {
+		return errors.New("cache-ttl and cache-list-ttl must be positive")
+	}
+	return nil
+}
+
+// CacheMetrics count the lookups of a CachedOrderRepository. A nil
+// *CacheMetrics records nothing.
+type CacheMetrics struct {
+	lookups *metrics.CounterVec
+	errors  *metrics.Counter
+}
+
+// NewCacheMetrics registers the order cache metrics on r.
+func NewCacheMetrics(r *metrics.Registry) *CacheMetrics {
+	return &CacheMetrics{
+		lookups: r.NewCounterVec("order_cache_lookups_total",
+			"Order cache lookups, by kind (order, list, count) and result (hit, miss).",
+			"kind", "result"),
+		errors: r.NewCounter("order_cache_errors_total",
+			"Order cache operations that failed and fell back to the store."),
+	}
+}
+
+func (m *CacheMetrics) lookup(kind string, hit bool) {
+	if m == nil {
+		return
+	}
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.lookups.WithLabelValues(kind, result).Inc()
+}
+
+func (m *CacheMetrics) failed() {
+	if m == nil {
+		return
+	}
+	m.errors.Inc()
+}
+
+var cacheLogger = logging.Component("order-cache")
+
+// CachedOrderRepository serves Get, List and Count of an OrderRepository
+// from a cache, reading the store on misses. Writes go to the store first:
+// UpdateStatus then caches the updated order, EraseCustomer drops the
+// orders it erased, and all writes replace the generation of the tenant, a
+// token part of every list and count key, so the lists cached before the
+// write are never served again.
+// A cache that fails is bypassed, never failing the call.
+//
+// Replicas sharing a Redis cache see each other's writes at once; with a
+// memory cache a replica serves what it cached for up to the TTLs.
+type CachedOrderRepository struct {
+	OrderRepository
+	cache   cache.Cache
+	ttl     time.Duration
+	listTTL time.Duration
+	metrics *CacheMetrics
+}
+
+func NewCachedOrderRepository(repo OrderRepository, c cache.Cache, ttl, listTTL time.Duration, m *CacheMetrics) *CachedOrderRepository {
+	return &CachedOrderRepository{OrderRepository: repo, cache: c, ttl: ttl, listTTL: listTTL, metrics: m}
+}
+
+func (r *CachedOrderRepository) Create(ctx context.Context, o *order.Order) error {
+	if err := r.OrderRepository.Create(ctx, o); err != nil {
+		return err
+	}
+	r.bumpGeneration(tenant.NewContext(ctx, o.TenantID))
+	return nil
+}
+
+func (r *CachedOrderRepository) Get(ctx context.Context, orderID string) (*order.Order, error) {
+	key := r.orderKey(ctx, orderID)
+	var cached order.Order
+	if r.load(ctx, "order", key, &cached) {
+		return &cached, nil
+	}
+	o, err := r.OrderRepository.Get(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	r.store(ctx, key, o, r.ttl)
+	return o, nil
+}
+
+// cachedPage is an OrderPage as cached.
+type cachedPage struct {
+	Orders     []*order.Order `json:"orders"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+func (r *CachedOrderRepository) List(ctx context.Context, opts ListOptions) (OrderPage, error) {
+	key, ok := r.queryKey(ctx, "list", opts)
+	var cached cachedPage
+	if ok && r.load(ctx, "list", key, &cached) {
+		return OrderPage(cached), nil
+	}
+	page, err := r.OrderRepository.List(ctx, opts)
+	if err != nil {
+		return OrderPage{}, err
+	}
+	if ok {
+		r.store(ctx, key, cachedPage(page), r.listTTL)
+	}
+	return page, nil
+}
+
+func (r *CachedOrderRepository) Count(ctx context.Context) (int, error) {
+	key, ok := r.queryKey(ctx, "count", nil)
+	var cached int
+	if ok && r.load(ctx, "count", key, &cached) {
+		return cached, nil
+	}
+	n, err := r.OrderRepository.Count(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		r.store(ctx, key, n, r.listTTL)
+	}
+	return n, nil
+}
+
+func (r *CachedOrderRepository) UpdateStatus(ctx context.Context, orderID string, update StatusUpdate) (*order.Order, error) {
+	o, err := r.OrderRepository.UpdateStatus(ctx, orderID, update)
+	if err != nil {
+		// The cached order may be what made the transition look valid
+		// or invalid: let the next read see the store.
+		r.delete(ctx, r.orderKey(ctx, orderID))
+		return nil, err
+	}
+	r.store(ctx, r.orderKey(ctx, orderID), o, r.ttl)
+	r.bumpGeneration(ctx)
+	return o, nil
+}
+
+func (r *CachedOrderRepository) EraseCustomer(ctx context.Context, customerID string, erase func(*order.Order) bool) ([]string, error) {
+	ids, err := r.OrderRepository.EraseCustomer(ctx, customerID, erase)
+	// Even a partial erasure must not leave cached copies behind.
+	for _, id := range ids {
+		r.delete(ctx, r.orderKey(ctx, id))
+	}
+	if len(ids) > 0 {
+		r.bumpGeneration(ctx)
+	}
+	return ids, err
+}
+
+func (r *CachedOrderRepository) orderKey(ctx context.Context, orderID string) string {
+	return "order:" + tenant.FromContext(ctx) + ":" + orderID
+}
+
+func generationKey(ctx context.Context) string {
+	return "order-gen:" + tenant.FromContext(ctx)
+}
+
+// queryKey returns the key of a list or count query of the current
+// generation of the tenant, and false when the cache cannot tell it.
+func (r *CachedOrderRepository) queryKey(ctx context.Context, kind string, query any) (string, bool) {
+	gen, found, err := r.cache.Get(ctx, generationKey(ctx))
+	if err != nil {
+		r.cacheFailed(ctx, err)
+		return "", false
+	}
+	if !found {
+		if gen, err = r.bumpGeneration(ctx); err != nil {
+			return "", false
+		}
+	}
+	q, err := json.Marshal(query)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(q)
+	return fmt.Sprintf("order-%s:%s:%s:%s", kind, tenant.FromContext(ctx), gen, hex.EncodeToString(sum[:12])), true
+}
+
+// bumpGeneration gives the tenant a new generation, orphaning its cached
+// lists and counts.
+func (r *CachedOrderRepository) bumpGeneration(ctx context.Context) ([]byte, error) {
+	gen := []byte(uuid.NewString())
+	if err := r.cache.Set(ctx, generationKey(ctx), gen, 0); err != nil {
+		r.cacheFailed(ctx, err)
+		// Cached lists could now be served stale: drop the generation
+		// so they are not.
+		r.delete(ctx, generationKey(ctx))
+		return nil, err
+	}
+	return gen, nil
+}
+
+// load decodes the value of key into v and reports whether it was there.
+func (r *CachedOrderRepository) load(ctx context.Context, kind, key string, v any) bool {
+	data, found, err := r.cache.Get(ctx, key)
+	if err != nil {
+		r.cacheFailed(ctx, err)
+		return false
+	}
+	if found {
+		if err := json.Unmarshal(data, v); err != nil {
+			r.cacheFailed(ctx, fmt.Errorf("decode %s: %w", key, err))
+			found = false
+		}
+	}
+	r.metrics.lookup(kind, found)
+	return found
+}
+
+func (r *CachedOrderRepository) store(ctx context.Context, key string, v any, ttl time.Duration) {
+	data, err := json.Marshal(v)
+	if err == nil {
+		err = r.cache.Set(ctx, key, data, ttl)
+	}
+	if err != nil {
+		r.cacheFailed(ctx, err)
+	}
+}
+
+func (r *CachedOrderRepository) delete(ctx context.Context, key string) {
+	if err := r.cache.Delete(ctx, key); err != nil {
+		r.cacheFailed(ctx, err)
+	}
+}
+
+func (r *CachedOrderRepository) cacheFailed(ctx context.Context, err error) {
+	r.metrics.failed()
+	cacheLogger.WarnContext(ctx, "order cache unavailable, using the store", logging.Err(err))
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/events"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+)
+
+// CancellationsTopic receives an events.OrderCancelledV1 for every order
+// cancelled through CancelOrder.
+const CancellationsTopic = "order.cancelled"
+
+// cancellable lists the statuses CancelOrder accepts. Orders that left the
+// warehouse, are disputed or already closed cannot be cancelled.
+var cancellable = map[order.OrderStatus]bool{
+	order.OrderStatus_ORDER_STATUS_PENDING:    true,
+	order.OrderStatus_ORDER_STATUS_PAID:       true,
+	order.OrderStatus_ORDER_STATUS_PROCESSING: true,
+}
+
+// CancelOrder compensates the payment of an order, marks it CANCELLED and
+// releases its stock reservation. Paid orders are refunded; an order without
+// a captured payment has its transaction, if any, voided.
+//
+// A non-zero version is the version the caller last read; an order changed
+// since returns a VersionConflictError. It is checked before the payment is
+// compensated, so a caller acting on a stale order triggers no refund.
+func (s *OrderService) CancelOrder(ctx context.Context, orderID, reason string, version int64) (*order.Order, error) {
+	if reason == "" {
+		reason = "cancelled by customer"
+	}
+
+	o, err := s.repo.Get(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && o.Version != version {
+		return nil, &VersionConflictError{Expected: version, Actual: o.Version}
+	}
+	if !cancellable[o.Status] {
+		return nil, fmt.Errorf("%w: order is %s", ErrOrderNotCancellable, o.Status)
+	}
+	if f, ok := shippedFulfillment(o); ok {
+		return nil, fmt.Errorf("%w: fulfillment %s is %s", ErrOrderNotCancellable, f.ID, f.Status)
+	}
+
+	if o.PaymentTransactionID != "" {
+		if err := s.compensatePayment(ctx, o, reason); err != nil {
+			return nil, err
+		}
+	}
+
+	cancelled, err := s.transition(ctx, orderID, StatusUpdate{Status: order.OrderStatus_ORDER_STATUS_CANCELLED, Reason: reason})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "order cancelled", "reason", reason)
+	s.releaseReservation(ctx, cancelled, reason)
+	s.publishes.Add(1)
+	go s.publishOrderCancelled(ctx, cancelled.ID, reason)
+
+	return cancelled, nil
+}
+
+// compensatePayment refunds a paid order or voids a pending payment. A
+// payment that is already in the target status counts as compensated, so a
+// cancellation that failed after the payment call can be retried.
+func (s *OrderService) compensatePayment(ctx context.Context, o *order.Order, reason string) error {
+	var (
+		err    error
+		target payment.PaymentStatus
+	)
+	if o.Status == order.OrderStatus_ORDER_STATUS_PENDING {
+		target = payment.PaymentStatus_PAYMENT_STATUS_CANCELLED
+		err = s.callPayment(ctx, "CancelPayment", true, func(ctx context.Context) error {
+			_, err := s.payments.CancelPayment(ctx, &payment.CancelPaymentRequest{
+				TransactionID: o.PaymentTransactionID,
+				Reason:        reason,
+			})
+			return err
+		})
+	} else {
+		target = payment.PaymentStatus_PAYMENT_STATUS_REFUNDED
+		err = s.callPayment(ctx, "RefundPayment", true, func(ctx context.Context) error {
+			_, err := s.payments.RefundPayment(ctx, &payment.RefundPaymentRequest{
+				TransactionID: o.PaymentTransactionID,
+				Reason:        reason,
+			})
+			return err
+		})
+	}
+	if err == nil {
+		return nil
+	}
+
+	// INVALID_TRANSITION carries the current payment status in "from".
+	if info, ok := grpcmw.Reason(err); ok && info.Reason == "INVALID_TRANSITION" && info.Metadata["from"] == target.String() {
+		logger.InfoContext(ctx, "payment already compensated", "transaction_id", o.PaymentTransactionID, "payment_status", target.String())
+		return nil
+	}
+
+	logger.WarnContext(ctx, "compensating payment failed", "transaction_id", o.PaymentTransactionID, logging.Err(err))
+	if msg, ok := paymentRejection(err); ok {
+		return fmt.Errorf("%w: %s", ErrOrderNotCancellable, msg)
+	}
+	return paymentFailure(err)
+}
+
+func (s *OrderService) publishOrderCancelled(ctx context.Context, orderID, reason string) {
+	defer s.publishes.Done()
+
+	event := events.NewOrderCancelledV1(orderID, reason)
+
+	msg, err := events.NewMessage(&event)
+	if err != nil {
+		return
+	}
+
+	msg.SetMetadata("order_id", orderID)
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	s.broker.Publish(ctx, CancellationsTopic, msg)
+}
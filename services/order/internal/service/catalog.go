@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/money"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/retry"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/catalog"
+)
+
+// What to do with a unit price that differs from the catalog price by more
+// than the tolerance.
+const (
+	// PriceMismatchReject fails the order with a validation error.
+	PriceMismatchReject = "reject"
+	// PriceMismatchCorrect charges the catalog price instead.
+	PriceMismatchCorrect = "correct"
+)
+
+// PriceCheckConfig controls how the unit prices of new orders are
+// reconciled with the catalog.
+type PriceCheckConfig struct {
+	// Tolerance is the difference from the catalog price, in percent of
+	// it, up to which a submitted price is kept as is.
+	Tolerance float64 `config:"price-tolerance" usage:"Difference from the catalog price, in percent, up to which a submitted unit price is accepted"`
+	Mismatch  string  `config:"price-mismatch" usage:"What to do with a unit price beyond the tolerance: reject or correct"`
+}
+
+// DefaultPriceCheckConfig rejects any price other than the catalog's.
+func DefaultPriceCheckConfig() PriceCheckConfig {
+	return PriceCheckConfig{Mismatch: PriceMismatchReject}
+}
+
+func (c PriceCheckConfig) Validate() error {
+	if c.Tolerance < 0 || c.Tolerance >= 100 {
+		return errors.New("price-tolerance must be at least 0 and below 100")
+	}
+	switch c.Mismatch {
+	case PriceMismatchReject, PriceMismatchCorrect:
+		return nil
+	default:
+		return fmt.Errorf("price-mismatch must be %s or %s, got %q", PriceMismatchReject, PriceMismatchCorrect, c.Mismatch)
+	}
+}
+
+// accepts reports whether submitted is within the tolerance of the
+// catalog price.
+func (c PriceCheckConfig) accepts(submitted, catalogPrice int64) bool {
+	diff := submitted - catalogPrice
+	if diff < 0 {
+		diff = -diff
+	}
+	return float64(diff) <= float64(catalogPrice)*c.Tolerance/100
+}
+
+// reconcilePrices checks the product IDs of req against the catalog and
+// the unit prices against the catalog prices in req.Currency. Items
+// without a product name get the catalog's. It reports whether a price
+// was corrected, and does nothing when no catalog client is configured.
+func (s *OrderService) reconcilePrices(ctx context.Context, req *CreateOrderRequest) (bool, error) {
+	if s.catalogClient == nil {
+		return false, nil
+	}
+
+	verr := &ValidationError{}
+	ids := make([]string, 0, len(req.Items))
+	for i, item := range req.Items {
+		if item.ProductID == "" {
+			verr.add(fmt.Sprintf("items[%d].product_id", i), "is required")
+			continue
+		}
+		ids = append(ids, item.ProductID)
+	}
+	if len(verr.Fields) > 0 {
+		return false, verr
+	}
+
+	// GetPrices is read-only, so it is retried like reservations.
+	var (
+		resp *catalog.GetPricesResponse
+		err  error
+	)
+	for attempt := 1; attempt <= max(s.retry.MaxAttempts, 1); attempt++ {
+		callCtx, cancel := ctx, context.CancelFunc(func() {})
+		if s.retry.CallTimeout > 0 {
+			callCtx, cancel = context.WithTimeout(ctx, s.retry.CallTimeout)
+		}
+		resp, err = s.catalogClient.GetPrices(callCtx, &catalog.GetPricesRequest{ProductIDs: ids, Currency: req.Currency})
+		cancel()
+		if !retry.TransientRPC(err) {
+			break
+		}
+	}
+	if err != nil {
+		logger.WarnContext(ctx, "looking up catalog prices failed", logging.Err(err))
+		return false, ErrCatalogServiceUnavailable
+	}
+
+	prices := make(map[string]*catalog.ProductPrice, len(resp.Prices))
+	for _, p := range resp.Prices {
+		prices[p.ProductID] = p
+	}
+
+	// The items are copied so the caller's request is left as submitted.
+	items := slices.Clone(req.Items)
+	corrected := false
+	for i := range items {
+		item := &items[i]
+		field := fmt.Sprintf("items[%d]", i)
+		switch {
+		case slices.Contains(resp.UnknownProductIDs, item.ProductID):
+			verr.add(field+".product_id", "does not match a product")
+			continue
+		case prices[item.ProductID] == nil:
+			verr.add(field+".product_id", "is not sold in %s", req.Currency)
+			continue
+		}
+
+		price := prices[item.ProductID]
+		if item.ProductName == "" {
+			item.ProductName = price.Name
+		}
+		if s.priceCheck.accepts(item.UnitPriceCents, price.UnitPriceCents) {
+			continue
+		}
+		catalogPrice := money.New(price.UnitPriceCents, req.Currency)
+		if s.priceCheck.Mismatch != PriceMismatchCorrect {
+			verr.add(field+".unit_price_cents", "does not match the catalog price of %s", catalogPrice)
+			continue
+		}
+		logger.WarnContext(ctx, "unit price corrected to the catalog price", "product_id", item.ProductID,
+			"submitted_cents", item.UnitPriceCents, "catalog_cents", price.UnitPriceCents)
+		item.UnitPriceCents = price.UnitPriceCents
+		corrected = true
+	}
+	if len(verr.Fields) > 0 {
+		return false, verr
+	}
+
+	req.Items = items
+	return corrected, nil
+}
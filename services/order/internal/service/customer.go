@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/retry"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/customer"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// resolveCustomer looks up the customer of req and fills the email and
+// currency the request leaves empty from the profile. A given email must
+// match the profile. It returns nil when no customer client is configured.
+func (s *OrderService) resolveCustomer(ctx context.Context, req *CreateOrderRequest) (*order.CustomerSnapshot, error) {
+	if s.customerClient == nil {
+		return nil, nil
+	}
+
+	verr := &ValidationError{}
+	if req.CustomerID == "" {
+		verr.add("customer_id", "is required")
+		return nil, verr
+	}
+
+	// GetCustomer is read-only, so it is retried like reservations.
+	var (
+		profile *customer.Customer
+		err     error
+	)
+	for attempt := 1; attempt <= max(s.retry.MaxAttempts, 1); attempt++ {
+		callCtx, cancel := ctx, context.CancelFunc(func() {})
+		if s.retry.CallTimeout > 0 {
+			callCtx, cancel = context.WithTimeout(ctx, s.retry.CallTimeout)
+		}
+		profile, err = s.customerClient.GetCustomer(callCtx, &customer.GetCustomerRequest{CustomerID: req.CustomerID})
+		cancel()
+		if !retry.TransientRPC(err) {
+			break
+		}
+	}
+
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			verr.add("customer_id", "does not match a customer")
+			return nil, verr
+		}
+		logger.WarnContext(ctx, "looking up customer failed", "customer_id", req.CustomerID, logging.Err(err))
+		return nil, ErrCustomerServiceUnavailable
+	}
+
+	switch {
+	case req.CustomerEmail == "":
+		req.CustomerEmail = profile.Email
+	case !strings.EqualFold(req.CustomerEmail, profile.Email):
+		verr.add("customer_email", "does not match the customer profile")
+		return nil, verr
+	}
+	if req.Currency == "" && profile.GetPaymentPreferences().GetCurrency() != "" {
+		req.Currency = profile.PaymentPreferences.Currency
+	}
+
+	return snapshotOf(profile), nil
+}
+
+func snapshotOf(c *customer.Customer) *order.CustomerSnapshot {
+	snapshot := &order.CustomerSnapshot{
+		ID:                     c.ID,
+		Name:                   c.Name,
+		Email:                  c.Email,
+		Phone:                  c.Phone,
+		PreferredPaymentMethod: c.GetPaymentPreferences().GetMethod(),
+	}
+	for _, a := range c.Addresses {
+		if a.Default {
+			snapshot.ShippingAddress = &order.Address{
+				Line1:      a.Line1,
+				Line2:      a.Line2,
+				City:       a.City,
+				State:      a.State,
+				PostalCode: a.PostalCode,
+				Country:    a.Country,
+			}
+			break
+		}
+	}
+	return snapshot
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+)
+
+// PaymentRetriesTopic receives an OrderRequestedEvent for every order
+// accepted while the payment circuit was open under the queue degradation
+// policy. Workers running HandleDeferredPayment charge those orders once
+// the payment service recovers.
+const PaymentRetriesTopic = "order.payment_retry"
+
+// DeferredReason is logged for orders whose payment was deferred.
+const DeferredReason = "payment deferred: payment service unavailable"
+
+// Degradation policies, what CreateOrder does while the payment circuit is
+// open.
+const (
+	// DegradationReject fails the order with ErrPaymentServiceUnavailable.
+	DegradationReject = "reject"
+
+	// DegradationQueue stores the order PENDING and charges it from
+	// PaymentRetriesTopic once the circuit lets calls through again.
+	DegradationQueue = "queue"
+)
+
+// DegradationConfig sets how orders are taken while the payment service is
+// down. Queued orders still count against the pending expiration, so those
+// not charged within pending-max-age are cancelled as expired.
+type DegradationConfig struct {
+	Policy string `config:"policy" usage:"What POST /orders does while the payment circuit is open: reject fails with 503, queue accepts the order PENDING and charges it once payment recovers"`
+
+	// MaxPending bounds the orders waiting for payment, so an outage does
+	// not reserve stock without limit. 0 leaves it unbounded.
+	MaxPending int `config:"max-pending" usage:"Orders queued for payment at most before POST /orders fails with 503 again, 0 is unbounded"`
+}
+
+func DefaultDegradationConfig() DegradationConfig {
+	return DegradationConfig{
+		Policy:     DegradationReject,
+		MaxPending: 1000,
+	}
+}
+
+func (c DegradationConfig) Validate() error {
+	if c.Policy != DegradationReject && c.Policy != DegradationQueue {
+		return fmt.Errorf("policy must be %s or %s, got %q", DegradationReject, DegradationQueue, c.Policy)
+	}
+	if c.MaxPending < 0 {
+		return errors.New("max-pending must not be negative")
+	}
+	return nil
+}
+
+// WithDegradation replaces DefaultDegradationConfig. The queue policy needs
+// a worker running HandleDeferredPayment on PaymentRetriesTopic.
+func WithDegradation(config DegradationConfig) Option {
+	return func(s *OrderService) {
+		s.degradation = config
+	}
+}
+
+// canDefer reports whether a new order may be queued for payment instead of
+// failing while the payment circuit is open.
+func (s *OrderService) canDefer() bool {
+	if s.degradation.Policy != DegradationQueue {
+		return false
+	}
+	return s.degradation.MaxPending == 0 || s.deferred.Load() < int64(s.degradation.MaxPending)
+}
+
+// DeferredPayments returns the number of orders waiting on
+// PaymentRetriesTopic for the payment service to recover.
+func (s *OrderService) DeferredPayments() int64 {
+	return s.deferred.Load()
+}
+
+// deferPayment queues the PENDING order o on PaymentRetriesTopic. An order
+// that cannot be queued is cancelled and its stock released, as SubmitOrder
+// does.
+func (s *OrderService) deferPayment(ctx context.Context, o *order.Order) (*order.Order, error) {
+	msg, err := broker.NewMessage("order.requested", order.NewOrderRequestedEvent(o.ID))
+	if err == nil {
+		msg.SetMetadata("order_id", o.ID)
+		s.deferred.Add(1)
+		if err = s.broker.Publish(ctx, PaymentRetriesTopic, msg); err != nil {
+			s.deferred.Add(-1)
+		}
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "queueing order for payment failed", logging.Err(err))
+		s.updateOrderStatus(ctx, o.ID, order.OrderStatus_ORDER_STATUS_CANCELLED, "order could not be queued")
+		s.releaseReservation(ctx, o, "order could not be queued")
+		return nil, err
+	}
+
+	logger.WarnContext(ctx, "order queued until payment recovers", "reason", DeferredReason, "deferred", s.deferred.Load())
+	return o, nil
+}
+
+// deferredHold bounds the time HandleDeferredPayment holds a message while
+// the payment circuit is open, well within the visibility timeout of the
+// queue.
+const deferredHold = 5 * time.Second
+
+// HandleDeferredPayment is the broker handler for PaymentRetriesTopic. It
+// charges the order if it is still PENDING, so orders expired or cancelled
+// while waiting are dropped. While the payment circuit is open the message
+// is held for up to deferredHold and then failed, to be redelivered and
+// looked at again; a payment call failing again fails it as well. A
+// decline is recorded on the order like any other.
+func (s *OrderService) HandleDeferredPayment(msg *broker.Message) error {
+	var event order.OrderRequestedEvent
+	if err := msg.Decode(&event); err != nil {
+		// The queue retries without limit, so a message that can never be
+		// read is dropped rather than redelivered forever.
+		logger.Error("dropping unreadable deferred payment", "message_id", msg.ID, logging.Err(err))
+		return nil
+	}
+	ctx := logging.WithAttrs(msg.Context(context.Background()), "order_id", event.OrderID)
+
+	o, err := s.repo.Get(ctx, event.OrderID)
+	if errors.Is(err, ErrOrderNotFound) {
+		s.deferred.Add(-1)
+		logger.WarnContext(ctx, "deferred order no longer exists")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if o.Status != order.OrderStatus_ORDER_STATUS_PENDING {
+		s.deferred.Add(-1)
+		logger.InfoContext(ctx, "deferred order already processed", "status", o.Status.String())
+		return nil
+	}
+	// Available leaves the half-open trial to the payment call below.
+	if !s.breaker.Available() {
+		time.Sleep(min(max(s.breaker.RetryIn(), 10*time.Millisecond), deferredHold))
+		return ErrPaymentServiceUnavailable
+	}
+
+	_, err = s.chargeOrder(ctx, o, true)
+	if errors.Is(err, ErrPaymentServiceUnavailable) || errors.Is(err, ErrPaymentTimeout) {
+		logger.InfoContext(ctx, "deferred order still not paid", logging.Err(err))
+		return err
+	}
+	s.deferred.Add(-1)
+	if err != nil {
+		logger.InfoContext(ctx, "deferred order not paid", logging.Err(err))
+		return nil
+	}
+	logger.InfoContext(ctx, "deferred order paid")
+	return nil
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+)
+
+// DisputesTopic receives an OrderDisputeEvent whenever a dispute is opened
+// or resolved.
+const DisputesTopic = "order.disputes"
+
+// OpenDispute simulates a chargeback on a paid order. The payment service
+// moves the transaction to DISPUTED and the order follows.
+func (s *OrderService) OpenDispute(ctx context.Context, orderID, reason string) (*order.Order, error) {
+	o, err := s.repo.Get(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if o.Status != order.OrderStatus_ORDER_STATUS_PAID {
+		return nil, ErrOrderNotPaid
+	}
+
+	var dispute *payment.Dispute
+	err = s.callPayment(ctx, "CreateDispute", false, func(ctx context.Context) error {
+		dispute, err = s.payments.CreateDispute(ctx, &payment.CreateDisputeRequest{
+			TransactionID: o.PaymentTransactionID,
+			Reason:        reason,
+		})
+		return err
+	})
+	if err != nil {
+		logger.WarnContext(ctx, "creating dispute failed", logging.Err(err))
+		return nil, disputeError(err)
+	}
+
+	disputed, err := s.transition(ctx, orderID, StatusUpdate{
+		Status:    order.OrderStatus_ORDER_STATUS_DISPUTED,
+		DisputeID: dispute.DisputeID,
+		Reason:    reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "order disputed", "dispute_id", dispute.DisputeID)
+	s.publishes.Add(1)
+	go s.publishDisputeEvent(ctx, order.EventTypeOrderDisputed, *disputed, dispute.Reason)
+
+	return disputed, nil
+}
+
+// ResolveDispute closes the open dispute of an order. A won dispute returns
+// the order to PAID; a lost one is the compensation path: the payment is
+// charged back and the order leaves PAID for good.
+func (s *OrderService) ResolveDispute(ctx context.Context, orderID string, won bool, note string) (*order.Order, error) {
+	o, err := s.repo.Get(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if o.Status != order.OrderStatus_ORDER_STATUS_DISPUTED || o.DisputeID == "" {
+		return nil, ErrNoOpenDispute
+	}
+
+	outcome := payment.DisputeStatus_DISPUTE_STATUS_LOST
+	next := order.OrderStatus_ORDER_STATUS_CHARGED_BACK
+	eventType := order.EventTypeOrderChargedBack
+	if won {
+		outcome = payment.DisputeStatus_DISPUTE_STATUS_WON
+		next = order.OrderStatus_ORDER_STATUS_PAID
+		eventType = order.EventTypeOrderDisputeWon
+	}
+
+	var dispute *payment.Dispute
+	err = s.callPayment(ctx, "ResolveDispute", false, func(ctx context.Context) error {
+		dispute, err = s.payments.ResolveDispute(ctx, &payment.ResolveDisputeRequest{
+			DisputeID: o.DisputeID,
+			Outcome:   outcome,
+			Note:      note,
+		})
+		return err
+	})
+	if err != nil {
+		logger.WarnContext(ctx, "resolving dispute failed", logging.Err(err))
+		return nil, disputeError(err)
+	}
+
+	resolved, err := s.transition(ctx, orderID, StatusUpdate{Status: next, Reason: note})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "dispute resolved",
+		"dispute_id", dispute.DisputeID, "dispute_status", dispute.Status.String(), "status", next.String())
+	s.publishes.Add(1)
+	go s.publishDisputeEvent(ctx, eventType, *resolved, note)
+
+	return resolved, nil
+}
+
+func (s *OrderService) publishDisputeEvent(ctx context.Context, eventType string, o order.Order, reason string) {
+	defer s.publishes.Done()
+
+	event := order.NewOrderDisputeEvent(eventType, o, reason)
+
+	msg, err := broker.NewMessage(eventType, event)
+	if err != nil {
+		return
+	}
+
+	msg.SetMetadata("order_id", o.ID)
+	msg.SetMetadata("dispute_id", o.DisputeID)
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	s.broker.Publish(ctx, DisputesTopic, msg)
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+)
+
+// ErasuresTopic receives a CustomerErasedEvent for every EraseCustomer
+// call, so the audit trail records the erasure.
+const ErasuresTopic = order.EventTypeCustomerErased
+
+// ErasedValue replaces the personal data the Scrubber finds.
+const ErasedValue = "[erased]"
+
+// CustomerScrubber returns a Scrubber for the personal data stored with the
+// orders of customerID in the tenant of ctx, to erase the copies kept
+// elsewhere, such as audit events and broker messages, before
+// EraseCustomer removes it from the orders.
+func (s *OrderService) CustomerScrubber(ctx context.Context, customerID string) (*Scrubber, error) {
+	scrubber := &Scrubber{values: make(map[string]bool)}
+	err := s.repo.Export(ctx, ListOptions{CustomerID: customerID}, func(o *order.Order) error {
+		scrubber.collect(o)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return scrubber, nil
+}
+
+// EraseCustomer anonymizes the orders of customerID in the tenant of ctx
+// and returns the IDs of those it changed. The email, name, phone and
+// street address of the customer are removed, while the orders, their
+// amounts and the state and country they shipped to are kept for
+// accounting, so erasing a customer again changes nothing. A
+// CustomerErasedEvent is published on ErasuresTopic.
+func (s *OrderService) EraseCustomer(ctx context.Context, customerID string) ([]string, error) {
+	ids, err := s.repo.EraseCustomer(ctx, customerID, anonymize)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "customer erased", "customer_id", customerID, "orders", len(ids))
+	s.publishes.Add(1)
+	go s.publishCustomerErased(ctx, customerID, ids)
+	return ids, nil
+}
+
+func (s *OrderService) publishCustomerErased(ctx context.Context, customerID string, orderIDs []string) {
+	defer s.publishes.Done()
+
+	event := order.NewCustomerErasedEvent(customerID, orderIDs)
+
+	msg, err := broker.NewMessage(event.EventType, event)
+	if err != nil {
+		return
+	}
+
+	msg.SetMetadata("customer_id", customerID)
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	if err := s.broker.Publish(ctx, ErasuresTopic, msg); err != nil {
+		logger.ErrorContext(ctx, "failed to publish customer erasure", "customer_id", customerID, logging.Err(err))
+	}
+}
+
+// anonymize removes the personal data of the customer from o and reports
+// whether there was any.
+func anonymize(o *order.Order) bool {
+	changed := o.CustomerEmail != ""
+	o.CustomerEmail = ""
+	if c := o.Customer; c != nil {
+		changed = changed || c.Name != "" || c.Email != "" || c.Phone != ""
+		c.Name, c.Email, c.Phone = "", "", ""
+		changed = anonymizeAddress(c.ShippingAddress) || changed
+	}
+	return anonymizeAddress(o.ShippingAddress) || changed
+}
+
+// anonymizeAddress keeps the state and country of a, which the tax of the
+// order depends on.
+func anonymizeAddress(a *order.Address) bool {
+	if a == nil || a.Line1 == "" && a.Line2 == "" && a.City == "" && a.PostalCode == "" {
+		return false
+	}
+	a.Line1, a.Line2, a.City, a.PostalCode = "", "", "", ""
+	return true
+}
+
+// Scrubber replaces the personal data of an erased customer with
+// ErasedValue. Emails are replaced wherever they appear in a string;
+// names, phones, street lines and postal codes only when they are the
+// whole string, so that a short name does not erase unrelated text.
+// Matching ignores case.
+type Scrubber struct {
+	emails []string
+	values map[string]bool
+}
+
+// collect adds the personal data of o.
+func (s *Scrubber) collect(o *order.Order) {
+	s.addEmail(o.CustomerEmail)
+	if c := o.Customer; c != nil {
+		s.addEmail(c.Email)
+		s.add(c.Name, c.Phone)
+		s.addAddress(c.ShippingAddress)
+	}
+	s.addAddress(o.ShippingAddress)
+}
+
+func (s *Scrubber) addEmail(email string) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email != "" && !s.values[email] {
+		s.values[email] = true
+		s.emails = append(s.emails, email)
+	}
+}
+
+func (s *Scrubber) addAddress(a *order.Address) {
+	if a != nil {
+		s.add(a.Line1, a.Line2, a.PostalCode)
+	}
+}
+
+func (s *Scrubber) add(values ...string) {
+	for _, v := range values {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			s.values[v] = true
+		}
+	}
+}
+
+// Empty reports whether s has nothing to find.
+func (s *Scrubber) Empty() bool {
+	return s == nil || len(s.values) == 0
+}
+
+// String returns v with the personal data replaced, and whether there was
+// any.
+func (s *Scrubber) String(v string) (string, bool) {
+	if s.Empty() || v == "" {
+		return v, false
+	}
+	if s.values[strings.ToLower(strings.TrimSpace(v))] {
+		return ErasedValue, true
+	}
+	changed := false
+	for _, email := range s.emails {
+		for i := indexFold(v, email); i >= 0; i = indexFold(v, email) {
+			v = v[:i] + ErasedValue + v[i+len(email):]
+			changed = true
+		}
+	}
+	return v, changed
+}
+
+// indexFold is strings.Index ignoring case, for a lower case substr.
+func indexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// JSON returns data with the personal data replaced in every string value,
+// and whether there was any. Data that is not JSON is scrubbed as text.
+func (s *Scrubber) JSON(data json.RawMessage) (json.RawMessage, bool) {
+	if s.Empty() || len(data) == 0 {
+		return data, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		text, changed := s.String(string(data))
+		return json.RawMessage(text), changed
+	}
+	v, changed := s.value(v)
+	if !changed {
+		return data, false
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return data, false
+	}
+	return out, true
+}
+
+func (s *Scrubber) value(v any) (any, bool) {
+	changed := false
+	switch v := v.(type) {
+	case string:
+		return s.String(v)
+	case map[string]any:
+		for k, item := range v {
+			scrubbed, ok := s.value(item)
+			if ok {
+				v[k] = scrubbed
+				changed = true
+			}
+		}
+	case []any:
+		for i, item := range v {
+			scrubbed, ok := s.value(item)
+			if ok {
+				v[i] = scrubbed
+				changed = true
+			}
+		}
+	}
+	return v, changed
+}
+
+// Message scrubs the payload and metadata values of msg in place. It is a
+// broker.Scrubber.
+func (s *Scrubber) Message(msg *broker.Message) bool {
+	payload, changed := s.JSON(msg.Payload)
+	msg.Payload = payload
+	for k, v := range msg.Metadata {
+		if scrubbed, ok := s.String(v); ok {
+			msg.Metadata[k] = scrubbed
+			changed = true
+		}
+	}
+	return changed
+}
@@ -1,28 +1,171 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
 	// ErrOrderNotFound is returned when an order doesn't exist
 	ErrOrderNotFound = errors.New("order not found")
 
-	// ErrNoItems is returned when trying to create an order with no items
-	ErrNoItems = errors.New("order must have at least one item")
-
-	// ErrMissingEmail is returned when customer email is missing
-	ErrMissingEmail = errors.New("customer email is required")
-
 	// ErrPaymentServiceUnavailable is returned when payment service is down
 	ErrPaymentServiceUnavailable = errors.New("payment service unavailable")
+
+	// ErrPaymentTimeout is returned when payment service did not answer in time
+	ErrPaymentTimeout = errors.New("payment service timed out")
+
+	// ErrInvalidListOptions is returned for inconsistent list filters or sorting
+	ErrInvalidListOptions = errors.New("invalid list options")
+
+	// ErrInvalidCursor is returned when a page cursor cannot be decoded
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// ErrInvalidSearchQuery is returned for a search term that cannot be parsed
+	ErrInvalidSearchQuery = errors.New("invalid search query")
+
+	// ErrOrderNotCancellable is returned when cancelling a shipped, disputed or closed order
+	ErrOrderNotCancellable = errors.New("order cannot be cancelled")
+
+	// ErrOrderNotPaid is returned when disputing an order that is not PAID
+	ErrOrderNotPaid = errors.New("only paid orders can be disputed")
+
+	// ErrNoOpenDispute is returned when resolving a dispute on an order that is not DISPUTED
+	ErrNoOpenDispute = errors.New("order has no open dispute")
+
+	// ErrDisputeRejected is returned when the payment service refuses a dispute change
+	ErrDisputeRejected = errors.New("dispute rejected by payment service")
+
+	// ErrInventoryServiceUnavailable is returned when stock cannot be reserved
+	// because the inventory service is down
+	ErrInventoryServiceUnavailable = errors.New("inventory service unavailable")
+
+	// ErrCustomerServiceUnavailable is returned when the customer of a new
+	// order cannot be looked up because the customer service is down
+	ErrCustomerServiceUnavailable = errors.New("customer service unavailable")
+
+	// ErrCatalogServiceUnavailable is returned when the items of a new order
+	// cannot be priced because the catalog service is down
+	ErrCatalogServiceUnavailable = errors.New("catalog service unavailable")
+
+	// ErrStatusNotSettable is returned when UpdateOrderStatus is asked for a
+	// status that only payment, cancellation or dispute handling may set
+	ErrStatusNotSettable = errors.New("status cannot be set directly")
+
+	// ErrStatusDerived is returned when a fulfilment status is set on an
+	// order split into fulfillments, whose status follows its items
+	ErrStatusDerived = errors.New("order status is derived from its fulfillments")
+
+	// ErrFulfillmentNotFound is returned for an unknown fulfillment ID
+	ErrFulfillmentNotFound = errors.New("fulfillment not found")
+
+	// ErrReturnNotFound is returned for an unknown return ID
+	ErrReturnNotFound = errors.New("return not found")
+
+	// ErrInvalidReturnTransition is returned when a return cannot move to
+	// the requested status
+	ErrInvalidReturnTransition = errors.New("cannot transition return")
+
+	// ErrRefundRejected is returned when the payment service refuses the
+	// refund of a return
+	ErrRefundRejected = errors.New("refund rejected by payment service")
 )
 
+// InvalidTransitionError is returned when a status change is not allowed
+type InvalidTransitionError struct {
+	From order.OrderStatus
+	To   order.OrderStatus
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("cannot transition order from %s to %s", e.From, e.To)
+}
+
+// IsInvalidTransition checks if an error is an invalid transition error
+func IsInvalidTransition(err error) bool {
+	var target *InvalidTransitionError
+	return errors.As(err, &target)
+}
+
+// VersionConflictError is returned when an order changed since the
+// version a conditional update expected
+type VersionConflictError struct {
+	Expected int64
+	Actual   int64
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("order is at version %d, not %d", e.Actual, e.Expected)
+}
+
+// IsVersionConflict checks if an error is a version conflict error
+func IsVersionConflict(err error) bool {
+	var target *VersionConflictError
+	return errors.As(err, &target)
+}
+
+// StockShortage is one product that cannot be reserved in full
+type StockShortage struct {
+	ProductID string `json:"product_id"`
+	Requested int32  `json:"requested"`
+	Available int32  `json:"available"`
+}
+
+// OutOfStockError is returned when the inventory cannot hold every item
+type OutOfStockError struct {
+	Items []StockShortage
+}
+
+func (e *OutOfStockError) Error() string {
+	parts := make([]string, len(e.Items))
+	for i, item := range e.Items {
+		parts[i] = fmt.Sprintf("%s (requested %d, available %d)", item.ProductID, item.Requested, item.Available)
+	}
+	return "out of stock: " + strings.Join(parts, ", ")
+}
+
+// IsOutOfStock checks if an error is an out of stock error
+func IsOutOfStock(err error) bool {
+	var target *OutOfStockError
+	return errors.As(err, &target)
+}
+
 // PaymentDeclinedError is returned when payment is declined
 type PaymentDeclinedError struct {
 	Code    string
 	Message string
+
+	// Retriable tells whether the payment service expects a new order to
+	// be paid later, and RetryAfter, when set, how long to wait from now.
+	Retriable  bool
+	RetryAfter time.Duration
+}
+
+// declinedFromResponse returns the PaymentDeclinedError of a declined
+// payment, with its retry hint counted from now.
+func declinedFromResponse(resp *payment.PaymentResponse, now time.Time) *PaymentDeclinedError {
+	declined := &PaymentDeclinedError{
+		Code:      resp.ErrorCode.String(),
+		Message:   resp.ErrorMessage,
+		Retriable: resp.Retriable,
+	}
+	switch {
+	case !resp.Retriable || resp.RetryAfterSeconds <= 0:
+	case resp.ProcessedAt.IsZero():
+		declined.RetryAfter = resp.GetRetryAfter()
+	default:
+		declined.RetryAfter = max(resp.ProcessedAt.Add(resp.GetRetryAfter()).Sub(now), 0)
+	}
+	return declined
 }
 
 func (e *PaymentDeclinedError) Error() string {
@@ -34,3 +177,62 @@ func IsPaymentDeclined(err error) bool {
 	_, ok := err.(*PaymentDeclinedError)
 	return ok
 }
+
+// declinedFromStatus turns a payment rejection carried in gRPC error details
+// into a PaymentDeclinedError. It returns nil for transport failures.
+func declinedFromStatus(err error) *PaymentDeclinedError {
+	st, ok := status.FromError(err)
+	if !ok || (st.Code() != codes.InvalidArgument && st.Code() != codes.FailedPrecondition) {
+		return nil
+	}
+
+	if violations := grpcmw.FieldViolations(err); len(violations) > 0 {
+		parts := make([]string, len(violations))
+		for i, v := range violations {
+			parts[i] = v.Field + " " + v.Description
+		}
+		return &PaymentDeclinedError{Code: "INVALID_REQUEST", Message: strings.Join(parts, "; ")}
+	}
+
+	if info, ok := grpcmw.Reason(err); ok {
+		return &PaymentDeclinedError{Code: info.Reason, Message: st.Message()}
+	}
+
+	return nil
+}
+
+// paymentRejection returns the message of an error in which the payment
+// service refused the request. It returns false for transport failures.
+func paymentRejection(err error) (string, bool) {
+	st, ok := status.FromError(err)
+	if !ok {
+		return "", false
+	}
+
+	switch st.Code() {
+	case codes.InvalidArgument, codes.NotFound, codes.FailedPrecondition:
+		return st.Message(), true
+	default:
+		return "", false
+	}
+}
+
+// paymentFailure turns a transport failure of a payment call into
+// ErrPaymentTimeout when the call ran out of time and into
+// ErrPaymentServiceUnavailable otherwise.
+func paymentFailure(err error) error {
+	if errors.Is(err, ErrPaymentTimeout) || errors.Is(err, context.DeadlineExceeded) ||
+		status.Code(err) == codes.DeadlineExceeded {
+		return ErrPaymentTimeout
+	}
+	return ErrPaymentServiceUnavailable
+}
+
+// disputeError wraps a payment service refusal in ErrDisputeRejected and
+// transport failures as paymentFailure does.
+func disputeError(err error) error {
+	if msg, ok := paymentRejection(err); ok {
+		return fmt.Errorf("%w: %s", ErrDisputeRejected, msg)
+	}
+	return paymentFailure(err)
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+)
+
+// ExpiredTopic receives an OrderExpiredEvent for every order cancelled by
+// the expiration sweeper.
+const ExpiredTopic = "order.expired"
+
+// ExpiredReason is the status history reason of expired orders.
+const ExpiredReason = "expired: payment not confirmed in time"
+
+// ExpiryConfig controls the sweeper that cancels orders stuck in PENDING,
+// for example because the payment service never answered.
+type ExpiryConfig struct {
+	// PendingMaxAge must exceed the time a payment can take with all its
+	// retries, or orders still being charged are expired. 0 disables the
+	// sweeper.
+	PendingMaxAge time.Duration `config:"pending-max-age" usage:"Age after which PENDING orders are cancelled as expired, 0 disables expiration"`
+	Interval      time.Duration `config:"expiry-interval" usage:"Interval between sweeps for expired orders"`
+	BatchSize     int           `config:"expiry-batch" usage:"Orders expired at most per sweep"`
+}
+
+func DefaultExpiryConfig() ExpiryConfig {
+	return ExpiryConfig{
+		PendingMaxAge: 15 * time.Minute,
+		Interval:      time.Minute,
+		BatchSize:     100,
+	}
+}
+
+func (c ExpiryConfig) Validate() error {
+	if c.PendingMaxAge < 0 {
+		return errors.New("pending-max-age must not be negative")
+	}
+	if c.Interval <= 0 || c.BatchSize < 1 {
+		return errors.New("expiry-interval must be positive and expiry-batch at least 1")
+	}
+	return nil
+}
+
+// StartExpirer expires stale PENDING orders every config.Interval until ctx
+// is cancelled. It returns at once when expiration is disabled.
+func (s *OrderService) StartExpirer(ctx context.Context, config ExpiryConfig) {
+	if config.PendingMaxAge == 0 {
+		return
+	}
+	ticker := time.NewTicker(config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			n, err := s.ExpireOrders(ctx, now.Add(-config.PendingMaxAge), config.BatchSize)
+			if err != nil && ctx.Err() == nil {
+				logger.ErrorContext(ctx, "expiring orders failed", logging.Err(err))
+			}
+			if n > 0 {
+				logger.InfoContext(ctx, "expired pending orders", "count", n)
+			}
+		}
+	}
+}
+
+// ExpireOrders cancels up to limit orders of any tenant that are still
+// PENDING and were created before cutoff, and returns how many it expired.
+// Orders whose payment cannot be voided stay PENDING for the next sweep.
+func (s *OrderService) ExpireOrders(ctx context.Context, cutoff time.Time, limit int) (int, error) {
+	stale, err := s.repo.Stale(ctx, order.OrderStatus_ORDER_STATUS_PENDING, cutoff, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, o := range stale {
+		if ctx.Err() != nil {
+			break
+		}
+		orderCtx := logging.WithAttrs(tenant.NewContext(ctx, o.TenantID), "order_id", o.ID)
+		if s.expireOrder(orderCtx, o) {
+			n++
+		}
+	}
+	return n, ctx.Err()
+}
+
+// expireOrder voids the payment of o, if it has one, cancels it and releases
+// its stock reservation. An order that left PENDING meanwhile is skipped.
+func (s *OrderService) expireOrder(ctx context.Context, o *order.Order) bool {
+	if o.PaymentTransactionID != "" {
+		if err := s.compensatePayment(ctx, o, ExpiredReason); err != nil {
+			logger.WarnContext(ctx, "expired order kept pending, voiding its payment failed", logging.Err(err))
+			return false
+		}
+	}
+
+	cancelled, err := s.transition(ctx, o.ID, StatusUpdate{Status: order.OrderStatus_ORDER_STATUS_CANCELLED, Reason: ExpiredReason})
+	var invalid *InvalidTransitionError
+	if errors.As(err, &invalid) {
+		logger.InfoContext(ctx, "stale order no longer pending", "status", invalid.From.String())
+		return false
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "expiring order failed", logging.Err(err))
+		return false
+	}
+
+	logger.InfoContext(ctx, "order expired", "created_at", o.CreatedAt)
+	s.metrics.orderExpired()
+	s.releaseReservation(ctx, cancelled, ExpiredReason)
+	s.publishes.Add(1)
+	go s.publishOrderExpired(ctx, *cancelled)
+	return true
+}
+
+func (s *OrderService) publishOrderExpired(ctx context.Context, o order.Order) {
+	defer s.publishes.Done()
+
+	event := order.NewOrderExpiredEvent(o)
+
+	msg, err := broker.NewMessage(event.EventType, event)
+	if err != nil {
+		return
+	}
+
+	msg.SetMetadata("order_id", o.ID)
+	msg.SetMetadata("customer_id", o.CustomerID)
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	s.broker.Publish(ctx, ExpiredTopic, msg)
+}
+
+// expired reports whether o was cancelled by the expiration sweeper.
+func expired(o *order.Order) bool {
+	n := len(o.Transitions)
+	return o.Status == order.OrderStatus_ORDER_STATUS_CANCELLED && n > 0 && o.Transitions[n-1].Reason == ExpiredReason
+}
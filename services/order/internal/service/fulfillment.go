@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+	"github.com/google/uuid"
+)
+
+// FulfillmentsTopic receives an OrderFulfillmentEvent whenever a
+// fulfillment is created or changes status.
+const FulfillmentsTopic = "order.fulfillments"
+
+// FulfillmentChange creates a fulfillment of Items, or, with Items nil,
+// updates fulfillment ID. Status moves the fulfillment along PROCESSING,
+// SHIPPED and DELIVERED; UNSPECIFIED keeps it. Non-empty Carrier and
+// TrackingNumber replace the stored ones.
+type FulfillmentChange struct {
+	ID             string
+	Items          []int
+	Status         order.OrderStatus
+	Carrier        string
+	TrackingNumber string
+
+	// from and orderChanged are set by apply for the events.
+	from         order.OrderStatus
+	orderChanged bool
+}
+
+func (c *FulfillmentChange) apply(o *order.Order, now time.Time) error {
+	c.from = order.OrderStatus_ORDER_STATUS_UNSPECIFIED
+	c.orderChanged = false
+
+	if c.Items != nil {
+		return c.create(o, now)
+	}
+
+	for i := range o.Fulfillments {
+		f := &o.Fulfillments[i]
+		if f.ID != c.ID {
+			continue
+		}
+		if c.Status != order.OrderStatus_ORDER_STATUS_UNSPECIFIED {
+			if c.Status == order.OrderStatus_ORDER_STATUS_CANCELLED || !CanTransition(f.Status, c.Status) {
+				return &InvalidTransitionError{From: f.Status, To: c.Status}
+			}
+			c.from = f.Status
+			f.Status = c.Status
+		}
+		if c.Carrier != "" {
+			f.Carrier = c.Carrier
+		}
+		if c.TrackingNumber != "" {
+			f.TrackingNumber = c.TrackingNumber
+		}
+		f.UpdatedAt = now
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrFulfillmentNotFound, c.ID)
+}
+
+func (c *FulfillmentChange) create(o *order.Order, now time.Time) error {
+	if o.Status != order.OrderStatus_ORDER_STATUS_PAID && o.Status != order.OrderStatus_ORDER_STATUS_PROCESSING {
+		return &InvalidTransitionError{From: o.Status, To: order.OrderStatus_ORDER_STATUS_PROCESSING}
+	}
+
+	assigned := make(map[int]string)
+	for _, f := range o.Fulfillments {
+		for _, i := range f.Items {
+			assigned[i] = f.ID
+		}
+	}
+	verr := &ValidationError{}
+	if len(c.Items) == 0 {
+		verr.add("items", "must list at least one item")
+	}
+	seen := make(map[int]bool, len(c.Items))
+	for n, i := range c.Items {
+		field := fmt.Sprintf("items[%d]", n)
+		switch {
+		case i < 0 || i >= len(o.Items):
+			verr.add(field, "must be an item index between 0 and %d", len(o.Items)-1)
+		case seen[i]:
+			verr.add(field, "repeats item %d", i)
+		case assigned[i] != "":
+			verr.add(field, "item %d is already in fulfillment %s", i, assigned[i])
+		}
+		seen[i] = true
+	}
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+
+	o.Fulfillments = append(o.Fulfillments, order.Fulfillment{
+		ID:        c.ID,
+		Items:     append([]int(nil), c.Items...),
+		Status:    order.OrderStatus_ORDER_STATUS_PROCESSING,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	return nil
+}
+
+// derivedStatus is the status of an order split into fulfillments: the
+// least advanced status of its items, and PROCESSING while some items are
+// in no fulfillment yet.
+func derivedStatus(o *order.Order) order.OrderStatus {
+	assigned := 0
+	status := order.OrderStatus_ORDER_STATUS_DELIVERED
+	for _, f := range o.Fulfillments {
+		assigned += len(f.Items)
+		if f.Status < status {
+			status = f.Status
+		}
+	}
+	if assigned < len(o.Items) || status < order.OrderStatus_ORDER_STATUS_PROCESSING {
+		return order.OrderStatus_ORDER_STATUS_PROCESSING
+	}
+	return status
+}
+
+// checkFulfilledUpdate rejects the statuses a split order cannot be given
+// directly: the fulfilment ones, which follow its items, and CANCELLED once
+// part of it has shipped.
+func checkFulfilledUpdate(o *order.Order, status order.OrderStatus) error {
+	if manualTargets[status] {
+		return ErrStatusDerived
+	}
+	if status == order.OrderStatus_ORDER_STATUS_CANCELLED {
+		if f, ok := shippedFulfillment(o); ok {
+			return fmt.Errorf("%w: fulfillment %s is %s", ErrOrderNotCancellable, f.ID, f.Status)
+		}
+	}
+	return nil
+}
+
+// shippedFulfillment returns a fulfillment of o that left the warehouse.
+func shippedFulfillment(o *order.Order) (order.Fulfillment, bool) {
+	for _, f := range o.Fulfillments {
+		if f.Status == order.OrderStatus_ORDER_STATUS_SHIPPED || f.Status == order.OrderStatus_ORDER_STATUS_DELIVERED {
+			return f, true
+		}
+	}
+	return order.Fulfillment{}, false
+}
+
+func cancelFulfillments(o *order.Order, now time.Time) {
+	for i := range o.Fulfillments {
+		if o.Fulfillments[i].Status == order.OrderStatus_ORDER_STATUS_PROCESSING {
+			o.Fulfillments[i].Status = order.OrderStatus_ORDER_STATUS_CANCELLED
+			o.Fulfillments[i].UpdatedAt = now
+		}
+	}
+}
+
+// syncItemStatus gives the items in a fulfillment its status and the
+// others the status of the order.
+func syncItemStatus(o *order.Order) {
+	for i := range o.Items {
+		o.Items[i].Status = o.Status
+	}
+	for _, f := range o.Fulfillments {
+		for _, i := range f.Items {
+			if i >= 0 && i < len(o.Items) {
+				o.Items[i].Status = f.Status
+			}
+		}
+	}
+}
+
+// CreateFulfillment groups the items at the given indexes of a PAID or
+// PROCESSING order into a new fulfillment, which starts PROCESSING. From
+// then on the order status is derived from its items. A non-zero version is
+// the version the caller last read.
+func (s *OrderService) CreateFulfillment(ctx context.Context, orderID string, items []int, reason string, version int64) (*order.Order, error) {
+	if items == nil {
+		items = []int{}
+	}
+	return s.fulfill(ctx, orderID, &FulfillmentChange{
+		ID:    "ful_" + uuid.New().String()[:8],
+		Items: items,
+	}, reason, version)
+}
+
+// UpdateFulfillment applies change, whose ID names the fulfillment, and
+// updates the order status when the least advanced item moved on. A
+// non-zero version is the version the caller last read.
+func (s *OrderService) UpdateFulfillment(ctx context.Context, orderID string, change FulfillmentChange, reason string, version int64) (*order.Order, error) {
+	change.Items = nil
+	return s.fulfill(ctx, orderID, &change, reason, version)
+}
+
+func (s *OrderService) fulfill(ctx context.Context, orderID string, change *FulfillmentChange, reason string, version int64) (*order.Order, error) {
+	o, err := s.repo.UpdateStatus(ctx, orderID, StatusUpdate{
+		Fulfillment: change,
+		Reason:      reason,
+		At:          s.now(),
+		Version:     version,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.customers.record(o)
+
+	var f order.Fulfillment
+	for _, candidate := range o.Fulfillments {
+		if candidate.ID == change.ID {
+			f = candidate
+		}
+	}
+	logger.InfoContext(ctx, "order fulfillment changed", "fulfillment_id", f.ID, "status", f.Status.String())
+	if change.Items != nil || change.from != order.OrderStatus_ORDER_STATUS_UNSPECIFIED {
+		s.publishes.Add(1)
+		go s.publishFulfillment(ctx, *o, f, change.from, reason)
+	}
+
+	if change.orderChanged {
+		t := o.Transitions[len(o.Transitions)-1]
+		logger.InfoContext(ctx, "order status changed", "from", t.From.String(), "to", t.To.String())
+		s.publishes.Add(1)
+		go s.publishStatusChanged(ctx, *o, t)
+	}
+	return o, nil
+}
+
+func (s *OrderService) publishFulfillment(ctx context.Context, o order.Order, f order.Fulfillment, from order.OrderStatus, reason string) {
+	defer s.publishes.Done()
+
+	event := order.NewOrderFulfillmentEvent(o, f, from, reason)
+
+	msg, err := broker.NewMessage(event.EventType, event)
+	if err != nil {
+		return
+	}
+
+	msg.SetMetadata("order_id", o.ID)
+	msg.SetMetadata("customer_id", o.CustomerID)
+	msg.SetMetadata("fulfillment_id", f.ID)
+	msg.SetMetadata("status", f.Status.String())
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	s.broker.Publish(ctx, FulfillmentsTopic, msg)
+}
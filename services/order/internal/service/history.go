@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+)
+
+// CustomerSummary aggregates every order of a customer.
+type CustomerSummary struct {
+	CustomerID string `json:"customer_id"`
+	OrderCount int    `json:"order_count"`
+
+	// LifetimeSpendCents sums, per currency, the orders whose payment the
+	// customer still owes: paid, in fulfilment, delivered or disputed.
+	LifetimeSpendCents map[string]int64 `json:"lifetime_spend_cents"`
+
+	// LastOrderAt is when the newest order was created, nil without orders.
+	LastOrderAt *time.Time `json:"last_order_at,omitempty"`
+}
+
+// spent reports whether the total of an order in status counts as spend.
+func spent(status order.OrderStatus) bool {
+	switch status {
+	case order.OrderStatus_ORDER_STATUS_PAID,
+		order.OrderStatus_ORDER_STATUS_PROCESSING,
+		order.OrderStatus_ORDER_STATUS_SHIPPED,
+		order.OrderStatus_ORDER_STATUS_DELIVERED,
+		order.OrderStatus_ORDER_STATUS_DISPUTED:
+		return true
+	}
+	return false
+}
+
+type customerKey struct {
+	tenantID   string
+	customerID string
+}
+
+// indexedOrder keeps what CustomerSummary needs of an order.
+type indexedOrder struct {
+	status     order.OrderStatus
+	totalCents int64
+	currency   string
+	createdAt  time.Time
+}
+
+// customerIndex keeps the orders of customers by tenant. A customer is
+// loaded from the repository the first time it is summarized and kept up
+// to date by record from then on, so customers nobody asks about cost
+// nothing.
+type customerIndex struct {
+	mu        sync.Mutex
+	customers map[customerKey]map[string]indexedOrder
+}
+
+func newCustomerIndex() *customerIndex {
+	return &customerIndex{customers: make(map[customerKey]map[string]indexedOrder)}
+}
+
+// record updates the entry of o if its customer is loaded.
+func (x *customerIndex) record(o *order.Order) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if orders, ok := x.customers[customerKey{o.TenantID, o.CustomerID}]; ok {
+		orders[o.ID] = indexOrder(o)
+	}
+}
+
+// summary summarizes the orders of key, loading them with load first if
+// the customer is not indexed yet. The lock is held while loading so no
+// record is lost in between.
+func (x *customerIndex) summary(key customerKey, load func() ([]*order.Order, error)) (CustomerSummary, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	orders, ok := x.customers[key]
+	if !ok {
+		loaded, err := load()
+		if err != nil {
+			return CustomerSummary{}, err
+		}
+		orders = make(map[string]indexedOrder, len(loaded))
+		for _, o := range loaded {
+			orders[o.ID] = indexOrder(o)
+		}
+		x.customers[key] = orders
+	}
+
+	sum := CustomerSummary{
+		CustomerID:         key.customerID,
+		OrderCount:         len(orders),
+		LifetimeSpendCents: make(map[string]int64),
+	}
+	for _, o := range orders {
+		if spent(o.status) {
+			sum.LifetimeSpendCents[o.currency] += o.totalCents
+		}
+		if sum.LastOrderAt == nil || o.createdAt.After(*sum.LastOrderAt) {
+			createdAt := o.createdAt
+			sum.LastOrderAt = &createdAt
+		}
+	}
+	return sum, nil
+}
+
+func indexOrder(o *order.Order) indexedOrder {
+	return indexedOrder{
+		status:     o.Status,
+		totalCents: o.TotalCents,
+		currency:   o.Currency,
+		createdAt:  o.CreatedAt,
+	}
+}
+
+// CustomerSummary summarizes the orders of customerID in the tenant of ctx.
+// A customer without orders gets an empty summary.
+func (s *OrderService) CustomerSummary(ctx context.Context, customerID string) (CustomerSummary, error) {
+	key := customerKey{tenant.FromContext(ctx), customerID}
+	return s.customers.summary(key, func() ([]*order.Order, error) {
+		page, err := s.repo.List(ctx, ListOptions{CustomerID: customerID})
+		return page.Orders, err
+	})
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/retry"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/inventory"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+)
+
+// reserveStock holds the items of o in the inventory service. It returns
+// an empty ID when no inventory client is configured.
+func (s *OrderService) reserveStock(ctx context.Context, o *order.Order) (string, error) {
+	if s.inventoryClient == nil {
+		return "", nil
+	}
+
+	items := make([]*inventory.StockItem, len(o.Items))
+	for i, item := range o.Items {
+		items[i] = &inventory.StockItem{ProductID: item.ProductID, Quantity: item.Quantity}
+	}
+
+	// The order ID makes the reservation idempotent, so the call is retried
+	// like payment calls, without the payment circuit breaker.
+	var (
+		reservation *inventory.Reservation
+		err         error
+	)
+	for attempt := 1; attempt <= max(s.retry.MaxAttempts, 1); attempt++ {
+		callCtx, cancel := ctx, context.CancelFunc(func() {})
+		if s.retry.CallTimeout > 0 {
+			callCtx, cancel = context.WithTimeout(ctx, s.retry.CallTimeout)
+		}
+		reservation, err = s.inventoryClient.ReserveStock(callCtx, &inventory.ReserveStockRequest{
+			OrderID: o.ID,
+			Items:   items,
+		})
+		cancel()
+		if !retry.TransientRPC(err) {
+			break
+		}
+	}
+
+	if err != nil {
+		logger.WarnContext(ctx, "reserving stock failed", logging.Err(err))
+		if out := outOfStockFromStatus(err); out != nil {
+			return "", out
+		}
+		if violations := grpcmw.FieldViolations(err); len(violations) > 0 {
+			verr := &ValidationError{}
+			for _, v := range violations {
+				verr.add(v.Field, "%s", v.Description)
+			}
+			return "", verr
+		}
+		return "", ErrInventoryServiceUnavailable
+	}
+
+	logger.InfoContext(ctx, "reserved stock", "reservation_id", reservation.ReservationID)
+	return reservation.ReservationID, nil
+}
+
+// releaseReservation returns the stock held for o. Failures are logged and
+// otherwise ignored: a cancelled order must not stay open because the
+// inventory service is down.
+func (s *OrderService) releaseReservation(ctx context.Context, o *order.Order, reason string) {
+	if s.inventoryClient == nil || o.ReservationID == "" {
+		return
+	}
+
+	_, err := s.inventoryClient.ReleaseReservation(ctx, &inventory.ReleaseReservationRequest{
+		ReservationID: o.ReservationID,
+		Reason:        reason,
+	})
+	if err != nil {
+		logger.WarnContext(ctx, "releasing reservation failed", "reservation_id", o.ReservationID, logging.Err(err))
+		return
+	}
+	logger.InfoContext(ctx, "released reservation", "reservation_id", o.ReservationID)
+}
+
+// outOfStockFromStatus turns an INSUFFICIENT_STOCK error of the inventory
+// service into an OutOfStockError. It returns nil for other errors.
+func outOfStockFromStatus(err error) *OutOfStockError {
+	info, ok := grpcmw.Reason(err)
+	if !ok || info.Reason != "INSUFFICIENT_STOCK" {
+		return nil
+	}
+
+	// Metadata maps each short product to "requested/available".
+	out := &OutOfStockError{}
+	for product, counts := range info.Metadata {
+		requested, available, _ := strings.Cut(counts, "/")
+		r, _ := strconv.Atoi(requested)
+		a, _ := strconv.Atoi(available)
+		out.Items = append(out.Items, StockShortage{ProductID: product, Requested: int32(r), Available: int32(a)})
+	}
+	sort.Slice(out.Items, func(i, j int) bool {
+		return out.Items[i].ProductID < out.Items[j].ProductID
+	})
+	return out
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+)
+
+// StatusTopic receives an OrderStatusChangedEvent for every status
+// transition, including those made by cancellations and disputes.
+const StatusTopic = "order.status_changed"
+
+// transitions lists the legal status changes for an order.
+var transitions = map[order.OrderStatus][]order.OrderStatus{
+	order.OrderStatus_ORDER_STATUS_UNSPECIFIED: {
+		order.OrderStatus_ORDER_STATUS_PENDING,
+	},
+	order.OrderStatus_ORDER_STATUS_PENDING: {
+		order.OrderStatus_ORDER_STATUS_PAID,
+		order.OrderStatus_ORDER_STATUS_CANCELLED,
+	},
+	order.OrderStatus_ORDER_STATUS_PAID: {
+		order.OrderStatus_ORDER_STATUS_PROCESSING,
+		order.OrderStatus_ORDER_STATUS_CANCELLED,
+		order.OrderStatus_ORDER_STATUS_DISPUTED,
+	},
+	order.OrderStatus_ORDER_STATUS_PROCESSING: {
+		order.OrderStatus_ORDER_STATUS_SHIPPED,
+		order.OrderStatus_ORDER_STATUS_CANCELLED,
+	},
+	order.OrderStatus_ORDER_STATUS_SHIPPED: {
+		order.OrderStatus_ORDER_STATUS_DELIVERED,
+	},
+	order.OrderStatus_ORDER_STATUS_DISPUTED: {
+		order.OrderStatus_ORDER_STATUS_PAID,
+		order.OrderStatus_ORDER_STATUS_CHARGED_BACK,
+	},
+}
+
+func CanTransition(from, to order.OrderStatus) bool {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// manualTargets are the statuses UpdateOrderStatus may set. The others are
+// reached through payment, cancellation and dispute handling.
+var manualTargets = map[order.OrderStatus]bool{
+	order.OrderStatus_ORDER_STATUS_PROCESSING: true,
+	order.OrderStatus_ORDER_STATUS_SHIPPED:    true,
+	order.OrderStatus_ORDER_STATUS_DELIVERED:  true,
+}
+
+// UpdateOrderStatus moves an order through fulfilment: PAID to PROCESSING,
+// SHIPPED and DELIVERED. A non-zero version is the version the caller last
+// read; an order changed since returns a VersionConflictError.
+func (s *OrderService) UpdateOrderStatus(ctx context.Context, orderID string, status order.OrderStatus, reason string, version int64) (*order.Order, error) {
+	if !manualTargets[status] {
+		return nil, fmt.Errorf("%w: %s", ErrStatusNotSettable, status)
+	}
+	return s.transition(ctx, orderID, StatusUpdate{Status: status, Reason: reason, Version: version})
+}
+
+// transition applies update through the repository, which enforces the
+// transition graph, and publishes the resulting status change.
+func (s *OrderService) transition(ctx context.Context, orderID string, update StatusUpdate) (*order.Order, error) {
+	if update.At.IsZero() {
+		update.At = s.now()
+	}
+	o, err := s.repo.UpdateStatus(ctx, orderID, update)
+	if err != nil {
+		return nil, err
+	}
+	s.customers.record(o)
+
+	if n := len(o.Transitions); n > 0 {
+		t := o.Transitions[n-1]
+		logger.InfoContext(ctx, "order status changed", "from", t.From.String(), "to", t.To.String())
+		s.publishes.Add(1)
+		go s.publishStatusChanged(ctx, *o, t)
+	}
+	return o, nil
+}
+
+func (s *OrderService) publishStatusChanged(ctx context.Context, o order.Order, t order.OrderStatusTransition) {
+	defer s.publishes.Done()
+
+	event := order.NewOrderStatusChangedEvent(o, t)
+
+	msg, err := broker.NewMessage(event.EventType, event)
+	if err != nil {
+		return
+	}
+
+	msg.SetMetadata("order_id", o.ID)
+	msg.SetMetadata("customer_id", o.CustomerID)
+	msg.SetMetadata("status", t.To.String())
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	s.broker.Publish(ctx, StatusTopic, msg)
+}
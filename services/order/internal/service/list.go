@@ -0,0 +1,161 @@
+package service
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+)
+
+// SortField selects the order of ListOrders results. Ties are broken by ID.
+type SortField string
+
+const (
+	SortByCreatedAt SortField = "created_at"
+	SortByTotal     SortField = "total"
+)
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+)
+
+// ListOptions filters and pages ListOrders. Zero values mean "no filter".
+// Cursor and Offset are alternatives: a cursor from a previous page stays
+// stable while orders are added, an offset does not.
+type ListOptions struct {
+	Limit  int
+	Offset int
+	Cursor string
+
+	Status     order.OrderStatus
+	CustomerID string
+
+	// CreatedFrom is inclusive, CreatedTo exclusive.
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+
+	SortBy     SortField
+	Descending bool
+}
+
+// OrderPage is one page of ListOrders. NextCursor is empty on the last page.
+type OrderPage struct {
+	Orders     []*order.Order
+	NextCursor string
+}
+
+// normalize applies the defaults and rejects inconsistent options.
+func (o ListOptions) normalize() (ListOptions, error) {
+	if o.SortBy == "" {
+		o.SortBy = SortByCreatedAt
+	}
+	switch {
+	case o.SortBy != SortByCreatedAt && o.SortBy != SortByTotal:
+		return o, fmt.Errorf("%w: unknown sort field %q", ErrInvalidListOptions, o.SortBy)
+	case o.Limit < 0 || o.Offset < 0:
+		return o, fmt.Errorf("%w: limit and offset cannot be negative", ErrInvalidListOptions)
+	case o.Cursor != "" && o.Offset > 0:
+		return o, fmt.Errorf("%w: use either cursor or offset", ErrInvalidListOptions)
+	case !o.CreatedFrom.IsZero() && !o.CreatedTo.IsZero() && !o.CreatedFrom.Before(o.CreatedTo):
+		return o, fmt.Errorf("%w: from must be before to", ErrInvalidListOptions)
+	}
+	return o, nil
+}
+
+// listCursor points just past the last order of a page.
+type listCursor struct {
+	SortBy SortField `json:"s"`
+	Value  string    `json:"v"`
+	ID     string    `json:"id"`
+}
+
+func newListCursor(sortBy SortField, last *order.Order) string {
+	c := listCursor{SortBy: sortBy, ID: last.ID, Value: sortValue(sortBy, last)}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeListCursor(s string, sortBy SortField) (*listCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c listCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	if c.SortBy != sortBy {
+		return nil, fmt.Errorf("%w: cursor was issued for sort %q", ErrInvalidCursor, c.SortBy)
+	}
+	if sortBy == SortByTotal {
+		if _, err := strconv.ParseInt(c.Value, 10, 64); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return &c, nil
+}
+
+// sortValue renders the sort key of o as stored by SQLOrderRepository.
+func sortValue(sortBy SortField, o *order.Order) string {
+	if sortBy == SortByTotal {
+		return strconv.FormatInt(o.TotalCents, 10)
+	}
+	return o.CreatedAt.UTC().Format(sqlTimeLayout)
+}
+
+func (o ListOptions) matches(ord *order.Order) bool {
+	switch {
+	case o.Status != order.OrderStatus_ORDER_STATUS_UNSPECIFIED && ord.Status != o.Status:
+		return false
+	case o.CustomerID != "" && ord.CustomerID != o.CustomerID:
+		return false
+	case !o.CreatedFrom.IsZero() && ord.CreatedAt.Before(o.CreatedFrom):
+		return false
+	case !o.CreatedTo.IsZero() && !ord.CreatedAt.Before(o.CreatedTo):
+		return false
+	}
+	return true
+}
+
+// less orders a before b according to the sort options.
+func (o ListOptions) less(a, b *order.Order) bool {
+	var c int
+	if o.SortBy == SortByTotal {
+		c = cmp.Compare(a.TotalCents, b.TotalCents)
+	} else {
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if c == 0 {
+		c = cmp.Compare(a.ID, b.ID)
+	}
+	if o.Descending {
+		return c > 0
+	}
+	return c < 0
+}
+
+// after reports whether ord comes after the cursor position.
+func (o ListOptions) after(ord *order.Order, cur *listCursor) bool {
+	var c int
+	if o.SortBy == SortByTotal {
+		v, _ := strconv.ParseInt(cur.Value, 10, 64)
+		c = cmp.Compare(ord.TotalCents, v)
+	} else {
+		c = cmp.Compare(sortValue(SortByCreatedAt, ord), cur.Value)
+	}
+	if c == 0 {
+		c = cmp.Compare(ord.ID, cur.ID)
+	}
+	if o.Descending {
+		return c < 0
+	}
+	return c > 0
+}
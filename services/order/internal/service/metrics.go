@@ -0,0 +1,90 @@
+package service
+
+import (
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/circuitbreaker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/metrics"
+	"google.golang.org/grpc/status"
+)
+
+// Metrics are the order counters. A nil *Metrics records nothing.
+type Metrics struct {
+	created      *metrics.CounterVec
+	declined     *metrics.CounterVec
+	expired      *metrics.Counter
+	paymentCalls *metrics.HistogramVec
+}
+
+// NewMetrics registers the order metrics on r.
+func NewMetrics(r *metrics.Registry) *Metrics {
+	return &Metrics{
+		created: r.NewCounterVec("orders_created_total",
+			"Orders created and paid, by currency.",
+			"currency"),
+		declined: r.NewCounterVec("orders_declined_total",
+			"Orders cancelled because the payment was declined, by error code.",
+			"error_code"),
+		expired: r.NewCounter("orders_expired_total",
+			"Orders cancelled because they stayed PENDING for too long."),
+		paymentCalls: r.NewHistogramVec("order_payment_call_duration_seconds",
+			"Duration of each payment service call attempt, by method and status code.",
+			metrics.DefBuckets, "method", "code"),
+	}
+}
+
+// WithMetrics records order and payment call metrics in m.
+func WithMetrics(m *Metrics) Option {
+	return func(s *OrderService) {
+		s.metrics = m
+	}
+}
+
+// RegisterGauges exposes the state of the payment circuit breaker on r, one
+// series per state with the current one set to 1, and the number of orders
+// deferred until payment recovers.
+func (s *OrderService) RegisterGauges(r *metrics.Registry) {
+	r.NewGaugeVecFunc("order_payment_circuit_state",
+		"State of the payment circuit breaker: 1 for the current state, 0 otherwise.",
+		func(emit func(float64, ...string)) {
+			current := s.breaker.State()
+			for _, state := range circuitbreaker.States {
+				value := 0.0
+				if state == current {
+					value = 1
+				}
+				emit(value, state.String())
+			}
+		}, "state")
+	r.NewGaugeFunc("order_payment_deferred",
+		"Orders queued for payment until the payment service recovers.",
+		func() float64 { return float64(s.DeferredPayments()) })
+}
+
+func (m *Metrics) orderCreated(currency string) {
+	if m == nil {
+		return
+	}
+	m.created.WithLabelValues(currency).Inc()
+}
+
+func (m *Metrics) orderDeclined(code string) {
+	if m == nil {
+		return
+	}
+	m.declined.WithLabelValues(code).Inc()
+}
+
+func (m *Metrics) orderExpired() {
+	if m == nil {
+		return
+	}
+	m.expired.Inc()
+}
+
+func (m *Metrics) paymentCall(method string, err error, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	m.paymentCalls.WithLabelValues(method, status.Code(err).String()).Observe(elapsed.Seconds())
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+)
+
+// CreatePendingOrder validates, prices and stores req as a PENDING order
+// without reserving its stock or charging it. It serves the saga
+// orchestrator, which reserves and charges through the inventory and
+// payment services itself and reports the payment with RecordPayment.
+// Unless it does, the order expires like any other pending order.
+//
+// req.ID is required and makes the call idempotent: when an order with
+// that ID exists it is returned as is.
+func (s *OrderService) CreatePendingOrder(ctx context.Context, req CreateOrderRequest) (*order.Order, error) {
+	existing, err := s.repo.Get(ctx, req.ID)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, ErrOrderNotFound) {
+		return nil, err
+	}
+	return s.prepareOrder(ctx, req, false)
+}
+
+// RecordPayment marks a PENDING order PAID with a transaction charged by
+// someone else and publishes its creation, as a payment made by CreateOrder
+// would. Recording the transaction an order was already paid with again
+// returns the order unchanged.
+func (s *OrderService) RecordPayment(ctx context.Context, orderID, transactionID string) (*order.Order, error) {
+	ctx = logging.WithAttrs(ctx, "order_id", orderID)
+
+	o, err := s.repo.Get(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if o.Status == order.OrderStatus_ORDER_STATUS_PAID && o.PaymentTransactionID == transactionID {
+		return o, nil
+	}
+	return s.markPaid(ctx, orderID, transactionID)
+}
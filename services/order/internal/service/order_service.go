@@ -2,111 +2,408 @@ package service
 
 import (
 	"context"
-	"log"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/circuitbreaker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/events"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/catalog"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/customer"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/inventory"
 	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
 	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
 	"github.com/google/uuid"
 )
 
+// DefaultCurrency is used for orders that name no currency, unless the
+// customer profile prefers another.
+const DefaultCurrency = "BRL"
+
+var logger = logging.Component("order")
+
 type OrderService struct {
-	mu            sync.RWMutex
-	orders        map[string]*order.Order
-	paymentClient payment.PaymentServiceClient
-	broker        *broker.Broker
-	topicName     string
+	repo            OrderRepository
+	payments        PaymentCharger
+	inventoryClient inventory.InventoryServiceClient
+	customerClient  customer.CustomerServiceClient
+	catalogClient   catalog.CatalogServiceClient
+	priceCheck      PriceCheckConfig
+	broker          broker.Publisher
+	topicName       string
+	retry           RetryConfig
+	breaker         *circuitbreaker.Breaker
+	validation      ValidationConfig
+	pricing         PricingConfig
+	metrics         *Metrics
+	customers       *customerIndex
+	windows         *windowedStats
+	degradation     DegradationConfig
+	now             func() time.Time
+
+	// deferred counts the orders queued on PaymentRetriesTopic.
+	deferred atomic.Int64
+
+	// publishes tracks events still being published in the background.
+	publishes sync.WaitGroup
+}
+
+type Option func(*OrderService)
+
+// WithRepository replaces the default in-memory order repository.
+func WithRepository(r OrderRepository) Option {
+	return func(s *OrderService) {
+		s.repo = r
+	}
+}
+
+// WithRetry replaces DefaultRetryConfig for payment calls.
+func WithRetry(config RetryConfig) Option {
+	return func(s *OrderService) {
+		s.retry = config
+	}
+}
+
+// WithCircuitBreaker replaces the default payment circuit breaker.
+func WithCircuitBreaker(b *circuitbreaker.Breaker) Option {
+	return func(s *OrderService) {
+		s.breaker = b
+	}
+}
+
+// WithValidation replaces DefaultValidationConfig for CreateOrder.
+func WithValidation(config ValidationConfig) Option {
+	return func(s *OrderService) {
+		s.validation = config
+	}
+}
+
+// WithPricing replaces DefaultPricingConfig for CreateOrder.
+func WithPricing(config PricingConfig) Option {
+	return func(s *OrderService) {
+		s.pricing = config
+	}
+}
+
+// WithInventory reserves stock for every new order before it is paid. Without
+// it orders are not checked against the inventory.
+func WithInventory(client inventory.InventoryServiceClient) Option {
+	return func(s *OrderService) {
+		s.inventoryClient = client
+	}
+}
+
+// WithCustomers checks the customer_id of every new order against the
+// customer service and stores the profile with the order. Without it the
+// customer fields of the request are taken as given.
+func WithCustomers(client customer.CustomerServiceClient) Option {
+	return func(s *OrderService) {
+		s.customerClient = client
+	}
 }
 
+// WithCatalog checks the product IDs of every new order against the
+// catalog service and reconciles the unit prices with the catalog prices as
+// cfg sets. Without it the prices of the request are taken as given.
+func WithCatalog(client catalog.CatalogServiceClient, cfg PriceCheckConfig) Option {
+	return func(s *OrderService) {
+		s.catalogClient = client
+		s.priceCheck = cfg
+	}
+}
+
+// WithClock replaces time.Now for the creation and status change times of
+// orders. Retries, the circuit breaker and the sweeper keep real time.
+func WithClock(now func() time.Time) Option {
+	return func(s *OrderService) {
+		s.now = now
+	}
+}
+
+// NewOrderService creates a service charging orders through payments and
+// publishing their events through b, the creation of paid orders on
+// topicName. Use GRPCPayments to charge through the gRPC payment client.
 func NewOrderService(
-	paymentClient payment.PaymentServiceClient,
-	b *broker.Broker,
+	payments PaymentCharger,
+	b broker.Publisher,
 	topicName string,
+	opts ...Option,
 ) *OrderService {
-	return &OrderService{
-		orders:        make(map[string]*order.Order),
-		paymentClient: paymentClient,
-		broker:        b,
-		topicName:     topicName,
+	s := &OrderService{
+		repo:        NewInMemoryOrderRepository(),
+		payments:    payments,
+		broker:      b,
+		topicName:   topicName,
+		retry:       DefaultRetryConfig(),
+		breaker:     circuitbreaker.New("payment", circuitbreaker.DefaultConfig()),
+		validation:  DefaultValidationConfig(),
+		pricing:     DefaultPricingConfig(),
+		customers:   newCustomerIndex(),
+		windows:     newWindowedStats(),
+		degradation: DefaultDegradationConfig(),
+		now:         time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Flush waits until the events published in the background by earlier
+// calls have reached the broker, or until ctx is done. Call it after the
+// HTTP server stopped accepting requests so no new publishes start.
+func (s *OrderService) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.publishes.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
 type CreateOrderRequest struct {
+	// ID names the new order; empty generates one.
+	ID string
+
 	CustomerID    string
 	CustomerEmail string
 	Items         []order.OrderItem
 	Currency      string
+	CouponCodes   []string
+
+	// ShippingAddress defaults to the default address of the customer
+	// profile.
+	ShippingAddress *order.Address
 }
 
+// CreateOrder stores the order and charges it before returning. The order
+// is PAID on success; a failed or declined payment cancels it.
 func (s *OrderService) CreateOrder(ctx context.Context, req CreateOrderRequest) (*order.Order, error) {
-	if len(req.Items) == 0 {
-		return nil, ErrNoItems
+	newOrder, err := s.prepareOrder(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+	return s.chargeOrder(logging.WithAttrs(ctx, "order_id", newOrder.ID), newOrder, false)
+}
+
+// prepareOrder validates and prices req and stores it as a PENDING order.
+// With charge set it also reserves the stock of the order and fails fast
+// while the payment circuit is open, as the order is about to be charged,
+// unless the degradation policy queues it for payment.
+func (s *OrderService) prepareOrder(ctx context.Context, req CreateOrderRequest, charge bool) (*order.Order, error) {
+	snapshot, err := s.resolveCustomer(ctx, &req)
+	if err != nil {
+		return nil, err
 	}
-	if req.CustomerEmail == "" {
-		return nil, ErrMissingEmail
+	if req.Currency == "" {
+		req.Currency = DefaultCurrency
 	}
 
-	var totalCents int64
-	for _, item := range req.Items {
-		totalCents += item.UnitPriceCents * int64(item.Quantity)
+	req.Currency = strings.ToUpper(req.Currency)
+	if req.ShippingAddress == nil && snapshot != nil {
+		req.ShippingAddress = snapshot.ShippingAddress
+	}
+	if err := s.validation.validate(req); err != nil {
+		return nil, err
+	}
+	corrected, err := s.reconcilePrices(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+	// Corrected prices may take the order past the limits.
+	if corrected {
+		if err := s.validation.validate(req); err != nil {
+			return nil, err
+		}
+	}
+	pricing, err := s.pricing.price(req.Currency, req.Items, req.CouponCodes, req.ShippingAddress)
+	if err != nil {
+		return nil, err
+	}
+	totalCents := pricing.TotalCents
+
+	// Fail fast instead of storing an order that is bound to be cancelled.
+	if charge && !s.breaker.Available() && !s.canDefer() {
+		return nil, ErrPaymentServiceUnavailable
 	}
 
-	now := time.Now()
+	if req.ID == "" {
+		req.ID = "ord_" + uuid.New().String()[:8]
+	}
+	now := s.now()
 	newOrder := &order.Order{
-		ID:            "ord_" + uuid.New().String()[:8],
-		CustomerID:    req.CustomerID,
-		CustomerEmail: req.CustomerEmail,
-		Items:         req.Items,
-		TotalCents:    totalCents,
-		Currency:      req.Currency,
-		Status:        order.OrderStatus_ORDER_STATUS_PENDING,
-		CreatedAt:     now,
-		UpdatedAt:     now,
-	}
-
-	s.mu.Lock()
-	s.orders[newOrder.ID] = newOrder
-	s.mu.Unlock()
-
-	paymentResp, err := s.paymentClient.ProcessPayment(ctx, &payment.PaymentRequest{
-		IdempotencyKey: newOrder.ID,
-		OrderID:        newOrder.ID,
-		AmountCents:    totalCents,
-		Currency:       req.Currency,
-		CustomerEmail:  req.CustomerEmail,
-	})
+		ID:              req.ID,
+		TenantID:        tenant.FromContext(ctx),
+		CustomerID:      req.CustomerID,
+		CustomerEmail:   req.CustomerEmail,
+		Items:           req.Items,
+		TotalCents:      totalCents,
+		Currency:        req.Currency,
+		Status:          order.OrderStatus_ORDER_STATUS_PENDING,
+		Version:         1,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		Customer:        snapshot,
+		ShippingAddress: req.ShippingAddress,
+		Pricing:         pricing,
+		Transitions: []order.OrderStatusTransition{{
+			From:   order.OrderStatus_ORDER_STATUS_UNSPECIFIED,
+			To:     order.OrderStatus_ORDER_STATUS_PENDING,
+			At:     now,
+			Reason: "order created",
+		}},
+	}
+	syncItemStatus(newOrder)
+
+	ctx = logging.WithAttrs(ctx, "order_id", newOrder.ID)
+
+	if charge {
+		newOrder.ReservationID, err = s.reserveStock(ctx, newOrder)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.Create(ctx, newOrder); err != nil {
+		logger.ErrorContext(ctx, "storing order failed", logging.Err(err))
+		s.releaseReservation(ctx, newOrder, "order not stored")
+		return nil, err
+	}
+	s.customers.record(newOrder)
+	return newOrder, nil
+}
+
+// chargeOrder processes the payment of a stored PENDING order and moves it
+// to PAID, or to CANCELLED with its reservation released.
+//
+// A retriable decline whose hinted wait fits RetryConfig.DeclineWait is
+// waited out and the order charged once more under the idempotency key
+// declineRetryKey, before giving up.
+//
+// Under the queue degradation policy a payment call that fails, rather
+// than being declined, defers the order to PaymentRetriesTopic and returns
+// it PENDING. Charging a deferred order again leaves it PENDING on such a
+// failure, for the message to be retried.
+func (s *OrderService) chargeOrder(ctx context.Context, newOrder *order.Order, deferred bool) (*order.Order, error) {
+	// The order ID is the idempotency key, so retries cannot charge twice.
+	paymentResp, err := s.processPayment(ctx, newOrder, newOrder.ID)
+	if err == nil && !paymentResp.Success {
+		hint := declinedFromResponse(paymentResp, s.now())
+		if wait, ok := s.retry.declineRetry(ctx, hint); ok {
+			logger.InfoContext(ctx, "payment declined, charging again", "code", hint.Code, "retry_in", wait.String())
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+			case <-timer.C:
+				paymentResp, err = s.processPayment(ctx, newOrder, declineRetryKey(newOrder.ID))
+			}
+			timer.Stop()
+		}
+	}
 
 	if err != nil {
-		log.Printf("[ORDER] gRPC error calling Payment service: %v", err)
-		s.updateOrderStatus(newOrder.ID, order.OrderStatus_ORDER_STATUS_CANCELLED)
-		return nil, ErrPaymentServiceUnavailable
+		logger.WarnContext(ctx, "payment call failed", logging.Err(err))
+		declined := declinedFromStatus(err)
+		if declined == nil {
+			if deferred {
+				return nil, paymentFailure(err)
+			}
+			if s.canDefer() {
+				return s.deferPayment(ctx, newOrder)
+			}
+		}
+		reason := "payment failed"
+		if declined != nil {
+			reason = "payment declined: " + declined.Code
+		}
+		s.updateOrderStatus(ctx, newOrder.ID, order.OrderStatus_ORDER_STATUS_CANCELLED, reason)
+		s.releaseReservation(ctx, newOrder, "payment failed")
+		if declined != nil {
+			s.metrics.orderDeclined(declined.Code)
+			s.windows.record(newOrder.TenantID, 0, true)
+			return nil, declined
+		}
+		return nil, paymentFailure(err)
 	}
 
 	if !paymentResp.Success {
-		s.updateOrderStatus(newOrder.ID, order.OrderStatus_ORDER_STATUS_CANCELLED)
-		return nil, &PaymentDeclinedError{
-			Code:    paymentResp.ErrorCode.String(),
-			Message: paymentResp.ErrorMessage,
-		}
+		// The error code lets consumers of order.status_changed, such as
+		// the analytics service, tell why payments are declined.
+		s.updateOrderStatus(ctx, newOrder.ID, order.OrderStatus_ORDER_STATUS_CANCELLED, "payment declined: "+paymentResp.ErrorCode.String())
+		s.releaseReservation(ctx, newOrder, "payment declined")
+		s.metrics.orderDeclined(paymentResp.ErrorCode.String())
+		s.windows.record(newOrder.TenantID, 0, true)
+		return nil, declinedFromResponse(paymentResp, s.now())
 	}
 
-	s.mu.Lock()
-	newOrder.Status = order.OrderStatus_ORDER_STATUS_PAID
-	newOrder.PaymentTransactionID = paymentResp.TransactionID
-	newOrder.UpdatedAt = time.Now()
-	s.mu.Unlock()
+	return s.markPaid(ctx, newOrder.ID, paymentResp.TransactionID)
+}
+
+// processPayment charges o under the idempotency key key.
+func (s *OrderService) processPayment(ctx context.Context, o *order.Order, key string) (*payment.PaymentResponse, error) {
+	var paymentResp *payment.PaymentResponse
+	err := s.callPayment(ctx, "ProcessPayment", true, func(ctx context.Context) error {
+		var err error
+		paymentResp, err = s.payments.ProcessPayment(ctx, &payment.PaymentRequest{
+			IdempotencyKey: key,
+			OrderID:        o.ID,
+			AmountCents:    o.TotalCents,
+			Currency:       o.Currency,
+			CustomerEmail:  o.CustomerEmail,
+		})
+		return err
+	})
+	return paymentResp, err
+}
 
-	go s.publishOrderCreated(newOrder)
+// declineRetryKey is the idempotency key of the second charge of an order
+// after a retriable decline. The payment service keeps the decline under
+// the order ID, and a redelivered request charging the order again finds
+// both outcomes under the same keys.
+func declineRetryKey(orderID string) string {
+	return orderID + "/retry"
+}
 
-	return newOrder, nil
+// markPaid moves a PENDING order to PAID with the transaction that charged
+// it and publishes its creation.
+func (s *OrderService) markPaid(ctx context.Context, orderID, transactionID string) (*order.Order, error) {
+	paid, err := s.transition(ctx, orderID, StatusUpdate{
+		Status:               order.OrderStatus_ORDER_STATUS_PAID,
+		PaymentTransactionID: transactionID,
+		Reason:               "payment " + transactionID,
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "order was paid but could not be updated",
+			"transaction_id", transactionID, logging.Err(err))
+		return nil, err
+	}
+
+	s.metrics.orderCreated(paid.Currency)
+	s.windows.record(paid.TenantID, paid.TotalCents, false)
+	s.publishes.Add(1)
+	go s.publishOrderCreated(ctx, paid)
+
+	return paid, nil
 }
 
-func (s *OrderService) publishOrderCreated(o *order.Order) {
-	event := order.NewOrderCreatedEvent(*o)
+func (s *OrderService) publishOrderCreated(ctx context.Context, o *order.Order) {
+	defer s.publishes.Done()
+
+	event := events.NewOrderCreatedV1(*o)
 
-	msg, err := broker.NewMessage("order.created", event)
+	msg, err := events.NewMessage(&event)
 	if err != nil {
 		return
 	}
@@ -114,73 +411,54 @@ func (s *OrderService) publishOrderCreated(o *order.Order) {
 	msg.SetMetadata("order_id", o.ID)
 	msg.SetMetadata("customer_email", o.CustomerEmail)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
 	defer cancel()
 
 	s.broker.Publish(ctx, s.topicName, msg)
 }
 
-func (s *OrderService) updateOrderStatus(orderID string, status order.OrderStatus) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if o, ok := s.orders[orderID]; ok {
-		o.Status = status
-		o.UpdatedAt = time.Now()
+func (s *OrderService) updateOrderStatus(ctx context.Context, orderID string, status order.OrderStatus, reason string) {
+	if _, err := s.transition(ctx, orderID, StatusUpdate{Status: status, Reason: reason}); err != nil {
+		logger.ErrorContext(ctx, "setting order status failed", "status", status.String(), logging.Err(err))
 	}
 }
 
 func (s *OrderService) GetOrder(ctx context.Context, orderID string) (*order.Order, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	o, ok := s.orders[orderID]
-	if !ok {
-		return nil, ErrOrderNotFound
-	}
-
-	return o, nil
+	return s.repo.Get(ctx, orderID)
 }
 
-func (s *OrderService) ListOrders(ctx context.Context) ([]*order.Order, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	orders := make([]*order.Order, 0, len(s.orders))
-	for _, o := range s.orders {
-		orders = append(orders, o)
+// ListOrders returns one page of orders. The limit defaults to
+// DefaultListLimit and is capped at MaxListLimit.
+func (s *OrderService) ListOrders(ctx context.Context, opts ListOptions) (OrderPage, error) {
+	if opts.Limit == 0 {
+		opts.Limit = DefaultListLimit
 	}
-
-	return orders, nil
+	opts.Limit = min(opts.Limit, MaxListLimit)
+	return s.repo.List(ctx, opts)
 }
 
-func (s *OrderService) Stats() OrderStats {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// ExportOrders calls fn with every order matching opts, see
+// OrderRepository.Export.
+func (s *OrderService) ExportOrders(ctx context.Context, opts ListOptions, fn func(*order.Order) error) error {
+	return s.repo.Export(ctx, opts, fn)
+}
 
-	stats := OrderStats{
-		TotalOrders: len(s.orders),
-	}
-
-	for _, o := range s.orders {
-		switch o.Status {
-		case order.OrderStatus_ORDER_STATUS_PAID:
-			stats.PaidOrders++
-			stats.TotalRevenueCents += o.TotalCents
-		case order.OrderStatus_ORDER_STATUS_CANCELLED:
-			stats.CancelledOrders++
-		case order.OrderStatus_ORDER_STATUS_PENDING:
-			stats.PendingOrders++
-		}
+// SearchOrders runs a support search. A zero Limit returns
+// DefaultListLimit orders.
+func (s *OrderService) SearchOrders(ctx context.Context, q SearchQuery) ([]*order.Order, error) {
+	if q.Limit == 0 {
+		q.Limit = DefaultListLimit
 	}
+	q.Limit = min(q.Limit, MaxListLimit)
+	return s.repo.Search(ctx, q)
+}
 
-	return stats
+// CountOrders returns the number of orders stored for the tenant of ctx.
+func (s *OrderService) CountOrders(ctx context.Context) (int, error) {
+	return s.repo.Count(ctx)
 }
 
-type OrderStats struct {
-	TotalOrders       int
-	PaidOrders        int
-	CancelledOrders   int
-	PendingOrders     int
-	TotalRevenueCents int64
+// PaymentCircuit returns the state of the payment circuit breaker.
+func (s *OrderService) PaymentCircuit() circuitbreaker.State {
+	return s.breaker.State()
 }
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+)
+
+// PaymentCharger is what OrderService needs from the payment service:
+// charging orders, compensating the charge and handling disputes. It names
+// no transport, so the service can be wired to the gRPC client with
+// GRPCPayments, to another transport or to a fake in unit tests.
+//
+// Rejections are read from the errors as gRPC statuses, with the codes and
+// details pkg/grpcmw produces; other errors count as the payment service
+// being unavailable. Implementations over other transports convert their
+// rejections accordingly.
+type PaymentCharger interface {
+	ProcessPayment(ctx context.Context, req *payment.PaymentRequest) (*payment.PaymentResponse, error)
+	CancelPayment(ctx context.Context, req *payment.CancelPaymentRequest) (*payment.PaymentStatusResponse, error)
+	RefundPayment(ctx context.Context, req *payment.RefundPaymentRequest) (*payment.PaymentStatusResponse, error)
+	CreateDispute(ctx context.Context, req *payment.CreateDisputeRequest) (*payment.Dispute, error)
+	ResolveDispute(ctx context.Context, req *payment.ResolveDisputeRequest) (*payment.Dispute, error)
+}
+
+// GRPCPayments is a PaymentCharger calling the payment service through
+// client.
+func GRPCPayments(client payment.PaymentServiceClient) PaymentCharger {
+	return grpcPayments{client: client}
+}
+
+type grpcPayments struct {
+	client payment.PaymentServiceClient
+}
+
+func (p grpcPayments) ProcessPayment(ctx context.Context, req *payment.PaymentRequest) (*payment.PaymentResponse, error) {
+	return p.client.ProcessPayment(ctx, req)
+}
+
+func (p grpcPayments) CancelPayment(ctx context.Context, req *payment.CancelPaymentRequest) (*payment.PaymentStatusResponse, error) {
+	return p.client.CancelPayment(ctx, req)
+}
+
+func (p grpcPayments) RefundPayment(ctx context.Context, req *payment.RefundPaymentRequest) (*payment.PaymentStatusResponse, error) {
+	return p.client.RefundPayment(ctx, req)
+}
+
+func (p grpcPayments) CreateDispute(ctx context.Context, req *payment.CreateDisputeRequest) (*payment.Dispute, error) {
+	return p.client.CreateDispute(ctx, req)
+}
+
+func (p grpcPayments) ResolveDispute(ctx context.Context, req *payment.ResolveDisputeRequest) (*payment.Dispute, error) {
+	return p.client.ResolveDispute(ctx, req)
+}
@@ -0,0 +1,343 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/money"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+)
+
+// PricingConfig holds the discount, coupon and tax rules CreateOrder
+// applies. Rates are in basis points: 1000 is 10%.
+type PricingConfig struct {
+	LineDiscounts []LineDiscount
+
+	// Coupons are keyed by upper case code.
+	Coupons map[string]Coupon
+
+	TaxRates []TaxRate
+}
+
+// LineDiscount takes PercentBps off an item bought at least MinQuantity
+// times. An empty ProductID matches every product.
+type LineDiscount struct {
+	ProductID   string
+	MinQuantity int32
+	PercentBps  int64
+}
+
+// Coupon is an order level discount of either PercentBps of the amount
+// left after line discounts or a fixed AmountCents.
+type Coupon struct {
+	Code             string
+	PercentBps       int64
+	AmountCents      int64
+	MinSubtotalCents int64
+}
+
+func (c Coupon) description() string {
+	var d string
+	if c.PercentBps > 0 {
+		d = formatPercent(c.PercentBps) + " off"
+	} else {
+		d = fmt.Sprintf("%s off", formatCents(c.AmountCents))
+	}
+	if c.MinSubtotalCents > 0 {
+		d += fmt.Sprintf(" orders of %s or more", formatCents(c.MinSubtotalCents))
+	}
+	return d
+}
+
+// TaxRate applies to shipping addresses in Region: a country code such as
+// "BR", or a country and state such as "BR-SP". The most specific rule
+// wins.
+type TaxRate struct {
+	Region  string
+	RateBps int64
+}
+
+func DefaultPricingConfig() PricingConfig {
+	return PricingConfig{
+		Coupons: map[string]Coupon{
+			"WELCOME10": {Code: "WELCOME10", PercentBps: 1000},
+			"SAVE20":    {Code: "SAVE20", AmountCents: 2000, MinSubtotalCents: 10000},
+		},
+		TaxRates: []TaxRate{
+			{Region: "BR", RateBps: 1700},
+			{Region: "BR-SP", RateBps: 1800},
+			{Region: "US-CA", RateBps: 725},
+			{Region: "US-NY", RateBps: 400},
+			{Region: "DE", RateBps: 1900},
+		},
+	}
+}
+
+// ParseLineDiscounts parses comma separated "[product_id:]min_quantity:percent"
+// entries such as "10:5,laptop:2:10".
+func ParseLineDiscounts(spec string) ([]LineDiscount, error) {
+	var discounts []LineDiscount
+	for _, entry := range splitSpec(spec) {
+		parts := strings.Split(entry, ":")
+		d := LineDiscount{}
+		if len(parts) == 3 {
+			d.ProductID, parts = parts[0], parts[1:]
+		}
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid line discount %q, expected [product_id:]min_quantity:percent", entry)
+		}
+		qty, err := strconv.ParseInt(parts[0], 10, 32)
+		if err != nil || qty < 1 {
+			return nil, fmt.Errorf("invalid line discount %q: bad minimum quantity", entry)
+		}
+		d.MinQuantity = int32(qty)
+		if d.PercentBps, err = parsePercent(parts[1]); err != nil || d.PercentBps > 10000 {
+			return nil, fmt.Errorf("invalid line discount %q: bad percentage", entry)
+		}
+		discounts = append(discounts, d)
+	}
+	return discounts, nil
+}
+
+// ParseCoupons parses comma separated "CODE:value[:min_subtotal_cents]"
+// entries. A value ending in % is a percentage, otherwise an amount in
+// cents: "WELCOME10:10%,SAVE20:2000:10000".
+func ParseCoupons(spec string) (map[string]Coupon, error) {
+	coupons := make(map[string]Coupon)
+	for _, entry := range splitSpec(spec) {
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid coupon %q, expected CODE:value[:min_subtotal_cents]", entry)
+		}
+		c := Coupon{Code: strings.ToUpper(parts[0])}
+		var err error
+		if pct, ok := strings.CutSuffix(parts[1], "%"); ok {
+			c.PercentBps, err = parsePercent(pct)
+			if c.PercentBps > 10000 {
+				err = fmt.Errorf("over 100%%")
+			}
+		} else {
+			c.AmountCents, err = strconv.ParseInt(parts[1], 10, 64)
+		}
+		if err != nil || c.PercentBps+c.AmountCents <= 0 {
+			return nil, fmt.Errorf("invalid coupon %q: bad value", entry)
+		}
+		if len(parts) == 3 {
+			if c.MinSubtotalCents, err = strconv.ParseInt(parts[2], 10, 64); err != nil || c.MinSubtotalCents < 0 {
+				return nil, fmt.Errorf("invalid coupon %q: bad minimum subtotal", entry)
+			}
+		}
+		coupons[c.Code] = c
+	}
+	return coupons, nil
+}
+
+// ParseTaxRates parses comma separated "region:percent" entries such as
+// "BR-SP:18,US-CA:7.25".
+func ParseTaxRates(spec string) ([]TaxRate, error) {
+	var rates []TaxRate
+	for _, entry := range splitSpec(spec) {
+		region, pct, ok := strings.Cut(entry, ":")
+		bps, err := parsePercent(pct)
+		if !ok || region == "" || err != nil {
+			return nil, fmt.Errorf("invalid tax rate %q, expected region:percent", entry)
+		}
+		rates = append(rates, TaxRate{Region: strings.ToUpper(region), RateBps: bps})
+	}
+	return rates, nil
+}
+
+func splitSpec(spec string) []string {
+	var entries []string
+	for _, entry := range strings.Split(spec, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func parsePercent(s string) (int64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid percentage %q", s)
+	}
+	return int64(math.Round(f * 100)), nil
+}
+
+func formatPercent(bps int64) string {
+	return strconv.FormatFloat(float64(bps)/100, 'f', -1, 64) + "%"
+}
+
+func formatCents(cents int64) string {
+	return money.FormatMinor(cents, 2)
+}
+
+// price computes the breakdown of items priced in currency. Invalid coupon
+// codes and totals too large to represent are reported as a
+// ValidationError. The tax rate is taken from address, which may be nil.
+func (c PricingConfig) price(currency string, items []order.OrderItem, couponCodes []string, address *order.Address) (*order.PriceBreakdown, error) {
+	b := &order.PriceBreakdown{Lines: make([]order.PriceLine, len(items))}
+
+	// Discounts never exceed what they apply to, so only the subtotals and
+	// the tax can overflow.
+	subtotal := money.New(0, currency)
+	var afterLines int64
+	for i, item := range items {
+		lineSubtotal, err := money.New(item.UnitPriceCents, currency).Mul(int64(item.Quantity))
+		if err == nil {
+			subtotal, err = subtotal.Add(lineSubtotal)
+		}
+		if err != nil {
+			return nil, totalTooLarge()
+		}
+		line := order.PriceLine{
+			ProductID:      item.ProductID,
+			Quantity:       item.Quantity,
+			UnitPriceCents: item.UnitPriceCents,
+			SubtotalCents:  lineSubtotal.Amount,
+		}
+		if d, ok := c.lineDiscount(item); ok {
+			line.DiscountCents = applyBps(line.SubtotalCents, d.PercentBps)
+			line.Discount = fmt.Sprintf("%s off %d or more", formatPercent(d.PercentBps), d.MinQuantity)
+		}
+		line.TotalCents = line.SubtotalCents - line.DiscountCents
+
+		b.Lines[i] = line
+		b.DiscountCents += line.DiscountCents
+		afterLines += line.TotalCents
+	}
+	b.SubtotalCents = subtotal.Amount
+
+	verr := &ValidationError{}
+	remaining := afterLines
+	seen := make(map[string]bool, len(couponCodes))
+	for i, code := range couponCodes {
+		field := fmt.Sprintf("coupon_codes[%d]", i)
+		code = strings.ToUpper(strings.TrimSpace(code))
+		coupon, ok := c.Coupons[code]
+		switch {
+		case !ok:
+			verr.add(field, "is not a valid coupon")
+			continue
+		case seen[code]:
+			verr.add(field, "is used more than once")
+			continue
+		case afterLines < coupon.MinSubtotalCents:
+			verr.add(field, "requires a subtotal of at least %s", formatCents(coupon.MinSubtotalCents))
+			continue
+		}
+		seen[code] = true
+
+		discount := coupon.AmountCents
+		if coupon.PercentBps > 0 {
+			discount = applyBps(remaining, coupon.PercentBps)
+		}
+		discount = min(discount, remaining)
+		remaining -= discount
+		b.DiscountCents += discount
+		b.Coupons = append(b.Coupons, order.AppliedCoupon{
+			Code:          coupon.Code,
+			Description:   coupon.description(),
+			DiscountCents: discount,
+		})
+	}
+	if len(verr.Fields) > 0 {
+		return nil, verr
+	}
+
+	if rate, ok := c.taxRate(address); ok {
+		b.TaxRegion = rate.Region
+		b.TaxRateBps = rate.RateBps
+		b.TaxCents = applyBpsRounded(remaining, rate.RateBps)
+	}
+	total, err := money.New(remaining, currency).Add(money.New(b.TaxCents, currency))
+	if err != nil {
+		return nil, totalTooLarge()
+	}
+	b.TotalCents = total.Amount
+	return b, nil
+}
+
+func totalTooLarge() *ValidationError {
+	verr := &ValidationError{}
+	verr.add("total_cents", "is too large")
+	return verr
+}
+
+// lineDiscount returns the largest discount that applies to item.
+func (c PricingConfig) lineDiscount(item order.OrderItem) (LineDiscount, bool) {
+	var best LineDiscount
+	found := false
+	for _, d := range c.LineDiscounts {
+		if d.ProductID != "" && d.ProductID != item.ProductID {
+			continue
+		}
+		if item.Quantity < d.MinQuantity {
+			continue
+		}
+		if !found || d.PercentBps > best.PercentBps {
+			best, found = d, true
+		}
+	}
+	return best, found
+}
+
+// taxRate matches "COUNTRY-STATE" before "COUNTRY".
+func (c PricingConfig) taxRate(address *order.Address) (TaxRate, bool) {
+	if address == nil || address.Country == "" {
+		return TaxRate{}, false
+	}
+	country := strings.ToUpper(address.Country)
+	candidates := []string{country}
+	if address.State != "" {
+		candidates = []string{country + "-" + strings.ToUpper(address.State), country}
+	}
+	for _, region := range candidates {
+		for _, rate := range c.TaxRates {
+			if rate.Region == region {
+				return rate, true
+			}
+		}
+	}
+	return TaxRate{}, false
+}
+
+// Regions returns the configured tax regions, sorted.
+func (c PricingConfig) Regions() []string {
+	regions := make([]string, len(c.TaxRates))
+	for i, r := range c.TaxRates {
+		regions[i] = r.Region
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// applyBps returns amount * bps / 10000 rounded down, without overflowing
+// for large amounts.
+func applyBps(amount, bps int64) int64 {
+	return mulDiv(amount, bps, 0)
+}
+
+// applyBpsRounded is applyBps rounded half up, as used for tax.
+func applyBpsRounded(amount, bps int64) int64 {
+	return mulDiv(amount, bps, 5000)
+}
+
+func mulDiv(amount, bps, bias int64) int64 {
+	if amount <= 0 || bps <= 0 {
+		return 0
+	}
+	hi, lo := bits.Mul64(uint64(amount), uint64(bps))
+	lo, carry := bits.Add64(lo, uint64(bias), 0)
+	hi += carry
+	if hi >= 10000 {
+		return math.MaxInt64
+	}
+	q, _ := bits.Div64(hi, lo, 10000)
+	return int64(min(q, math.MaxInt64))
+}
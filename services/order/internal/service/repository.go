@@ -0,0 +1,1000 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+)
+
+// OrderRepository stores orders. Implementations return copies, so callers
+// may keep or modify the orders they receive. Every method but Create and
+// Stale acts within the tenant of its context, see tenant.FromContext:
+// orders of other tenants are not found, listed or counted.
+type OrderRepository interface {
+	Create(ctx context.Context, o *order.Order) error
+	Get(ctx context.Context, orderID string) (*order.Order, error)
+
+	// List returns the orders matching opts. A zero Limit returns every
+	// match.
+	List(ctx context.Context, opts ListOptions) (OrderPage, error)
+
+	// Export calls fn with every order matching opts, in the order List
+	// would return them; Limit, Offset and Cursor are ignored. It stops at
+	// the first error of fn and returns it. The SQL store reads a row only
+	// once fn has returned for the previous one, so a slow consumer holds
+	// the query back instead of the whole result piling up in memory.
+	Export(ctx context.Context, opts ListOptions, fn func(*order.Order) error) error
+
+	// Search returns up to q.Limit orders matching q, newest first.
+	Search(ctx context.Context, q SearchQuery) ([]*order.Order, error)
+
+	// UpdateStatus applies update, records the transition and bumps
+	// UpdatedAt and Version. It returns the updated order, ErrOrderNotFound,
+	// a VersionConflictError when the order is not at update.Version or an
+	// InvalidTransitionError when the transition graph forbids the change.
+	UpdateStatus(ctx context.Context, orderID string, update StatusUpdate) (*order.Order, error)
+	Count(ctx context.Context) (int, error)
+
+	// EraseCustomer hands erase a copy of every order of customerID, which
+	// may remove the personal data of the customer: its email, its
+	// snapshot and the shipping address, the only fields stored back. The
+	// orders erase reports as changed get a new UpdatedAt and Version; their
+	// IDs are returned.
+	EraseCustomer(ctx context.Context, customerID string, erase func(*order.Order) bool) ([]string, error)
+
+	// Stale returns up to limit orders of any tenant that have status and
+	// were created before cutoff, oldest first. It serves background jobs.
+	Stale(ctx context.Context, status order.OrderStatus, cutoff time.Time, limit int) ([]*order.Order, error)
+
+	// Ping reports whether the store can serve requests.
+	Ping(ctx context.Context) error
+}
+
+// StatusUpdate moves an order to Status. Non-empty IDs are stored with it;
+// empty ones keep their current value. Reason is kept in the status history
+// with At, the time of the change, which defaults to now. A non-zero Version
+// makes the update conditional: it only applies to the order at that
+// version, so a caller cannot overwrite a change it has not seen.
+//
+// With Fulfillment set the update changes a fulfillment instead, and Status
+// is derived from the items; the order only gets a transition when the
+// derived status differs from the current one. With Return set it changes
+// a return and keeps the status.
+type StatusUpdate struct {
+	Status               order.OrderStatus
+	PaymentTransactionID string
+	DisputeID            string
+	Fulfillment          *FulfillmentChange
+	Return               *ReturnChange
+	Reason               string
+	At                   time.Time
+	Version              int64
+}
+
+func (u StatusUpdate) apply(o *order.Order) error {
+	if u.Version != 0 && u.Version != o.Version {
+		return &VersionConflictError{Expected: u.Version, Actual: o.Version}
+	}
+	now := u.At
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	switch {
+	case u.Fulfillment != nil:
+		if err := u.Fulfillment.apply(o, now); err != nil {
+			return err
+		}
+		u.Status = derivedStatus(o)
+	case u.Return != nil:
+		if err := u.Return.apply(o, now); err != nil {
+			return err
+		}
+		u.Status = o.Status
+	case len(o.Fulfillments) > 0:
+		if err := checkFulfilledUpdate(o, u.Status); err != nil {
+			return err
+		}
+	}
+
+	if u.Status != o.Status {
+		if !CanTransition(o.Status, u.Status) {
+			return &InvalidTransitionError{From: o.Status, To: u.Status}
+		}
+		o.Transitions = append(o.Transitions, order.OrderStatusTransition{
+			From:   o.Status,
+			To:     u.Status,
+			At:     now,
+			Reason: u.Reason,
+		})
+		o.Status = u.Status
+		if u.Fulfillment != nil {
+			u.Fulfillment.orderChanged = true
+		}
+	} else if u.Fulfillment == nil && u.Return == nil {
+		return &InvalidTransitionError{From: o.Status, To: u.Status}
+	}
+	if u.Status == order.OrderStatus_ORDER_STATUS_CANCELLED {
+		cancelFulfillments(o, now)
+	}
+	syncItemStatus(o)
+
+	if u.PaymentTransactionID != "" {
+		o.PaymentTransactionID = u.PaymentTransactionID
+	}
+	if u.DisputeID != "" {
+		o.DisputeID = u.DisputeID
+	}
+	o.UpdatedAt = now
+	o.Version++
+	return nil
+}
+
+func cloneOrder(o *order.Order) *order.Order {
+	c := *o
+	c.Items = append([]order.OrderItem(nil), o.Items...)
+	c.Transitions = append([]order.OrderStatusTransition(nil), o.Transitions...)
+	if o.Fulfillments != nil {
+		c.Fulfillments = make([]order.Fulfillment, len(o.Fulfillments))
+		for i, f := range o.Fulfillments {
+			f.Items = append([]int(nil), f.Items...)
+			c.Fulfillments[i] = f
+		}
+	}
+	if o.Returns != nil {
+		c.Returns = make([]order.Return, len(o.Returns))
+		for i, r := range o.Returns {
+			r.Items = append([]order.ReturnItem(nil), r.Items...)
+			c.Returns[i] = r
+		}
+	}
+	if o.Customer != nil {
+		customer := *o.Customer
+		if customer.ShippingAddress != nil {
+			address := *customer.ShippingAddress
+			customer.ShippingAddress = &address
+		}
+		c.Customer = &customer
+	}
+	if o.ShippingAddress != nil {
+		address := *o.ShippingAddress
+		c.ShippingAddress = &address
+	}
+	if o.Pricing != nil {
+		pricing := *o.Pricing
+		pricing.Lines = append([]order.PriceLine(nil), o.Pricing.Lines...)
+		pricing.Coupons = append([]order.AppliedCoupon(nil), o.Pricing.Coupons...)
+		c.Pricing = &pricing
+	}
+	return &c
+}
+
+// InMemoryOrderRepository keeps orders for the lifetime of the process.
+type InMemoryOrderRepository struct {
+	mu     sync.RWMutex
+	orders map[string]*order.Order
+	index  *searchIndex
+}
+
+func NewInMemoryOrderRepository() *InMemoryOrderRepository {
+	return &InMemoryOrderRepository{
+		orders: make(map[string]*order.Order),
+		index:  newSearchIndex(),
+	}
+}
+
+func (r *InMemoryOrderRepository) Create(ctx context.Context, o *order.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.orders[o.ID]; ok {
+		return fmt.Errorf("order %s already exists", o.ID)
+	}
+	r.orders[o.ID] = cloneOrder(o)
+	r.index.add(o)
+	return nil
+}
+
+func (r *InMemoryOrderRepository) Get(ctx context.Context, orderID string) (*order.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	o, ok := r.orders[orderID]
+	if !ok || o.TenantID != tenant.FromContext(ctx) {
+		return nil, ErrOrderNotFound
+	}
+	return cloneOrder(o), nil
+}
+
+func (r *InMemoryOrderRepository) List(ctx context.Context, opts ListOptions) (OrderPage, error) {
+	opts, err := opts.normalize()
+	if err != nil {
+		return OrderPage{}, err
+	}
+	cursor, err := decodeListCursor(opts.Cursor, opts.SortBy)
+	if err != nil {
+		return OrderPage{}, err
+	}
+
+	tenantID := tenant.FromContext(ctx)
+	r.mu.RLock()
+	orders := make([]*order.Order, 0, len(r.orders))
+	for _, o := range r.orders {
+		if o.TenantID == tenantID && opts.matches(o) && (cursor == nil || opts.after(o, cursor)) {
+			orders = append(orders, cloneOrder(o))
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(orders, func(i, j int) bool {
+		return opts.less(orders[i], orders[j])
+	})
+
+	orders = orders[min(opts.Offset, len(orders)):]
+	return newOrderPage(orders, opts), nil
+}
+
+func (r *InMemoryOrderRepository) Export(ctx context.Context, opts ListOptions, fn func(*order.Order) error) error {
+	opts, err := opts.normalize()
+	if err != nil {
+		return err
+	}
+
+	tenantID := tenant.FromContext(ctx)
+	r.mu.RLock()
+	var orders []*order.Order
+	for _, o := range r.orders {
+		if o.TenantID == tenantID && opts.matches(o) {
+			orders = append(orders, o)
+		}
+	}
+	r.mu.RUnlock()
+
+	// Updates replace the stored orders rather than modify them, so the
+	// copies can be made one at a time as fn takes them.
+	sort.Slice(orders, func(i, j int) bool {
+		return opts.less(orders[i], orders[j])
+	})
+	for _, o := range orders {
+		if err := fn(cloneOrder(o)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *InMemoryOrderRepository) Search(ctx context.Context, q SearchQuery) ([]*order.Order, error) {
+	tenantID := tenant.FromContext(ctx)
+	r.mu.RLock()
+	var orders []*order.Order
+	if ids, ok := r.index.lookup(q); ok {
+		for id := range ids {
+			if o := r.orders[id]; o.TenantID == tenantID && q.matchesTotal(o) {
+				orders = append(orders, cloneOrder(o))
+			}
+		}
+	} else {
+		for _, o := range r.orders {
+			if o.TenantID == tenantID && q.matchesTotal(o) {
+				orders = append(orders, cloneOrder(o))
+			}
+		}
+	}
+	r.mu.RUnlock()
+
+	return newestFirst(orders, q.Limit), nil
+}
+
+// newestFirst sorts orders by creation time, newest first, and keeps up
+// to limit of them; 0 keeps all.
+func newestFirst(orders []*order.Order, limit int) []*order.Order {
+	sort.Slice(orders, func(i, j int) bool {
+		if c := orders[i].CreatedAt.Compare(orders[j].CreatedAt); c != 0 {
+			return c > 0
+		}
+		return orders[i].ID > orders[j].ID
+	})
+	if limit > 0 && len(orders) > limit {
+		orders = orders[:limit]
+	}
+	if orders == nil {
+		orders = []*order.Order{}
+	}
+	return orders
+}
+
+func (r *InMemoryOrderRepository) UpdateStatus(ctx context.Context, orderID string, update StatusUpdate) (*order.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	o, ok := r.orders[orderID]
+	if !ok || o.TenantID != tenant.FromContext(ctx) {
+		return nil, ErrOrderNotFound
+	}
+	updated := cloneOrder(o)
+	if err := update.apply(updated); err != nil {
+		return nil, err
+	}
+	r.orders[orderID] = updated
+	return cloneOrder(updated), nil
+}
+
+// newOrderPage trims orders to the limit and sets the cursor when more
+// orders follow.
+func newOrderPage(orders []*order.Order, opts ListOptions) OrderPage {
+	if opts.Limit == 0 || len(orders) <= opts.Limit {
+		return OrderPage{Orders: orders}
+	}
+	orders = orders[:opts.Limit]
+	return OrderPage{
+		Orders:     orders,
+		NextCursor: newListCursor(opts.SortBy, orders[len(orders)-1]),
+	}
+}
+
+func (r *InMemoryOrderRepository) EraseCustomer(ctx context.Context, customerID string, erase func(*order.Order) bool) ([]string, error) {
+	tenantID := tenant.FromContext(ctx)
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := []string{}
+	for id, o := range r.orders {
+		if o.TenantID != tenantID || o.CustomerID != customerID {
+			continue
+		}
+		erased := cloneOrder(o)
+		if !erase(erased) {
+			continue
+		}
+		erased.UpdatedAt = now
+		erased.Version++
+		r.index.replaceEmail(o, erased.CustomerEmail)
+		r.orders[id] = erased
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (r *InMemoryOrderRepository) Count(ctx context.Context) (int, error) {
+	tenantID := tenant.FromContext(ctx)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := 0
+	for _, o := range r.orders {
+		if o.TenantID == tenantID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *InMemoryOrderRepository) Stale(ctx context.Context, status order.OrderStatus, cutoff time.Time, limit int) ([]*order.Order, error) {
+	r.mu.RLock()
+	var orders []*order.Order
+	for _, o := range r.orders {
+		if o.Status == status && o.CreatedAt.Before(cutoff) {
+			orders = append(orders, cloneOrder(o))
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].CreatedAt.Before(orders[j].CreatedAt)
+	})
+	if len(orders) > limit {
+		orders = orders[:limit]
+	}
+	return orders, nil
+}
+
+func (r *InMemoryOrderRepository) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// sqlTimeLayout has a fixed width so that timestamps sort as text.
+const sqlTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// SQLOrderRepository stores orders in an orders table. Items and the status
+// history are kept as JSON columns.
+type SQLOrderRepository struct {
+	db       *sql.DB
+	postgres bool
+}
+
+// NewSQLOrderRepository creates the table if needed. driver is the name
+// passed to sql.Open and selects the placeholder style.
+func NewSQLOrderRepository(ctx context.Context, db *sql.DB, driver string) (*SQLOrderRepository, error) {
+	r := &SQLOrderRepository{
+		db:       db,
+		postgres: driver == "pgx" || driver == "postgres",
+	}
+
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS orders (
+		id                     TEXT PRIMARY KEY,
+		customer_id            TEXT NOT NULL,
+		customer_email         TEXT NOT NULL,
+		items                  TEXT NOT NULL,
+		total_cents            BIGINT NOT NULL,
+		currency               TEXT NOT NULL,
+		status                 INTEGER NOT NULL,
+		payment_transaction_id TEXT NOT NULL,
+		dispute_id             TEXT NOT NULL,
+		transitions            TEXT NOT NULL DEFAULT '[]',
+		reservation_id         TEXT NOT NULL DEFAULT '',
+		customer               TEXT NOT NULL DEFAULT '',
+		shipping_address       TEXT NOT NULL DEFAULT '',
+		pricing                TEXT NOT NULL DEFAULT '',
+		tenant_id              TEXT NOT NULL DEFAULT 'default',
+		created_at             TEXT NOT NULL,
+		updated_at             TEXT NOT NULL,
+		version                BIGINT NOT NULL DEFAULT 1,
+		fulfillments           TEXT NOT NULL DEFAULT '[]',
+		returns                TEXT NOT NULL DEFAULT '[]'
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create orders table: %w", err)
+	}
+
+	// Tables created by earlier versions lack the newer columns.
+	for _, column := range []struct{ name, definition string }{
+		{"transitions", `TEXT NOT NULL DEFAULT '[]'`},
+		{"reservation_id", `TEXT NOT NULL DEFAULT ''`},
+		{"customer", `TEXT NOT NULL DEFAULT ''`},
+		{"shipping_address", `TEXT NOT NULL DEFAULT ''`},
+		{"pricing", `TEXT NOT NULL DEFAULT ''`},
+		{"tenant_id", `TEXT NOT NULL DEFAULT 'default'`},
+		{"version", `BIGINT NOT NULL DEFAULT 1`},
+		{"fulfillments", `TEXT NOT NULL DEFAULT '[]'`},
+		{"returns", `TEXT NOT NULL DEFAULT '[]'`},
+	} {
+		if _, err := db.ExecContext(ctx, `SELECT `+column.name+` FROM orders LIMIT 1`); err == nil {
+			continue
+		}
+		_, err = db.ExecContext(ctx, `ALTER TABLE orders ADD COLUMN `+column.name+` `+column.definition)
+		if err != nil {
+			return nil, fmt.Errorf("add orders.%s column: %w", column.name, err)
+		}
+	}
+
+	_, err = db.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS orders_created_at ON orders (created_at)`)
+	if err != nil {
+		return nil, fmt.Errorf("create orders index: %w", err)
+	}
+	_, err = db.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS orders_tenant_created_at ON orders (tenant_id, created_at)`)
+	if err != nil {
+		return nil, fmt.Errorf("create orders tenant index: %w", err)
+	}
+	_, err = db.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS orders_tenant_customer_created_at ON orders (tenant_id, customer_id, created_at)`)
+	if err != nil {
+		return nil, fmt.Errorf("create orders customer index: %w", err)
+	}
+
+	return r, nil
+}
+
+// rebind rewrites ? placeholders as $n for Postgres.
+func (r *SQLOrderRepository) rebind(query string) string {
+	if !r.postgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+const orderColumns = `id, customer_id, customer_email, items, total_cents, currency, status,
+	payment_transaction_id, dispute_id, transitions, reservation_id, customer, shipping_address, pricing,
+	tenant_id, created_at, updated_at, version, fulfillments, returns`
+
+func (r *SQLOrderRepository) Create(ctx context.Context, o *order.Order) error {
+	items, err := json.Marshal(o.Items)
+	if err != nil {
+		return err
+	}
+	transitions, err := marshalList(o.Transitions)
+	if err != nil {
+		return err
+	}
+	fulfillments, err := marshalList(o.Fulfillments)
+	if err != nil {
+		return err
+	}
+	returns, err := marshalList(o.Returns)
+	if err != nil {
+		return err
+	}
+	customer, err := marshalOptional(o.Customer)
+	if err != nil {
+		return err
+	}
+	address, err := marshalOptional(o.ShippingAddress)
+	if err != nil {
+		return err
+	}
+	pricing, err := marshalOptional(o.Pricing)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, r.rebind(`INSERT INTO orders (`+orderColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		o.ID,
+		o.CustomerID,
+		o.CustomerEmail,
+		string(items),
+		o.TotalCents,
+		o.Currency,
+		int32(o.Status),
+		o.PaymentTransactionID,
+		o.DisputeID,
+		transitions,
+		o.ReservationID,
+		customer,
+		address,
+		pricing,
+		o.TenantID,
+		o.CreatedAt.UTC().Format(sqlTimeLayout),
+		o.UpdatedAt.UTC().Format(sqlTimeLayout),
+		o.Version,
+		fulfillments,
+		returns,
+	)
+	return err
+}
+
+func (r *SQLOrderRepository) Get(ctx context.Context, orderID string) (*order.Order, error) {
+	row := r.db.QueryRowContext(ctx, r.rebind(`SELECT `+orderColumns+` FROM orders WHERE id = ? AND tenant_id = ?`),
+		orderID, tenant.FromContext(ctx))
+	o, err := scanOrder(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrOrderNotFound
+	}
+	return o, err
+}
+
+func (r *SQLOrderRepository) List(ctx context.Context, opts ListOptions) (OrderPage, error) {
+	opts, err := opts.normalize()
+	if err != nil {
+		return OrderPage{}, err
+	}
+	cursor, err := decodeListCursor(opts.Cursor, opts.SortBy)
+	if err != nil {
+		return OrderPage{}, err
+	}
+
+	rows, err := r.queryOrders(ctx, opts, cursor)
+	if err != nil {
+		return OrderPage{}, err
+	}
+	defer rows.Close()
+
+	orders := []*order.Order{}
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return OrderPage{}, err
+		}
+		orders = append(orders, o)
+	}
+	if err := rows.Err(); err != nil {
+		return OrderPage{}, err
+	}
+
+	return newOrderPage(orders, opts), nil
+}
+
+func (r *SQLOrderRepository) Export(ctx context.Context, opts ListOptions, fn func(*order.Order) error) error {
+	opts, err := opts.normalize()
+	if err != nil {
+		return err
+	}
+	opts.Limit, opts.Offset = 0, 0
+
+	rows, err := r.queryOrders(ctx, opts, nil)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(o); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// queryOrders runs the query of List for the normalized opts, starting
+// after cursor when it is not nil.
+func (r *SQLOrderRepository) queryOrders(ctx context.Context, opts ListOptions, cursor *listCursor) (*sql.Rows, error) {
+	where := []string{"tenant_id = ?"}
+	args := []any{tenant.FromContext(ctx)}
+	if opts.Status != order.OrderStatus_ORDER_STATUS_UNSPECIFIED {
+		where = append(where, "status = ?")
+		args = append(args, int32(opts.Status))
+	}
+	if opts.CustomerID != "" {
+		where = append(where, "customer_id = ?")
+		args = append(args, opts.CustomerID)
+	}
+	if !opts.CreatedFrom.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, opts.CreatedFrom.UTC().Format(sqlTimeLayout))
+	}
+	if !opts.CreatedTo.IsZero() {
+		where = append(where, "created_at < ?")
+		args = append(args, opts.CreatedTo.UTC().Format(sqlTimeLayout))
+	}
+
+	column, dir, op := "created_at", "ASC", ">"
+	if opts.SortBy == SortByTotal {
+		column = "total_cents"
+	}
+	if opts.Descending {
+		dir, op = "DESC", "<"
+	}
+
+	if cursor != nil {
+		var value any = cursor.Value
+		if opts.SortBy == SortByTotal {
+			value, _ = strconv.ParseInt(cursor.Value, 10, 64)
+		}
+		where = append(where, "("+column+" "+op+" ? OR ("+column+" = ? AND id "+op+" ?))")
+		args = append(args, value, value, cursor.ID)
+	}
+
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE ` + strings.Join(where, " AND ")
+	query += " ORDER BY " + column + " " + dir + ", id " + dir
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit+1)
+	}
+	if opts.Offset > 0 {
+		if opts.Limit == 0 {
+			// SQLite requires a LIMIT before OFFSET.
+			if r.postgres {
+				query += " LIMIT ALL"
+			} else {
+				query += " LIMIT -1"
+			}
+		}
+		query += " OFFSET ?"
+		args = append(args, opts.Offset)
+	}
+
+	return r.db.QueryContext(ctx, r.rebind(query), args...)
+}
+
+// Search matches terms as case-insensitive substrings of the email, the
+// items and the ID, a superset of what the in-memory index matches.
+func (r *SQLOrderRepository) Search(ctx context.Context, q SearchQuery) ([]*order.Order, error) {
+	where := []string{"tenant_id = ?"}
+	args := []any{tenant.FromContext(ctx)}
+	like := func(column, pattern string) {
+		where = append(where, column+` LIKE ? ESCAPE '\'`)
+		args = append(args, pattern)
+	}
+	if q.Email != "" {
+		like("LOWER(customer_email)", "%"+escapeLike(q.Email)+"%")
+	}
+	for _, p := range q.Products {
+		like("LOWER(items)", "%"+escapeLike(p)+"%")
+	}
+	if q.IDPrefix != "" {
+		like("id", escapeLike(q.IDPrefix)+"%")
+	}
+	for _, term := range q.Terms {
+		where = append(where, `(LOWER(customer_email) LIKE ? ESCAPE '\' OR LOWER(items) LIKE ? ESCAPE '\' OR id LIKE ? ESCAPE '\')`)
+		pattern := "%" + escapeLike(term) + "%"
+		args = append(args, pattern, pattern, escapeLike(term)+"%")
+	}
+	if q.MinTotalCents > 0 {
+		where = append(where, "total_cents >= ?")
+		args = append(args, q.MinTotalCents)
+	}
+	if q.MaxTotalCents > 0 {
+		where = append(where, "total_cents <= ?")
+		args = append(args, q.MaxTotalCents)
+	}
+	if q.CustomerID != "" {
+		where = append(where, "customer_id = ?")
+		args = append(args, q.CustomerID)
+	}
+
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE ` + strings.Join(where, " AND ")
+	query += " ORDER BY created_at DESC, id DESC"
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []*order.Order{}
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
+
+// escapeLike makes the LIKE wildcards in s match themselves.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// sqlUpdateRetries bounds how often UpdateStatus re-reads an order that
+// changed between its read and its write.
+const sqlUpdateRetries = 5
+
+// UpdateStatus validates the transition against the stored order and writes
+// it only if the order still has the version that was read, so concurrent
+// updates cannot skip the transition graph. A conditional update that loses
+// the race reads the new version and fails with a VersionConflictError.
+func (r *SQLOrderRepository) UpdateStatus(ctx context.Context, orderID string, update StatusUpdate) (*order.Order, error) {
+	for range sqlUpdateRetries {
+		o, err := r.Get(ctx, orderID)
+		if err != nil {
+			return nil, err
+		}
+		prevVersion := o.Version
+
+		if err := update.apply(o); err != nil {
+			return nil, err
+		}
+		transitions, err := marshalList(o.Transitions)
+		if err != nil {
+			return nil, err
+		}
+		items, err := json.Marshal(o.Items)
+		if err != nil {
+			return nil, err
+		}
+		fulfillments, err := marshalList(o.Fulfillments)
+		if err != nil {
+			return nil, err
+		}
+		returns, err := marshalList(o.Returns)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := r.db.ExecContext(ctx, r.rebind(`UPDATE orders
+			SET status = ?, payment_transaction_id = ?, dispute_id = ?, transitions = ?, items = ?, fulfillments = ?,
+				returns = ?, updated_at = ?, version = ?
+			WHERE id = ? AND version = ?`),
+			int32(o.Status),
+			o.PaymentTransactionID,
+			o.DisputeID,
+			transitions,
+			string(items),
+			fulfillments,
+			returns,
+			o.UpdatedAt.UTC().Format(sqlTimeLayout),
+			o.Version,
+			orderID,
+			prevVersion,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 1 {
+			return o, err
+		}
+	}
+	return nil, fmt.Errorf("order %s: concurrent status updates, giving up", orderID)
+}
+
+func (r *SQLOrderRepository) EraseCustomer(ctx context.Context, customerID string, erase func(*order.Order) bool) ([]string, error) {
+	opts, _ := ListOptions{CustomerID: customerID}.normalize()
+	rows, err := r.queryOrders(ctx, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+	var orders []*order.Order
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, o := range orders {
+		erased, err := r.eraseOrder(ctx, o, erase)
+		if err != nil {
+			return ids, err
+		}
+		if erased {
+			ids = append(ids, o.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// eraseOrder stores o as erase leaves it, reading it again when it was
+// updated meanwhile.
+func (r *SQLOrderRepository) eraseOrder(ctx context.Context, o *order.Order, erase func(*order.Order) bool) (bool, error) {
+	for range sqlUpdateRetries {
+		prevVersion := o.Version
+		if !erase(o) {
+			return false, nil
+		}
+		customer, err := marshalOptional(o.Customer)
+		if err != nil {
+			return false, err
+		}
+		address, err := marshalOptional(o.ShippingAddress)
+		if err != nil {
+			return false, err
+		}
+		o.UpdatedAt = time.Now()
+		o.Version++
+
+		res, err := r.db.ExecContext(ctx, r.rebind(`UPDATE orders
+			SET customer_email = ?, customer = ?, shipping_address = ?, updated_at = ?, version = ?
+			WHERE id = ? AND version = ?`),
+			o.CustomerEmail, customer, address, o.UpdatedAt.UTC().Format(sqlTimeLayout), o.Version, o.ID, prevVersion)
+		if err != nil {
+			return false, err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 1 {
+			return err == nil, err
+		}
+		if o, err = r.Get(ctx, o.ID); err != nil {
+			return false, err
+		}
+	}
+	return false, fmt.Errorf("order %s: concurrent updates, giving up erasing it", o.ID)
+}
+
+func (r *SQLOrderRepository) Count(ctx context.Context) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, r.rebind(`SELECT COUNT(*) FROM orders WHERE tenant_id = ?`), tenant.FromContext(ctx)).Scan(&n)
+	return n, err
+}
+
+func (r *SQLOrderRepository) Stale(ctx context.Context, status order.OrderStatus, cutoff time.Time, limit int) ([]*order.Order, error) {
+	rows, err := r.db.QueryContext(ctx, r.rebind(`SELECT `+orderColumns+` FROM orders
+		WHERE status = ? AND created_at < ? ORDER BY created_at LIMIT ?`),
+		int32(status), cutoff.UTC().Format(sqlTimeLayout), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*order.Order
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
+
+func (r *SQLOrderRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanOrder(row rowScanner) (*order.Order, error) {
+	var (
+		o                    order.Order
+		items                string
+		status               int32
+		transitions          string
+		customer             string
+		address              string
+		pricing              string
+		createdAt, updatedAt string
+		fulfillments         string
+		returns              string
+	)
+	err := row.Scan(&o.ID, &o.CustomerID, &o.CustomerEmail, &items, &o.TotalCents, &o.Currency, &status,
+		&o.PaymentTransactionID, &o.DisputeID, &transitions, &o.ReservationID, &customer, &address, &pricing,
+		&o.TenantID, &createdAt, &updatedAt, &o.Version, &fulfillments, &returns)
+	if err != nil {
+		return nil, err
+	}
+
+	o.Status = order.OrderStatus(status)
+	if err := json.Unmarshal([]byte(items), &o.Items); err != nil {
+		return nil, fmt.Errorf("order %s items: %w", o.ID, err)
+	}
+	if err := json.Unmarshal([]byte(transitions), &o.Transitions); err != nil {
+		return nil, fmt.Errorf("order %s transitions: %w", o.ID, err)
+	}
+	if err := json.Unmarshal([]byte(fulfillments), &o.Fulfillments); err != nil {
+		return nil, fmt.Errorf("order %s fulfillments: %w", o.ID, err)
+	}
+	if err := json.Unmarshal([]byte(returns), &o.Returns); err != nil {
+		return nil, fmt.Errorf("order %s returns: %w", o.ID, err)
+	}
+	// Rows written before items had a status get it here.
+	syncItemStatus(&o)
+	if err := unmarshalOptional(customer, &o.Customer); err != nil {
+		return nil, fmt.Errorf("order %s customer: %w", o.ID, err)
+	}
+	if err := unmarshalOptional(address, &o.ShippingAddress); err != nil {
+		return nil, fmt.Errorf("order %s shipping_address: %w", o.ID, err)
+	}
+	if err := unmarshalOptional(pricing, &o.Pricing); err != nil {
+		return nil, fmt.Errorf("order %s pricing: %w", o.ID, err)
+	}
+	if o.CreatedAt, err = time.Parse(sqlTimeLayout, createdAt); err != nil {
+		return nil, fmt.Errorf("order %s created_at: %w", o.ID, err)
+	}
+	if o.UpdatedAt, err = time.Parse(sqlTimeLayout, updatedAt); err != nil {
+		return nil, fmt.Errorf("order %s updated_at: %w", o.ID, err)
+	}
+	return &o, nil
+}
+
+// marshalList stores a nil slice as an empty JSON array.
+func marshalList[T any](list []T) (string, error) {
+	if list == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(list)
+	return string(data), err
+}
+
+// marshalOptional stores a nil value as an empty string.
+func marshalOptional[T any](v *T) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+func unmarshalOptional[T any](data string, v **T) error {
+	if data == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(data), v)
+}
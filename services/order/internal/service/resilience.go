@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/circuitbreaker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/retry"
+	"google.golang.org/grpc/status"
+)
+
+// RetryConfig controls how payment calls are retried. Only Unavailable and
+// DeadlineExceeded errors are retried, and only for calls that are safe to
+// repeat.
+type RetryConfig struct {
+	// Config sets the attempts of idempotent calls and the backoff
+	// between them.
+	retry.Config `config:",inline"`
+
+	// CallTimeout bounds each attempt; 0 leaves only the caller's deadline.
+	CallTimeout time.Duration `config:"timeout" usage:"Deadline for each call attempt"`
+
+	// Timeouts replace CallTimeout for single methods, as Method:duration
+	// entries such as ProcessPayment:3s.
+	Timeouts []string `config:"timeouts" usage:"Comma separated Method:duration entries replacing timeout for single payment methods"`
+
+	// DeadlineMargin is kept from the deadline of the caller, so a call
+	// that times out leaves time to answer before the caller gives up.
+	DeadlineMargin time.Duration `config:"deadline-margin" usage:"Time kept from the caller's deadline to answer after a timed out call"`
+
+	// DeclineWait is the longest wait hinted by a retriable payment decline
+	// that is waited out to charge the order once more, under a new
+	// idempotency key; 0 cancels every declined order at once.
+	DeclineWait time.Duration `config:"decline-wait" usage:"Longest retry-after of a retriable payment decline waited out to charge again, zero never charges again"`
+}
+
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		Config: retry.Config{
+			MaxAttempts: 3,
+			BaseDelay:   100 * time.Millisecond,
+			MaxDelay:    2 * time.Second,
+			Multiplier:  2,
+			Jitter:      retry.JitterFull,
+		},
+		CallTimeout:    5 * time.Second,
+		DeadlineMargin: 250 * time.Millisecond,
+	}
+}
+
+func (c RetryConfig) Validate() error {
+	for _, entry := range c.Timeouts {
+		if _, _, err := parseMethodTimeout(entry); err != nil {
+			return fmt.Errorf("timeouts: %w", err)
+		}
+	}
+	if c.DeadlineMargin < 0 {
+		return errors.New("deadline-margin must not be negative")
+	}
+	if c.DeclineWait < 0 {
+		return errors.New("decline-wait must not be negative")
+	}
+	return nil
+}
+
+func parseMethodTimeout(entry string) (string, time.Duration, error) {
+	method, raw, ok := strings.Cut(entry, ":")
+	if !ok || method == "" {
+		return "", 0, fmt.Errorf("%q is not Method:duration", entry)
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return "", 0, fmt.Errorf("%q has an invalid duration", entry)
+	}
+	return method, d, nil
+}
+
+// timeout returns the deadline of one attempt of method, 0 for none.
+func (c RetryConfig) timeout(method string) time.Duration {
+	for _, entry := range c.Timeouts {
+		if name, d, err := parseMethodTimeout(entry); err == nil && name == method {
+			return d
+		}
+	}
+	return c.CallTimeout
+}
+
+// attemptTimeout returns the deadline of the next attempt of method: its
+// timeout, cut to what remains of the deadline of ctx minus DeadlineMargin.
+// It returns false when nothing remains.
+func (c RetryConfig) attemptTimeout(ctx context.Context, method string) (time.Duration, bool) {
+	timeout := c.timeout(method)
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return timeout, true
+	}
+	remaining := time.Until(deadline) - c.DeadlineMargin
+	if remaining <= 0 {
+		return 0, false
+	}
+	if timeout <= 0 || remaining < timeout {
+		timeout = remaining
+	}
+	return timeout, true
+}
+
+// declineRetry returns how long to wait before charging again an order
+// whose payment was declined with hint, and false when it should not be
+// charged again: the decline is final, the wait exceeds DeclineWait or
+// outlasts the deadline of ctx. A retriable decline without a wait is
+// charged again after the base delay of the retries.
+func (c RetryConfig) declineRetry(ctx context.Context, hint *PaymentDeclinedError) (time.Duration, bool) {
+	if c.DeclineWait <= 0 || !hint.Retriable || hint.RetryAfter > c.DeclineWait {
+		return 0, false
+	}
+	wait := hint.RetryAfter
+	if wait == 0 {
+		wait = c.BaseDelay
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline)-c.DeadlineMargin <= wait {
+		return 0, false
+	}
+	return wait, true
+}
+
+// callPayment runs call through the circuit breaker, with a deadline per
+// attempt that ends DeadlineMargin before the deadline of ctx. Idempotent
+// calls are retried on transient errors while time remains. A call refused
+// by an open circuit returns ErrPaymentServiceUnavailable, and one left
+// without time ErrPaymentTimeout.
+func (s *OrderService) callPayment(ctx context.Context, name string, idempotent bool, call func(ctx context.Context) error) error {
+	cfg := s.retry.Config
+	if !idempotent {
+		cfg.MaxAttempts = 1
+	}
+
+	// An attempt that cannot be made ends the retries with the error of
+	// the previous one, if any.
+	var last error
+	return retry.Do(ctx, cfg, func(ctx context.Context) error {
+		timeout, ok := s.retry.attemptTimeout(ctx, name)
+		if !ok {
+			logger.WarnContext(ctx, "payment call skipped: deadline too close", "method", name)
+			if last == nil {
+				last = ErrPaymentTimeout
+			}
+			return retry.Permanent(last)
+		}
+		if !s.breaker.Allow() {
+			logger.WarnContext(ctx, "payment call skipped: circuit is open", "method", name)
+			return retry.Permanent(ErrPaymentServiceUnavailable)
+		}
+
+		callCtx, cancel := ctx, context.CancelFunc(func() {})
+		if timeout > 0 {
+			callCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		start := time.Now()
+		last = call(callCtx)
+		cancel()
+		s.metrics.paymentCall(name, last, time.Since(start))
+
+		s.breaker.Record(!circuitbreaker.TransportFailure(last))
+		return last
+	}, retry.If(retry.TransientRPC), retry.OnRetry(func(attempt int, err error, wait time.Duration) {
+		logger.WarnContext(ctx, "payment call failed, retrying", "method", name,
+			"attempt", attempt, "attempts", cfg.MaxAttempts, "code", status.Code(err).String(), "retry_in", wait.String())
+	}))
+}
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+	"github.com/google/uuid"
+)
+
+// ReturnsTopic receives an OrderReturnEvent whenever a return is requested
+// or changes status.
+const ReturnsTopic = "order.returns"
+
+// returnTransitions lists the legal status changes for a return.
+var returnTransitions = map[order.ReturnStatus][]order.ReturnStatus{
+	order.ReturnStatus_RETURN_STATUS_REQUESTED: {
+		order.ReturnStatus_RETURN_STATUS_APPROVED,
+		order.ReturnStatus_RETURN_STATUS_REJECTED,
+	},
+	order.ReturnStatus_RETURN_STATUS_APPROVED: {
+		order.ReturnStatus_RETURN_STATUS_RECEIVED,
+	},
+	order.ReturnStatus_RETURN_STATUS_RECEIVED: {
+		order.ReturnStatus_RETURN_STATUS_REFUNDED,
+	},
+}
+
+// CanTransitionReturn reports whether a return may move from one status to
+// another.
+func CanTransitionReturn(from, to order.ReturnStatus) bool {
+	for _, allowed := range returnTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ReturnChange requests a return of Items, or, with Items nil, moves
+// return ID to Status with Note as its reason. A non-empty CustomerID
+// limits the change to the orders of that customer.
+type ReturnChange struct {
+	ID         string
+	Items      []order.ReturnItem
+	Reason     string
+	CustomerID string
+	Status     order.ReturnStatus
+	Note       string
+
+	// from is set by apply for the event.
+	from order.ReturnStatus
+}
+
+func (c *ReturnChange) apply(o *order.Order, now time.Time) error {
+	c.from = order.ReturnStatus_RETURN_STATUS_UNSPECIFIED
+	if c.CustomerID != "" && o.CustomerID != c.CustomerID {
+		return ErrOrderNotFound
+	}
+	if c.Items != nil {
+		return c.request(o, now)
+	}
+
+	r := findReturn(o, c.ID)
+	if r == nil {
+		return fmt.Errorf("%w: %s", ErrReturnNotFound, c.ID)
+	}
+	if !CanTransitionReturn(r.Status, c.Status) {
+		return fmt.Errorf("%w from %s to %s", ErrInvalidReturnTransition, r.Status, c.Status)
+	}
+	c.from = r.Status
+	r.Status = c.Status
+	r.Note = c.Note
+	r.UpdatedAt = now
+	return nil
+}
+
+// request appends a REQUESTED return of c.Items. Only delivered items can
+// be returned, each at most up to the quantity bought; a quantity of 0
+// returns all units not returned yet.
+func (c *ReturnChange) request(o *order.Order, now time.Time) error {
+	returned := returnedQuantities(o)
+	items := make([]order.ReturnItem, 0, len(c.Items))
+	seen := make(map[int]bool, len(c.Items))
+
+	verr := &ValidationError{}
+	if len(c.Items) == 0 {
+		verr.add("items", "must list at least one item")
+	}
+	for n, ri := range c.Items {
+		field := fmt.Sprintf("items[%d]", n)
+		if ri.Item < 0 || ri.Item >= len(o.Items) {
+			verr.add(field+".item", "must be an item index between 0 and %d", len(o.Items)-1)
+			continue
+		}
+		item := o.Items[ri.Item]
+		left := item.Quantity - returned[ri.Item]
+		switch {
+		case seen[ri.Item]:
+			verr.add(field+".item", "repeats item %d", ri.Item)
+		case item.Status != order.OrderStatus_ORDER_STATUS_DELIVERED:
+			verr.add(field+".item", "item %d is %s, only delivered items can be returned", ri.Item, item.Status)
+		case left == 0:
+			verr.add(field+".item", "item %d was returned already", ri.Item)
+		case ri.Quantity < 0 || ri.Quantity > left:
+			verr.add(field+".quantity", "must be between 1 and %d", left)
+		}
+		seen[ri.Item] = true
+		if ri.Quantity == 0 {
+			ri.Quantity = left
+		}
+		items = append(items, ri)
+	}
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+
+	o.Returns = append(o.Returns, order.Return{
+		ID:        c.ID,
+		Items:     items,
+		Status:    order.ReturnStatus_RETURN_STATUS_REQUESTED,
+		Reason:    c.Reason,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	o.Returns[len(o.Returns)-1].RefundCents = refundCents(o, items)
+	return nil
+}
+
+func findReturn(o *order.Order, id string) *order.Return {
+	for i := range o.Returns {
+		if o.Returns[i].ID == id {
+			return &o.Returns[i]
+		}
+	}
+	return nil
+}
+
+// returnedQuantities sums the quantities of the returns of o that were not
+// rejected, by item index.
+func returnedQuantities(o *order.Order) map[int]int32 {
+	returned := make(map[int]int32)
+	for _, r := range o.Returns {
+		if r.Status == order.ReturnStatus_RETURN_STATUS_REJECTED {
+			continue
+		}
+		for _, ri := range r.Items {
+			returned[ri.Item] += ri.Quantity
+		}
+	}
+	return returned
+}
+
+// refundCents is the share of the order total paid for items, which is
+// already part of o.Returns: line totals after discounts, plus the same
+// share of tax. The return completing the order refunds what the others
+// left, so rounding never refunds more or less than the total.
+func refundCents(o *order.Order, items []order.ReturnItem) int64 {
+	lines := make([]int64, len(o.Items))
+	var sum int64
+	for i, item := range o.Items {
+		lines[i] = item.UnitPriceCents * int64(item.Quantity)
+		if o.Pricing != nil && len(o.Pricing.Lines) == len(o.Items) {
+			lines[i] = o.Pricing.Lines[i].TotalCents
+		}
+		sum += lines[i]
+	}
+	if sum <= 0 {
+		return 0
+	}
+
+	returned := returnedQuantities(o)
+	complete := true
+	for i, item := range o.Items {
+		if returned[i] < item.Quantity {
+			complete = false
+		}
+	}
+	if complete {
+		refunded := int64(0)
+		for _, r := range o.Returns {
+			if r.Status != order.ReturnStatus_RETURN_STATUS_REJECTED {
+				refunded += r.RefundCents
+			}
+		}
+		return o.TotalCents - refunded
+	}
+
+	var share int64
+	for _, ri := range items {
+		share += lines[ri.Item] * int64(ri.Quantity) / int64(o.Items[ri.Item].Quantity)
+	}
+	return o.TotalCents * share / sum
+}
+
+// RequestReturn asks to send items of an order back. A non-empty
+// customerID limits the request to the orders of that customer, as other
+// orders return ErrOrderNotFound.
+func (s *OrderService) RequestReturn(ctx context.Context, orderID, customerID string, items []order.ReturnItem, reason string) (*order.Order, error) {
+	if items == nil {
+		items = []order.ReturnItem{}
+	}
+	return s.changeReturn(ctx, orderID, &ReturnChange{
+		ID:         "ret_" + uuid.New().String()[:8],
+		Items:      items,
+		Reason:     reason,
+		CustomerID: customerID,
+	}, 0)
+}
+
+// UpdateReturn moves a return to status with note as its reason. Moving
+// it to REFUNDED refunds its RefundCents through the payment service
+// first, with the return ID as refund ID, so a retried call refunds once.
+// A non-zero version is the version the caller last read; it is checked
+// before the refund.
+func (s *OrderService) UpdateReturn(ctx context.Context, orderID, returnID string, status order.ReturnStatus, note string, version int64) (*order.Order, error) {
+	if status == order.ReturnStatus_RETURN_STATUS_REFUNDED {
+		if err := s.refundReturn(ctx, orderID, returnID, version); err != nil {
+			return nil, err
+		}
+	}
+	return s.changeReturn(ctx, orderID, &ReturnChange{ID: returnID, Status: status, Note: note}, version)
+}
+
+func (s *OrderService) refundReturn(ctx context.Context, orderID, returnID string, version int64) error {
+	o, err := s.repo.Get(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if version != 0 && o.Version != version {
+		return &VersionConflictError{Expected: version, Actual: o.Version}
+	}
+	r := findReturn(o, returnID)
+	if r == nil {
+		return fmt.Errorf("%w: %s", ErrReturnNotFound, returnID)
+	}
+	if !CanTransitionReturn(r.Status, order.ReturnStatus_RETURN_STATUS_REFUNDED) {
+		return fmt.Errorf("%w from %s to %s", ErrInvalidReturnTransition, r.Status, order.ReturnStatus_RETURN_STATUS_REFUNDED)
+	}
+	// An amount of 0 would refund the whole payment.
+	if r.RefundCents <= 0 || o.PaymentTransactionID == "" {
+		return nil
+	}
+
+	err = s.callPayment(ctx, "RefundPayment", true, func(ctx context.Context) error {
+		_, err := s.payments.RefundPayment(ctx, &payment.RefundPaymentRequest{
+			TransactionID: o.PaymentTransactionID,
+			AmountCents:   r.RefundCents,
+			RefundID:      r.ID,
+			Reason:        "return " + r.ID,
+		})
+		return err
+	})
+	if err != nil {
+		logger.WarnContext(ctx, "refunding return failed", "return_id", r.ID, logging.Err(err))
+		if msg, ok := paymentRejection(err); ok {
+			return fmt.Errorf("%w: %s", ErrRefundRejected, msg)
+		}
+		return paymentFailure(err)
+	}
+	logger.InfoContext(ctx, "return refunded", "return_id", r.ID, "refund_cents", r.RefundCents)
+	return nil
+}
+
+func (s *OrderService) changeReturn(ctx context.Context, orderID string, change *ReturnChange, version int64) (*order.Order, error) {
+	o, err := s.repo.UpdateStatus(ctx, orderID, StatusUpdate{
+		Return:  change,
+		At:      s.now(),
+		Version: version,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.customers.record(o)
+
+	r := findReturn(o, change.ID)
+	logger.InfoContext(ctx, "order return changed", "return_id", r.ID, "status", r.Status.String())
+	s.publishes.Add(1)
+	go s.publishReturn(ctx, *o, *r, change.from)
+	return o, nil
+}
+
+func (s *OrderService) publishReturn(ctx context.Context, o order.Order, r order.Return, from order.ReturnStatus) {
+	defer s.publishes.Done()
+
+	event := order.NewOrderReturnEvent(o, r, from)
+
+	msg, err := broker.NewMessage(event.EventType, event)
+	if err != nil {
+		return
+	}
+
+	msg.SetMetadata("order_id", o.ID)
+	msg.SetMetadata("customer_id", o.CustomerID)
+	msg.SetMetadata("return_id", r.ID)
+	msg.SetMetadata("status", r.Status.String())
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	s.broker.Publish(ctx, ReturnsTopic, msg)
+}
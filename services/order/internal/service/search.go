@@ -0,0 +1,260 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+)
+
+// SearchQuery finds orders for support lookups. Every set field must match;
+// zero values mean "no filter". Results are sorted newest first.
+type SearchQuery struct {
+	// Terms match a word of the customer email or of a product name, or
+	// the start of the order ID.
+	Terms []string
+
+	Email    string
+	Products []string
+	IDPrefix string
+
+	// MinTotalCents and MaxTotalCents are inclusive.
+	MinTotalCents int64
+	MaxTotalCents int64
+
+	// CustomerID limits the search to one customer.
+	CustomerID string
+
+	Limit int
+}
+
+// ParseSearchQuery reads space separated terms. email:, product: and id:
+// restrict a term to one field; amount: takes a total in cents as N, N..M,
+// >N, >=N, <N or <=N. Other terms are free text. At least one term is
+// required.
+func ParseSearchQuery(q string) (SearchQuery, error) {
+	var query SearchQuery
+	for _, term := range strings.Fields(q) {
+		key, value, ok := strings.Cut(term, ":")
+		if !ok {
+			query.Terms = append(query.Terms, strings.ToLower(term))
+			continue
+		}
+		if value == "" {
+			return query, fmt.Errorf("%w: %s needs a value", ErrInvalidSearchQuery, key)
+		}
+		switch strings.ToLower(key) {
+		case "email":
+			query.Email = strings.ToLower(value)
+		case "product":
+			query.Products = append(query.Products, words(value)...)
+		case "id":
+			query.IDPrefix = value
+		case "amount":
+			if err := query.parseAmount(value); err != nil {
+				return query, err
+			}
+		default:
+			// Unknown keys, as in a pasted URL, are free text.
+			query.Terms = append(query.Terms, strings.ToLower(term))
+		}
+	}
+	if query.IsZero() {
+		return query, fmt.Errorf("%w: no search terms", ErrInvalidSearchQuery)
+	}
+	if query.MaxTotalCents > 0 && query.MinTotalCents > query.MaxTotalCents {
+		return query, fmt.Errorf("%w: empty amount range", ErrInvalidSearchQuery)
+	}
+	return query, nil
+}
+
+func (q *SearchQuery) parseAmount(v string) error {
+	cents := func(s string) (int64, error) {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("%w: amount %q is not a number of cents", ErrInvalidSearchQuery, s)
+		}
+		return n, nil
+	}
+
+	var err error
+	switch {
+	case strings.HasPrefix(v, ">="):
+		q.MinTotalCents, err = cents(v[2:])
+	case strings.HasPrefix(v, ">"):
+		q.MinTotalCents, err = cents(v[1:])
+		q.MinTotalCents++
+	case strings.HasPrefix(v, "<="):
+		q.MaxTotalCents, err = cents(v[2:])
+	case strings.HasPrefix(v, "<"):
+		q.MaxTotalCents, err = cents(v[1:])
+		q.MaxTotalCents--
+		if err == nil && q.MaxTotalCents < 1 {
+			err = fmt.Errorf("%w: empty amount range", ErrInvalidSearchQuery)
+		}
+	default:
+		from, to, isRange := strings.Cut(v, "..")
+		if q.MinTotalCents, err = cents(from); err != nil {
+			return err
+		}
+		if !isRange {
+			to = from
+		}
+		q.MaxTotalCents, err = cents(to)
+	}
+	return err
+}
+
+// IsZero reports whether the query has no criteria.
+func (q SearchQuery) IsZero() bool {
+	return len(q.Terms) == 0 && q.Email == "" && len(q.Products) == 0 && q.IDPrefix == "" &&
+		q.MinTotalCents == 0 && q.MaxTotalCents == 0
+}
+
+// matchesTotal checks the amount range and the customer, which the index
+// does not cover.
+func (q SearchQuery) matchesTotal(o *order.Order) bool {
+	switch {
+	case q.CustomerID != "" && o.CustomerID != q.CustomerID:
+		return false
+	case q.MinTotalCents > 0 && o.TotalCents < q.MinTotalCents:
+		return false
+	case q.MaxTotalCents > 0 && o.TotalCents > q.MaxTotalCents:
+		return false
+	}
+	return true
+}
+
+// searchIndex maps the words of customer emails and product names to order
+// IDs and keeps the IDs sorted for prefix lookups. The indexed fields do not
+// change after an order is created, but for the email of an erased
+// customer, so orders are only ever added.
+type searchIndex struct {
+	emails   map[string]map[string]struct{}
+	products map[string]map[string]struct{}
+	ids      []string
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		emails:   make(map[string]map[string]struct{}),
+		products: make(map[string]map[string]struct{}),
+	}
+}
+
+func (x *searchIndex) add(o *order.Order) {
+	for _, word := range emailWords(o.CustomerEmail) {
+		addPosting(x.emails, word, o.ID)
+	}
+	for _, item := range o.Items {
+		for _, word := range words(item.ProductName) {
+			addPosting(x.products, word, o.ID)
+		}
+	}
+	i, _ := slices.BinarySearch(x.ids, o.ID)
+	x.ids = slices.Insert(x.ids, i, o.ID)
+}
+
+// replaceEmail indexes o under email instead of its current email.
+func (x *searchIndex) replaceEmail(o *order.Order, email string) {
+	for _, word := range emailWords(o.CustomerEmail) {
+		if ids := x.emails[word]; ids != nil {
+			delete(ids, o.ID)
+			if len(ids) == 0 {
+				delete(x.emails, word)
+			}
+		}
+	}
+	for _, word := range emailWords(email) {
+		addPosting(x.emails, word, o.ID)
+	}
+}
+
+func addPosting(postings map[string]map[string]struct{}, word, id string) {
+	ids, ok := postings[word]
+	if !ok {
+		ids = make(map[string]struct{})
+		postings[word] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+// lookup returns the IDs of the orders matching the text criteria of q, or
+// nil and false when q has none and every order is a candidate.
+func (x *searchIndex) lookup(q SearchQuery) (map[string]struct{}, bool) {
+	var sets []map[string]struct{}
+	if q.Email != "" {
+		sets = append(sets, x.emails[q.Email])
+	}
+	for _, p := range q.Products {
+		sets = append(sets, x.products[p])
+	}
+	if q.IDPrefix != "" {
+		sets = append(sets, x.prefixed(q.IDPrefix))
+	}
+	for _, term := range q.Terms {
+		sets = append(sets, union(x.emails[term], x.products[term], x.prefixed(term)))
+	}
+	if len(sets) == 0 {
+		return nil, false
+	}
+
+	sort.Slice(sets, func(i, j int) bool { return len(sets[i]) < len(sets[j]) })
+	result := make(map[string]struct{}, len(sets[0]))
+	for id := range sets[0] {
+		result[id] = struct{}{}
+	}
+	for _, set := range sets[1:] {
+		for id := range result {
+			if _, ok := set[id]; !ok {
+				delete(result, id)
+			}
+		}
+	}
+	return result, true
+}
+
+func (x *searchIndex) prefixed(prefix string) map[string]struct{} {
+	out := make(map[string]struct{})
+	i, _ := slices.BinarySearch(x.ids, prefix)
+	for ; i < len(x.ids) && strings.HasPrefix(x.ids[i], prefix); i++ {
+		out[x.ids[i]] = struct{}{}
+	}
+	return out
+}
+
+func union(sets ...map[string]struct{}) map[string]struct{} {
+	out := make(map[string]struct{})
+	for _, set := range sets {
+		for id := range set {
+			out[id] = struct{}{}
+		}
+	}
+	return out
+}
+
+// emailWords indexes an address under itself, its local part, its domain
+// and their words, so "ana.silva@example.com" is found by "ana", "silva",
+// "example.com" or the full address.
+func emailWords(email string) []string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return nil
+	}
+	out := []string{email}
+	if local, domain, ok := strings.Cut(email, "@"); ok {
+		out = append(out, local, domain)
+	}
+	return append(out, words(email)...)
+}
+
+// words splits s into lower case letter and digit runs.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+)
+
+// OrderStats summarizes the orders of a tenant: counts by status over every
+// stored order, and the activity of recent windows.
+type OrderStats struct {
+	TenantID          string `json:"tenant_id"`
+	TotalOrders       int    `json:"total_orders"`
+	PaidOrders        int    `json:"paid_orders"`
+	CancelledOrders   int    `json:"cancelled_orders"`
+	ExpiredOrders     int    `json:"expired_orders"`
+	PendingOrders     int    `json:"pending_orders"`
+	DisputedOrders    int    `json:"disputed_orders"`
+	ChargedBackOrders int    `json:"charged_back_orders"`
+	TotalRevenueCents int64  `json:"total_revenue_cents"`
+	PaymentCircuit    string `json:"payment_circuit"`
+
+	Windows StatsWindows `json:"windows"`
+}
+
+// StatsWindows is the recent activity at three resolutions.
+type StatsWindows struct {
+	LastHour   StatsWindow `json:"last_hour"`
+	LastDay    StatsWindow `json:"last_day"`
+	Last30Days StatsWindow `json:"last_30_days"`
+}
+
+// StatsWindow aggregates the buckets of one resolution. Revenue sums the
+// totals of paid orders in cents, whatever their currency.
+type StatsWindow struct {
+	BucketSeconds int64         `json:"bucket_seconds"`
+	Buckets       []StatsBucket `json:"buckets"`
+
+	Orders                 int     `json:"orders"`
+	Declined               int     `json:"declined"`
+	RevenueCents           int64   `json:"revenue_cents"`
+	DeclineRate            float64 `json:"decline_rate"`
+	AverageOrderValueCents int64   `json:"average_order_value_cents"`
+}
+
+// StatsBucket counts the orders paid and declined from Start for the width
+// of its window.
+type StatsBucket struct {
+	Start        time.Time `json:"start"`
+	Orders       int       `json:"orders"`
+	Declined     int       `json:"declined"`
+	RevenueCents int64     `json:"revenue_cents"`
+}
+
+// ring keeps the last len(buckets) buckets of width. The bucket of a time
+// sits at its bucket number modulo the length and is reset when a later
+// bucket takes its place.
+type ring struct {
+	width   time.Duration
+	buckets []StatsBucket
+}
+
+func newRing(width time.Duration, n int) *ring {
+	return &ring{width: width, buckets: make([]StatsBucket, n)}
+}
+
+func (r *ring) slot(start time.Time) *StatsBucket {
+	return &r.buckets[int(start.UnixNano()/int64(r.width))%len(r.buckets)]
+}
+
+func (r *ring) add(t time.Time, paidCents int64, declined bool) {
+	start := t.Truncate(r.width)
+	b := r.slot(start)
+	if !b.Start.Equal(start) {
+		*b = StatsBucket{Start: start}
+	}
+	if declined {
+		b.Declined++
+	} else {
+		b.Orders++
+		b.RevenueCents += paidCents
+	}
+}
+
+// window returns every bucket up to the one of now, oldest first, with
+// empty buckets for the periods without orders.
+func (r *ring) window(now time.Time) StatsWindow {
+	w := StatsWindow{
+		BucketSeconds: int64(r.width / time.Second),
+		Buckets:       make([]StatsBucket, 0, len(r.buckets)),
+	}
+	current := now.Truncate(r.width)
+	for i := len(r.buckets) - 1; i >= 0; i-- {
+		start := current.Add(-time.Duration(i) * r.width)
+		b := StatsBucket{Start: start}
+		if slot := r.slot(start); slot.Start.Equal(start) {
+			b = *slot
+		}
+		w.Buckets = append(w.Buckets, b)
+		w.Orders += b.Orders
+		w.Declined += b.Declined
+		w.RevenueCents += b.RevenueCents
+	}
+	if attempts := w.Orders + w.Declined; attempts > 0 {
+		w.DeclineRate = float64(w.Declined) / float64(attempts)
+	}
+	if w.Orders > 0 {
+		w.AverageOrderValueCents = w.RevenueCents / int64(w.Orders)
+	}
+	return w
+}
+
+// windowedStats records paid and declined orders per tenant in a ring per
+// resolution. It lives in memory: a restart starts the windows empty.
+type windowedStats struct {
+	mu      sync.Mutex
+	tenants map[string]*tenantWindows
+	now     func() time.Time
+}
+
+type tenantWindows struct {
+	minutes, hours, days *ring
+}
+
+func newTenantWindows() *tenantWindows {
+	return &tenantWindows{
+		minutes: newRing(time.Minute, 60),
+		hours:   newRing(time.Hour, 24),
+		days:    newRing(24*time.Hour, 30),
+	}
+}
+
+func newWindowedStats() *windowedStats {
+	return &windowedStats{
+		tenants: make(map[string]*tenantWindows),
+		now:     time.Now,
+	}
+}
+
+func (s *windowedStats) record(tenantID string, paidCents int64, declined bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tenants[tenantID]
+	if !ok {
+		t = newTenantWindows()
+		s.tenants[tenantID] = t
+	}
+	now := s.now().UTC()
+	for _, r := range []*ring{t.minutes, t.hours, t.days} {
+		r.add(now, paidCents, declined)
+	}
+}
+
+func (s *windowedStats) windows(tenantID string) StatsWindows {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tenants[tenantID]
+	if !ok {
+		t = newTenantWindows()
+	}
+	now := s.now().UTC()
+	return StatsWindows{
+		LastHour:   t.minutes.window(now),
+		LastDay:    t.hours.window(now),
+		Last30Days: t.days.window(now),
+	}
+}
+
+// Stats summarizes the orders of the tenant of ctx.
+func (s *OrderService) Stats(ctx context.Context) (OrderStats, error) {
+	page, err := s.repo.List(ctx, ListOptions{})
+	if err != nil {
+		return OrderStats{}, err
+	}
+	orders := page.Orders
+
+	stats := OrderStats{
+		TenantID:       tenant.FromContext(ctx),
+		TotalOrders:    len(orders),
+		PaymentCircuit: s.breaker.State().String(),
+	}
+
+	for _, o := range orders {
+		switch o.Status {
+		case order.OrderStatus_ORDER_STATUS_PAID:
+			stats.PaidOrders++
+			stats.TotalRevenueCents += o.TotalCents
+		case order.OrderStatus_ORDER_STATUS_CANCELLED:
+			stats.CancelledOrders++
+			if expired(o) {
+				stats.ExpiredOrders++
+			}
+		case order.OrderStatus_ORDER_STATUS_PENDING:
+			stats.PendingOrders++
+		case order.OrderStatus_ORDER_STATUS_DISPUTED:
+			stats.DisputedOrders++
+		case order.OrderStatus_ORDER_STATUS_CHARGED_BACK:
+			stats.ChargedBackOrders++
+		}
+	}
+	stats.Windows = s.windows.windows(stats.TenantID)
+
+	return stats, nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
+	"strings"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/money"
+)
+
+// ValidationConfig bounds what CreateOrder accepts.
+type ValidationConfig struct {
+	MaxItems      int   `config:"max-order-items" usage:"Maximum items per order, 0 disables"`
+	MaxTotalCents int64 `config:"max-order-total-cents" usage:"Maximum order total in cents, 0 disables"`
+
+	// Currencies lists the accepted ISO 4217 codes.
+	Currencies []string `config:"currencies" usage:"Comma separated currency codes accepted for orders"`
+}
+
+func DefaultValidationConfig() ValidationConfig {
+	return ValidationConfig{
+		MaxItems:      50,
+		MaxTotalCents: 10_000_000,
+		Currencies:    []string{"BRL", "USD", "EUR"},
+	}
+}
+
+func (c ValidationConfig) Validate() error {
+	if len(c.Currencies) == 0 {
+		return errors.New("currencies must not be empty")
+	}
+	for _, code := range c.Currencies {
+		if _, err := money.ParseCurrency(code); err != nil {
+			return fmt.Errorf("currencies: %w", err)
+		}
+	}
+	return nil
+}
+
+// FieldError describes one invalid field of a request. Field uses the JSON
+// path of the request body, such as "items[2].quantity".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when a request has one or more invalid fields
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + " " + f.Message
+	}
+	return "invalid order: " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) add(field, format string, args ...any) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// IsValidationError checks if an error is a validation error
+func IsValidationError(err error) bool {
+	var target *ValidationError
+	return errors.As(err, &target)
+}
+
+// validate checks req against the limits. MaxTotalCents applies to the item
+// subtotal, before discounts and tax.
+func (c ValidationConfig) validate(req CreateOrderRequest) error {
+	verr := &ValidationError{}
+
+	if req.CustomerEmail == "" {
+		verr.add("customer_email", "is required")
+	} else if addr, err := mail.ParseAddress(req.CustomerEmail); err != nil || addr.Address != req.CustomerEmail {
+		verr.add("customer_email", "is not a valid email address")
+	}
+
+	if !slices.Contains(c.Currencies, req.Currency) {
+		verr.add("currency", "must be one of %s", strings.Join(c.Currencies, ", "))
+	}
+
+	if a := req.ShippingAddress; a != nil {
+		if a.Line1 == "" {
+			verr.add("shipping_address.line1", "is required")
+		}
+		if a.City == "" {
+			verr.add("shipping_address.city", "is required")
+		}
+		if len(a.Country) != 2 {
+			verr.add("shipping_address.country", "must be an ISO 3166-1 alpha-2 code")
+		}
+	}
+
+	switch {
+	case len(req.Items) == 0:
+		verr.add("items", "must contain at least one item")
+	case c.MaxItems > 0 && len(req.Items) > c.MaxItems:
+		verr.add("items", "must contain at most %d items", c.MaxItems)
+	}
+
+	total := money.New(0, req.Currency)
+	overflow := false
+	for i, item := range req.Items {
+		field := fmt.Sprintf("items[%d]", i)
+		if item.Quantity <= 0 {
+			verr.add(field+".quantity", "must be positive")
+		}
+		if item.UnitPriceCents <= 0 {
+			verr.add(field+".unit_price_cents", "must be positive")
+		}
+		if item.Quantity > 0 && item.UnitPriceCents > 0 {
+			line, err := money.New(item.UnitPriceCents, req.Currency).Mul(int64(item.Quantity))
+			if err == nil {
+				line, err = total.Add(line)
+			}
+			if err != nil {
+				overflow = true
+				continue
+			}
+			total = line
+		}
+	}
+	switch {
+	case overflow:
+		verr.add("total_cents", "is too large")
+	case c.MaxTotalCents > 0 && total.Amount > c.MaxTotalCents:
+		verr.add("total_cents", "must not exceed %d", c.MaxTotalCents)
+	}
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
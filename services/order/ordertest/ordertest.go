@@ -0,0 +1,165 @@
+// Package ordertest runs the order service in process for end-to-end tests:
+// its HTTP API on an httptest server, its events on a broker the test
+// owns, and payments through any payment client, usually one from
+// services/payment/paymenttest.
+package ordertest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/inventory"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/order/internal/handler"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/order/internal/service"
+)
+
+// CreatedTopic receives order.created events for paid orders.
+const CreatedTopic = "order.created"
+
+// Topics are the topics the order service publishes on. Start creates the
+// missing ones.
+var Topics = []string{
+	CreatedTopic,
+	service.StatusTopic,
+	service.CancellationsTopic,
+	service.DisputesTopic,
+	service.ExpiredTopic,
+	service.RequestsTopic,
+}
+
+// Options configures Start. Payment and Broker are required.
+type Options struct {
+	Payment   payment.PaymentServiceClient
+	Inventory inventory.InventoryServiceClient
+	Broker    *broker.Broker
+
+	// Clock stamps orders and their status changes; nil uses time.Now.
+	Clock func() time.Time
+}
+
+// Order is an order service reached at URL.
+type Order struct {
+	URL string
+
+	svc *service.OrderService
+}
+
+// Start runs an order service until tb ends. Payment calls are retried
+// with millisecond backoffs so failure scenarios stay fast.
+func Start(tb testing.TB, opts Options) *Order {
+	tb.Helper()
+
+	for _, topic := range Topics {
+		if _, ok := opts.Broker.GetTopic(topic); !ok {
+			opts.Broker.CreateTopic(topic)
+		}
+	}
+
+	retry := service.DefaultRetryConfig()
+	retry.BaseDelay = time.Millisecond
+	retry.MaxDelay = 5 * time.Millisecond
+
+	svcOpts := []service.Option{service.WithRetry(retry)}
+	if opts.Inventory != nil {
+		svcOpts = append(svcOpts, service.WithInventory(opts.Inventory))
+	}
+	if opts.Clock != nil {
+		svcOpts = append(svcOpts, service.WithClock(opts.Clock))
+	}
+	svc := service.NewOrderService(service.GRPCPayments(opts.Payment), opts.Broker, CreatedTopic, svcOpts...)
+
+	mux := http.NewServeMux()
+	handler.NewOrderHandler(svc, handler.WithDeadLetters(opts.Broker)).RegisterRoutes(mux)
+	srv := httptest.NewServer(logging.Middleware(tenant.Middleware(mux)))
+	tb.Cleanup(func() {
+		srv.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		svc.Flush(ctx)
+	})
+
+	return &Order{URL: srv.URL, svc: svc}
+}
+
+// Response is the answer to a Do call.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Decode decodes the JSON body into v and fails tb if it cannot.
+func (r *Response) Decode(tb testing.TB, v interface{}) {
+	tb.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		tb.Fatalf("decode %d response %s: %v", r.StatusCode, r.Body, err)
+	}
+}
+
+// Do sends body, encoded as JSON unless nil, to the API and reads the
+// whole response. Headers are given as name, value pairs, such as
+// tenant.Header and a tenant ID.
+func (o *Order) Do(tb testing.TB, method, path string, body interface{}, headers ...string) *Response {
+	tb.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			tb.Fatalf("encode request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, o.URL+path, reader)
+	if err != nil {
+		tb.Fatalf("build %s %s: %v", method, path, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		tb.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		tb.Fatalf("read %s %s: %v", method, path, err)
+	}
+	return &Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: data}
+}
+
+// Flush waits until the events the service publishes in the background
+// have reached the broker. Call it before asserting that an event was not
+// published.
+func (o *Order) Flush(tb testing.TB) {
+	tb.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := o.svc.Flush(ctx); err != nil {
+		tb.Fatalf("flush order events: %v", err)
+	}
+}
+
+// Expire runs one sweep of the expiration job, cancelling the PENDING
+// orders created before cutoff, and returns how many it expired.
+func (o *Order) Expire(tb testing.TB, cutoff time.Time) int {
+	tb.Helper()
+	n, err := o.svc.ExpireOrders(context.Background(), cutoff, 1000)
+	if err != nil {
+		tb.Fatalf("expire orders: %v", err)
+	}
+	return n
+}
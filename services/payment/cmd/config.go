@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/chaos"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/config"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcconn"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/loadshed"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/ratelimit"
+)
+
+// Config holds the startup settings of the payment service. The tunables
+// that can be reloaded at runtime are resolved by internal/config from the
+// same file.
+type Config struct {
+	Port           int              `config:"port" usage:"gRPC server port"`
+	HealthInterval time.Duration    `config:"health-interval" usage:"Interval between readiness checks"`
+	TLS            config.ServerTLS `config:"tls"`
+
+	APIKeys   string `config:"api-keys,secret" usage:"Comma separated key:name pairs accepted as credentials"`
+	JWTSecret string `config:"jwt-secret,secret" usage:"HMAC secret for JWT validation"`
+	JWTIssuer string `config:"jwt-issuer" usage:"Required JWT issuer"`
+
+	// AdminAPIKeys are the only credentials accepted by the PaymentAdmin
+	// RPCs, which are not served without them.
+	AdminAPIKeys string `config:"admin-api-keys,secret" usage:"Comma separated key:name pairs accepted by the PaymentAdmin RPCs, empty disables them"`
+
+	SchedulerTick time.Duration    `config:"scheduler-tick" usage:"How often the subscription scheduler looks for due charges"`
+	RateLimit     ratelimit.Config `config:",inline"`
+	Concurrency   loadshed.Config  `config:",inline"`
+
+	AuditLog    string     `config:"audit-log" usage:"Audit log backend: memory, file, sqlite or postgres"`
+	AuditDSN    string     `config:"audit-dsn,secret" usage:"Audit log file path or database DSN"`
+	MetricsAddr string     `config:"metrics-addr" usage:"Address of the Prometheus /metrics listener, empty disables"`
+	AdminAddr   string     `config:"admin-addr" usage:"Address of the admin HTTP endpoint for config reloads, empty disables"`
+	REST        RESTConfig `config:"rest"`
+
+	Chaos  chaos.Config          `config:",inline"`
+	Conn   grpcconn.ServerConfig `config:",inline"`
+	Broker broker.BrokerConfig   `config:"broker"`
+	Log    logging.Config        `config:"log"`
+}
+
+func defaultConfig() Config {
+	rateLimit := ratelimit.DefaultConfig()
+	rateLimit.Rate = 0
+	rateLimit.Burst = 20
+
+	return Config{
+		Port:           50051,
+		HealthInterval: 5 * time.Second,
+		SchedulerTick:  time.Second,
+		RateLimit:      rateLimit,
+		Concurrency:    loadshed.DefaultConfig(),
+		AuditLog:       "memory",
+		MetricsAddr:    ":9090",
+		REST:           RESTConfig{Addr: ":8081", Timeout: 30 * time.Second},
+		Chaos:          chaos.DefaultConfig(),
+		Conn:           grpcconn.DefaultServerConfig(),
+		Broker:         broker.DefaultBrokerConfig(),
+		Log:            logging.DefaultConfig(),
+	}
+}
+
+// RESTConfig configures the JSON over HTTP transcoding of the payment RPCs.
+// Requests reach the gRPC port through a loopback connection, which uses
+// TLS when the server does.
+type RESTConfig struct {
+	Addr    string           `config:"addr" usage:"Address of the REST transcoding listener, empty disables"`
+	Timeout time.Duration    `config:"timeout" usage:"Deadline of transcoded unary calls"`
+	TLS     config.ClientTLS `config:"tls"`
+}
+
+func (c Config) Validate() error {
+	if err := config.CheckPort("port", c.Port); err != nil {
+		return err
+	}
+	switch c.AuditLog {
+	case "memory", "file":
+	case "sqlite", "postgres":
+		if c.AuditDSN == "" {
+			return fmt.Errorf("audit-log %s requires audit-dsn", c.AuditLog)
+		}
+	default:
+		return fmt.Errorf("unknown audit-log %q", c.AuditLog)
+	}
+	if _, err := auth.ParseStaticKeys(c.APIKeys); err != nil {
+		return fmt.Errorf("api-keys: %w", err)
+	}
+	if _, err := auth.ParseStaticKeys(c.AdminAPIKeys); err != nil {
+		return fmt.Errorf("admin-api-keys: %w", err)
+	}
+	if err := c.Concurrency.Validate(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -2,72 +2,441 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/chaos"
 	_ "github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/codec"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/config"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/events"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcconn"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/loadshed"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/metrics"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/ratelimit"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/reqmeta"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tlsutil"
 	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+	tunables "github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/payment/internal/config"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/payment/internal/rest"
 	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/payment/internal/server"
 	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/payment/internal/service"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
 )
 
 func main() {
-	port := flag.Int("port", 50051, "gRPC server port")
+	cfg := defaultConfig()
+	loader := config.Register(flag.CommandLine, "payment", &cfg)
+	tunablesLoader := tunables.RegisterFlags(flag.CommandLine)
 	flag.Parse()
+	if err := loader.Load(); err != nil {
+		logging.Fatal("invalid configuration", logging.Err(err))
+	}
+	tunablesLoader.Path = loader.Path()
+	connCfg := cfg.Conn
 
-	log.SetPrefix("[PAYMENT] ")
-	log.Printf("Starting Payment Service on port %d", *port)
+	if err := logging.Setup("payment", cfg.Log); err != nil {
+		logging.Fatal("invalid logging configuration", logging.Err(err))
+	}
+	slog.Info("starting payment service", "port", cfg.Port)
 
-	paymentSvc := service.NewPaymentService(service.DefaultPaymentConfig())
-	paymentServer := server.NewPaymentServer(paymentSvc)
+	tlsCfg := cfg.TLS.Config()
+	creds, err := tlsutil.ServerCredentials(tlsCfg)
+	if err != nil {
+		logging.Fatal("failed to load TLS credentials", logging.Err(err))
+	}
+	if tlsCfg.Enabled() {
+		slog.Info("TLS enabled", "require_client_cert", tlsCfg.RequireClientCert)
+	} else {
+		slog.Info("TLS disabled, serving plaintext gRPC")
+	}
+
+	faults := chaos.NewInjector(cfg.Chaos)
+	if cfg.Chaos.Enabled {
+		slog.Warn("fault injection enabled", "latency", cfg.Chaos.Latency.String(), "error_rate", cfg.Chaos.ErrorRate,
+			"drop_rate", cfg.Chaos.DropRate, "duplicate_rate", cfg.Chaos.DuplicateRate, "reorder_rate", cfg.Chaos.ReorderRate)
+	}
+
+	msgBroker := broker.NewBroker(cfg.Broker)
+	msgBroker.CreateTopic("payment.events")
+	eventsQueue := msgBroker.CreateQueue("payment-events-log", broker.WithMaxRetries(3), faults.QueueOption("payment-events-log"))
+	msgBroker.Subscribe("payment.events", "payment-events-log")
+	go startPaymentEventsWorker(eventsQueue)
 
-	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(loggingInterceptor),
+	auditLog, closeAudit, err := buildAuditLog(cfg.AuditLog, cfg.AuditDSN)
+	if err != nil {
+		logging.Fatal("failed to open audit log", logging.Err(err))
+	}
+	slog.Info("audit log opened", "backend", cfg.AuditLog)
+
+	paymentCfg, err := tunablesLoader.Load()
+	if err != nil {
+		logging.Fatal("invalid payment tunables", logging.Err(err))
+	}
+	logConfig("configuration loaded", paymentCfg)
+
+	registry := metrics.NewRegistry()
+	rpcLatency := grpcmw.NewServerHandlingHistogram(registry)
+
+	paymentSvc := service.NewPaymentService(
+		paymentCfg,
+		service.WithEventBroker(msgBroker, "payment.events"),
+		service.WithAuditLog(auditLog),
+		service.WithMetrics(service.NewMetrics(registry)),
 	)
+	paymentSvc.RegisterGauges(registry)
+	paymentServer := server.NewPaymentServer(paymentSvc)
+
+	reloadConfig := func() error {
+		paymentCfg, err := tunablesLoader.Load()
+		if err != nil {
+			return err
+		}
+		paymentSvc.UpdateConfig(paymentCfg)
+		logConfig("configuration reloaded", paymentCfg)
+		return nil
+	}
+
+	go func() {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		for range hup {
+			if err := reloadConfig(); err != nil {
+				slog.Warn("config reload failed, keeping previous values", logging.Err(err))
+			}
+		}
+	}()
+
+	if cfg.MetricsAddr != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", registry.Handler())
+			slog.Info("metrics listening", "addr", cfg.MetricsAddr, "path", "/metrics")
+			if err := http.ListenAndServe(cfg.MetricsAddr, mux); err != nil {
+				slog.Error("metrics listener stopped", logging.Err(err))
+			}
+		}()
+	}
+
+	if cfg.AdminAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/", server.NewAdminHandler(paymentSvc, reloadConfig))
+		if cfg.Chaos.Enabled {
+			mux.Handle("/chaos", chaos.Handler(faults))
+		}
+		go func() {
+			slog.Info("admin endpoint listening", "addr", cfg.AdminAddr)
+			if err := http.ListenAndServe(cfg.AdminAddr, logging.Middleware(mux)); err != nil {
+				slog.Error("admin endpoint stopped", logging.Err(err))
+			}
+		}()
+	}
+
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	go paymentSvc.StartScheduler(schedulerCtx, cfg.SchedulerTick)
+
+	interceptors := []grpc.UnaryServerInterceptor{
+		reqmeta.UnaryServerInterceptor(),
+		grpcmw.UnaryLoggingInterceptor(),
+		grpcmw.UnaryRecoveryInterceptor(),
+		grpcmw.UnaryMetricsInterceptor(rpcLatency),
+	}
+	streamInterceptors := []grpc.StreamServerInterceptor{
+		reqmeta.StreamServerInterceptor(),
+		grpcmw.StreamLoggingInterceptor(),
+		grpcmw.StreamRecoveryInterceptor(),
+		grpcmw.StreamMetricsInterceptor(rpcLatency),
+	}
+
+	// The PaymentAdmin RPCs take their own credentials and, like the health
+	// checks, are spared injected faults and rate limits.
+	adminKeys, err := auth.ParseStaticKeys(cfg.AdminAPIKeys)
+	if err != nil {
+		logging.Fatal("invalid admin auth configuration", logging.Err(err))
+	}
+	var adminMethods []string
+	if adminKeys.Len() > 0 {
+		adminMethods = server.AdminMethods()
+	}
+	operational := append(append([]string(nil), auth.HealthMethods...), adminMethods...)
+
+	// Injected faults are logged and measured like real ones. The hooks do
+	// nothing unless -chaos is set.
+	interceptors = append(interceptors, faults.UnaryServerInterceptor(operational...))
+	streamInterceptors = append(streamInterceptors, faults.StreamServerInterceptor(auth.HealthMethods...))
+
+	authn, err := buildAuthenticator(cfg.APIKeys, cfg.JWTSecret, cfg.JWTIssuer)
+	if err != nil {
+		logging.Fatal("invalid auth configuration", logging.Err(err))
+	}
+	if authn != nil {
+		interceptors = append(interceptors, auth.UnaryServerInterceptor(authn, operational...))
+		streamInterceptors = append(streamInterceptors, auth.StreamServerInterceptor(authn, auth.HealthMethods...))
+		slog.Info("authentication enabled for payment RPCs")
+	} else {
+		slog.Info("authentication disabled")
+	}
+	if len(adminMethods) > 0 {
+		interceptors = append(interceptors, auth.UnaryServerInterceptorFor(adminKeys, adminMethods...))
+		slog.Info("admin RPCs enabled", "keys", adminKeys.Len())
+	} else {
+		slog.Info("admin RPCs disabled, set admin-api-keys to enable them")
+	}
+	// The tenant is resolved after authentication, which may bind it.
+	interceptors = append(interceptors, tenant.UnaryServerInterceptor())
+	streamInterceptors = append(streamInterceptors, tenant.StreamServerInterceptor())
+
+	if limiterCfg := cfg.RateLimit; limiterCfg.Rate > 0 {
+		interceptors = append(interceptors,
+			ratelimit.UnaryServerInterceptor(ratelimit.NewLimiter(limiterCfg), operational...))
+		slog.Info("rate limiting enabled", "rate", limiterCfg.Rate, "burst", limiterCfg.Burst)
+	}
+
+	// The concurrency limit bounds the calls waiting out the simulated
+	// latency, which comes last so that calls turned away do not wait.
+	if concurrency := cfg.Concurrency; concurrency.MaxInFlight > 0 {
+		limiter := loadshed.NewLimiter(concurrency)
+		limiter.RegisterMetrics(registry)
+		interceptors = append(interceptors, loadshed.UnaryServerInterceptor(limiter, operational...))
+		slog.Info("concurrency limit enabled", "max_in_flight", concurrency.MaxInFlight,
+			"max_queued", concurrency.MaxQueued, "queue_timeout", concurrency.QueueTimeout.String())
+	}
+	interceptors = append(interceptors, server.UnaryLatencyInterceptor(paymentSvc))
+
+	serverOpts := append([]grpc.ServerOption{
+		grpc.Creds(creds),
+		grpc.ChainUnaryInterceptor(interceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+	}, grpcconn.ServerOptions(connCfg)...)
+	slog.Info("connection limits configured",
+		"max_idle", connCfg.MaxConnectionIdle.String(),
+		"max_age", connCfg.MaxConnectionAge.String(),
+		"max_age_grace", connCfg.MaxConnectionAgeGrace.String(),
+		"keepalive_time", connCfg.Time.String(),
+		"keepalive_timeout", connCfg.Timeout.String())
+
+	grpcServer := grpc.NewServer(serverOpts...)
 
 	payment.RegisterPaymentServiceServer(grpcServer, paymentServer)
+	if len(adminMethods) > 0 {
+		payment.RegisterPaymentAdminServer(grpcServer, server.NewAdminServer(paymentSvc))
+	}
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	healthReporter := server.NewHealthReporter(healthServer, paymentSvc, cfg.HealthInterval)
+	healthReporter.Start()
+
 	reflection.Register(grpcServer)
 
-	addr := fmt.Sprintf(":%d", *port)
+	addr := fmt.Sprintf(":%d", cfg.Port)
 	listener, err := net.Listen("tcp", addr)
 	if err != nil {
-		log.Fatalf("Failed to listen on %s: %v", addr, err)
+		logging.Fatal("failed to listen", "addr", addr, logging.Err(err))
+	}
+
+	var restServer *http.Server
+	if cfg.REST.Addr != "" {
+		restServer = startREST(cfg.REST, cfg.Port)
 	}
 
 	go func() {
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 		<-sigChan
-		log.Println("Shutting down...")
+		slog.Info("shutting down")
+		healthReporter.Shutdown()
+		stopScheduler()
+		if restServer != nil {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			restServer.Shutdown(shutdownCtx)
+			cancel()
+		}
 		grpcServer.GracefulStop()
+		if err := closeAudit(); err != nil {
+			slog.Error("failed to close audit log", logging.Err(err))
+		}
 	}()
 
-	log.Printf("Payment Service ready at %s", addr)
+	slog.Info("payment service ready", "addr", addr)
 
 	if err := grpcServer.Serve(listener); err != nil {
-		log.Fatalf("Failed to serve: %v", err)
+		logging.Fatal("failed to serve", logging.Err(err))
 	}
 }
 
-func loggingInterceptor(
-	ctx context.Context,
-	req interface{},
-	info *grpc.UnaryServerInfo,
-	handler grpc.UnaryHandler,
-) (interface{}, error) {
-	log.Printf("→ %s", info.FullMethod)
-	resp, err := handler(ctx, req)
+// startREST serves the REST transcoding of the payment RPCs on cfg.Addr.
+// Calls go through the gRPC port so its interceptors apply to them too.
+func startREST(cfg RESTConfig, port int) *http.Server {
+	creds, err := tlsutil.ClientCredentials(cfg.TLS.Config())
 	if err != nil {
-		log.Printf("← %s ERROR: %v", info.FullMethod, err)
-	} else {
-		log.Printf("← %s OK", info.FullMethod)
+		logging.Fatal("failed to load REST loopback TLS credentials", logging.Err(err))
+	}
+	conn, err := grpc.NewClient(fmt.Sprintf("localhost:%d", port),
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype("json")),
+		grpc.WithChainUnaryInterceptor(grpcmw.UnaryClientRequestIDInterceptor()),
+		grpc.WithChainStreamInterceptor(grpcmw.StreamClientRequestIDInterceptor()),
+	)
+	if err != nil {
+		logging.Fatal("failed to connect REST transcoding to the gRPC server", logging.Err(err))
+	}
+
+	gateway := rest.NewGateway(payment.NewPaymentServiceClient(conn), cfg.Timeout)
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           logging.Middleware(gateway),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		defer conn.Close()
+		slog.Info("REST transcoding listening", "addr", cfg.Addr, "prefix", "/v1")
+		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+			slog.Error("REST listener stopped", logging.Err(err))
+		}
+	}()
+	return srv
+}
+
+func startPaymentEventsWorker(queue *broker.Queue) {
+	logger := logging.Component("events")
+
+	worker := broker.NewWorker("payment-events-worker", queue, func(msg *broker.Message) error {
+		ctx := msg.Context(context.Background())
+
+		if msg.Type == events.TypePaymentCompleted {
+			var completed events.PaymentCompletedV1
+			if err := events.Decode(msg, &completed); err != nil {
+				return err
+			}
+			logger.InfoContext(ctx, completed.EventType,
+				"order_id", completed.OrderID,
+				"subscription_id", completed.SubscriptionID,
+				"cycle", completed.Cycle,
+				"transaction_id", completed.TransactionID,
+				"amount_cents", completed.AmountCents,
+				"currency", completed.Currency)
+			return nil
+		}
+
+		var event payment.PaymentEvent
+		if err := msg.Decode(&event); err != nil {
+			return err
+		}
+
+		if event.DisputeID != "" {
+			logger.InfoContext(ctx, event.EventType,
+				"order_id", event.OrderID,
+				"dispute_id", event.DisputeID,
+				"transaction_id", event.TransactionID,
+				"amount_cents", event.AmountCents,
+				"currency", event.Currency)
+			return nil
+		}
+
+		logger.InfoContext(ctx, event.EventType,
+			"order_id", event.OrderID,
+			"subscription_id", event.SubscriptionID,
+			"cycle", event.Cycle,
+			"amount_cents", event.AmountCents,
+			"currency", event.Currency)
+
+		return nil
+	})
+
+	worker.Start(context.Background())
+}
+
+func buildAuthenticator(apiKeys, jwtSecret, jwtIssuer string) (auth.Authenticator, error) {
+	var chain auth.Chain
+
+	if apiKeys != "" {
+		keys, err := auth.ParseStaticKeys(apiKeys)
+		if err != nil {
+			return nil, err
+		}
+		if keys.Len() > 0 {
+			chain = append(chain, keys)
+		}
+	}
+
+	if jwtSecret != "" {
+		chain = append(chain, auth.NewJWTValidator([]byte(jwtSecret), jwtIssuer))
+	}
+
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	return chain, nil
+}
+
+func logConfig(msg string, cfg service.PaymentConfig) {
+	slog.Info(msg,
+		"max_amount_cents", cfg.MaxAmountCents,
+		"latency", cfg.Latency.String(),
+		"latency_overrides", service.FormatLatencyOverrides(cfg.LatencyOverrides),
+		"failure_rate", cfg.FailureRate,
+		"velocity_window", cfg.VelocityWindow.String(),
+		"velocity_limits", fmt.Sprint(cfg.VelocityLimits),
+		"velocity_bypass", len(cfg.VelocityBypass))
+}
+
+// buildAuditLog opens the configured audit backend and returns a function
+// that releases it on shutdown.
+func buildAuditLog(backend, dsn string) (service.AuditLog, func() error, error) {
+	noop := func() error { return nil }
+
+	switch backend {
+	case "", "memory":
+		return service.NewInMemoryAuditLog(), noop, nil
+	case "file":
+		if dsn == "" {
+			dsn = "payment-audit.log"
+		}
+		l, err := service.NewFileAuditLog(dsn)
+		if err != nil {
+			return nil, nil, err
+		}
+		return l, l.Close, nil
+	case "sqlite", "postgres":
+		driver := "sqlite"
+		if backend == "postgres" {
+			driver = "pgx"
+		}
+		if dsn == "" {
+			return nil, nil, fmt.Errorf("audit backend %s requires -audit-dsn", backend)
+		}
+		db, err := sql.Open(driver, dsn)
+		if err != nil {
+			return nil, nil, err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		l, err := service.NewSQLAuditLog(ctx, db, driver)
+		if err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+		return l, db.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown audit backend %q", backend)
 	}
-	return resp, err
 }
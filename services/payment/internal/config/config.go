@@ -0,0 +1,228 @@
+// Package config resolves the payment service tunables from defaults, a
+// YAML file, environment variables and command-line flags.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/payment/internal/service"
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables read by the loader.
+const (
+	EnvMaxAmountCents   = "PAYMENT_MAX_AMOUNT_CENTS"
+	EnvSimulateLatency  = "PAYMENT_SIMULATE_LATENCY"
+	EnvLatencyOverrides = "PAYMENT_LATENCY_OVERRIDES"
+	EnvFailureRate      = "PAYMENT_FAILURE_RATE"
+	EnvVelocityWindow   = "PAYMENT_VELOCITY_WINDOW"
+	EnvVelocityLimits   = "PAYMENT_VELOCITY_LIMITS"
+	EnvVelocityBypass   = "PAYMENT_VELOCITY_BYPASS"
+	EnvTenantLimits     = "PAYMENT_TENANT_LIMITS"
+)
+
+// overrides holds the values set by one source. Nil fields are left alone.
+type overrides struct {
+	MaxAmountCents *int64                  `yaml:"max_amount_cents"`
+	Latency        *service.LatencyProfile `yaml:"simulate_latency"`
+	FailureRate    *float64                `yaml:"failure_rate"`
+	VelocityWindow *time.Duration          `yaml:"velocity_window"`
+
+	VelocityLimits *[]service.VelocityLimit `yaml:"velocity_limits"`
+	VelocityBypass *[]string                `yaml:"velocity_bypass"`
+
+	TenantLimits *map[string]int64 `yaml:"tenant_limits"`
+
+	LatencyOverrides *map[string]service.LatencyProfile `yaml:"latency_overrides"`
+}
+
+func (o overrides) apply(cfg *service.PaymentConfig) {
+	if o.MaxAmountCents != nil {
+		cfg.MaxAmountCents = *o.MaxAmountCents
+	}
+	if o.Latency != nil {
+		cfg.Latency = *o.Latency
+	}
+	if o.LatencyOverrides != nil {
+		cfg.LatencyOverrides = *o.LatencyOverrides
+	}
+	if o.FailureRate != nil {
+		cfg.FailureRate = *o.FailureRate
+	}
+	if o.VelocityWindow != nil {
+		cfg.VelocityWindow = *o.VelocityWindow
+	}
+	if o.VelocityLimits != nil {
+		cfg.VelocityLimits = *o.VelocityLimits
+	}
+	if o.VelocityBypass != nil {
+		cfg.VelocityBypass = *o.VelocityBypass
+	}
+	if o.TenantLimits != nil {
+		cfg.TenantLimits = *o.TenantLimits
+	}
+}
+
+// Loader builds a PaymentConfig with increasing precedence: defaults, the
+// YAML file at Path, environment variables, then flags set on the command
+// line. Load can be called again to pick up file and environment changes;
+// flags keep winning.
+type Loader struct {
+	// Path is the service config file; the tunables are its top-level
+	// keys and other keys are ignored.
+	Path string
+
+	mu    sync.Mutex
+	flags overrides
+}
+
+// RegisterFlags defines the tunable flags on fs and returns a loader. The
+// caller sets Path once the flags are parsed.
+func RegisterFlags(fs *flag.FlagSet) *Loader {
+	l := &Loader{}
+
+	fs.Func("max-amount-cents", "Maximum payment amount in cents (env "+EnvMaxAmountCents+")", func(v string) error {
+		n, err := strconv.ParseInt(v, 10, 64)
+		l.flags.MaxAmountCents = &n
+		return err
+	})
+	fs.Func("simulate-latency", "Artificial latency of every payment: a duration or a profile such as pareto:50ms:1.5 (env "+EnvSimulateLatency+")", func(v string) error {
+		p, err := service.ParseLatencyProfile(v)
+		l.flags.Latency = &p
+		return err
+	})
+	fs.Func("latency-overrides", "Latency profiles of single RPCs, as ProcessPayment=uniform:50ms:150ms,RefundPayment=1s (env "+EnvLatencyOverrides+")", func(v string) error {
+		overrides, err := service.ParseLatencyOverrides(v)
+		l.flags.LatencyOverrides = &overrides
+		return err
+	})
+	fs.Func("failure-rate", "Probability between 0 and 1 that the primary gateway is unavailable (env "+EnvFailureRate+")", func(v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		l.flags.FailureRate = &f
+		return err
+	})
+	fs.Func("velocity-window", "Window for per-customer velocity checks (env "+EnvVelocityWindow+")", func(v string) error {
+		d, err := time.ParseDuration(v)
+		l.flags.VelocityWindow = &d
+		return err
+	})
+
+	return l
+}
+
+func (l *Loader) Load() (service.PaymentConfig, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cfg := service.DefaultPaymentConfig()
+
+	if l.Path != "" {
+		file, err := readFile(l.Path)
+		if err != nil {
+			return cfg, err
+		}
+		file.apply(&cfg)
+	}
+
+	env, err := readEnv()
+	if err != nil {
+		return cfg, err
+	}
+	env.apply(&cfg)
+
+	l.flags.apply(&cfg)
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+func readFile(path string) (overrides, error) {
+	var o overrides
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return o, err
+	}
+	if err := yaml.Unmarshal(data, &o); err != nil {
+		return o, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return o, nil
+}
+
+func readEnv() (overrides, error) {
+	var o overrides
+
+	if v := os.Getenv(EnvMaxAmountCents); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return o, fmt.Errorf("%s: %w", EnvMaxAmountCents, err)
+		}
+		o.MaxAmountCents = &n
+	}
+	if v := os.Getenv(EnvSimulateLatency); v != "" {
+		p, err := service.ParseLatencyProfile(v)
+		if err != nil {
+			return o, fmt.Errorf("%s: %w", EnvSimulateLatency, err)
+		}
+		o.Latency = &p
+	}
+	if v, ok := os.LookupEnv(EnvLatencyOverrides); ok {
+		overrides, err := service.ParseLatencyOverrides(v)
+		if err != nil {
+			return o, fmt.Errorf("%s: %w", EnvLatencyOverrides, err)
+		}
+		o.LatencyOverrides = &overrides
+	}
+	if v := os.Getenv(EnvFailureRate); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return o, fmt.Errorf("%s: %w", EnvFailureRate, err)
+		}
+		o.FailureRate = &f
+	}
+	if v := os.Getenv(EnvVelocityWindow); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return o, fmt.Errorf("%s: %w", EnvVelocityWindow, err)
+		}
+		o.VelocityWindow = &d
+	}
+	if v, ok := os.LookupEnv(EnvVelocityLimits); ok {
+		limits, err := service.ParseVelocityLimits(v)
+		if err != nil {
+			return o, fmt.Errorf("%s: %w", EnvVelocityLimits, err)
+		}
+		o.VelocityLimits = &limits
+	}
+	if v, ok := os.LookupEnv(EnvVelocityBypass); ok {
+		bypass := splitList(v)
+		o.VelocityBypass = &bypass
+	}
+	if v, ok := os.LookupEnv(EnvTenantLimits); ok {
+		limits, err := service.ParseTenantLimits(v)
+		if err != nil {
+			return o, fmt.Errorf("%s: %w", EnvTenantLimits, err)
+		}
+		o.TenantLimits = &limits
+	}
+
+	return o, nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,340 @@
+// Package rest serves the payment RPCs as JSON over HTTP, following the
+// google.api.http annotations of proto/payment/payment.proto. Every request
+// is sent to the gRPC server as a call, so authentication, tenants, rate
+// limits and metrics apply to both transports alike.
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/reqmeta"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var logger = logging.Component("rest")
+
+// maxBodyBytes bounds the JSON bodies of requests.
+const maxBodyBytes = 1 << 20
+
+// ErrorResponse is the body of failed requests. Code is the name of the
+// gRPC status code; Reason, Metadata and Fields carry its details.
+type ErrorResponse struct {
+	Error     string                  `json:"error"`
+	Code      string                  `json:"code"`
+	Reason    string                  `json:"reason,omitempty"`
+	Metadata  map[string]string       `json:"metadata,omitempty"`
+	Fields    []grpcmw.FieldViolation `json:"fields,omitempty"`
+	RequestID string                  `json:"request_id,omitempty"`
+}
+
+// Gateway transcodes HTTP requests into calls of client, usually connected
+// to the gRPC port of the same process.
+type Gateway struct {
+	client  payment.PaymentServiceClient
+	timeout time.Duration
+	mux     *http.ServeMux
+}
+
+// NewGateway creates a gateway whose calls are given timeout to complete;
+// 0 leaves them without a deadline.
+func NewGateway(client payment.PaymentServiceClient, timeout time.Duration) *Gateway {
+	g := &Gateway{client: client, timeout: timeout, mux: http.NewServeMux()}
+
+	g.mux.HandleFunc("POST /v1/payments", g.processPayment)
+	g.mux.HandleFunc("GET /v1/payments/held", g.listHeld)
+	g.mux.HandleFunc("GET /v1/payments/{transaction_id}", g.getStatus)
+	g.mux.HandleFunc("POST /v1/payments/{transaction_id}/cancel", g.cancel)
+	g.mux.HandleFunc("POST /v1/payments/{transaction_id}/refund", g.refund)
+	g.mux.HandleFunc("POST /v1/payments/{transaction_id}/review", g.review)
+	g.mux.HandleFunc("GET /v1/payments/{transaction_id}/history", g.history)
+	g.mux.HandleFunc("POST /v1/payments/{transaction_id}/disputes", g.createDispute)
+	g.mux.HandleFunc("POST /v1/disputes/{dispute_id}/resolve", g.resolveDispute)
+	g.mux.HandleFunc("POST /v1/subscriptions", g.createSubscription)
+	g.mux.HandleFunc("POST /v1/subscriptions/{subscription_id}/cancel", g.cancelSubscription)
+	g.mux.HandleFunc("GET /v1/reconciliation", g.reconciliation)
+	g.mux.HandleFunc("GET /v1/transactions/export", g.export)
+	g.mux.HandleFunc("/", g.notFound)
+
+	return g
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
+
+func (g *Gateway) processPayment(w http.ResponseWriter, r *http.Request) {
+	req := &payment.PaymentRequest{}
+	if !decodeBody(w, r, req) {
+		return
+	}
+	g.transcode(w, r, func(ctx context.Context) (interface{}, error) {
+		return g.client.ProcessPayment(ctx, req)
+	})
+}
+
+func (g *Gateway) listHeld(w http.ResponseWriter, r *http.Request) {
+	g.transcode(w, r, func(ctx context.Context) (interface{}, error) {
+		return g.client.ListHeldPayments(ctx, &payment.ListHeldPaymentsRequest{})
+	})
+}
+
+func (g *Gateway) getStatus(w http.ResponseWriter, r *http.Request) {
+	g.transcode(w, r, func(ctx context.Context) (interface{}, error) {
+		return g.client.GetPaymentStatus(ctx, &payment.PaymentStatusRequest{TransactionID: r.PathValue("transaction_id")})
+	})
+}
+
+func (g *Gateway) cancel(w http.ResponseWriter, r *http.Request) {
+	req := &payment.CancelPaymentRequest{}
+	if !decodeBody(w, r, req) {
+		return
+	}
+	req.TransactionID = r.PathValue("transaction_id")
+	g.transcode(w, r, func(ctx context.Context) (interface{}, error) {
+		return g.client.CancelPayment(ctx, req)
+	})
+}
+
+func (g *Gateway) refund(w http.ResponseWriter, r *http.Request) {
+	req := &payment.RefundPaymentRequest{}
+	if !decodeBody(w, r, req) {
+		return
+	}
+	req.TransactionID = r.PathValue("transaction_id")
+	g.transcode(w, r, func(ctx context.Context) (interface{}, error) {
+		return g.client.RefundPayment(ctx, req)
+	})
+}
+
+func (g *Gateway) review(w http.ResponseWriter, r *http.Request) {
+	req := &payment.ReviewPaymentRequest{}
+	if !decodeBody(w, r, req) {
+		return
+	}
+	req.TransactionID = r.PathValue("transaction_id")
+	g.transcode(w, r, func(ctx context.Context) (interface{}, error) {
+		return g.client.ReviewPayment(ctx, req)
+	})
+}
+
+func (g *Gateway) history(w http.ResponseWriter, r *http.Request) {
+	g.transcode(w, r, func(ctx context.Context) (interface{}, error) {
+		return g.client.GetTransactionHistory(ctx, &payment.TransactionHistoryRequest{TransactionID: r.PathValue("transaction_id")})
+	})
+}
+
+func (g *Gateway) createDispute(w http.ResponseWriter, r *http.Request) {
+	req := &payment.CreateDisputeRequest{}
+	if !decodeBody(w, r, req) {
+		return
+	}
+	req.TransactionID = r.PathValue("transaction_id")
+	g.transcode(w, r, func(ctx context.Context) (interface{}, error) {
+		return g.client.CreateDispute(ctx, req)
+	})
+}
+
+func (g *Gateway) resolveDispute(w http.ResponseWriter, r *http.Request) {
+	req := &payment.ResolveDisputeRequest{}
+	if !decodeBody(w, r, req) {
+		return
+	}
+	req.DisputeID = r.PathValue("dispute_id")
+	g.transcode(w, r, func(ctx context.Context) (interface{}, error) {
+		return g.client.ResolveDispute(ctx, req)
+	})
+}
+
+func (g *Gateway) createSubscription(w http.ResponseWriter, r *http.Request) {
+	req := &payment.CreateSubscriptionRequest{}
+	if !decodeBody(w, r, req) {
+		return
+	}
+	g.transcode(w, r, func(ctx context.Context) (interface{}, error) {
+		return g.client.CreateSubscription(ctx, req)
+	})
+}
+
+func (g *Gateway) cancelSubscription(w http.ResponseWriter, r *http.Request) {
+	req := &payment.CancelSubscriptionRequest{SubscriptionID: r.PathValue("subscription_id")}
+	g.transcode(w, r, func(ctx context.Context) (interface{}, error) {
+		return g.client.CancelSubscription(ctx, req)
+	})
+}
+
+func (g *Gateway) reconciliation(w http.ResponseWriter, r *http.Request) {
+	req := &payment.ReconciliationReportRequest{}
+	if !queryTime(w, r, "from", &req.From) || !queryTime(w, r, "to", &req.To) {
+		return
+	}
+	g.transcode(w, r, func(ctx context.Context) (interface{}, error) {
+		return g.client.GetReconciliationReport(ctx, req)
+	})
+}
+
+// export writes the chunks of the ExportTransactions stream as they
+// arrive. A failure after the first chunk can only end the response early.
+func (g *Gateway) export(w http.ResponseWriter, r *http.Request) {
+	req := &payment.ExportTransactionsRequest{Format: r.URL.Query().Get("format")}
+	if !queryTime(w, r, "from", &req.From) || !queryTime(w, r, "to", &req.To) {
+		return
+	}
+
+	// Exports may outlast the timeout of unary calls.
+	ctx, cancel := context.WithCancel(outgoingContext(r))
+	defer cancel()
+
+	stream, err := g.client.ExportTransactions(ctx, req)
+	if err != nil {
+		writeGRPCError(w, r, err)
+		return
+	}
+	chunk, err := stream.Recv()
+	if err != nil && !errors.Is(err, io.EOF) {
+		writeGRPCError(w, r, err)
+		return
+	}
+
+	contentType := "application/x-ndjson"
+	if req.Format == "" || req.Format == "csv" {
+		contentType = "text/csv"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	for err == nil {
+		if _, werr := w.Write(chunk.Data); werr != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		chunk, err = stream.Recv()
+	}
+	if !errors.Is(err, io.EOF) {
+		logger.WarnContext(r.Context(), "export stream failed", logging.Err(err))
+	}
+}
+
+func (g *Gateway) notFound(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusNotFound, ErrorResponse{
+		Error:     "No route for " + r.Method + " " + r.URL.Path,
+		Code:      "NOT_FOUND",
+		RequestID: logging.RequestID(r.Context()),
+	})
+}
+
+// transcode runs call with the credentials, tenant and request ID of r and
+// writes its response message as JSON.
+func (g *Gateway) transcode(w http.ResponseWriter, r *http.Request, call func(ctx context.Context) (interface{}, error)) {
+	ctx := outgoingContext(r)
+	if g.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.timeout)
+		defer cancel()
+	}
+
+	resp, err := call(ctx)
+	if err != nil {
+		writeGRPCError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// outgoingContext forwards the Authorization, X-API-Key, X-Tenant-Id and
+// X-Actor headers of r as gRPC metadata. The request ID is forwarded by the client
+// interceptors.
+func outgoingContext(r *http.Request) context.Context {
+	var pairs []string
+	for header, key := range map[string]string{
+		"Authorization":     auth.AuthorizationHeader,
+		"X-API-Key":         auth.APIKeyHeader,
+		tenant.Header:       tenant.MetadataKey,
+		reqmeta.ActorHeader: reqmeta.ActorMetadataKey,
+	} {
+		if v := r.Header.Get(header); v != "" {
+			pairs = append(pairs, key, v)
+		}
+	}
+	return metadata.AppendToOutgoingContext(r.Context(), pairs...)
+}
+
+// decodeBody decodes an optional JSON body into msg. An empty body leaves
+// msg unchanged.
+func decodeBody(w http.ResponseWriter, r *http.Request, msg interface{}) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(msg); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error:     "Invalid request body: " + err.Error(),
+			Code:      "INVALID_ARGUMENT",
+			RequestID: logging.RequestID(r.Context()),
+		})
+		return false
+	}
+	return true
+}
+
+// queryTime parses the query parameter name, an RFC 3339 time or a
+// YYYY-MM-DD date, into t. A missing parameter leaves t zero.
+func queryTime(w http.ResponseWriter, r *http.Request, name string, t *time.Time) bool {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return true
+	}
+	var err error
+	if *t, err = time.Parse(time.RFC3339, s); err == nil {
+		return true
+	}
+	if *t, err = time.Parse(time.DateOnly, s); err == nil {
+		return true
+	}
+	writeJSON(w, http.StatusBadRequest, ErrorResponse{
+		Error:     name + " must be an RFC 3339 time or a YYYY-MM-DD date",
+		Code:      "INVALID_ARGUMENT",
+		Fields:    []grpcmw.FieldViolation{{Field: name, Description: "must be an RFC 3339 time or a YYYY-MM-DD date"}},
+		RequestID: logging.RequestID(r.Context()),
+	})
+	return false
+}
+
+// writeGRPCError translates a gRPC status into an HTTP response, keeping
+// the ErrorInfo reason and field violations of the status.
+func writeGRPCError(w http.ResponseWriter, r *http.Request, err error) {
+	st := status.Convert(err)
+	resp := ErrorResponse{
+		Error:     st.Message(),
+		Code:      grpcmw.CodeName(st.Code()),
+		Fields:    grpcmw.FieldViolations(err),
+		RequestID: logging.RequestID(r.Context()),
+	}
+	if info, ok := grpcmw.Reason(err); ok {
+		resp.Reason = info.Reason
+		resp.Metadata = info.Metadata
+	}
+
+	httpStatus := grpcmw.HTTPStatus(st.Code())
+	if httpStatus == http.StatusServiceUnavailable {
+		w.Header().Set("Retry-After", "1")
+	}
+	writeJSON(w, httpStatus, resp)
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/payment/internal/service"
+)
+
+type configView struct {
+	MaxAmountCents  int64    `json:"max_amount_cents"`
+	SimulateLatency string   `json:"simulate_latency"`
+	FailureRate     float64  `json:"failure_rate"`
+	VelocityWindow  string   `json:"velocity_window"`
+	VelocityLimits  []string `json:"velocity_limits"`
+	VelocityBypass  []string `json:"velocity_bypass"`
+
+	TenantLimits     map[string]int64  `json:"tenant_limits,omitempty"`
+	LatencyOverrides map[string]string `json:"latency_overrides,omitempty"`
+}
+
+// NewAdminHandler serves the operator endpoints: GET /config returns the
+// tunables in effect and POST /config/reload calls reload.
+func NewAdminHandler(svc *service.PaymentService, reload func() error) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/config", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeConfig(w, svc.Config())
+	})
+
+	mux.HandleFunc("/config/reload", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := reload(); err != nil {
+			logging.Component("admin").WarnContext(r.Context(), "config reload failed", logging.Err(err))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		writeConfig(w, svc.Config())
+	})
+
+	return mux
+}
+
+func writeConfig(w http.ResponseWriter, cfg service.PaymentConfig) {
+	limits := make([]string, len(cfg.VelocityLimits))
+	for i, l := range cfg.VelocityLimits {
+		limits[i] = l.String()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(configView{
+		MaxAmountCents:   cfg.MaxAmountCents,
+		SimulateLatency:  cfg.Latency.String(),
+		FailureRate:      cfg.FailureRate,
+		VelocityWindow:   cfg.VelocityWindow.String(),
+		VelocityLimits:   limits,
+		VelocityBypass:   cfg.VelocityBypass,
+		TenantLimits:     cfg.TenantLimits,
+		LatencyOverrides: latencyOverrides(cfg),
+	})
+}
+
+// latencyOverrides returns the latency overrides of cfg as strings, nil
+// without any.
+func latencyOverrides(cfg service.PaymentConfig) map[string]string {
+	if len(cfg.LatencyOverrides) == 0 {
+		return nil
+	}
+	overrides := make(map[string]string, len(cfg.LatencyOverrides))
+	for rpc, p := range cfg.LatencyOverrides {
+		overrides[rpc] = p.String()
+	}
+	return overrides
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/payment/internal/service"
+)
+
+// AdminServer implements the PaymentAdmin service. Its methods change the
+// running service, so they take the admin credentials rather than the
+// ones accepted for payments.
+type AdminServer struct {
+	payment.UnimplementedPaymentAdminServer
+	svc *service.PaymentService
+}
+
+func NewAdminServer(svc *service.PaymentService) *AdminServer {
+	return &AdminServer{svc: svc}
+}
+
+// AdminMethods returns the full method names of the PaymentAdmin service.
+func AdminMethods() []string {
+	desc := payment.PaymentAdmin_ServiceDesc
+	methods := make([]string, len(desc.Methods))
+	for i, m := range desc.Methods {
+		methods[i] = "/" + desc.ServiceName + "/" + m.MethodName
+	}
+	return methods
+}
+
+func (s *AdminServer) ListIdempotencyKeys(ctx context.Context, req *payment.ListIdempotencyKeysRequest) (*payment.ListIdempotencyKeysResponse, error) {
+	logger.DebugContext(ctx, "ListIdempotencyKeys", "prefix", req.Prefix, "limit", req.Limit)
+
+	if req.Limit < 0 {
+		return nil, grpcmw.BadRequest("invalid limit", grpcmw.FieldViolation{Field: "limit", Description: "must not be negative"})
+	}
+
+	keys, total := s.svc.IdempotencyKeys(ctx, req.Prefix, int(req.Limit))
+	return &payment.ListIdempotencyKeysResponse{Keys: keys, Total: int32(total)}, nil
+}
+
+func (s *AdminServer) EvictIdempotencyKey(ctx context.Context, req *payment.EvictIdempotencyKeyRequest) (*payment.IdempotencyKey, error) {
+	logger.InfoContext(ctx, "EvictIdempotencyKey", "key", req.Key)
+
+	if req.Key == "" {
+		return nil, grpcmw.Required("key")
+	}
+
+	key, err := s.svc.EvictIdempotencyKey(ctx, req.Key)
+	if err != nil {
+		return nil, toStatus(err, "failed to evict idempotency key")
+	}
+	return &key, nil
+}
+
+// SetConfig applies the tunables set in req like a config reload does. A
+// later reload replaces them with the values of the config file.
+func (s *AdminServer) SetConfig(ctx context.Context, req *payment.SetConfigRequest) (*payment.PaymentConfig, error) {
+	logger.InfoContext(ctx, "SetConfig")
+
+	cfg, err := s.svc.ChangeConfig(func(cfg *service.PaymentConfig) error {
+		if req.MaxAmountCents != nil {
+			cfg.MaxAmountCents = *req.MaxAmountCents
+		}
+		if req.FailureRate != nil {
+			cfg.FailureRate = *req.FailureRate
+		}
+		if req.SimulateLatencyMs != nil {
+			cfg.Latency = service.FixedLatency(time.Duration(*req.SimulateLatencyMs) * time.Millisecond)
+		}
+		if req.LatencyProfile != nil {
+			p, err := service.ParseLatencyProfile(*req.LatencyProfile)
+			if err != nil {
+				return err
+			}
+			cfg.Latency = p
+		}
+		if len(req.LatencyOverrides) > 0 {
+			overrides := maps.Clone(cfg.LatencyOverrides)
+			if overrides == nil {
+				overrides = make(map[string]service.LatencyProfile, len(req.LatencyOverrides))
+			}
+			for rpc, spec := range req.LatencyOverrides {
+				if spec == "" {
+					delete(overrides, rpc)
+					continue
+				}
+				p, err := service.ParseLatencyProfile(spec)
+				if err != nil {
+					return err
+				}
+				overrides[rpc] = p
+			}
+			cfg.LatencyOverrides = overrides
+		}
+		if req.VelocityLimits != nil {
+			limits, err := service.ParseVelocityLimits(*req.VelocityLimits)
+			if err != nil {
+				return err
+			}
+			cfg.VelocityLimits = limits
+		}
+		if len(req.TenantLimits) > 0 {
+			limits := maps.Clone(cfg.TenantLimits)
+			if limits == nil {
+				limits = make(map[string]int64, len(req.TenantLimits))
+			}
+			for id, limit := range req.TenantLimits {
+				if limit == 0 {
+					delete(limits, id)
+				} else {
+					limits[id] = limit
+				}
+			}
+			cfg.TenantLimits = limits
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, toStatus(err, "failed to change configuration")
+	}
+
+	logger.InfoContext(ctx, "configuration changed",
+		"max_amount_cents", cfg.MaxAmountCents,
+		"latency", cfg.Latency.String(),
+		"latency_overrides", service.FormatLatencyOverrides(cfg.LatencyOverrides),
+		"failure_rate", cfg.FailureRate,
+		"velocity_limits", len(cfg.VelocityLimits),
+		"tenant_limits", len(cfg.TenantLimits))
+	return configMessage(cfg), nil
+}
+
+func (s *AdminServer) GetStats(ctx context.Context, req *payment.GetStatsRequest) (*payment.PaymentStats, error) {
+	logger.DebugContext(ctx, "GetStats")
+
+	stats := s.svc.Stats()
+	byStatus := make(map[string]int64, len(stats.ByStatus))
+	for status, n := range stats.ByStatus {
+		byStatus[status.String()] = int64(n)
+	}
+	return &payment.PaymentStats{
+		TotalTransactions:     int64(stats.TotalTransactions),
+		TotalAmountCents:      stats.TotalAmountCents,
+		CachedIdempotencyKeys: int64(stats.CachedIdempotencies),
+		HeldForReview:         int64(stats.HeldForReview),
+		ActiveSubscriptions:   int64(stats.ActiveSubscriptions),
+		OpenDisputes:          int64(stats.OpenDisputes),
+		TransactionsByStatus:  byStatus,
+	}, nil
+}
+
+func configMessage(cfg service.PaymentConfig) *payment.PaymentConfig {
+	limits := make([]string, len(cfg.VelocityLimits))
+	for i, l := range cfg.VelocityLimits {
+		limits[i] = l.String()
+	}
+
+	return &payment.PaymentConfig{
+		MaxAmountCents:        cfg.MaxAmountCents,
+		FailureRate:           cfg.FailureRate,
+		SimulateLatencyMs:     cfg.Latency.Base.Milliseconds(),
+		VelocityWindowSeconds: int64(cfg.VelocityWindow / time.Second),
+		VelocityLimits:        strings.Join(limits, ","),
+		VelocityBypass:        cfg.VelocityBypass,
+		TenantLimits:          cfg.TenantLimits,
+		LatencyProfile:        cfg.Latency.String(),
+		LatencyOverrides:      latencyOverrides(cfg),
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/payment/internal/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the google.rpc.ErrorInfo domain of payment errors.
+const ErrorDomain = "payment.PaymentService"
+
+// Error reasons reported in google.rpc.ErrorInfo.
+const (
+	ReasonTransactionNotFound    = "TRANSACTION_NOT_FOUND"
+	ReasonNotHeldForReview       = "NOT_HELD_FOR_REVIEW"
+	ReasonInvalidTransition      = "INVALID_TRANSITION"
+	ReasonSubscriptionNotFound   = "SUBSCRIPTION_NOT_FOUND"
+	ReasonSubscriptionNotActive  = "SUBSCRIPTION_NOT_ACTIVE"
+	ReasonDisputeNotFound        = "DISPUTE_NOT_FOUND"
+	ReasonDisputeNotOpen         = "DISPUTE_NOT_OPEN"
+	ReasonIdempotencyKeyNotFound = "IDEMPOTENCY_KEY_NOT_FOUND"
+)
+
+// toStatus maps service errors to gRPC status errors with machine-readable
+// details. Unknown errors are logged and reported as codes.Internal with
+// the fallback message.
+func toStatus(err error, fallback string) error {
+	var invalid *service.InvalidTransitionError
+
+	switch {
+	case errors.Is(err, service.ErrTransactionNotFound):
+		return grpcmw.ErrorInfo(codes.NotFound, err.Error(), ReasonTransactionNotFound, ErrorDomain, nil)
+	case errors.Is(err, service.ErrNotHeldForReview):
+		return grpcmw.ErrorInfo(codes.NotFound, err.Error(), ReasonNotHeldForReview, ErrorDomain, nil)
+	case errors.As(err, &invalid):
+		return grpcmw.ErrorInfo(codes.FailedPrecondition, err.Error(), ReasonInvalidTransition, ErrorDomain, map[string]string{
+			"from": invalid.From.String(),
+			"to":   invalid.To.String(),
+		})
+	case errors.Is(err, service.ErrSubscriptionNotFound):
+		return grpcmw.ErrorInfo(codes.NotFound, err.Error(), ReasonSubscriptionNotFound, ErrorDomain, nil)
+	case errors.Is(err, service.ErrSubscriptionNotActive):
+		return grpcmw.ErrorInfo(codes.FailedPrecondition, err.Error(), ReasonSubscriptionNotActive, ErrorDomain, nil)
+	case errors.Is(err, service.ErrDisputeNotFound):
+		return grpcmw.ErrorInfo(codes.NotFound, err.Error(), ReasonDisputeNotFound, ErrorDomain, nil)
+	case errors.Is(err, service.ErrDisputeNotOpen):
+		return grpcmw.ErrorInfo(codes.FailedPrecondition, err.Error(), ReasonDisputeNotOpen, ErrorDomain, nil)
+	case errors.Is(err, service.ErrInvalidDisputeOutcome):
+		return grpcmw.BadRequest(err.Error(), grpcmw.FieldViolation{Field: "outcome", Description: "must be DISPUTE_STATUS_WON or DISPUTE_STATUS_LOST"})
+	case errors.Is(err, service.ErrInvalidRefundAmount):
+		return grpcmw.BadRequest(err.Error(), grpcmw.FieldViolation{Field: "amount_cents", Description: "must be between 0 and the amount left to refund"})
+	case errors.Is(err, service.ErrInvalidSubscription):
+		return grpcmw.BadRequest(err.Error(), grpcmw.FieldViolation{Field: "subscription", Description: err.Error()})
+	case errors.Is(err, service.ErrInvalidDateRange):
+		return grpcmw.BadRequest(err.Error(), grpcmw.FieldViolation{Field: "from", Description: err.Error()})
+	case errors.Is(err, service.ErrIdempotencyKeyNotFound):
+		return grpcmw.ErrorInfo(codes.NotFound, err.Error(), ReasonIdempotencyKeyNotFound, ErrorDomain, nil)
+	case errors.Is(err, service.ErrInvalidConfig):
+		return grpcmw.BadRequest(err.Error(), grpcmw.FieldViolation{Field: "config", Description: err.Error()})
+	case errors.Is(err, service.ErrUnsupportedFormat):
+		return grpcmw.BadRequest(err.Error(), grpcmw.FieldViolation{Field: "format", Description: `must be "csv" or "json"`})
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		logger.Error(fallback, logging.Err(err))
+		return grpcmw.ErrorInfo(codes.Internal, fallback, grpcmw.ReasonInternal, ErrorDomain, nil)
+	}
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"bufio"
+	"context"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const exportChunkSize = 32 * 1024
+
+func (s *PaymentServer) GetReconciliationReport(ctx context.Context, req *payment.ReconciliationReportRequest) (*payment.ReconciliationReport, error) {
+	logger.InfoContext(ctx, "GetReconciliationReport", "from", req.From, "to", req.To)
+
+	report, err := s.svc.ReconciliationReport(ctx, req.From, req.To)
+	if err != nil {
+		return nil, toStatus(err, "failed to build report")
+	}
+
+	return report, nil
+}
+
+func (s *PaymentServer) ExportTransactions(req *payment.ExportTransactionsRequest, stream grpc.ServerStreamingServer[payment.ExportChunk]) error {
+	logger.InfoContext(stream.Context(), "ExportTransactions", "from", req.From, "to", req.To, "format", req.Format)
+
+	w := bufio.NewWriterSize(chunkWriter{stream: stream}, exportChunkSize)
+
+	err := s.svc.ExportTransactions(stream.Context(), req.From, req.To, req.Format, w)
+	if err == nil {
+		err = w.Flush()
+	}
+
+	switch {
+	case err == nil:
+		return nil
+	case stream.Context().Err() != nil:
+		return status.FromContextError(stream.Context().Err()).Err()
+	default:
+		return toStatus(err, "export failed")
+	}
+}
+
+// chunkWriter sends every write as one ExportChunk message.
+type chunkWriter struct {
+	stream grpc.ServerStreamingServer[payment.ExportChunk]
+}
+
+func (c chunkWriter) Write(p []byte) (int, error) {
+	data := make([]byte, len(p))
+	copy(data, p)
+	if err := c.stream.Send(&payment.ExportChunk{Data: data}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
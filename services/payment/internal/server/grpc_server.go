@@ -2,14 +2,16 @@ package server
 
 import (
 	"context"
-	"log"
 
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/money"
 	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
 	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/payment/internal/service"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
+var logger = logging.Component("grpc")
+
 type PaymentServer struct {
 	payment.UnimplementedPaymentServiceServer
 	svc *service.PaymentService
@@ -20,58 +22,185 @@ func NewPaymentServer(svc *service.PaymentService) *PaymentServer {
 }
 
 func (s *PaymentServer) ProcessPayment(ctx context.Context, req *payment.PaymentRequest) (*payment.PaymentResponse, error) {
-	log.Printf("[GRPC] ProcessPayment: order=%s amount=%d currency=%s",
-		req.OrderID, req.AmountCents, req.Currency)
+	ctx = logging.WithAttrs(ctx, "order_id", req.OrderID)
+	logger.InfoContext(ctx, "ProcessPayment", "amount_cents", req.AmountCents, "currency", req.Currency)
 
-	if err := validatePaymentRequest(req); err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+	if violations := validatePaymentRequest(req); len(violations) > 0 {
+		return nil, grpcmw.BadRequest("invalid payment request", violations...)
 	}
 
 	resp, err := s.svc.ProcessPayment(ctx, req)
 	if err != nil {
-		log.Printf("[GRPC] ProcessPayment error: %v", err)
-		return nil, status.Error(codes.Internal, "payment processing failed")
+		return nil, toStatus(err, "payment processing failed")
 	}
 
 	if resp.Success {
-		log.Printf("[GRPC] ProcessPayment success: transaction=%s", resp.TransactionID)
+		logger.InfoContext(ctx, "payment approved", "transaction_id", resp.TransactionID)
 	} else {
-		log.Printf("[GRPC] ProcessPayment declined: code=%s", resp.ErrorCode)
+		logger.InfoContext(ctx, "payment declined", "error_code", resp.ErrorCode.String())
 	}
 
 	return resp, nil
 }
 
 func (s *PaymentServer) GetPaymentStatus(ctx context.Context, req *payment.PaymentStatusRequest) (*payment.PaymentStatusResponse, error) {
-	log.Printf("[GRPC] GetPaymentStatus: transaction=%s", req.TransactionID)
+	logger.DebugContext(ctx, "GetPaymentStatus", "transaction_id", req.TransactionID)
 
 	if req.TransactionID == "" {
-		return nil, status.Error(codes.InvalidArgument, "transaction_id is required")
+		return nil, grpcmw.Required("transaction_id")
 	}
 
 	resp, err := s.svc.GetPaymentStatus(ctx, req)
 	if err != nil {
-		if err == service.ErrTransactionNotFound {
-			return nil, status.Error(codes.NotFound, "transaction not found")
-		}
-		return nil, status.Error(codes.Internal, "failed to get status")
+		return nil, toStatus(err, "failed to get status")
 	}
 
 	return resp, nil
 }
 
-func validatePaymentRequest(req *payment.PaymentRequest) error {
+func (s *PaymentServer) GetTransactionHistory(ctx context.Context, req *payment.TransactionHistoryRequest) (*payment.TransactionHistoryResponse, error) {
+	logger.DebugContext(ctx, "GetTransactionHistory", "transaction_id", req.TransactionID)
+
+	if req.TransactionID == "" {
+		return nil, grpcmw.Required("transaction_id")
+	}
+
+	entries, err := s.svc.GetTransactionHistory(ctx, req.TransactionID)
+	if err != nil {
+		return nil, toStatus(err, "failed to read audit log")
+	}
+
+	return &payment.TransactionHistoryResponse{
+		TransactionID: req.TransactionID,
+		Entries:       entries,
+	}, nil
+}
+
+func (s *PaymentServer) CancelPayment(ctx context.Context, req *payment.CancelPaymentRequest) (*payment.PaymentStatusResponse, error) {
+	logger.InfoContext(ctx, "CancelPayment", "transaction_id", req.TransactionID, "reason", req.Reason)
+
+	if req.TransactionID == "" {
+		return nil, grpcmw.Required("transaction_id")
+	}
+
+	resp, err := s.svc.CancelPayment(ctx, req)
+	if err != nil {
+		return nil, toStatus(err, "failed to cancel payment")
+	}
+
+	logger.InfoContext(ctx, "payment cancelled", "transaction_id", resp.TransactionID, "payment_status", resp.Status.String())
+	return resp, nil
+}
+
+func (s *PaymentServer) RefundPayment(ctx context.Context, req *payment.RefundPaymentRequest) (*payment.PaymentStatusResponse, error) {
+	logger.InfoContext(ctx, "RefundPayment", "transaction_id", req.TransactionID, "amount_cents", req.AmountCents, "reason", req.Reason)
+
+	if req.TransactionID == "" {
+		return nil, grpcmw.Required("transaction_id")
+	}
+
+	resp, err := s.svc.RefundPayment(ctx, req)
+	if err != nil {
+		return nil, toStatus(err, "failed to refund payment")
+	}
+
+	logger.InfoContext(ctx, "payment refunded", "transaction_id", resp.TransactionID, "payment_status", resp.Status.String())
+	return resp, nil
+}
+
+func (s *PaymentServer) ListHeldPayments(ctx context.Context, req *payment.ListHeldPaymentsRequest) (*payment.ListHeldPaymentsResponse, error) {
+	held := s.svc.ListHeldPayments(ctx)
+	logger.DebugContext(ctx, "ListHeldPayments", "held", len(held))
+	return &payment.ListHeldPaymentsResponse{Payments: held}, nil
+}
+
+func (s *PaymentServer) ReviewPayment(ctx context.Context, req *payment.ReviewPaymentRequest) (*payment.PaymentStatusResponse, error) {
+	logger.InfoContext(ctx, "ReviewPayment", "transaction_id", req.TransactionID, "approve", req.Approve, "reviewer", req.Reviewer)
+
+	if req.TransactionID == "" {
+		return nil, grpcmw.Required("transaction_id")
+	}
+
+	resp, err := s.svc.ReviewPayment(ctx, req)
+	if err != nil {
+		return nil, toStatus(err, "failed to review payment")
+	}
+
+	return resp, nil
+}
+
+func (s *PaymentServer) CreateSubscription(ctx context.Context, req *payment.CreateSubscriptionRequest) (*payment.Subscription, error) {
+	logger.InfoContext(ctx, "CreateSubscription",
+		"amount_cents", req.AmountCents, "currency", req.Currency, "interval_seconds", req.IntervalSeconds)
+
+	sub, err := s.svc.CreateSubscription(ctx, req)
+	if err != nil {
+		return nil, toStatus(err, "failed to create subscription")
+	}
+
+	return sub, nil
+}
+
+func (s *PaymentServer) CancelSubscription(ctx context.Context, req *payment.CancelSubscriptionRequest) (*payment.Subscription, error) {
+	logger.InfoContext(ctx, "CancelSubscription", "subscription_id", req.SubscriptionID)
+
+	if req.SubscriptionID == "" {
+		return nil, grpcmw.Required("subscription_id")
+	}
+
+	sub, err := s.svc.CancelSubscription(ctx, req.SubscriptionID)
+	if err != nil {
+		return nil, toStatus(err, "failed to cancel subscription")
+	}
+
+	return sub, nil
+}
+
+func (s *PaymentServer) CreateDispute(ctx context.Context, req *payment.CreateDisputeRequest) (*payment.Dispute, error) {
+	logger.InfoContext(ctx, "CreateDispute", "transaction_id", req.TransactionID, "reason", req.Reason)
+
+	if req.TransactionID == "" {
+		return nil, grpcmw.Required("transaction_id")
+	}
+
+	dispute, err := s.svc.CreateDispute(ctx, req)
+	if err != nil {
+		return nil, toStatus(err, "failed to create dispute")
+	}
+
+	return dispute, nil
+}
+
+func (s *PaymentServer) ResolveDispute(ctx context.Context, req *payment.ResolveDisputeRequest) (*payment.Dispute, error) {
+	logger.InfoContext(ctx, "ResolveDispute", "dispute_id", req.DisputeID, "outcome", req.Outcome.String())
+
+	if req.DisputeID == "" {
+		return nil, grpcmw.Required("dispute_id")
+	}
+
+	dispute, err := s.svc.ResolveDispute(ctx, req)
+	if err != nil {
+		return nil, toStatus(err, "failed to resolve dispute")
+	}
+
+	return dispute, nil
+}
+
+func validatePaymentRequest(req *payment.PaymentRequest) []grpcmw.FieldViolation {
+	var violations []grpcmw.FieldViolation
 	if req.OrderID == "" {
-		return status.Error(codes.InvalidArgument, "order_id is required")
+		violations = append(violations, grpcmw.FieldViolation{Field: "order_id", Description: "is required"})
 	}
 	if req.AmountCents <= 0 {
-		return status.Error(codes.InvalidArgument, "amount must be positive")
+		violations = append(violations, grpcmw.FieldViolation{Field: "amount_cents", Description: "must be positive"})
 	}
 	if req.Currency == "" {
-		return status.Error(codes.InvalidArgument, "currency is required")
+		violations = append(violations, grpcmw.FieldViolation{Field: "currency", Description: "is required"})
+	} else if _, err := money.ParseCurrency(req.Currency); err != nil {
+		violations = append(violations, grpcmw.FieldViolation{Field: "currency", Description: "must be a three letter ISO 4217 code"})
 	}
 	if req.IdempotencyKey == "" {
-		return status.Error(codes.InvalidArgument, "idempotency_key is required")
+		violations = append(violations, grpcmw.FieldViolation{Field: "idempotency_key", Description: "is required"})
 	}
-	return nil
+	return violations
 }
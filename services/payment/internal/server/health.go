@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/payment/internal/service"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// PaymentServiceName is the fully-qualified service name reported by the health server.
+const PaymentServiceName = "payment.PaymentService"
+
+var healthLogger = logging.Component("health")
+
+// HealthReporter keeps the grpc.health.v1 serving status in sync with the
+// payment service readiness. The overall ("") status and the PaymentService
+// status are always updated together.
+type HealthReporter struct {
+	server   *health.Server
+	svc      *service.PaymentService
+	interval time.Duration
+
+	mu       sync.Mutex
+	stopping bool
+	stopCh   chan struct{}
+	last     healthpb.HealthCheckResponse_ServingStatus
+}
+
+func NewHealthReporter(server *health.Server, svc *service.PaymentService, interval time.Duration) *HealthReporter {
+	return &HealthReporter{
+		server:   server,
+		svc:      svc,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+		last:     healthpb.HealthCheckResponse_UNKNOWN,
+	}
+}
+
+func (h *HealthReporter) Start() {
+	h.check()
+
+	go func() {
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-h.stopCh:
+				return
+			case <-ticker.C:
+				h.check()
+			}
+		}
+	}()
+}
+
+// Shutdown marks every service as NOT_SERVING so that clients stop routing
+// new calls here while in-flight RPCs drain.
+func (h *HealthReporter) Shutdown() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.stopping {
+		return
+	}
+	h.stopping = true
+	close(h.stopCh)
+
+	h.server.Shutdown()
+	healthLogger.Info("status changed, shutting down", "status", healthpb.HealthCheckResponse_NOT_SERVING.String())
+}
+
+func (h *HealthReporter) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), h.interval)
+	defer cancel()
+
+	status := healthpb.HealthCheckResponse_SERVING
+	err := h.svc.Ping(ctx)
+	if err != nil {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.stopping || status == h.last {
+		return
+	}
+	h.last = status
+
+	h.server.SetServingStatus("", status)
+	h.server.SetServingStatus(PaymentServiceName, status)
+
+	if err != nil {
+		healthLogger.Warn("status changed", "status", status.String(), logging.Err(err))
+	} else {
+		healthLogger.Info("status changed", "status", status.String())
+	}
+}
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/payment/internal/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryLatencyInterceptor delays the PaymentService RPCs by the latency
+// profile configured for them, see service.PaymentConfig.LatencyFor. A call
+// whose deadline passes while it waits fails with DEADLINE_EXCEEDED without
+// reaching the service, like a call to a slow server would.
+func UnaryLatencyInterceptor(svc *service.PaymentService) grpc.UnaryServerInterceptor {
+	prefix := "/" + payment.PaymentService_ServiceDesc.ServiceName + "/"
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if strings.HasPrefix(info.FullMethod, prefix) {
+			if err := svc.SimulateLatency(ctx, path.Base(info.FullMethod)); err != nil {
+				return nil, status.FromContextError(err).Err()
+			}
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+)
+
+// IdempotencyKeys returns the cached ProcessPayment responses of the tenant
+// of ctx whose key starts with prefix, sorted by key. It returns at most
+// limit of them, all when limit is 0, and the number that matched.
+func (s *PaymentService) IdempotencyKeys(ctx context.Context, prefix string, limit int) ([]payment.IdempotencyKey, int) {
+	scope := tenantKey(ctx, "")
+
+	s.mu.RLock()
+	var keys []payment.IdempotencyKey
+	for key, resp := range s.processedKeys {
+		key, ok := strings.CutPrefix(key, scope)
+		if !ok || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		keys = append(keys, idempotencyKey(key, resp))
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+	total := len(keys)
+	if limit > 0 && limit < total {
+		keys = keys[:limit]
+	}
+	return keys, total
+}
+
+// EvictIdempotencyKey forgets the cached response of key in the tenant of
+// ctx, so the next ProcessPayment with it charges again. The transaction it
+// created is kept.
+func (s *PaymentService) EvictIdempotencyKey(ctx context.Context, key string) (payment.IdempotencyKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scoped := tenantKey(ctx, key)
+	resp, ok := s.processedKeys[scoped]
+	if !ok {
+		return payment.IdempotencyKey{}, fmt.Errorf("%w: %s", ErrIdempotencyKeyNotFound, key)
+	}
+	delete(s.processedKeys, scoped)
+
+	logger.InfoContext(ctx, "idempotency key evicted", "tenant_id", tenant.FromContext(ctx), "key", key)
+	return idempotencyKey(key, resp), nil
+}
+
+func idempotencyKey(key string, resp *payment.PaymentResponse) payment.IdempotencyKey {
+	return payment.IdempotencyKey{
+		Key:           key,
+		TransactionID: resp.TransactionID,
+		Success:       resp.Success,
+		ErrorCode:     resp.ErrorCode,
+		ProcessedAt:   resp.ProcessedAt,
+	}
+}
+
+// ChangeConfig calls change on a copy of the configuration in effect and
+// applies the result like UpdateConfig, unless change fails or the result
+// is out of range. Concurrent changes are applied one after the other.
+func (s *PaymentService) ChangeConfig(change func(*PaymentConfig) error) (PaymentConfig, error) {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+
+	cfg := s.Config()
+	if err := change(&cfg); err != nil {
+		return s.Config(), err
+	}
+	if err := cfg.Validate(); err != nil {
+		return s.Config(), err
+	}
+	s.UpdateConfig(cfg)
+	return s.Config(), nil
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/reqmeta"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+)
+
+// AuditLog is an append-only record of transaction status changes.
+// Append assigns the entry's sequence number.
+type AuditLog interface {
+	Append(ctx context.Context, entry payment.AuditEntry) error
+	History(ctx context.Context, transactionID string) ([]payment.AuditEntry, error)
+}
+
+type actorKey struct{}
+
+// WithActor names the internal component acting on ctx, for calls that are
+// not made on behalf of an authenticated client.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// actorFromContext prefers the authenticated principal over the actor set
+// with WithActor, and names the user a calling service acts for.
+func actorFromContext(ctx context.Context) string {
+	if p, ok := auth.FromContext(ctx); ok {
+		if user := reqmeta.Actor(ctx); user != "" && user != p.Subject {
+			return p.Method + ":" + p.Subject + " for " + user
+		}
+		return p.Method + ":" + p.Subject
+	}
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return "anonymous"
+}
+
+// InMemoryAuditLog keeps entries for the lifetime of the process.
+type InMemoryAuditLog struct {
+	mu      sync.RWMutex
+	seq     int64
+	entries map[string][]payment.AuditEntry
+}
+
+func NewInMemoryAuditLog() *InMemoryAuditLog {
+	return &InMemoryAuditLog{entries: make(map[string][]payment.AuditEntry)}
+}
+
+func (l *InMemoryAuditLog) Append(ctx context.Context, entry payment.AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.seq++
+	entry.Sequence = l.seq
+	l.entries[entry.TransactionID] = append(l.entries[entry.TransactionID], entry)
+	return nil
+}
+
+func (l *InMemoryAuditLog) History(ctx context.Context, transactionID string) ([]payment.AuditEntry, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return append([]payment.AuditEntry(nil), l.entries[transactionID]...), nil
+}
+
+// FileAuditLog appends entries as JSON lines to a file opened in append
+// mode. History scans the whole file, which is fine for compliance lookups
+// but not for hot paths.
+type FileAuditLog struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	seq  int64
+}
+
+func NewFileAuditLog(path string) (*FileAuditLog, error) {
+	l := &FileAuditLog{path: path}
+
+	// Resume the sequence from the existing log.
+	err := l.scan(func(e payment.AuditEntry) {
+		if e.Sequence > l.seq {
+			l.seq = e.Sequence
+		}
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, err
+	}
+	l.file = f
+
+	return l, nil
+}
+
+func (l *FileAuditLog) Append(ctx context.Context, entry payment.AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.Sequence = l.seq + 1
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	l.seq = entry.Sequence
+	return nil
+}
+
+func (l *FileAuditLog) History(ctx context.Context, transactionID string) ([]payment.AuditEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var entries []payment.AuditEntry
+	err := l.scan(func(e payment.AuditEntry) {
+		if e.TransactionID == transactionID {
+			entries = append(entries, e)
+		}
+	})
+	return entries, err
+}
+
+func (l *FileAuditLog) scan(fn func(payment.AuditEntry)) error {
+	f, err := os.Open(l.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e payment.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("corrupt audit log %s: %w", l.path, err)
+		}
+		fn(e)
+	}
+	return scanner.Err()
+}
+
+func (l *FileAuditLog) Close() error {
+	return l.file.Close()
+}
+
+// SQLAuditLog stores entries in a payment_audit_log table. The sequence is
+// the table's auto-generated primary key.
+type SQLAuditLog struct {
+	db       *sql.DB
+	postgres bool
+}
+
+// NewSQLAuditLog creates the table if needed. driver is the name passed to
+// sql.Open and selects the SQL dialect ("pgx"/"postgres" or sqlite).
+func NewSQLAuditLog(ctx context.Context, db *sql.DB, driver string) (*SQLAuditLog, error) {
+	l := &SQLAuditLog{
+		db:       db,
+		postgres: driver == "pgx" || driver == "postgres",
+	}
+
+	idColumn := "sequence INTEGER PRIMARY KEY AUTOINCREMENT"
+	if l.postgres {
+		idColumn = "sequence BIGSERIAL PRIMARY KEY"
+	}
+
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS payment_audit_log (
+		`+idColumn+`,
+		transaction_id  TEXT NOT NULL,
+		actor           TEXT NOT NULL,
+		previous_status INTEGER NOT NULL,
+		new_status      INTEGER NOT NULL,
+		reason          TEXT NOT NULL,
+		recorded_at     TEXT NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create audit table: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS payment_audit_log_tx ON payment_audit_log (transaction_id)`)
+	if err != nil {
+		return nil, fmt.Errorf("create audit index: %w", err)
+	}
+
+	return l, nil
+}
+
+// rebind rewrites ? placeholders as $n for Postgres.
+func (l *SQLAuditLog) rebind(query string) string {
+	if !l.postgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (l *SQLAuditLog) Append(ctx context.Context, entry payment.AuditEntry) error {
+	_, err := l.db.ExecContext(ctx, l.rebind(`INSERT INTO payment_audit_log
+		(transaction_id, actor, previous_status, new_status, reason, recorded_at)
+		VALUES (?, ?, ?, ?, ?, ?)`),
+		entry.TransactionID,
+		entry.Actor,
+		int32(entry.PreviousStatus),
+		int32(entry.NewStatus),
+		entry.Reason,
+		entry.RecordedAt.UTC().Format(time.RFC3339Nano),
+	)
+	return err
+}
+
+func (l *SQLAuditLog) History(ctx context.Context, transactionID string) ([]payment.AuditEntry, error) {
+	rows, err := l.db.QueryContext(ctx, l.rebind(`SELECT sequence, transaction_id, actor, previous_status, new_status, reason, recorded_at
+		FROM payment_audit_log WHERE transaction_id = ? ORDER BY sequence`), transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []payment.AuditEntry
+	for rows.Next() {
+		var (
+			e          payment.AuditEntry
+			prev, next int32
+			recordedAt string
+		)
+		if err := rows.Scan(&e.Sequence, &e.TransactionID, &e.Actor, &prev, &next, &e.Reason, &recordedAt); err != nil {
+			return nil, err
+		}
+		e.PreviousStatus = payment.PaymentStatus(prev)
+		e.NewStatus = payment.PaymentStatus(next)
+		if e.RecordedAt, err = time.Parse(time.RFC3339Nano, recordedAt); err != nil {
+			return nil, fmt.Errorf("audit entry %d: %w", e.Sequence, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// GetTransactionHistory returns the audit trail of a transaction. Entries
+// recorded by a durable log before a restart are returned even though the
+// transaction itself is no longer in memory.
+func (s *PaymentService) GetTransactionHistory(ctx context.Context, transactionID string) ([]payment.AuditEntry, error) {
+	entries, err := s.audit.History(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		s.mu.RLock()
+		_, ok := s.transactions[transactionID]
+		s.mu.RUnlock()
+		if !ok {
+			return nil, ErrTransactionNotFound
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+	"github.com/google/uuid"
+)
+
+// CreateDispute simulates a chargeback request from the customer's bank.
+// The payment must be COMPLETED and moves to DISPUTED until the dispute is
+// resolved.
+func (s *PaymentService) CreateDispute(ctx context.Context, req *payment.CreateDisputeRequest) (*payment.Dispute, error) {
+	reason := req.Reason
+	if reason == "" {
+		reason = "unspecified"
+	}
+
+	s.mu.Lock()
+
+	tx, ok := s.transactionLocked(ctx, req.TransactionID)
+	if !ok {
+		s.mu.Unlock()
+		return nil, ErrTransactionNotFound
+	}
+
+	dispute := &payment.Dispute{
+		DisputeID:     "dsp_" + uuid.New().String()[:8],
+		TransactionID: tx.TransactionID,
+		OrderID:       tx.OrderID,
+		AmountCents:   tx.AmountCents,
+		Currency:      tx.Currency,
+		Reason:        reason,
+		Status:        payment.DisputeStatus_DISPUTE_STATUS_OPEN,
+		OpenedAt:      s.now(),
+	}
+
+	if err := s.transitionLocked(ctx, tx, payment.PaymentStatus_PAYMENT_STATUS_DISPUTED, "dispute "+dispute.DisputeID+" opened: "+reason); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	s.disputes[dispute.DisputeID] = dispute
+	out := cloneDispute(dispute)
+
+	s.mu.Unlock()
+
+	logger.InfoContext(ctx, "dispute opened",
+		"dispute_id", out.DisputeID,
+		"transaction_id", out.TransactionID,
+		"amount_cents", out.AmountCents,
+		"currency", out.Currency,
+		"reason", reason)
+	s.publishDisputeEvent(out)
+
+	return out, nil
+}
+
+// ResolveDispute closes an open dispute. WON returns the payment to
+// COMPLETED; LOST reverses the funds and leaves it CHARGED_BACK.
+func (s *PaymentService) ResolveDispute(ctx context.Context, req *payment.ResolveDisputeRequest) (*payment.Dispute, error) {
+	var to payment.PaymentStatus
+	switch req.Outcome {
+	case payment.DisputeStatus_DISPUTE_STATUS_WON:
+		to = payment.PaymentStatus_PAYMENT_STATUS_COMPLETED
+	case payment.DisputeStatus_DISPUTE_STATUS_LOST:
+		to = payment.PaymentStatus_PAYMENT_STATUS_CHARGED_BACK
+	default:
+		return nil, ErrInvalidDisputeOutcome
+	}
+
+	s.mu.Lock()
+
+	dispute, ok := s.disputes[req.DisputeID]
+	if !ok {
+		s.mu.Unlock()
+		return nil, ErrDisputeNotFound
+	}
+	if dispute.Status != payment.DisputeStatus_DISPUTE_STATUS_OPEN {
+		s.mu.Unlock()
+		return nil, ErrDisputeNotOpen
+	}
+
+	tx, ok := s.transactionLocked(ctx, dispute.TransactionID)
+	if !ok {
+		s.mu.Unlock()
+		return nil, ErrTransactionNotFound
+	}
+
+	reason := "dispute " + dispute.DisputeID + " " + req.Outcome.String()
+	if req.Note != "" {
+		reason += ": " + req.Note
+	}
+	if err := s.transitionLocked(ctx, tx, to, reason); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+
+	dispute.Status = req.Outcome
+	dispute.Note = req.Note
+	dispute.ResolvedAt = s.now()
+	out := cloneDispute(dispute)
+
+	s.mu.Unlock()
+
+	logger.InfoContext(ctx, "dispute resolved",
+		"dispute_id", out.DisputeID,
+		"outcome", out.Status.String(),
+		"transaction_id", out.TransactionID,
+		"payment_status", to.String())
+	s.publishDisputeEvent(out)
+
+	return out, nil
+}
+
+func (s *PaymentService) publishDisputeEvent(d *payment.Dispute) {
+	if s.broker == nil {
+		return
+	}
+
+	event := payment.NewDisputeEvent(d)
+
+	msg, err := broker.NewMessage(event.EventType, event)
+	if err != nil {
+		return
+	}
+	msg.SetMetadata("dispute_id", d.DisputeID)
+	msg.SetMetadata("order_id", d.OrderID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s.broker.Publish(ctx, s.topicName, msg)
+}
+
+func cloneDispute(d *payment.Dispute) *payment.Dispute {
+	return &payment.Dispute{
+		DisputeID:     d.DisputeID,
+		TransactionID: d.TransactionID,
+		OrderID:       d.OrderID,
+		AmountCents:   d.AmountCents,
+		Currency:      d.Currency,
+		Reason:        d.Reason,
+		Status:        d.Status,
+		Note:          d.Note,
+		OpenedAt:      d.OpenedAt,
+		ResolvedAt:    d.ResolvedAt,
+	}
+}
@@ -1,8 +1,74 @@
 package service
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+)
 
 var (
 	// ErrTransactionNotFound is returned when a transaction doesn't exist
 	ErrTransactionNotFound = errors.New("transaction not found")
+
+	// ErrStoreUnavailable is returned when the transaction store cannot be reached
+	ErrStoreUnavailable = errors.New("transaction store unavailable")
+
+	// ErrNotHeldForReview is returned when reviewing a payment that is not in the review queue
+	ErrNotHeldForReview = errors.New("payment is not held for review")
+
+	// ErrInvalidSubscription is returned when a subscription request is incomplete
+	ErrInvalidSubscription = errors.New("subscription requires email, positive amount, currency and an interval of at least 1s")
+
+	// ErrSubscriptionNotFound is returned when a subscription doesn't exist
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+
+	// ErrSubscriptionNotActive is returned when cancelling a finished subscription
+	ErrSubscriptionNotActive = errors.New("subscription is not active")
+
+	// ErrInvalidDateRange is returned when "from" is not before "to"
+	ErrInvalidDateRange = errors.New("from must be before to")
+
+	// ErrUnsupportedFormat is returned for unknown export formats
+	ErrUnsupportedFormat = errors.New("unsupported export format")
+
+	// ErrGatewayUnavailable is returned by a gateway that cannot process charges
+	ErrGatewayUnavailable = errors.New("payment gateway unavailable")
+
+	// ErrNoGateway is returned when no gateway is configured for a charge
+	ErrNoGateway = errors.New("no payment gateway configured")
+
+	// ErrDisputeNotFound is returned when a dispute doesn't exist
+	ErrDisputeNotFound = errors.New("dispute not found")
+
+	// ErrDisputeNotOpen is returned when resolving a dispute that is already closed
+	ErrDisputeNotOpen = errors.New("dispute is not open")
+
+	// ErrInvalidDisputeOutcome is returned when a dispute is resolved as anything but WON or LOST
+	ErrInvalidDisputeOutcome = errors.New("dispute outcome must be WON or LOST")
+
+	// ErrInvalidRefundAmount is returned when a refund is negative or exceeds what is left of the payment
+	ErrInvalidRefundAmount = errors.New("invalid refund amount")
+
+	// ErrIdempotencyKeyNotFound is returned when evicting a key that is not cached
+	ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
+
+	// ErrInvalidConfig is returned when a configuration value is out of range
+	ErrInvalidConfig = errors.New("invalid payment configuration")
 )
+
+// InvalidTransitionError is returned when a status change is not allowed
+type InvalidTransitionError struct {
+	From payment.PaymentStatus
+	To   payment.PaymentStatus
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("cannot transition payment from %s to %s", e.From, e.To)
+}
+
+// IsInvalidTransition checks if an error is an invalid transition error
+func IsInvalidTransition(err error) bool {
+	_, ok := err.(*InvalidTransitionError)
+	return ok
+}
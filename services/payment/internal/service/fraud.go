@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type FraudDecision int
+
+const (
+	FraudAllow FraudDecision = iota
+	FraudReview
+	FraudDeny
+)
+
+func (d FraudDecision) String() string {
+	switch d {
+	case FraudReview:
+		return "REVIEW"
+	case FraudDeny:
+		return "DENY"
+	default:
+		return "ALLOW"
+	}
+}
+
+// FraudInput is the data available to a fraud checker for a single payment.
+type FraudInput struct {
+	OrderID       string
+	AmountCents   int64
+	Currency      string
+	CustomerEmail string
+
+	// Velocity is the number of payment attempts by the same customer
+	// within the configured velocity window, including this one.
+	Velocity int
+}
+
+type FraudResult struct {
+	Score    float64
+	Decision FraudDecision
+	Reasons  []string
+}
+
+// FraudChecker scores a payment before it is charged.
+type FraudChecker interface {
+	Check(ctx context.Context, in FraudInput) FraudResult
+}
+
+type FraudConfig struct {
+	ReviewThreshold   float64
+	DenyThreshold     float64
+	HighAmountCents   int64
+	MaxVelocity       int
+	KnownCurrencies   []string
+	BlockedDomains    []string
+	DisposableDomains []string
+}
+
+func DefaultFraudConfig() FraudConfig {
+	return FraudConfig{
+		ReviewThreshold:   0.5,
+		DenyThreshold:     0.8,
+		HighAmountCents:   500000,
+		MaxVelocity:       5,
+		KnownCurrencies:   []string{"BRL", "USD", "EUR"},
+		DisposableDomains: []string{"mailinator.com", "tempmail.com", "10minutemail.com"},
+	}
+}
+
+// HeuristicFraudChecker adds up weighted signals into a score between 0 and 1.
+type HeuristicFraudChecker struct {
+	config FraudConfig
+}
+
+func NewHeuristicFraudChecker(config FraudConfig) *HeuristicFraudChecker {
+	return &HeuristicFraudChecker{config: config}
+}
+
+func (c *HeuristicFraudChecker) Check(ctx context.Context, in FraudInput) FraudResult {
+	var result FraudResult
+
+	if c.config.HighAmountCents > 0 && in.AmountCents >= c.config.HighAmountCents {
+		result.Score += 0.4
+		result.Reasons = append(result.Reasons, fmt.Sprintf("high amount (%d cents)", in.AmountCents))
+	}
+
+	if c.config.MaxVelocity > 0 && in.Velocity > c.config.MaxVelocity {
+		result.Score += 0.4
+		result.Reasons = append(result.Reasons, fmt.Sprintf("high velocity (%d attempts)", in.Velocity))
+	}
+
+	domain := emailDomain(in.CustomerEmail)
+	if containsFold(c.config.BlockedDomains, domain) {
+		result.Score += 1.0
+		result.Reasons = append(result.Reasons, "blocked email domain "+domain)
+	} else if containsFold(c.config.DisposableDomains, domain) {
+		result.Score += 0.3
+		result.Reasons = append(result.Reasons, "disposable email domain "+domain)
+	}
+
+	if len(c.config.KnownCurrencies) > 0 && !containsFold(c.config.KnownCurrencies, in.Currency) {
+		result.Score += 0.2
+		result.Reasons = append(result.Reasons, "unusual currency "+in.Currency)
+	}
+
+	if result.Score > 1 {
+		result.Score = 1
+	}
+
+	switch {
+	case result.Score >= c.config.DenyThreshold:
+		result.Decision = FraudDeny
+	case result.Score >= c.config.ReviewThreshold:
+		result.Decision = FraudReview
+	default:
+		result.Decision = FraudAllow
+	}
+
+	return result
+}
+
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(email[at+1:])
+}
+
+func containsFold(list []string, value string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
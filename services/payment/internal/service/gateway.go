@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+	"github.com/google/uuid"
+)
+
+// ChargeRequest is what a gateway needs to authorize and capture a payment.
+type ChargeRequest struct {
+	OrderID       string
+	AmountCents   int64
+	Currency      string
+	CustomerEmail string
+	PaymentMethod string
+}
+
+// ChargeResult is the acquirer's answer. A declined charge is a normal
+// result, not an error.
+type ChargeResult struct {
+	Approved     bool
+	Reference    string
+	ErrorCode    payment.PaymentErrorCode
+	ErrorMessage string
+}
+
+// Gateway abstracts a payment acquirer. Charge returns an error only when
+// the gateway itself could not process the request, which triggers failover.
+type Gateway interface {
+	Name() string
+	Charge(ctx context.Context, req ChargeRequest) (ChargeResult, error)
+}
+
+type SimulatedGatewayConfig struct {
+	Name        string
+	Latency     time.Duration
+	FailureRate float64
+	Prefix      string
+}
+
+// SimulatedGateway approves every charge except amounts ending in 99 cents,
+// after the configured latency. FailureRate makes it randomly unavailable.
+type SimulatedGateway struct {
+	mu     sync.RWMutex
+	config SimulatedGatewayConfig
+}
+
+func NewSimulatedGateway(config SimulatedGatewayConfig) *SimulatedGateway {
+	return &SimulatedGateway{config: config}
+}
+
+func (g *SimulatedGateway) Name() string {
+	return g.config.Name
+}
+
+func (g *SimulatedGateway) FailureRate() float64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.config.FailureRate
+}
+
+func (g *SimulatedGateway) SetFailureRate(rate float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.config.FailureRate = rate
+}
+
+func (g *SimulatedGateway) Charge(ctx context.Context, req ChargeRequest) (ChargeResult, error) {
+	if g.config.Latency > 0 {
+		select {
+		case <-time.After(g.config.Latency):
+		case <-ctx.Done():
+			return ChargeResult{}, ctx.Err()
+		}
+	}
+
+	if rate := g.FailureRate(); rate > 0 && rand.Float64() < rate {
+		return ChargeResult{}, fmt.Errorf("%w: %s", ErrGatewayUnavailable, g.config.Name)
+	}
+
+	if req.AmountCents%100 == 99 {
+		return ChargeResult{
+			Approved:     false,
+			ErrorCode:    payment.PaymentErrorCode_PAYMENT_ERROR_CODE_INVALID_CARD,
+			ErrorMessage: "Card declined (simulated)",
+		}, nil
+	}
+
+	return ChargeResult{
+		Approved:  true,
+		Reference: g.config.Prefix + uuid.New().String()[:8],
+	}, nil
+}
+
+// RoutingRule sends matching charges to a gateway. Empty fields match anything.
+type RoutingRule struct {
+	Currency       string
+	MinAmountCents int64
+	MaxAmountCents int64
+	Gateway        string
+}
+
+func (r RoutingRule) Matches(req ChargeRequest) bool {
+	if r.Currency != "" && !strings.EqualFold(r.Currency, req.Currency) {
+		return false
+	}
+	if r.MinAmountCents > 0 && req.AmountCents < r.MinAmountCents {
+		return false
+	}
+	if r.MaxAmountCents > 0 && req.AmountCents > r.MaxAmountCents {
+		return false
+	}
+	return true
+}
+
+// GatewayRouter picks a gateway with the first matching rule and fails over
+// to the remaining gateways, in registration order, when it is unavailable.
+type GatewayRouter struct {
+	gateways       []Gateway
+	rules          []RoutingRule
+	defaultGateway string
+}
+
+func NewGatewayRouter(defaultGateway string, gateways []Gateway, rules []RoutingRule) *GatewayRouter {
+	return &GatewayRouter{
+		gateways:       gateways,
+		rules:          rules,
+		defaultGateway: defaultGateway,
+	}
+}
+
+// DefaultGatewayRouter ships two acquirers: a fast primary and a slower,
+// less reliable secondary used for USD and high-value charges.
+func DefaultGatewayRouter(config PaymentConfig) *GatewayRouter {
+	primary := NewSimulatedGateway(SimulatedGatewayConfig{
+		Name:        "acquirer-a",
+		FailureRate: config.FailureRate,
+		Prefix:      "tx_",
+	})
+	secondary := NewSimulatedGateway(SimulatedGatewayConfig{
+		Name:        "acquirer-b",
+		Latency:     150 * time.Millisecond,
+		FailureRate: 0.05,
+		Prefix:      "tx_",
+	})
+
+	return NewGatewayRouter(primary.Name(), []Gateway{primary, secondary}, []RoutingRule{
+		{Currency: "USD", Gateway: secondary.Name()},
+		{MinAmountCents: 500000, Gateway: secondary.Name()},
+	})
+}
+
+// Default returns the gateway used when no rule matches, or nil.
+func (r *GatewayRouter) Default() Gateway {
+	for _, g := range r.gateways {
+		if g.Name() == r.defaultGateway {
+			return g
+		}
+	}
+	return nil
+}
+
+// Route returns the gateways to try for req, preferred one first.
+func (r *GatewayRouter) Route(req ChargeRequest) []Gateway {
+	preferred := r.defaultGateway
+	for _, rule := range r.rules {
+		if rule.Matches(req) {
+			preferred = rule.Gateway
+			break
+		}
+	}
+
+	ordered := make([]Gateway, 0, len(r.gateways))
+	for _, g := range r.gateways {
+		if g.Name() == preferred {
+			ordered = append(ordered, g)
+		}
+	}
+	for _, g := range r.gateways {
+		if g.Name() != preferred {
+			ordered = append(ordered, g)
+		}
+	}
+	return ordered
+}
+
+// Charge tries each routed gateway until one answers.
+func (r *GatewayRouter) Charge(ctx context.Context, req ChargeRequest) (ChargeResult, string, error) {
+	var errs []error
+	for _, g := range r.Route(req) {
+		result, err := g.Charge(ctx, req)
+		if err == nil {
+			return result, g.Name(), nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if len(errs) == 0 {
+		return ChargeResult{}, "", ErrNoGateway
+	}
+	return ChargeResult{}, "", errors.Join(errs...)
+}
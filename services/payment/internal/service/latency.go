@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+)
+
+// Kinds of latency profile.
+const (
+	LatencyFixed   = "fixed"
+	LatencyUniform = "uniform"
+	LatencyPareto  = "pareto"
+	LatencySpikes  = "spikes"
+)
+
+// LatencyProfile is the artificial latency of an RPC. Each kind reads the
+// fields it needs:
+//
+//	fixed:100ms              Base every time
+//	uniform:50ms:150ms       between Base and Max
+//	pareto:50ms:1.5[:5s]     at least Base, with a tail as heavy as Shape is low, capped at Max
+//	spikes:100ms:2s:1m:5s    Base, but Spike during the first For of every Every
+//
+// A bare duration is a fixed profile, and the zero profile adds nothing.
+type LatencyProfile struct {
+	Kind  string
+	Base  time.Duration
+	Max   time.Duration
+	Shape float64
+	Spike time.Duration
+	Every time.Duration
+	For   time.Duration
+}
+
+// FixedLatency returns the profile adding d to every call.
+func FixedLatency(d time.Duration) LatencyProfile {
+	return LatencyProfile{Kind: LatencyFixed, Base: d}
+}
+
+// ParseLatencyProfile parses the syntax documented on LatencyProfile.
+func ParseLatencyProfile(s string) (LatencyProfile, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return LatencyProfile{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		p := FixedLatency(d)
+		return p, p.Validate()
+	}
+
+	fields := strings.Split(s, ":")
+	p := LatencyProfile{Kind: fields[0]}
+	args := fields[1:]
+	durations := func(dst ...*time.Duration) error {
+		for i, d := range dst {
+			v, err := time.ParseDuration(args[i])
+			if err != nil {
+				return err
+			}
+			*d = v
+		}
+		return nil
+	}
+
+	var err error
+	switch {
+	case p.Kind == LatencyFixed && len(args) == 1:
+		err = durations(&p.Base)
+	case p.Kind == LatencyUniform && len(args) == 2:
+		err = durations(&p.Base, &p.Max)
+	case p.Kind == LatencyPareto && (len(args) == 2 || len(args) == 3):
+		if err = durations(&p.Base); err == nil {
+			p.Shape, err = strconv.ParseFloat(args[1], 64)
+		}
+		if err == nil && len(args) == 3 {
+			p.Max, err = time.ParseDuration(args[2])
+		}
+	case p.Kind == LatencySpikes && len(args) == 4:
+		err = durations(&p.Base, &p.Spike, &p.Every, &p.For)
+	default:
+		return LatencyProfile{}, fmt.Errorf("%w: latency profile %q is not fixed:base, uniform:min:max, pareto:min:shape[:max] or spikes:base:spike:every:for",
+			ErrInvalidConfig, s)
+	}
+	if err != nil {
+		return LatencyProfile{}, fmt.Errorf("%w: latency profile %q: %v", ErrInvalidConfig, s, err)
+	}
+	return p, p.Validate()
+}
+
+// Validate checks that the fields of the kind of p are in range.
+func (p LatencyProfile) Validate() error {
+	invalid := func(reason string) error {
+		return fmt.Errorf("%w: latency profile %s: %s", ErrInvalidConfig, p, reason)
+	}
+	if p.Base < 0 || p.Max < 0 || p.Spike < 0 || p.Every < 0 || p.For < 0 {
+		return invalid("durations cannot be negative")
+	}
+	switch p.Kind {
+	case "", LatencyFixed:
+	case LatencyUniform:
+		if p.Max < p.Base {
+			return invalid("max is below min")
+		}
+	case LatencyPareto:
+		if p.Base <= 0 || p.Shape <= 0 {
+			return invalid("min and shape must be positive")
+		}
+		if p.Max > 0 && p.Max < p.Base {
+			return invalid("max is below min")
+		}
+	case LatencySpikes:
+		if p.Every <= 0 || p.For <= 0 || p.For > p.Every {
+			return invalid("spikes must last for a positive time no longer than their period")
+		}
+	default:
+		return fmt.Errorf("%w: unknown latency profile %q", ErrInvalidConfig, p.Kind)
+	}
+	return nil
+}
+
+// String returns p in the syntax of ParseLatencyProfile, a bare duration
+// for fixed profiles.
+func (p LatencyProfile) String() string {
+	switch p.Kind {
+	case LatencyUniform:
+		return fmt.Sprintf("%s:%v:%v", p.Kind, p.Base, p.Max)
+	case LatencyPareto:
+		s := fmt.Sprintf("%s:%v:%s", p.Kind, p.Base, strconv.FormatFloat(p.Shape, 'g', -1, 64))
+		if p.Max > 0 {
+			s += ":" + p.Max.String()
+		}
+		return s
+	case LatencySpikes:
+		return fmt.Sprintf("%s:%v:%v:%v:%v", p.Kind, p.Base, p.Spike, p.Every, p.For)
+	}
+	return p.Base.String()
+}
+
+// MarshalText and UnmarshalText let config files and JSON carry profiles
+// as strings.
+func (p LatencyProfile) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *LatencyProfile) UnmarshalText(text []byte) error {
+	parsed, err := ParseLatencyProfile(string(text))
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// Sample draws a latency at now.
+func (p LatencyProfile) Sample(now time.Time) time.Duration {
+	switch p.Kind {
+	case LatencyUniform:
+		if p.Max > p.Base {
+			return p.Base + rand.N(p.Max-p.Base+1)
+		}
+	case LatencyPareto:
+		d := float64(p.Base) / math.Pow(1-rand.Float64(), 1/p.Shape)
+		if p.Max > 0 && d > float64(p.Max) {
+			return p.Max
+		}
+		return time.Duration(min(d, math.MaxInt64))
+	case LatencySpikes:
+		if time.Duration(now.UnixNano()%int64(p.Every)) < p.For {
+			return p.Spike
+		}
+	}
+	return p.Base
+}
+
+// ParseLatencyOverrides parses a comma separated "rpc=profile" list such as
+// "ProcessPayment=pareto:50ms:1.5,RefundPayment=1s".
+func ParseLatencyOverrides(s string) (map[string]LatencyProfile, error) {
+	overrides := make(map[string]LatencyProfile)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		rpc, spec, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: latency override %q is not rpc=profile", ErrInvalidConfig, part)
+		}
+		p, err := ParseLatencyProfile(spec)
+		if err != nil {
+			return nil, err
+		}
+		overrides[strings.TrimSpace(rpc)] = p
+	}
+	return overrides, nil
+}
+
+// FormatLatencyOverrides returns overrides in the syntax of
+// ParseLatencyOverrides, ordered by RPC.
+func FormatLatencyOverrides(overrides map[string]LatencyProfile) string {
+	parts := make([]string, 0, len(overrides))
+	for rpc, p := range overrides {
+		parts = append(parts, rpc+"="+p.String())
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, ",")
+}
+
+// validateLatencyRPC checks that rpc names a method of PaymentService.
+func validateLatencyRPC(rpc string) error {
+	for _, m := range payment.PaymentService_ServiceDesc.Methods {
+		if m.MethodName == rpc {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: latency override for unknown rpc %q", ErrInvalidConfig, rpc)
+}
+
+// LatencyFor returns the profile of the PaymentService method rpc:
+// its override, else Latency for ProcessPayment, else none.
+func (c PaymentConfig) LatencyFor(rpc string) LatencyProfile {
+	if p, ok := c.LatencyOverrides[rpc]; ok {
+		return p
+	}
+	if rpc == "ProcessPayment" {
+		return c.Latency
+	}
+	return LatencyProfile{}
+}
+
+// SimulateLatency waits the latency drawn for rpc, or until ctx is done,
+// when it returns ctx.Err().
+func (s *PaymentService) SimulateLatency(ctx context.Context, rpc string) error {
+	d := s.Config().LatencyFor(rpc).Sample(s.now())
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+	"github.com/google/uuid"
+)
+
+// transitions lists the legal status changes for a transaction.
+var transitions = map[payment.PaymentStatus][]payment.PaymentStatus{
+	payment.PaymentStatus_PAYMENT_STATUS_UNSPECIFIED: {
+		payment.PaymentStatus_PAYMENT_STATUS_PENDING,
+	},
+	payment.PaymentStatus_PAYMENT_STATUS_PENDING: {
+		payment.PaymentStatus_PAYMENT_STATUS_COMPLETED,
+		payment.PaymentStatus_PAYMENT_STATUS_FAILED,
+		payment.PaymentStatus_PAYMENT_STATUS_CANCELLED,
+	},
+	payment.PaymentStatus_PAYMENT_STATUS_COMPLETED: {
+		payment.PaymentStatus_PAYMENT_STATUS_REFUNDED,
+		payment.PaymentStatus_PAYMENT_STATUS_CANCELLED,
+		payment.PaymentStatus_PAYMENT_STATUS_DISPUTED,
+	},
+	payment.PaymentStatus_PAYMENT_STATUS_DISPUTED: {
+		payment.PaymentStatus_PAYMENT_STATUS_COMPLETED,
+		payment.PaymentStatus_PAYMENT_STATUS_CHARGED_BACK,
+	},
+}
+
+func CanTransition(from, to payment.PaymentStatus) bool {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// transitionLocked moves tx to a new status, records the change on the
+// transaction and appends it to the audit log. The caller must hold s.mu
+// for writing.
+func (s *PaymentService) transitionLocked(ctx context.Context, tx *payment.PaymentStatusResponse, to payment.PaymentStatus, reason string) error {
+	if !CanTransition(tx.Status, to) {
+		return &InvalidTransitionError{From: tx.Status, To: to}
+	}
+
+	now := s.now()
+	tx.Transitions = append(tx.Transitions, payment.PaymentStatusTransition{
+		From:   tx.Status,
+		To:     to,
+		At:     now,
+		Reason: reason,
+	})
+
+	err := s.audit.Append(ctx, payment.AuditEntry{
+		TransactionID:  tx.TransactionID,
+		Actor:          actorFromContext(ctx),
+		PreviousStatus: tx.Status,
+		NewStatus:      to,
+		Reason:         reason,
+		RecordedAt:     now,
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to record audit entry",
+			"transaction_id", tx.TransactionID,
+			"from", tx.Status.String(),
+			"to", to.String(),
+			logging.Err(err))
+	}
+
+	tx.Status = to
+	tx.UpdatedAt = now
+
+	return nil
+}
+
+func (s *PaymentService) CancelPayment(ctx context.Context, req *payment.CancelPaymentRequest) (*payment.PaymentStatusResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, ok := s.transactionLocked(ctx, req.TransactionID)
+	if !ok {
+		return nil, ErrTransactionNotFound
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "cancelled by client"
+	}
+
+	if err := s.transitionLocked(ctx, tx, payment.PaymentStatus_PAYMENT_STATUS_CANCELLED, reason); err != nil {
+		return nil, err
+	}
+
+	return cloneStatus(tx), nil
+}
+
+// RefundPayment returns the funds of a completed payment: what is left of
+// it, or req.AmountCents of it. A partial refund keeps the payment
+// COMPLETED; the refund of the rest moves it to REFUNDED. A refund ID that
+// was refunded already returns the payment unchanged, so a retried refund
+// is not paid twice.
+func (s *PaymentService) RefundPayment(ctx context.Context, req *payment.RefundPaymentRequest) (*payment.PaymentStatusResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, ok := s.transactionLocked(ctx, req.TransactionID)
+	if !ok {
+		return nil, ErrTransactionNotFound
+	}
+	if req.RefundID != "" {
+		for _, r := range tx.Refunds {
+			if r.RefundID == req.RefundID {
+				return cloneStatus(tx), nil
+			}
+		}
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "refunded by client"
+	}
+
+	if !CanTransition(tx.Status, payment.PaymentStatus_PAYMENT_STATUS_REFUNDED) {
+		return nil, &InvalidTransitionError{From: tx.Status, To: payment.PaymentStatus_PAYMENT_STATUS_REFUNDED}
+	}
+	remaining := tx.AmountCents - tx.RefundedCents
+	amount := req.AmountCents
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount < 0 || amount > remaining {
+		return nil, fmt.Errorf("%w: %d cents requested, %d cents left", ErrInvalidRefundAmount, amount, remaining)
+	}
+
+	if amount < remaining {
+		s.recordPartialRefundLocked(ctx, tx, amount, reason)
+	} else if err := s.transitionLocked(ctx, tx, payment.PaymentStatus_PAYMENT_STATUS_REFUNDED, reason); err != nil {
+		return nil, err
+	}
+
+	refundID := req.RefundID
+	if refundID == "" {
+		refundID = "rf_" + uuid.New().String()[:8]
+	}
+	tx.RefundedCents += amount
+	tx.Refunds = append(tx.Refunds, payment.Refund{
+		RefundID:    refundID,
+		AmountCents: amount,
+		Reason:      reason,
+		At:          tx.UpdatedAt,
+	})
+
+	return cloneStatus(tx), nil
+}
+
+// recordPartialRefundLocked appends a partial refund, which leaves the
+// status as it is, to the audit log. The caller must hold s.mu for writing.
+func (s *PaymentService) recordPartialRefundLocked(ctx context.Context, tx *payment.PaymentStatusResponse, amount int64, reason string) {
+	now := s.now()
+	err := s.audit.Append(ctx, payment.AuditEntry{
+		TransactionID:  tx.TransactionID,
+		Actor:          actorFromContext(ctx),
+		PreviousStatus: tx.Status,
+		NewStatus:      tx.Status,
+		Reason:         fmt.Sprintf("partial refund of %d cents: %s", amount, reason),
+		RecordedAt:     now,
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to record audit entry",
+			"transaction_id", tx.TransactionID,
+			"refund_cents", amount,
+			logging.Err(err))
+	}
+	tx.UpdatedAt = now
+}
+
+// cloneStatus returns a copy that is safe to hand out while the stored
+// transaction keeps changing.
+func cloneStatus(tx *payment.PaymentStatusResponse) *payment.PaymentStatusResponse {
+	return &payment.PaymentStatusResponse{
+		TransactionID: tx.TransactionID,
+		OrderID:       tx.OrderID,
+		AmountCents:   tx.AmountCents,
+		Currency:      tx.Currency,
+		Gateway:       tx.Gateway,
+		Status:        tx.Status,
+		CreatedAt:     tx.CreatedAt,
+		UpdatedAt:     tx.UpdatedAt,
+		Transitions:   append([]payment.PaymentStatusTransition(nil), tx.Transitions...),
+		RefundedCents: tx.RefundedCents,
+		Refunds:       append([]payment.Refund(nil), tx.Refunds...),
+	}
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/metrics"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+)
+
+// Metrics are the payment counters. A nil *Metrics records nothing.
+type Metrics struct {
+	processed       *metrics.CounterVec
+	amountCents     *metrics.CounterVec
+	idempotencyHits *metrics.Counter
+}
+
+// NewMetrics registers the payment metrics on r, including gauges read from
+// the service at scrape time.
+func NewMetrics(r *metrics.Registry) *Metrics {
+	return &Metrics{
+		processed: r.NewCounterVec("payment_processed_total",
+			"Payments processed, by result (approved, declined, held, abandoned) and error code.",
+			"result", "error_code"),
+		amountCents: r.NewCounterVec("payment_approved_amount_cents_total",
+			"Sum of approved payment amounts in cents, by currency.",
+			"currency"),
+		idempotencyHits: r.NewCounter("payment_idempotency_cache_hits_total",
+			"ProcessPayment calls answered from the idempotency cache."),
+	}
+}
+
+// RegisterGauges exposes the service's current state on r.
+func (s *PaymentService) RegisterGauges(r *metrics.Registry) {
+	r.NewGaugeFunc("payment_transactions", "Transactions stored in memory.", func() float64 {
+		return float64(s.Stats().TotalTransactions)
+	})
+	r.NewGaugeFunc("payment_held_for_review", "Payments waiting for manual fraud review.", func() float64 {
+		return float64(s.Stats().HeldForReview)
+	})
+	r.NewGaugeFunc("payment_active_subscriptions", "Active recurring subscriptions.", func() float64 {
+		return float64(s.Stats().ActiveSubscriptions)
+	})
+}
+
+func (m *Metrics) observe(req *payment.PaymentRequest, result processResult) {
+	if m == nil {
+		return
+	}
+
+	resp := result.response
+	switch {
+	case resp.Success:
+		m.processed.WithLabelValues("approved", "").Inc()
+		m.amountCents.WithLabelValues(req.Currency).Add(float64(req.AmountCents))
+	case result.fraud.Decision == FraudReview:
+		m.processed.WithLabelValues("held", resp.ErrorCode.String()).Inc()
+	default:
+		m.processed.WithLabelValues("declined", resp.ErrorCode.String()).Inc()
+	}
+}
+
+// abandoned counts a payment whose caller gave up before it was settled.
+func (m *Metrics) abandoned() {
+	if m == nil {
+		return
+	}
+	m.processed.WithLabelValues("abandoned", "").Inc()
+}
+
+func (m *Metrics) idempotencyHit() {
+	if m == nil {
+		return
+	}
+	m.idempotencyHits.Inc()
+}
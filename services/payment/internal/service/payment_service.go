@@ -2,131 +2,434 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
 	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
 	"github.com/google/uuid"
 )
 
+var logger = logging.Component("payment")
+
 type PaymentService struct {
 	mu            sync.RWMutex
+	configMu      sync.Mutex
 	transactions  map[string]*payment.PaymentStatusResponse
 	processedKeys map[string]*payment.PaymentResponse
+	reviewQueue   map[string]*payment.HeldPayment
+	subscriptions map[string]*payment.Subscription
+	disputes      map[string]*payment.Dispute
 	config        PaymentConfig
+	fraud         FraudChecker
+	gateways      *GatewayRouter
+	velocity      *velocityTracker
+	audit         AuditLog
+	metrics       *Metrics
+	broker        broker.Publisher
+	topicName     string
+	now           func() time.Time
 }
 
 type PaymentConfig struct {
-	MaxAmountCents  int64
-	SimulateLatency time.Duration
-	FailureRate     float64
+	MaxAmountCents int64
+	FailureRate    float64
+	VelocityWindow time.Duration
+	Fraud          FraudConfig
+
+	// Latency is the artificial latency of ProcessPayment, and
+	// LatencyOverrides that of the PaymentService RPCs it names, by method
+	// name, ProcessPayment included. See PaymentService.SimulateLatency.
+	Latency          LatencyProfile
+	LatencyOverrides map[string]LatencyProfile
+
+	// VelocityLimits decline payments with PAYMENT_ERROR_CODE_LIMIT_EXCEEDED
+	// when a customer exceeds them. Customers in VelocityBypass (emails or
+	// "@domain") are exempt.
+	VelocityLimits []VelocityLimit
+	VelocityBypass []string
+
+	// TenantLimits replaces MaxAmountCents for the tenants it names.
+	TenantLimits map[string]int64
 }
 
 func DefaultPaymentConfig() PaymentConfig {
 	return PaymentConfig{
-		MaxAmountCents:  1000000,
-		SimulateLatency: 100 * time.Millisecond,
-		FailureRate:     0.0,
+		MaxAmountCents: 1000000,
+		Latency:        FixedLatency(100 * time.Millisecond),
+		FailureRate:    0.0,
+		VelocityWindow: time.Hour,
+		Fraud:          DefaultFraudConfig(),
+	}
+}
+
+// Validate checks the ranges of the tunables.
+func (c PaymentConfig) Validate() error {
+	switch {
+	case c.MaxAmountCents <= 0:
+		return fmt.Errorf("%w: max amount must be positive", ErrInvalidConfig)
+	case c.FailureRate < 0 || c.FailureRate > 1:
+		return fmt.Errorf("%w: failure rate must be between 0 and 1", ErrInvalidConfig)
+	case c.VelocityWindow <= 0:
+		return fmt.Errorf("%w: velocity window must be positive", ErrInvalidConfig)
+	}
+	if err := c.Latency.Validate(); err != nil {
+		return err
+	}
+	for rpc, p := range c.LatencyOverrides {
+		if err := validateLatencyRPC(rpc); err != nil {
+			return err
+		}
+		if err := p.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, l := range c.VelocityLimits {
+		if l.Window <= 0 || l.MaxCount < 0 || l.MaxAmountCents < 0 {
+			return fmt.Errorf("%w: velocity limit %v", ErrInvalidConfig, l)
+		}
+	}
+	for id, limit := range c.TenantLimits {
+		if limit <= 0 {
+			return fmt.Errorf("%w: max amount of tenant %s must be positive", ErrInvalidConfig, id)
+		}
+	}
+	return nil
+}
+
+type Option func(*PaymentService)
+
+// WithGatewayRouter replaces the default simulated acquirers.
+func WithGatewayRouter(r *GatewayRouter) Option {
+	return func(s *PaymentService) {
+		s.gateways = r
+	}
+}
+
+// WithEventBroker publishes payment events for scheduled charges and disputes
+// to topicName.
+func WithEventBroker(b broker.Publisher, topicName string) Option {
+	return func(s *PaymentService) {
+		s.broker = b
+		s.topicName = topicName
+	}
+}
+
+// WithFraudChecker replaces the default heuristic fraud checker.
+func WithFraudChecker(fc FraudChecker) Option {
+	return func(s *PaymentService) {
+		s.fraud = fc
 	}
 }
 
-func NewPaymentService(config PaymentConfig) *PaymentService {
-	return &PaymentService{
+// WithAuditLog replaces the default in-memory audit log.
+func WithAuditLog(l AuditLog) Option {
+	return func(s *PaymentService) {
+		s.audit = l
+	}
+}
+
+// WithMetrics records payment counters in m.
+func WithMetrics(m *Metrics) Option {
+	return func(s *PaymentService) {
+		s.metrics = m
+	}
+}
+
+// WithClock replaces time.Now for the timestamps of transactions, disputes
+// and subscriptions, and for deciding which subscriptions are due.
+func WithClock(now func() time.Time) Option {
+	return func(s *PaymentService) {
+		s.now = now
+	}
+}
+
+func NewPaymentService(config PaymentConfig, opts ...Option) *PaymentService {
+	s := &PaymentService{
 		transactions:  make(map[string]*payment.PaymentStatusResponse),
 		processedKeys: make(map[string]*payment.PaymentResponse),
+		reviewQueue:   make(map[string]*payment.HeldPayment),
+		subscriptions: make(map[string]*payment.Subscription),
+		disputes:      make(map[string]*payment.Dispute),
 		config:        config,
+		fraud:         NewHeuristicFraudChecker(config.Fraud),
+		gateways:      DefaultGatewayRouter(config),
+		velocity:      newVelocityTracker(velocityRetention(config)),
+		audit:         NewInMemoryAuditLog(),
+		now:           time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Config returns the configuration currently in effect.
+func (s *PaymentService) Config() PaymentConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config
+}
+
+// UpdateConfig applies the hot-reloadable tunables: MaxAmountCents,
+// TenantLimits, the latency profiles, FailureRate of the default gateway
+// and the velocity limits. Changes to the velocity window and fraud settings take
+// effect on restart.
+func (s *PaymentService) UpdateConfig(config PaymentConfig) {
+	s.mu.Lock()
+	s.config.MaxAmountCents = config.MaxAmountCents
+	s.config.TenantLimits = config.TenantLimits
+	s.config.Latency = config.Latency
+	s.config.LatencyOverrides = config.LatencyOverrides
+	s.config.FailureRate = config.FailureRate
+	s.config.VelocityLimits = config.VelocityLimits
+	s.config.VelocityBypass = config.VelocityBypass
+	retention := velocityRetention(s.config)
+	s.mu.Unlock()
+
+	s.velocity.SetRetention(retention)
+
+	if sim, ok := s.gateways.Default().(*SimulatedGateway); ok {
+		sim.SetFailureRate(config.FailureRate)
 	}
 }
 
+// ProcessPayment charges req once per idempotency key. It returns ctx.Err()
+// without recording anything when the caller gives up before the charge is
+// settled, so a retry with the same key charges again; a charge settled by
+// the gateway is recorded even if the caller is gone by then.
 func (s *PaymentService) ProcessPayment(ctx context.Context, req *payment.PaymentRequest) (*payment.PaymentResponse, error) {
-	if s.config.SimulateLatency > 0 {
-		time.Sleep(s.config.SimulateLatency)
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
+	// Idempotency keys are only unique within a tenant.
+	key := tenantKey(ctx, req.IdempotencyKey)
+
 	s.mu.RLock()
-	if cached, ok := s.processedKeys[req.IdempotencyKey]; ok {
+	if cached, ok := s.processedKeys[key]; ok {
 		s.mu.RUnlock()
+		s.metrics.idempotencyHit()
 		return cached, nil
 	}
 	s.mu.RUnlock()
 
-	response := s.processPaymentInternal(req)
+	result, err := s.processPaymentInternal(ctx, req)
+	if err != nil {
+		logger.InfoContext(ctx, "payment abandoned by the caller", "order_id", req.OrderID, logging.Err(err))
+		s.metrics.abandoned()
+		return nil, err
+	}
+	response := result.response
+	s.metrics.observe(req, result)
 
 	s.mu.Lock()
-	s.processedKeys[req.IdempotencyKey] = response
-	if response.Success {
-		s.transactions[response.TransactionID] = &payment.PaymentStatusResponse{
+	s.processedKeys[key] = response
+	if response.TransactionID != "" {
+		tx := &payment.PaymentStatusResponse{
 			TransactionID: response.TransactionID,
+			TenantID:      tenant.FromContext(ctx),
 			OrderID:       req.OrderID,
 			AmountCents:   req.AmountCents,
 			Currency:      req.Currency,
-			Status:        payment.PaymentStatus_PAYMENT_STATUS_COMPLETED,
+			Gateway:       result.gateway,
 			CreatedAt:     response.ProcessedAt,
 		}
+		s.transitionLocked(ctx, tx, payment.PaymentStatus_PAYMENT_STATUS_PENDING, "payment received")
+		if response.Success {
+			s.transitionLocked(ctx, tx, payment.PaymentStatus_PAYMENT_STATUS_COMPLETED, "payment captured by "+result.gateway)
+		} else if result.fraud.Decision == FraudReview {
+			s.holdForReviewLocked(tx, req, result.fraud)
+		}
+		s.transactions[response.TransactionID] = tx
 	}
 	s.mu.Unlock()
 
 	return response, nil
 }
 
-func (s *PaymentService) processPaymentInternal(req *payment.PaymentRequest) *payment.PaymentResponse {
-	now := time.Now()
+// processResult carries what ProcessPayment needs to record a transaction.
+type processResult struct {
+	response *payment.PaymentResponse
+	fraud    FraudResult
+	gateway  string
+}
+
+// processPaymentInternal decides on req. It returns ctx.Err() when ctx is
+// done before the gateway settles the charge.
+func (s *PaymentService) processPaymentInternal(ctx context.Context, req *payment.PaymentRequest) (processResult, error) {
+	now := s.now()
 
 	if req.AmountCents <= 0 {
-		return &payment.PaymentResponse{
-			Success:      false,
-			ErrorCode:    payment.PaymentErrorCode_PAYMENT_ERROR_CODE_PROCESSING_ERROR,
-			ErrorMessage: "Amount must be positive",
-			ProcessedAt:  now,
-		}
+		return declined(payment.PaymentErrorCode_PAYMENT_ERROR_CODE_PROCESSING_ERROR, "Amount must be positive", now).retryAfter(false, 0), nil
 	}
 
-	if req.AmountCents > s.config.MaxAmountCents {
-		return &payment.PaymentResponse{
-			Success:      false,
-			ErrorCode:    payment.PaymentErrorCode_PAYMENT_ERROR_CODE_LIMIT_EXCEEDED,
-			ErrorMessage: "Amount exceeds maximum allowed",
-			ProcessedAt:  now,
-		}
+	config := s.Config()
+
+	if req.AmountCents > config.maxAmountFor(tenant.FromContext(ctx)) {
+		return declined(payment.PaymentErrorCode_PAYMENT_ERROR_CODE_LIMIT_EXCEEDED, "Amount exceeds maximum allowed", now).retryAfter(false, 0), nil
 	}
 
 	if req.OrderID == "" {
-		return &payment.PaymentResponse{
-			Success:      false,
-			ErrorCode:    payment.PaymentErrorCode_PAYMENT_ERROR_CODE_PROCESSING_ERROR,
-			ErrorMessage: "Order ID is required",
-			ProcessedAt:  now,
+		return declined(payment.PaymentErrorCode_PAYMENT_ERROR_CODE_PROCESSING_ERROR, "Order ID is required", now).retryAfter(false, 0), nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return processResult{}, err
+	}
+
+	// Customers are tracked per tenant: the same email at two tenants is
+	// two customers.
+	customer := tenantKey(ctx, req.CustomerEmail)
+	limits := config.VelocityLimits
+	if bypassesVelocity(config.VelocityBypass, req.CustomerEmail) {
+		limits = nil
+	}
+	// The attempt counts from here on, so payments made at the same time
+	// see it; one abandoned before the gateway charges it is given back.
+	exceeded, wait, release := s.velocity.Reserve(customer, req.AmountCents, limits, now)
+	if exceeded != "" {
+		logger.WarnContext(ctx, "velocity limit reached", "customer_email", req.CustomerEmail, "limit", exceeded)
+		result := declined(payment.PaymentErrorCode_PAYMENT_ERROR_CODE_LIMIT_EXCEEDED, "Velocity limit exceeded: "+exceeded, now)
+		return result.retryAfter(wait > 0, wait), nil
+	}
+	velocity, _ := s.velocity.Totals(customer, config.VelocityWindow, now)
+
+	fraud := s.fraud.Check(ctx, FraudInput{
+		OrderID:       req.OrderID,
+		AmountCents:   req.AmountCents,
+		Currency:      req.Currency,
+		CustomerEmail: req.CustomerEmail,
+		Velocity:      velocity,
+	})
+
+	if err := ctx.Err(); err != nil {
+		release()
+		return processResult{}, err
+	}
+
+	switch fraud.Decision {
+	case FraudDeny:
+		result := declined(payment.PaymentErrorCode_PAYMENT_ERROR_CODE_SUSPECTED_FRAUD, "Payment declined by fraud screening", now)
+		result.fraud = fraud
+		return result, nil
+	case FraudReview:
+		result := declined(payment.PaymentErrorCode_PAYMENT_ERROR_CODE_SUSPECTED_FRAUD, "Payment held for manual review", now)
+		result.response.TransactionID = "tx_" + uuid.New().String()[:8]
+		result.fraud = fraud
+		return result, nil
+	}
+
+	charge, gateway, err := s.gateways.Charge(ctx, ChargeRequest{
+		OrderID:       req.OrderID,
+		AmountCents:   req.AmountCents,
+		Currency:      req.Currency,
+		CustomerEmail: req.CustomerEmail,
+		PaymentMethod: req.PaymentMethod,
+	})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			release()
+			return processResult{}, ctxErr
 		}
+		logger.ErrorContext(ctx, "all gateways failed", "order_id", req.OrderID, logging.Err(err))
+		result := declined(payment.PaymentErrorCode_PAYMENT_ERROR_CODE_PROCESSING_ERROR, "Payment gateways unavailable", now).
+			retryAfter(true, gatewaysUnavailableRetryAfter)
+		result.fraud = fraud
+		return result, nil
+	}
+
+	if !charge.Approved {
+		result := declined(charge.ErrorCode, charge.ErrorMessage, now)
+		result.fraud = fraud
+		result.gateway = gateway
+		return result, nil
 	}
 
-	if req.AmountCents%100 == 99 {
-		return &payment.PaymentResponse{
+	return processResult{
+		response: &payment.PaymentResponse{
+			Success:       true,
+			TransactionID: charge.Reference,
+			ProcessedAt:   now,
+		},
+		fraud:   fraud,
+		gateway: gateway,
+	}, nil
+}
+
+// declined returns a declined payment with the retry hint of code, see
+// declineRetries.
+func declined(code payment.PaymentErrorCode, message string, now time.Time) processResult {
+	hint := declineRetries[code]
+	return processResult{
+		response: &payment.PaymentResponse{
 			Success:      false,
-			ErrorCode:    payment.PaymentErrorCode_PAYMENT_ERROR_CODE_INVALID_CARD,
-			ErrorMessage: "Card declined (simulated)",
+			ErrorCode:    code,
+			ErrorMessage: message,
 			ProcessedAt:  now,
-		}
-	}
+		},
+	}.retryAfter(hint.retriable, hint.after)
+}
+
+// retryHint tells callers whether a decline may be overcome by paying
+// again, and how long to wait first; 0 leaves the wait to them.
+type retryHint struct {
+	retriable bool
+	after     time.Duration
+}
+
+// declineRetries are the retry hints of the error codes, for declines
+// whose cause does not tell better: funds and limits may allow the payment
+// later, while an invalid card, a duplicate or suspected fraud will not
+// change by waiting.
+var declineRetries = map[payment.PaymentErrorCode]retryHint{
+	payment.PaymentErrorCode_PAYMENT_ERROR_CODE_INSUFFICIENT_FUNDS: {retriable: true, after: time.Hour},
+	payment.PaymentErrorCode_PAYMENT_ERROR_CODE_LIMIT_EXCEEDED:     {retriable: true, after: 24 * time.Hour},
+	payment.PaymentErrorCode_PAYMENT_ERROR_CODE_PROCESSING_ERROR:   {retriable: true},
+}
 
-	transactionID := "tx_" + uuid.New().String()[:8]
+// gatewaysUnavailableRetryAfter is the wait hinted when no gateway could
+// settle a charge.
+const gatewaysUnavailableRetryAfter = 5 * time.Second
 
-	return &payment.PaymentResponse{
-		Success:       true,
-		TransactionID: transactionID,
-		ProcessedAt:   now,
+// retryAfter sets the retry hint of a declined result, rounding after up
+// to whole seconds.
+func (r processResult) retryAfter(retriable bool, after time.Duration) processResult {
+	r.response.Retriable = retriable
+	r.response.RetryAfterSeconds = 0
+	if retriable && after > 0 {
+		r.response.RetryAfterSeconds = int64((after + time.Second - 1) / time.Second)
 	}
+	return r
 }
 
 func (s *PaymentService) GetPaymentStatus(ctx context.Context, req *payment.PaymentStatusRequest) (*payment.PaymentStatusResponse, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	tx, ok := s.transactions[req.TransactionID]
+	tx, ok := s.transactionLocked(ctx, req.TransactionID)
 	if !ok {
 		return nil, ErrTransactionNotFound
 	}
 
-	return tx, nil
+	return cloneStatus(tx), nil
+}
+
+// Ping reports whether the transaction store can serve requests.
+func (s *PaymentService) Ping(ctx context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.transactions == nil || s.processedKeys == nil {
+		return ErrStoreUnavailable
+	}
+
+	return ctx.Err()
 }
 
 func (s *PaymentService) Stats() PaymentStats {
@@ -136,11 +439,25 @@ func (s *PaymentService) Stats() PaymentStats {
 	stats := PaymentStats{
 		TotalTransactions:   len(s.transactions),
 		CachedIdempotencies: len(s.processedKeys),
+		HeldForReview:       len(s.reviewQueue),
+	}
+
+	for _, sub := range s.subscriptions {
+		if sub.Status == payment.SubscriptionStatus_SUBSCRIPTION_STATUS_ACTIVE {
+			stats.ActiveSubscriptions++
+		}
+	}
+	for _, d := range s.disputes {
+		if d.Status == payment.DisputeStatus_DISPUTE_STATUS_OPEN {
+			stats.OpenDisputes++
+		}
 	}
 
 	var totalAmount int64
+	stats.ByStatus = make(map[payment.PaymentStatus]int)
 	for _, tx := range s.transactions {
 		totalAmount += tx.AmountCents
+		stats.ByStatus[tx.Status]++
 	}
 	stats.TotalAmountCents = totalAmount
 
@@ -151,4 +468,8 @@ type PaymentStats struct {
 	TotalTransactions   int
 	TotalAmountCents    int64
 	CachedIdempotencies int
+	HeldForReview       int
+	ActiveSubscriptions int
+	OpenDisputes        int
+	ByStatus            map[payment.PaymentStatus]int
 }
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+)
+
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatJSON = "json"
+)
+
+func inRange(t, from, to time.Time) bool {
+	if !from.IsZero() && t.Before(from) {
+		return false
+	}
+	if !to.IsZero() && !t.Before(to) {
+		return false
+	}
+	return true
+}
+
+// TransactionsBetween returns copies of transactions created in [from, to),
+// oldest first. Zero bounds are open-ended.
+func (s *PaymentService) TransactionsBetween(from, to time.Time) []*payment.PaymentStatusResponse {
+	s.mu.RLock()
+	txs := make([]*payment.PaymentStatusResponse, 0, len(s.transactions))
+	for _, tx := range s.transactions {
+		if inRange(tx.CreatedAt, from, to) {
+			txs = append(txs, cloneStatus(tx))
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(txs, func(i, j int) bool {
+		return txs[i].CreatedAt.Before(txs[j].CreatedAt)
+	})
+
+	return txs
+}
+
+func (s *PaymentService) ReconciliationReport(ctx context.Context, from, to time.Time) (*payment.ReconciliationReport, error) {
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return nil, ErrInvalidDateRange
+	}
+
+	type key struct {
+		status   payment.PaymentStatus
+		currency string
+	}
+	totals := make(map[key]*payment.ReconciliationTotal)
+
+	report := &payment.ReconciliationReport{
+		From:                   from,
+		To:                     to,
+		GeneratedAt:            s.now(),
+		SettledCentsByCurrency: make(map[string]int64),
+	}
+
+	for _, tx := range s.TransactionsBetween(from, to) {
+		k := key{status: tx.Status, currency: tx.Currency}
+		total, ok := totals[k]
+		if !ok {
+			total = &payment.ReconciliationTotal{Status: tx.Status, Currency: tx.Currency}
+			totals[k] = total
+		}
+		total.Count++
+		total.AmountCents += tx.AmountCents
+		report.TransactionCount++
+
+		if tx.Status == payment.PaymentStatus_PAYMENT_STATUS_COMPLETED {
+			report.SettledCentsByCurrency[tx.Currency] += tx.AmountCents
+		}
+	}
+
+	for _, total := range totals {
+		report.Totals = append(report.Totals, *total)
+	}
+	sort.Slice(report.Totals, func(i, j int) bool {
+		if report.Totals[i].Currency != report.Totals[j].Currency {
+			return report.Totals[i].Currency < report.Totals[j].Currency
+		}
+		return report.Totals[i].Status < report.Totals[j].Status
+	})
+
+	return report, nil
+}
+
+var exportHeader = []string{"transaction_id", "order_id", "amount_cents", "currency", "status", "gateway", "created_at", "updated_at"}
+
+// ExportTransactions writes transactions created in [from, to) to w as CSV
+// or newline-delimited JSON.
+func (s *PaymentService) ExportTransactions(ctx context.Context, from, to time.Time, format string, w io.Writer) error {
+	if format == "" {
+		format = ExportFormatCSV
+	}
+	if format != ExportFormatCSV && format != ExportFormatJSON {
+		return ErrUnsupportedFormat
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return ErrInvalidDateRange
+	}
+
+	txs := s.TransactionsBetween(from, to)
+
+	if format == ExportFormatJSON {
+		enc := json.NewEncoder(w)
+		for _, tx := range txs {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := enc.Encode(exportRecord(tx)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(exportHeader); err != nil {
+		return err
+	}
+	for _, tx := range txs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := cw.Write([]string{
+			tx.TransactionID,
+			tx.OrderID,
+			strconv.FormatInt(tx.AmountCents, 10),
+			tx.Currency,
+			tx.Status.String(),
+			tx.Gateway,
+			tx.CreatedAt.Format(time.RFC3339),
+			tx.UpdatedAt.Format(time.RFC3339),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func exportRecord(tx *payment.PaymentStatusResponse) map[string]interface{} {
+	return map[string]interface{}{
+		"transaction_id": tx.TransactionID,
+		"order_id":       tx.OrderID,
+		"amount_cents":   tx.AmountCents,
+		"currency":       tx.Currency,
+		"status":         tx.Status.String(),
+		"gateway":        tx.Gateway,
+		"created_at":     tx.CreatedAt,
+		"updated_at":     tx.UpdatedAt,
+	}
+}
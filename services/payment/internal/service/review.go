@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+)
+
+func (s *PaymentService) holdForReviewLocked(tx *payment.PaymentStatusResponse, req *payment.PaymentRequest, fraud FraudResult) {
+	s.reviewQueue[tx.TransactionID] = &payment.HeldPayment{
+		TransactionID: tx.TransactionID,
+		OrderID:       req.OrderID,
+		AmountCents:   req.AmountCents,
+		Currency:      req.Currency,
+		CustomerEmail: req.CustomerEmail,
+		FraudScore:    fraud.Score,
+		Reasons:       fraud.Reasons,
+		HeldAt:        s.now(),
+	}
+}
+
+func (s *PaymentService) ListHeldPayments(ctx context.Context) []payment.HeldPayment {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	held := make([]payment.HeldPayment, 0, len(s.reviewQueue))
+	for _, h := range s.reviewQueue {
+		held = append(held, *h)
+	}
+
+	sort.Slice(held, func(i, j int) bool {
+		return held[i].HeldAt.Before(held[j].HeldAt)
+	})
+
+	return held
+}
+
+// ReviewPayment charges an approved held payment through the gateways or
+// fails a denied one.
+func (s *PaymentService) ReviewPayment(ctx context.Context, req *payment.ReviewPaymentRequest) (*payment.PaymentStatusResponse, error) {
+	s.mu.RLock()
+	held, ok := s.reviewQueue[req.TransactionID]
+	var charge ChargeRequest
+	if ok {
+		charge = ChargeRequest{
+			OrderID:       held.OrderID,
+			AmountCents:   held.AmountCents,
+			Currency:      held.Currency,
+			CustomerEmail: held.CustomerEmail,
+		}
+	}
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrNotHeldForReview
+	}
+
+	to := payment.PaymentStatus_PAYMENT_STATUS_FAILED
+	reason := "fraud review denied by " + reviewerName(req.Reviewer)
+	var gateway string
+
+	if req.Approve {
+		result, gw, err := s.gateways.Charge(ctx, charge)
+		switch {
+		case err != nil:
+			reason = "approved by " + reviewerName(req.Reviewer) + " but gateways unavailable"
+		case !result.Approved:
+			reason = "approved by " + reviewerName(req.Reviewer) + " but declined by " + gw + ": " + result.ErrorMessage
+		default:
+			to = payment.PaymentStatus_PAYMENT_STATUS_COMPLETED
+			reason = "fraud review approved by " + reviewerName(req.Reviewer) + ", captured by " + gw
+			gateway = gw
+		}
+	}
+	if req.Note != "" {
+		reason += ": " + req.Note
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.reviewQueue[req.TransactionID]; !ok {
+		return nil, ErrNotHeldForReview
+	}
+
+	tx, ok := s.transactions[req.TransactionID]
+	if !ok {
+		return nil, ErrTransactionNotFound
+	}
+
+	if err := s.transitionLocked(ctx, tx, to, reason); err != nil {
+		return nil, err
+	}
+	if gateway != "" {
+		tx.Gateway = gateway
+	}
+	delete(s.reviewQueue, req.TransactionID)
+
+	return cloneStatus(tx), nil
+}
+
+func reviewerName(reviewer string) string {
+	if reviewer == "" {
+		return "unknown reviewer"
+	}
+	return reviewer
+}
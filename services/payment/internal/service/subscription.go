@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/events"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/money"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+	"github.com/google/uuid"
+)
+
+const (
+	minSubscriptionInterval = time.Second
+	maxFailedAttempts       = 3
+)
+
+var schedulerLogger = logging.Component("scheduler")
+
+func (s *PaymentService) CreateSubscription(ctx context.Context, req *payment.CreateSubscriptionRequest) (*payment.Subscription, error) {
+	if req.AmountCents <= 0 || req.CustomerEmail == "" {
+		return nil, ErrInvalidSubscription
+	}
+	if _, err := money.ParseCurrency(req.Currency); err != nil {
+		return nil, ErrInvalidSubscription
+	}
+
+	interval := time.Duration(req.IntervalSeconds) * time.Second
+	if interval < minSubscriptionInterval {
+		return nil, ErrInvalidSubscription
+	}
+
+	now := s.now()
+	sub := &payment.Subscription{
+		SubscriptionID:  "sub_" + uuid.New().String()[:8],
+		CustomerEmail:   req.CustomerEmail,
+		AmountCents:     req.AmountCents,
+		Currency:        req.Currency,
+		IntervalSeconds: req.IntervalSeconds,
+		MaxCycles:       req.MaxCycles,
+		Reference:       req.Reference,
+		Status:          payment.SubscriptionStatus_SUBSCRIPTION_STATUS_ACTIVE,
+		NextChargeAt:    now,
+		CreatedAt:       now,
+	}
+
+	s.mu.Lock()
+	s.subscriptions[sub.SubscriptionID] = sub
+	s.mu.Unlock()
+
+	schedulerLogger.InfoContext(ctx, "subscription created",
+		"subscription_id", sub.SubscriptionID,
+		"amount_cents", sub.AmountCents,
+		"currency", sub.Currency,
+		"interval", interval.String())
+
+	return cloneSubscription(sub), nil
+}
+
+func (s *PaymentService) CancelSubscription(ctx context.Context, subscriptionID string) (*payment.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subscriptions[subscriptionID]
+	if !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+	if sub.Status != payment.SubscriptionStatus_SUBSCRIPTION_STATUS_ACTIVE {
+		return nil, ErrSubscriptionNotActive
+	}
+
+	sub.Status = payment.SubscriptionStatus_SUBSCRIPTION_STATUS_CANCELLED
+	schedulerLogger.InfoContext(ctx, "subscription cancelled", "subscription_id", subscriptionID)
+
+	return cloneSubscription(sub), nil
+}
+
+// StartScheduler charges due subscriptions every tick until ctx is cancelled.
+func (s *PaymentService) StartScheduler(ctx context.Context, tick time.Duration) {
+	ctx = WithActor(ctx, "scheduler")
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.chargeDueSubscriptions(ctx, s.now())
+		}
+	}
+}
+
+type dueCharge struct {
+	subscriptionID string
+	cycle          int32
+	attempt        int32
+	request        *payment.PaymentRequest
+}
+
+func (s *PaymentService) chargeDueSubscriptions(ctx context.Context, now time.Time) {
+	var due []dueCharge
+
+	s.mu.RLock()
+	for _, sub := range s.subscriptions {
+		if sub.Status != payment.SubscriptionStatus_SUBSCRIPTION_STATUS_ACTIVE || now.Before(sub.NextChargeAt) {
+			continue
+		}
+
+		cycle := sub.CyclesCharged + 1
+		attempt := sub.FailedAttempts + 1
+
+		// The key is stable per cycle and attempt, so a charge that is
+		// picked up twice is only processed once.
+		key := fmt.Sprintf("%s_cycle_%d_attempt_%d", sub.SubscriptionID, cycle, attempt)
+
+		due = append(due, dueCharge{
+			subscriptionID: sub.SubscriptionID,
+			cycle:          cycle,
+			attempt:        attempt,
+			request: &payment.PaymentRequest{
+				IdempotencyKey: key,
+				OrderID:        fmt.Sprintf("%s_cycle_%d", sub.SubscriptionID, cycle),
+				AmountCents:    sub.AmountCents,
+				Currency:       sub.Currency,
+				CustomerEmail:  sub.CustomerEmail,
+			},
+		})
+	}
+	s.mu.RUnlock()
+
+	for _, charge := range due {
+		resp, err := s.ProcessPayment(ctx, charge.request)
+		if err != nil {
+			schedulerLogger.ErrorContext(ctx, "subscription charge failed", "subscription_id", charge.subscriptionID, logging.Err(err))
+			continue
+		}
+
+		s.recordSubscriptionCharge(charge, resp)
+		s.publishPaymentEvent(charge, resp)
+	}
+}
+
+func (s *PaymentService) recordSubscriptionCharge(charge dueCharge, resp *payment.PaymentResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subscriptions[charge.subscriptionID]
+	if !ok || sub.Status != payment.SubscriptionStatus_SUBSCRIPTION_STATUS_ACTIVE {
+		return
+	}
+
+	interval := time.Duration(sub.IntervalSeconds) * time.Second
+
+	if !resp.Success {
+		sub.FailedAttempts++
+		schedulerLogger.Warn("subscription charge declined",
+			"subscription_id", sub.SubscriptionID,
+			"cycle", charge.cycle,
+			"attempt", charge.attempt,
+			"error_code", resp.ErrorCode.String())
+
+		if sub.FailedAttempts >= maxFailedAttempts {
+			sub.Status = payment.SubscriptionStatus_SUBSCRIPTION_STATUS_CANCELLED
+			schedulerLogger.Warn("subscription cancelled after failed attempts",
+				"subscription_id", sub.SubscriptionID,
+				"failed_attempts", sub.FailedAttempts)
+			return
+		}
+
+		sub.NextChargeAt = s.now().Add(min(interval, time.Minute))
+		return
+	}
+
+	sub.CyclesCharged = charge.cycle
+	sub.FailedAttempts = 0
+	sub.LastTransactionID = resp.TransactionID
+	sub.NextChargeAt = sub.NextChargeAt.Add(interval)
+
+	schedulerLogger.Info("subscription charged",
+		"subscription_id", sub.SubscriptionID,
+		"cycle", charge.cycle,
+		"transaction_id", resp.TransactionID)
+
+	if sub.MaxCycles > 0 && sub.CyclesCharged >= sub.MaxCycles {
+		sub.Status = payment.SubscriptionStatus_SUBSCRIPTION_STATUS_COMPLETED
+		schedulerLogger.Info("subscription completed", "subscription_id", sub.SubscriptionID)
+	}
+}
+
+func (s *PaymentService) publishPaymentEvent(charge dueCharge, resp *payment.PaymentResponse) {
+	if s.broker == nil {
+		return
+	}
+
+	var (
+		msg *broker.Message
+		err error
+	)
+	if resp.Success {
+		event := events.NewPaymentCompletedV1(charge.request, resp)
+		event.SubscriptionID = charge.subscriptionID
+		event.Cycle = charge.cycle
+		msg, err = events.NewMessage(&event)
+	} else {
+		event := payment.NewPaymentFailedEvent(charge.request, resp)
+		event.SubscriptionID = charge.subscriptionID
+		event.Cycle = charge.cycle
+		msg, err = broker.NewMessage(event.EventType, event)
+	}
+	if err != nil {
+		return
+	}
+	msg.SetMetadata("subscription_id", charge.subscriptionID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s.broker.Publish(ctx, s.topicName, msg)
+}
+
+func cloneSubscription(sub *payment.Subscription) *payment.Subscription {
+	return &payment.Subscription{
+		SubscriptionID:    sub.SubscriptionID,
+		CustomerEmail:     sub.CustomerEmail,
+		AmountCents:       sub.AmountCents,
+		Currency:          sub.Currency,
+		IntervalSeconds:   sub.IntervalSeconds,
+		MaxCycles:         sub.MaxCycles,
+		Reference:         sub.Reference,
+		Status:            sub.Status,
+		CyclesCharged:     sub.CyclesCharged,
+		FailedAttempts:    sub.FailedAttempts,
+		LastTransactionID: sub.LastTransactionID,
+		NextChargeAt:      sub.NextChargeAt,
+		CreatedAt:         sub.CreatedAt,
+	}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+)
+
+// ParseTenantLimits parses a comma separated "tenant:max_amount_cents" list
+// such as "acme:50000,globex:2000000".
+func ParseTenantLimits(s string) (map[string]int64, error) {
+	limits := make(map[string]int64)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, amount, ok := strings.Cut(part, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("%w: tenant limit %q is not tenant:max_amount_cents", ErrInvalidConfig, part)
+		}
+		n, err := strconv.ParseInt(amount, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: tenant limit %q: bad amount", ErrInvalidConfig, part)
+		}
+		limits[id] = n
+	}
+	return limits, nil
+}
+
+// maxAmountFor returns the largest payment the tenant may make.
+func (c PaymentConfig) maxAmountFor(tenantID string) int64 {
+	if limit, ok := c.TenantLimits[tenantID]; ok {
+		return limit
+	}
+	return c.MaxAmountCents
+}
+
+// tenantKey scopes key, such as an idempotency key or a customer email, to
+// the tenant of ctx, so equal keys of two tenants never collide.
+func tenantKey(ctx context.Context, key string) string {
+	return tenant.FromContext(ctx) + "/" + key
+}
+
+// transactionLocked returns the transaction with the given ID if it
+// belongs to the tenant of ctx. s.mu must be held.
+func (s *PaymentService) transactionLocked(ctx context.Context, id string) (*payment.PaymentStatusResponse, bool) {
+	tx, ok := s.transactions[id]
+	if !ok || tx.TenantID != tenant.FromContext(ctx) {
+		return nil, false
+	}
+	return tx, true
+}
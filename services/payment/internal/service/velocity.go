@@ -0,0 +1,217 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VelocityLimit caps the payments a single customer may make within a
+// sliding window. Zero maximums are not enforced.
+type VelocityLimit struct {
+	Window         time.Duration `yaml:"window"`
+	MaxCount       int           `yaml:"max_count"`
+	MaxAmountCents int64         `yaml:"max_amount_cents"`
+}
+
+func (l VelocityLimit) String() string {
+	return fmt.Sprintf("%v:%d:%d", l.Window, l.MaxCount, l.MaxAmountCents)
+}
+
+// ParseVelocityLimits parses a comma separated "window:max_count:max_amount_cents"
+// list such as "1h:10:500000,24h:30:0".
+func ParseVelocityLimits(s string) ([]VelocityLimit, error) {
+	var limits []VelocityLimit
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.Split(part, ":")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%w: velocity limit %q is not window:max_count:max_amount_cents", ErrInvalidConfig, part)
+		}
+		window, err := time.ParseDuration(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: velocity limit %q: %v", ErrInvalidConfig, part, err)
+		}
+		count, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: velocity limit %q: bad count", ErrInvalidConfig, part)
+		}
+		amount, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: velocity limit %q: bad amount", ErrInvalidConfig, part)
+		}
+		limits = append(limits, VelocityLimit{Window: window, MaxCount: count, MaxAmountCents: amount})
+	}
+	return limits, nil
+}
+
+type velocityEntry struct {
+	at          time.Time
+	amountCents int64
+}
+
+// velocityTracker remembers recent payment attempts per customer for as
+// long as the longest window that needs them.
+type velocityTracker struct {
+	mu        sync.Mutex
+	retention time.Duration
+	entries   map[string][]velocityEntry
+}
+
+func newVelocityTracker(retention time.Duration) *velocityTracker {
+	return &velocityTracker{
+		retention: retention,
+		entries:   make(map[string][]velocityEntry),
+	}
+}
+
+// velocityRetention is the longest window among the fraud window and the limits.
+func velocityRetention(config PaymentConfig) time.Duration {
+	retention := config.VelocityWindow
+	for _, l := range config.VelocityLimits {
+		if l.Window > retention {
+			retention = l.Window
+		}
+	}
+	return retention
+}
+
+func (v *velocityTracker) SetRetention(d time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.retention = d
+}
+
+// Totals returns the number and sum of attempts within window before now.
+func (v *velocityTracker) Totals(customer string, window time.Duration, now time.Time) (int, int64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return totalsOf(v.pruneLocked(customer, now), window, now)
+}
+
+// totalsOf returns the number and sum of entries within window before now.
+func totalsOf(entries []velocityEntry, window time.Duration, now time.Time) (int, int64) {
+	cutoff := now.Add(-window)
+	var (
+		count  int
+		amount int64
+	)
+	for _, e := range entries {
+		if !e.at.Before(cutoff) {
+			count++
+			amount += e.amountCents
+		}
+	}
+	return count, amount
+}
+
+// Reserve records a payment of amountCents by customer unless it would
+// break one of limits, checking and recording under one lock so payments
+// made at once cannot all pass a limit only one of them fits. It returns
+// a func taking the attempt back, for a payment abandoned before it is
+// made. Otherwise it returns a description of the first limit broken and
+// how long until enough attempts leave its window for the payment to
+// fit, 0 for a payment larger than the limit on its own.
+func (v *velocityTracker) Reserve(customer string, amountCents int64, limits []VelocityLimit, now time.Time) (string, time.Duration, func()) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	entries := v.pruneLocked(customer, now)
+	for _, l := range limits {
+		count, amount := totalsOf(entries, l.Window, now)
+		if l.MaxCount > 0 && count+1 > l.MaxCount {
+			return fmt.Sprintf("more than %d payments in %v", l.MaxCount, l.Window), waitFor(entries, l, now, func(dropped int, _ int64) bool {
+				return count-dropped+1 <= l.MaxCount
+			}), nil
+		}
+		if l.MaxAmountCents > 0 && amount+amountCents > l.MaxAmountCents {
+			var wait time.Duration
+			if amountCents <= l.MaxAmountCents {
+				wait = waitFor(entries, l, now, func(_ int, dropped int64) bool {
+					return amount-dropped+amountCents <= l.MaxAmountCents
+				})
+			}
+			return fmt.Sprintf("more than %d cents in %v", l.MaxAmountCents, l.Window), wait, nil
+		}
+	}
+
+	entry := velocityEntry{at: now, amountCents: amountCents}
+	v.entries[customer] = append(entries, entry)
+	return "", 0, func() { v.release(customer, entry) }
+}
+
+// release removes an attempt recorded by Reserve. Attempts of the same
+// time and amount are alike, so any one of them goes.
+func (v *velocityTracker) release(customer string, entry velocityEntry) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	entries := v.entries[customer]
+	if i := slices.Index(entries, entry); i >= 0 {
+		entries = slices.Delete(entries, i, i+1)
+	}
+	if len(entries) == 0 {
+		delete(v.entries, customer)
+		return
+	}
+	v.entries[customer] = entries
+}
+
+// waitFor returns how long until fits accepts the entries that left the
+// window of l by then, counted and summed oldest first.
+func waitFor(entries []velocityEntry, l VelocityLimit, now time.Time, fits func(count int, amountCents int64) bool) time.Duration {
+	cutoff := now.Add(-l.Window)
+	var (
+		count  int
+		amount int64
+	)
+	for _, e := range entries {
+		if e.at.Before(cutoff) {
+			continue
+		}
+		count++
+		amount += e.amountCents
+		if fits(count, amount) {
+			return e.at.Add(l.Window).Sub(now)
+		}
+	}
+	return l.Window
+}
+
+func (v *velocityTracker) pruneLocked(customer string, now time.Time) []velocityEntry {
+	entries := v.entries[customer]
+	cutoff := now.Add(-v.retention)
+
+	i := 0
+	for i < len(entries) && entries[i].at.Before(cutoff) {
+		i++
+	}
+	entries = entries[i:]
+
+	if len(entries) == 0 {
+		delete(v.entries, customer)
+	}
+	return entries
+}
+
+// bypassesVelocity reports whether the customer is exempt from velocity
+// limits. Entries are full emails or "@domain".
+func bypassesVelocity(bypass []string, email string) bool {
+	for _, b := range bypass {
+		if strings.HasPrefix(b, "@") {
+			if strings.EqualFold(b[1:], emailDomain(email)) {
+				return true
+			}
+		} else if strings.EqualFold(b, email) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+)
+
+// allowAll is a FraudChecker that lets every payment through after delay,
+// as a remote checker would.
+type allowAll struct {
+	delay time.Duration
+}
+
+func (a allowAll) Check(context.Context, FraudInput) FraudResult {
+	time.Sleep(a.delay)
+	return FraudResult{Decision: FraudAllow}
+}
+
+// slowGateway routes every charge to a gateway approving it after latency.
+func slowGateway(latency time.Duration) *GatewayRouter {
+	g := NewSimulatedGateway(SimulatedGatewayConfig{Name: "slow", Latency: latency, Prefix: "tx_"})
+	return NewGatewayRouter(g.Name(), []Gateway{g}, nil)
+}
+
+func TestVelocityLimitHoldsUnderConcurrentPayments(t *testing.T) {
+	const (
+		payments = 50
+		maxCount = 5
+	)
+	config := DefaultPaymentConfig()
+	config.VelocityLimits = []VelocityLimit{{Window: time.Hour, MaxCount: maxCount}}
+	// Payments spend time between the limit check and the charge, for
+	// the others to check the limit meanwhile.
+	s := NewPaymentService(config, WithFraudChecker(allowAll{delay: 5 * time.Millisecond}), WithGatewayRouter(slowGateway(10*time.Millisecond)))
+
+	var (
+		succeeded atomic.Int64
+		limited   atomic.Int64
+		wg        sync.WaitGroup
+		start     = make(chan struct{})
+	)
+	for i := range payments {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			resp, err := s.ProcessPayment(context.Background(), &payment.PaymentRequest{
+				IdempotencyKey: fmt.Sprintf("key-%d", i),
+				OrderID:        fmt.Sprintf("ord-%d", i),
+				AmountCents:    1000,
+				Currency:       "BRL",
+				CustomerEmail:  "ana@example.com",
+			})
+			switch {
+			case err != nil:
+				t.Error(err)
+			case resp.Success:
+				succeeded.Add(1)
+			case resp.ErrorCode == payment.PaymentErrorCode_PAYMENT_ERROR_CODE_LIMIT_EXCEEDED:
+				limited.Add(1)
+			default:
+				t.Errorf("payment %d declined with %s: %s", i, resp.ErrorCode, resp.ErrorMessage)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if n := succeeded.Load(); n != maxCount {
+		t.Fatalf("%d of %d concurrent payments succeeded, want %d", n, payments, maxCount)
+	}
+	if n := limited.Load(); n != payments-maxCount {
+		t.Fatalf("%d payments hit the velocity limit, want %d", n, payments-maxCount)
+	}
+}
+
+func TestVelocityReservationReleasedWhenAbandoned(t *testing.T) {
+	config := DefaultPaymentConfig()
+	config.VelocityLimits = []VelocityLimit{{Window: time.Hour, MaxCount: 1}}
+	s := NewPaymentService(config, WithFraudChecker(allowAll{}), WithGatewayRouter(slowGateway(100*time.Millisecond)))
+	request := func(i int) *payment.PaymentRequest {
+		return &payment.PaymentRequest{
+			IdempotencyKey: fmt.Sprintf("key-%d", i),
+			OrderID:        fmt.Sprintf("ord-%d", i),
+			AmountCents:    1000,
+			Currency:       "BRL",
+			CustomerEmail:  "ana@example.com",
+		}
+	}
+
+	// Abandoned while the gateway is charging it.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.ProcessPayment(ctx, request(1)); err == nil {
+		t.Fatal("ProcessPayment succeeded past its deadline")
+	}
+
+	resp, err := s.ProcessPayment(context.Background(), request(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Success {
+		t.Fatalf("payment after the abandoned one declined with %s: %s", resp.ErrorCode, resp.ErrorMessage)
+	}
+}
@@ -0,0 +1,109 @@
+// Package paymenttest runs the payment service in process for end-to-end
+// tests. Charges are deterministic: one simulated acquirer without latency
+// or random failures declines amounts ending in 99 cents and approves the
+// rest.
+package paymenttest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/reqmeta"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/tenant"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/testkit"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/payment"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/payment/internal/server"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/payment/internal/service"
+	"google.golang.org/grpc"
+)
+
+// EventsTopic receives the subscription and dispute events of the service.
+const EventsTopic = "payment.events"
+
+// Options configures Start. The zero value is usable.
+type Options struct {
+	// Clock stamps transactions; nil uses time.Now.
+	Clock func() time.Time
+
+	// Broker receives the events of the service on EventsTopic, which is
+	// created when missing.
+	Broker *broker.Broker
+
+	// MaxAmountCents replaces the default limit of a single payment.
+	MaxAmountCents int64
+}
+
+// Payment is a payment service reached over an in-memory connection.
+type Payment struct {
+	Client payment.PaymentServiceClient
+	Conn   *grpc.ClientConn
+
+	// Faults fail or delay RPCs before they reach the service.
+	Faults *testkit.Faults
+
+	gateway *service.SimulatedGateway
+}
+
+// Start runs a payment service until tb ends.
+func Start(tb testing.TB, opts Options) *Payment {
+	tb.Helper()
+
+	config := service.DefaultPaymentConfig()
+	config.Latency = service.LatencyProfile{}
+	if opts.MaxAmountCents > 0 {
+		config.MaxAmountCents = opts.MaxAmountCents
+	}
+
+	gateway := service.NewSimulatedGateway(service.SimulatedGatewayConfig{Name: "acquirer-test", Prefix: "tx_"})
+	svcOpts := []service.Option{
+		service.WithGatewayRouter(service.NewGatewayRouter(gateway.Name(), []service.Gateway{gateway}, nil)),
+	}
+	if opts.Clock != nil {
+		svcOpts = append(svcOpts, service.WithClock(opts.Clock))
+	}
+	if opts.Broker != nil {
+		if _, ok := opts.Broker.GetTopic(EventsTopic); !ok {
+			opts.Broker.CreateTopic(EventsTopic)
+		}
+		svcOpts = append(svcOpts, service.WithEventBroker(opts.Broker, EventsTopic))
+	}
+
+	faults := testkit.NewFaults()
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			reqmeta.UnaryServerInterceptor(),
+			grpcmw.UnaryRecoveryInterceptor(),
+			tenant.UnaryServerInterceptor(),
+			faults.UnaryServerInterceptor(),
+		),
+		grpc.ChainStreamInterceptor(
+			reqmeta.StreamServerInterceptor(),
+			grpcmw.StreamRecoveryInterceptor(),
+			tenant.StreamServerInterceptor(),
+			faults.StreamServerInterceptor(),
+		),
+	)
+	payment.RegisterPaymentServiceServer(srv, server.NewPaymentServer(service.NewPaymentService(config, svcOpts...)))
+
+	conn := testkit.ServeGRPC(tb, srv, grpc.WithChainUnaryInterceptor(
+		reqmeta.UnaryClientInterceptor(),
+	))
+	return &Payment{
+		Client:  payment.NewPaymentServiceClient(conn),
+		Conn:    conn,
+		Faults:  faults,
+		gateway: gateway,
+	}
+}
+
+// SetGatewayDown makes the acquirer unavailable, or available again. While
+// it is down every charge is declined with PAYMENT_ERROR_CODE_PROCESSING_ERROR.
+func (p *Payment) SetGatewayDown(down bool) {
+	rate := 0.0
+	if down {
+		rate = 1
+	}
+	p.gateway.SetFailureRate(rate)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/config"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/shipping/internal/service"
+)
+
+// Config holds the startup settings of the shipping service.
+type Config struct {
+	Port       int                   `config:"port" usage:"gRPC server port"`
+	HTTP       config.HTTPServer     `config:"http"`
+	OrderURL   string                `config:"order-url" usage:"Order Service base URL"`
+	OrderToken string                `config:"order-token,secret" usage:"Admin API key or JWT for the Order Service"`
+	Carriers   service.CarrierConfig `config:",inline"`
+
+	// EventDedupWindow drops order events the order stream sends more
+	// than once, as it does for a redelivered message, so a shipment is not created twice.
+	EventDedupWindow time.Duration `config:"event-dedup-window" usage:"How long forwarded order event IDs are remembered to drop repeated events, 0 disables"`
+
+	Broker broker.BrokerConfig `config:"broker"`
+	Log    logging.Config      `config:"log"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		Port:     50053,
+		HTTP:     config.DefaultHTTPServer(8082),
+		OrderURL: "http://localhost:8080",
+		Carriers: service.DefaultCarrierConfig(),
+		Broker:   broker.DefaultBrokerConfig(),
+		Log:      logging.DefaultConfig(),
+
+		EventDedupWindow: 10 * time.Minute,
+	}
+}
+
+func (c Config) Validate() error {
+	if err := config.CheckPort("port", c.Port); err != nil {
+		return err
+	}
+	if c.OrderURL == "" {
+		return errors.New("order-url is required")
+	}
+	if c.EventDedupWindow < 0 {
+		return errors.New("event-dedup-window must not be negative")
+	}
+	return nil
+}
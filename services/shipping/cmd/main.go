@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	_ "github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/codec"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/config"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/reqmeta"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/sse"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/shipping"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/shipping/internal/handler"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/shipping/internal/orders"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/shipping/internal/server"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/shipping/internal/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// orderStatusFor maps shipment statuses to the order status they imply.
+var orderStatusFor = map[shipping.ShipmentStatus]string{
+	shipping.ShipmentStatus_SHIPMENT_STATUS_LABEL_CREATED: "processing",
+	shipping.ShipmentStatus_SHIPMENT_STATUS_IN_TRANSIT:    "shipped",
+	shipping.ShipmentStatus_SHIPMENT_STATUS_DELIVERED:     "delivered",
+}
+
+func main() {
+	cfg := defaultConfig()
+	loader := config.Register(flag.CommandLine, "shipping", &cfg)
+	flag.Parse()
+	if err := loader.Load(); err != nil {
+		logging.Fatal("invalid configuration", logging.Err(err))
+	}
+	carrierCfg := cfg.Carriers
+
+	if err := logging.Setup("shipping", cfg.Log); err != nil {
+		logging.Fatal("invalid logging configuration", logging.Err(err))
+	}
+	slog.Info("starting shipping service", "port", cfg.Port, "http_port", cfg.HTTP.Port)
+
+	msgBroker := broker.NewBroker(cfg.Broker)
+	msgBroker.CreateTopic("order.created", broker.WithDedupWindow(cfg.EventDedupWindow))
+	msgBroker.CreateTopic("order.status_changed", broker.WithDedupWindow(cfg.EventDedupWindow))
+	msgBroker.CreateTopic(service.UpdatesTopic)
+
+	shipmentQueue := msgBroker.CreateQueue("shipments", broker.WithMaxRetries(3))
+	orderStatusQueue := msgBroker.CreateQueue("order-status", broker.WithMaxRetries(5))
+
+	msgBroker.Subscribe("order.created", "shipments")
+	msgBroker.Subscribe("order.status_changed", "shipments")
+	msgBroker.Subscribe(service.UpdatesTopic, "order-status")
+	slog.Info("message broker configured")
+
+	shippingSvc := service.NewShippingService(msgBroker, carrierCfg)
+	defer shippingSvc.Stop()
+	slog.Info("carriers configured",
+		"carriers", strings.Join(carrierCfg.Carriers, ","),
+		"pickup_delay", carrierCfg.PickupDelay.String(),
+		"delivery_delay", carrierCfg.DeliveryDelay.String())
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	orderClient := orders.NewClient(cfg.OrderURL, cfg.OrderToken)
+	go broker.NewWorker("shipment-worker", shipmentQueue, shippingSvc.HandleOrderEvent).Start(ctx)
+	go broker.NewWorker("order-status-worker", orderStatusQueue, orderStatusHandler(orderClient)).Start(ctx)
+	go orderClient.Follow(ctx, func(e sse.Event) {
+		forwardOrderEvent(ctx, msgBroker, e)
+	})
+	slog.Info("following order events", "url", cfg.OrderURL)
+
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		reqmeta.UnaryServerInterceptor(),
+		grpcmw.UnaryLoggingInterceptor(),
+		grpcmw.UnaryRecoveryInterceptor(),
+	))
+	shipping.RegisterShippingServiceServer(grpcServer, server.NewShippingServer(shippingSvc))
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	healthServer.SetServingStatus("shipping.ShippingService", healthpb.HealthCheckResponse_SERVING)
+
+	reflection.Register(grpcServer)
+
+	mux := http.NewServeMux()
+	handler.NewShippingHandler(shippingSvc).RegisterRoutes(mux)
+	httpServer := cfg.HTTP.Server(logging.Middleware(mux))
+	go func() {
+		if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+			logging.Fatal("HTTP server error", logging.Err(err))
+		}
+	}()
+
+	addr := fmt.Sprintf(":%d", cfg.Port)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		logging.Fatal("failed to listen", "addr", addr, logging.Err(err))
+	}
+
+	go func() {
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+		<-sigChan
+		slog.Info("shutting down")
+		stop()
+		healthServer.Shutdown()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+		grpcServer.GracefulStop()
+	}()
+
+	slog.Info("shipping service ready", "addr", addr)
+	slog.Info("endpoints: GET /shipments, GET /shipments/{id}, GET /health")
+
+	if err := grpcServer.Serve(listener); err != nil {
+		logging.Fatal("failed to serve", logging.Err(err))
+	}
+}
+
+// forwardOrderEvent publishes an event read from the order stream on the
+// local topic of the same name.
+func forwardOrderEvent(ctx context.Context, b *broker.Broker, e sse.Event) {
+	if _, ok := b.GetTopic(e.Type); !ok {
+		return
+	}
+	msg := &broker.Message{
+		ID:        e.ID,
+		Type:      e.Type,
+		Payload:   e.Data,
+		Metadata:  map[string]string{broker.ContentTypeMetadata: broker.ContentTypeJSON},
+		Timestamp: time.Now(),
+	}
+	if err := b.Publish(ctx, e.Type, msg); err != nil {
+		slog.ErrorContext(ctx, "failed to forward order event", "event_type", e.Type, "event_id", e.ID, logging.Err(err))
+	}
+}
+
+// orderStatusHandler moves orders along as their shipments progress.
+// Rejected transitions, such as an order cancelled in the meantime, are
+// logged and acknowledged; other failures, including an order that changed
+// between reading and updating it, are retried by the queue.
+func orderStatusHandler(client *orders.Client) broker.MessageHandler {
+	return func(msg *broker.Message) error {
+		var event shipping.ShipmentUpdatedEvent
+		if err := msg.Decode(&event); err != nil {
+			return err
+		}
+
+		status, ok := orderStatusFor[event.Status]
+		if !ok {
+			return nil
+		}
+
+		ctx, cancel := context.WithTimeout(msg.Context(context.Background()), 10*time.Second)
+		defer cancel()
+		ctx = logging.WithAttrs(ctx, "order_id", event.OrderID, "shipment_id", event.ShipmentID)
+
+		reason := fmt.Sprintf("%s (%s %s)", event.Description, event.Carrier, event.TrackingNumber)
+		err := client.UpdateStatus(ctx, event.OrderID, status, reason)
+		switch {
+		case err == nil:
+			slog.InfoContext(ctx, "order status updated", "status", status)
+			return nil
+		case errors.Is(err, orders.ErrTransitionRejected), errors.Is(err, orders.ErrOrderNotFound):
+			slog.WarnContext(ctx, "order status not updated", "status", status, logging.Err(err))
+			return nil
+		case errors.Is(err, orders.ErrVersionConflict):
+			slog.WarnContext(ctx, "order changed while updating its status, retrying", "status", status, logging.Err(err))
+			return err
+		default:
+			slog.ErrorContext(ctx, "failed to update order status", "status", status, logging.Err(err))
+			return err
+		}
+	}
+}
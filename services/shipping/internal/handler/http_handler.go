@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/shipping"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/shipping/internal/service"
+)
+
+// ShippingHandler serves read-only shipment lookups over HTTP.
+type ShippingHandler struct {
+	svc *service.ShippingService
+}
+
+func NewShippingHandler(svc *service.ShippingService) *ShippingHandler {
+	return &ShippingHandler{svc: svc}
+}
+
+func (h *ShippingHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /shipments", h.listShipments)
+	mux.HandleFunc("GET /shipments/{id}", h.getShipment)
+	mux.HandleFunc("/health", h.handleHealth)
+}
+
+// listShipments serves GET /shipments. With order_id it returns that
+// order's shipment; otherwise the status and customer_id filters apply.
+func (h *ShippingHandler) listShipments(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	if orderID := q.Get("order_id"); orderID != "" {
+		sh, err := h.svc.GetShipment(r.Context(), &shipping.GetShipmentRequest{OrderID: orderID})
+		if err != nil {
+			respondShipmentError(w, r, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, sh)
+		return
+	}
+
+	req := &shipping.ListShipmentsRequest{CustomerID: q.Get("customer_id")}
+	if s := q.Get("status"); s != "" {
+		status, ok := parseShipmentStatus(s)
+		if !ok {
+			respondError(w, http.StatusBadRequest, "Unknown status "+s)
+			return
+		}
+		req.Status = status
+	}
+
+	resp, err := h.svc.ListShipments(r.Context(), req)
+	if err != nil {
+		respondShipmentError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"shipments": resp.Shipments,
+		"count":     len(resp.Shipments),
+	})
+}
+
+func (h *ShippingHandler) getShipment(w http.ResponseWriter, r *http.Request) {
+	sh, err := h.svc.GetShipment(r.Context(), &shipping.GetShipmentRequest{ShipmentID: r.PathValue("id")})
+	if err != nil {
+		respondShipmentError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, sh)
+}
+
+func (h *ShippingHandler) handleHealth(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, map[string]string{
+		"status":  "healthy",
+		"service": "shipping-service",
+	})
+}
+
+// parseShipmentStatus accepts names such as "in_transit" or "IN_TRANSIT".
+func parseShipmentStatus(s string) (shipping.ShipmentStatus, bool) {
+	s = strings.ToUpper(s)
+	for status := shipping.ShipmentStatus_SHIPMENT_STATUS_LABEL_CREATED; status <= shipping.ShipmentStatus_SHIPMENT_STATUS_CANCELLED; status++ {
+		if status.String() == s {
+			return status, true
+		}
+	}
+	return shipping.ShipmentStatus_SHIPMENT_STATUS_UNSPECIFIED, false
+}
+
+func respondShipmentError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrShipmentNotFound):
+		respondError(w, http.StatusNotFound, "Shipment not found")
+	default:
+		slog.ErrorContext(r.Context(), "shipment lookup failed", logging.Err(err))
+		respondError(w, http.StatusInternalServerError, "Internal error")
+	}
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,155 @@
+// Package orders talks to the Order Service HTTP API: it follows the order
+// event stream and moves orders along as their shipments progress.
+package orders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/auth"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/sse"
+)
+
+var (
+	// ErrOrderNotFound is returned when the order service does not know the order
+	ErrOrderNotFound = errors.New("order not found")
+
+	// ErrTransitionRejected is returned when the order cannot move to the requested status
+	ErrTransitionRejected = errors.New("order status change rejected")
+
+	// ErrVersionConflict is returned when the order changed between reading
+	// it and updating it; a retry reads the new version
+	ErrVersionConflict = errors.New("order changed concurrently")
+)
+
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+	events  *sse.Stream
+}
+
+// NewClient creates a client for the order service at baseURL. A non-empty
+// token is sent as a bearer token; it needs the admin role to see the
+// events of every customer.
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: 10 * time.Second, Transport: &logging.Transport{}},
+		events:  sse.NewStream(strings.TrimRight(baseURL, "/")+"/orders/events", token),
+	}
+}
+
+// UpdateStatus reads the ETag of the order with GET /orders/{id} and calls
+// PATCH /orders/{id}/status with it in If-Match, so the change only applies
+// to the order as read.
+func (c *Client) UpdateStatus(ctx context.Context, orderID, status, reason string) error {
+	path := "/orders/" + url.PathEscape(orderID)
+	etag, err := c.etag(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]string{"status": status, "reason": reason})
+	if err != nil {
+		return err
+	}
+	req, err := c.newRequest(ctx, http.MethodPatch, path+"/status", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", etag)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return ErrOrderNotFound
+	case http.StatusConflict:
+		// Version conflicts carry the current version of the order.
+		var conflict struct {
+			Error   string `json:"error"`
+			Version int64  `json:"version"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&conflict)
+		if conflict.Version != 0 {
+			return fmt.Errorf("%w: %s", ErrVersionConflict, conflict.Error)
+		}
+		return fmt.Errorf("%w: %s", ErrTransitionRejected, conflict.Error)
+	default:
+		return fmt.Errorf("PATCH %s/status: %s: %s", path, resp.Status, errorMessage(resp.Body))
+	}
+}
+
+// etag returns the ETag of GET path.
+func (c *Client) etag(ctx context.Context, path string) (string, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", ErrOrderNotFound
+	default:
+		return "", fmt.Errorf("GET %s: %s: %s", path, resp.Status, errorMessage(resp.Body))
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return "", fmt.Errorf("GET %s: response has no ETag", path)
+	}
+	io.Copy(io.Discard, resp.Body)
+	return etag, nil
+}
+
+// Follow reads GET /orders/events until ctx is done, reconnecting whenever
+// the stream ends.
+func (c *Client) Follow(ctx context.Context, handle func(sse.Event)) {
+	c.events.Follow(ctx, handle)
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set(auth.AuthorizationHeader, "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+// errorMessage extracts the "error" field of a JSON error response.
+func errorMessage(body io.Reader) string {
+	var e struct {
+		Error string `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(body, 4096))
+	if json.Unmarshal(data, &e) == nil && e.Error != "" {
+		return e.Error
+	}
+	return strings.TrimSpace(string(data))
+}
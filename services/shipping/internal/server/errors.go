@@ -0,0 +1,31 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/grpcmw"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/shipping/internal/service"
+	"google.golang.org/grpc/codes"
+)
+
+// ErrorDomain is the google.rpc.ErrorInfo domain of shipping errors.
+const ErrorDomain = "shipping.ShippingService"
+
+// ReasonShipmentNotFound is reported in google.rpc.ErrorInfo for unknown shipments.
+const ReasonShipmentNotFound = "SHIPMENT_NOT_FOUND"
+
+// toStatus maps service errors to gRPC status errors with machine-readable
+// details. Unknown errors are logged and reported as codes.Internal with
+// the fallback message.
+func toStatus(err error, fallback string) error {
+	switch {
+	case errors.Is(err, service.ErrShipmentNotFound):
+		return grpcmw.ErrorInfo(codes.NotFound, err.Error(), ReasonShipmentNotFound, ErrorDomain, nil)
+	case errors.Is(err, service.ErrMissingLookup):
+		return grpcmw.BadRequest(err.Error(), grpcmw.FieldViolation{Field: "shipment_id", Description: "or order_id is required"})
+	default:
+		logger.Error(fallback, logging.Err(err))
+		return grpcmw.ErrorInfo(codes.Internal, fallback, grpcmw.ReasonInternal, ErrorDomain, nil)
+	}
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"context"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/shipping"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/services/shipping/internal/service"
+)
+
+var logger = logging.Component("grpc")
+
+type ShippingServer struct {
+	shipping.UnimplementedShippingServiceServer
+	svc *service.ShippingService
+}
+
+func NewShippingServer(svc *service.ShippingService) *ShippingServer {
+	return &ShippingServer{svc: svc}
+}
+
+func (s *ShippingServer) GetShipment(ctx context.Context, req *shipping.GetShipmentRequest) (*shipping.Shipment, error) {
+	logger.DebugContext(ctx, "GetShipment", "shipment_id", req.ShipmentID, "order_id", req.OrderID)
+
+	sh, err := s.svc.GetShipment(ctx, req)
+	if err != nil {
+		return nil, toStatus(err, "failed to get shipment")
+	}
+	return sh, nil
+}
+
+func (s *ShippingServer) ListShipments(ctx context.Context, req *shipping.ListShipmentsRequest) (*shipping.ListShipmentsResponse, error) {
+	logger.DebugContext(ctx, "ListShipments", "status", req.Status.String(), "customer_id", req.CustomerID)
+
+	resp, err := s.svc.ListShipments(ctx, req)
+	if err != nil {
+		return nil, toStatus(err, "failed to list shipments")
+	}
+	return resp, nil
+}
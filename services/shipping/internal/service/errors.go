@@ -0,0 +1,11 @@
+package service
+
+import "errors"
+
+var (
+	// ErrShipmentNotFound is returned when a shipment doesn't exist
+	ErrShipmentNotFound = errors.New("shipment not found")
+
+	// ErrMissingLookup is returned when neither a shipment ID nor an order ID is given
+	ErrMissingLookup = errors.New("shipment_id or order_id is required")
+)
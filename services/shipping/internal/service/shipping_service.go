@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/broker"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/events"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/pkg/logging"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/order"
+	"github.com/Tiago-De-Liz/go-microservices-grpc-messaging/proto/shipping"
+	"github.com/google/uuid"
+)
+
+var logger = logging.Component("shipping")
+
+// UpdatesTopic receives a ShipmentUpdatedEvent for every status change.
+const UpdatesTopic = shipping.EventTypeShipmentUpdated
+
+// CarrierConfig controls the simulated carriers.
+type CarrierConfig struct {
+	// Carriers are assigned to new shipments at random.
+	Carriers []string `config:"carriers" usage:"Comma separated carrier names assigned to shipments"`
+
+	// PickupDelay is the time from label creation to the carrier's pickup
+	// scan; DeliveryDelay the time from pickup to delivery.
+	PickupDelay   time.Duration `config:"pickup-delay" usage:"Time from label creation to carrier pickup"`
+	DeliveryDelay time.Duration `config:"delivery-delay" usage:"Time from pickup to delivery"`
+}
+
+func DefaultCarrierConfig() CarrierConfig {
+	return CarrierConfig{
+		Carriers:      []string{"Correios", "DHL", "FedEx"},
+		PickupDelay:   10 * time.Second,
+		DeliveryDelay: 30 * time.Second,
+	}
+}
+
+func (c CarrierConfig) Validate() error {
+	if len(c.Carriers) == 0 {
+		return errors.New("at least one carrier is required")
+	}
+	if c.PickupDelay < 0 || c.DeliveryDelay < 0 {
+		return errors.New("carrier delays must not be negative")
+	}
+	return nil
+}
+
+// ShippingService creates a shipment for every paid order and moves it
+// through the carrier's scans on timers, publishing each change.
+type ShippingService struct {
+	broker broker.Publisher
+	config CarrierConfig
+
+	mu        sync.Mutex
+	shipments map[string]*shipping.Shipment
+	byOrder   map[string]string
+	timers    map[string]*time.Timer
+}
+
+func NewShippingService(b broker.Publisher, config CarrierConfig) *ShippingService {
+	if len(config.Carriers) == 0 {
+		config.Carriers = DefaultCarrierConfig().Carriers
+	}
+	return &ShippingService{
+		broker:    b,
+		config:    config,
+		shipments: make(map[string]*shipping.Shipment),
+		byOrder:   make(map[string]string),
+		timers:    make(map[string]*time.Timer),
+	}
+}
+
+// HandleOrderEvent is a broker.MessageHandler for order events. Paid orders
+// from order.created get a shipment; order.status_changed to CANCELLED
+// cancels a shipment the carrier has not picked up yet. Other message types
+// are acknowledged and ignored.
+func (s *ShippingService) HandleOrderEvent(msg *broker.Message) error {
+	switch msg.Type {
+	case events.TypeOrderCreated:
+		var created events.OrderCreatedV1
+		if err := events.Decode(msg, &created); err != nil {
+			return err
+		}
+		if created.Order.Status != order.OrderStatus_ORDER_STATUS_PAID {
+			return nil
+		}
+		s.CreateShipment(created.Order)
+	case "order.status_changed":
+		var changed order.OrderStatusChangedEvent
+		if err := msg.Decode(&changed); err != nil {
+			return err
+		}
+		if changed.To == order.OrderStatus_ORDER_STATUS_CANCELLED {
+			s.CancelShipment(changed.OrderID, changed.Reason)
+		}
+	}
+	return nil
+}
+
+// CreateShipment creates the shipment of a paid order. An order that
+// already has a shipment gets the existing one back, so redelivered events
+// are harmless.
+func (s *ShippingService) CreateShipment(o order.Order) *shipping.Shipment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id, ok := s.byOrder[o.ID]; ok {
+		return cloneShipment(s.shipments[id])
+	}
+
+	now := time.Now()
+	carrier := s.config.Carriers[rand.N(len(s.config.Carriers))]
+	sh := &shipping.Shipment{
+		ShipmentID:     "shp_" + uuid.New().String()[:8],
+		OrderID:        o.ID,
+		CustomerID:     o.CustomerID,
+		Carrier:        carrier,
+		TrackingNumber: trackingNumber(carrier),
+		CreatedAt:      now,
+	}
+	s.shipments[sh.ShipmentID] = sh
+	s.byOrder[o.ID] = sh.ShipmentID
+
+	logger.Info("shipment created",
+		"shipment_id", sh.ShipmentID,
+		"order_id", o.ID,
+		"carrier", sh.Carrier,
+		"tracking_number", sh.TrackingNumber)
+	s.advanceLocked(sh, shipping.ShipmentStatus_SHIPMENT_STATUS_LABEL_CREATED, "Shipping label created", now)
+	s.scheduleLocked(sh.ShipmentID, s.config.PickupDelay,
+		shipping.ShipmentStatus_SHIPMENT_STATUS_IN_TRANSIT, "Picked up by "+carrier)
+
+	return cloneShipment(sh)
+}
+
+// CancelShipment cancels the shipment of an order unless the carrier has
+// already picked it up.
+func (s *ShippingService) CancelShipment(orderID, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, ok := s.byOrder[orderID]
+	if !ok {
+		return
+	}
+	sh := s.shipments[id]
+	if sh.Status != shipping.ShipmentStatus_SHIPMENT_STATUS_LABEL_CREATED {
+		logger.Warn("order cancelled after shipment left the warehouse",
+			"order_id", orderID,
+			"shipment_id", id,
+			"status", sh.Status.String())
+		return
+	}
+
+	if t, ok := s.timers[id]; ok {
+		t.Stop()
+		delete(s.timers, id)
+	}
+	if reason == "" {
+		reason = "order cancelled"
+	}
+	s.advanceLocked(sh, shipping.ShipmentStatus_SHIPMENT_STATUS_CANCELLED, "Cancelled: "+reason, time.Now())
+}
+
+// scheduleLocked moves the shipment to next after delay, unless it was
+// cancelled in the meantime.
+func (s *ShippingService) scheduleLocked(id string, delay time.Duration, next shipping.ShipmentStatus, description string) {
+	s.timers[id] = time.AfterFunc(delay, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		delete(s.timers, id)
+		sh := s.shipments[id]
+		if sh.Status == shipping.ShipmentStatus_SHIPMENT_STATUS_CANCELLED {
+			return
+		}
+		s.advanceLocked(sh, next, description, time.Now())
+
+		if next == shipping.ShipmentStatus_SHIPMENT_STATUS_IN_TRANSIT {
+			s.scheduleLocked(id, s.config.DeliveryDelay,
+				shipping.ShipmentStatus_SHIPMENT_STATUS_DELIVERED, "Delivered")
+		}
+	})
+}
+
+func (s *ShippingService) advanceLocked(sh *shipping.Shipment, status shipping.ShipmentStatus, description string, now time.Time) {
+	sh.Status = status
+	sh.UpdatedAt = now
+	sh.Checkpoints = append(sh.Checkpoints, &shipping.ShipmentCheckpoint{
+		Status:      status,
+		Description: description,
+		At:          now,
+	})
+	logger.Info("shipment status changed",
+		"shipment_id", sh.ShipmentID,
+		"order_id", sh.OrderID,
+		"status", status.String())
+
+	go s.publishUpdated(shipping.NewShipmentUpdatedEvent(sh))
+}
+
+func (s *ShippingService) publishUpdated(event shipping.ShipmentUpdatedEvent) {
+	msg, err := broker.NewMessage(event.EventType, event)
+	if err != nil {
+		return
+	}
+
+	msg.SetMetadata("order_id", event.OrderID)
+	msg.SetMetadata("shipment_id", event.ShipmentID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.broker.Publish(ctx, UpdatesTopic, msg); err != nil {
+		logger.Error("failed to publish shipment event",
+			"event_type", event.EventType,
+			"shipment_id", event.ShipmentID,
+			logging.Err(err))
+	}
+}
+
+// GetShipment looks up a shipment by ID, or by order ID if no ID is given.
+func (s *ShippingService) GetShipment(ctx context.Context, req *shipping.GetShipmentRequest) (*shipping.Shipment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := req.GetShipmentID()
+	if id == "" {
+		if req.GetOrderID() == "" {
+			return nil, ErrMissingLookup
+		}
+		id = s.byOrder[req.OrderID]
+	}
+
+	sh, ok := s.shipments[id]
+	if !ok {
+		return nil, ErrShipmentNotFound
+	}
+	return cloneShipment(sh), nil
+}
+
+// ListShipments returns the matching shipments, newest first.
+func (s *ShippingService) ListShipments(ctx context.Context, req *shipping.ListShipmentsRequest) (*shipping.ListShipmentsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := &shipping.ListShipmentsResponse{Shipments: []*shipping.Shipment{}}
+	for _, sh := range s.shipments {
+		if req.GetStatus() != shipping.ShipmentStatus_SHIPMENT_STATUS_UNSPECIFIED && sh.Status != req.Status {
+			continue
+		}
+		if req.GetCustomerID() != "" && sh.CustomerID != req.CustomerID {
+			continue
+		}
+		resp.Shipments = append(resp.Shipments, cloneShipment(sh))
+	}
+	sort.Slice(resp.Shipments, func(i, j int) bool {
+		return resp.Shipments[i].CreatedAt.After(resp.Shipments[j].CreatedAt)
+	})
+	return resp, nil
+}
+
+// Stop cancels the pending carrier scans.
+func (s *ShippingService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, t := range s.timers {
+		t.Stop()
+		delete(s.timers, id)
+	}
+}
+
+// trackingNumber returns a carrier-style tracking number: two letters from
+// the carrier name, nine digits and a country code.
+func trackingNumber(carrier string) string {
+	prefix := strings.ToUpper(carrier)
+	if len(prefix) > 2 {
+		prefix = prefix[:2]
+	}
+	return fmt.Sprintf("%s%09dBR", prefix, rand.N(1_000_000_000))
+}
+
+func cloneShipment(sh *shipping.Shipment) *shipping.Shipment {
+	return &shipping.Shipment{
+		ShipmentID:     sh.ShipmentID,
+		OrderID:        sh.OrderID,
+		CustomerID:     sh.CustomerID,
+		Carrier:        sh.Carrier,
+		TrackingNumber: sh.TrackingNumber,
+		Status:         sh.Status,
+		Checkpoints:    append([]*shipping.ShipmentCheckpoint(nil), sh.Checkpoints...),
+		CreatedAt:      sh.CreatedAt,
+		UpdatedAt:      sh.UpdatedAt,
+	}
+}